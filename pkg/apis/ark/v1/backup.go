@@ -21,11 +21,15 @@ import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 // BackupSpec defines the specification for an Ark backup.
 type BackupSpec struct {
 	// IncludedNamespaces is a slice of namespace names to include objects
-	// from. If empty, all namespaces are included.
+	// from. If empty, all namespaces are included. Entries may also be glob
+	// patterns, e.g. pr-*, or regular expressions between slashes, e.g.
+	// /^pr-[0-9]+$/, which are matched against the cluster's namespaces
+	// when the backup runs.
 	IncludedNamespaces []string `json:"includedNamespaces"`
 
 	// ExcludedNamespaces contains a list of namespaces that are not
-	// included in the backup.
+	// included in the backup. Like IncludedNamespaces, entries may be
+	// patterns.
 	ExcludedNamespaces []string `json:"excludedNamespaces"`
 
 	// IncludedResources is a slice of resource names to include
@@ -46,6 +50,19 @@ type BackupSpec struct {
 	// in the Backup.
 	SnapshotVolumes *bool `json:"snapshotVolumes"`
 
+	// VolumeSnapshotSelector, if specified, limits volume snapshots to the
+	// PersistentVolumes that match it, or whose bound PersistentVolumeClaims
+	// do. Volumes that don't match are still backed up, but aren't
+	// snapshotted. Optional.
+	VolumeSnapshotSelector *metav1.LabelSelector `json:"volumeSnapshotSelector,omitempty"`
+
+	// VolumeGroupLabel, if specified, is the key of a label on
+	// PersistentVolumeClaims whose volumes should be snapshotted together,
+	// as a consistency group, by cloud providers that support them. Claims
+	// in the same namespace with the same value for the label are in the
+	// same group. Optional.
+	VolumeGroupLabel string `json:"volumeGroupLabel,omitempty"`
+
 	// TTL is a time.Duration-parseable string describing how long
 	// the Backup should be retained for.
 	TTL metav1.Duration `json:"ttl"`
@@ -56,6 +73,93 @@ type BackupSpec struct {
 
 	// Hooks represent custom behaviors that should be executed at different phases of the backup.
 	Hooks BackupHooks `json:"hooks"`
+
+	// ConsistentListing specifies whether every resource should be listed at a
+	// single resourceVersion captured at the start of the backup, so that all
+	// objects reflect the same logical point in time where the API server
+	// supports it.
+	ConsistentListing bool `json:"consistentListing"`
+
+	// IncludeTerminatingNamespaces specifies whether namespaces that are being
+	// deleted (i.e. whose phase is Terminating) should be included in the backup.
+	// By default they are skipped, since their contents are partially deleted.
+	IncludeTerminatingNamespaces bool `json:"includeTerminatingNamespaces"`
+
+	// IncludeServiceAccountTokens specifies whether service account token
+	// secrets created automatically by Kubernetes should be included in the
+	// backup. By default they are skipped, since Kubernetes creates them again
+	// and restoring them causes conflicts.
+	IncludeServiceAccountTokens bool `json:"includeServiceAccountTokens"`
+
+	// ExcludedSecretAnnotations excludes Secrets that have any of these
+	// annotations from the backup, e.g. Secrets materialized by an operator
+	// that creates them again. An empty value matches any value of the
+	// annotation. Optional.
+	ExcludedSecretAnnotations map[string]string `json:"excludedSecretAnnotations,omitempty"`
+
+	// ExcludedSecretOwnerKinds excludes Secrets that have an owner reference
+	// to an object of any of these kinds, such as ExternalSecret, from the
+	// backup. Kinds are matched like ExcludedOwnerKinds. Optional.
+	ExcludedSecretOwnerKinds []string `json:"excludedSecretOwnerKinds,omitempty"`
+
+	// ExcludedOwnerKinds excludes items of any resource that have an owner
+	// reference to an object of any of these kinds from the backup, e.g. the
+	// Pods and other children of an operator's custom resources, which the
+	// operator creates again once the custom resource is restored. Each kind
+	// is either a kind, such as EtcdCluster, or a kind qualified by its API
+	// group, such as EtcdCluster.etcd.database.coreos.com. Optional.
+	ExcludedOwnerKinds []string `json:"excludedOwnerKinds,omitempty"`
+
+	// MinItems is the minimum number of items the backup must contain. If
+	// fewer items are backed up, the backup is marked Skipped and only its
+	// metadata and log are uploaded to object storage. Zero means no minimum.
+	MinItems int `json:"minItems,omitempty"`
+
+	// ModifiedSince, if non-zero, limits the backup to items modified within
+	// this long before the backup was created. An item's modification time is
+	// the latest time in its metadata.managedFields; items without them are
+	// always included, since when they were last modified can't be
+	// determined. Namespaces are always included.
+	ModifiedSince metav1.Duration `json:"modifiedSince,omitempty"`
+
+	// IncludeOwnerReferences specifies whether the owners of each backed-up
+	// item, as listed in its metadata.ownerReferences, are backed up too,
+	// along with their own owners, even if they don't match the label
+	// selector. This keeps e.g. a Pod's ReplicaSet and Deployment with it,
+	// so it isn't restored as an orphan. Owners are still subject to the
+	// namespace and resource includes/excludes.
+	IncludeOwnerReferences bool `json:"includeOwnerReferences,omitempty"`
+
+	// RequireIncludeAnnotation specifies whether only items annotated with
+	// backup.ark.heptio.com/include=true are backed up, in addition to the
+	// namespace, resource and label selector filters. Namespaces, and items
+	// backed up along with an annotated item, such as its volumes or owners,
+	// are always included.
+	RequireIncludeAnnotation bool `json:"requireIncludeAnnotation,omitempty"`
+
+	// ExcludedFields maps resources, such as pods or deployments.apps, to the
+	// fields removed from their items before they're written to the backup,
+	// e.g. large fields that are regenerated when the item is restored. Each
+	// field is a JSON pointer, such as /spec/nodeName. The fields listed for
+	// "*" are removed from items of every resource. Fields that an item
+	// doesn't have are ignored.
+	ExcludedFields map[string][]string `json:"excludedFields,omitempty"`
+
+	// CompressionFormat is the format the backup tarball is compressed
+	// with. If empty, it's gzip.
+	CompressionFormat string `json:"compressionFormat,omitempty"`
+
+	// BaseBackup is the name of a completed backup in the same namespace
+	// that this backup is incremental to. It must either be annotated with
+	// ark.heptio.com/incremental-base=true, or itself have a base backup.
+	// Optional.
+	BaseBackup string `json:"baseBackup,omitempty"`
+
+	// ResourceRequestTimeout is how long each request to list or get a
+	// resource's items can take before it's abandoned. A resource whose
+	// request times out is skipped, with a warning, rather than holding up
+	// the rest of the backup. If zero, the server's default is used.
+	ResourceRequestTimeout metav1.Duration `json:"resourceRequestTimeout,omitempty"`
 }
 
 // BackupHooks contains custom behaviors that should be executed at different phases of the backup.
@@ -89,6 +193,13 @@ type BackupResourceHookSpec struct {
 	// PostHooks is a list of BackupResourceHooks to execute after storing the item in the backup.
 	// These are executed after all "additional items" from item actions are processed.
 	PostHooks []BackupResourceHook `json:"post,omitempty"`
+	// PreSnapshotHooks is a list of BackupResourceHooks to execute on each running pod that uses a
+	// PersistentVolume immediately before the volume is snapshotted, e.g. to freeze its filesystem.
+	PreSnapshotHooks []BackupResourceHook `json:"preSnapshot,omitempty"`
+	// PostSnapshotHooks is a list of BackupResourceHooks to execute on each running pod that uses a
+	// PersistentVolume right after the volume is snapshotted, e.g. to unfreeze its filesystem. They
+	// are executed even if the pre-snapshot hooks or the snapshot fail.
+	PostSnapshotHooks []BackupResourceHook `json:"postSnapshot,omitempty"`
 }
 
 // BackupResourceHook defines a hook for a resource.
@@ -104,6 +215,13 @@ type ExecHook struct {
 	Container string `json:"container"`
 	// Command is the command and arguments to execute.
 	Command []string `json:"command"`
+	// Commands is a list of commands and their arguments to execute in order in the same
+	// container, after Command if it's also specified. By default, the remaining commands
+	// aren't executed once one fails.
+	Commands [][]string `json:"commands,omitempty"`
+	// ContinueOnCommandError is whether the remaining commands should still be executed after
+	// one fails. The hook is still considered failed, and OnError still applies.
+	ContinueOnCommandError bool `json:"continueOnCommandError,omitempty"`
 	// OnError specifies how Ark should behave if it encounters an error executing this hook.
 	OnError HookErrorMode `json:"onError"`
 	// Timeout defines the maximum amount of time Ark should wait for the hook to complete before
@@ -143,12 +261,75 @@ const (
 	// errors.
 	BackupPhaseCompleted BackupPhase = "Completed"
 
+	// BackupPhasePartiallyFailed means the backup's contents were uploaded,
+	// but some of it couldn't be backed up, such as volumes whose snapshots
+	// weren't created. The specifics are in its status's Errors.
+	BackupPhasePartiallyFailed BackupPhase = "PartiallyFailed"
+
 	// BackupPhaseFailed means the backup ran but encountered an error that
 	// prevented it from completing successfully.
 	BackupPhaseFailed BackupPhase = "Failed"
 
+	// BackupPhaseSkipped means the backup ran but contained fewer items than
+	// its spec's MinItems, so its contents were discarded.
+	BackupPhaseSkipped BackupPhase = "Skipped"
+
 	// BackupPhaseDeleting means the backup and all its associated data are being deleted.
 	BackupPhaseDeleting BackupPhase = "Deleting"
+
+	// BackupPhasePendingDeletion means the backup has expired and is in the
+	// GC controller's recycle bin. It's deleted once the recycle bin retention
+	// elapses, unless it's recovered first.
+	BackupPhasePendingDeletion BackupPhase = "PendingDeletion"
+)
+
+// BackupConditionType is the type of a condition of a backup.
+type BackupConditionType string
+
+const (
+	// BackupConditionSnapshotsMissing means some of the volume snapshots
+	// recorded in the backup's status no longer exist in the cloud provider,
+	// so its persistent volumes can't all be restored.
+	BackupConditionSnapshotsMissing BackupConditionType = "SnapshotsMissing"
+)
+
+// ConditionStatus is the status of a condition: True, False, or Unknown.
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// BackupCondition describes an aspect of a backup's state that's discovered
+// after it has completed.
+type BackupCondition struct {
+	// Type is the type of the condition.
+	Type BackupConditionType `json:"type"`
+
+	// Status is whether the condition applies.
+	Status ConditionStatus `json:"status"`
+
+	// LastTransitionTime is when the condition's status last changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// Message is a human-readable description of the condition.
+	Message string `json:"message,omitempty"`
+}
+
+// BackupStorageCleanup is the result of deleting the objects a failed
+// backup left in object storage.
+type BackupStorageCleanup string
+
+const (
+	// BackupStorageCleanupSucceeded means all of the objects the backup
+	// wrote to object storage, other than its log, were deleted.
+	BackupStorageCleanupSucceeded BackupStorageCleanup = "Succeeded"
+
+	// BackupStorageCleanupFailed means some of the objects the backup
+	// wrote to object storage couldn't be deleted.
+	BackupStorageCleanupFailed BackupStorageCleanup = "Failed"
 )
 
 // BackupStatus captures the current status of an Ark backup.
@@ -156,12 +337,28 @@ type BackupStatus struct {
 	// Version is the backup format version.
 	Version int `json:"version"`
 
+	// ClusterID is the ID of the cluster the backup was taken from, as
+	// configured in the Ark server's Config. Empty if none was configured.
+	ClusterID string `json:"clusterID,omitempty"`
+
 	// Expiration is when this Backup is eligible for garbage-collection.
 	Expiration metav1.Time `json:"expiration"`
 
 	// Phase is the current state of the Backup.
 	Phase BackupPhase `json:"phase"`
 
+	// StartTimestamp records the time the backup started being run, when
+	// its phase changed to InProgress.
+	StartTimestamp metav1.Time `json:"startTimestamp"`
+
+	// CompletionTimestamp records the time the backup finished being run,
+	// whatever its outcome.
+	CompletionTimestamp metav1.Time `json:"completionTimestamp"`
+
+	// StageDurations is how long each stage of running the backup took,
+	// once it's finished.
+	StageDurations *BackupStageDurations `json:"stageDurations,omitempty"`
+
 	// VolumeBackups is a map of PersistentVolume names to
 	// information about the backed-up volume in the cloud
 	// provider API.
@@ -170,6 +367,155 @@ type BackupStatus struct {
 	// ValidationErrors is a slice of all validation errors (if
 	// applicable).
 	ValidationErrors []string `json:"validationErrors"`
+
+	// ListResourceVersion is the resourceVersion watermark that
+	// resources were listed at, if consistent listing was requested.
+	ListResourceVersion string `json:"listResourceVersion,omitempty"`
+
+	// ContentsSHA256 is the hex-encoded SHA-256 checksum of the
+	// backup tarball, used to verify downloaded copies of it.
+	ContentsSHA256 string `json:"contentsSHA256,omitempty"`
+
+	// CompressionFormat is the format the backup tarball was compressed
+	// with, so it can be decompressed when it's restored. If empty, it's
+	// gzip.
+	CompressionFormat string `json:"compressionFormat,omitempty"`
+
+	// Progress is the number of items found and backed up so far. It's
+	// updated periodically while the backup is in progress.
+	Progress *BackupProgress `json:"progress,omitempty"`
+
+	// ItemCounts is the number of items of each resource the backup
+	// contains, once it's completed. Empty for backups taken before it
+	// was recorded.
+	ItemCounts *BackupItemCounts `json:"itemCounts,omitempty"`
+
+	// StorageCleanup is the result of deleting the objects the backup
+	// wrote to object storage after it failed to upload them. Empty if
+	// no cleanup was needed.
+	StorageCleanup BackupStorageCleanup `json:"storageCleanup,omitempty"`
+
+	// Conditions are the backup's current conditions, such as whether its
+	// volume snapshots still exist.
+	Conditions []BackupCondition `json:"conditions,omitempty"`
+
+	// Warnings are problems that didn't fail the backup, such as failed
+	// uploads to backup storage mirrors when the storage quorum was met.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Errors are the parts of the backup that couldn't be backed up when
+	// its phase is PartiallyFailed.
+	Errors []string `json:"errors,omitempty"`
+
+	// FailureReason is why the backup's phase is Failed, when it wasn't
+	// started because of a problem with its backup storage.
+	FailureReason string `json:"failureReason,omitempty"`
+
+	// IncludedOwners are the items that were backed up because
+	// spec.includeOwnerReferences is set and they own another backed-up
+	// item, as <resource>/<namespace>/<name>, or <resource>/<name> for
+	// cluster-scoped owners.
+	IncludedOwners []string `json:"includedOwners,omitempty"`
+
+	// BackupChain is the names of the backups this backup is incremental
+	// to, in the order they're applied when restoring it: the annotated
+	// base backup first, and this backup's spec.baseBackup last. Empty if
+	// the backup has no base backup.
+	BackupChain []string `json:"backupChain,omitempty"`
+
+	// HookResults are the results of each command executed by the
+	// backup's exec hooks, in the order they were executed.
+	HookResults []HookCommandResult `json:"hookResults,omitempty"`
+
+	// RecycleBin records when and why the backup was moved to the GC
+	// controller's recycle bin, while its phase is PendingDeletion.
+	RecycleBin *BackupRecycleBinStatus `json:"recycleBin,omitempty"`
+}
+
+// BackupRecycleBinStatus describes a backup in the GC controller's recycle bin.
+type BackupRecycleBinStatus struct {
+	// Timestamp is when the backup was moved to the recycle bin.
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// PreviousPhase is the backup's phase before it was moved to the
+	// recycle bin, which it's returned to if it's recovered.
+	PreviousPhase BackupPhase `json:"previousPhase"`
+
+	// Reason is why the backup is being deleted.
+	Reason string `json:"reason,omitempty"`
+}
+
+// HookCommandResult is the result of executing one command of an exec hook.
+type HookCommandResult struct {
+	// Hook is the name of the backup spec's resource hook the command
+	// is from, or <from-annotation> if it's from the pod's annotations.
+	Hook string `json:"hook"`
+
+	// Phase is when the hook was executed: pre, post, pre-snapshot, or
+	// post-snapshot.
+	Phase string `json:"phase"`
+
+	// Pod is the pod the command was executed in, as <namespace>/<name>.
+	Pod string `json:"pod"`
+
+	// Container is the container the command was executed in.
+	Container string `json:"container,omitempty"`
+
+	// Command is the command and arguments that were executed.
+	Command []string `json:"command"`
+
+	// Succeeded is whether the command completed without error.
+	Succeeded bool `json:"succeeded"`
+
+	// Error is the error executing the command, if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+// BackupProgress describes how much of a backup has been completed.
+type BackupProgress struct {
+	// TotalItems is the number of items found to back up. While the
+	// backup is in progress, it increases as resources are listed.
+	TotalItems int `json:"totalItems"`
+
+	// ItemsBackedUp is the number of items that have been backed up.
+	ItemsBackedUp int `json:"itemsBackedUp"`
+}
+
+// BackupItemCounts is the number of items of each resource a backup contains.
+type BackupItemCounts struct {
+	// Namespaces are the number of items of each resource in each
+	// namespace with items in the backup, sorted by namespace.
+	Namespaces []NamespaceItemCounts `json:"namespaces,omitempty"`
+
+	// ClusterResources maps each cluster-scoped group-resource in the
+	// backup to its number of items.
+	ClusterResources map[string]int `json:"clusterResources,omitempty"`
+}
+
+// NamespaceItemCounts is the number of items of each resource a backup
+// contains in one namespace.
+type NamespaceItemCounts struct {
+	// Namespace is the namespace the items are in.
+	Namespace string `json:"namespace"`
+
+	// Resources maps each group-resource with items in the namespace to
+	// its number of items.
+	Resources map[string]int `json:"resources"`
+}
+
+// BackupStageDurations describes how long each stage of running a backup took.
+type BackupStageDurations struct {
+	// ItemCollection is how long listing, processing and writing the
+	// backup's items took, excluding the time taken by VolumeSnapshots.
+	ItemCollection metav1.Duration `json:"itemCollection"`
+
+	// VolumeSnapshots is the total time taken by the block store to create
+	// the backup's volume snapshots.
+	VolumeSnapshots metav1.Duration `json:"volumeSnapshots"`
+
+	// Upload is how long uploading the backup to object storage took,
+	// including its backup storage mirrors.
+	Upload metav1.Duration `json:"upload"`
 }
 
 // VolumeBackupInfo captures the required information about
@@ -180,6 +526,19 @@ type VolumeBackupInfo struct {
 	// provider API of this volume.
 	SnapshotID string `json:"snapshotID"`
 
+	// ParentSnapshotID is the ID of the earlier snapshot of the same
+	// volume, taken by Ark, that this snapshot is incremental to, for
+	// cloud providers whose snapshots are incremental. The snapshot can
+	// still be restored on its own. Empty for full snapshots.
+	ParentSnapshotID string `json:"parentSnapshotID,omitempty"`
+
+	// SnapshotGroupID is the ID of the consistency group the snapshot
+	// was taken in, together with the other volumes' snapshots with the
+	// same ID, for cloud providers that support them. Empty if the
+	// snapshot was taken on its own. It's informational only: restores
+	// create each volume from its own snapshot.
+	SnapshotGroupID string `json:"snapshotGroupID,omitempty"`
+
 	// Type is the type of the disk/volume in the cloud provider
 	// API.
 	Type string `json:"type"`
@@ -191,6 +550,36 @@ type VolumeBackupInfo struct {
 	// Iops is the optional value of provisioned IOPS for the
 	// disk/volume in the cloud provider API.
 	Iops *int64 `json:"iops,omitempty"`
+
+	// CSISnapshot identifies the snapshot, if the volume was snapshotted
+	// with the CSI snapshot API instead of the cloud provider API. Its
+	// snapshot handle is also recorded as the SnapshotID.
+	CSISnapshot *CSISnapshotInfo `json:"csiSnapshot,omitempty"`
+}
+
+// CSISnapshotInfo identifies a snapshot of a PersistentVolume taken with the
+// CSI snapshot API, i.e. by creating a VolumeSnapshot of its claim.
+type CSISnapshotInfo struct {
+	// Driver is the name of the CSI driver that took the snapshot.
+	Driver string `json:"driver"`
+
+	// SnapshotHandle is the CSI driver's ID of the snapshot.
+	SnapshotHandle string `json:"snapshotHandle"`
+
+	// VolumeSnapshotClassName is the name of the VolumeSnapshotClass the
+	// snapshot was taken with.
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+
+	// VolumeSnapshotNamespace and VolumeSnapshotName are the namespace and
+	// name of the VolumeSnapshot created to take the snapshot, in the
+	// namespace of the volume's claim.
+	VolumeSnapshotNamespace string `json:"volumeSnapshotNamespace"`
+	VolumeSnapshotName      string `json:"volumeSnapshotName"`
+
+	// VolumeSnapshotContentName is the name of the VolumeSnapshotContent
+	// bound to the VolumeSnapshot, which represents the snapshot in the
+	// cluster.
+	VolumeSnapshotContentName string `json:"volumeSnapshotContentName"`
 }
 
 // +genclient
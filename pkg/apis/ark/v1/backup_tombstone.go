@@ -0,0 +1,58 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// BackupTombstoneSpec records a backup that was deleted by garbage collection.
+type BackupTombstoneSpec struct {
+	// BackupName is the name of the deleted backup.
+	BackupName string `json:"backupName"`
+	// BackupUID is the UID of the deleted backup.
+	BackupUID string `json:"backupUID"`
+	// ScheduleName is the name of the schedule that created the deleted
+	// backup, if any.
+	ScheduleName string `json:"scheduleName,omitempty"`
+	// BackupCreationTimestamp is when the deleted backup was created.
+	BackupCreationTimestamp metav1.Time `json:"backupCreationTimestamp"`
+	// BackupExpiration is when the deleted backup expired.
+	BackupExpiration metav1.Time `json:"backupExpiration"`
+	// Reason is why the backup was deleted.
+	Reason string `json:"reason"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// BackupTombstone is an audit record of a backup that was deleted by garbage
+// collection. When the backup was deleted is recorded in its metadata's
+// creationTimestamp.
+type BackupTombstone struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec BackupTombstoneSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// BackupTombstoneList is a list of BackupTombstones.
+type BackupTombstoneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []BackupTombstone `json:"items"`
+}
@@ -16,7 +16,10 @@ limitations under the License.
 
 package v1
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -39,13 +42,42 @@ type Config struct {
 
 	// PersistentVolumeProvider is the configuration information for the cloud where
 	// the cluster is running and has PersistentVolumes to snapshot or restore. Optional.
-	PersistentVolumeProvider *CloudProviderConfig `json:"persistentVolumeProvider"`
+	PersistentVolumeProvider *PersistentVolumeProviderConfig `json:"persistentVolumeProvider"`
+
+	// CSISnapshots is whether PersistentVolumes of CSI drivers are snapshotted
+	// and restored with the CSI snapshot API, by creating VolumeSnapshots of
+	// their claims, instead of with the PersistentVolumeProvider, which isn't
+	// required for them.
+	CSISnapshots bool `json:"csiSnapshots"`
 
 	// BackupStorageProvider is the configuration information for the cloud where
 	// Ark backups are stored in object storage. This may be a different cloud than
 	// where the cluster is running.
 	BackupStorageProvider ObjectStorageProviderConfig `json:"backupStorageProvider"`
 
+	// BackupStorageMirrors are additional object storage locations that each
+	// backup is uploaded to after it's uploaded to the BackupStorageProvider's
+	// bucket, e.g. for redundancy across clouds or regions. Optional.
+	BackupStorageMirrors []ObjectStorageProviderConfig `json:"backupStorageMirrors"`
+
+	// BackupStorageQuorum is the number of buckets, counting the
+	// BackupStorageProvider's, that a backup must be uploaded to for it to
+	// be completed. Failed uploads to mirrors beyond the quorum are recorded
+	// as warnings on the backup. Zero, the default, requires every bucket.
+	BackupStorageQuorum int `json:"backupStorageQuorum"`
+
+	// BackupStream is an object storage location that the tarball of each
+	// backup is uploaded to while it's written, rather than after the backup
+	// finishes like the BackupStorageMirrors. Optional.
+	BackupStream *BackupStreamConfig `json:"backupStream,omitempty"`
+
+	// MinAvailableBackupStorage is the storage, e.g. 10Gi, that must still be
+	// available in the BackupStorageProvider's bucket for a backup to start.
+	// Backups started with less available fail. It's only checked for object
+	// stores that report their available storage, and if zero, the default,
+	// it's not checked.
+	MinAvailableBackupStorage resource.Quantity `json:"minAvailableBackupStorage"`
+
 	// BackupSyncPeriod is how often the BackupSyncController runs to ensure all
 	// Ark backups in object storage exist as Backup API objects in the cluster.
 	BackupSyncPeriod metav1.Duration `json:"backupSyncPeriod"`
@@ -58,14 +90,231 @@ type Config struct {
 	// new backups that should be triggered based on schedules.
 	ScheduleSyncPeriod metav1.Duration `json:"scheduleSyncPeriod"`
 
+	// BackupNameTemplate is a Go text/template the ScheduleController generates
+	// the names of scheduled backups with, from the fields .Schedule,
+	// .Timestamp, .Time and .ClusterID. It must generate valid names that are
+	// unique for each schedule and run. If empty, backups are named
+	// <schedule>-<timestamp>.
+	BackupNameTemplate string `json:"backupNameTemplate"`
+
 	// ResourcePriorities is an ordered slice of resources specifying the desired
 	// order of resource restores. Any resources not in the list will be restored
 	// alphabetically after the prioritized resources.
 	ResourcePriorities []string `json:"resourcePriorities"`
 
+	// BackupResourcePriorities is an ordered slice of resources specifying the
+	// order in which resources are backed up. Any resources not in the list are
+	// backed up after the prioritized resources, in the default order.
+	BackupResourcePriorities []string `json:"backupResourcePriorities"`
+
+	// DefaultExcludedResources are resources that are excluded from every
+	// backup, in addition to the backup's own excluded resources, unless the
+	// backup explicitly lists them in its included resources.
+	DefaultExcludedResources []string `json:"defaultExcludedResources"`
+
+	// RestoreNamespaceConcurrency is the maximum number of namespaces that are
+	// created at once at the start of a restore, before any items are restored
+	// into them. If zero, 10 namespaces are created at once.
+	RestoreNamespaceConcurrency int `json:"restoreNamespaceConcurrency"`
+
+	// VersionedResources are the resources whose items are revisions of
+	// something else, such as Helm releases, of which restores with
+	// spec.latestRevisionsOnly only restore the latest revision. If empty,
+	// secrets, configmaps and controllerrevisions.apps are versioned.
+	VersionedResources []string `json:"versionedResources"`
+
 	// RestoreOnlyMode is whether Ark should run in a mode where only restores
 	// are allowed; backups, schedules, and garbage-collection are all disabled.
 	RestoreOnlyMode bool `json:"restoreOnlyMode"`
+
+	// GCKeepLastScheduledBackup is whether the GCController should keep the
+	// last remaining completed backup of a schedule even after it has expired.
+	// If false, the backup is deleted and a warning is logged.
+	GCKeepLastScheduledBackup bool `json:"gcKeepLastScheduledBackup"`
+
+	// GCMinRetention is the minimum amount of time a backup is retained after
+	// it completes, or after it's created if it doesn't record when it
+	// completed, regardless of its expiration. If zero, backups are deleted
+	// as soon as they expire.
+	GCMinRetention metav1.Duration `json:"gcMinRetention"`
+
+	// GCFailedBackupTTL is how long a Failed backup is kept after it's created
+	// before it's garbage-collected, if that's sooner than its expiration. If
+	// zero, failed backups are garbage-collected when they expire.
+	GCFailedBackupTTL metav1.Duration `json:"gcFailedBackupTTL"`
+
+	// GCSnapshotsMissingBackupTTL is how long a backup is kept after its
+	// SnapshotsMissing condition becomes true before it's garbage-collected, if
+	// that's sooner than its expiration. If zero, such backups are
+	// garbage-collected when they expire.
+	GCSnapshotsMissingBackupTTL metav1.Duration `json:"gcSnapshotsMissingBackupTTL"`
+
+	// GCMaintenanceWindow, if specified, is the time of day during which the
+	// GCController deletes expired backups. Outside of it, expired backups are
+	// kept until the GCController's next sync within the window. Optional.
+	GCMaintenanceWindow *MaintenanceWindow `json:"gcMaintenanceWindow,omitempty"`
+
+	// GCMissingBackupContents is whether the GCController should check that
+	// completed backups' contents still exist in object storage, and delete
+	// backups whose contents are missing without waiting for them to expire.
+	GCMissingBackupContents bool `json:"gcMissingBackupContents"`
+
+	// GCRecycleBinRetention is how long an expired backup is kept in the
+	// GCController's recycle bin, with its phase set to PendingDeletion,
+	// before it's deleted. Backups can be recovered from the recycle bin
+	// until then. If zero, expired backups are deleted right away.
+	GCRecycleBinRetention metav1.Duration `json:"gcRecycleBinRetention"`
+
+	// GCOrphanedScheduleBackupRetention is how long after they're created
+	// backups of schedules that have since been deleted are kept before the
+	// GCController deletes them, even if they haven't expired. If zero, such
+	// backups are kept until they expire.
+	GCOrphanedScheduleBackupRetention metav1.Duration `json:"gcOrphanedScheduleBackupRetention"`
+
+	// GCRetentionClasses are named TTLs that backups labeled with
+	// RetentionClassLabel, such as those of schedules annotated with
+	// RetentionClassAnnotation, expire after, measured from when they're
+	// created, instead of at their status.expiration. Optional.
+	GCRetentionClasses map[string]metav1.Duration `json:"gcRetentionClasses,omitempty"`
+
+	// BackupTombstoneRetention is how long a BackupTombstone recording a backup
+	// that was garbage-collected is kept after the backup is deleted. If zero,
+	// no tombstones are created.
+	BackupTombstoneRetention metav1.Duration `json:"backupTombstoneRetention"`
+
+	// GCReportPeriod is how often the GCController creates a GCReport of the
+	// backups it deleted, kept or deferred deleting since the last one, and
+	// why. If zero, no reports are created.
+	GCReportPeriod metav1.Duration `json:"gcReportPeriod"`
+
+	// GCReportRetention is how long a GCReport is kept after its period ends.
+	// If zero, GCReports are kept until they're deleted.
+	GCReportRetention metav1.Duration `json:"gcReportRetention"`
+
+	// GCTransientErrorBackoff is how long the GCController waits before
+	// processing a backup again after a transient API error, such as
+	// throttling by the API server, or longer if the API server asks it to.
+	// Other errors are retried with the usual per-item rate limiting.
+	GCTransientErrorBackoff metav1.Duration `json:"gcTransientErrorBackoff"`
+
+	// ReconcileBackupExpiration is whether the BackupSyncController should update
+	// a Backup API object's expiration to match the backup's metadata in object
+	// storage when they differ. If false, differences are only logged.
+	ReconcileBackupExpiration bool `json:"reconcileBackupExpiration"`
+
+	// DeduplicateBackupContents is whether the contents of backups are stored
+	// deduplicated in object storage, with each distinct item stored once and
+	// shared by the backups that have it, rather than as a tarball per backup.
+	// Deduplicated backups can still be restored and deleted once it's
+	// disabled. It requires ClusterID, since only one cluster may write
+	// deduplicated backups to a bucket. Deleting a deduplicated backup reads
+	// the manifest of every other deduplicated backup in the bucket, so it
+	// takes O(N) requests to object storage for N backups.
+	DeduplicateBackupContents bool `json:"deduplicateBackupContents"`
+
+	// MaxConcurrentBackups is the maximum number of backups the BackupController
+	// runs at the same time. If zero, backups are run one at a time.
+	MaxConcurrentBackups int `json:"maxConcurrentBackups"`
+
+	// BackupDeletionConcurrency is the maximum number of DeleteBackupRequests
+	// the BackupDeletionController processes at the same time. If zero,
+	// requests are processed one at a time.
+	BackupDeletionConcurrency int `json:"backupDeletionConcurrency"`
+
+	// SnapshotDeletionBatchSize is the maximum number of a backup's snapshots
+	// deleted with a single call to the cloud API, if the PersistentVolumeProvider
+	// supports deleting snapshots in bulk. If zero, it defaults to 50; if one,
+	// snapshots are always deleted individually.
+	SnapshotDeletionBatchSize int `json:"snapshotDeletionBatchSize"`
+
+	// DefaultBackupTTL is how long backups whose spec doesn't set a TTL are
+	// kept before they expire. If zero, those backups never expire.
+	DefaultBackupTTL metav1.Duration `json:"defaultBackupTTL"`
+
+	// MaxBackups is the maximum number of backups to keep. When there are more,
+	// the completed backups that aren't protected and completed first are
+	// deleted, regardless of their expiration. If zero, there's no maximum.
+	MaxBackups int `json:"maxBackups"`
+
+	// BackupRetries is the number of times a failed backup is automatically
+	// retried, by creating a new backup with the same spec. If zero, failed
+	// backups aren't retried.
+	BackupRetries int `json:"backupRetries"`
+
+	// BackupRetryBackoff is how long to wait before the first retry of a failed
+	// backup. The wait doubles for each subsequent retry.
+	BackupRetryBackoff metav1.Duration `json:"backupRetryBackoff"`
+
+	// ShutdownGracePeriod is how long the server waits for in-progress backups
+	// and restores to finish when it's stopped. Backups that don't finish in time
+	// are marked as failed; restores are resumed when the server starts again.
+	ShutdownGracePeriod metav1.Duration `json:"shutdownGracePeriod"`
+
+	// StaleBackupTimeout is how long a backup can be InProgress without being
+	// run by one of the server's workers, e.g. because the server that was
+	// running it crashed, before it's marked as failed. Defaults to 1 hour.
+	StaleBackupTimeout metav1.Duration `json:"staleBackupTimeout"`
+
+	// BackupResourceRequestTimeout is how long each request a backup makes to
+	// list or get a resource's items can take, for backups whose spec doesn't
+	// set a timeout. Resources whose requests time out are skipped with a
+	// warning. If zero, requests don't time out.
+	BackupResourceRequestTimeout metav1.Duration `json:"backupResourceRequestTimeout"`
+
+	// SnapshotTags are tags applied to every volume snapshot Ark takes, in
+	// addition to the backup's labels and annotations, e.g. to identify the
+	// cluster the snapshots were taken from. Optional.
+	SnapshotTags map[string]string `json:"snapshotTags"`
+
+	// ClusterID identifies the cluster the server runs in. It's recorded in
+	// each backup, and restores of backups recorded with a different ID
+	// fail validation unless they allow it, so that backups in a bucket
+	// shared by several clusters aren't restored to the wrong one by
+	// accident. Optional.
+	ClusterID string `json:"clusterID"`
+
+	// SnapshotCheckPeriod is how often the snapshots of completed backups are
+	// checked to make sure they still exist in the cloud provider. If zero,
+	// snapshots aren't checked.
+	SnapshotCheckPeriod metav1.Duration `json:"snapshotCheckPeriod"`
+
+	// MaxConcurrentSnapshots is the maximum number of volume snapshots that
+	// are taken at the same time, across all backups, so that snapshotting
+	// many volumes doesn't saturate the storage backend. If zero, there's no
+	// maximum.
+	MaxConcurrentSnapshots int `json:"maxConcurrentSnapshots"`
+
+	// MaxConcurrentSnapshotsPerStorageClass is the maximum number of
+	// snapshots of volumes of each storage class that are taken at the same
+	// time, in addition to MaxConcurrentSnapshots. Volumes without a storage
+	// class can be limited using the empty string as their storage class.
+	// Storage classes that aren't listed have no maximum of their own.
+	MaxConcurrentSnapshotsPerStorageClass map[string]int `json:"maxConcurrentSnapshotsPerStorageClass"`
+
+	// NamespaceSchedulePolicies are the named policies that namespaces can
+	// opt in to backups with, by setting the NamespaceScheduleAnnotation to
+	// a policy's name. A schedule is generated for each annotated namespace
+	// from its policy.
+	NamespaceSchedulePolicies map[string]NamespaceSchedulePolicy `json:"namespaceSchedulePolicies"`
+
+	// NamespaceDeletionBackupTemplate, if set, is the spec of the backups
+	// taken of namespaces annotated with NamespaceBackupBeforeDeleteAnnotation
+	// when they're deleted. Its included namespaces are replaced with the
+	// deleted namespace. The server's namespace deletion webhook denies the
+	// deletion until the backup finishes. If nil, namespaces aren't backed up
+	// when they're deleted.
+	NamespaceDeletionBackupTemplate *BackupSpec `json:"namespaceDeletionBackupTemplate"`
+}
+
+// NamespaceSchedulePolicy is the schedule generated for each namespace that
+// opts in to backups with the policy.
+type NamespaceSchedulePolicy struct {
+	// Schedule is the Cron expression of the generated schedules.
+	Schedule string `json:"schedule"`
+
+	// Template is the spec of the backups the generated schedules create.
+	// Its included namespaces are replaced with the annotated namespace.
+	Template BackupSpec `json:"template"`
 }
 
 // CloudProviderConfig is configuration information about how to connect
@@ -76,8 +325,45 @@ type CloudProviderConfig struct {
 	Config map[string]string `json:"config"`
 }
 
+// PersistentVolumeProviderConfig is configuration information for the cloud
+// where PersistentVolumes are snapshotted and restored.
+type PersistentVolumeProviderConfig struct {
+	CloudProviderConfig `json:",inline"`
+
+	// SnapshotTTL is how long the provider's volume snapshots are kept after
+	// they're taken, independent of their backup's own TTL. Snapshots are
+	// tagged with when it elapses, and when a backup is deleted before then,
+	// its snapshots are left in the cloud rather than deleted, and deleted
+	// once it has elapsed. If zero, snapshots are deleted with their backup.
+	SnapshotTTL metav1.Duration `json:"snapshotTTL"`
+}
+
+// MaintenanceWindow is a range of time that recurs every day.
+type MaintenanceWindow struct {
+	// Start is the time of day the window opens, as HH:MM in 24-hour time.
+	Start string `json:"start"`
+
+	// End is the time of day the window closes, as HH:MM in 24-hour time. If
+	// it's earlier than Start, the window spans midnight.
+	End string `json:"end"`
+
+	// TimeZone is the IANA name of the time zone that Start and End are in,
+	// e.g. America/New_York. Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
 // ObjectStorageProviderConfig is configuration information for connecting to
 // a particular bucket in object storage to access Ark backups.
+// BackupStreamConfig is the configuration of the object storage location
+// backup tarballs are streamed to while they're written.
+type BackupStreamConfig struct {
+	ObjectStorageProviderConfig `json:",inline"`
+
+	// Required is whether failing to stream a backup fails it. Otherwise,
+	// the failure is recorded in the backup's warnings.
+	Required bool `json:"required"`
+}
+
 type ObjectStorageProviderConfig struct {
 	// CloudProviderConfig is the configuration information for the cloud where
 	// Ark backups are stored in object storage.
@@ -86,4 +372,10 @@ type ObjectStorageProviderConfig struct {
 	// Bucket is the name of the bucket in object storage where Ark backups
 	// are stored.
 	Bucket string `json:"bucket"`
+
+	// RetryableErrors are regular expressions matching the messages of
+	// errors from the object storage provider that are transient, such as
+	// throttling by an S3-compatible backend. Uploads, downloads and
+	// listings that fail with matching errors are retried. Optional.
+	RetryableErrors []string `json:"retryableErrors,omitempty"`
 }
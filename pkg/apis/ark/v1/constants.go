@@ -30,6 +30,139 @@ const (
 	// of restored resources. The value will be the restore's name.
 	RestoreLabelKey = "ark-restore"
 
+	// RestoredFromBackupLabel is the label key that's applied to all resources
+	// that are created during a restore, along with RestoreLabelKey. The value
+	// will be the name of the backup they were restored from.
+	RestoredFromBackupLabel = "restore.ark.heptio.com/backup-name"
+
+	// ScheduleNameLabel is the label key that's applied to all backups created
+	// by a schedule. The value will be the schedule's name.
+	ScheduleNameLabel = "ark-schedule"
+
+	// RetryOfLabel is the label key that's applied to backups created to retry
+	// a failed backup. The value will be the name of the first backup that failed.
+	RetryOfLabel = "ark.heptio.com/retry-of"
+
+	// RetryAttemptLabel is the label key that's applied to backups created to
+	// retry a failed backup. The value will be the number of the retry, starting
+	// at 1.
+	RetryAttemptLabel = "ark.heptio.com/retry-attempt"
+
+	// BackupSetLabel is the label key that's applied to backups taken together,
+	// e.g. of each of a federation's clusters, to identify the set they belong
+	// to. The value is the set's ID.
+	BackupSetLabel = "ark.heptio.com/backup-set"
+
+	// RetentionClassAnnotation is the annotation key that, set on a schedule,
+	// assigns the backups it creates to the named retention class, by labeling
+	// them with RetentionClassLabel.
+	RetentionClassAnnotation = "ark.heptio.com/retention-class"
+
+	// RetentionClassLabel is the label key that's applied to backups in a
+	// retention class. The value is the name of one of the Config's
+	// GCRetentionClasses, whose TTL the GCController expires them after.
+	RetentionClassLabel = "ark.heptio.com/retention-class"
+
+	// CSIVolumeSnapshotClassLabel is the label key that, set to "true" on a
+	// VolumeSnapshotClass, selects it as the class Ark snapshots the volumes
+	// of its CSI driver with, instead of the driver's default class.
+	CSIVolumeSnapshotClassLabel = "ark.heptio.com/csi-volumesnapshot-class"
+
+	// OriginalCreationTimestampAnnotation is the annotation key that's applied to
+	// restored resources to record when the backed-up resource was originally
+	// created, since restored resources get a new creationTimestamp. The value is
+	// an RFC 3339 timestamp.
+	OriginalCreationTimestampAnnotation = "restore.ark.heptio.com/original-created-at"
+
+	// OriginalNameAnnotation is the annotation key that's applied to restored
+	// resources that were renamed by the restore's resourceRenames, to record
+	// the name they were backed up with.
+	OriginalNameAnnotation = "restore.ark.heptio.com/original-name"
+
+	// OriginalReplicasAnnotation is the annotation key that's applied to
+	// Deployments and StatefulSets that are scaled to zero when they're restored,
+	// to record their backed-up number of replicas so they can be scaled back up.
+	OriginalReplicasAnnotation = "restore.ark.heptio.com/original-replicas"
+
+	// OriginalSuspendAnnotation is the annotation key that's applied to CronJobs
+	// that are suspended when they're restored, to record whether they were
+	// suspended when they were backed up. The value is "true" or "false".
+	OriginalSuspendAnnotation = "restore.ark.heptio.com/original-suspend"
+
+	// OriginalResourcesAnnotation is the annotation key that's applied to
+	// restored pods and workloads whose containers' resource requests and
+	// limits were scaled by spec.containerResourcesFactor. The value is a JSON
+	// object mapping each modified container's name to its backed-up resources.
+	OriginalResourcesAnnotation = "restore.ark.heptio.com/original-resources"
+
+	// OriginalImagesAnnotation is the annotation key that's applied to
+	// restored pods and workloads whose containers' images were rewritten by
+	// spec.imageRegistryMapping. The value is a JSON object mapping each
+	// modified container's name to its backed-up image.
+	OriginalImagesAnnotation = "restore.ark.heptio.com/original-images"
+
+	// AssignedStorageClassAnnotation is the annotation key that's applied to
+	// restored PersistentVolumeClaims that had no storage class when they were
+	// backed up and were assigned the restore's spec.defaultStorageClass. The
+	// value is the name of the assigned StorageClass.
+	AssignedStorageClassAnnotation = "restore.ark.heptio.com/assigned-storage-class"
+
+	// ProtectedBackupAnnotation is the annotation key that, when set to "true" on
+	// a backup, exempts it from being deleted to stay under the configured
+	// maximum number of backups.
+	ProtectedBackupAnnotation = "ark.heptio.com/protected"
+
+	// IncrementalBaseAnnotation is the annotation key that, when set to "true"
+	// on a backup, marks it as a base that other backups can be incremental to
+	// by naming it in their spec.baseBackup.
+	IncrementalBaseAnnotation = "ark.heptio.com/incremental-base"
+
+	// DeletionApprovalRequiredLabel is the label key that, when set to "true" on
+	// a backup, holds its DeleteBackupRequests in the PendingApproval phase until
+	// they're approved. Schedules with this label apply it to their backups.
+	DeletionApprovalRequiredLabel = "ark.heptio.com/deletion-approval-required"
+
+	// DeletionApprovedAnnotation is the annotation key on a backup whose value is
+	// the comma-separated UIDs of the DeleteBackupRequests approved to delete it.
+	// It's set by `ark backup approve-deletion`. Keeping approvals on the backup
+	// means a DeleteBackupRequest can't be created already approved.
+	DeletionApprovedAnnotation = "ark.heptio.com/deletion-approved-requests"
+
+	// IncludeAnnotation is the annotation key that, when set to "true" on an
+	// item, opts it in to backups with spec.requireIncludeAnnotation set.
+	IncludeAnnotation = "backup.ark.heptio.com/include"
+
+	// NamespaceScheduleAnnotation is the annotation key that, when set on a
+	// namespace to the name of one of the server's namespaceSchedulePolicies,
+	// opts the namespace in to backups by a schedule generated from the policy.
+	NamespaceScheduleAnnotation = "backup.ark.heptio.com/schedule"
+
+	// ScheduleNamespaceLabel is the label key set on schedules generated for
+	// namespaces with the NamespaceScheduleAnnotation. The value is the name of
+	// the namespace.
+	ScheduleNamespaceLabel = "backup.ark.heptio.com/namespace"
+
+	// NamespaceBackupBeforeDeleteAnnotation is the annotation key that, when set
+	// to "true" on a namespace, has the namespace backed up when it's deleted,
+	// before the deletion is allowed, if the server has a
+	// namespaceDeletionBackupTemplate.
+	NamespaceBackupBeforeDeleteAnnotation = "backup.ark.heptio.com/backup-before-delete"
+
+	// DeletedNamespaceLabel is the label key set on backups taken of namespaces
+	// with the NamespaceBackupBeforeDeleteAnnotation when they're deleted. The
+	// value is the name of the namespace.
+	DeletedNamespaceLabel = "backup.ark.heptio.com/deleted-namespace"
+
+	// DeletedNamespaceUIDAnnotation is the annotation key set on backups with the
+	// DeletedNamespaceLabel. The value is the UID of the namespace, so backups of
+	// an earlier namespace with the same name aren't mistaken for its backups.
+	DeletedNamespaceUIDAnnotation = "backup.ark.heptio.com/deleted-namespace-uid"
+
+	// SyncedFromObjectStorageAnnotation is the annotation key, set to "true", that's
+	// applied to backups the BackupSyncController creates from their metadata in
+	// object storage, e.g. in a new cluster whose schedules haven't been created yet.
+	SyncedFromObjectStorageAnnotation = "ark.heptio.com/synced-from-object-storage"
+
 	// ClusterScopedDir is the name of the directory containing cluster-scoped
 	// resources within an Ark backup.
 	ClusterScopedDir = "cluster"
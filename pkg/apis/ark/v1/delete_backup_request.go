@@ -21,6 +21,14 @@ import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 // DeleteBackupRequestSpec is the specification for which backups to delete.
 type DeleteBackupRequestSpec struct {
 	BackupName string `json:"backupName"`
+	// Reason is why the backup is being deleted. It's set on requests created
+	// by garbage collection, and recorded in the backup's BackupTombstone.
+	Reason string `json:"reason,omitempty"`
+	// TTLAfterProcessed is how long the DeleteBackupRequest is kept once it's
+	// been processed, after which it's deleted. Requests are checked for
+	// expiry hourly. If zero, the request is deleted 24 hours after it was
+	// created, once it's been processed.
+	TTLAfterProcessed metav1.Duration `json:"ttlAfterProcessed,omitempty"`
 }
 
 // DeleteBackupRequestPhase represents the lifecycle phase of a DeleteBackupRequest.
@@ -29,6 +37,9 @@ type DeleteBackupRequestPhase string
 const (
 	// DeleteBackupRequestPhaseNew means the DeleteBackupRequest has not been processed yet.
 	DeleteBackupRequestPhaseNew DeleteBackupRequestPhase = "New"
+	// DeleteBackupRequestPhasePendingApproval means the DeleteBackupRequest's backup
+	// requires approval to be deleted, and the DeleteBackupRequest is waiting for it.
+	DeleteBackupRequestPhasePendingApproval DeleteBackupRequestPhase = "PendingApproval"
 	// DeleteBackupRequestPhaseInProgress means the DeleteBackupRequest is being processed.
 	DeleteBackupRequestPhaseInProgress DeleteBackupRequestPhase = "InProgress"
 	// DeleteBackupRequestPhaseProcessed means the DeleteBackupRequest has been processed.
@@ -46,6 +57,11 @@ type DeleteBackupRequestStatus struct {
 	Phase DeleteBackupRequestPhase `json:"phase"`
 	// Errors contains any errors that were encountered during the deletion process.
 	Errors []string `json:"errors"`
+	// StartTimestamp is when processing of the DeleteBackupRequest started. When
+	// it was created is recorded in its metadata's creationTimestamp.
+	StartTimestamp metav1.Time `json:"startTimestamp"`
+	// CompletionTimestamp is when the DeleteBackupRequest was processed.
+	CompletionTimestamp metav1.Time `json:"completionTimestamp"`
 }
 
 // +genclient
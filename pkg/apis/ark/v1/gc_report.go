@@ -0,0 +1,93 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// GCReportDecision is what garbage collection decided to do with an expired
+// backup.
+type GCReportDecision string
+
+const (
+	// GCReportDecisionDeleted means a DeleteBackupRequest was created for
+	// the backup.
+	GCReportDecisionDeleted GCReportDecision = "Deleted"
+
+	// GCReportDecisionRecycled means the backup was moved to the recycle bin.
+	GCReportDecisionRecycled GCReportDecision = "Recycled"
+
+	// GCReportDecisionRetained means the backup was kept, e.g. because it's
+	// within the minimum retention period or is the last backup of its
+	// schedule.
+	GCReportDecisionRetained GCReportDecision = "Retained"
+
+	// GCReportDecisionDeferred means the backup's deletion was put off, e.g.
+	// until the maintenance window or until its deletion is approved.
+	GCReportDecisionDeferred GCReportDecision = "Deferred"
+)
+
+// GCReportEntry is a decision garbage collection made about a backup during
+// a report's period. Decisions that are made again at each sync are recorded
+// once, with when they were first and last made.
+type GCReportEntry struct {
+	// BackupName is the name of the backup.
+	BackupName string `json:"backupName"`
+	// BackupNamespace is the namespace of the backup.
+	BackupNamespace string `json:"backupNamespace"`
+	// Decision is what was decided.
+	Decision GCReportDecision `json:"decision"`
+	// Reason is why it was decided.
+	Reason string `json:"reason"`
+	// FirstTimestamp is when the decision was first made during the period.
+	FirstTimestamp metav1.Time `json:"firstTimestamp"`
+	// LastTimestamp is when the decision was last made during the period.
+	LastTimestamp metav1.Time `json:"lastTimestamp"`
+}
+
+// GCReportSpec is the decisions garbage collection made over a period.
+type GCReportSpec struct {
+	// StartTimestamp is when the period started.
+	StartTimestamp metav1.Time `json:"startTimestamp"`
+	// EndTimestamp is when the period ended. It's unset while the period is
+	// in progress, during which the report is updated with the decisions made
+	// so far at each GC sync.
+	EndTimestamp metav1.Time `json:"endTimestamp"`
+	// Entries are the decisions made during the period, in the order they
+	// were first made.
+	Entries []GCReportEntry `json:"entries"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GCReport is a periodic report of the backups garbage collection deleted,
+// kept or deferred deleting, and why.
+type GCReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec GCReportSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GCReportList is a list of GCReports.
+type GCReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []GCReport `json:"items"`
+}
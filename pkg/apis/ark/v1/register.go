@@ -45,6 +45,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&Backup{},
 		&BackupList{},
+		&BackupTombstone{},
+		&BackupTombstoneList{},
 		&Schedule{},
 		&ScheduleList{},
 		&Restore{},
@@ -55,6 +57,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&DownloadRequestList{},
 		&DeleteBackupRequest{},
 		&DeleteBackupRequestList{},
+		&GCReport{},
+		&GCReportList{},
+		&ServerStatusRequest{},
+		&ServerStatusRequestList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -16,7 +16,10 @@ limitations under the License.
 
 package v1
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
 
 // RestoreSpec defines the specification for an Ark restore.
 type RestoreSpec struct {
@@ -59,8 +62,270 @@ type RestoreSpec struct {
 	// should be included for consideration in the restore. If null, defaults
 	// to true.
 	IncludeClusterResources *bool `json:"includeClusterResources"`
+
+	// MergeStrategies is a map of resource names to the strategy to use
+	// when an item of that resource already exists in the cluster. Only
+	// configmaps and secrets are supported. Resources without an entry
+	// are not modified if they already exist.
+	MergeStrategies map[string]MergeStrategy `json:"mergeStrategies"`
+
+	// ApplyMethod specifies how restored items are written to the cluster.
+	// If empty, items are created.
+	ApplyMethod RestoreApplyMethod `json:"applyMethod"`
+
+	// ApplyConflictPolicy specifies what happens when applying an item
+	// conflicts with fields owned by another field manager, if ApplyMethod
+	// is server-side apply. If empty, conflicting items are skipped.
+	ApplyConflictPolicy ApplyConflictPolicy `json:"applyConflictPolicy,omitempty"`
+
+	// FieldManager is the field manager that restored items are applied
+	// with, if ApplyMethod is server-side apply, e.g. that of a GitOps
+	// controller that should take over managing the items' fields. If
+	// empty, items are applied with the ark-restore field manager.
+	FieldManager string `json:"fieldManager,omitempty"`
+
+	// ExistingNamespacePolicy specifies what happens to the labels and
+	// annotations of restored namespaces that already exist in the cluster.
+	// Namespaces that don't exist are always created with the labels and
+	// annotations they were backed up with. If empty, existing namespaces
+	// are left as they are.
+	ExistingNamespacePolicy ExistingNamespacePolicy `json:"existingNamespacePolicy,omitempty"`
+
+	// MissingCRDPolicy specifies what happens when the backup contains items
+	// of resources the cluster doesn't have, such as custom resources whose
+	// CustomResourceDefinition isn't installed. Those items can't be
+	// restored. If empty, they're skipped.
+	MissingCRDPolicy MissingCRDPolicy `json:"missingCRDPolicy,omitempty"`
+
+	// ResourceModifiers is a list of JSON patches to apply to restored
+	// items before they're created. Each modifier applies to the items
+	// matching its selector, in order.
+	ResourceModifiers []ResourceModifier `json:"resourceModifiers"`
+
+	// AllowClusterMismatch specifies whether the backup may be restored
+	// even though it was taken from a different cluster than the one the
+	// Ark server is configured with.
+	AllowClusterMismatch bool `json:"allowClusterMismatch,omitempty"`
+
+	// RestoreWebhooksLast specifies whether APIServices and webhook
+	// configurations are restored after all other resources, so that the
+	// workloads backing them exist before they start handling requests.
+	// If null, defaults to true.
+	RestoreWebhooksLast *bool `json:"restoreWebhooksLast,omitempty"`
+
+	// LabelRestoredItems specifies whether restored items are labeled with
+	// the names of the restore and its backup, so everything a restore
+	// created can be found. If null, defaults to true.
+	LabelRestoredItems *bool `json:"labelRestoredItems,omitempty"`
+
+	// AddedLabels are labels added to every restored item, replacing any
+	// it was backed up with that have the same keys, e.g. so that a GitOps
+	// controller recognizes the items as its own.
+	AddedLabels map[string]string `json:"addedLabels,omitempty"`
+
+	// AddedAnnotations are annotations added to every restored item,
+	// replacing any it was backed up with that have the same keys.
+	AddedAnnotations map[string]string `json:"addedAnnotations,omitempty"`
+
+	// ScaleToZero specifies whether restored Deployments and StatefulSets
+	// are scaled to zero replicas, and restored CronJobs are suspended, so
+	// that restored workloads don't run until they're scaled back up.
+	ScaleToZero bool `json:"scaleToZero,omitempty"`
+
+	// ContainerResourcesFactor, if set, is the factor by which the resource
+	// requests and limits of the containers in restored pods and pod
+	// templates are multiplied, e.g. 0.5 to halve them. It must be between
+	// 0 and 1; 0 removes requests and limits altogether.
+	ContainerResourcesFactor *float64 `json:"containerResourcesFactor,omitempty"`
+
+	// ImageRegistryMapping is a map of registry prefixes to the prefixes that
+	// replace them in the images of the containers in restored pods and pod
+	// templates, e.g. "registry.old.example.com" to "registry.new.example.com".
+	// A prefix matches an image that starts with it followed by a "/". Images
+	// that no prefix matches are restored unchanged.
+	ImageRegistryMapping map[string]string `json:"imageRegistryMapping,omitempty"`
+
+	// Verify specifies whether the items created by the restore are read
+	// back from the cluster once it's finished and compared against the
+	// versions that were restored from the backup. Fields that differ are
+	// reported as warnings; fields set by the server are ignored.
+	Verify bool `json:"verify,omitempty"`
+
+	// Prune specifies whether, once everything has been restored, items in
+	// the namespaces restored into that an earlier restore created, i.e.
+	// that have the ark-restore label, but that aren't in the backup are
+	// deleted, so the namespaces match the backup. Only items of the
+	// resources the restore includes that match its label selector and
+	// don't have a controller owner are deleted. Nothing is deleted if the
+	// restore has errors.
+	Prune bool `json:"prune,omitempty"`
+
+	// DefaultStorageClass, if set, is the name of the StorageClass that
+	// restored PersistentVolumeClaims without a storage class, which would
+	// otherwise be provisioned with the target cluster's default, are
+	// assigned. Claims that are bound to a volume are not modified.
+	DefaultStorageClass string `json:"defaultStorageClass,omitempty"`
+
+	// LatestRevisionsOnly specifies whether only the latest revision of
+	// each family of revisions in the server's configured versioned
+	// resources, such as the release secrets of a Helm release, is
+	// restored. Older revisions are skipped.
+	LatestRevisionsOnly bool `json:"latestRevisionsOnly,omitempty"`
+
+	// BatchSize is the number of items restored between checkpoints. After
+	// each batch, the restore's status.progress and status.checkpoint are
+	// updated, and a restore that's resumed after being interrupted skips
+	// the items before its checkpoint. If zero, no checkpoints are taken.
+	BatchSize int `json:"batchSize,omitempty"`
+
+	// PreserveUIDs specifies whether items are created with the UIDs they
+	// had when they were backed up. Most API servers assign new UIDs
+	// regardless, so the items that are restored with different UIDs are
+	// recorded in the restore's status.uidMappings.
+	PreserveUIDs bool `json:"preserveUIDs,omitempty"`
+
+	// SkipLiveOwnedItems specifies whether namespaced items with an owner,
+	// by their owner references, that already exists in the namespace
+	// they're restored into, and isn't being deleted, are skipped, so the
+	// owner's controller manages them rather than the restore recreating
+	// them. Each skipped item is recorded as a warning in the restore's
+	// results.
+	SkipLiveOwnedItems bool `json:"skipLiveOwnedItems,omitempty"`
+
+	// ResourceRenames is a map of items, as "<resource>/<name>", e.g.
+	// "widgets.example.com/prod-app", to the names they're restored with.
+	// Namespaced items are renamed in every namespace they're restored
+	// into. Restored pods and pod templates that mount a renamed
+	// PersistentVolumeClaim, ConfigMap, or Secret are updated to refer to
+	// its new name. Two items of the same resource can't be renamed to the
+	// same name.
+	ResourceRenames map[string]string `json:"resourceRenames,omitempty"`
+
+	// NamespaceSelector, if set, is a metav1.LabelSelector that namespaces
+	// in the backup must match, by the labels they were backed up with, for
+	// their items to be restored, in addition to IncludedNamespaces and
+	// ExcludedNamespaces. Namespaces whose Namespace object wasn't backed up
+	// have no labels. The number of namespaces skipped because they don't
+	// match is recorded in the restore's status.progress.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// ResourceModifier is a JSON patch that is applied to the restored items
+// matching its resources, namespaces, and label selector.
+type ResourceModifier struct {
+	// Resources is a slice of resource names the patch applies to. If
+	// empty, it applies to all resources.
+	Resources []string `json:"resources"`
+
+	// Namespaces is a slice of the namespaces, after any namespace
+	// mapping, that the patch applies to. If empty, it applies to all
+	// namespaces. Cluster-scoped items are not filtered by namespace.
+	Namespaces []string `json:"namespaces"`
+
+	// LabelSelector, if specified, filters the items the patch applies to.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector"`
+
+	// Patches is a list of JSON patch (RFC 6902) operations to apply.
+	Patches []JSONPatchOperation `json:"patches"`
 }
 
+// JSONPatchOperation is a single JSON patch (RFC 6902) operation.
+type JSONPatchOperation struct {
+	// Op is the operation to perform: add, remove, replace, move, copy,
+	// or test.
+	Op string `json:"op"`
+
+	// Path is the JSON pointer to the location the operation applies to.
+	Path string `json:"path"`
+
+	// From is the JSON pointer to the source location for move and copy
+	// operations.
+	From string `json:"from,omitempty"`
+
+	// Value is the JSON-encoded value for add, replace, and test
+	// operations, e.g. "3" or "\"registry.example.com/app:v1\"".
+	Value string `json:"value,omitempty"`
+}
+
+// RestoreApplyMethod is a string representation of how a restore writes
+// items to the cluster.
+type RestoreApplyMethod string
+
+const (
+	// RestoreApplyMethodCreate means items are created, and items that
+	// already exist are left unchanged.
+	RestoreApplyMethodCreate RestoreApplyMethod = "create"
+
+	// RestoreApplyMethodServerSideApply means items are written using
+	// server-side apply with a dedicated field manager, so that fields
+	// owned by other managers are merged rather than conflicting. Items
+	// of resources that don't support server-side apply are created.
+	RestoreApplyMethodServerSideApply RestoreApplyMethod = "ssa"
+)
+
+// ApplyConflictPolicy is a string representation of how a restore handles
+// server-side apply conflicts with fields owned by other field managers.
+type ApplyConflictPolicy string
+
+const (
+	// ApplyConflictPolicySkip means conflicting items aren't restored, and
+	// a warning is recorded for each of them.
+	ApplyConflictPolicySkip ApplyConflictPolicy = "Skip"
+
+	// ApplyConflictPolicyForce means conflicting items are applied again
+	// with force, taking ownership of the conflicting fields from their
+	// other field managers.
+	ApplyConflictPolicyForce ApplyConflictPolicy = "Force"
+)
+
+// MissingCRDPolicy is a string representation of how a restore handles
+// backed-up resources that the cluster doesn't have.
+type MissingCRDPolicy string
+
+const (
+	// MissingCRDPolicySkip means items of missing resources are skipped.
+	MissingCRDPolicySkip MissingCRDPolicy = "Skip"
+
+	// MissingCRDPolicyWarn means items of missing resources are skipped,
+	// and a warning is recorded for each missing resource.
+	MissingCRDPolicyWarn MissingCRDPolicy = "Warn"
+
+	// MissingCRDPolicyFail means nothing is restored if the backup
+	// contains items of missing resources, and an error is recorded for
+	// each missing resource.
+	MissingCRDPolicyFail MissingCRDPolicy = "Fail"
+)
+
+// ExistingNamespacePolicy is a string representation of how a restore
+// handles the metadata of namespaces that already exist in the cluster.
+type ExistingNamespacePolicy string
+
+const (
+	// ExistingNamespacePolicyLeave means existing namespaces are left as
+	// they are.
+	ExistingNamespacePolicyLeave ExistingNamespacePolicy = "Leave"
+
+	// ExistingNamespacePolicyMerge means the labels and annotations the
+	// namespace was backed up with are added to the existing namespace.
+	// Labels and annotations the existing namespace already has keep their
+	// values.
+	ExistingNamespacePolicyMerge ExistingNamespacePolicy = "Merge"
+)
+
+// MergeStrategy is a string representation of how a restored item's data
+// is combined with the data of an existing item of the same name.
+type MergeStrategy string
+
+const (
+	// MergeStrategyRestoredWins means the restored and existing keys are
+	// unioned, keeping the restored value for any key present in both.
+	MergeStrategyRestoredWins MergeStrategy = "MergeRestoredWins"
+
+	// MergeStrategyExistingWins means the restored and existing keys are
+	// unioned, keeping the existing value for any key present in both.
+	MergeStrategyExistingWins MergeStrategy = "MergeExistingWins"
+)
+
 // RestorePhase is a string representation of the lifecycle phase
 // of an Ark restore
 type RestorePhase string
@@ -98,6 +363,80 @@ type RestoreStatus struct {
 	// Errors is a count of all error messages that were generated during
 	// execution of the restore. The actual errors are stored in object storage.
 	Errors int `json:"errors"`
+
+	// Progress is the number of items to restore and restored so far. It's
+	// updated periodically while the restore is in progress.
+	Progress *RestoreProgress `json:"progress,omitempty"`
+
+	// RestoredVolumes maps the names of PersistentVolumes restored from snapshots
+	// to the IDs of the volumes created for them. An empty ID means the volume's
+	// creation started but didn't finish. It's updated as volumes are created, so
+	// that an interrupted restore can reuse them when it's resumed.
+	RestoredVolumes map[string]string `json:"restoredVolumes,omitempty"`
+
+	// Checkpoint is the last item of the last batch of items that the
+	// restore finished, if spec.batchSize is set.
+	Checkpoint *RestoreCheckpoint `json:"checkpoint,omitempty"`
+
+	// UIDMappings are the items that were restored with different UIDs
+	// than they were backed up with, if spec.preserveUIDs is set. If the
+	// restore was resumed after being interrupted, only the items restored
+	// since it was resumed are included.
+	UIDMappings []RestoredUIDMapping `json:"uidMappings,omitempty"`
+}
+
+// RestoredUIDMapping maps the UID an item had when it was backed up to the
+// UID it was restored with.
+type RestoredUIDMapping struct {
+	// Resource is the item's resource, e.g. deployments.apps.
+	Resource string `json:"resource"`
+
+	// Namespace is the item's namespace in the cluster, after any namespace
+	// mapping. It's empty for cluster-scoped items.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name is the item's name.
+	Name string `json:"name"`
+
+	// OriginalUID is the item's UID when it was backed up.
+	OriginalUID types.UID `json:"originalUID"`
+
+	// UID is the item's UID in the cluster it was restored to.
+	UID types.UID `json:"uid"`
+}
+
+// RestoreCheckpoint identifies an item in a backup that a restore has
+// restored, and every item before it.
+type RestoreCheckpoint struct {
+	// Resource is the item's resource, e.g. deployments.apps.
+	Resource string `json:"resource"`
+
+	// Namespace is the item's namespace in the backup, before any namespace
+	// mapping. It's empty for cluster-scoped items.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name is the item's name.
+	Name string `json:"name"`
+
+	// ItemsRestored is the number of items that had been processed when
+	// the checkpoint was taken, including this one.
+	ItemsRestored int `json:"itemsRestored"`
+}
+
+// RestoreProgress describes how much of a restore has been completed.
+type RestoreProgress struct {
+	// TotalItems is the number of items in the backup that are included
+	// by the restore's namespace and resource filters.
+	TotalItems int `json:"totalItems"`
+
+	// ItemsRestored is the number of those items that have been processed,
+	// whether they were created or skipped.
+	ItemsRestored int `json:"itemsRestored"`
+
+	// SkippedNamespaces is the number of namespaces in the backup that
+	// weren't restored because they don't match the restore's
+	// namespaceSelector.
+	SkippedNamespaces int `json:"skippedNamespaces,omitempty"`
 }
 
 // RestoreResult is a collection of messages that were generated
@@ -54,6 +54,23 @@ func (in *Backup) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupCondition) DeepCopyInto(out *BackupCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupCondition.
+func (in *BackupCondition) DeepCopy() *BackupCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupHooks) DeepCopyInto(out *BackupHooks) {
 	*out = *in
@@ -77,6 +94,36 @@ func (in *BackupHooks) DeepCopy() *BackupHooks {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupItemCounts) DeepCopyInto(out *BackupItemCounts) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]NamespaceItemCounts, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClusterResources != nil {
+		in, out := &in.ClusterResources, &out.ClusterResources
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupItemCounts.
+func (in *BackupItemCounts) DeepCopy() *BackupItemCounts {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupItemCounts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupList) DeepCopyInto(out *BackupList) {
 	*out = *in
@@ -111,6 +158,39 @@ func (in *BackupList) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupProgress) DeepCopyInto(out *BackupProgress) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupProgress.
+func (in *BackupProgress) DeepCopy() *BackupProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRecycleBinStatus) DeepCopyInto(out *BackupRecycleBinStatus) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRecycleBinStatus.
+func (in *BackupRecycleBinStatus) DeepCopy() *BackupRecycleBinStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRecycleBinStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupResourceHook) DeepCopyInto(out *BackupResourceHook) {
 	*out = *in
@@ -189,6 +269,20 @@ func (in *BackupResourceHookSpec) DeepCopyInto(out *BackupResourceHookSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PreSnapshotHooks != nil {
+		in, out := &in.PreSnapshotHooks, &out.PreSnapshotHooks
+		*out = make([]BackupResourceHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostSnapshotHooks != nil {
+		in, out := &in.PostSnapshotHooks, &out.PostSnapshotHooks
+		*out = make([]BackupResourceHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -243,6 +337,15 @@ func (in *BackupSpec) DeepCopyInto(out *BackupSpec) {
 			**out = **in
 		}
 	}
+	if in.VolumeSnapshotSelector != nil {
+		in, out := &in.VolumeSnapshotSelector, &out.VolumeSnapshotSelector
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.LabelSelector)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	out.TTL = in.TTL
 	if in.IncludeClusterResources != nil {
 		in, out := &in.IncludeClusterResources, &out.IncludeClusterResources
@@ -254,6 +357,37 @@ func (in *BackupSpec) DeepCopyInto(out *BackupSpec) {
 		}
 	}
 	in.Hooks.DeepCopyInto(&out.Hooks)
+	if in.ExcludedSecretAnnotations != nil {
+		in, out := &in.ExcludedSecretAnnotations, &out.ExcludedSecretAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ExcludedSecretOwnerKinds != nil {
+		in, out := &in.ExcludedSecretOwnerKinds, &out.ExcludedSecretOwnerKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludedOwnerKinds != nil {
+		in, out := &in.ExcludedOwnerKinds, &out.ExcludedOwnerKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.ModifiedSince = in.ModifiedSince
+	if in.ExcludedFields != nil {
+		in, out := &in.ExcludedFields, &out.ExcludedFields
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				(*out)[key] = make([]string, len(val))
+				copy((*out)[key], val)
+			}
+		}
+	}
+	out.ResourceRequestTimeout = in.ResourceRequestTimeout
 	return
 }
 
@@ -267,10 +401,40 @@ func (in *BackupSpec) DeepCopy() *BackupSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStageDurations) DeepCopyInto(out *BackupStageDurations) {
+	*out = *in
+	out.ItemCollection = in.ItemCollection
+	out.VolumeSnapshots = in.VolumeSnapshots
+	out.Upload = in.Upload
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStageDurations.
+func (in *BackupStageDurations) DeepCopy() *BackupStageDurations {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStageDurations)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 	*out = *in
 	in.Expiration.DeepCopyInto(&out.Expiration)
+	in.StartTimestamp.DeepCopyInto(&out.StartTimestamp)
+	in.CompletionTimestamp.DeepCopyInto(&out.CompletionTimestamp)
+	if in.StageDurations != nil {
+		in, out := &in.StageDurations, &out.StageDurations
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupStageDurations)
+			**out = **in
+		}
+	}
 	if in.VolumeBackups != nil {
 		in, out := &in.VolumeBackups, &out.VolumeBackups
 		*out = make(map[string]*VolumeBackupInfo, len(*in))
@@ -288,6 +452,67 @@ func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupProgress)
+			**out = **in
+		}
+	}
+	if in.ItemCounts != nil {
+		in, out := &in.ItemCounts, &out.ItemCounts
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupItemCounts)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]BackupCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IncludedOwners != nil {
+		in, out := &in.IncludedOwners, &out.IncludedOwners
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BackupChain != nil {
+		in, out := &in.BackupChain, &out.BackupChain
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HookResults != nil {
+		in, out := &in.HookResults, &out.HookResults
+		*out = make([]HookCommandResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RecycleBin != nil {
+		in, out := &in.RecycleBin, &out.RecycleBin
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupRecycleBinStatus)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -301,6 +526,119 @@ func (in *BackupStatus) DeepCopy() *BackupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStreamConfig) DeepCopyInto(out *BackupStreamConfig) {
+	*out = *in
+	in.ObjectStorageProviderConfig.DeepCopyInto(&out.ObjectStorageProviderConfig)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStreamConfig.
+func (in *BackupStreamConfig) DeepCopy() *BackupStreamConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStreamConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupTombstone) DeepCopyInto(out *BackupTombstone) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupTombstone.
+func (in *BackupTombstone) DeepCopy() *BackupTombstone {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupTombstone)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BackupTombstone) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupTombstoneList) DeepCopyInto(out *BackupTombstoneList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BackupTombstone, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupTombstoneList.
+func (in *BackupTombstoneList) DeepCopy() *BackupTombstoneList {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupTombstoneList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BackupTombstoneList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupTombstoneSpec) DeepCopyInto(out *BackupTombstoneSpec) {
+	*out = *in
+	in.BackupCreationTimestamp.DeepCopyInto(&out.BackupCreationTimestamp)
+	in.BackupExpiration.DeepCopyInto(&out.BackupExpiration)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupTombstoneSpec.
+func (in *BackupTombstoneSpec) DeepCopy() *BackupTombstoneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupTombstoneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSISnapshotInfo) DeepCopyInto(out *CSISnapshotInfo) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSISnapshotInfo.
+func (in *CSISnapshotInfo) DeepCopy() *CSISnapshotInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(CSISnapshotInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudProviderConfig) DeepCopyInto(out *CloudProviderConfig) {
 	*out = *in
@@ -334,11 +672,28 @@ func (in *Config) DeepCopyInto(out *Config) {
 		if *in == nil {
 			*out = nil
 		} else {
-			*out = new(CloudProviderConfig)
+			*out = new(PersistentVolumeProviderConfig)
 			(*in).DeepCopyInto(*out)
 		}
 	}
 	in.BackupStorageProvider.DeepCopyInto(&out.BackupStorageProvider)
+	if in.BackupStorageMirrors != nil {
+		in, out := &in.BackupStorageMirrors, &out.BackupStorageMirrors
+		*out = make([]ObjectStorageProviderConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BackupStream != nil {
+		in, out := &in.BackupStream, &out.BackupStream
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupStreamConfig)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	out.MinAvailableBackupStorage = in.MinAvailableBackupStorage.DeepCopy()
 	out.BackupSyncPeriod = in.BackupSyncPeriod
 	out.GCSyncPeriod = in.GCSyncPeriod
 	out.ScheduleSyncPeriod = in.ScheduleSyncPeriod
@@ -347,6 +702,84 @@ func (in *Config) DeepCopyInto(out *Config) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.BackupResourcePriorities != nil {
+		in, out := &in.BackupResourcePriorities, &out.BackupResourcePriorities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DefaultExcludedResources != nil {
+		in, out := &in.DefaultExcludedResources, &out.DefaultExcludedResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.VersionedResources != nil {
+		in, out := &in.VersionedResources, &out.VersionedResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.GCMinRetention = in.GCMinRetention
+	out.GCFailedBackupTTL = in.GCFailedBackupTTL
+	out.GCSnapshotsMissingBackupTTL = in.GCSnapshotsMissingBackupTTL
+	if in.GCMaintenanceWindow != nil {
+		in, out := &in.GCMaintenanceWindow, &out.GCMaintenanceWindow
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(MaintenanceWindow)
+			**out = **in
+		}
+	}
+	out.GCRecycleBinRetention = in.GCRecycleBinRetention
+	out.GCOrphanedScheduleBackupRetention = in.GCOrphanedScheduleBackupRetention
+	if in.GCRetentionClasses != nil {
+		in, out := &in.GCRetentionClasses, &out.GCRetentionClasses
+		*out = make(map[string]meta_v1.Duration, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.BackupTombstoneRetention = in.BackupTombstoneRetention
+	out.GCReportPeriod = in.GCReportPeriod
+	out.GCReportRetention = in.GCReportRetention
+	out.GCTransientErrorBackoff = in.GCTransientErrorBackoff
+	out.DefaultBackupTTL = in.DefaultBackupTTL
+	out.BackupRetryBackoff = in.BackupRetryBackoff
+	out.ShutdownGracePeriod = in.ShutdownGracePeriod
+	out.StaleBackupTimeout = in.StaleBackupTimeout
+	out.BackupResourceRequestTimeout = in.BackupResourceRequestTimeout
+	if in.SnapshotTags != nil {
+		in, out := &in.SnapshotTags, &out.SnapshotTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.SnapshotCheckPeriod = in.SnapshotCheckPeriod
+	if in.MaxConcurrentSnapshotsPerStorageClass != nil {
+		in, out := &in.MaxConcurrentSnapshotsPerStorageClass, &out.MaxConcurrentSnapshotsPerStorageClass
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NamespaceSchedulePolicies != nil {
+		in, out := &in.NamespaceSchedulePolicies, &out.NamespaceSchedulePolicies
+		*out = make(map[string]NamespaceSchedulePolicy, len(*in))
+		for key, val := range *in {
+			newVal := new(NamespaceSchedulePolicy)
+			val.DeepCopyInto(newVal)
+			(*out)[key] = *newVal
+		}
+	}
+	if in.NamespaceDeletionBackupTemplate != nil {
+		in, out := &in.NamespaceDeletionBackupTemplate, &out.NamespaceDeletionBackupTemplate
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -469,6 +902,7 @@ func (in *DeleteBackupRequestList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeleteBackupRequestSpec) DeepCopyInto(out *DeleteBackupRequestSpec) {
 	*out = *in
+	out.TTLAfterProcessed = in.TTLAfterProcessed
 	return
 }
 
@@ -490,6 +924,8 @@ func (in *DeleteBackupRequestStatus) DeepCopyInto(out *DeleteBackupRequestStatus
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	in.StartTimestamp.DeepCopyInto(&out.StartTimestamp)
+	in.CompletionTimestamp.DeepCopyInto(&out.CompletionTimestamp)
 	return
 }
 
@@ -564,93 +1000,365 @@ func (in *DownloadRequestList) DeepCopyObject() runtime.Object {
 	} else {
 		return nil
 	}
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DownloadRequestSpec) DeepCopyInto(out *DownloadRequestSpec) {
-	*out = *in
-	out.Target = in.Target
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DownloadRequestSpec) DeepCopyInto(out *DownloadRequestSpec) {
+	*out = *in
+	out.Target = in.Target
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownloadRequestSpec.
+func (in *DownloadRequestSpec) DeepCopy() *DownloadRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DownloadRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DownloadRequestStatus) DeepCopyInto(out *DownloadRequestStatus) {
+	*out = *in
+	in.Expiration.DeepCopyInto(&out.Expiration)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownloadRequestStatus.
+func (in *DownloadRequestStatus) DeepCopy() *DownloadRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DownloadRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DownloadTarget) DeepCopyInto(out *DownloadTarget) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownloadTarget.
+func (in *DownloadTarget) DeepCopy() *DownloadTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(DownloadTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecHook) DeepCopyInto(out *ExecHook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Commands != nil {
+		in, out := &in.Commands, &out.Commands
+		*out = make([][]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+		}
+	}
+	out.Timeout = in.Timeout
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecHook.
+func (in *ExecHook) DeepCopy() *ExecHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCReport) DeepCopyInto(out *GCReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCReport.
+func (in *GCReport) DeepCopy() *GCReport {
+	if in == nil {
+		return nil
+	}
+	out := new(GCReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GCReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCReportEntry) DeepCopyInto(out *GCReportEntry) {
+	*out = *in
+	in.FirstTimestamp.DeepCopyInto(&out.FirstTimestamp)
+	in.LastTimestamp.DeepCopyInto(&out.LastTimestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCReportEntry.
+func (in *GCReportEntry) DeepCopy() *GCReportEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(GCReportEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCReportList) DeepCopyInto(out *GCReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GCReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCReportList.
+func (in *GCReportList) DeepCopy() *GCReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(GCReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GCReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCReportSpec) DeepCopyInto(out *GCReportSpec) {
+	*out = *in
+	in.StartTimestamp.DeepCopyInto(&out.StartTimestamp)
+	in.EndTimestamp.DeepCopyInto(&out.EndTimestamp)
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]GCReportEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCReportSpec.
+func (in *GCReportSpec) DeepCopy() *GCReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GCReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookCommandResult) DeepCopyInto(out *HookCommandResult) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookCommandResult.
+func (in *HookCommandResult) DeepCopy() *HookCommandResult {
+	if in == nil {
+		return nil
+	}
+	out := new(HookCommandResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JSONPatchOperation) DeepCopyInto(out *JSONPatchOperation) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JSONPatchOperation.
+func (in *JSONPatchOperation) DeepCopy() *JSONPatchOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(JSONPatchOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceItemCounts) DeepCopyInto(out *NamespaceItemCounts) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownloadRequestSpec.
-func (in *DownloadRequestSpec) DeepCopy() *DownloadRequestSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceItemCounts.
+func (in *NamespaceItemCounts) DeepCopy() *NamespaceItemCounts {
 	if in == nil {
 		return nil
 	}
-	out := new(DownloadRequestSpec)
+	out := new(NamespaceItemCounts)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DownloadRequestStatus) DeepCopyInto(out *DownloadRequestStatus) {
+func (in *NamespaceSchedulePolicy) DeepCopyInto(out *NamespaceSchedulePolicy) {
 	*out = *in
-	in.Expiration.DeepCopyInto(&out.Expiration)
+	in.Template.DeepCopyInto(&out.Template)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownloadRequestStatus.
-func (in *DownloadRequestStatus) DeepCopy() *DownloadRequestStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceSchedulePolicy.
+func (in *NamespaceSchedulePolicy) DeepCopy() *NamespaceSchedulePolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(DownloadRequestStatus)
+	out := new(NamespaceSchedulePolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DownloadTarget) DeepCopyInto(out *DownloadTarget) {
+func (in *ObjectStorageProviderConfig) DeepCopyInto(out *ObjectStorageProviderConfig) {
 	*out = *in
+	in.CloudProviderConfig.DeepCopyInto(&out.CloudProviderConfig)
+	if in.RetryableErrors != nil {
+		in, out := &in.RetryableErrors, &out.RetryableErrors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownloadTarget.
-func (in *DownloadTarget) DeepCopy() *DownloadTarget {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStorageProviderConfig.
+func (in *ObjectStorageProviderConfig) DeepCopy() *ObjectStorageProviderConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(DownloadTarget)
+	out := new(ObjectStorageProviderConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExecHook) DeepCopyInto(out *ExecHook) {
+func (in *PersistentVolumeProviderConfig) DeepCopyInto(out *PersistentVolumeProviderConfig) {
 	*out = *in
-	if in.Command != nil {
-		in, out := &in.Command, &out.Command
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	out.Timeout = in.Timeout
+	in.CloudProviderConfig.DeepCopyInto(&out.CloudProviderConfig)
+	out.SnapshotTTL = in.SnapshotTTL
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecHook.
-func (in *ExecHook) DeepCopy() *ExecHook {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistentVolumeProviderConfig.
+func (in *PersistentVolumeProviderConfig) DeepCopy() *PersistentVolumeProviderConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(ExecHook)
+	out := new(PersistentVolumeProviderConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ObjectStorageProviderConfig) DeepCopyInto(out *ObjectStorageProviderConfig) {
+func (in *ResourceModifier) DeepCopyInto(out *ResourceModifier) {
 	*out = *in
-	in.CloudProviderConfig.DeepCopyInto(&out.CloudProviderConfig)
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.LabelSelector)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Patches != nil {
+		in, out := &in.Patches, &out.Patches
+		*out = make([]JSONPatchOperation, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStorageProviderConfig.
-func (in *ObjectStorageProviderConfig) DeepCopy() *ObjectStorageProviderConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceModifier.
+func (in *ResourceModifier) DeepCopy() *ResourceModifier {
 	if in == nil {
 		return nil
 	}
-	out := new(ObjectStorageProviderConfig)
+	out := new(ResourceModifier)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -684,6 +1392,22 @@ func (in *Restore) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreCheckpoint) DeepCopyInto(out *RestoreCheckpoint) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreCheckpoint.
+func (in *RestoreCheckpoint) DeepCopy() *RestoreCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RestoreList) DeepCopyInto(out *RestoreList) {
 	*out = *in
@@ -718,6 +1442,22 @@ func (in *RestoreList) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreProgress) DeepCopyInto(out *RestoreProgress) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreProgress.
+func (in *RestoreProgress) DeepCopy() *RestoreProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RestoreResult) DeepCopyInto(out *RestoreResult) {
 	*out = *in
@@ -813,6 +1553,84 @@ func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
 			**out = **in
 		}
 	}
+	if in.MergeStrategies != nil {
+		in, out := &in.MergeStrategies, &out.MergeStrategies
+		*out = make(map[string]MergeStrategy, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ResourceModifiers != nil {
+		in, out := &in.ResourceModifiers, &out.ResourceModifiers
+		*out = make([]ResourceModifier, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RestoreWebhooksLast != nil {
+		in, out := &in.RestoreWebhooksLast, &out.RestoreWebhooksLast
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(bool)
+			**out = **in
+		}
+	}
+	if in.LabelRestoredItems != nil {
+		in, out := &in.LabelRestoredItems, &out.LabelRestoredItems
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(bool)
+			**out = **in
+		}
+	}
+	if in.AddedLabels != nil {
+		in, out := &in.AddedLabels, &out.AddedLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AddedAnnotations != nil {
+		in, out := &in.AddedAnnotations, &out.AddedAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ContainerResourcesFactor != nil {
+		in, out := &in.ContainerResourcesFactor, &out.ContainerResourcesFactor
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(float64)
+			**out = **in
+		}
+	}
+	if in.ImageRegistryMapping != nil {
+		in, out := &in.ImageRegistryMapping, &out.ImageRegistryMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ResourceRenames != nil {
+		in, out := &in.ResourceRenames, &out.ResourceRenames
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.LabelSelector)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -834,6 +1652,36 @@ func (in *RestoreStatus) DeepCopyInto(out *RestoreStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RestoreProgress)
+			**out = **in
+		}
+	}
+	if in.RestoredVolumes != nil {
+		in, out := &in.RestoredVolumes, &out.RestoredVolumes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Checkpoint != nil {
+		in, out := &in.Checkpoint, &out.Checkpoint
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RestoreCheckpoint)
+			**out = **in
+		}
+	}
+	if in.UIDMappings != nil {
+		in, out := &in.UIDMappings, &out.UIDMappings
+		*out = make([]RestoredUIDMapping, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -847,6 +1695,22 @@ func (in *RestoreStatus) DeepCopy() *RestoreStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoredUIDMapping) DeepCopyInto(out *RestoredUIDMapping) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoredUIDMapping.
+func (in *RestoredUIDMapping) DeepCopy() *RestoredUIDMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoredUIDMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Schedule) DeepCopyInto(out *Schedule) {
 	*out = *in
@@ -949,6 +1813,102 @@ func (in *ScheduleStatus) DeepCopy() *ScheduleStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerStatusRequest) DeepCopyInto(out *ServerStatusRequest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerStatusRequest.
+func (in *ServerStatusRequest) DeepCopy() *ServerStatusRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerStatusRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServerStatusRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerStatusRequestList) DeepCopyInto(out *ServerStatusRequestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServerStatusRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerStatusRequestList.
+func (in *ServerStatusRequestList) DeepCopy() *ServerStatusRequestList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerStatusRequestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServerStatusRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerStatusRequestSpec) DeepCopyInto(out *ServerStatusRequestSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerStatusRequestSpec.
+func (in *ServerStatusRequestSpec) DeepCopy() *ServerStatusRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerStatusRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerStatusRequestStatus) DeepCopyInto(out *ServerStatusRequestStatus) {
+	*out = *in
+	in.ProcessedTimestamp.DeepCopyInto(&out.ProcessedTimestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerStatusRequestStatus.
+func (in *ServerStatusRequestStatus) DeepCopy() *ServerStatusRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerStatusRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeBackupInfo) DeepCopyInto(out *VolumeBackupInfo) {
 	*out = *in
@@ -961,6 +1921,15 @@ func (in *VolumeBackupInfo) DeepCopyInto(out *VolumeBackupInfo) {
 			**out = **in
 		}
 	}
+	if in.CSISnapshot != nil {
+		in, out := &in.CSISnapshot, &out.CSISnapshot
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(CSISnapshotInfo)
+			**out = **in
+		}
+	}
 	return
 }
 
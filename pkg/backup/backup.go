@@ -21,29 +21,78 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
+	"sort"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	kuberrs "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cloudprovider"
+	"github.com/heptio/ark/pkg/csi"
 	"github.com/heptio/ark/pkg/discovery"
 	"github.com/heptio/ark/pkg/util/collections"
+	"github.com/heptio/ark/pkg/util/compression"
 	kubeutil "github.com/heptio/ark/pkg/util/kube"
 	"github.com/heptio/ark/pkg/util/logging"
 )
 
+const (
+	// FormatVersion is the latest version of the backup tarball's layout written by this
+	// version of Ark. It's recorded in each backup's status.version, and must be increased
+	// whenever the layout changes in a way that older versions of Ark can't restore.
+	FormatVersion = deduplicatedFormatVersion
+
+	// gzipFormatVersion is the version of gzip-compressed backup tarballs, which every
+	// version of Ark can restore.
+	gzipFormatVersion = 1
+
+	// compressedFormatVersion is the version of backup tarballs compressed in a format
+	// other than gzip, which versions of Ark before compression formats were supported
+	// can't decompress.
+	compressedFormatVersion = 2
+
+	// deduplicatedFormatVersion is the version of backups whose contents are stored
+	// deduplicated in object storage instead of as a tarball, which versions of Ark before
+	// deduplication was supported can't download.
+	deduplicatedFormatVersion = 3
+)
+
+// FormatVersionFor returns the format version of backups whose tarballs are compressed in
+// compressionFormat, and whose contents are stored deduplicated if deduplicated is true.
+// Backups are given the lowest version that can restore them, so older versions of Ark can
+// still restore the backups they support, and reject the others.
+func FormatVersionFor(compressionFormat string, deduplicated bool) int {
+	if deduplicated {
+		return deduplicatedFormatVersion
+	}
+	if compressionFormat == "" || compressionFormat == compression.Gzip {
+		return gzipFormatVersion
+	}
+	return compressedFormatVersion
+}
+
+// IsSupportedFormatVersion returns true if backups with the specified format version can
+// be restored by this version of Ark.
+func IsSupportedFormatVersion(version int) bool {
+	return version <= FormatVersion
+}
+
 // Backupper performs backups.
 type Backupper interface {
 	// Backup takes a backup using the specification in the api.Backup and writes backup and log data
 	// to the given writers.
-	Backup(backup *api.Backup, backupFile, logFile io.Writer, actions []ItemAction) error
+	Backup(backup *api.Backup, backupFile, logFile io.Writer, actions []ItemAction, progress *Progress) error
 }
 
 // kubernetesBackupper implements Backupper.
@@ -53,6 +102,13 @@ type kubernetesBackupper struct {
 	podCommandExecutor    podCommandExecutor
 	groupBackupperFactory groupBackupperFactory
 	snapshotService       cloudprovider.SnapshotService
+	csiSnapshotter        csi.Snapshotter
+	snapshotThrottle      snapshotThrottle
+	resourcePriorities    []string
+
+	// resourceRequestTimeout is the timeout for listing and getting items, for
+	// backups that don't set their own
+	resourceRequestTimeout time.Duration
 }
 
 type itemKey struct {
@@ -79,6 +135,10 @@ func NewKubernetesBackupper(
 	dynamicFactory client.DynamicFactory,
 	podCommandExecutor podCommandExecutor,
 	snapshotService cloudprovider.SnapshotService,
+	csiSnapshotter csi.Snapshotter,
+	snapshotThrottle snapshotThrottle,
+	resourcePriorities []string,
+	resourceRequestTimeout time.Duration,
 ) (Backupper, error) {
 	return &kubernetesBackupper{
 		discoveryHelper:       discoveryHelper,
@@ -86,6 +146,11 @@ func NewKubernetesBackupper(
 		podCommandExecutor:    podCommandExecutor,
 		groupBackupperFactory: &defaultGroupBackupperFactory{},
 		snapshotService:       snapshotService,
+		csiSnapshotter:        csiSnapshotter,
+		snapshotThrottle:      snapshotThrottle,
+		resourcePriorities:    resourcePriorities,
+
+		resourceRequestTimeout: resourceRequestTimeout,
 	}, nil
 }
 
@@ -143,9 +208,21 @@ func getResourceIncludesExcludes(helper discovery.Helper, includes, excludes []s
 }
 
 // getNamespaceIncludesExcludes returns an IncludesExcludes list containing which namespaces to
-// include and exclude from the backup.
-func getNamespaceIncludesExcludes(backup *api.Backup) *collections.IncludesExcludes {
-	return collections.NewIncludesExcludes().Includes(backup.Spec.IncludedNamespaces...).Excludes(backup.Spec.ExcludedNamespaces...)
+// include and exclude from the backup, with the patterns in either list expanded to the
+// namespaces that match them.
+func getNamespaceIncludesExcludes(backup *api.Backup, namespaces []string) *collections.IncludesExcludes {
+	includes := collections.ExpandPatterns(backup.Spec.IncludedNamespaces, namespaces)
+	excludes := collections.ExpandPatterns(backup.Spec.ExcludedNamespaces, namespaces)
+
+	res := collections.NewIncludesExcludes().Includes(includes...).Excludes(excludes...)
+
+	// an empty includes list means everything is included, so if none of the included
+	// patterns matched a namespace, exclude all of them instead
+	if len(backup.Spec.IncludedNamespaces) > 0 && len(includes) == 0 {
+		res.Includes("*").Excludes(namespaces...)
+	}
+
+	return res
 }
 
 func getResourceHooks(hookSpecs []api.BackupResourceHookSpec, discoveryHelper discovery.Helper) ([]resourceHook, error) {
@@ -172,11 +249,13 @@ func getResourceHook(hookSpec api.BackupResourceHookSpec, discoveryHelper discov
 	}
 
 	h := resourceHook{
-		name:       hookSpec.Name,
-		namespaces: collections.NewIncludesExcludes().Includes(hookSpec.IncludedNamespaces...).Excludes(hookSpec.ExcludedNamespaces...),
-		resources:  getResourceIncludesExcludes(discoveryHelper, hookSpec.IncludedResources, hookSpec.ExcludedResources),
-		pre:        preHooks,
-		post:       hookSpec.PostHooks,
+		name:         hookSpec.Name,
+		namespaces:   collections.NewIncludesExcludes().Includes(hookSpec.IncludedNamespaces...).Excludes(hookSpec.ExcludedNamespaces...),
+		resources:    getResourceIncludesExcludes(discoveryHelper, hookSpec.IncludedResources, hookSpec.ExcludedResources),
+		pre:          preHooks,
+		post:         hookSpec.PostHooks,
+		preSnapshot:  hookSpec.PreSnapshotHooks,
+		postSnapshot: hookSpec.PostSnapshotHooks,
 	}
 
 	if hookSpec.LabelSelector != nil {
@@ -190,13 +269,28 @@ func getResourceHook(hookSpec api.BackupResourceHookSpec, discoveryHelper discov
 	return h, nil
 }
 
-// Backup backs up the items specified in the Backup, placing them in a gzip-compressed tar file
-// written to backupFile. The finalized api.Backup is written to metadata.
-func (kb *kubernetesBackupper) Backup(backup *api.Backup, backupFile, logFile io.Writer, actions []ItemAction) error {
-	gzippedData := gzip.NewWriter(backupFile)
-	defer gzippedData.Close()
+// Backup backs up the items specified in the Backup, placing them in a tar file compressed in
+// the backup's compression format and written to backupFile. The finalized api.Backup is written
+// to metadata. The number of items found and backed up is recorded in progress as the backup runs.
+func (kb *kubernetesBackupper) Backup(backup *api.Backup, backupFile, logFile io.Writer, actions []ItemAction, progress *Progress) error {
+	codec, err := compression.CodecFor(backup.Spec.CompressionFormat)
+	if err != nil {
+		return err
+	}
+
+	// recorded so restores know how to decompress the tarball
+	backup.Status.CompressionFormat = backup.Spec.CompressionFormat
+	if backup.Status.CompressionFormat == "" {
+		backup.Status.CompressionFormat = compression.Gzip
+	}
+
+	compressedData, err := codec.NewWriter(backupFile)
+	if err != nil {
+		return err
+	}
+	defer compressedData.Close()
 
-	tw := tar.NewWriter(gzippedData)
+	tw := tar.NewWriter(compressedData)
 	defer tw.Close()
 
 	gzippedLog := gzip.NewWriter(logFile)
@@ -209,7 +303,22 @@ func (kb *kubernetesBackupper) Backup(backup *api.Backup, backupFile, logFile io
 	log := logger.WithField("backup", kubeutil.NamespaceAndName(backup))
 	log.Info("Starting backup")
 
-	namespaceIncludesExcludes := getNamespaceIncludesExcludes(backup)
+	namespaces, terminating, err := kb.getNamespaces()
+	if err != nil {
+		return err
+	}
+
+	namespaceIncludesExcludes := getNamespaceIncludesExcludes(backup, append(append([]string{}, namespaces...), terminating...))
+	if backup.Spec.IncludeTerminatingNamespaces {
+		namespaces = append(namespaces, terminating...)
+	} else {
+		for _, ns := range terminating {
+			if namespaceIncludesExcludes.ShouldInclude(ns) {
+				log.Infof("Excluding namespace %s because it is terminating", ns)
+				namespaceIncludesExcludes.Excludes(ns)
+			}
+		}
+	}
 	log.Infof("Including namespaces: %s", namespaceIncludesExcludes.IncludesString())
 	log.Infof("Excluding namespaces: %s", namespaceIncludesExcludes.ExcludesString())
 
@@ -217,6 +326,13 @@ func (kb *kubernetesBackupper) Backup(backup *api.Backup, backupFile, logFile io
 	log.Infof("Including resources: %s", resourceIncludesExcludes.IncludesString())
 	log.Infof("Excluding resources: %s", resourceIncludesExcludes.ExcludesString())
 
+	if !includesAnyNamespace(namespaceIncludesExcludes, namespaces) &&
+		!includesClusterResources(backup, namespaceIncludesExcludes, resourceIncludesExcludes, kb.discoveryHelper) {
+		err := &NothingSelectedError{}
+		log.Info(err.Error())
+		return err
+	}
+
 	resourceHooks, err := getResourceHooks(backup.Spec.Hooks.Resources, kb.discoveryHelper)
 	if err != nil {
 		return err
@@ -241,31 +357,80 @@ func (kb *kubernetesBackupper) Backup(backup *api.Backup, backupFile, logFile io
 		return err
 	}
 
+	if backup.Spec.ConsistentListing {
+		resourceVersion, err := kb.getResourceVersionWatermark()
+		if err != nil {
+			return err
+		}
+		backup.Status.ListResourceVersion = resourceVersion
+		log.Infof("Listing resources at resourceVersion %s", resourceVersion)
+	}
+
+	// volumes in consistency groups are snapshotted together when the first of them is
+	// backed up, and their snapshots are recorded as their PersistentVolumes are backed up
+	var groupSnapshotter *groupSnapshotService
+	snapshotService := kb.snapshotService
+	if snapshotService != nil {
+		snapshotService = &timedSnapshotService{SnapshotService: snapshotService, progress: progress}
+		groupSnapshotter, err = kb.newGroupSnapshotService(log, backup, namespaceIncludesExcludes, snapshotService, progress)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		snapshotService = groupSnapshotter
+	}
+
+	// each resource's requests are made with a timeout, if there is one, so a hung
+	// request only skips its resource
+	dynamicFactory := kb.dynamicFactory
+	timeout := backup.Spec.ResourceRequestTimeout.Duration
+	if timeout == 0 {
+		timeout = kb.resourceRequestTimeout
+	}
+	if timeout > 0 {
+		log.Infof("Timing out requests for resources' items after %s", timeout)
+		dynamicFactory = &timeoutDynamicFactory{DynamicFactory: dynamicFactory, timeout: timeout}
+	}
+
 	gb := kb.groupBackupperFactory.newGroupBackupper(
 		log,
 		backup,
 		namespaceIncludesExcludes,
 		resourceIncludesExcludes,
 		labelSelector,
-		kb.dynamicFactory,
+		dynamicFactory,
 		kb.discoveryHelper,
 		backedUpItems,
 		cohabitatingResources,
 		resolvedActions,
 		kb.podCommandExecutor,
-		tw,
+		&progressTarWriter{tarWriter: tw, progress: progress},
 		resourceHooks,
-		kb.snapshotService,
+		snapshotService,
+		kb.csiSnapshotter,
+		kb.snapshotThrottle,
+		progress,
 	)
 
-	for _, group := range kb.discoveryHelper.Resources() {
+	for _, group := range prioritizeGroups(kb.discoveryHelper, kb.resourcePriorities, log) {
 		if err := gb.backupGroup(group); err != nil {
 			errs = append(errs, err)
 		}
 	}
 
+	if groupSnapshotter != nil {
+		errs = append(errs, groupSnapshotter.finish(backup, log)...)
+	}
+
+	backup.Status.ItemCounts = countItems(backedUpItems)
+
 	err = kuberrs.Flatten(kuberrs.NewAggregate(errs))
+	if err == nil && len(backedUpItems) < backup.Spec.MinItems {
+		err = &BelowMinItemsError{Items: len(backedUpItems), MinItems: backup.Spec.MinItems}
+		log.Info(err.Error())
+		return err
+	}
 	if err == nil {
+		progress.finish()
 		log.Infof("Backup completed successfully")
 	} else {
 		log.Infof("Backup completed with errors: %v", err)
@@ -274,6 +439,227 @@ func (kb *kubernetesBackupper) Backup(backup *api.Backup, backupFile, logFile io
 	return err
 }
 
+// countItems returns the number of items of each resource in items, so the CLI can
+// summarize a backup's contents without downloading it.
+func countItems(items map[itemKey]struct{}) *api.BackupItemCounts {
+	counts := &api.BackupItemCounts{
+		ClusterResources: make(map[string]int),
+	}
+
+	namespaces := make(map[string]map[string]int)
+	for key := range items {
+		if key.namespace == "" {
+			counts.ClusterResources[key.resource]++
+			continue
+		}
+
+		if namespaces[key.namespace] == nil {
+			namespaces[key.namespace] = make(map[string]int)
+		}
+		namespaces[key.namespace][key.resource]++
+	}
+
+	for ns, resources := range namespaces {
+		counts.Namespaces = append(counts.Namespaces, api.NamespaceItemCounts{Namespace: ns, Resources: resources})
+	}
+	sort.Slice(counts.Namespaces, func(i, j int) bool {
+		return counts.Namespaces[i].Namespace < counts.Namespaces[j].Namespace
+	})
+
+	return counts
+}
+
+// BelowMinItemsError is returned by Backup when a backup contains fewer items than
+// its spec's MinItems.
+type BelowMinItemsError struct {
+	Items    int
+	MinItems int
+}
+
+func (e *BelowMinItemsError) Error() string {
+	return fmt.Sprintf("backup contains %d items, fewer than the minimum of %d", e.Items, e.MinItems)
+}
+
+// prioritizeGroups returns the API groups to back up, in order. Each resource in priorities
+// is returned first, in a group of its own, followed by the groups from discovery without
+// the prioritized resources.
+func prioritizeGroups(helper discovery.Helper, priorities []string, log logrus.FieldLogger) []*metav1.APIResourceList {
+	if len(priorities) == 0 {
+		return helper.Resources()
+	}
+
+	var groups []*metav1.APIResourceList
+	prioritized := sets.NewString()
+
+	for _, r := range priorities {
+		gvr, resource, err := helper.ResourceFor(schema.ParseGroupResource(r).WithVersion(""))
+		if err != nil {
+			// the resource may be a custom resource that isn't installed in this cluster
+			log.WithError(err).WithField("resource", r).Warn("Unable to resolve prioritized resource, skipping it")
+			continue
+		}
+
+		gr := gvr.GroupResource()
+		if prioritized.Has(gr.String()) {
+			continue
+		}
+		prioritized.Insert(gr.String())
+
+		groups = append(groups, &metav1.APIResourceList{
+			GroupVersion: gvr.GroupVersion().String(),
+			APIResources: []metav1.APIResource{resource},
+		})
+	}
+
+	for _, group := range helper.Resources() {
+		gv, err := schema.ParseGroupVersion(group.GroupVersion)
+		if err != nil {
+			// backupGroup reports the error
+			groups = append(groups, group)
+			continue
+		}
+
+		remaining := &metav1.APIResourceList{
+			TypeMeta:     group.TypeMeta,
+			GroupVersion: group.GroupVersion,
+		}
+		for _, resource := range group.APIResources {
+			gr := gv.WithResource(resource.Name).GroupResource()
+			if !prioritized.Has(gr.String()) {
+				remaining.APIResources = append(remaining.APIResources, resource)
+			}
+		}
+
+		if len(remaining.APIResources) > 0 {
+			groups = append(groups, remaining)
+		}
+	}
+
+	return groups
+}
+
+// NothingSelectedError is returned by Backup when a backup's include/exclude settings
+// select no namespaces and no cluster-scoped resources.
+type NothingSelectedError struct{}
+
+func (e *NothingSelectedError) Error() string {
+	return "backup's included/excluded namespaces and resources select no namespaces and no cluster-scoped resources"
+}
+
+// includesAnyNamespace returns whether any of namespaces are included by namespaceIncludesExcludes.
+func includesAnyNamespace(namespaceIncludesExcludes *collections.IncludesExcludes, namespaces []string) bool {
+	for _, ns := range namespaces {
+		if namespaceIncludesExcludes.ShouldInclude(ns) {
+			return true
+		}
+	}
+	return false
+}
+
+// includesClusterResources returns whether backup directly includes any cluster-scoped resources
+// other than namespaces, following the same rules as the resource backupper.
+func includesClusterResources(backup *api.Backup, namespaces, resources *collections.IncludesExcludes, helper discovery.Helper) bool {
+	if backup.Spec.IncludeClusterResources == nil {
+		if !namespaces.IncludeEverything() {
+			return false
+		}
+	} else if !*backup.Spec.IncludeClusterResources {
+		return false
+	}
+
+	for _, group := range helper.Resources() {
+		gv, err := schema.ParseGroupVersion(group.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, resource := range group.APIResources {
+			if resource.Namespaced {
+				continue
+			}
+
+			gr := gv.WithResource(resource.Name).GroupResource()
+			if gr == namespacesGroupResource {
+				continue
+			}
+
+			if resources.ShouldInclude(gr.String()) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// getResourceVersionWatermark returns the API server's current resourceVersion, obtained via a
+// single-item list of namespaces so that the result comes from a quorum read.
+func (kb *kubernetesBackupper) getResourceVersionWatermark() (string, error) {
+	gv := schema.GroupVersion{Group: "", Version: "v1"}
+	resource := metav1.APIResource{Name: "namespaces", Namespaced: false}
+
+	resourceClient, err := kb.dynamicFactory.ClientForGroupVersionResource(gv, resource, "")
+	if err != nil {
+		return "", err
+	}
+
+	list, err := resourceClient.List(metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return "", errors.Wrap(err, "error getting resourceVersion watermark")
+	}
+
+	listAccessor, err := meta.ListAccessor(list)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return listAccessor.GetResourceVersion(), nil
+}
+
+// getNamespaces returns the names of the namespaces in the cluster, split into those
+// whose phase is Terminating and all others.
+func (kb *kubernetesBackupper) getNamespaces() ([]string, []string, error) {
+	gv := schema.GroupVersion{Group: "", Version: "v1"}
+	resource := metav1.APIResource{Name: "namespaces", Namespaced: false}
+
+	resourceClient, err := kb.dynamicFactory.ClientForGroupVersionResource(gv, resource, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list, err := resourceClient.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error listing namespaces")
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	var active, terminating []string
+	for _, item := range items {
+		obj, ok := item.(runtime.Unstructured)
+		if !ok {
+			return nil, nil, errors.Errorf("unexpected type %T", item)
+		}
+
+		metadata, err := meta.Accessor(item)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+
+		phase, _ := collections.GetString(obj.UnstructuredContent(), "status.phase")
+		if phase == string(corev1api.NamespaceTerminating) {
+			terminating = append(terminating, metadata.GetName())
+		} else {
+			active = append(active, metadata.GetName())
+		}
+	}
+
+	return active, terminating, nil
+}
+
 type tarWriter interface {
 	io.Closer
 	Write([]byte) (int, error)
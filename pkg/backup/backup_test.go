@@ -42,8 +42,10 @@ import (
 	"github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cloudprovider"
+	"github.com/heptio/ark/pkg/csi"
 	"github.com/heptio/ark/pkg/discovery"
 	"github.com/heptio/ark/pkg/util/collections"
+	"github.com/heptio/ark/pkg/util/compression"
 	kubeutil "github.com/heptio/ark/pkg/util/kube"
 	arktest "github.com/heptio/ark/pkg/util/test"
 )
@@ -237,7 +239,7 @@ func TestGetNamespaceIncludesExcludes(t *testing.T) {
 		},
 	}
 
-	ns := getNamespaceIncludesExcludes(backup)
+	ns := getNamespaceIncludesExcludes(backup, []string{"a", "b", "c", "d", "e", "f", "g"})
 
 	actualIncludes := ns.GetIncludes()
 	sort.Strings(actualIncludes)
@@ -252,6 +254,60 @@ func TestGetNamespaceIncludesExcludes(t *testing.T) {
 	}
 }
 
+func TestGetNamespaceIncludesExcludesWithPatterns(t *testing.T) {
+	namespaces := []string{"default", "pr-1", "pr-2", "pr-x", "staging"}
+
+	tests := []struct {
+		name             string
+		includes         []string
+		excludes         []string
+		expectedIncludes []string
+		expectedExcludes []string
+	}{
+		{
+			name:             "excluded glob pattern is expanded",
+			includes:         []string{"*"},
+			excludes:         []string{"pr-*"},
+			expectedIncludes: []string{"*"},
+			expectedExcludes: []string{"pr-1", "pr-2", "pr-x"},
+		},
+		{
+			name:             "included regexp pattern is expanded",
+			includes:         []string{"/^pr-[0-9]+$/", "staging"},
+			expectedIncludes: []string{"pr-1", "pr-2", "staging"},
+			expectedExcludes: []string{},
+		},
+		{
+			name:             "included patterns that match nothing exclude every namespace",
+			includes:         []string{"dev-*"},
+			expectedIncludes: []string{"*"},
+			expectedExcludes: namespaces,
+		},
+		{
+			name:             "excluded patterns that match nothing exclude nothing",
+			excludes:         []string{"dev-*"},
+			expectedIncludes: []string{},
+			expectedExcludes: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backup := &v1.Backup{
+				Spec: v1.BackupSpec{
+					IncludedNamespaces: test.includes,
+					ExcludedNamespaces: test.excludes,
+				},
+			}
+
+			ns := getNamespaceIncludesExcludes(backup, namespaces)
+
+			assert.Equal(t, test.expectedIncludes, ns.GetIncludes())
+			assert.Equal(t, test.expectedExcludes, ns.GetExcludes())
+		})
+	}
+}
+
 var (
 	v1Group = &metav1.APIResourceList{
 		GroupVersion: "v1",
@@ -380,6 +436,7 @@ func TestBackup(t *testing.T) {
 		expectedHooks         []resourceHook
 		backupGroupErrors     map[*metav1.APIResourceList]error
 		expectedError         error
+		namespaces            []string
 	}{
 		{
 			name: "happy path, no actions, no label selector, no hooks, no errors",
@@ -393,6 +450,10 @@ func TestBackup(t *testing.T) {
 					ExcludedNamespaces: []string{"c", "d"},
 				},
 			},
+			namespaces: []string{
+				`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"a"},"status":{"phase":"Active"}}`,
+				`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"b"},"status":{"phase":"Active"}}`,
+			},
 			expectedNamespaces: collections.NewIncludesExcludes().Includes("a", "b").Excludes("c", "d"),
 			expectedResources:  collections.NewIncludesExcludes().Includes("configmaps", "certificatesigningrequests.certificates.k8s.io", "roles.rbac.authorization.k8s.io"),
 			expectedHooks:      []resourceHook{},
@@ -421,6 +482,41 @@ func TestBackup(t *testing.T) {
 				rbacGroup:         nil,
 			},
 		},
+		{
+			name:   "terminating namespaces are excluded",
+			backup: &v1.Backup{},
+			namespaces: []string{
+				`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"active"},"status":{"phase":"Active"}}`,
+				`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"terminating"},"status":{"phase":"Terminating"}}`,
+			},
+			expectedNamespaces: collections.NewIncludesExcludes().Excludes("terminating"),
+			expectedResources:  collections.NewIncludesExcludes(),
+			expectedHooks:      []resourceHook{},
+			backupGroupErrors: map[*metav1.APIResourceList]error{
+				v1Group:           nil,
+				certificatesGroup: nil,
+				rbacGroup:         nil,
+			},
+		},
+		{
+			name: "terminating namespaces are included when IncludeTerminatingNamespaces=true",
+			backup: &v1.Backup{
+				Spec: v1.BackupSpec{
+					IncludeTerminatingNamespaces: true,
+				},
+			},
+			namespaces: []string{
+				`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"terminating"},"status":{"phase":"Terminating"}}`,
+			},
+			expectedNamespaces: collections.NewIncludesExcludes(),
+			expectedResources:  collections.NewIncludesExcludes(),
+			expectedHooks:      []resourceHook{},
+			backupGroupErrors: map[*metav1.APIResourceList]error{
+				v1Group:           nil,
+				certificatesGroup: nil,
+				rbacGroup:         nil,
+			},
+		},
 		{
 			name:               "backupGroup errors",
 			backup:             &v1.Backup{},
@@ -434,6 +530,23 @@ func TestBackup(t *testing.T) {
 			},
 			expectedError: errors.New("[v1 error, rbac error]"),
 		},
+		{
+			name: "fewer items than MinItems",
+			backup: &v1.Backup{
+				Spec: v1.BackupSpec{
+					MinItems: 1,
+				},
+			},
+			expectedNamespaces: collections.NewIncludesExcludes(),
+			expectedResources:  collections.NewIncludesExcludes(),
+			expectedHooks:      []resourceHook{},
+			backupGroupErrors: map[*metav1.APIResourceList]error{
+				v1Group:           nil,
+				certificatesGroup: nil,
+				rbacGroup:         nil,
+			},
+			expectedError: &BelowMinItemsError{Items: 0, MinItems: 1},
+		},
 		{
 			name: "hooks",
 			backup: &v1.Backup{
@@ -505,6 +618,18 @@ func TestBackup(t *testing.T) {
 
 			dynamicFactory := &arktest.FakeDynamicFactory{}
 
+			namespaceList := &unstructured.UnstructuredList{}
+			for _, ns := range test.namespaces {
+				namespaceList.Items = append(namespaceList.Items, *unstructuredOrDie(ns))
+			}
+			namespaceClient := &arktest.FakeDynamicClient{}
+			namespaceClient.On("List", metav1.ListOptions{}).Return(namespaceList, nil)
+			dynamicFactory.On("ClientForGroupVersionResource",
+				schema.GroupVersion{Group: "", Version: "v1"},
+				metav1.APIResource{Name: "namespaces", Namespaced: false},
+				"",
+			).Return(namespaceClient, nil)
+
 			podCommandExecutor := &mockPodCommandExecutor{}
 			defer podCommandExecutor.AssertExpectations(t)
 
@@ -513,6 +638,10 @@ func TestBackup(t *testing.T) {
 				dynamicFactory,
 				podCommandExecutor,
 				nil,
+				nil,
+				nil,
+				nil,
+				0,
 			)
 			require.NoError(t, err)
 			kb := b.(*kubernetesBackupper)
@@ -545,6 +674,9 @@ func TestBackup(t *testing.T) {
 				mock.Anything, // tarWriter
 				test.expectedHooks,
 				mock.Anything,
+				mock.Anything,
+				mock.Anything,
+				mock.Anything, // progress
 			).Return(groupBackupper)
 
 			for group, err := range test.backupGroupErrors {
@@ -553,7 +685,7 @@ func TestBackup(t *testing.T) {
 
 			var backupFile, logFile bytes.Buffer
 
-			err = b.Backup(test.backup, &backupFile, &logFile, nil)
+			err = b.Backup(test.backup, &backupFile, &logFile, nil, &Progress{})
 			defer func() {
 				// print log if anything failed
 				if t.Failed() {
@@ -573,6 +705,138 @@ func TestBackup(t *testing.T) {
 				return
 			}
 			assert.NoError(t, err)
+
+			// the default compression format is recorded
+			assert.Equal(t, "gzip", test.backup.Status.CompressionFormat)
+			gzr, err := gzip.NewReader(&backupFile)
+			require.NoError(t, err)
+			require.NoError(t, gzr.Close())
+		})
+	}
+}
+
+func TestPrioritizeGroups(t *testing.T) {
+	discoveryHelper := &arktest.FakeDiscoveryHelper{
+		Mapper: &arktest.FakeMapper{
+			Resources: map[schema.GroupVersionResource]schema.GroupVersionResource{
+				{Resource: "roles"}: {Group: "rbac.authorization.k8s.io", Version: "v1beta1", Resource: "roles"},
+				{Resource: "pods"}:  {Group: "", Version: "v1", Resource: "pods"},
+			},
+		},
+		ResourceList: []*metav1.APIResourceList{
+			{GroupVersion: "v1", APIResources: []metav1.APIResource{configMapsResource, podsResource}},
+			certificatesGroup,
+			rbacGroup,
+		},
+	}
+
+	tests := []struct {
+		name       string
+		priorities []string
+		expected   []*metav1.APIResourceList
+	}{
+		{
+			name:     "no priorities returns groups from discovery",
+			expected: discoveryHelper.Resources(),
+		},
+		{
+			name:       "prioritized resources come first, in their own groups",
+			priorities: []string{"roles", "pods", "unknown"},
+			expected: []*metav1.APIResourceList{
+				{GroupVersion: "rbac.authorization.k8s.io/v1beta1", APIResources: []metav1.APIResource{rolesResource}},
+				{GroupVersion: "v1", APIResources: []metav1.APIResource{podsResource}},
+				{GroupVersion: "v1", APIResources: []metav1.APIResource{configMapsResource}},
+				certificatesGroup,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, prioritizeGroups(discoveryHelper, test.priorities, arktest.NewLogger()))
+		})
+	}
+}
+
+func TestBackupSelectingNothing(t *testing.T) {
+	f := false
+
+	tests := []struct {
+		name       string
+		spec       v1.BackupSpec
+		namespaces []string
+	}{
+		{
+			name: "included namespace doesn't exist",
+			spec: v1.BackupSpec{
+				IncludedNamespaces: []string{"missing"},
+			},
+			namespaces: []string{
+				`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"a"},"status":{"phase":"Active"}}`,
+			},
+		},
+		{
+			name: "only namespace is terminating and cluster resources are excluded",
+			spec: v1.BackupSpec{
+				IncludedNamespaces:      []string{"*"},
+				IncludeClusterResources: &f,
+			},
+			namespaces: []string{
+				`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"terminating"},"status":{"phase":"Terminating"}}`,
+			},
+		},
+		{
+			name: "no namespaces and only namespaced resources included",
+			spec: v1.BackupSpec{
+				IncludedResources: []string{"cm", "roles"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			discoveryHelper := &arktest.FakeDiscoveryHelper{
+				Mapper: &arktest.FakeMapper{
+					Resources: map[schema.GroupVersionResource]schema.GroupVersionResource{
+						{Resource: "cm"}:    {Group: "", Version: "v1", Resource: "configmaps"},
+						{Resource: "roles"}: {Group: "rbac.authorization.k8s.io", Version: "v1beta1", Resource: "roles"},
+					},
+				},
+				ResourceList: []*metav1.APIResourceList{
+					v1Group,
+					certificatesGroup,
+					rbacGroup,
+				},
+			}
+
+			namespaceList := &unstructured.UnstructuredList{}
+			for _, ns := range test.namespaces {
+				namespaceList.Items = append(namespaceList.Items, *unstructuredOrDie(ns))
+			}
+			namespaceClient := &arktest.FakeDynamicClient{}
+			namespaceClient.On("List", metav1.ListOptions{}).Return(namespaceList, nil)
+			dynamicFactory := &arktest.FakeDynamicFactory{}
+			dynamicFactory.On("ClientForGroupVersionResource",
+				schema.GroupVersion{Group: "", Version: "v1"},
+				metav1.APIResource{Name: "namespaces", Namespaced: false},
+				"",
+			).Return(namespaceClient, nil)
+
+			// nothing should be backed up, so the group backupper factory has no expectations
+			groupBackupperFactory := &mockGroupBackupperFactory{}
+			defer groupBackupperFactory.AssertExpectations(t)
+
+			kb := &kubernetesBackupper{
+				discoveryHelper:       discoveryHelper,
+				dynamicFactory:        dynamicFactory,
+				groupBackupperFactory: groupBackupperFactory,
+			}
+
+			var backupFile, logFile bytes.Buffer
+			err := kb.Backup(&v1.Backup{Spec: test.spec}, &backupFile, &logFile, nil, &Progress{})
+
+			require.Error(t, err)
+			assert.IsType(t, &NothingSelectedError{}, err)
 		})
 	}
 }
@@ -595,6 +859,9 @@ func (f *mockGroupBackupperFactory) newGroupBackupper(
 	tarWriter tarWriter,
 	resourceHooks []resourceHook,
 	snapshotService cloudprovider.SnapshotService,
+	csiSnapshotter csi.Snapshotter,
+	snapshotThrottle snapshotThrottle,
+	progress *Progress,
 ) groupBackupper {
 	args := f.Called(
 		log,
@@ -611,6 +878,9 @@ func (f *mockGroupBackupperFactory) newGroupBackupper(
 		tarWriter,
 		resourceHooks,
 		snapshotService,
+		csiSnapshotter,
+		snapshotThrottle,
+		progress,
 	)
 	return args.Get(0).(groupBackupper)
 }
@@ -777,3 +1047,41 @@ func TestGetResourceHook(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatVersionFor(t *testing.T) {
+	assert.Equal(t, 1, FormatVersionFor("", false))
+	assert.Equal(t, 1, FormatVersionFor(compression.Gzip, false))
+	assert.Equal(t, 2, FormatVersionFor(compression.Zstd, false))
+	assert.Equal(t, 2, FormatVersionFor(compression.LZ4, false))
+	assert.Equal(t, 3, FormatVersionFor(compression.Gzip, true))
+	assert.Equal(t, 3, FormatVersionFor(compression.Zstd, true))
+	assert.True(t, IsSupportedFormatVersion(FormatVersionFor(compression.Zstd, true)))
+}
+
+func TestIsSupportedFormatVersion(t *testing.T) {
+	assert.True(t, IsSupportedFormatVersion(FormatVersion))
+	assert.True(t, IsSupportedFormatVersion(FormatVersion-1))
+	assert.False(t, IsSupportedFormatVersion(FormatVersion+1))
+}
+
+func TestCountItems(t *testing.T) {
+	items := map[itemKey]struct{}{
+		{resource: "pods", namespace: "ns-1", name: "pod-1"}:                {},
+		{resource: "pods", namespace: "ns-1", name: "pod-2"}:                {},
+		{resource: "deployments.apps", namespace: "ns-1", name: "deploy-1"}: {},
+		{resource: "pods", namespace: "ns-2", name: "pod-1"}:                {},
+		{resource: "namespaces", name: "ns-1"}:                              {},
+		{resource: "persistentvolumes", name: "pv-1"}:                       {},
+		{resource: "persistentvolumes", name: "pv-2"}:                       {},
+	}
+
+	expected := &v1.BackupItemCounts{
+		Namespaces: []v1.NamespaceItemCounts{
+			{Namespace: "ns-1", Resources: map[string]int{"pods": 2, "deployments.apps": 1}},
+			{Namespace: "ns-2", Resources: map[string]int{"pods": 1}},
+		},
+		ClusterResources: map[string]int{"namespaces": 1, "persistentvolumes": 2},
+	}
+
+	assert.Equal(t, expected, countItems(items))
+}
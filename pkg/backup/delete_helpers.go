@@ -21,11 +21,14 @@ import (
 
 	"github.com/heptio/ark/pkg/apis/ark/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // NewDeleteBackupRequest creates a DeleteBackupRequest for the backup identified by name and uid.
+// If uid is set, the request is owned by the backup, so Kubernetes garbage collects it if the
+// backup is deleted first.
 func NewDeleteBackupRequest(name string, uid string) *v1.DeleteBackupRequest {
-	return &v1.DeleteBackupRequest{
+	req := &v1.DeleteBackupRequest{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: name + "-",
 			Labels: map[string]string{
@@ -37,6 +40,19 @@ func NewDeleteBackupRequest(name string, uid string) *v1.DeleteBackupRequest {
 			BackupName: name,
 		},
 	}
+
+	if uid != "" {
+		req.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: v1.SchemeGroupVersion.String(),
+				Kind:       "Backup",
+				Name:       name,
+				UID:        types.UID(uid),
+			},
+		}
+	}
+
+	return req
 }
 
 // NewDeleteBackupRequestListOptions creates a ListOptions with a label selector configured to
@@ -0,0 +1,45 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+func TestNewDeleteBackupRequest(t *testing.T) {
+	req := NewDeleteBackupRequest("foo", "uid")
+
+	assert.Equal(t, "foo-", req.GenerateName)
+	assert.Equal(t, map[string]string{v1.BackupNameLabel: "foo", v1.BackupUIDLabel: "uid"}, req.Labels)
+	assert.Equal(t, "foo", req.Spec.BackupName)
+	assert.Equal(t, []metav1.OwnerReference{
+		{
+			APIVersion: "ark.heptio.com/v1",
+			Kind:       "Backup",
+			Name:       "foo",
+			UID:        "uid",
+		},
+	}, req.OwnerReferences)
+
+	// a backup's UID is required to own the request
+	assert.Empty(t, NewDeleteBackupRequest("foo", "").OwnerReferences)
+}
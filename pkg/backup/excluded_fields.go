@@ -0,0 +1,149 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/heptio/ark/pkg/discovery"
+)
+
+// requiredFields are the fields an item can't be restored without, so they can't
+// be excluded from backups.
+var requiredFields = []string{"/apiVersion", "/kind", "/metadata", "/metadata/name", "/metadata/namespace"}
+
+// ValidateExcludedField returns an error if field isn't a JSON pointer to a field
+// that can be excluded from backed-up items.
+func ValidateExcludedField(field string) error {
+	if !strings.HasPrefix(field, "/") {
+		return errors.Errorf("%q is not a JSON pointer to a field, which must start with /", field)
+	}
+
+	for _, required := range requiredFields {
+		if field == required {
+			return errors.Errorf("%s is required to restore items, so it can't be excluded", field)
+		}
+	}
+
+	return nil
+}
+
+// resolveExcludedFields returns excludedFields keyed by the fully-qualified names of
+// its resources, as returned by schema.GroupResource's String, so they can be looked
+// up for each item. Resources that can't be resolved are kept as they were given.
+func resolveExcludedFields(excludedFields map[string][]string, helper discovery.Helper) map[string][]string {
+	resolved := make(map[string][]string, len(excludedFields))
+
+	for resource, fields := range excludedFields {
+		if resource != "*" {
+			if gvr, _, err := helper.ResourceFor(schema.ParseGroupResource(resource).WithVersion("")); err == nil {
+				groupResource := gvr.GroupResource()
+				resource = groupResource.String()
+			}
+		}
+
+		resolved[resource] = append(resolved[resource], fields...)
+	}
+
+	return resolved
+}
+
+// excludeFieldsFromJSON removes the fields, as JSON pointers, from the item encoded
+// in itemBytes, and returns the item re-encoded.
+func excludeFieldsFromJSON(log logrus.FieldLogger, itemBytes []byte, fields []string) ([]byte, error) {
+	var item map[string]interface{}
+	if err := json.Unmarshal(itemBytes, &item); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	excluded := excludeFields(item, fields)
+	if len(excluded) == 0 {
+		return itemBytes, nil
+	}
+	log.WithField("fields", excluded).Info("Excluding fields from item")
+
+	res, err := json.Marshal(item)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return res, nil
+}
+
+// excludeFields removes the fields, as JSON pointers, from item, and returns the
+// ones it had.
+func excludeFields(item map[string]interface{}, fields []string) []string {
+	var excluded []string
+
+	for _, field := range fields {
+		if !strings.HasPrefix(field, "/") {
+			continue
+		}
+
+		var tokens []string
+		for _, token := range strings.Split(field[1:], "/") {
+			tokens = append(tokens, strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1))
+		}
+
+		if _, removed := removeField(item, tokens); removed {
+			excluded = append(excluded, field)
+		}
+	}
+
+	return excluded
+}
+
+// removeField removes the field at the path given by tokens from value, returning
+// the updated value and whether the field was found.
+func removeField(value interface{}, tokens []string) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		child, found := v[tokens[0]]
+		if !found {
+			return v, false
+		}
+
+		if len(tokens) == 1 {
+			delete(v, tokens[0])
+			return v, true
+		}
+
+		updated, removed := removeField(child, tokens[1:])
+		v[tokens[0]] = updated
+		return v, removed
+	case []interface{}:
+		i, err := strconv.Atoi(tokens[0])
+		if err != nil || i < 0 || i >= len(v) {
+			return v, false
+		}
+
+		if len(tokens) == 1 {
+			return append(v[:i:i], v[i+1:]...), true
+		}
+
+		updated, removed := removeField(v[i], tokens[1:])
+		v[i] = updated
+		return v, removed
+	}
+
+	return value, false
+}
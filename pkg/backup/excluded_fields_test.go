@@ -0,0 +1,184 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/util/collections"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestValidateExcludedField(t *testing.T) {
+	tests := []struct {
+		field       string
+		expectedErr bool
+	}{
+		{field: "/status", expectedErr: false},
+		{field: "/metadata/annotations/foo~1bar", expectedErr: false},
+		{field: "/spec/containers/0/env", expectedErr: false},
+		{field: "status", expectedErr: true},
+		{field: "", expectedErr: true},
+		{field: "/apiVersion", expectedErr: true},
+		{field: "/kind", expectedErr: true},
+		{field: "/metadata", expectedErr: true},
+		{field: "/metadata/name", expectedErr: true},
+		{field: "/metadata/namespace", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.field, func(t *testing.T) {
+			err := ValidateExcludedField(test.field)
+			assert.Equal(t, test.expectedErr, err != nil, "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestResolveExcludedFields(t *testing.T) {
+	deployments := schema.GroupVersionResource{Group: "apps", Resource: "deployments"}
+
+	helper := &arktest.FakeDiscoveryHelper{
+		ResourceList: []*metav1.APIResourceList{
+			{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment", Namespaced: true}},
+			},
+			{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{{Name: "pods", Kind: "Pod", Namespaced: true}},
+			},
+		},
+		Mapper: &arktest.FakeMapper{
+			Resources: map[schema.GroupVersionResource]schema.GroupVersionResource{
+				{Resource: "deploy"}:     {Group: "apps", Version: "v1", Resource: "deployments"},
+				deployments:              {Group: "apps", Version: "v1", Resource: "deployments"},
+				{Resource: "pods"}:       {Group: "", Version: "v1", Resource: "pods"},
+				{Resource: "configmaps"}: {Group: "", Version: "v1", Resource: "configmaps"},
+			},
+		},
+	}
+
+	resolved := resolveExcludedFields(map[string][]string{
+		"*":                {"/status"},
+		"deploy":           {"/spec/replicas"},
+		"deployments.apps": {"/metadata/annotations"},
+		"pods":             {"/spec/nodeName"},
+		"unknown":          {"/spec"},
+	}, helper)
+
+	require.Len(t, resolved, 4)
+	assert.Equal(t, []string{"/status"}, resolved["*"])
+	assert.Len(t, resolved["deployments.apps"], 2)
+	assert.Contains(t, resolved["deployments.apps"], "/spec/replicas")
+	assert.Contains(t, resolved["deployments.apps"], "/metadata/annotations")
+	assert.Equal(t, []string{"/spec/nodeName"}, resolved["pods"])
+	assert.Equal(t, []string{"/spec"}, resolved["unknown"])
+}
+
+func TestExcludeFields(t *testing.T) {
+	tests := []struct {
+		name             string
+		item             string
+		fields           []string
+		expectedItem     string
+		expectedExcluded []string
+	}{
+		{
+			name:             "top-level and nested fields are removed",
+			item:             `{"metadata":{"name":"foo","annotations":{"a":"b"}},"spec":{"x":1},"status":{"y":2}}`,
+			fields:           []string{"/status", "/metadata/annotations"},
+			expectedItem:     `{"metadata":{"name":"foo"},"spec":{"x":1}}`,
+			expectedExcluded: []string{"/status", "/metadata/annotations"},
+		},
+		{
+			name:             "missing fields are ignored",
+			item:             `{"metadata":{"name":"foo"},"spec":{"x":1}}`,
+			fields:           []string{"/status", "/spec/y", "/spec/x/z", "/metadata/labels/a"},
+			expectedItem:     `{"metadata":{"name":"foo"},"spec":{"x":1}}`,
+			expectedExcluded: nil,
+		},
+		{
+			name:             "array elements and their fields are removed",
+			item:             `{"spec":{"containers":[{"name":"a","env":[1]},{"name":"b"},{"name":"c"}]}}`,
+			fields:           []string{"/spec/containers/0/env", "/spec/containers/1", "/spec/containers/5", "/spec/containers/x"},
+			expectedItem:     `{"spec":{"containers":[{"name":"a"},{"name":"c"}]}}`,
+			expectedExcluded: []string{"/spec/containers/0/env", "/spec/containers/1"},
+		},
+		{
+			name:             "escaped tokens are unescaped",
+			item:             `{"metadata":{"annotations":{"example.com/a":"1","b~c":"2","d":"3"}}}`,
+			fields:           []string{"/metadata/annotations/example.com~1a", "/metadata/annotations/b~0c"},
+			expectedItem:     `{"metadata":{"annotations":{"d":"3"}}}`,
+			expectedExcluded: []string{"/metadata/annotations/example.com~1a", "/metadata/annotations/b~0c"},
+		},
+		{
+			name:             "fields that aren't JSON pointers are ignored",
+			item:             `{"status":{"y":2}}`,
+			fields:           []string{"status"},
+			expectedItem:     `{"status":{"y":2}}`,
+			expectedExcluded: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var item map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(test.item), &item))
+
+			excluded := excludeFields(item, test.fields)
+			assert.Equal(t, test.expectedExcluded, excluded)
+
+			res, err := json.Marshal(item)
+			require.NoError(t, err)
+			assert.JSONEq(t, test.expectedItem, string(res))
+		})
+	}
+}
+
+func TestBackupItemExcludesFields(t *testing.T) {
+	w := &fakeTarWriter{}
+	ib := &defaultItemBackupper{
+		backup:          &v1.Backup{},
+		namespaces:      collections.NewIncludesExcludes(),
+		resources:       collections.NewIncludesExcludes(),
+		backedUpItems:   make(map[itemKey]struct{}),
+		tarWriter:       w,
+		itemHookHandler: &defaultItemHookHandler{},
+		excludedFields: map[string][]string{
+			"*":                {"/metadata/labels"},
+			"deployments.apps": {"/spec/replicas"},
+		},
+	}
+
+	obj := unstructuredOrDie(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"namespace":"ns","name":"d-1","labels":{"a":"b"}},"spec":{"replicas":3,"paused":true}}`)
+
+	require.NoError(t, ib.backupItem(arktest.NewLogger(), obj, schema.GroupResource{Group: "apps", Resource: "deployments"}))
+
+	require.Len(t, w.data, 1)
+	assert.JSONEq(t, `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"namespace":"ns","name":"d-1"},"spec":{"paused":true}}`, string(w.data[0]))
+	assert.Equal(t, int64(len(w.data[0])), w.headers[0].Size)
+
+	// the item itself is unchanged
+	assert.Equal(t, map[string]string{"a": "b"}, obj.GetLabels())
+}
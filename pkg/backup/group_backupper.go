@@ -30,6 +30,7 @@ import (
 	"github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cloudprovider"
+	"github.com/heptio/ark/pkg/csi"
 	"github.com/heptio/ark/pkg/discovery"
 	"github.com/heptio/ark/pkg/util/collections"
 )
@@ -49,6 +50,9 @@ type groupBackupperFactory interface {
 		tarWriter tarWriter,
 		resourceHooks []resourceHook,
 		snapshotService cloudprovider.SnapshotService,
+		csiSnapshotter csi.Snapshotter,
+		snapshotThrottle snapshotThrottle,
+		progress *Progress,
 	) groupBackupper
 }
 
@@ -68,6 +72,9 @@ func (f *defaultGroupBackupperFactory) newGroupBackupper(
 	tarWriter tarWriter,
 	resourceHooks []resourceHook,
 	snapshotService cloudprovider.SnapshotService,
+	csiSnapshotter csi.Snapshotter,
+	snapshotThrottle snapshotThrottle,
+	progress *Progress,
 ) groupBackupper {
 	return &defaultGroupBackupper{
 		log:                      log,
@@ -84,6 +91,9 @@ func (f *defaultGroupBackupperFactory) newGroupBackupper(
 		tarWriter:                tarWriter,
 		resourceHooks:            resourceHooks,
 		snapshotService:          snapshotService,
+		csiSnapshotter:           csiSnapshotter,
+		snapshotThrottle:         snapshotThrottle,
+		progress:                 progress,
 		resourceBackupperFactory: &defaultResourceBackupperFactory{},
 	}
 }
@@ -106,6 +116,9 @@ type defaultGroupBackupper struct {
 	tarWriter                tarWriter
 	resourceHooks            []resourceHook
 	snapshotService          cloudprovider.SnapshotService
+	csiSnapshotter           csi.Snapshotter
+	snapshotThrottle         snapshotThrottle
+	progress                 *Progress
 	resourceBackupperFactory resourceBackupperFactory
 }
 
@@ -129,6 +142,9 @@ func (gb *defaultGroupBackupper) backupGroup(group *metav1.APIResourceList) erro
 			gb.tarWriter,
 			gb.resourceHooks,
 			gb.snapshotService,
+			gb.csiSnapshotter,
+			gb.snapshotThrottle,
+			gb.progress,
 		)
 	)
 
@@ -22,6 +22,7 @@ import (
 	"github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cloudprovider"
+	"github.com/heptio/ark/pkg/csi"
 	"github.com/heptio/ark/pkg/discovery"
 	"github.com/heptio/ark/pkg/util/collections"
 	arktest "github.com/heptio/ark/pkg/util/test"
@@ -73,6 +74,8 @@ func TestBackupGroup(t *testing.T) {
 		{name: "myhook"},
 	}
 
+	progress := &Progress{}
+
 	gb := (&defaultGroupBackupperFactory{}).newGroupBackupper(
 		arktest.NewLogger(),
 		backup,
@@ -88,6 +91,9 @@ func TestBackupGroup(t *testing.T) {
 		tarWriter,
 		resourceHooks,
 		nil,
+		nil,
+		nil,
+		progress,
 	).(*defaultGroupBackupper)
 
 	resourceBackupperFactory := &mockResourceBackupperFactory{}
@@ -112,6 +118,9 @@ func TestBackupGroup(t *testing.T) {
 		tarWriter,
 		resourceHooks,
 		nil,
+		nil,
+		nil,
+		progress,
 	).Return(resourceBackupper)
 
 	group := &metav1.APIResourceList{
@@ -160,6 +169,9 @@ func (rbf *mockResourceBackupperFactory) newResourceBackupper(
 	tarWriter tarWriter,
 	resourceHooks []resourceHook,
 	snapshotService cloudprovider.SnapshotService,
+	csiSnapshotter csi.Snapshotter,
+	snapshotThrottle snapshotThrottle,
+	progress *Progress,
 ) resourceBackupper {
 	args := rbf.Called(
 		log,
@@ -176,6 +188,9 @@ func (rbf *mockResourceBackupperFactory) newResourceBackupper(
 		tarWriter,
 		resourceHooks,
 		snapshotService,
+		csiSnapshotter,
+		snapshotThrottle,
+		progress,
 	)
 	return args.Get(0).(resourceBackupper)
 }
@@ -0,0 +1,111 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+const (
+	// helmOwnerLabel and helmOwnerValue identify the secrets Helm uses to store
+	// release information.
+	helmOwnerLabel = "owner"
+	helmOwnerValue = "helm"
+
+	// helmReleaseNameLabel is the label on a release secret containing the
+	// name of the release.
+	helmReleaseNameLabel = "name"
+)
+
+var secretsGroupResource = schema.GroupResource{Group: "", Resource: "secrets"}
+
+// helmReleaseAction implements ItemAction.
+type helmReleaseAction struct {
+	log           logrus.FieldLogger
+	secretsGetter corev1client.SecretsGetter
+}
+
+// NewHelmReleaseAction creates a new ItemAction for Helm release secrets.
+func NewHelmReleaseAction(log logrus.FieldLogger, secretsGetter corev1client.SecretsGetter) ItemAction {
+	return &helmReleaseAction{
+		log:           log,
+		secretsGetter: secretsGetter,
+	}
+}
+
+// AppliesTo returns a ResourceSelector that applies only to secrets owned by Helm.
+func (a *helmReleaseAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"secrets"},
+		LabelSelector:     labels.SelectorFromSet(labels.Set{helmOwnerLabel: helmOwnerValue}).String(),
+	}, nil
+}
+
+// Execute looks up every revision of the Helm release that the secret belongs to and returns
+// them as additional items, so that a release's full history is always backed up together.
+func (a *helmReleaseAction) Execute(item runtime.Unstructured, backup *v1.Backup) (runtime.Unstructured, []ResourceIdentifier, error) {
+	a.log.Info("Executing helmReleaseAction")
+	defer a.log.Info("Done executing helmReleaseAction")
+
+	metadata, err := meta.Accessor(item)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to access secret metadata")
+	}
+
+	releaseName := metadata.GetLabels()[helmReleaseNameLabel]
+	if releaseName == "" {
+		a.log.Infof("Secret has no %s label, skipping", helmReleaseNameLabel)
+		return item, nil, nil
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{
+		helmOwnerLabel:       helmOwnerValue,
+		helmReleaseNameLabel: releaseName,
+	})
+
+	revisions, err := a.secretsGetter.Secrets(metadata.GetNamespace()).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error listing secrets for helm release %s", releaseName)
+	}
+
+	var additionalItems []ResourceIdentifier
+	for _, revision := range revisions.Items {
+		if revision.Name == metadata.GetName() {
+			continue
+		}
+
+		a.log.Infof("Adding secret %s for helm release %s to additionalItems", revision.Name, releaseName)
+
+		additionalItems = append(additionalItems, ResourceIdentifier{
+			GroupResource: secretsGroupResource,
+			Namespace:     revision.Namespace,
+			Name:          revision.Name,
+		})
+	}
+
+	return item, additionalItems, nil
+}
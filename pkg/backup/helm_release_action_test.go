@@ -0,0 +1,121 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestHelmReleaseActionAppliesTo(t *testing.T) {
+	a := NewHelmReleaseAction(arktest.NewLogger(), nil)
+
+	actual, err := a.AppliesTo()
+	require.NoError(t, err)
+
+	expected := ResourceSelector{
+		IncludedResources: []string{"secrets"},
+		LabelSelector:     "owner=helm",
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestHelmReleaseActionExecute(t *testing.T) {
+	tests := []struct {
+		name            string
+		secret          string
+		existing        []corev1api.Secret
+		expectedLister  bool
+		expectedItems   []ResourceIdentifier
+		expectedListSel string
+	}{
+		{
+			name:   "secret without a release name label is returned unchanged",
+			secret: `{"apiVersion":"v1","kind":"Secret","metadata":{"namespace":"ns","name":"sh.helm.release.v1.foo.v1","labels":{"owner":"helm"}}}`,
+		},
+		{
+			name:   "all other revisions of the release are added",
+			secret: `{"apiVersion":"v1","kind":"Secret","metadata":{"namespace":"ns","name":"sh.helm.release.v1.foo.v2","labels":{"owner":"helm","name":"foo"}}}`,
+			existing: []corev1api.Secret{
+				{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "sh.helm.release.v1.foo.v1"}},
+				{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "sh.helm.release.v1.foo.v2"}},
+				{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "sh.helm.release.v1.foo.v3"}},
+			},
+			expectedLister:  true,
+			expectedListSel: "name=foo,owner=helm",
+			expectedItems: []ResourceIdentifier{
+				{GroupResource: secretsGroupResource, Namespace: "ns", Name: "sh.helm.release.v1.foo.v1"},
+				{GroupResource: secretsGroupResource, Namespace: "ns", Name: "sh.helm.release.v1.foo.v3"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secrets := &fakeSecretsClient{items: test.existing}
+			a := NewHelmReleaseAction(arktest.NewLogger(), &fakeSecretsGetter{client: secrets})
+
+			item := unstructuredOrDie(test.secret)
+			updated, additionalItems, err := a.Execute(item, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, item, updated)
+			assert.Equal(t, test.expectedItems, additionalItems)
+
+			if test.expectedLister {
+				require.Len(t, secrets.listOptions, 1)
+				selector, err := labels.Parse(secrets.listOptions[0].LabelSelector)
+				require.NoError(t, err)
+				assert.Equal(t, test.expectedListSel, selector.String())
+				assert.Equal(t, "ns", secrets.namespace)
+			} else {
+				assert.Empty(t, secrets.listOptions)
+			}
+		})
+	}
+}
+
+type fakeSecretsGetter struct {
+	client *fakeSecretsClient
+}
+
+func (g *fakeSecretsGetter) Secrets(namespace string) corev1client.SecretInterface {
+	g.client.namespace = namespace
+	return g.client
+}
+
+type fakeSecretsClient struct {
+	namespace   string
+	items       []corev1api.Secret
+	listOptions []metav1.ListOptions
+
+	corev1client.SecretInterface
+}
+
+func (c *fakeSecretsClient) List(options metav1.ListOptions) (*corev1api.SecretList, error) {
+	c.listOptions = append(c.listOptions, options)
+	return &corev1api.SecretList{Items: c.items}, nil
+}
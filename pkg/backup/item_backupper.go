@@ -20,20 +20,25 @@ import (
 	"archive/tar"
 	"encoding/json"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cloudprovider"
+	"github.com/heptio/ark/pkg/csi"
 	"github.com/heptio/ark/pkg/discovery"
 	"github.com/heptio/ark/pkg/util/collections"
 	"github.com/heptio/ark/pkg/util/logging"
@@ -51,6 +56,8 @@ type itemBackupperFactory interface {
 		dynamicFactory client.DynamicFactory,
 		discoveryHelper discovery.Helper,
 		snapshotService cloudprovider.SnapshotService,
+		csiSnapshotter csi.Snapshotter,
+		snapshotThrottle snapshotThrottle,
 	) ItemBackupper
 }
 
@@ -67,20 +74,26 @@ func (f *defaultItemBackupperFactory) newItemBackupper(
 	dynamicFactory client.DynamicFactory,
 	discoveryHelper discovery.Helper,
 	snapshotService cloudprovider.SnapshotService,
+	csiSnapshotter csi.Snapshotter,
+	snapshotThrottle snapshotThrottle,
 ) ItemBackupper {
 	ib := &defaultItemBackupper{
-		backup:          backup,
-		namespaces:      namespaces,
-		resources:       resources,
-		backedUpItems:   backedUpItems,
-		actions:         actions,
-		tarWriter:       tarWriter,
-		resourceHooks:   resourceHooks,
-		dynamicFactory:  dynamicFactory,
-		discoveryHelper: discoveryHelper,
-		snapshotService: snapshotService,
+		backup:           backup,
+		namespaces:       namespaces,
+		resources:        resources,
+		backedUpItems:    backedUpItems,
+		actions:          actions,
+		tarWriter:        tarWriter,
+		resourceHooks:    resourceHooks,
+		dynamicFactory:   dynamicFactory,
+		discoveryHelper:  discoveryHelper,
+		snapshotService:  snapshotService,
+		csiSnapshotter:   csiSnapshotter,
+		snapshotThrottle: snapshotThrottle,
+		excludedFields:   resolveExcludedFields(backup.Spec.ExcludedFields, discoveryHelper),
 		itemHookHandler: &defaultItemHookHandler{
 			podCommandExecutor: podCommandExecutor,
+			backup:             backup,
 		},
 	}
 
@@ -95,16 +108,22 @@ type ItemBackupper interface {
 }
 
 type defaultItemBackupper struct {
-	backup          *api.Backup
-	namespaces      *collections.IncludesExcludes
-	resources       *collections.IncludesExcludes
-	backedUpItems   map[itemKey]struct{}
-	actions         []resolvedAction
-	tarWriter       tarWriter
-	resourceHooks   []resourceHook
-	dynamicFactory  client.DynamicFactory
-	discoveryHelper discovery.Helper
-	snapshotService cloudprovider.SnapshotService
+	backup           *api.Backup
+	namespaces       *collections.IncludesExcludes
+	resources        *collections.IncludesExcludes
+	backedUpItems    map[itemKey]struct{}
+	actions          []resolvedAction
+	tarWriter        tarWriter
+	resourceHooks    []resourceHook
+	dynamicFactory   client.DynamicFactory
+	discoveryHelper  discovery.Helper
+	snapshotService  cloudprovider.SnapshotService
+	csiSnapshotter   csi.Snapshotter
+	snapshotThrottle snapshotThrottle
+
+	// excludedFields are the fields removed from items of each resource, keyed
+	// by fully-qualified resource name, or "*" for every resource.
+	excludedFields map[string][]string
 
 	itemHookHandler         itemHookHandler
 	additionalItemBackupper ItemBackupper
@@ -148,6 +167,34 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 		return nil
 	}
 
+	if groupResource == secretsGroupResource && !ib.backup.Spec.IncludeServiceAccountTokens && isAutoGeneratedServiceAccountToken(obj, metadata) {
+		log.Info("Excluding item because it's an auto-generated service account token and backup.spec.includeServiceAccountTokens is false")
+		return nil
+	}
+
+	if groupResource == secretsGroupResource && isExcludedSecret(metadata, ib.backup) {
+		log.Info("Excluding item because it matches backup.spec.excludedSecretAnnotations or backup.spec.excludedSecretOwnerKinds")
+		return nil
+	}
+
+	if csi.IsBackupVolumeSnapshot(groupResource, metadata) {
+		log.Info("Excluding item because it's a VolumeSnapshot Ark created for a backup")
+		return nil
+	}
+
+	if owner, found := excludedOwner(metadata, ib.backup.Spec.ExcludedOwnerKinds); found {
+		log.Infof("Excluding item because its owner %s %s matches backup.spec.excludedOwnerKinds", owner.Kind, owner.Name)
+		return nil
+	}
+
+	if window := ib.backup.Spec.ModifiedSince.Duration; window > 0 && groupResource != namespacesGroupResource {
+		cutoff := ib.backup.CreationTimestamp.Add(-window)
+		if modified, found := lastModified(obj); found && modified.Before(cutoff) {
+			log.Infof("Excluding item because it was last modified at %s, before backup.spec.modifiedSince", modified.Format(time.RFC3339))
+			return nil
+		}
+	}
+
 	key := itemKey{
 		resource:  groupResource.String(),
 		namespace: namespace,
@@ -224,7 +271,7 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 	}
 
 	if groupResource == pvGroupResource {
-		if ib.snapshotService == nil {
+		if ib.snapshotService == nil && ib.csiSnapshotter == nil {
 			log.Debug("Skipping Persistent Volume snapshot because they're not enabled.")
 		} else {
 			if err := ib.takePVSnapshot(obj, ib.backup, log); err != nil {
@@ -250,6 +297,17 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 		return errors.WithStack(err)
 	}
 
+	var fields []string
+	fields = append(fields, ib.excludedFields["*"]...)
+	fields = append(fields, ib.excludedFields[groupResource.String()]...)
+	if len(fields) > 0 {
+		// fields are excluded from a copy of the item, so the item itself is unchanged
+		// for the rest of the backup, e.g. for backing up its owners
+		if itemBytes, err = excludeFieldsFromJSON(log, itemBytes, fields); err != nil {
+			return err
+		}
+	}
+
 	hdr := &tar.Header{
 		Name:     filePath,
 		Size:     int64(len(itemBytes)),
@@ -266,16 +324,236 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 		return errors.WithStack(err)
 	}
 
+	if ib.backup.Spec.IncludeOwnerReferences {
+		if err := ib.backupOwners(log, metadata); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backupOwners backs up the objects listed in the item's owner references, whether or not they
+// match the backup's label selector. Since backing up an owner backs up its own owners, this
+// includes the item's whole chain of owners; owners that are already in the backup are skipped,
+// so cycles end. Each owner that's backed up is recorded in the backup's status.
+func (ib *defaultItemBackupper) backupOwners(log logrus.FieldLogger, metadata metav1.Object) error {
+	for _, ref := range metadata.GetOwnerReferences() {
+		ownerLog := log.WithFields(logrus.Fields{
+			"ownerKind": ref.Kind,
+			"ownerName": ref.Name,
+		})
+
+		gvr, resource, err := ib.resourceForOwner(ref)
+		if err != nil {
+			ownerLog.WithError(err).Warn("Skipping owner of item because its resource can't be found")
+			continue
+		}
+
+		var namespace string
+		if resource.Namespaced {
+			namespace = metadata.GetNamespace()
+		}
+
+		groupResource := gvr.GroupResource()
+		key := itemKey{
+			resource:  groupResource.String(),
+			namespace: namespace,
+			name:      ref.Name,
+		}
+		if _, exists := ib.backedUpItems[key]; exists {
+			continue
+		}
+
+		client, err := ib.dynamicFactory.ClientForGroupVersionResource(gvr.GroupVersion(), resource, namespace)
+		if err != nil {
+			return err
+		}
+
+		owner, err := client.Get(ref.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			ownerLog.Warn("Skipping owner of item because it no longer exists")
+			continue
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		ownerLog.Info("Backing up owner of item because backup.spec.includeOwnerReferences is true")
+		if err := ib.additionalItemBackupper.backupItem(log, owner, groupResource); err != nil {
+			return err
+		}
+
+		// the owner may have been excluded by the backup's other settings
+		if _, backedUp := ib.backedUpItems[key]; backedUp {
+			ib.backup.Status.IncludedOwners = append(ib.backup.Status.IncludedOwners, filepath.Join(key.resource, namespace, ref.Name))
+		}
+	}
+
 	return nil
 }
 
+// resourceForOwner returns the resource of the kind that ref refers to, in the group of its API
+// version.
+func (ib *defaultItemBackupper) resourceForOwner(ref metav1.OwnerReference) (schema.GroupVersionResource, metav1.APIResource, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, metav1.APIResource{}, errors.WithStack(err)
+	}
+
+	for _, resourceList := range ib.discoveryHelper.Resources() {
+		listGV, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil || listGV.Group != gv.Group {
+			continue
+		}
+
+		for _, resource := range resourceList.APIResources {
+			// subresources share their parent resource's kind
+			if resource.Kind == ref.Kind && !strings.Contains(resource.Name, "/") {
+				return ib.discoveryHelper.ResourceFor(listGV.WithResource(resource.Name))
+			}
+		}
+	}
+
+	return schema.GroupVersionResource{}, metav1.APIResource{}, errors.Errorf("no resource found for kind %s in API version %s", ref.Kind, ref.APIVersion)
+}
+
+// isSelectedForSnapshot returns true if backup has no volume snapshot selector, or if pv or
+// the PersistentVolumeClaim it's bound to matches it.
+func (ib *defaultItemBackupper) isSelectedForSnapshot(pv runtime.Unstructured, backup *api.Backup) (bool, error) {
+	if backup.Spec.VolumeSnapshotSelector == nil {
+		return true, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(backup.Spec.VolumeSnapshotSelector)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	metadata, err := meta.Accessor(pv)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if selector.Matches(labels.Set(metadata.GetLabels())) {
+		return true, nil
+	}
+
+	claimNamespace, _ := collections.GetString(pv.UnstructuredContent(), "spec.claimRef.namespace")
+	claimName, _ := collections.GetString(pv.UnstructuredContent(), "spec.claimRef.name")
+	if claimName == "" {
+		return false, nil
+	}
+
+	gvr, resource, err := ib.discoveryHelper.ResourceFor(pvcGroupResource.WithVersion(""))
+	if err != nil {
+		return false, err
+	}
+
+	client, err := ib.dynamicFactory.ClientForGroupVersionResource(gvr.GroupVersion(), resource, claimNamespace)
+	if err != nil {
+		return false, err
+	}
+
+	pvc, err := client.Get(claimName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	pvcMetadata, err := meta.Accessor(pvc)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return selector.Matches(labels.Set(pvcMetadata.GetLabels())), nil
+}
+
+// isAutoGeneratedServiceAccountToken returns true if obj is a service account token secret
+// that was created by Kubernetes' token controller, which names them
+// "<service account name>-token-<random suffix>". The controller creates them again for
+// service accounts that don't have one, so they don't need to be restored.
+func isAutoGeneratedServiceAccountToken(obj runtime.Unstructured, metadata metav1.Object) bool {
+	if secretType, _ := obj.UnstructuredContent()["type"].(string); secretType != string(corev1api.SecretTypeServiceAccountToken) {
+		return false
+	}
+
+	serviceAccount := metadata.GetAnnotations()[corev1api.ServiceAccountNameKey]
+	return serviceAccount != "" && strings.HasPrefix(metadata.GetName(), serviceAccount+"-token-")
+}
+
+// isExcludedSecret returns true if the secret with the specified metadata has one of the
+// backup's excluded secret annotations, or an owner of one of its excluded secret owner kinds.
+func isExcludedSecret(metadata metav1.Object, backup *api.Backup) bool {
+	annotations := metadata.GetAnnotations()
+	for key, value := range backup.Spec.ExcludedSecretAnnotations {
+		if actual, found := annotations[key]; found && (value == "" || value == actual) {
+			return true
+		}
+	}
+
+	_, found := excludedOwner(metadata, backup.Spec.ExcludedSecretOwnerKinds)
+	return found
+}
+
+// excludedOwner returns the first of the owners of the item with the specified metadata
+// that's of one of kinds, each either a kind or a kind qualified by its API group, and
+// whether one was found.
+func excludedOwner(metadata metav1.Object, kinds []string) (metav1.OwnerReference, bool) {
+	for _, owner := range metadata.GetOwnerReferences() {
+		group := ""
+		if gv, err := schema.ParseGroupVersion(owner.APIVersion); err == nil {
+			group = gv.Group
+		}
+
+		for _, kind := range kinds {
+			if kind == owner.Kind || (group != "" && kind == owner.Kind+"."+group) {
+				return owner, true
+			}
+		}
+	}
+
+	return metav1.OwnerReference{}, false
+}
+
+// lastModified returns the latest time recorded in obj's managedFields, and whether one was
+// found. Items without managedFields, which Kubernetes only records from v1.18, have no reliable
+// modification time: their creationTimestamp doesn't change when they're edited, and their
+// resourceVersion doesn't correspond to a time.
+func lastModified(obj runtime.Unstructured) (time.Time, bool) {
+	var latest time.Time
+
+	managedFields, _ := collections.GetSlice(obj.UnstructuredContent(), "metadata.managedFields")
+	for _, entry := range managedFields {
+		fields, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		value, ok := fields["time"].(string)
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			continue
+		}
+		if t.After(latest) {
+			latest = t
+		}
+	}
+
+	return latest, !latest.IsZero()
+}
+
 // zoneLabel is the label that stores availability-zone info
 // on PVs
 const zoneLabel = "failure-domain.beta.kubernetes.io/zone"
 
 // takePVSnapshot triggers a snapshot for the volume/disk underlying a PersistentVolume if the provided
 // backup has volume snapshots enabled and the PV is of a compatible type. Also records cloud
-// disk type and IOPS (if applicable) to be able to restore to current state later.
+// disk type and IOPS (if applicable) to be able to restore to current state later. Volumes of
+// CSI drivers are snapshotted with the CSI snapshot API instead, if it's enabled.
 func (ib *defaultItemBackupper) takePVSnapshot(pv runtime.Unstructured, backup *api.Backup, log logrus.FieldLogger) error {
 	log.Info("Executing takePVSnapshot")
 
@@ -284,6 +562,15 @@ func (ib *defaultItemBackupper) takePVSnapshot(pv runtime.Unstructured, backup *
 		return nil
 	}
 
+	selected, err := ib.isSelectedForSnapshot(pv, backup)
+	if err != nil {
+		return err
+	}
+	if !selected {
+		log.Info("PersistentVolume doesn't match the backup's volume snapshot selector; skipping volume snapshot action.")
+		return nil
+	}
+
 	metadata, err := meta.Accessor(pv)
 	if err != nil {
 		return errors.WithStack(err)
@@ -299,24 +586,94 @@ func (ib *defaultItemBackupper) takePVSnapshot(pv runtime.Unstructured, backup *
 		log.Infof("label %q is not present on PersistentVolume", zoneLabel)
 	}
 
-	volumeID, err := ib.snapshotService.GetVolumeID(pv)
+	var csiDriver, volumeID string
+	if ib.csiSnapshotter != nil {
+		csiDriver = csi.Driver(pv)
+	}
+
+	if csiDriver != "" {
+		if claimName, _ := collections.GetString(pv.UnstructuredContent(), "spec.claimRef.name"); claimName == "" {
+			log.Info("PersistentVolume of a CSI driver isn't bound to a claim, so it can't be snapshotted with the CSI snapshot API, skipping.")
+			return nil
+		}
+
+		log = log.WithField("csiDriver", csiDriver)
+	} else {
+		if ib.snapshotService == nil {
+			log.Info("PersistentVolume is not a CSI volume and no PersistentVolumeProvider is configured, skipping.")
+			return nil
+		}
+
+		volumeID, err = ib.snapshotService.GetVolumeID(pv)
+		if err != nil {
+			return errors.Wrapf(err, "error getting volume ID for PersistentVolume")
+		}
+		if volumeID == "" {
+			log.Info("PersistentVolume is not a supported volume type for snapshots, skipping.")
+			return nil
+		}
+
+		log = log.WithField("volumeID", volumeID)
+	}
+
+	// Ark's own tags take precedence over the backup's labels and annotations
+	tags := backupSnapshotTags(backup)
+	tags["ark.heptio.com/backup"] = backup.Name
+	tags["ark.heptio.com/pv"] = metadata.GetName()
+
+	pods, err := ib.podsUsingVolume(pv)
 	if err != nil {
-		return errors.Wrapf(err, "error getting volume ID for PersistentVolume")
+		return err
 	}
-	if volumeID == "" {
-		log.Info("PersistentVolume is not a supported volume type for snapshots, skipping.")
-		return nil
+
+	// a volume in a consistency group is snapshotted along with the rest of the group, so
+	// the hooks of the pods using any of the group's claims run around the group's snapshot
+	if groups, ok := ib.snapshotService.(*groupSnapshotService); ok && volumeID != "" {
+		group, snapshotted := groups.groupState(volumeID)
+		switch {
+		case snapshotted:
+			// it was snapshotted while its pods' hooks ran for the group
+			pods = nil
+		case group != nil:
+			log = log.WithField("volumeGroup", group.name)
+			if pods, err = ib.podsUsingClaims(group.namespace, group.claims); err != nil {
+				return err
+			}
+		}
 	}
 
-	log = log.WithField("volumeID", volumeID)
+	// the throttle is waited on before the pre-snapshot hooks run, so volumes aren't left
+	// frozen while waiting for their turn
+	release := func() {}
+	if ib.snapshotThrottle != nil {
+		storageClass, _ := collections.GetString(pv.UnstructuredContent(), "spec.storageClassName")
+		log.WithField("storageClass", storageClass).Debug("Waiting to snapshot PersistentVolume")
+		release = ib.snapshotThrottle.acquire(storageClass)
+	}
 
-	tags := map[string]string{
-		"ark.heptio.com/backup": backup.Name,
-		"ark.heptio.com/pv":     metadata.GetName(),
+	// Pre-snapshot hooks, e.g. to freeze filesystems, run immediately before the snapshot is
+	// taken. Post-snapshot hooks run right after it, even if it or the pre-snapshot hooks fail,
+	// so that nothing is left frozen.
+	if err := ib.handleSnapshotHooks(log, pods, hookPhasePreSnapshot); err != nil {
+		release()
+		ib.handleSnapshotHooks(log, pods, hookPhasePostSnapshot)
+		return err
 	}
 
 	log.Info("Snapshotting PersistentVolume")
-	snapshotID, err := ib.snapshotService.CreateSnapshot(volumeID, pvFailureDomainZone, tags)
+	if csiDriver != "" {
+		err := ib.takeCSISnapshot(pv, name, backup, log)
+		release()
+		postSnapshotHookErr := ib.handleSnapshotHooks(log, pods, hookPhasePostSnapshot)
+		if err != nil {
+			return err
+		}
+		return postSnapshotHookErr
+	}
+
+	snapshotID, parentSnapshotID, err := ib.snapshotService.CreateSnapshot(volumeID, pvFailureDomainZone, tags)
+	release()
+	postSnapshotHookErr := ib.handleSnapshotHooks(log, pods, hookPhasePostSnapshot)
 	if err != nil {
 		// log+error on purpose - log goes to the per-backup log file, error goes to the backup
 		log.WithError(err).Error("error creating snapshot")
@@ -333,12 +690,157 @@ func (ib *defaultItemBackupper) takePVSnapshot(pv runtime.Unstructured, backup *
 		backup.Status.VolumeBackups = make(map[string]*api.VolumeBackupInfo)
 	}
 
+	if snapshotID == "" {
+		// recorded anyway so the backup controller can tell the volume wasn't snapshotted
+		log.Error("Block store returned an empty snapshot ID without an error")
+	}
+
+	if parentSnapshotID != "" {
+		log.WithField("parentSnapshotID", parentSnapshotID).Info("Snapshot is incremental")
+	}
+
 	backup.Status.VolumeBackups[name] = &api.VolumeBackupInfo{
 		SnapshotID:       snapshotID,
+		ParentSnapshotID: parentSnapshotID,
 		Type:             volumeType,
 		Iops:             iops,
 		AvailabilityZone: pvFailureDomainZone,
 	}
 
+	// the snapshot is recorded even if a post-snapshot hook failed, so it isn't orphaned
+	return postSnapshotHookErr
+}
+
+// takeCSISnapshot snapshots pv, named name, with the CSI snapshot API and records the snapshot,
+// with its snapshot handle as its snapshot ID, on the backup.
+func (ib *defaultItemBackupper) takeCSISnapshot(pv runtime.Unstructured, name string, backup *api.Backup, log logrus.FieldLogger) error {
+	snapshot, err := ib.csiSnapshotter.CreateSnapshot(pv, map[string]string{api.BackupNameLabel: backup.Name})
+	if err != nil {
+		// log+error on purpose - log goes to the per-backup log file, error goes to the backup
+		log.WithError(err).Error("error creating CSI snapshot")
+		return errors.WithMessage(err, "error creating CSI snapshot")
+	}
+
+	log.WithFields(logrus.Fields{
+		"volumeSnapshot": snapshot.VolumeSnapshotNamespace + "/" + snapshot.VolumeSnapshotName,
+		"snapshotHandle": snapshot.SnapshotHandle,
+	}).Info("Snapshotted PersistentVolume with the CSI snapshot API")
+
+	if backup.Status.VolumeBackups == nil {
+		backup.Status.VolumeBackups = make(map[string]*api.VolumeBackupInfo)
+	}
+
+	backup.Status.VolumeBackups[name] = &api.VolumeBackupInfo{
+		SnapshotID:  snapshot.SnapshotHandle,
+		CSISnapshot: snapshot,
+	}
+
 	return nil
 }
+
+// podsUsingVolume returns the running pods that mount pv through its PersistentVolumeClaim.
+func (ib *defaultItemBackupper) podsUsingVolume(pv runtime.Unstructured) ([]runtime.Unstructured, error) {
+	claimNamespace, _ := collections.GetString(pv.UnstructuredContent(), "spec.claimRef.namespace")
+	claimName, _ := collections.GetString(pv.UnstructuredContent(), "spec.claimRef.name")
+	if claimName == "" {
+		return nil, nil
+	}
+
+	return ib.podsUsingClaims(claimNamespace, []string{claimName})
+}
+
+// podsUsingClaims returns the running pods in claimNamespace that mount any of the
+// PersistentVolumeClaims named claimNames.
+func (ib *defaultItemBackupper) podsUsingClaims(claimNamespace string, claimNames []string) ([]runtime.Unstructured, error) {
+	claims := sets.NewString(claimNames...)
+
+	gvr, resource, err := ib.discoveryHelper.ResourceFor(podsGroupResource.WithVersion(""))
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := ib.dynamicFactory.ClientForGroupVersionResource(gvr.GroupVersion(), resource, claimNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := client.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var pods []runtime.Unstructured
+	for _, item := range items {
+		pod, ok := item.(runtime.Unstructured)
+		if !ok {
+			return nil, errors.Errorf("unexpected type %T", item)
+		}
+
+		if phase, _ := collections.GetString(pod.UnstructuredContent(), "status.phase"); phase != string(corev1api.PodRunning) {
+			continue
+		}
+
+		volumes, err := collections.GetSlice(pod.UnstructuredContent(), "spec.volumes")
+		if err != nil {
+			continue
+		}
+
+		for _, volume := range volumes {
+			volumeMap, ok := volume.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if name, _ := collections.GetString(volumeMap, "persistentVolumeClaim.claimName"); claims.Has(name) {
+				pods = append(pods, pod)
+				break
+			}
+		}
+	}
+
+	return pods, nil
+}
+
+// handleSnapshotHooks runs the hooks for phase on each of pods, returning the first error from a
+// hook that's configured to fail the backup.
+func (ib *defaultItemBackupper) handleSnapshotHooks(log logrus.FieldLogger, pods []runtime.Unstructured, phase hookPhase) error {
+	var firstErr error
+
+	for _, pod := range pods {
+		if err := ib.itemHookHandler.handleHooks(log, podsGroupResource, pod, ib.resourceHooks, phase); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+const (
+	// maxSnapshotTagKeyLength and maxSnapshotTagValueLength are the longest keys and
+	// values the cloud providers allow in tags.
+	maxSnapshotTagKeyLength   = 128
+	maxSnapshotTagValueLength = 256
+)
+
+// backupSnapshotTags returns the tags copied from the backup onto its snapshots so they can
+// be attributed outside of Ark: its labels, and its annotations that are short enough to be
+// tags, which the labels take precedence over. Annotations like kubectl's last-applied
+// configuration are usually too long, so they're skipped rather than failing the snapshot.
+func backupSnapshotTags(backup *api.Backup) map[string]string {
+	tags := make(map[string]string, len(backup.Annotations)+len(backup.Labels)+2)
+	for k, v := range backup.Annotations {
+		if len(k) > maxSnapshotTagKeyLength || len(v) > maxSnapshotTagValueLength {
+			continue
+		}
+		tags[k] = v
+	}
+	for k, v := range backup.Labels {
+		tags[k] = v
+	}
+
+	return tags
+}
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -33,6 +34,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -94,6 +96,7 @@ func TestBackupItemSkips(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
 			ib := &defaultItemBackupper{
+				backup:        &v1.Backup{},
 				namespaces:    test.namespaces,
 				resources:     test.resources,
 				backedUpItems: test.backedUpItems,
@@ -123,6 +126,390 @@ func TestBackupItemSkipsClusterScopedResourceWhenIncludeClusterResourcesFalse(t
 	assert.NoError(t, err)
 }
 
+func TestBackupItemSkipsAutoGeneratedServiceAccountTokens(t *testing.T) {
+	backedUpItems := make(map[itemKey]struct{})
+	ib := &defaultItemBackupper{
+		backup:        &v1.Backup{},
+		namespaces:    collections.NewIncludesExcludes(),
+		resources:     collections.NewIncludesExcludes(),
+		backedUpItems: backedUpItems,
+	}
+
+	u := unstructuredOrDie(`{"apiVersion":"v1","kind":"Secret","type":"kubernetes.io/service-account-token","metadata":{"namespace":"ns","name":"default-token-abcde","annotations":{"kubernetes.io/service-account.name":"default"}}}`)
+	err := ib.backupItem(arktest.NewLogger(), u, schema.GroupResource{Resource: "secrets"})
+	assert.NoError(t, err)
+	assert.Empty(t, backedUpItems)
+}
+
+func TestBackupItemSkipsExcludedSecrets(t *testing.T) {
+	backedUpItems := make(map[itemKey]struct{})
+	ib := &defaultItemBackupper{
+		backup: &v1.Backup{
+			Spec: v1.BackupSpec{
+				ExcludedSecretOwnerKinds: []string{"ExternalSecret"},
+			},
+		},
+		namespaces:    collections.NewIncludesExcludes(),
+		resources:     collections.NewIncludesExcludes(),
+		backedUpItems: backedUpItems,
+	}
+
+	u := unstructuredOrDie(`{"apiVersion":"v1","kind":"Secret","type":"Opaque","metadata":{"namespace":"ns","name":"s-1","ownerReferences":[{"kind":"ExternalSecret","name":"es-1"}]}}`)
+	err := ib.backupItem(arktest.NewLogger(), u, schema.GroupResource{Resource: "secrets"})
+	assert.NoError(t, err)
+	assert.Empty(t, backedUpItems)
+}
+
+func TestBackupItemSkipsBackupVolumeSnapshots(t *testing.T) {
+	backedUpItems := make(map[itemKey]struct{})
+	ib := &defaultItemBackupper{
+		backup:        &v1.Backup{},
+		namespaces:    collections.NewIncludesExcludes(),
+		resources:     collections.NewIncludesExcludes(),
+		backedUpItems: backedUpItems,
+	}
+
+	u := unstructuredOrDie(`{"apiVersion":"snapshot.storage.k8s.io/v1","kind":"VolumeSnapshot","metadata":{"namespace":"ns","name":"vs-1","labels":{"ark.heptio.com/backup-name":"backup-1"}}}`)
+	err := ib.backupItem(arktest.NewLogger(), u, schema.GroupResource{Group: "snapshot.storage.k8s.io", Resource: "volumesnapshots"})
+	assert.NoError(t, err)
+	assert.Empty(t, backedUpItems)
+}
+
+func TestBackupItemSkipsItemsNotModifiedSince(t *testing.T) {
+	backedUpItems := make(map[itemKey]struct{})
+	ib := &defaultItemBackupper{
+		backup: &v1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				CreationTimestamp: metav1.NewTime(time.Date(2018, 4, 5, 20, 0, 0, 0, time.UTC)),
+			},
+			Spec: v1.BackupSpec{
+				ModifiedSince: metav1.Duration{Duration: time.Hour},
+			},
+		},
+		namespaces:    collections.NewIncludesExcludes(),
+		resources:     collections.NewIncludesExcludes(),
+		backedUpItems: backedUpItems,
+	}
+
+	u := unstructuredOrDie(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"ns","name":"cm","managedFields":[{"manager":"kubectl","time":"2018-04-05T18:00:00Z"}]}}`)
+	err := ib.backupItem(arktest.NewLogger(), u, schema.GroupResource{Resource: "configmaps"})
+	assert.NoError(t, err)
+	assert.Empty(t, backedUpItems)
+}
+
+func TestBackupItemIncludesItemsWithoutManagedFields(t *testing.T) {
+	backedUpItems := make(map[itemKey]struct{})
+	w := &fakeTarWriter{}
+	ib := &defaultItemBackupper{
+		backup: &v1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				CreationTimestamp: metav1.NewTime(time.Date(2018, 4, 5, 20, 0, 0, 0, time.UTC)),
+			},
+			Spec: v1.BackupSpec{
+				ModifiedSince: metav1.Duration{Duration: time.Hour},
+			},
+		},
+		namespaces:      collections.NewIncludesExcludes(),
+		resources:       collections.NewIncludesExcludes(),
+		backedUpItems:   backedUpItems,
+		tarWriter:       w,
+		itemHookHandler: &defaultItemHookHandler{},
+	}
+
+	// created long before the window, and edited within it on a cluster that doesn't record managedFields
+	u := unstructuredOrDie(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"ns","name":"cm","creationTimestamp":"2018-01-01T00:00:00Z","resourceVersion":"12345"},"data":{"edited":"2018-04-05T19:30:00Z"}}`)
+	err := ib.backupItem(arktest.NewLogger(), u, schema.GroupResource{Resource: "configmaps"})
+	assert.NoError(t, err)
+	assert.Len(t, backedUpItems, 1)
+	require.Len(t, w.headers, 1)
+	assert.Equal(t, "resources/configmaps/namespaces/ns/cm.json", w.headers[0].Name)
+}
+
+func TestLastModified(t *testing.T) {
+	tests := []struct {
+		name          string
+		obj           string
+		expected      time.Time
+		expectedFound bool
+	}{
+		{
+			name:     "no managedFields, despite a creationTimestamp",
+			obj:      `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm","creationTimestamp":"2018-04-05T18:00:00Z"}}`,
+			expected: time.Time{},
+		},
+		{
+			name:     "no managedFields or creationTimestamp",
+			obj:      `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"}}`,
+			expected: time.Time{},
+		},
+		{
+			name:          "latest managedFields time",
+			obj:           `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm","managedFields":[{"manager":"a","time":"2018-04-05T18:00:00Z"},{"manager":"b","time":"2018-04-05T19:00:00Z"},{"manager":"c"}]}}`,
+			expected:      time.Date(2018, 4, 5, 19, 0, 0, 0, time.UTC),
+			expectedFound: true,
+		},
+		{
+			name:     "managedFields without times",
+			obj:      `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm","managedFields":[{"manager":"a"}]}}`,
+			expected: time.Time{},
+		},
+		{
+			name:          "managedFields take precedence over creationTimestamp",
+			obj:           `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm","creationTimestamp":"2018-04-05T18:00:00Z","managedFields":[{"manager":"a","time":"2018-04-05T19:00:00Z"}]}}`,
+			expected:      time.Date(2018, 4, 5, 19, 0, 0, 0, time.UTC),
+			expectedFound: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			modified, found := lastModified(unstructuredOrDie(test.obj))
+			assert.Equal(t, test.expectedFound, found)
+			assert.True(t, test.expected.Equal(modified), "expected %s, got %s", test.expected, modified)
+		})
+	}
+}
+
+func TestIsAutoGeneratedServiceAccountToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		expected bool
+	}{
+		{
+			name:     "auto-generated token",
+			secret:   `{"apiVersion":"v1","kind":"Secret","type":"kubernetes.io/service-account-token","metadata":{"name":"default-token-abcde","annotations":{"kubernetes.io/service-account.name":"default"}}}`,
+			expected: true,
+		},
+		{
+			name:   "token created by a user",
+			secret: `{"apiVersion":"v1","kind":"Secret","type":"kubernetes.io/service-account-token","metadata":{"name":"ci-credentials","annotations":{"kubernetes.io/service-account.name":"default"}}}`,
+		},
+		{
+			name:   "token without a service account",
+			secret: `{"apiVersion":"v1","kind":"Secret","type":"kubernetes.io/service-account-token","metadata":{"name":"default-token-abcde"}}`,
+		},
+		{
+			name:   "opaque secret",
+			secret: `{"apiVersion":"v1","kind":"Secret","type":"Opaque","metadata":{"name":"default-token-abcde","annotations":{"kubernetes.io/service-account.name":"default"}}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := unstructuredOrDie(test.secret)
+			assert.Equal(t, test.expected, isAutoGeneratedServiceAccountToken(u, u))
+		})
+	}
+}
+
+func TestIsExcludedSecret(t *testing.T) {
+	tests := []struct {
+		name        string
+		secret      string
+		annotations map[string]string
+		ownerKinds  []string
+		expected    bool
+	}{
+		{
+			name:   "nothing is excluded by default",
+			secret: `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s-1","annotations":{"a":"b"},"ownerReferences":[{"kind":"ExternalSecret","name":"es-1"}]}}`,
+		},
+		{
+			name:        "annotation with an empty value matches any value",
+			secret:      `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s-1","annotations":{"a":"b"}}}`,
+			annotations: map[string]string{"a": ""},
+			expected:    true,
+		},
+		{
+			name:        "annotation with a value matches that value",
+			secret:      `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s-1","annotations":{"a":"b"}}}`,
+			annotations: map[string]string{"a": "b"},
+			expected:    true,
+		},
+		{
+			name:        "annotation with a different value doesn't match",
+			secret:      `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s-1","annotations":{"a":"c"}}}`,
+			annotations: map[string]string{"a": "b"},
+		},
+		{
+			name:        "missing annotation doesn't match",
+			secret:      `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s-1"}}`,
+			annotations: map[string]string{"a": ""},
+		},
+		{
+			name:       "owner of an excluded kind matches",
+			secret:     `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s-1","ownerReferences":[{"kind":"Deployment","name":"d-1"},{"kind":"ExternalSecret","name":"es-1"}]}}`,
+			ownerKinds: []string{"ExternalSecret"},
+			expected:   true,
+		},
+		{
+			name:       "owner of another kind doesn't match",
+			secret:     `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s-1","ownerReferences":[{"kind":"Deployment","name":"d-1"}]}}`,
+			ownerKinds: []string{"ExternalSecret"},
+		},
+		{
+			name:       "owner of an excluded kind qualified by its group matches",
+			secret:     `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s-1","ownerReferences":[{"apiVersion":"kubernetes-client.io/v1","kind":"ExternalSecret","name":"es-1"}]}}`,
+			ownerKinds: []string{"ExternalSecret.kubernetes-client.io"},
+			expected:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backup := &v1.Backup{
+				Spec: v1.BackupSpec{
+					ExcludedSecretAnnotations: test.annotations,
+					ExcludedSecretOwnerKinds:  test.ownerKinds,
+				},
+			}
+			u := unstructuredOrDie(test.secret)
+			assert.Equal(t, test.expected, isExcludedSecret(u, backup))
+		})
+	}
+}
+
+func TestBackupItemSkipsItemsWithExcludedOwners(t *testing.T) {
+	backedUpItems := make(map[itemKey]struct{})
+	ib := &defaultItemBackupper{
+		backup: &v1.Backup{
+			Spec: v1.BackupSpec{
+				ExcludedOwnerKinds: []string{"EtcdCluster"},
+			},
+		},
+		namespaces:    collections.NewIncludesExcludes(),
+		resources:     collections.NewIncludesExcludes(),
+		backedUpItems: backedUpItems,
+	}
+
+	u := unstructuredOrDie(`{"apiVersion":"v1","kind":"Pod","metadata":{"namespace":"ns","name":"etcd-1","ownerReferences":[{"apiVersion":"etcd.database.coreos.com/v1beta2","kind":"EtcdCluster","name":"etcd"}]}}`)
+	err := ib.backupItem(arktest.NewLogger(), u, schema.GroupResource{Resource: "pods"})
+	assert.NoError(t, err)
+	assert.Empty(t, backedUpItems)
+}
+
+func TestExcludedOwner(t *testing.T) {
+	tests := []struct {
+		name          string
+		item          string
+		kinds         []string
+		expectedOwner string
+	}{
+		{
+			name:  "no excluded kinds doesn't match",
+			item:  `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"p-1","ownerReferences":[{"apiVersion":"etcd.database.coreos.com/v1beta2","kind":"EtcdCluster","name":"etcd"}]}}`,
+			kinds: nil,
+		},
+		{
+			name:          "owner of an excluded kind matches",
+			item:          `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"p-1","ownerReferences":[{"apiVersion":"apps/v1","kind":"Deployment","name":"d-1"},{"apiVersion":"etcd.database.coreos.com/v1beta2","kind":"EtcdCluster","name":"etcd"}]}}`,
+			kinds:         []string{"EtcdCluster"},
+			expectedOwner: "etcd",
+		},
+		{
+			name:          "owner of an excluded group-qualified kind matches",
+			item:          `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"p-1","ownerReferences":[{"apiVersion":"etcd.database.coreos.com/v1beta2","kind":"EtcdCluster","name":"etcd"}]}}`,
+			kinds:         []string{"EtcdCluster.etcd.database.coreos.com"},
+			expectedOwner: "etcd",
+		},
+		{
+			name:  "owner of the same kind in another group doesn't match",
+			item:  `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"p-1","ownerReferences":[{"apiVersion":"example.com/v1","kind":"EtcdCluster","name":"etcd"}]}}`,
+			kinds: []string{"EtcdCluster.etcd.database.coreos.com"},
+		},
+		{
+			name:  "owner of another kind doesn't match",
+			item:  `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"p-1","ownerReferences":[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"rs-1"}]}}`,
+			kinds: []string{"EtcdCluster"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			owner, found := excludedOwner(unstructuredOrDie(test.item), test.kinds)
+			assert.Equal(t, test.expectedOwner != "", found)
+			assert.Equal(t, test.expectedOwner, owner.Name)
+		})
+	}
+}
+
+func TestBackupItemIncludesOwnerReferences(t *testing.T) {
+	var (
+		replicaSets = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+		deployments = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+		replicaSetsResource = metav1.APIResource{Name: "replicasets", Kind: "ReplicaSet", Namespaced: true}
+		deploymentsResource = metav1.APIResource{Name: "deployments", Kind: "Deployment", Namespaced: true}
+	)
+
+	backup := &v1.Backup{Spec: v1.BackupSpec{IncludeOwnerReferences: true}}
+	backedUpItems := make(map[itemKey]struct{})
+	dynamicFactory := &arktest.FakeDynamicFactory{}
+	defer dynamicFactory.AssertExpectations(t)
+
+	ib := &defaultItemBackupper{
+		backup:         backup,
+		namespaces:     collections.NewIncludesExcludes(),
+		resources:      collections.NewIncludesExcludes(),
+		backedUpItems:  backedUpItems,
+		tarWriter:      &fakeTarWriter{},
+		dynamicFactory: dynamicFactory,
+		discoveryHelper: &arktest.FakeDiscoveryHelper{
+			ResourceList: []*metav1.APIResourceList{
+				{
+					GroupVersion: "apps/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "deployments/scale", Kind: "Scale", Namespaced: true},
+						replicaSetsResource,
+						deploymentsResource,
+					},
+				},
+			},
+			Mapper: &arktest.FakeMapper{
+				Resources: map[schema.GroupVersionResource]schema.GroupVersionResource{
+					replicaSets: replicaSets,
+					deployments: deployments,
+				},
+			},
+		},
+		itemHookHandler: &defaultItemHookHandler{},
+	}
+	ib.additionalItemBackupper = ib
+
+	// the deployment's owner reference to the replica set makes a cycle, which ends at
+	// the replica set because it's already backed up
+	replicaSet := unstructuredOrDie(`{"apiVersion":"apps/v1","kind":"ReplicaSet","metadata":{"namespace":"ns","name":"rs-1","ownerReferences":[{"apiVersion":"apps/v1","kind":"Deployment","name":"d-1"}]}}`)
+	deployment := unstructuredOrDie(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"namespace":"ns","name":"d-1","ownerReferences":[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"rs-1"}]}}`)
+
+	replicaSetClient := &arktest.FakeDynamicClient{}
+	defer replicaSetClient.AssertExpectations(t)
+	deploymentClient := &arktest.FakeDynamicClient{}
+	defer deploymentClient.AssertExpectations(t)
+
+	dynamicFactory.On("ClientForGroupVersionResource", replicaSets.GroupVersion(), replicaSetsResource, "ns").Return(replicaSetClient, nil)
+	dynamicFactory.On("ClientForGroupVersionResource", deployments.GroupVersion(), deploymentsResource, "ns").Return(deploymentClient, nil)
+	replicaSetClient.On("Get", "rs-1", metav1.GetOptions{}).Return(replicaSet, nil)
+	replicaSetClient.On("Get", "rs-gone", metav1.GetOptions{}).Return((*unstructured.Unstructured)(nil), apierrors.NewNotFound(replicaSets.GroupResource(), "rs-gone"))
+	deploymentClient.On("Get", "d-1", metav1.GetOptions{}).Return(deployment, nil)
+
+	// owners that no longer exist, or whose kind isn't known, are skipped
+	pod := unstructuredOrDie(`{"apiVersion":"v1","kind":"Pod","metadata":{"namespace":"ns","name":"pod-1","ownerReferences":[
+		{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"rs-1"},
+		{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"rs-gone"},
+		{"apiVersion":"example.com/v1","kind":"Unknown","name":"u-1"}
+	]}}`)
+
+	require.NoError(t, ib.backupItem(arktest.NewLogger(), pod, schema.GroupResource{Resource: "pods"}))
+
+	assert.Equal(t, map[itemKey]struct{}{
+		{resource: "pods", namespace: "ns", name: "pod-1"}:            {},
+		{resource: "replicasets.apps", namespace: "ns", name: "rs-1"}: {},
+		{resource: "deployments.apps", namespace: "ns", name: "d-1"}:  {},
+	}, backedUpItems)
+	assert.Equal(t, []string{"deployments.apps/ns/d-1", "replicasets.apps/ns/rs-1"}, backup.Status.IncludedOwners)
+}
+
 func TestBackupItemNoSkips(t *testing.T) {
 	tests := []struct {
 		name                                  string
@@ -320,6 +707,8 @@ func TestBackupItemNoSkips(t *testing.T) {
 				dynamicFactory,
 				discoveryHelper,
 				nil,
+				nil,
+				nil,
 			).(*defaultItemBackupper)
 
 			var snapshotService *arktest.FakeSnapshotService
@@ -443,6 +832,10 @@ func TestTakePVSnapshot(t *testing.T) {
 		expectedSnapshotsTaken int
 		existingVolumeBackups  map[string]*v1.VolumeBackupInfo
 		volumeInfo             map[string]v1.VolumeBackupInfo
+		backupLabels           map[string]string
+		backupAnnotations      map[string]string
+		expectedTags           map[string]string
+		parentSnapshots        map[string]string
 	}{
 		{
 			name:            "snapshot disabled",
@@ -466,6 +859,53 @@ func TestTakePVSnapshot(t *testing.T) {
 			volumeInfo: map[string]v1.VolumeBackupInfo{
 				"vol-abc123": {Type: "gp", SnapshotID: "snap-1", AvailabilityZone: "us-east-1c"},
 			},
+			expectedTags: map[string]string{
+				"ark.heptio.com/backup": "mybackup",
+				"ark.heptio.com/pv":     "mypv",
+			},
+		},
+		{
+			name:                   "backup labels are added to snapshot tags",
+			snapshotEnabled:        true,
+			pv:                     `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv", "labels": {"failure-domain.beta.kubernetes.io/zone": "us-east-1c"}}, "spec": {"awsElasticBlockStore": {"volumeID": "aws://us-east-1c/vol-abc123"}}}`,
+			expectedSnapshotsTaken: 1,
+			expectedVolumeID:       "vol-abc123",
+			volumeInfo: map[string]v1.VolumeBackupInfo{
+				"vol-abc123": {Type: "gp", SnapshotID: "snap-1", AvailabilityZone: "us-east-1c"},
+			},
+			backupLabels: map[string]string{
+				"cost-center":           "1234",
+				"ark.heptio.com/backup": "not-mybackup",
+			},
+			expectedTags: map[string]string{
+				"cost-center":           "1234",
+				"ark.heptio.com/backup": "mybackup",
+				"ark.heptio.com/pv":     "mypv",
+			},
+		},
+		{
+			name:                   "backup annotations that fit in tags are added to snapshot tags",
+			snapshotEnabled:        true,
+			pv:                     `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv", "labels": {"failure-domain.beta.kubernetes.io/zone": "us-east-1c"}}, "spec": {"awsElasticBlockStore": {"volumeID": "aws://us-east-1c/vol-abc123"}}}`,
+			expectedSnapshotsTaken: 1,
+			expectedVolumeID:       "vol-abc123",
+			volumeInfo: map[string]v1.VolumeBackupInfo{
+				"vol-abc123": {Type: "gp", SnapshotID: "snap-1", AvailabilityZone: "us-east-1c"},
+			},
+			backupLabels: map[string]string{
+				"cost-center": "1234",
+			},
+			backupAnnotations: map[string]string{
+				"cost-center": "5678",
+				"owner":       "team-a",
+				"kubectl.kubernetes.io/last-applied-configuration": strings.Repeat("x", 257),
+			},
+			expectedTags: map[string]string{
+				"cost-center":           "1234",
+				"owner":                 "team-a",
+				"ark.heptio.com/backup": "mybackup",
+				"ark.heptio.com/pv":     "mypv",
+			},
 		},
 		{
 			name:                   "with iops",
@@ -494,6 +934,19 @@ func TestTakePVSnapshot(t *testing.T) {
 				"pd-abc123": {Type: "gp", SnapshotID: "snap-1"},
 			},
 		},
+		{
+			name:                   "incremental snapshot records its parent",
+			snapshotEnabled:        true,
+			pv:                     `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv", "labels": {"failure-domain.beta.kubernetes.io/zone": "us-east-1c"}}, "spec": {"awsElasticBlockStore": {"volumeID": "aws://us-east-1c/vol-abc123"}}}`,
+			expectedSnapshotsTaken: 1,
+			expectedVolumeID:       "vol-abc123",
+			volumeInfo: map[string]v1.VolumeBackupInfo{
+				"vol-abc123": {Type: "gp", SnapshotID: "snap-2", AvailabilityZone: "us-east-1c"},
+			},
+			parentSnapshots: map[string]string{
+				"vol-abc123": "snap-1",
+			},
+		},
 		{
 			name:             "create snapshot error",
 			snapshotEnabled:  true,
@@ -519,8 +972,10 @@ func TestTakePVSnapshot(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			backup := &v1.Backup{
 				ObjectMeta: metav1.ObjectMeta{
-					Namespace: v1.DefaultNamespace,
-					Name:      "mybackup",
+					Namespace:   v1.DefaultNamespace,
+					Name:        "mybackup",
+					Labels:      test.backupLabels,
+					Annotations: test.backupAnnotations,
 				},
 				Spec: v1.BackupSpec{
 					SnapshotVolumes: &test.snapshotEnabled,
@@ -534,6 +989,7 @@ func TestTakePVSnapshot(t *testing.T) {
 			snapshotService := &arktest.FakeSnapshotService{
 				SnapshottableVolumes: test.volumeInfo,
 				VolumeID:             test.expectedVolumeID,
+				ParentSnapshots:      test.parentSnapshots,
 			}
 
 			ib := &defaultItemBackupper{snapshotService: snapshotService}
@@ -573,8 +1029,13 @@ func TestTakePVSnapshot(t *testing.T) {
 				// for the volume we ran the test for
 				snapshotID, _ := snapshotService.SnapshotsTaken.PopAny()
 
+				if test.expectedTags != nil {
+					assert.Equal(t, test.expectedTags, snapshotService.SnapshotTags[snapshotID])
+				}
+
 				expectedVolumeBackups["mypv"] = &v1.VolumeBackupInfo{
 					SnapshotID:       snapshotID,
+					ParentSnapshotID: test.parentSnapshots[test.expectedVolumeID],
 					Type:             test.volumeInfo[test.expectedVolumeID].Type,
 					Iops:             test.volumeInfo[test.expectedVolumeID].Iops,
 					AvailabilityZone: test.volumeInfo[test.expectedVolumeID].AvailabilityZone,
@@ -588,6 +1049,277 @@ func TestTakePVSnapshot(t *testing.T) {
 	}
 }
 
+func TestTakePVSnapshotRunsSnapshotHooks(t *testing.T) {
+	pv := `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv"}, "spec": {"gcePersistentDisk": {"pdName": "pd-abc123"}, "claimRef": {"namespace": "ns", "name": "mypvc"}}}`
+
+	podUsingClaim := unstructuredOrDie(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"namespace": "ns", "name": "db-0"}, "spec": {"volumes": [{"name": "data", "persistentVolumeClaim": {"claimName": "mypvc"}}]}, "status": {"phase": "Running"}}`)
+	pendingPodUsingClaim := unstructuredOrDie(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"namespace": "ns", "name": "db-1"}, "spec": {"volumes": [{"name": "data", "persistentVolumeClaim": {"claimName": "mypvc"}}]}, "status": {"phase": "Pending"}}`)
+	podUsingOtherClaim := unstructuredOrDie(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"namespace": "ns", "name": "web-0"}, "spec": {"volumes": [{"name": "data", "persistentVolumeClaim": {"claimName": "otherpvc"}}]}, "status": {"phase": "Running"}}`)
+
+	tests := []struct {
+		name                   string
+		snapshottable          bool
+		preHookErr             error
+		postHookErr            error
+		expectedErr            bool
+		expectedPhases         []hookPhase
+		expectedSnapshotsTaken int
+	}{
+		{
+			name:                   "hooks run immediately before and after the snapshot",
+			snapshottable:          true,
+			expectedPhases:         []hookPhase{hookPhasePreSnapshot, hookPhasePostSnapshot},
+			expectedSnapshotsTaken: 1,
+		},
+		{
+			name:           "post-snapshot hooks run when the snapshot fails",
+			snapshottable:  false,
+			expectedErr:    true,
+			expectedPhases: []hookPhase{hookPhasePreSnapshot, hookPhasePostSnapshot},
+		},
+		{
+			name:           "volume isn't snapshotted when a pre-snapshot hook fails, but post-snapshot hooks run",
+			snapshottable:  true,
+			preHookErr:     errors.New("fsfreeze failed"),
+			expectedErr:    true,
+			expectedPhases: []hookPhase{hookPhasePreSnapshot, hookPhasePostSnapshot},
+		},
+		{
+			name:                   "snapshot is recorded when a post-snapshot hook fails",
+			snapshottable:          true,
+			postHookErr:            errors.New("unfreeze failed"),
+			expectedErr:            true,
+			expectedPhases:         []hookPhase{hookPhasePreSnapshot, hookPhasePostSnapshot},
+			expectedSnapshotsTaken: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snapshotService := &arktest.FakeSnapshotService{VolumeID: "pd-abc123"}
+			if test.snapshottable {
+				snapshotService.SnapshottableVolumes = map[string]v1.VolumeBackupInfo{
+					"pd-abc123": {Type: "gp", SnapshotID: "snap-1"},
+				}
+			}
+
+			dynamicFactory := &arktest.FakeDynamicFactory{}
+			podClient := &arktest.FakeDynamicClient{}
+			dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{}, metav1.APIResource{Name: "pods"}, "ns").Return(podClient, nil)
+			podClient.On("List", metav1.ListOptions{}).Return(&unstructured.UnstructuredList{
+				Items: []unstructured.Unstructured{*podUsingClaim, *pendingPodUsingClaim, *podUsingOtherClaim},
+			}, nil)
+
+			resourceHooks := []resourceHook{{name: "freeze"}}
+
+			var phases []hookPhase
+			itemHookHandler := &mockItemHookHandler{}
+			itemHookHandler.On("handleHooks", mock.Anything, podsGroupResource, podUsingClaim, resourceHooks, hookPhasePreSnapshot).
+				Run(func(mock.Arguments) {
+					assert.Equal(t, 0, snapshotService.SnapshotsTaken.Len(), "pre-snapshot hook ran after the snapshot")
+					phases = append(phases, hookPhasePreSnapshot)
+				}).
+				Return(test.preHookErr)
+			itemHookHandler.On("handleHooks", mock.Anything, podsGroupResource, podUsingClaim, resourceHooks, hookPhasePostSnapshot).
+				Run(func(mock.Arguments) {
+					phases = append(phases, hookPhasePostSnapshot)
+				}).
+				Return(test.postHookErr)
+
+			ib := &defaultItemBackupper{
+				snapshotService: snapshotService,
+				dynamicFactory:  dynamicFactory,
+				discoveryHelper: arktest.NewFakeDiscoveryHelper(true, nil),
+				resourceHooks:   resourceHooks,
+				itemHookHandler: itemHookHandler,
+			}
+			backup := &v1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "mybackup"}}
+
+			err := ib.takePVSnapshot(unstructuredOrDie(pv), backup, arktest.NewLogger())
+
+			assert.Equal(t, test.expectedErr, err != nil)
+			assert.Equal(t, test.expectedPhases, phases)
+			assert.Equal(t, test.expectedSnapshotsTaken, snapshotService.SnapshotsTaken.Len())
+			assert.Equal(t, test.expectedSnapshotsTaken, len(backup.Status.VolumeBackups))
+			itemHookHandler.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTakePVSnapshotWithCSISnapshotter(t *testing.T) {
+	csiPV := `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv"}, "spec": {"csi": {"driver": "ebs.csi.aws.com", "volumeHandle": "vol-abc123"}, "claimRef": {"namespace": "ns", "name": "mypvc"}}}`
+	unboundCSIPV := `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv"}, "spec": {"csi": {"driver": "ebs.csi.aws.com", "volumeHandle": "vol-abc123"}}}`
+	inTreePV := `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv"}, "spec": {"gcePersistentDisk": {"pdName": "pd-abc123"}, "claimRef": {"namespace": "ns", "name": "mypvc"}}}`
+
+	snapshot := &v1.CSISnapshotInfo{
+		Driver:                    "ebs.csi.aws.com",
+		SnapshotHandle:            "snap-1",
+		VolumeSnapshotNamespace:   "ns",
+		VolumeSnapshotName:        "mypvc-abcde",
+		VolumeSnapshotContentName: "snapcontent-1",
+	}
+
+	tests := []struct {
+		name                string
+		pv                  string
+		csiSnapshots        bool
+		csiSnapshottable    bool
+		snapshotService     bool
+		expectedErr         bool
+		expectedVolumeInfo  *v1.VolumeBackupInfo
+		expectedCloudTaken  int
+		expectedSnapshotted bool
+	}{
+		{
+			name:             "CSI volume is snapshotted with the CSI snapshot API instead of the snapshot service",
+			pv:               csiPV,
+			csiSnapshots:     true,
+			csiSnapshottable: true,
+			snapshotService:  true,
+			expectedVolumeInfo: &v1.VolumeBackupInfo{
+				SnapshotID:  "snap-1",
+				CSISnapshot: snapshot,
+			},
+		},
+		{
+			name:            "CSI volume isn't snapshotted without CSI snapshots",
+			pv:              csiPV,
+			snapshotService: true,
+		},
+		{
+			name:         "CSI volume that isn't bound to a claim isn't snapshotted",
+			pv:           unboundCSIPV,
+			csiSnapshots: true,
+		},
+		{
+			name:         "failed CSI snapshot returns an error",
+			pv:           csiPV,
+			csiSnapshots: true,
+			expectedErr:  true,
+		},
+		{
+			name:         "volume that isn't a CSI volume isn't snapshotted without a snapshot service",
+			pv:           inTreePV,
+			csiSnapshots: true,
+		},
+		{
+			name:               "volume that isn't a CSI volume is snapshotted with the snapshot service",
+			pv:                 inTreePV,
+			csiSnapshots:       true,
+			snapshotService:    true,
+			expectedVolumeInfo: &v1.VolumeBackupInfo{SnapshotID: "snap-2", Type: "gp"},
+			expectedCloudTaken: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dynamicFactory := &arktest.FakeDynamicFactory{}
+			podClient := &arktest.FakeDynamicClient{}
+			dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{}, metav1.APIResource{Name: "pods"}, "ns").Return(podClient, nil)
+			podClient.On("List", metav1.ListOptions{}).Return(&unstructured.UnstructuredList{}, nil)
+
+			ib := &defaultItemBackupper{
+				dynamicFactory:  dynamicFactory,
+				discoveryHelper: arktest.NewFakeDiscoveryHelper(true, nil),
+				itemHookHandler: &defaultItemHookHandler{},
+			}
+
+			csiSnapshotter := &arktest.FakeCSISnapshotter{}
+			if test.csiSnapshottable {
+				csiSnapshotter.SnapshottableVolumes = map[string]*v1.CSISnapshotInfo{"mypv": snapshot}
+			}
+			if test.csiSnapshots {
+				ib.csiSnapshotter = csiSnapshotter
+			}
+
+			snapshotService := &arktest.FakeSnapshotService{
+				VolumeIDs: map[string]string{},
+				SnapshottableVolumes: map[string]v1.VolumeBackupInfo{
+					"pd-abc123": {Type: "gp", SnapshotID: "snap-2"},
+				},
+			}
+			if test.pv == inTreePV {
+				snapshotService.VolumeID = "pd-abc123"
+			}
+			if test.snapshotService {
+				ib.snapshotService = snapshotService
+			}
+
+			backup := &v1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "mybackup"}}
+
+			err := ib.takePVSnapshot(unstructuredOrDie(test.pv), backup, arktest.NewLogger())
+
+			assert.Equal(t, test.expectedErr, err != nil)
+			assert.Equal(t, test.expectedCloudTaken, snapshotService.SnapshotsTaken.Len())
+			if test.expectedVolumeInfo == nil {
+				assert.Empty(t, backup.Status.VolumeBackups)
+				return
+			}
+
+			require.Len(t, backup.Status.VolumeBackups, 1)
+			assert.Equal(t, test.expectedVolumeInfo, backup.Status.VolumeBackups["mypv"])
+			if test.expectedVolumeInfo.CSISnapshot != nil {
+				assert.Equal(t, map[string]string{v1.BackupNameLabel: "mybackup"}, csiSnapshotter.SnapshotLabels["mypv"])
+			}
+		})
+	}
+}
+
+type fakeSnapshotThrottle struct {
+	acquired []string
+	released int
+}
+
+func (t *fakeSnapshotThrottle) acquire(storageClass string) func() {
+	t.acquired = append(t.acquired, storageClass)
+	return func() { t.released++ }
+}
+
+func TestTakePVSnapshotUsesSnapshotThrottle(t *testing.T) {
+	pv := `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv"}, "spec": {"storageClassName": "standard", "gcePersistentDisk": {"pdName": "pd-abc123"}}}`
+
+	tests := []struct {
+		name          string
+		snapshottable bool
+		expectedErr   bool
+	}{
+		{
+			name:          "throttle is released after the volume is snapshotted",
+			snapshottable: true,
+		},
+		{
+			name:          "throttle is released when the snapshot fails",
+			snapshottable: false,
+			expectedErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snapshotService := &arktest.FakeSnapshotService{VolumeID: "pd-abc123"}
+			if test.snapshottable {
+				snapshotService.SnapshottableVolumes = map[string]v1.VolumeBackupInfo{
+					"pd-abc123": {Type: "gp", SnapshotID: "snap-1"},
+				}
+			}
+
+			throttle := &fakeSnapshotThrottle{}
+			ib := &defaultItemBackupper{
+				snapshotService:  snapshotService,
+				snapshotThrottle: throttle,
+			}
+			backup := &v1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "mybackup"}}
+
+			err := ib.takePVSnapshot(unstructuredOrDie(pv), backup, arktest.NewLogger())
+
+			assert.Equal(t, test.expectedErr, err != nil)
+			assert.Equal(t, []string{"standard"}, throttle.acquired)
+			assert.Equal(t, 1, throttle.released)
+		})
+	}
+}
+
 type fakeTarWriter struct {
 	closeCalled      bool
 	headers          []*tar.Header
@@ -616,3 +1348,78 @@ func (ib *mockItemBackupper) backupItem(logger logrus.FieldLogger, obj runtime.U
 	args := ib.Called(logger, obj, groupResource)
 	return args.Error(0)
 }
+
+func TestIsSelectedForSnapshot(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"snapshot": "true"}}
+
+	tests := []struct {
+		name     string
+		selector *metav1.LabelSelector
+		pv       string
+		pvc      *unstructured.Unstructured
+		pvcErr   error
+		expected bool
+	}{
+		{
+			name:     "no selector selects every PV",
+			pv:       `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv"}}`,
+			expected: true,
+		},
+		{
+			name:     "PV matching the selector is selected",
+			selector: selector,
+			pv:       `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv", "labels": {"snapshot": "true"}}}`,
+			expected: true,
+		},
+		{
+			name:     "unbound PV not matching the selector isn't selected",
+			selector: selector,
+			pv:       `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv"}}`,
+			expected: false,
+		},
+		{
+			name:     "PV whose claim matches the selector is selected",
+			selector: selector,
+			pv:       `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv"}, "spec": {"claimRef": {"namespace": "ns", "name": "mypvc"}}}`,
+			pvc:      unstructuredOrDie(`{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": {"namespace": "ns", "name": "mypvc", "labels": {"snapshot": "true"}}}`),
+			expected: true,
+		},
+		{
+			name:     "PV whose claim doesn't match the selector isn't selected",
+			selector: selector,
+			pv:       `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv"}, "spec": {"claimRef": {"namespace": "ns", "name": "mypvc"}}}`,
+			pvc:      unstructuredOrDie(`{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": {"namespace": "ns", "name": "mypvc", "labels": {"snapshot": "false"}}}`),
+			expected: false,
+		},
+		{
+			name:     "PV whose claim doesn't exist isn't selected",
+			selector: selector,
+			pv:       `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "mypv"}, "spec": {"claimRef": {"namespace": "ns", "name": "mypvc"}}}`,
+			pvcErr:   apierrors.NewNotFound(schema.GroupResource{Resource: "persistentvolumeclaims"}, "mypvc"),
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				dynamicFactory = &arktest.FakeDynamicFactory{}
+				pvcClient      = &arktest.FakeDynamicClient{}
+				ib             = &defaultItemBackupper{
+					dynamicFactory:  dynamicFactory,
+					discoveryHelper: arktest.NewFakeDiscoveryHelper(true, nil),
+				}
+				backup = &v1.Backup{Spec: v1.BackupSpec{VolumeSnapshotSelector: test.selector}}
+			)
+
+			if test.pvc != nil || test.pvcErr != nil {
+				dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{}, metav1.APIResource{Name: "persistentvolumeclaims"}, "ns").Return(pvcClient, nil)
+				pvcClient.On("Get", "mypvc", metav1.GetOptions{}).Return(test.pvc, test.pvcErr)
+			}
+
+			selected, err := ib.isSelectedForSnapshot(unstructuredOrDie(test.pv), backup)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, selected)
+		})
+	}
+}
@@ -35,8 +35,10 @@ import (
 type hookPhase string
 
 const (
-	hookPhasePre  hookPhase = "pre"
-	hookPhasePost hookPhase = "post"
+	hookPhasePre          hookPhase = "pre"
+	hookPhasePost         hookPhase = "post"
+	hookPhasePreSnapshot  hookPhase = "pre-snapshot"
+	hookPhasePostSnapshot hookPhase = "post-snapshot"
 )
 
 // itemHookHandler invokes hooks for an item.
@@ -57,6 +59,10 @@ type itemHookHandler interface {
 // defaultItemHookHandler is the default itemHookHandler.
 type defaultItemHookHandler struct {
 	podCommandExecutor podCommandExecutor
+
+	// backup, if set, is the backup whose status the result of each hook
+	// command is recorded in.
+	backup *api.Backup
 }
 
 func (h *defaultItemHookHandler) handleHooks(
@@ -93,7 +99,7 @@ func (h *defaultItemHookHandler) handleHooks(
 				"hookPhase":  phase,
 			},
 		)
-		if err := h.podCommandExecutor.executePodCommand(hookLog, obj.UnstructuredContent(), namespace, name, "<from-annotation>", hookFromAnnotations); err != nil {
+		if err := h.executeExecHook(hookLog, obj.UnstructuredContent(), namespace, name, "<from-annotation>", phase, hookFromAnnotations); err != nil {
 			hookLog.WithError(err).Error("Error executing hook")
 			if hookFromAnnotations.OnError == api.HookErrorModeFail {
 				return err
@@ -111,10 +117,15 @@ func (h *defaultItemHookHandler) handleHooks(
 		}
 
 		var hooks []api.BackupResourceHook
-		if phase == hookPhasePre {
+		switch phase {
+		case hookPhasePre:
 			hooks = resourceHook.pre
-		} else {
+		case hookPhasePost:
 			hooks = resourceHook.post
+		case hookPhasePreSnapshot:
+			hooks = resourceHook.preSnapshot
+		case hookPhasePostSnapshot:
+			hooks = resourceHook.postSnapshot
 		}
 		for _, hook := range hooks {
 			if groupResource == podsGroupResource {
@@ -126,7 +137,7 @@ func (h *defaultItemHookHandler) handleHooks(
 							"hookPhase":  phase,
 						},
 					)
-					err := h.podCommandExecutor.executePodCommand(hookLog, obj.UnstructuredContent(), namespace, name, resourceHook.name, hook.Exec)
+					err := h.executeExecHook(hookLog, obj.UnstructuredContent(), namespace, name, resourceHook.name, phase, hook.Exec)
 					if err != nil {
 						hookLog.WithError(err).Error("Error executing hook")
 						if hook.Exec.OnError == api.HookErrorModeFail {
@@ -141,6 +152,68 @@ func (h *defaultItemHookHandler) handleHooks(
 	return nil
 }
 
+// executeExecHook executes each of hook's commands in order, recording their results in the
+// backup's status, and returns the first error. Unless the hook's ContinueOnCommandError is set,
+// its remaining commands aren't executed once one fails.
+func (h *defaultItemHookHandler) executeExecHook(
+	log logrus.FieldLogger,
+	item map[string]interface{},
+	namespace, name, hookName string,
+	phase hookPhase,
+	hook *api.ExecHook,
+) error {
+	var commands [][]string
+	if len(hook.Command) > 0 || len(hook.Commands) == 0 {
+		commands = append(commands, hook.Command)
+	}
+	commands = append(commands, hook.Commands...)
+
+	var firstErr error
+	for i, command := range commands {
+		commandHook := *hook
+		commandHook.Command = command
+		commandHook.Commands = nil
+
+		commandLog := log
+		if len(commands) > 1 {
+			commandLog = log.WithField("hookCommandIndex", i)
+		}
+
+		err := h.podCommandExecutor.executePodCommand(commandLog, item, namespace, name, hookName, &commandHook)
+		h.recordHookResult(hookName, phase, namespace, name, &commandHook, err)
+
+		if err != nil && firstErr == nil {
+			firstErr = err
+			if !hook.ContinueOnCommandError {
+				break
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// recordHookResult appends the result of executing hook's command to the backup's status.
+func (h *defaultItemHookHandler) recordHookResult(hookName string, phase hookPhase, namespace, name string, hook *api.ExecHook, err error) {
+	if h.backup == nil {
+		return
+	}
+
+	result := api.HookCommandResult{
+		Hook:      hookName,
+		Phase:     string(phase),
+		Pod:       namespace + "/" + name,
+		Container: hook.Container,
+		Command:   hook.Command,
+		Succeeded: err == nil,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	h.backup.Status.HookResults = append(h.backup.Status.HookResults, result)
+}
+
 const (
 	podBackupHookContainerAnnotationKey = "hook.backup.ark.heptio.com/container"
 	podBackupHookCommandAnnotationKey   = "hook.backup.ark.heptio.com/command"
@@ -210,6 +283,8 @@ type resourceHook struct {
 	labelSelector labels.Selector
 	pre           []api.BackupResourceHook
 	post          []api.BackupResourceHook
+	preSnapshot   []api.BackupResourceHook
+	postSnapshot  []api.BackupResourceHook
 }
 
 func (r resourceHook) applicableTo(groupResource schema.GroupResource, namespace string, labels labels.Set) bool {
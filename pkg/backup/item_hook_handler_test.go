@@ -501,8 +501,131 @@ func TestHandleHooks(t *testing.T) {
 	}
 }
 
+func TestHandleHooksRunsOnlyTheHooksForThePhase(t *testing.T) {
+	pod := unstructuredOrDie(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"namespace": "ns", "name": "name"}}`)
+
+	hook := resourceHook{
+		name:         "hook1",
+		pre:          []v1.BackupResourceHook{{Exec: &v1.ExecHook{Container: "c", Command: []string{"pre"}}}},
+		post:         []v1.BackupResourceHook{{Exec: &v1.ExecHook{Container: "c", Command: []string{"post"}}}},
+		preSnapshot:  []v1.BackupResourceHook{{Exec: &v1.ExecHook{Container: "c", Command: []string{"fsfreeze", "--freeze", "/data"}}}},
+		postSnapshot: []v1.BackupResourceHook{{Exec: &v1.ExecHook{Container: "c", Command: []string{"fsfreeze", "--unfreeze", "/data"}}}},
+	}
+
+	tests := []struct {
+		phase    hookPhase
+		expected *v1.ExecHook
+	}{
+		{phase: hookPhasePre, expected: hook.pre[0].Exec},
+		{phase: hookPhasePost, expected: hook.post[0].Exec},
+		{phase: hookPhasePreSnapshot, expected: hook.preSnapshot[0].Exec},
+		{phase: hookPhasePostSnapshot, expected: hook.postSnapshot[0].Exec},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.phase), func(t *testing.T) {
+			podCommandExecutor := &mockPodCommandExecutor{}
+			defer podCommandExecutor.AssertExpectations(t)
+
+			h := &defaultItemHookHandler{podCommandExecutor: podCommandExecutor}
+
+			podCommandExecutor.On("executePodCommand", mock.Anything, pod.UnstructuredContent(), "ns", "name", "hook1", test.expected).Return(nil)
+
+			require.NoError(t, h.handleHooks(arktest.NewLogger(), podsGroupResource, pod, []resourceHook{hook}, test.phase))
+		})
+	}
+}
+
+func TestHandleHooksExecutesCommandsInOrder(t *testing.T) {
+	pod := unstructuredOrDie(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"namespace": "ns", "name": "name"}}`)
+
+	tests := []struct {
+		name                   string
+		continueOnCommandError bool
+		errors                 map[string]error
+		expectedCommands       []string
+		expectedResults        []v1.HookCommandResult
+		expectedError          string
+	}{
+		{
+			name:             "all commands succeed",
+			expectedCommands: []string{"first", "second", "third"},
+			expectedResults: []v1.HookCommandResult{
+				{Hook: "hook1", Phase: "pre", Pod: "ns/name", Container: "c", Command: []string{"first"}, Succeeded: true},
+				{Hook: "hook1", Phase: "pre", Pod: "ns/name", Container: "c", Command: []string{"second"}, Succeeded: true},
+				{Hook: "hook1", Phase: "pre", Pod: "ns/name", Container: "c", Command: []string{"third"}, Succeeded: true},
+			},
+		},
+		{
+			name:             "remaining commands aren't executed after one fails",
+			errors:           map[string]error{"second": errors.New("second failed")},
+			expectedCommands: []string{"first", "second"},
+			expectedResults: []v1.HookCommandResult{
+				{Hook: "hook1", Phase: "pre", Pod: "ns/name", Container: "c", Command: []string{"first"}, Succeeded: true},
+				{Hook: "hook1", Phase: "pre", Pod: "ns/name", Container: "c", Command: []string{"second"}, Error: "second failed"},
+			},
+			expectedError: "second failed",
+		},
+		{
+			name:                   "remaining commands are executed after one fails with continueOnCommandError",
+			continueOnCommandError: true,
+			errors:                 map[string]error{"first": errors.New("first failed"), "second": errors.New("second failed")},
+			expectedCommands:       []string{"first", "second", "third"},
+			expectedResults: []v1.HookCommandResult{
+				{Hook: "hook1", Phase: "pre", Pod: "ns/name", Container: "c", Command: []string{"first"}, Error: "first failed"},
+				{Hook: "hook1", Phase: "pre", Pod: "ns/name", Container: "c", Command: []string{"second"}, Error: "second failed"},
+				{Hook: "hook1", Phase: "pre", Pod: "ns/name", Container: "c", Command: []string{"third"}, Succeeded: true},
+			},
+			expectedError: "first failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podCommandExecutor := &mockPodCommandExecutor{}
+			defer podCommandExecutor.AssertExpectations(t)
+
+			backup := &v1.Backup{}
+			h := &defaultItemHookHandler{podCommandExecutor: podCommandExecutor, backup: backup}
+
+			hook := resourceHook{
+				name: "hook1",
+				pre: []v1.BackupResourceHook{
+					{
+						Exec: &v1.ExecHook{
+							Container:              "c",
+							Command:                []string{"first"},
+							Commands:               [][]string{{"second"}, {"third"}},
+							OnError:                v1.HookErrorModeFail,
+							ContinueOnCommandError: test.continueOnCommandError,
+						},
+					},
+				},
+			}
+
+			for _, command := range test.expectedCommands {
+				commandHook := &v1.ExecHook{
+					Container:              "c",
+					Command:                []string{command},
+					OnError:                v1.HookErrorModeFail,
+					ContinueOnCommandError: test.continueOnCommandError,
+				}
+				podCommandExecutor.On("executePodCommand", mock.Anything, pod.UnstructuredContent(), "ns", "name", "hook1", commandHook).Return(test.errors[command])
+			}
+
+			err := h.handleHooks(arktest.NewLogger(), podsGroupResource, pod, []resourceHook{hook}, hookPhasePre)
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResults, backup.Status.HookResults)
+		})
+	}
+}
+
 func TestGetPodExecHookFromAnnotations(t *testing.T) {
-	phases := []hookPhase{"", hookPhasePre, hookPhasePost}
+	phases := []hookPhase{"", hookPhasePre, hookPhasePost, hookPhasePreSnapshot, hookPhasePostSnapshot}
 	for _, phase := range phases {
 		tests := []struct {
 			name         string
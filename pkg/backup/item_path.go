@@ -0,0 +1,68 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"strings"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+// ItemPath is what the path of an entry in a backup tarball within its resources
+// directory refers to. Items are stored as
+// resources/<resource>/namespaces/<namespace>/<name>.json or
+// resources/<resource>/cluster/<name>.json.
+type ItemPath struct {
+	// Resource is the resource the entry belongs to.
+	Resource string
+
+	// Namespace is the namespace of a namespaced item, or the namespace whose
+	// items a directory contains. It's empty otherwise.
+	Namespace string
+
+	// Name is the name of the item, without the .json extension, or empty if the
+	// entry isn't an item.
+	Name string
+}
+
+// IsItem returns true if the path is an item's.
+func (p ItemPath) IsItem() bool {
+	return p.Name != ""
+}
+
+// ParseItemPath parses the path of an entry in a backup tarball, with or without a
+// leading "./". It returns false if the entry isn't within a resource's directory.
+func ParseItemPath(path string) (ItemPath, bool) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, "./"), "/"), "/")
+	if len(parts) < 2 || parts[0] != v1.ResourcesDir || parts[1] == "" {
+		return ItemPath{}, false
+	}
+
+	res := ItemPath{Resource: parts[1]}
+
+	switch {
+	case len(parts) == 4 && parts[2] == v1.ClusterScopedDir:
+		res.Name = strings.TrimSuffix(parts[3], ".json")
+	case len(parts) >= 4 && parts[2] == v1.NamespaceScopedDir:
+		res.Namespace = parts[3]
+		if len(parts) == 5 {
+			res.Name = strings.TrimSuffix(parts[4], ".json")
+		}
+	}
+
+	return res, true
+}
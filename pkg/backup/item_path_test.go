@@ -0,0 +1,53 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseItemPath(t *testing.T) {
+	tests := []struct {
+		path          string
+		expected      ItemPath
+		expectedFound bool
+	}{
+		{path: "metadata/version"},
+		{path: "resources"},
+		{path: "resources/", expectedFound: false},
+		{path: "resources/secrets", expected: ItemPath{Resource: "secrets"}, expectedFound: true},
+		{path: "resources/secrets/", expected: ItemPath{Resource: "secrets"}, expectedFound: true},
+		{path: "resources/secrets/namespaces", expected: ItemPath{Resource: "secrets"}, expectedFound: true},
+		{path: "resources/secrets/namespaces/ns-1/", expected: ItemPath{Resource: "secrets", Namespace: "ns-1"}, expectedFound: true},
+		{path: "resources/secrets/namespaces/ns-1/secret-1.json", expected: ItemPath{Resource: "secrets", Namespace: "ns-1", Name: "secret-1"}, expectedFound: true},
+		{path: "./resources/secrets/namespaces/ns-1/secret-1.json", expected: ItemPath{Resource: "secrets", Namespace: "ns-1", Name: "secret-1"}, expectedFound: true},
+		{path: "resources/persistentvolumes/cluster", expected: ItemPath{Resource: "persistentvolumes"}, expectedFound: true},
+		{path: "resources/persistentvolumes/cluster/pv-1.json", expected: ItemPath{Resource: "persistentvolumes", Name: "pv-1"}, expectedFound: true},
+		{path: "./resources/persistentvolumes/cluster/pv-1.json", expected: ItemPath{Resource: "persistentvolumes", Name: "pv-1"}, expectedFound: true},
+		{path: "resources/persistentvolumes/other/pv-1.json", expected: ItemPath{Resource: "persistentvolumes"}, expectedFound: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			res, found := ParseItemPath(test.path)
+			assert.Equal(t, test.expectedFound, found)
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NameTemplateData is the data backup name templates are executed with.
+type NameTemplateData struct {
+	// Schedule is the name of the schedule creating the backup, or empty if
+	// it isn't created by a schedule.
+	Schedule string
+
+	// Timestamp is when the backup is created, in UTC, formatted as
+	// YYYYMMDDhhmmss like the default names of scheduled backups.
+	Timestamp string
+
+	// Time is when the backup is created, for other formats, e.g.
+	// {{.Time.Format "2006-01-02"}}.
+	Time time.Time
+
+	// ClusterID is the clusterID from the Ark server's Config.
+	ClusterID string
+}
+
+// NameTemplate generates backup names from a Go text/template.
+type NameTemplate struct {
+	tmpl *template.Template
+}
+
+// sampleTime is when the backups that backup name templates are checked with are created.
+var sampleTime = time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// NewNameTemplate parses text as a backup name template, and returns an error if it
+// doesn't generate valid backup names, or if it doesn't generate different names for
+// different schedules. Whether the names of a schedule's backups are unique depends on
+// how often it runs, which CheckInterval checks.
+func NewNameTemplate(text string) (*NameTemplate, error) {
+	tmpl, err := template.New("backup-name").Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing backup name template")
+	}
+
+	t := &NameTemplate{tmpl: tmpl}
+
+	a, err := t.Name(NewNameTemplateData("schedule-a", sampleTime, "cluster"))
+	if err != nil {
+		return nil, err
+	}
+	b, err := t.Name(NewNameTemplateData("schedule-b", sampleTime, "cluster"))
+	if err != nil {
+		return nil, err
+	}
+	if a == b {
+		return nil, errors.Errorf("backup name template %q doesn't generate unique names: it must include the schedule", text)
+	}
+
+	return t, nil
+}
+
+// CheckInterval returns an error if the template generates the same name for backups
+// of a schedule created interval apart, e.g. for a template that only includes the
+// date and a schedule that runs hourly. Backups are created at different times of
+// day, so templates whose names change at a particular hour are checked too.
+func (t *NameTemplate) CheckInterval(interval time.Duration) error {
+	for hour := 0; hour < 24; hour++ {
+		created := sampleTime.Add(time.Duration(hour) * time.Hour)
+
+		first, err := t.Name(NewNameTemplateData("schedule", created, "cluster"))
+		if err != nil {
+			return err
+		}
+		next, err := t.Name(NewNameTemplateData("schedule", created.Add(interval), "cluster"))
+		if err != nil {
+			return err
+		}
+
+		if first == next {
+			return errors.Errorf("backup name template generates the same name %q for backups created %v apart", first, interval)
+		}
+	}
+
+	return nil
+}
+
+// Name executes the template with the schedule, time and cluster ID in data, returning
+// an error if the result isn't a valid backup name.
+func (t *NameTemplate) Name(data NameTemplateData) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := t.tmpl.Execute(buf, data); err != nil {
+		return "", errors.Wrap(err, "error executing backup name template")
+	}
+
+	name := buf.String()
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", errors.Errorf("backup name template generated an invalid name %q: %s", name, strings.Join(errs, "; "))
+	}
+
+	return name, nil
+}
+
+// NewNameTemplateData returns the data for executing a backup name template for a
+// backup created at timestamp, by schedule if it's not empty.
+func NewNameTemplateData(schedule string, timestamp time.Time, clusterID string) NameTemplateData {
+	timestamp = timestamp.UTC()
+
+	return NameTemplateData{
+		Schedule:  schedule,
+		Timestamp: timestamp.Format("20060102150405"),
+		Time:      timestamp,
+		ClusterID: clusterID,
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNameTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		expectedErr bool
+	}{
+		{name: "schedule and timestamp", template: "{{.Schedule}}-{{.Timestamp}}"},
+		{name: "cluster, schedule and custom time format", template: `{{.ClusterID}}-{{.Schedule}}-{{.Time.Format "2006-01-02-1504"}}`},
+		{name: "invalid syntax", template: "{{.Schedule", expectedErr: true},
+		{name: "unknown field", template: "{{.Namespace}}-{{.Timestamp}}", expectedErr: true},
+		{name: "invalid name", template: "{{.Schedule}}_{{.Timestamp}}", expectedErr: true},
+		{name: "not unique across schedules", template: "backup-{{.Timestamp}}", expectedErr: true},
+		{name: "date only", template: `{{.Schedule}}-{{.Time.Format "20060102"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewNameTemplate(test.template)
+			assert.Equal(t, test.expectedErr, err != nil, "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestNameTemplateCheckInterval(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		interval    time.Duration
+		expectedErr bool
+	}{
+		{name: "timestamp, every minute", template: "{{.Schedule}}-{{.Timestamp}}", interval: time.Minute},
+		{name: "date, daily", template: `{{.ClusterID}}-{{.Schedule}}-{{.Time.Format "2006-01-02"}}`, interval: 24 * time.Hour},
+		{name: "date, weekly", template: `{{.Schedule}}-{{.Time.Format "2006-01-02"}}`, interval: 7 * 24 * time.Hour},
+		{name: "date, hourly", template: `{{.Schedule}}-{{.Time.Format "2006-01-02"}}`, interval: time.Hour, expectedErr: true},
+		{name: "date, less than a day apart", template: `{{.Schedule}}-{{.Time.Format "2006-01-02"}}`, interval: 23 * time.Hour, expectedErr: true},
+		{name: "hour, every 30 minutes", template: `{{.Schedule}}-{{.Time.Format "2006010215"}}`, interval: 30 * time.Minute, expectedErr: true},
+		{name: "no time", template: "{{.Schedule}}", interval: 24 * time.Hour, expectedErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := NewNameTemplate(test.template)
+			require.NoError(t, err)
+
+			err = tmpl.CheckInterval(test.interval)
+			assert.Equal(t, test.expectedErr, err != nil, "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestNameTemplateName(t *testing.T) {
+	tmpl, err := NewNameTemplate(`{{.ClusterID}}-{{.Schedule}}-{{.Time.Format "2006-01-02"}}-{{.Timestamp}}`)
+	require.NoError(t, err)
+
+	timestamp := time.Date(2018, 6, 7, 8, 9, 10, 0, time.FixedZone("", 3600))
+
+	name, err := tmpl.Name(NewNameTemplateData("daily", timestamp, "prod"))
+	require.NoError(t, err)
+	assert.Equal(t, "prod-daily-2018-06-07-20180607070910", name)
+
+	// without a cluster ID the name starts with a hyphen, which isn't valid
+	_, err = tmpl.Name(NewNameTemplateData("daily", timestamp, ""))
+	assert.Error(t, err)
+}
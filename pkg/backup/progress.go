@@ -0,0 +1,154 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"archive/tar"
+	"sync"
+	"time"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/cloudprovider"
+)
+
+// Progress tracks the number of items a running backup has found and backed up,
+// and how long creating its volume snapshots has taken. It's safe for concurrent
+// use, so it can be read while the backup runs. A nil Progress tracks nothing.
+type Progress struct {
+	lock              sync.Mutex
+	totalItems        int
+	itemsBackedUp     int
+	snapshotsDuration time.Duration
+}
+
+// Get returns the backup's progress so far.
+func (p *Progress) Get() api.BackupProgress {
+	if p == nil {
+		return api.BackupProgress{}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	total := p.totalItems
+	// items added by item actions aren't found by listing, so they can
+	// outnumber the items found
+	if p.itemsBackedUp > total {
+		total = p.itemsBackedUp
+	}
+
+	return api.BackupProgress{
+		TotalItems:    total,
+		ItemsBackedUp: p.itemsBackedUp,
+	}
+}
+
+// SnapshotsDuration returns the total time creating the backup's volume snapshots has
+// taken so far.
+func (p *Progress) SnapshotsDuration() time.Duration {
+	if p == nil {
+		return 0
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.snapshotsDuration
+}
+
+// addItems records that n more items were found.
+func (p *Progress) addItems(n int) {
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.totalItems += n
+}
+
+// itemBackedUp records that an item was written to the backup.
+func (p *Progress) itemBackedUp() {
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.itemsBackedUp++
+}
+
+// snapshotCreated records that creating a volume snapshot took duration.
+func (p *Progress) snapshotCreated(duration time.Duration) {
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.snapshotsDuration += duration
+}
+
+// finish records that no more items will be backed up, so that items that were
+// found but skipped no longer count towards the total.
+func (p *Progress) finish() {
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.totalItems = p.itemsBackedUp
+}
+
+// progressTarWriter is a tarWriter that records each item written to it
+// as backed up.
+type progressTarWriter struct {
+	tarWriter
+	progress *Progress
+}
+
+func (w *progressTarWriter) WriteHeader(hdr *tar.Header) error {
+	if err := w.tarWriter.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	// every file in the tarball is a single item
+	w.progress.itemBackedUp()
+
+	return nil
+}
+
+// timedSnapshotService is a SnapshotService that records the time taken to create
+// each snapshot in progress.
+type timedSnapshotService struct {
+	cloudprovider.SnapshotService
+	progress *Progress
+}
+
+func (s *timedSnapshotService) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, string, error) {
+	started := time.Now()
+	defer func() {
+		s.progress.snapshotCreated(time.Since(started))
+	}()
+
+	return s.SnapshotService.CreateSnapshot(volumeID, volumeAZ, tags)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"archive/tar"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/cloudprovider"
+)
+
+func TestProgress(t *testing.T) {
+	progress := &Progress{}
+	w := &progressTarWriter{tarWriter: &fakeTarWriter{}, progress: progress}
+
+	progress.addItems(3)
+	assert.NoError(t, w.WriteHeader(&tar.Header{Name: "item-1"}))
+	assert.Equal(t, v1.BackupProgress{TotalItems: 3, ItemsBackedUp: 1}, progress.Get())
+
+	// items that fail to be written aren't backed up
+	w.tarWriter = &fakeTarWriter{writeHeaderError: errors.New("error")}
+	assert.Error(t, w.WriteHeader(&tar.Header{Name: "item-2"}))
+	assert.Equal(t, v1.BackupProgress{TotalItems: 3, ItemsBackedUp: 1}, progress.Get())
+
+	// additional items that weren't found by listing increase the total
+	w.tarWriter = &fakeTarWriter{}
+	for _, name := range []string{"item-2", "item-3", "additional-item"} {
+		assert.NoError(t, w.WriteHeader(&tar.Header{Name: name}))
+	}
+	assert.Equal(t, v1.BackupProgress{TotalItems: 4, ItemsBackedUp: 4}, progress.Get())
+
+	progress.addItems(2)
+	progress.finish()
+	assert.Equal(t, v1.BackupProgress{TotalItems: 4, ItemsBackedUp: 4}, progress.Get())
+}
+
+func TestNilProgress(t *testing.T) {
+	var progress *Progress
+
+	progress.addItems(1)
+	progress.itemBackedUp()
+	progress.snapshotCreated(time.Second)
+	progress.finish()
+	assert.Equal(t, v1.BackupProgress{}, progress.Get())
+	assert.Equal(t, time.Duration(0), progress.SnapshotsDuration())
+}
+
+type slowSnapshotService struct {
+	cloudprovider.SnapshotService
+	delay time.Duration
+}
+
+func (s *slowSnapshotService) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, string, error) {
+	time.Sleep(s.delay)
+	return "snap-" + volumeID, "", nil
+}
+
+func TestTimedSnapshotService(t *testing.T) {
+	progress := &Progress{}
+	snapshotService := &timedSnapshotService{
+		SnapshotService: &slowSnapshotService{delay: 10 * time.Millisecond},
+		progress:        progress,
+	}
+
+	for _, volumeID := range []string{"vol-1", "vol-2"} {
+		snapshotID, _, err := snapshotService.CreateSnapshot(volumeID, "zone-1", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "snap-"+volumeID, snapshotID)
+	}
+
+	assert.True(t, progress.SnapshotsDuration() >= 20*time.Millisecond, "snapshots duration %v is too short", progress.SnapshotsDuration())
+}
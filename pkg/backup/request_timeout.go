@@ -0,0 +1,122 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/heptio/ark/pkg/client"
+)
+
+// requestTimeoutError is returned by the clients of a timeoutDynamicFactory when a
+// request doesn't complete within the timeout.
+type requestTimeoutError struct {
+	verb     string
+	resource string
+	timeout  time.Duration
+}
+
+func (e *requestTimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting to %s %s", e.timeout, e.verb, e.resource)
+}
+
+// isRequestTimeout returns whether err was caused by a request timing out.
+func isRequestTimeout(err error) bool {
+	_, ok := errors.Cause(err).(*requestTimeoutError)
+	return ok
+}
+
+// timeoutDynamicFactory is a client.DynamicFactory whose clients' List and Get
+// requests fail with a requestTimeoutError if they take longer than timeout, so a
+// hung request doesn't block the backup. The dynamic clients don't support
+// cancellation, so a request that times out is abandoned rather than cancelled.
+type timeoutDynamicFactory struct {
+	client.DynamicFactory
+	timeout time.Duration
+}
+
+func (f *timeoutDynamicFactory) ClientForGroupVersionResource(gv schema.GroupVersion, resource metav1.APIResource, namespace string) (client.Dynamic, error) {
+	dynamicClient, err := f.DynamicFactory.ClientForGroupVersionResource(gv, resource, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	groupResource := schema.GroupResource{Group: gv.Group, Resource: resource.Name}
+	description := groupResource.String()
+	if namespace != "" {
+		description += " in namespace " + namespace
+	}
+
+	return &timeoutDynamic{Dynamic: dynamicClient, timeout: f.timeout, resource: description}, nil
+}
+
+// timeoutDynamic is a client.Dynamic whose List and Get requests time out.
+type timeoutDynamic struct {
+	client.Dynamic
+	timeout  time.Duration
+	resource string
+}
+
+func (d *timeoutDynamic) List(options metav1.ListOptions) (runtime.Object, error) {
+	res, err := d.withTimeout("list", func() (interface{}, error) {
+		return d.Dynamic.List(options)
+	})
+	list, _ := res.(runtime.Object)
+	return list, err
+}
+
+func (d *timeoutDynamic) Get(name string, opts metav1.GetOptions) (*unstructured.Unstructured, error) {
+	res, err := d.withTimeout("get", func() (interface{}, error) {
+		return d.Dynamic.Get(name, opts)
+	})
+	obj, _ := res.(*unstructured.Unstructured)
+	return obj, err
+}
+
+type requestResult struct {
+	res interface{}
+	err error
+}
+
+// withTimeout returns request's results, or a requestTimeoutError if it doesn't
+// return within the timeout.
+func (d *timeoutDynamic) withTimeout(verb string, request func() (interface{}, error)) (interface{}, error) {
+	// buffered so an abandoned request's goroutine can still exit
+	done := make(chan requestResult, 1)
+	go func() {
+		res, err := request()
+		done <- requestResult{res: res, err: err}
+	}()
+
+	timer := time.NewTimer(d.timeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		return result.res, result.err
+	case <-timer.C:
+		return nil, errors.WithStack(&requestTimeoutError{verb: verb, resource: d.resource, timeout: d.timeout})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestTimeoutDynamic(t *testing.T) {
+	appsV1 := schema.GroupVersion{Group: "apps", Version: "v1"}
+	deployments := metav1.APIResource{Name: "deployments", Namespaced: true}
+
+	newClient := func(t *testing.T) (*timeoutDynamic, *arktest.FakeDynamicClient) {
+		fakeClient := &arktest.FakeDynamicClient{}
+		dynamicFactory := &arktest.FakeDynamicFactory{}
+		dynamicFactory.On("ClientForGroupVersionResource", appsV1, deployments, "ns-1").Return(fakeClient, nil)
+
+		factory := &timeoutDynamicFactory{DynamicFactory: dynamicFactory, timeout: 50 * time.Millisecond}
+		c, err := factory.ClientForGroupVersionResource(appsV1, deployments, "ns-1")
+		require.NoError(t, err)
+		return c.(*timeoutDynamic), fakeClient
+	}
+
+	t.Run("list and get results are returned if they complete in time", func(t *testing.T) {
+		c, fakeClient := newClient(t)
+		list := &unstructured.UnstructuredList{}
+		obj := &unstructured.Unstructured{}
+		fakeClient.On("List", metav1.ListOptions{}).Return(list, nil)
+		fakeClient.On("Get", "deploy-1", metav1.GetOptions{}).Return(obj, nil)
+
+		res, err := c.List(metav1.ListOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, list, res)
+
+		got, err := c.Get("deploy-1", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, obj, got)
+	})
+
+	t.Run("errors are returned if they occur in time", func(t *testing.T) {
+		c, fakeClient := newClient(t)
+		fakeClient.On("List", metav1.ListOptions{}).Return(&unstructured.UnstructuredList{}, errors.New("list failed"))
+
+		_, err := c.List(metav1.ListOptions{})
+		require.EqualError(t, err, "list failed")
+		assert.False(t, isRequestTimeout(err))
+	})
+
+	t.Run("requests that don't complete in time return a timeout error", func(t *testing.T) {
+		c, fakeClient := newClient(t)
+		release := make(chan time.Time)
+		defer close(release)
+		fakeClient.On("List", metav1.ListOptions{}).WaitUntil(release).Return(&unstructured.UnstructuredList{}, nil)
+		fakeClient.On("Get", "deploy-1", metav1.GetOptions{}).WaitUntil(release).Return(&unstructured.Unstructured{}, nil)
+
+		res, err := c.List(metav1.ListOptions{})
+		require.Error(t, err)
+		assert.True(t, isRequestTimeout(err))
+		assert.Nil(t, res)
+		assert.EqualError(t, err, "timed out after 50ms waiting to list deployments.apps in namespace ns-1")
+
+		got, err := c.Get("deploy-1", metav1.GetOptions{})
+		require.Error(t, err)
+		assert.True(t, isRequestTimeout(err))
+		assert.Nil(t, got)
+	})
+}
@@ -17,13 +17,17 @@ limitations under the License.
 package backup
 
 import (
+	"fmt"
+
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cloudprovider"
+	"github.com/heptio/ark/pkg/csi"
 	"github.com/heptio/ark/pkg/discovery"
 	"github.com/heptio/ark/pkg/util/collections"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -48,6 +52,9 @@ type resourceBackupperFactory interface {
 		tarWriter tarWriter,
 		resourceHooks []resourceHook,
 		snapshotService cloudprovider.SnapshotService,
+		csiSnapshotter csi.Snapshotter,
+		snapshotThrottle snapshotThrottle,
+		progress *Progress,
 	) resourceBackupper
 }
 
@@ -68,6 +75,9 @@ func (f *defaultResourceBackupperFactory) newResourceBackupper(
 	tarWriter tarWriter,
 	resourceHooks []resourceHook,
 	snapshotService cloudprovider.SnapshotService,
+	csiSnapshotter csi.Snapshotter,
+	snapshotThrottle snapshotThrottle,
+	progress *Progress,
 ) resourceBackupper {
 	return &defaultResourceBackupper{
 		log:                   log,
@@ -84,6 +94,9 @@ func (f *defaultResourceBackupperFactory) newResourceBackupper(
 		tarWriter:             tarWriter,
 		resourceHooks:         resourceHooks,
 		snapshotService:       snapshotService,
+		csiSnapshotter:        csiSnapshotter,
+		snapshotThrottle:      snapshotThrottle,
+		progress:              progress,
 		itemBackupperFactory:  &defaultItemBackupperFactory{},
 	}
 }
@@ -107,6 +120,9 @@ type defaultResourceBackupper struct {
 	tarWriter             tarWriter
 	resourceHooks         []resourceHook
 	snapshotService       cloudprovider.SnapshotService
+	csiSnapshotter        csi.Snapshotter
+	snapshotThrottle      snapshotThrottle
+	progress              *Progress
 	itemBackupperFactory  itemBackupperFactory
 }
 
@@ -181,6 +197,8 @@ func (rb *defaultResourceBackupper) backupResource(
 		rb.dynamicFactory,
 		rb.discoveryHelper,
 		rb.snapshotService,
+		rb.csiSnapshotter,
+		rb.snapshotThrottle,
 	)
 
 	namespacesToList := getNamespacesToList(rb.namespaces)
@@ -201,9 +219,15 @@ func (rb *defaultResourceBackupper) backupResource(
 			}
 		}
 
+		rb.progress.addItems(len(namespacesToList))
+
 		for _, ns := range namespacesToList {
 			log.WithField("namespace", ns).Info("Getting namespace")
 			unstructured, err := resourceClient.Get(ns, metav1.GetOptions{})
+			if isRequestTimeout(err) {
+				rb.addTimeoutWarning(log, err)
+				continue
+			}
 			if err != nil {
 				errs = append(errs, errors.Wrap(err, "error getting namespace"))
 				continue
@@ -235,18 +259,18 @@ func (rb *defaultResourceBackupper) backupResource(
 		}
 
 		log.WithField("namespace", namespace).Info("Listing items")
-		unstructuredList, err := resourceClient.List(metav1.ListOptions{LabelSelector: rb.labelSelector})
-		if err != nil {
-			return errors.WithStack(err)
+		items, err := rb.listItems(log, resourceClient)
+		if isRequestTimeout(err) {
+			rb.addTimeoutWarning(log, err)
+			continue
 		}
-
-		// do the backup
-		items, err := meta.ExtractList(unstructuredList)
 		if err != nil {
-			return errors.WithStack(err)
+			return err
 		}
 
 		log.WithField("namespace", namespace).Infof("Retrieved %d items", len(items))
+		rb.progress.addItems(len(items))
+
 		for _, item := range items {
 			unstructured, ok := item.(runtime.Unstructured)
 			if !ok {
@@ -265,6 +289,11 @@ func (rb *defaultResourceBackupper) backupResource(
 				continue
 			}
 
+			if rb.backup.Spec.RequireIncludeAnnotation && gr != namespacesGroupResource && metadata.GetAnnotations()[api.IncludeAnnotation] != "true" {
+				log.WithField("name", metadata.GetName()).Infof("skipping item because it isn't annotated with %s=true", api.IncludeAnnotation)
+				continue
+			}
+
 			if err := itemBackupper.backupItem(log, unstructured, gr); err != nil {
 				errs = append(errs, err)
 			}
@@ -274,6 +303,79 @@ func (rb *defaultResourceBackupper) backupResource(
 	return kuberrs.NewAggregate(errs)
 }
 
+// addTimeoutWarning records a warning on the backup that a request timed out, in
+// which case its items are skipped rather than failing the backup.
+func (rb *defaultResourceBackupper) addTimeoutWarning(log logrus.FieldLogger, err error) {
+	log.WithError(err).Warn("Request timed out, skipping its items")
+	rb.backup.Status.Warnings = append(rb.backup.Status.Warnings, fmt.Sprintf("skipped items: %v", err))
+}
+
+// consistentListPageSize is the number of items requested per page when listing
+// at a resourceVersion watermark.
+const consistentListPageSize = 500
+
+// listItems lists all items for the resource client. If the backup recorded a
+// resourceVersion watermark, items are listed at that resourceVersion, falling back
+// to a regular list if the API server can no longer serve it.
+func (rb *defaultResourceBackupper) listItems(log logrus.FieldLogger, resourceClient client.Dynamic) ([]runtime.Object, error) {
+	resourceVersion := rb.backup.Status.ListResourceVersion
+	if resourceVersion == "" {
+		return listAll(resourceClient, metav1.ListOptions{LabelSelector: rb.labelSelector})
+	}
+
+	items, err := listAll(resourceClient, metav1.ListOptions{
+		LabelSelector:   rb.labelSelector,
+		ResourceVersion: resourceVersion,
+		Limit:           consistentListPageSize,
+	})
+	if err != nil {
+		if cause := errors.Cause(err); apierrors.IsResourceExpired(cause) || apierrors.IsGone(cause) || apierrors.IsBadRequest(cause) {
+			log.WithError(err).WithField("resourceVersion", resourceVersion).Warn("Unable to list items at resourceVersion watermark, listing latest items instead")
+			return listAll(resourceClient, metav1.ListOptions{LabelSelector: rb.labelSelector})
+		}
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// listAll lists items for the resource client using the given options, following
+// continue tokens until all pages have been retrieved.
+func listAll(resourceClient client.Dynamic, options metav1.ListOptions) ([]runtime.Object, error) {
+	var items []runtime.Object
+
+	for {
+		list, err := resourceClient.List(options)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		pageItems, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		items = append(items, pageItems...)
+
+		if options.Limit == 0 {
+			return items, nil
+		}
+
+		listAccessor, err := meta.ListAccessor(list)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if listAccessor.GetContinue() == "" {
+			return items, nil
+		}
+
+		// the continue token encodes the resourceVersion, so it must not be
+		// specified on subsequent requests
+		options.Continue = listAccessor.GetContinue()
+		options.ResourceVersion = ""
+	}
+}
+
 // getNamespacesToList examines ie and resolves the includes and excludes to a full list of
 // namespaces to list. If ie is nil or it includes *, the result is just "" (list across all
 // namespaces). Otherwise, the result is a list of every included namespace minus all excluded ones.
@@ -18,16 +18,20 @@ package backup
 
 import (
 	"testing"
+	"time"
 
 	"github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cloudprovider"
+	"github.com/heptio/ark/pkg/csi"
 	"github.com/heptio/ark/pkg/discovery"
 	"github.com/heptio/ark/pkg/util/collections"
 	arktest "github.com/heptio/ark/pkg/util/test"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -271,6 +275,9 @@ func TestBackupResource(t *testing.T) {
 				tarWriter,
 				resourceHooks,
 				nil,
+				nil,
+				nil,
+				nil,
 			).(*defaultResourceBackupper)
 
 			itemBackupperFactory := &mockItemBackupperFactory{}
@@ -293,6 +300,8 @@ func TestBackupResource(t *testing.T) {
 					dynamicFactory,
 					discoveryHelper,
 					mock.Anything,
+					mock.Anything,
+					mock.Anything,
 				).Return(itemBackupper)
 
 				if len(test.listResponses) > 0 {
@@ -430,6 +439,9 @@ func TestBackupResourceCohabitation(t *testing.T) {
 				tarWriter,
 				resourceHooks,
 				nil,
+				nil,
+				nil,
+				nil,
 			).(*defaultResourceBackupper)
 
 			itemBackupperFactory := &mockItemBackupperFactory{}
@@ -451,6 +463,8 @@ func TestBackupResourceCohabitation(t *testing.T) {
 				dynamicFactory,
 				discoveryHelper,
 				mock.Anything,
+				mock.Anything,
+				mock.Anything,
 			).Return(itemBackupper)
 
 			client := &arktest.FakeDynamicClient{}
@@ -511,6 +525,9 @@ func TestBackupResourceOnlyIncludesSpecifiedNamespaces(t *testing.T) {
 		tarWriter,
 		resourceHooks,
 		nil,
+		nil,
+		nil,
+		nil,
 	).(*defaultResourceBackupper)
 
 	itemBackupperFactory := &mockItemBackupperFactory{}
@@ -546,6 +563,8 @@ func TestBackupResourceOnlyIncludesSpecifiedNamespaces(t *testing.T) {
 		dynamicFactory,
 		discoveryHelper,
 		mock.Anything,
+		mock.Anything,
+		mock.Anything,
 	).Return(itemBackupper)
 
 	client := &arktest.FakeDynamicClient{}
@@ -606,6 +625,9 @@ func TestBackupResourceListAllNamespacesExcludesCorrectly(t *testing.T) {
 		tarWriter,
 		resourceHooks,
 		nil,
+		nil,
+		nil,
+		nil,
 	).(*defaultResourceBackupper)
 
 	itemBackupperFactory := &mockItemBackupperFactory{}
@@ -630,6 +652,8 @@ func TestBackupResourceListAllNamespacesExcludesCorrectly(t *testing.T) {
 		dynamicFactory,
 		discoveryHelper,
 		mock.Anything,
+		mock.Anything,
+		mock.Anything,
 	).Return(itemBackupper)
 
 	client := &arktest.FakeDynamicClient{}
@@ -651,6 +675,268 @@ func TestBackupResourceListAllNamespacesExcludesCorrectly(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestBackupResourceRequireIncludeAnnotation(t *testing.T) {
+	backup := &v1.Backup{Spec: v1.BackupSpec{RequireIncludeAnnotation: true}}
+
+	namespaces := collections.NewIncludesExcludes().Includes("*")
+	resources := collections.NewIncludesExcludes().Includes("*")
+
+	backedUpItems := map[itemKey]struct{}{}
+
+	dynamicFactory := &arktest.FakeDynamicFactory{}
+	defer dynamicFactory.AssertExpectations(t)
+
+	discoveryHelper := arktest.NewFakeDiscoveryHelper(true, nil)
+
+	cohabitatingResources := map[string]*cohabitatingResource{}
+
+	actions := []resolvedAction{}
+
+	resourceHooks := []resourceHook{}
+
+	podCommandExecutor := &mockPodCommandExecutor{}
+	defer podCommandExecutor.AssertExpectations(t)
+
+	tarWriter := &fakeTarWriter{}
+
+	rb := (&defaultResourceBackupperFactory{}).newResourceBackupper(
+		arktest.NewLogger(),
+		backup,
+		namespaces,
+		resources,
+		"",
+		dynamicFactory,
+		discoveryHelper,
+		backedUpItems,
+		cohabitatingResources,
+		actions,
+		podCommandExecutor,
+		tarWriter,
+		resourceHooks,
+		nil,
+		nil,
+		nil,
+		nil,
+	).(*defaultResourceBackupper)
+
+	itemBackupperFactory := &mockItemBackupperFactory{}
+	defer itemBackupperFactory.AssertExpectations(t)
+	rb.itemBackupperFactory = itemBackupperFactory
+
+	itemBackupper := &mockItemBackupper{}
+	defer itemBackupper.AssertExpectations(t)
+
+	itemBackupperFactory.On("newItemBackupper",
+		backup,
+		namespaces,
+		resources,
+		backedUpItems,
+		actions,
+		podCommandExecutor,
+		tarWriter,
+		resourceHooks,
+		dynamicFactory,
+		discoveryHelper,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+	).Return(itemBackupper)
+
+	client := &arktest.FakeDynamicClient{}
+	defer client.AssertExpectations(t)
+
+	coreV1Group := schema.GroupVersion{Group: "", Version: "v1"}
+	dynamicFactory.On("ClientForGroupVersionResource", coreV1Group, configMapsResource, "").Return(client, nil)
+
+	annotated := unstructuredOrDie(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"ns-1","name":"cm-1","annotations":{"backup.ark.heptio.com/include":"true"}}}`)
+	notAnnotated := unstructuredOrDie(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"ns-1","name":"cm-2"}}`)
+	notTrue := unstructuredOrDie(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"ns-1","name":"cm-3","annotations":{"backup.ark.heptio.com/include":"false"}}}`)
+	list := &unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{*annotated, *notAnnotated, *notTrue},
+	}
+	client.On("List", metav1.ListOptions{}).Return(list, nil)
+
+	itemBackupper.On("backupItem", mock.AnythingOfType("*logrus.Entry"), annotated, schema.GroupResource{Resource: "configmaps"}).Return(nil)
+
+	err := rb.backupResource(v1Group, configMapsResource)
+	require.NoError(t, err)
+}
+
+func TestBackupResourceRequestTimeout(t *testing.T) {
+	backup := &v1.Backup{}
+
+	namespaces := collections.NewIncludesExcludes().Includes("ns-1", "ns-2")
+	resources := collections.NewIncludesExcludes().Includes("*")
+
+	backedUpItems := map[itemKey]struct{}{}
+
+	fakeDynamicFactory := &arktest.FakeDynamicFactory{}
+	defer fakeDynamicFactory.AssertExpectations(t)
+	dynamicFactory := &timeoutDynamicFactory{DynamicFactory: fakeDynamicFactory, timeout: 50 * time.Millisecond}
+
+	discoveryHelper := arktest.NewFakeDiscoveryHelper(true, nil)
+
+	cohabitatingResources := map[string]*cohabitatingResource{}
+
+	actions := []resolvedAction{}
+
+	resourceHooks := []resourceHook{}
+
+	podCommandExecutor := &mockPodCommandExecutor{}
+	defer podCommandExecutor.AssertExpectations(t)
+
+	tarWriter := &fakeTarWriter{}
+
+	rb := (&defaultResourceBackupperFactory{}).newResourceBackupper(
+		arktest.NewLogger(),
+		backup,
+		namespaces,
+		resources,
+		"",
+		dynamicFactory,
+		discoveryHelper,
+		backedUpItems,
+		cohabitatingResources,
+		actions,
+		podCommandExecutor,
+		tarWriter,
+		resourceHooks,
+		nil,
+		nil,
+		nil,
+		nil,
+	).(*defaultResourceBackupper)
+
+	itemBackupperFactory := &mockItemBackupperFactory{}
+	defer itemBackupperFactory.AssertExpectations(t)
+	rb.itemBackupperFactory = itemBackupperFactory
+
+	itemBackupper := &mockItemBackupper{}
+	defer itemBackupper.AssertExpectations(t)
+
+	itemBackupperFactory.On("newItemBackupper",
+		backup,
+		namespaces,
+		resources,
+		backedUpItems,
+		actions,
+		podCommandExecutor,
+		tarWriter,
+		resourceHooks,
+		dynamicFactory,
+		discoveryHelper,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+	).Return(itemBackupper)
+
+	coreV1Group := schema.GroupVersion{Group: "", Version: "v1"}
+
+	// ns-1's list hangs, so it's skipped with a warning and ns-2 is still backed up
+	hungClient := &arktest.FakeDynamicClient{}
+	defer hungClient.AssertExpectations(t)
+	release := make(chan time.Time)
+	defer close(release)
+	fakeDynamicFactory.On("ClientForGroupVersionResource", coreV1Group, configMapsResource, "ns-1").Return(hungClient, nil)
+	hungClient.On("List", metav1.ListOptions{}).WaitUntil(release).Return(&unstructured.UnstructuredList{}, nil)
+
+	client := &arktest.FakeDynamicClient{}
+	defer client.AssertExpectations(t)
+	fakeDynamicFactory.On("ClientForGroupVersionResource", coreV1Group, configMapsResource, "ns-2").Return(client, nil)
+
+	cm := unstructuredOrDie(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"ns-2","name":"cm-1"}}`)
+	client.On("List", metav1.ListOptions{}).Return(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{*cm}}, nil)
+
+	itemBackupper.On("backupItem", mock.AnythingOfType("*logrus.Entry"), cm, schema.GroupResource{Resource: "configmaps"}).Return(nil)
+
+	err := rb.backupResource(v1Group, configMapsResource)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"skipped items: timed out after 50ms waiting to list configmaps in namespace ns-1"}, backup.Status.Warnings)
+}
+
+func TestListItems(t *testing.T) {
+	cm1 := unstructuredOrDie(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"ns-1","name":"cm-1"}}`)
+	cm2 := unstructuredOrDie(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"ns-1","name":"cm-2"}}`)
+
+	tests := []struct {
+		name            string
+		resourceVersion string
+		setup           func(client *arktest.FakeDynamicClient)
+		expectedItems   []*unstructured.Unstructured
+		expectedErr     bool
+	}{
+		{
+			name: "no watermark lists latest items",
+			setup: func(client *arktest.FakeDynamicClient) {
+				list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*cm1, *cm2}}
+				client.On("List", metav1.ListOptions{LabelSelector: "foo=bar"}).Return(list, nil)
+			},
+			expectedItems: []*unstructured.Unstructured{cm1, cm2},
+		},
+		{
+			name:            "watermark lists at resourceVersion and follows continue tokens",
+			resourceVersion: "123",
+			setup: func(client *arktest.FakeDynamicClient) {
+				page1 := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*cm1}}
+				page1.SetContinue("next")
+				client.On("List", metav1.ListOptions{LabelSelector: "foo=bar", ResourceVersion: "123", Limit: consistentListPageSize}).Return(page1, nil)
+
+				page2 := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*cm2}}
+				client.On("List", metav1.ListOptions{LabelSelector: "foo=bar", Continue: "next", Limit: consistentListPageSize}).Return(page2, nil)
+			},
+			expectedItems: []*unstructured.Unstructured{cm1, cm2},
+		},
+		{
+			name:            "expired watermark falls back to latest items",
+			resourceVersion: "123",
+			setup: func(client *arktest.FakeDynamicClient) {
+				expired := apierrors.NewResourceExpired("too old resource version")
+				client.On("List", metav1.ListOptions{LabelSelector: "foo=bar", ResourceVersion: "123", Limit: consistentListPageSize}).Return(&unstructured.UnstructuredList{}, expired)
+
+				list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*cm2}}
+				client.On("List", metav1.ListOptions{LabelSelector: "foo=bar"}).Return(list, nil)
+			},
+			expectedItems: []*unstructured.Unstructured{cm2},
+		},
+		{
+			name:            "other errors are returned",
+			resourceVersion: "123",
+			setup: func(client *arktest.FakeDynamicClient) {
+				client.On("List", metav1.ListOptions{LabelSelector: "foo=bar", ResourceVersion: "123", Limit: consistentListPageSize}).Return(&unstructured.UnstructuredList{}, errors.New("bang"))
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backup := &v1.Backup{}
+			backup.Status.ListResourceVersion = test.resourceVersion
+
+			rb := &defaultResourceBackupper{
+				backup:        backup,
+				labelSelector: "foo=bar",
+			}
+
+			client := &arktest.FakeDynamicClient{}
+			defer client.AssertExpectations(t)
+			test.setup(client)
+
+			items, err := rb.listItems(arktest.NewLogger(), client)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Len(t, items, len(test.expectedItems))
+			for i := range test.expectedItems {
+				assert.Equal(t, test.expectedItems[i].GetName(), items[i].(*unstructured.Unstructured).GetName())
+			}
+		})
+	}
+}
+
 type mockItemBackupperFactory struct {
 	mock.Mock
 }
@@ -666,6 +952,8 @@ func (ibf *mockItemBackupperFactory) newItemBackupper(
 	dynamicFactory client.DynamicFactory,
 	discoveryHelper discovery.Helper,
 	snapshotService cloudprovider.SnapshotService,
+	csiSnapshotter csi.Snapshotter,
+	snapshotThrottle snapshotThrottle,
 ) ItemBackupper {
 	args := ibf.Called(
 		backup,
@@ -679,6 +967,8 @@ func (ibf *mockItemBackupperFactory) newItemBackupper(
 		dynamicFactory,
 		discoveryHelper,
 		snapshotService,
+		csiSnapshotter,
+		snapshotThrottle,
 	)
 	return args.Get(0).(ItemBackupper)
 }
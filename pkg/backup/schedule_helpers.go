@@ -0,0 +1,51 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+// NewScheduledBackup creates a Backup of schedule's template, labeled with the schedule's
+// name, and named after the schedule and timestamp.
+func NewScheduledBackup(schedule *v1.Schedule, timestamp time.Time) *v1.Backup {
+	backup := &v1.Backup{
+		Spec: schedule.Spec.Template,
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: schedule.Namespace,
+			Name:      fmt.Sprintf("%s-%s", schedule.Name, timestamp.Format("20060102150405")),
+			Labels: map[string]string{
+				v1.ScheduleNameLabel: schedule.Name,
+			},
+		},
+	}
+
+	if schedule.Labels[v1.DeletionApprovalRequiredLabel] == "true" {
+		backup.Labels[v1.DeletionApprovalRequiredLabel] = "true"
+	}
+
+	if class := schedule.Annotations[v1.RetentionClassAnnotation]; class != "" {
+		backup.Labels[v1.RetentionClassLabel] = class
+	}
+
+	return backup
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+func TestNewScheduledBackup(t *testing.T) {
+	tests := []struct {
+		name           string
+		schedule       *v1.Schedule
+		testClockTime  string
+		expectedBackup *v1.Backup
+	}{
+		{
+			name: "ensure name is formatted correctly (AM time)",
+			schedule: &v1.Schedule{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "foo",
+					Name:      "bar",
+				},
+				Spec: v1.ScheduleSpec{
+					Template: v1.BackupSpec{},
+				},
+			},
+			testClockTime: "2017-07-25 09:15:00",
+			expectedBackup: &v1.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "foo",
+					Labels:    map[string]string{v1.ScheduleNameLabel: "bar"},
+					Name:      "bar-20170725091500",
+				},
+				Spec: v1.BackupSpec{},
+			},
+		},
+		{
+			name: "ensure name is formatted correctly (PM time)",
+			schedule: &v1.Schedule{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "foo",
+					Name:      "bar",
+				},
+				Spec: v1.ScheduleSpec{
+					Template: v1.BackupSpec{},
+				},
+			},
+			testClockTime: "2017-07-25 14:15:00",
+			expectedBackup: &v1.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "foo",
+					Labels:    map[string]string{v1.ScheduleNameLabel: "bar"},
+					Name:      "bar-20170725141500",
+				},
+				Spec: v1.BackupSpec{},
+			},
+		},
+		{
+			name: "ensure schedule backup template is copied",
+			schedule: &v1.Schedule{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "foo",
+					Name:      "bar",
+				},
+				Spec: v1.ScheduleSpec{
+					Template: v1.BackupSpec{
+						IncludedNamespaces: []string{"ns-1", "ns-2"},
+						ExcludedNamespaces: []string{"ns-3"},
+						IncludedResources:  []string{"foo", "bar"},
+						ExcludedResources:  []string{"baz"},
+						LabelSelector:      &metav1.LabelSelector{MatchLabels: map[string]string{"label": "value"}},
+						TTL:                metav1.Duration{Duration: time.Duration(300)},
+					},
+				},
+			},
+			testClockTime: "2017-07-25 09:15:00",
+			expectedBackup: &v1.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "foo",
+					Labels:    map[string]string{v1.ScheduleNameLabel: "bar"},
+					Name:      "bar-20170725091500",
+				},
+				Spec: v1.BackupSpec{
+					IncludedNamespaces: []string{"ns-1", "ns-2"},
+					ExcludedNamespaces: []string{"ns-3"},
+					IncludedResources:  []string{"foo", "bar"},
+					ExcludedResources:  []string{"baz"},
+					LabelSelector:      &metav1.LabelSelector{MatchLabels: map[string]string{"label": "value"}},
+					TTL:                metav1.Duration{Duration: time.Duration(300)},
+				},
+			},
+		},
+		{
+			name: "ensure the deletion approval required label is copied",
+			schedule: &v1.Schedule{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "foo",
+					Name:      "bar",
+					Labels:    map[string]string{v1.DeletionApprovalRequiredLabel: "true", "other": "label"},
+				},
+			},
+			testClockTime: "2017-07-25 09:15:00",
+			expectedBackup: &v1.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "foo",
+					Name:      "bar-20170725091500",
+					Labels:    map[string]string{v1.ScheduleNameLabel: "bar", v1.DeletionApprovalRequiredLabel: "true"},
+				},
+			},
+		},
+		{
+			name: "ensure the retention class annotation is applied as a label",
+			schedule: &v1.Schedule{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "foo",
+					Name:        "bar",
+					Annotations: map[string]string{v1.RetentionClassAnnotation: "config"},
+				},
+			},
+			testClockTime: "2017-07-25 09:15:00",
+			expectedBackup: &v1.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "foo",
+					Name:      "bar-20170725091500",
+					Labels:    map[string]string{v1.ScheduleNameLabel: "bar", v1.RetentionClassLabel: "config"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testTime, err := time.Parse("2006-01-02 15:04:05", test.testClockTime)
+			require.NoError(t, err, "unable to parse test.testClockTime: %v", err)
+
+			backup := NewScheduledBackup(test.schedule, testTime)
+
+			assert.Equal(t, test.expectedBackup.Namespace, backup.Namespace)
+			assert.Equal(t, test.expectedBackup.Name, backup.Name)
+			assert.Equal(t, test.expectedBackup.Labels, backup.Labels)
+			assert.Equal(t, test.expectedBackup.Spec, backup.Spec)
+		})
+	}
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/cloudprovider"
+	"github.com/heptio/ark/pkg/util/collections"
+)
+
+// groupSnapshot is a volume's snapshot taken in a consistency group.
+type groupSnapshot struct {
+	snapshotID string
+	groupID    string
+	used       bool
+}
+
+// volumeGroup is the volumes of a backup's PersistentVolumeClaims in a namespace that have
+// the same value for its volume group label, which are snapshotted as a consistency group.
+type volumeGroup struct {
+	name      string
+	namespace string
+	claims    []string
+	// volumeID -> availability zone
+	volumes map[string]string
+	// whether the group has been snapshotted, or failed to be
+	attempted bool
+}
+
+// groupSnapshotService is a SnapshotService that snapshots each of a backup's volume groups
+// as a consistency group when the first of its volumes is snapshotted, once the pre-snapshot
+// hooks of the pods using the group's claims have run. Each volume's CreateSnapshot returns
+// its snapshot from the group the first time it's called, rather than taking another one.
+type groupSnapshotService struct {
+	cloudprovider.SnapshotService
+
+	backup   *api.Backup
+	log      logrus.FieldLogger
+	progress *Progress
+
+	lock sync.Mutex
+	// volumeID -> group
+	groups map[string]*volumeGroup
+	// volumeID -> snapshot
+	snapshots map[string]*groupSnapshot
+	errs      []error
+}
+
+// groupState returns the group of volumeID if the group hasn't been snapshotted yet, and
+// whether volumeID has a snapshot from its group that hasn't been used.
+func (s *groupSnapshotService) groupState(volumeID string) (*volumeGroup, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if snapshot, found := s.snapshots[volumeID]; found && !snapshot.used {
+		return nil, true
+	}
+	if group := s.groups[volumeID]; group != nil && !group.attempted {
+		return group, false
+	}
+	return nil, false
+}
+
+func (s *groupSnapshotService) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, string, error) {
+	s.lock.Lock()
+	if group := s.groups[volumeID]; group != nil && !group.attempted {
+		s.snapshotGroup(group)
+	}
+
+	snapshot, found := s.snapshots[volumeID]
+	if found && !snapshot.used {
+		snapshot.used = true
+		s.lock.Unlock()
+		return snapshot.snapshotID, "", nil
+	}
+	s.lock.Unlock()
+
+	return s.SnapshotService.CreateSnapshot(volumeID, volumeAZ, tags)
+}
+
+// snapshotGroup snapshots group's volumes as a consistency group. If that fails, or the
+// snapshot service doesn't support consistency groups, the volumes are snapshotted
+// individually. The lock must be held.
+func (s *groupSnapshotService) snapshotGroup(group *volumeGroup) {
+	group.attempted = true
+
+	log := s.log.WithFields(logrus.Fields{"volumeGroupLabel": s.backup.Spec.VolumeGroupLabel, "volumeGroup": group.name})
+
+	tags := backupSnapshotTags(s.backup)
+	tags["ark.heptio.com/backup"] = s.backup.Name
+	tags["ark.heptio.com/volume-group"] = group.name
+
+	log.Infof("Snapshotting %d volumes as a consistency group", len(group.volumes))
+	started := time.Now()
+	groupID, snapshotIDs, err := s.SnapshotService.CreateSnapshotGroup(group.volumes, tags)
+	if s.progress != nil {
+		s.progress.snapshotCreated(time.Since(started))
+	}
+	if err != nil {
+		// log+error on purpose - log goes to the per-backup log file, error goes to the backup
+		log.WithError(err).Error("Error creating snapshot group; snapshotting volumes individually")
+		s.errs = append(s.errs, errors.WithMessage(err, "error creating snapshot group"))
+		return
+	}
+	if groupID == "" {
+		log.Info("Block store can't snapshot the group's volumes as a consistency group; snapshotting them individually")
+		return
+	}
+
+	log.WithField("groupID", groupID).Info("Created snapshot group")
+	for volumeID, snapshotID := range snapshotIDs {
+		s.snapshots[volumeID] = &groupSnapshot{snapshotID: snapshotID, groupID: groupID}
+	}
+}
+
+// finish records the group IDs of the backup's volume snapshots that were taken in
+// consistency groups, deletes the ones taken of volumes that weren't backed up, and
+// returns the errors creating snapshot groups.
+func (s *groupSnapshotService) finish(backup *api.Backup, log logrus.FieldLogger) []error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	groupIDs := make(map[string]string)
+	for volumeID, snapshot := range s.snapshots {
+		if snapshot.used {
+			groupIDs[snapshot.snapshotID] = snapshot.groupID
+			continue
+		}
+
+		log := log.WithFields(logrus.Fields{"volumeID": volumeID, "snapshotID": snapshot.snapshotID, "groupID": snapshot.groupID})
+		log.Info("Deleting snapshot of volume that wasn't backed up")
+		if err := s.SnapshotService.DeleteSnapshot(snapshot.snapshotID); err != nil {
+			log.WithError(err).Error("Error deleting snapshot")
+		}
+	}
+
+	for _, volumeBackup := range backup.Status.VolumeBackups {
+		if groupID, found := groupIDs[volumeBackup.SnapshotID]; found {
+			volumeBackup.SnapshotGroupID = groupID
+		}
+	}
+
+	return s.errs
+}
+
+// newGroupSnapshotService returns a groupSnapshotService that snapshots the volumes of the
+// backup's PersistentVolumeClaims that have the same value for the backup's volume group
+// label, in the same namespace, as consistency groups, with snapshotService.
+func (kb *kubernetesBackupper) newGroupSnapshotService(
+	log logrus.FieldLogger,
+	backup *api.Backup,
+	namespaces *collections.IncludesExcludes,
+	snapshotService cloudprovider.SnapshotService,
+	progress *Progress,
+) (*groupSnapshotService, error) {
+	s := &groupSnapshotService{
+		SnapshotService: snapshotService,
+		backup:          backup,
+		log:             log,
+		progress:        progress,
+		groups:          make(map[string]*volumeGroup),
+		snapshots:       make(map[string]*groupSnapshot),
+	}
+
+	if backup.Spec.VolumeGroupLabel == "" || (backup.Spec.SnapshotVolumes != nil && !*backup.Spec.SnapshotVolumes) {
+		return s, nil
+	}
+
+	groups, err := kb.getVolumeGroups(backup, namespaces)
+	if err != nil {
+		return s, err
+	}
+
+	for _, group := range groups {
+		// a single volume is consistent with itself
+		if len(group.volumes) < 2 {
+			continue
+		}
+		for volumeID := range group.volumes {
+			s.groups[volumeID] = group
+		}
+	}
+
+	return s, nil
+}
+
+// getVolumeGroups returns the groups of the volumes of the backup's PersistentVolumeClaims
+// that have its volume group label and are selected for snapshots, keyed by their claims'
+// namespace and label value.
+func (kb *kubernetesBackupper) getVolumeGroups(backup *api.Backup, namespaces *collections.IncludesExcludes) (map[string]*volumeGroup, error) {
+	var selector labels.Selector
+	if backup.Spec.VolumeSnapshotSelector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(backup.Spec.VolumeSnapshotSelector); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	pvcGVR, pvcResource, err := kb.discoveryHelper.ResourceFor(pvcGroupResource.WithVersion(""))
+	if err != nil {
+		return nil, err
+	}
+	pvcClient, err := kb.dynamicFactory.ClientForGroupVersionResource(pvcGVR.GroupVersion(), pvcResource, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// claims without the label aren't in any group
+	list, err := pvcClient.List(metav1.ListOptions{LabelSelector: backup.Spec.VolumeGroupLabel})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	pvcs, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	pvGVR, pvResource, err := kb.discoveryHelper.ResourceFor(pvGroupResource.WithVersion(""))
+	if err != nil {
+		return nil, err
+	}
+	pvClient, err := kb.dynamicFactory.ClientForGroupVersionResource(pvGVR.GroupVersion(), pvResource, "")
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*volumeGroup)
+	for _, item := range pvcs {
+		pvc, ok := item.(runtime.Unstructured)
+		if !ok {
+			return nil, errors.Errorf("unexpected type %T", item)
+		}
+		pvcMetadata, err := meta.Accessor(pvc)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if !namespaces.ShouldInclude(pvcMetadata.GetNamespace()) {
+			continue
+		}
+
+		volumeName, _ := collections.GetString(pvc.UnstructuredContent(), "spec.volumeName")
+		if volumeName == "" {
+			// not bound yet
+			continue
+		}
+
+		pv, err := pvClient.Get(volumeName, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		pvMetadata, err := meta.Accessor(pv)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if selector != nil && !selector.Matches(labels.Set(pvMetadata.GetLabels())) && !selector.Matches(labels.Set(pvcMetadata.GetLabels())) {
+			continue
+		}
+
+		volumeID, err := kb.snapshotService.GetVolumeID(pv)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting volume ID for PersistentVolume %s", volumeName)
+		}
+		if volumeID == "" {
+			continue
+		}
+
+		name := pvcMetadata.GetNamespace() + "/" + pvcMetadata.GetLabels()[backup.Spec.VolumeGroupLabel]
+		if groups[name] == nil {
+			groups[name] = &volumeGroup{
+				name:      name,
+				namespace: pvcMetadata.GetNamespace(),
+				volumes:   make(map[string]string),
+			}
+		}
+		groups[name].claims = append(groups[name].claims, pvcMetadata.GetName())
+		groups[name].volumes[volumeID] = pvMetadata.GetLabels()[zoneLabel]
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,333 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/util/collections"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestNewGroupSnapshotService(t *testing.T) {
+	// namespace, PVC name, group label value, PV name
+	claims := [][]string{
+		{"ns-1", "db-0", "db", "pv-1"},
+		{"ns-1", "db-1", "db", "pv-2"},
+		{"ns-1", "web", "web", "pv-3"},
+		{"ns-2", "db", "db", "pv-4"},
+		{"ns-3", "db-0", "db", "pv-5"},
+		{"ns-3", "db-1", "db", "pv-6"},
+		{"ns-1", "db-2", "db", ""},
+	}
+
+	snapshotVolumes := false
+
+	tests := []struct {
+		name             string
+		backup           *v1.Backup
+		expectedGroup    *volumeGroup
+		expectNoListings bool
+	}{
+		{
+			name:             "no volume group label doesn't group anything",
+			backup:           &v1.Backup{},
+			expectNoListings: true,
+		},
+		{
+			name:             "volume snapshots disabled doesn't group anything",
+			backup:           &v1.Backup{Spec: v1.BackupSpec{VolumeGroupLabel: "app", SnapshotVolumes: &snapshotVolumes}},
+			expectNoListings: true,
+		},
+		{
+			name:          "volumes of included namespaces' claims with the same label value are grouped together",
+			backup:        &v1.Backup{Spec: v1.BackupSpec{VolumeGroupLabel: "app"}},
+			expectedGroup: &volumeGroup{name: "ns-1/db", namespace: "ns-1", claims: []string{"db-0", "db-1"}, volumes: map[string]string{"vol-1": "", "vol-2": ""}},
+		},
+		{
+			name: "volumes not matching the volume snapshot selector aren't in groups",
+			backup: &v1.Backup{Spec: v1.BackupSpec{
+				VolumeGroupLabel:       "app",
+				VolumeSnapshotSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"snapshot": "true"}},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				dynamicFactory  = &arktest.FakeDynamicFactory{}
+				pvcClient       = &arktest.FakeDynamicClient{}
+				pvClient        = &arktest.FakeDynamicClient{}
+				snapshotService = &arktest.FakeSnapshotService{
+					SnapshotGroupID:      "group-1",
+					SnapshottableVolumes: map[string]v1.VolumeBackupInfo{},
+					VolumeIDs:            map[string]string{},
+				}
+				kb = &kubernetesBackupper{
+					dynamicFactory:  dynamicFactory,
+					discoveryHelper: arktest.NewFakeDiscoveryHelper(true, nil),
+					snapshotService: snapshotService,
+				}
+				pvcs []unstructured.Unstructured
+			)
+
+			for i, claim := range claims {
+				pvcs = append(pvcs, *unstructuredOrDie(fmt.Sprintf(
+					`{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": {"namespace": %q, "name": %q, "labels": {"app": %q}}, "spec": {"volumeName": %q}}`,
+					claim[0], claim[1], claim[2], claim[3],
+				)))
+
+				if claim[3] == "" {
+					continue
+				}
+				volumeID := fmt.Sprintf("vol-%d", i+1)
+				snapshotService.VolumeIDs[claim[3]] = volumeID
+				snapshotService.SnapshottableVolumes[volumeID] = v1.VolumeBackupInfo{SnapshotID: "snap-" + volumeID}
+				pvClient.On("Get", claim[3], metav1.GetOptions{}).Return(unstructuredOrDie(fmt.Sprintf(
+					`{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": %q}}`, claim[3],
+				)), nil)
+			}
+
+			dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{}, metav1.APIResource{Name: "persistentvolumeclaims"}, "").Return(pvcClient, nil)
+			dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{}, metav1.APIResource{Name: "persistentvolumes"}, "").Return(pvClient, nil)
+			pvcClient.On("List", metav1.ListOptions{LabelSelector: "app"}).Return(&unstructured.UnstructuredList{Items: pvcs}, nil)
+
+			namespaces := collections.NewIncludesExcludes().Includes("*").Excludes("ns-3")
+
+			s, err := kb.newGroupSnapshotService(arktest.NewLogger(), test.backup, namespaces, snapshotService, nil)
+			require.NoError(t, err)
+
+			if test.expectNoListings {
+				pvcClient.AssertNotCalled(t, "List", metav1.ListOptions{LabelSelector: "app"})
+			}
+
+			// nothing's snapshotted until the groups' volumes are
+			assert.Empty(t, snapshotService.SnapshotGroupsTaken)
+
+			if test.expectedGroup == nil {
+				assert.Empty(t, s.groups)
+				return
+			}
+			assert.Len(t, s.groups, len(test.expectedGroup.volumes))
+			for volumeID := range test.expectedGroup.volumes {
+				assert.Equal(t, test.expectedGroup, s.groups[volumeID])
+			}
+		})
+	}
+}
+
+func TestGroupSnapshotService(t *testing.T) {
+	snapshotService := &arktest.FakeSnapshotService{
+		SnapshotGroupID: "group-1",
+		SnapshottableVolumes: map[string]v1.VolumeBackupInfo{
+			"vol-1": {SnapshotID: "snap-1"},
+			"vol-2": {SnapshotID: "snap-2"},
+			"vol-3": {SnapshotID: "snap-3"},
+		},
+	}
+	group := &volumeGroup{name: "ns-1/db", namespace: "ns-1", claims: []string{"db-0", "db-1"}, volumes: map[string]string{"vol-1": "", "vol-2": ""}}
+	s := &groupSnapshotService{
+		SnapshotService: snapshotService,
+		backup:          &v1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "backup-1", Labels: map[string]string{"team": "a"}}},
+		log:             arktest.NewLogger(),
+		groups:          map[string]*volumeGroup{"vol-1": group, "vol-2": group},
+		snapshots:       make(map[string]*groupSnapshot),
+	}
+
+	// the group is pending until the first of its volumes is snapshotted
+	pending, snapshotted := s.groupState("vol-1")
+	assert.Equal(t, group, pending)
+	assert.False(t, snapshotted)
+	assert.Empty(t, snapshotService.SnapshotGroupsTaken)
+
+	// which snapshots the group, and returns the volume's snapshot from it
+	snapshotID, parentSnapshotID, err := s.CreateSnapshot("vol-1", "zone-1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "snap-1", snapshotID)
+	assert.Empty(t, parentSnapshotID)
+	assert.Equal(t, map[string][]string{"group-1": {"vol-1", "vol-2"}}, snapshotService.SnapshotGroupsTaken)
+	assert.Equal(t, map[string]string{"team": "a", "ark.heptio.com/backup": "backup-1", "ark.heptio.com/volume-group": "ns-1/db"}, snapshotService.SnapshotTags["snap-2"])
+
+	// the group's other volumes already have snapshots
+	pending, snapshotted = s.groupState("vol-2")
+	assert.Nil(t, pending)
+	assert.True(t, snapshotted)
+
+	// after a volume's snapshot from the group is used, the volume is snapshotted again
+	snapshotService.SnapshottableVolumes["vol-1"] = v1.VolumeBackupInfo{SnapshotID: "snap-4"}
+	pending, snapshotted = s.groupState("vol-1")
+	assert.Nil(t, pending)
+	assert.False(t, snapshotted)
+	snapshotID, _, err = s.CreateSnapshot("vol-1", "zone-1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "snap-4", snapshotID)
+
+	// volumes not in a group are snapshotted individually
+	snapshotID, _, err = s.CreateSnapshot("vol-3", "zone-1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "snap-3", snapshotID)
+
+	backup := &v1.Backup{
+		Status: v1.BackupStatus{
+			VolumeBackups: map[string]*v1.VolumeBackupInfo{
+				"pv-1": {SnapshotID: "snap-1"},
+				"pv-3": {SnapshotID: "snap-3"},
+			},
+		},
+	}
+	assert.Empty(t, s.finish(backup, arktest.NewLogger()))
+
+	assert.Equal(t, "group-1", backup.Status.VolumeBackups["pv-1"].SnapshotGroupID)
+	assert.Empty(t, backup.Status.VolumeBackups["pv-3"].SnapshotGroupID)
+
+	// the snapshot of the volume that wasn't backed up is deleted
+	assert.Equal(t, sets.NewString("snap-1", "snap-3", "snap-4"), snapshotService.SnapshotsTaken)
+}
+
+func TestGroupSnapshotServiceWithoutGroups(t *testing.T) {
+	tests := []struct {
+		name        string
+		groupID     string
+		expectedErr bool
+	}{
+		{
+			name: "volumes are snapshotted individually if the snapshot service doesn't support groups",
+		},
+		{
+			name:        "volumes are snapshotted individually if the group can't be snapshotted",
+			groupID:     "group-1",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snapshotService := &arktest.FakeSnapshotService{
+				SnapshotGroupID: test.groupID,
+				SnapshottableVolumes: map[string]v1.VolumeBackupInfo{
+					"vol-1": {SnapshotID: "snap-1"},
+				},
+			}
+			// vol-2 isn't snapshottable, so snapshotting the group fails
+			group := &volumeGroup{name: "ns-1/db", namespace: "ns-1", claims: []string{"db-0", "db-1"}, volumes: map[string]string{"vol-1": "", "vol-2": ""}}
+			s := &groupSnapshotService{
+				SnapshotService: snapshotService,
+				backup:          &v1.Backup{},
+				log:             arktest.NewLogger(),
+				groups:          map[string]*volumeGroup{"vol-1": group, "vol-2": group},
+				snapshots:       make(map[string]*groupSnapshot),
+			}
+
+			snapshotID, _, err := s.CreateSnapshot("vol-1", "zone-1", nil)
+			require.NoError(t, err)
+			assert.Equal(t, "snap-1", snapshotID)
+			assert.Empty(t, snapshotService.SnapshotGroupsTaken)
+
+			// the group isn't pending anymore, so its pods' hooks don't run for it again
+			pending, snapshotted := s.groupState("vol-2")
+			assert.Nil(t, pending)
+			assert.False(t, snapshotted)
+
+			assert.Equal(t, test.expectedErr, len(s.finish(&v1.Backup{}, arktest.NewLogger())) > 0)
+		})
+	}
+}
+
+func TestTakePVSnapshotRunsGroupSnapshotHooks(t *testing.T) {
+	pv := `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "pv-1"}, "spec": {"gcePersistentDisk": {"pdName": "vol-1"}, "claimRef": {"namespace": "ns-1", "name": "db-0"}}}`
+
+	db0 := unstructuredOrDie(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"namespace": "ns-1", "name": "db-0"}, "spec": {"volumes": [{"name": "data", "persistentVolumeClaim": {"claimName": "db-0"}}]}, "status": {"phase": "Running"}}`)
+	db1 := unstructuredOrDie(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"namespace": "ns-1", "name": "db-1"}, "spec": {"volumes": [{"name": "data", "persistentVolumeClaim": {"claimName": "db-1"}}]}, "status": {"phase": "Running"}}`)
+	web := unstructuredOrDie(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"namespace": "ns-1", "name": "web"}, "spec": {"volumes": [{"name": "data", "persistentVolumeClaim": {"claimName": "web"}}]}, "status": {"phase": "Running"}}`)
+
+	snapshotService := &arktest.FakeSnapshotService{
+		SnapshotGroupID: "group-1",
+		SnapshottableVolumes: map[string]v1.VolumeBackupInfo{
+			"vol-1": {SnapshotID: "snap-1"},
+			"vol-2": {SnapshotID: "snap-2"},
+		},
+		VolumeIDs: map[string]string{"pv-1": "vol-1", "pv-2": "vol-2"},
+	}
+	group := &volumeGroup{name: "ns-1/db", namespace: "ns-1", claims: []string{"db-0", "db-1"}, volumes: map[string]string{"vol-1": "", "vol-2": ""}}
+	groups := &groupSnapshotService{
+		SnapshotService: snapshotService,
+		backup:          &v1.Backup{},
+		log:             arktest.NewLogger(),
+		groups:          map[string]*volumeGroup{"vol-1": group, "vol-2": group},
+		snapshots:       make(map[string]*groupSnapshot),
+	}
+
+	dynamicFactory := &arktest.FakeDynamicFactory{}
+	podClient := &arktest.FakeDynamicClient{}
+	dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{}, metav1.APIResource{Name: "pods"}, "ns-1").Return(podClient, nil)
+	podClient.On("List", metav1.ListOptions{}).Return(&unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{*db0, *db1, *web},
+	}, nil)
+
+	resourceHooks := []resourceHook{{name: "freeze"}}
+
+	// the pods using any of the group's claims are quiesced before the group's snapshot
+	var hooked []string
+	itemHookHandler := &mockItemHookHandler{}
+	for _, pod := range []*unstructured.Unstructured{db0, db1} {
+		pod := pod
+		itemHookHandler.On("handleHooks", mock.Anything, podsGroupResource, pod, resourceHooks, hookPhasePreSnapshot).
+			Run(func(mock.Arguments) {
+				assert.Empty(t, snapshotService.SnapshotGroupsTaken, "pre-snapshot hook ran after the group's snapshot")
+				hooked = append(hooked, "pre:"+pod.GetName())
+			}).
+			Return(nil).Once()
+		itemHookHandler.On("handleHooks", mock.Anything, podsGroupResource, pod, resourceHooks, hookPhasePostSnapshot).
+			Run(func(mock.Arguments) {
+				hooked = append(hooked, "post:"+pod.GetName())
+			}).
+			Return(nil).Once()
+	}
+
+	ib := &defaultItemBackupper{
+		snapshotService: groups,
+		dynamicFactory:  dynamicFactory,
+		discoveryHelper: arktest.NewFakeDiscoveryHelper(true, nil),
+		resourceHooks:   resourceHooks,
+		itemHookHandler: itemHookHandler,
+	}
+	backup := &v1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "backup-1"}}
+
+	require.NoError(t, ib.takePVSnapshot(unstructuredOrDie(pv), backup, arktest.NewLogger()))
+	assert.Equal(t, []string{"pre:db-0", "pre:db-1", "post:db-0", "post:db-1"}, hooked)
+	assert.Equal(t, map[string][]string{"group-1": {"vol-1", "vol-2"}}, snapshotService.SnapshotGroupsTaken)
+	assert.Equal(t, "snap-1", backup.Status.VolumeBackups["pv-1"].SnapshotID)
+
+	// the group's other volume was snapshotted with it, so no hooks run for it
+	otherPV := `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "pv-2"}, "spec": {"gcePersistentDisk": {"pdName": "vol-2"}, "claimRef": {"namespace": "ns-1", "name": "db-1"}}}`
+	require.NoError(t, ib.takePVSnapshot(unstructuredOrDie(otherPV), backup, arktest.NewLogger()))
+	assert.Len(t, hooked, 4)
+	assert.Equal(t, "snap-2", backup.Status.VolumeBackups["pv-2"].SnapshotID)
+	itemHookHandler.AssertExpectations(t)
+}
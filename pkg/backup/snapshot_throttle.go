@@ -0,0 +1,72 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+// snapshotThrottle limits how many volume snapshots are taken at once.
+type snapshotThrottle interface {
+	// acquire blocks until a snapshot of a volume of the specified storage class
+	// may be taken, and returns a function that must be called once it has been.
+	acquire(storageClass string) (release func())
+}
+
+type defaultSnapshotThrottle struct {
+	all            chan struct{}
+	byStorageClass map[string]chan struct{}
+}
+
+// NewSnapshotThrottle creates a snapshotThrottle that allows at most maxConcurrent
+// snapshots at once, and at most maxConcurrentPerStorageClass[class] snapshots of
+// volumes of each listed storage class at once. Limits that aren't positive are
+// treated as no limit.
+func NewSnapshotThrottle(maxConcurrent int, maxConcurrentPerStorageClass map[string]int) snapshotThrottle {
+	t := &defaultSnapshotThrottle{
+		byStorageClass: make(map[string]chan struct{}),
+	}
+
+	if maxConcurrent > 0 {
+		t.all = make(chan struct{}, maxConcurrent)
+	}
+
+	for storageClass, max := range maxConcurrentPerStorageClass {
+		if max > 0 {
+			t.byStorageClass[storageClass] = make(chan struct{}, max)
+		}
+	}
+
+	return t
+}
+
+func (t *defaultSnapshotThrottle) acquire(storageClass string) func() {
+	// the storage class's slot is always taken before the overall one, so a
+	// snapshot waiting for its storage class doesn't hold up other classes
+	byStorageClass := t.byStorageClass[storageClass]
+	if byStorageClass != nil {
+		byStorageClass <- struct{}{}
+	}
+	if t.all != nil {
+		t.all <- struct{}{}
+	}
+
+	return func() {
+		if t.all != nil {
+			<-t.all
+		}
+		if byStorageClass != nil {
+			<-byStorageClass
+		}
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// acquireAsync calls acquire in a goroutine and returns a channel that receives the
+// release function once acquire returns.
+func acquireAsync(throttle snapshotThrottle, storageClass string) <-chan func() {
+	acquired := make(chan func(), 1)
+	go func() {
+		acquired <- throttle.acquire(storageClass)
+	}()
+	return acquired
+}
+
+func assertAcquired(t *testing.T, acquired <-chan func()) func() {
+	select {
+	case release := <-acquired:
+		return release
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for snapshot throttle")
+		return nil
+	}
+}
+
+func assertBlocked(t *testing.T, acquired <-chan func()) {
+	select {
+	case <-acquired:
+		t.Fatal("snapshot throttle was acquired when it should have been full")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSnapshotThrottleLimitsStorageClass(t *testing.T) {
+	throttle := NewSnapshotThrottle(0, map[string]int{"gp2": 1})
+
+	release := assertAcquired(t, acquireAsync(throttle, "gp2"))
+
+	blocked := acquireAsync(throttle, "gp2")
+	assertBlocked(t, blocked)
+
+	// other storage classes, and volumes without one, aren't limited
+	assertAcquired(t, acquireAsync(throttle, "io1"))
+	assertAcquired(t, acquireAsync(throttle, "io1"))
+	assertAcquired(t, acquireAsync(throttle, ""))
+
+	release()
+	assertAcquired(t, blocked)()
+}
+
+func TestSnapshotThrottleLimitsAllSnapshots(t *testing.T) {
+	throttle := NewSnapshotThrottle(2, map[string]int{"gp2": 5})
+
+	release := assertAcquired(t, acquireAsync(throttle, "gp2"))
+	assertAcquired(t, acquireAsync(throttle, "io1"))
+
+	blocked := acquireAsync(throttle, "gp2")
+	assertBlocked(t, blocked)
+
+	release()
+	assertAcquired(t, blocked)
+}
+
+func TestSnapshotThrottleWithoutLimits(t *testing.T) {
+	throttle := NewSnapshotThrottle(0, map[string]int{"gp2": 0, "io1": -1})
+
+	for i := 0; i < 10; i++ {
+		assertAcquired(t, acquireAsync(throttle, "gp2"))
+		assertAcquired(t, acquireAsync(throttle, "io1"))
+	}
+
+	assert.Empty(t, throttle.(*defaultSnapshotThrottle).byStorageClass)
+}
@@ -23,10 +23,16 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 )
 
+// ApplyPatchType is the patch type used for server-side apply requests. It's
+// defined here because the vendored version of apimachinery predates it.
+const ApplyPatchType types.PatchType = "application/apply-patch+yaml"
+
 // DynamicFactory contains methods for retrieving dynamic clients for GroupVersionResources and
 // GroupVersionKinds.
 type DynamicFactory interface {
@@ -38,11 +44,14 @@ type DynamicFactory interface {
 // dynamicFactory implements DynamicFactory.
 type dynamicFactory struct {
 	clientPool dynamic.ClientPool
+	config     *rest.Config
 }
 
-// NewDynamicFactory returns a new ClientPool-based dynamic factory.
-func NewDynamicFactory(clientPool dynamic.ClientPool) DynamicFactory {
-	return &dynamicFactory{clientPool: clientPool}
+// NewDynamicFactory returns a new ClientPool-based dynamic factory. config is
+// used to make server-side apply requests, which the ClientPool's clients don't
+// support.
+func NewDynamicFactory(clientPool dynamic.ClientPool, config *rest.Config) DynamicFactory {
+	return &dynamicFactory{clientPool: clientPool, config: config}
 }
 
 func (f *dynamicFactory) ClientForGroupVersionResource(gv schema.GroupVersion, resource metav1.APIResource, namespace string) (Dynamic, error) {
@@ -55,6 +64,10 @@ func (f *dynamicFactory) ClientForGroupVersionResource(gv schema.GroupVersion, r
 
 	return &dynamicResourceClient{
 		resourceClient: dynamicClient.Resource(&resource, namespace),
+		config:         f.config,
+		groupVersion:   gv,
+		resource:       resource,
+		namespace:      namespace,
 	}, nil
 }
 
@@ -82,17 +95,46 @@ type Getter interface {
 	Get(name string, opts metav1.GetOptions) (*unstructured.Unstructured, error)
 }
 
+// Updater updates an object.
+type Updater interface {
+	// Update updates an object.
+	Update(obj *unstructured.Unstructured) (*unstructured.Unstructured, error)
+}
+
+// Applier applies an object using server-side apply.
+type Applier interface {
+	// Apply applies an object using server-side apply, recording the given
+	// field manager as the owner of the object's fields. If force is true,
+	// fields owned by other field managers are taken over instead of
+	// conflicting.
+	Apply(obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error)
+}
+
+// Deleter deletes an object.
+type Deleter interface {
+	// Delete deletes an object by name.
+	Delete(name string, opts *metav1.DeleteOptions) error
+}
+
 // Dynamic contains client methods that Ark needs for backing up and restoring resources.
 type Dynamic interface {
 	Creator
 	Lister
 	Watcher
 	Getter
+	Updater
+	Applier
+	Deleter
 }
 
 // dynamicResourceClient implements Dynamic.
 type dynamicResourceClient struct {
 	resourceClient dynamic.ResourceInterface
+
+	config       *rest.Config
+	groupVersion schema.GroupVersion
+	resource     metav1.APIResource
+	namespace    string
 }
 
 var _ Dynamic = &dynamicResourceClient{}
@@ -112,3 +154,50 @@ func (d *dynamicResourceClient) Watch(options metav1.ListOptions) (watch.Interfa
 func (d *dynamicResourceClient) Get(name string, opts metav1.GetOptions) (*unstructured.Unstructured, error) {
 	return d.resourceClient.Get(name, opts)
 }
+
+func (d *dynamicResourceClient) Update(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return d.resourceClient.Update(obj)
+}
+
+func (d *dynamicResourceClient) Delete(name string, opts *metav1.DeleteOptions) error {
+	return d.resourceClient.Delete(name, opts)
+}
+
+func (d *dynamicResourceClient) Apply(obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error) {
+	if d.config == nil {
+		return nil, errors.New("server-side apply is not configured for this client")
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// mirror what dynamic.NewClient does, since the ClientPool's REST client isn't exposed
+	config := *d.config
+	config.ContentConfig = dynamic.ContentConfig()
+	config.GroupVersion = &d.groupVersion
+	config.APIPath = dynamic.LegacyAPIPathResolverFunc(d.groupVersion.WithKind(""))
+	if len(config.UserAgent) == 0 {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+
+	restClient, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	req := restClient.Patch(ApplyPatchType).
+		NamespaceIfScoped(d.namespace, d.resource.Namespaced).
+		Resource(d.resource.Name).
+		Name(obj.GetName()).
+		Param("fieldManager", fieldManager)
+	if force {
+		req = req.Param("force", "true")
+	}
+
+	result := new(unstructured.Unstructured)
+	err = req.Body(data).Do().Into(result)
+
+	return result, err
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/heptio/ark/pkg/apis/ark/v1"
@@ -39,6 +40,9 @@ type Factory interface {
 	// KubeClient returns a Kubernetes client. It uses the following priority to specify the cluster
 	// configuration: --kubeconfig flag, KUBECONFIG environment variable, in-cluster configuration.
 	KubeClient() (kubernetes.Interface, error)
+	// DynamicFactory returns a DynamicFactory for clients of arbitrary resources. It uses the same
+	// cluster configuration as KubeClient.
+	DynamicFactory() (DynamicFactory, error)
 	Namespace() string
 }
 
@@ -104,6 +108,15 @@ func (f *factory) KubeClient() (kubernetes.Interface, error) {
 	return kubeClient, nil
 }
 
+func (f *factory) DynamicFactory() (DynamicFactory, error) {
+	clientConfig, err := Config(f.kubeconfig, f.kubecontext, f.baseName)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDynamicFactory(dynamic.NewDynamicClientPool(clientConfig), clientConfig), nil
+}
+
 func (f *factory) Namespace() string {
 	return f.namespace
 }
@@ -18,8 +18,10 @@ package aws
 
 import (
 	"regexp"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
@@ -33,6 +35,10 @@ import (
 
 const regionKey = "region"
 
+// arkBackupTag is the tag Ark applies to every snapshot it takes, whose value is the name of
+// the snapshot's backup.
+const arkBackupTag = "ark.heptio.com/backup"
+
 // iopsVolumeTypes is a set of AWS EBS volume types for which IOPS should
 // be captured during snapshot and provided when creating a new volume
 // from snapshot.
@@ -67,6 +73,14 @@ func (b *blockStore) Init(config map[string]string) error {
 
 	awsConfig := aws.NewConfig().WithRegion(region)
 
+	httpClient, err := cloudprovider.NewProxyHTTPClient(config)
+	if err != nil {
+		return err
+	}
+	if httpClient != nil {
+		awsConfig = awsConfig.WithHTTPClient(httpClient)
+	}
+
 	sess, err := getSession(awsConfig)
 	if err != nil {
 		return err
@@ -77,7 +91,7 @@ func (b *blockStore) Init(config map[string]string) error {
 	return nil
 }
 
-func (b *blockStore) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64) (volumeID string, err error) {
+func (b *blockStore) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64, tags map[string]string) (volumeID string, err error) {
 	// describe the snapshot so we can apply its tags to the volume
 	snapReq := &ec2.DescribeSnapshotsInput{
 		SnapshotIds: []*string{&snapshotID},
@@ -99,7 +113,7 @@ func (b *blockStore) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ s
 		TagSpecifications: []*ec2.TagSpecification{
 			{
 				ResourceType: aws.String(ec2.ResourceTypeVolume),
-				Tags:         snapRes.Snapshots[0].Tags,
+				Tags:         getTags(tags, snapRes.Snapshots[0].Tags),
 			},
 		},
 	}
@@ -163,11 +177,18 @@ func (b *blockStore) describeVolume(volumeID string) (*ec2.Volume, error) {
 	return res.Volumes[0], nil
 }
 
-func (b *blockStore) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, error) {
+func (b *blockStore) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, string, error) {
 	// describe the volume so we can copy its tags to the snapshot
 	volumeInfo, err := b.describeVolume(volumeID)
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+
+	// EBS snapshots only store the blocks that changed since the volume's previous snapshot,
+	// so the latest one Ark took is recorded as the new snapshot's parent
+	parentSnapshotID, err := b.getLatestArkSnapshotID(volumeID)
+	if err != nil {
+		return "", "", err
 	}
 
 	res, err := b.ec2.CreateSnapshot(&ec2.CreateSnapshotInput{
@@ -179,11 +200,63 @@ func (b *blockStore) CreateSnapshot(volumeID, volumeAZ string, tags map[string]s
 			},
 		},
 	})
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	return *res.SnapshotId, parentSnapshotID, nil
+}
+
+// getLatestArkSnapshotID returns the ID of the most recent completed snapshot of the volume
+// that was taken by Ark, or an empty string if there isn't one.
+func (b *blockStore) getLatestArkSnapshotID(volumeID string) (string, error) {
+	req := &ec2.DescribeSnapshotsInput{
+		OwnerIds: []*string{aws.String("self")},
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("volume-id"),
+				Values: []*string{&volumeID},
+			},
+			{
+				Name:   aws.String("status"),
+				Values: []*string{aws.String(ec2.SnapshotStateCompleted)},
+			},
+			{
+				Name:   aws.String("tag-key"),
+				Values: []*string{aws.String(arkBackupTag)},
+			},
+		},
+	}
+
+	var snapshots []*ec2.Snapshot
+	err := b.ec2.DescribeSnapshotsPages(req, func(res *ec2.DescribeSnapshotsOutput, lastPage bool) bool {
+		snapshots = append(snapshots, res.Snapshots...)
+		return !lastPage
+	})
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
 
-	return *res.SnapshotId, nil
+	return getLatestSnapshotID(snapshots), nil
+}
+
+// getLatestSnapshotID returns the ID of the snapshot with the latest start time, or an empty
+// string if there are no snapshots.
+func getLatestSnapshotID(snapshots []*ec2.Snapshot) string {
+	var latest *ec2.Snapshot
+	for _, snapshot := range snapshots {
+		if snapshot.StartTime == nil || snapshot.SnapshotId == nil {
+			continue
+		}
+		if latest == nil || snapshot.StartTime.After(*latest.StartTime) {
+			latest = snapshot
+		}
+	}
+
+	if latest == nil {
+		return ""
+	}
+	return *latest.SnapshotId
 }
 
 func getTags(arkTags map[string]string, volumeTags []*ec2.Tag) []*ec2.Tag {
@@ -222,6 +295,184 @@ func (b *blockStore) DeleteSnapshot(snapshotID string) error {
 	return errors.WithStack(err)
 }
 
+func (b *blockStore) SnapshotExists(snapshotID string) (bool, error) {
+	req := &ec2.DescribeSnapshotsInput{
+		SnapshotIds: []*string{&snapshotID},
+	}
+
+	res, err := b.ec2.DescribeSnapshots(req)
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "InvalidSnapshot.NotFound" {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return len(res.Snapshots) > 0, nil
+}
+
+// CreateSnapshotGroup snapshots the volumes as a consistency group with an EBS multi-volume
+// snapshot, which is crash-consistent across the volumes attached to an instance. EBS can't
+// snapshot volumes attached to different instances, or detached ones, together, so if the
+// volumes aren't all attached to the same instance an empty group ID is returned and they're
+// snapshotted individually. The snapshots of the instance's other data volumes are deleted.
+func (b *blockStore) CreateSnapshotGroup(volumes map[string]string, tags map[string]string) (string, map[string]string, error) {
+	volumeIDs := make([]*string, 0, len(volumes))
+	for volumeID := range volumes {
+		volumeIDs = append(volumeIDs, aws.String(volumeID))
+	}
+
+	res, err := b.ec2.DescribeVolumes(&ec2.DescribeVolumesInput{VolumeIds: volumeIDs})
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	if len(res.Volumes) != len(volumes) {
+		return "", nil, errors.Errorf("expected %d volumes from DescribeVolumes, got %d", len(volumes), len(res.Volumes))
+	}
+
+	var instanceID string
+	volumeTags := make(map[string][]*ec2.Tag, len(volumes))
+	for _, volume := range res.Volumes {
+		if len(volume.Attachments) != 1 || volume.Attachments[0].InstanceId == nil {
+			return "", nil, nil
+		}
+		if instanceID != "" && *volume.Attachments[0].InstanceId != instanceID {
+			return "", nil, nil
+		}
+		instanceID = *volume.Attachments[0].InstanceId
+		volumeTags[*volume.VolumeId] = volume.Tags
+	}
+
+	created, err := b.ec2.CreateSnapshots(&ec2.CreateSnapshotsInput{
+		Description: aws.String("Ark snapshot group"),
+		InstanceSpecification: &ec2.InstanceSpecification{
+			ExcludeBootVolume: aws.Bool(true),
+			InstanceId:        aws.String(instanceID),
+		},
+		TagSpecifications: []*ec2.TagSpecification{
+			{
+				ResourceType: aws.String(ec2.ResourceTypeSnapshot),
+				Tags:         getTags(tags, nil),
+			},
+		},
+	})
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	snapshots := created.Snapshots
+
+	snapshotIDs := make(map[string]string, len(volumes))
+	var others []string
+	var startTime time.Time
+	for _, snapshot := range snapshots {
+		volumeID, snapshotID := aws.StringValue(snapshot.VolumeId), aws.StringValue(snapshot.SnapshotId)
+		if _, found := volumes[volumeID]; !found {
+			others = append(others, snapshotID)
+			continue
+		}
+		snapshotIDs[volumeID] = snapshotID
+		if snapshot.StartTime != nil {
+			startTime = *snapshot.StartTime
+		}
+	}
+
+	// if the group can't be used, none of its snapshots are left behind
+	deleteAll := func() {
+		for _, snapshot := range snapshots {
+			b.DeleteSnapshot(aws.StringValue(snapshot.SnapshotId))
+		}
+	}
+	for _, snapshotID := range others {
+		if err := b.DeleteSnapshot(snapshotID); err != nil {
+			deleteAll()
+			return "", nil, errors.Wrapf(err, "error deleting snapshot %s of volume not in the group", snapshotID)
+		}
+	}
+	if len(snapshotIDs) != len(volumes) {
+		deleteAll()
+		return "", nil, errors.Errorf("expected snapshots of %d volumes of instance %s, got %d", len(volumes), instanceID, len(snapshotIDs))
+	}
+
+	// like individual snapshots, the group's get their volumes' tags
+	for volumeID, snapshotID := range snapshotIDs {
+		if len(volumeTags[volumeID]) == 0 {
+			continue
+		}
+		if _, err := b.ec2.CreateTags(&ec2.CreateTagsInput{
+			Resources: []*string{aws.String(snapshotID)},
+			Tags:      getTags(tags, volumeTags[volumeID]),
+		}); err != nil {
+			deleteAll()
+			return "", nil, errors.WithStack(err)
+		}
+	}
+
+	return instanceID + "/" + startTime.UTC().Format(time.RFC3339), snapshotIDs, nil
+}
+
+// DeleteSnapshots returns false, since EBS has no API for deleting snapshots in bulk, so
+// they're deleted individually.
+func (b *blockStore) DeleteSnapshots(snapshotIDs []string) (bool, error) {
+	return false, nil
+}
+
+func (b *blockStore) ListSnapshotsWithTag(key string) (map[string]string, bool, error) {
+	req := &ec2.DescribeSnapshotsInput{
+		OwnerIds: []*string{aws.String("self")},
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("tag-key"),
+				Values: []*string{&key},
+			},
+		},
+	}
+
+	values := make(map[string]string)
+	err := b.ec2.DescribeSnapshotsPages(req, func(res *ec2.DescribeSnapshotsOutput, lastPage bool) bool {
+		for _, snapshot := range res.Snapshots {
+			for _, tag := range snapshot.Tags {
+				if tag.Key != nil && *tag.Key == key && tag.Value != nil {
+					values[*snapshot.SnapshotId] = *tag.Value
+				}
+			}
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+
+	return values, true, nil
+}
+
+func (b *blockStore) ListVolumesWithTag(key string) (map[string]string, bool, error) {
+	req := &ec2.DescribeVolumesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("tag-key"),
+				Values: []*string{&key},
+			},
+		},
+	}
+
+	values := make(map[string]string)
+	err := b.ec2.DescribeVolumesPages(req, func(res *ec2.DescribeVolumesOutput, lastPage bool) bool {
+		for _, volume := range res.Volumes {
+			for _, tag := range volume.Tags {
+				if tag.Key != nil && *tag.Key == key && tag.Value != nil {
+					values[*volume.VolumeId] = *tag.Value
+				}
+			}
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+
+	return values, true, nil
+}
+
 var ebsVolumeIDRegex = regexp.MustCompile("vol-.*")
 
 func (b *blockStore) GetVolumeID(pv runtime.Unstructured) (string, error) {
@@ -17,9 +17,19 @@ limitations under the License.
 package aws
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/heptio/ark/pkg/util/collections"
 	"github.com/stretchr/testify/assert"
@@ -167,3 +177,200 @@ func TestGetTags(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLatestSnapshotID(t *testing.T) {
+	now := time.Now()
+
+	snapshot := func(id string, start time.Time) *ec2.Snapshot {
+		return &ec2.Snapshot{SnapshotId: aws.String(id), StartTime: aws.Time(start)}
+	}
+
+	tests := []struct {
+		name      string
+		snapshots []*ec2.Snapshot
+		expected  string
+	}{
+		{
+			name:     "no snapshots",
+			expected: "",
+		},
+		{
+			name:      "single snapshot",
+			snapshots: []*ec2.Snapshot{snapshot("snap-1", now)},
+			expected:  "snap-1",
+		},
+		{
+			name: "latest snapshot is returned regardless of order",
+			snapshots: []*ec2.Snapshot{
+				snapshot("snap-1", now.Add(-2*time.Hour)),
+				snapshot("snap-3", now),
+				snapshot("snap-2", now.Add(-time.Hour)),
+			},
+			expected: "snap-3",
+		},
+		{
+			name: "snapshots without a start time are ignored",
+			snapshots: []*ec2.Snapshot{
+				snapshot("snap-1", now.Add(-time.Hour)),
+				{SnapshotId: aws.String("snap-2")},
+			},
+			expected: "snap-1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, getLatestSnapshotID(test.snapshots))
+		})
+	}
+}
+
+// fakeEC2 serves EC2 query API responses for volumes attached to instances, and records
+// the requests it gets.
+type fakeEC2 struct {
+	// volumeID -> instanceID, or "" if it's detached
+	attachments map[string]string
+	// the instance's data volumes CreateSnapshots snapshots
+	instanceVolumes []string
+
+	lock     sync.Mutex
+	requests []url.Values
+}
+
+func (f *fakeEC2) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	f.lock.Lock()
+	f.requests = append(f.requests, r.Form)
+	f.lock.Unlock()
+
+	action := r.Form.Get("Action")
+	var body string
+	switch action {
+	case "DescribeVolumes":
+		for i := 1; r.Form.Get(fmt.Sprintf("VolumeId.%d", i)) != ""; i++ {
+			volumeID := r.Form.Get(fmt.Sprintf("VolumeId.%d", i))
+			var attachment string
+			if instanceID := f.attachments[volumeID]; instanceID != "" {
+				attachment = "<attachmentSet><item><instanceId>" + instanceID + "</instanceId></item></attachmentSet>"
+			}
+			body += "<item><volumeId>" + volumeID + "</volumeId>" + attachment + "<tagSet><item><key>app</key><value>db</value></item></tagSet></item>"
+		}
+		body = "<volumeSet>" + body + "</volumeSet>"
+	case "CreateSnapshots":
+		for _, volumeID := range f.instanceVolumes {
+			body += "<item><snapshotId>snap-" + volumeID + "</snapshotId><volumeId>" + volumeID + "</volumeId><startTime>2018-06-01T12:00:00.000Z</startTime></item>"
+		}
+		body = "<snapshotSet>" + body + "</snapshotSet>"
+	case "DeleteSnapshot", "CreateTags":
+		body = "<return>true</return>"
+	default:
+		http.Error(w, "unexpected action "+action, http.StatusBadRequest)
+		return
+	}
+
+	fmt.Fprintf(w, `<%sResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><requestId>1</requestId>%s</%sResponse>`, action, body, action)
+}
+
+func (f *fakeEC2) actions() []string {
+	var actions []string
+	for _, request := range f.requests {
+		action := request.Get("Action")
+		if id := request.Get("SnapshotId"); id != "" {
+			action += " " + id
+		}
+		if id := request.Get("ResourceId.1"); id != "" {
+			action += " " + id
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+func newFakeEC2BlockStore(t *testing.T, fake *fakeEC2) (*blockStore, func()) {
+	server := httptest.NewServer(fake)
+	sess, err := session.NewSession(aws.NewConfig().
+		WithRegion("us-east-1").
+		WithEndpoint(server.URL).
+		WithCredentials(credentials.NewStaticCredentials("id", "secret", "")).
+		WithMaxRetries(0))
+	require.NoError(t, err)
+
+	return &blockStore{ec2: ec2.New(sess)}, server.Close
+}
+
+func TestCreateSnapshotGroup(t *testing.T) {
+	fake := &fakeEC2{
+		attachments:     map[string]string{"vol-1": "i-1", "vol-2": "i-1"},
+		instanceVolumes: []string{"vol-1", "vol-2", "vol-3"},
+	}
+	b, stop := newFakeEC2BlockStore(t, fake)
+	defer stop()
+
+	groupID, snapshotIDs, err := b.CreateSnapshotGroup(map[string]string{"vol-1": "us-east-1a", "vol-2": "us-east-1a"}, map[string]string{"ark.heptio.com/backup": "backup-1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "i-1/2018-06-01T12:00:00Z", groupID)
+	assert.Equal(t, map[string]string{"vol-1": "snap-vol-1", "vol-2": "snap-vol-2"}, snapshotIDs)
+
+	createSnapshots := fake.requests[1]
+	assert.Equal(t, "CreateSnapshots", createSnapshots.Get("Action"))
+	assert.Equal(t, "i-1", createSnapshots.Get("InstanceSpecification.InstanceId"))
+	assert.Equal(t, "true", createSnapshots.Get("InstanceSpecification.ExcludeBootVolume"))
+	assert.Equal(t, "snapshot", createSnapshots.Get("TagSpecification.1.ResourceType"))
+	assert.Equal(t, "ark.heptio.com/backup", createSnapshots.Get("TagSpecification.1.Tag.1.Key"))
+
+	// the snapshot of the instance's volume that isn't in the group is deleted, and the
+	// group's snapshots get their volumes' tags
+	actions := fake.actions()
+	require.Len(t, actions, 5)
+	assert.Equal(t, []string{"DescribeVolumes", "CreateSnapshots", "DeleteSnapshot snap-vol-3"}, actions[:3])
+	sort.Strings(actions[3:])
+	assert.Equal(t, []string{"CreateTags snap-vol-1", "CreateTags snap-vol-2"}, actions[3:])
+}
+
+func TestCreateSnapshotGroupUnsupported(t *testing.T) {
+	tests := []struct {
+		name        string
+		attachments map[string]string
+	}{
+		{
+			name:        "volumes attached to different instances",
+			attachments: map[string]string{"vol-1": "i-1", "vol-2": "i-2"},
+		},
+		{
+			name:        "detached volume",
+			attachments: map[string]string{"vol-1": "i-1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeEC2{attachments: test.attachments}
+			b, stop := newFakeEC2BlockStore(t, fake)
+			defer stop()
+
+			groupID, snapshotIDs, err := b.CreateSnapshotGroup(map[string]string{"vol-1": "", "vol-2": ""}, nil)
+			require.NoError(t, err)
+			assert.Empty(t, groupID)
+			assert.Empty(t, snapshotIDs)
+			assert.Equal(t, []string{"DescribeVolumes"}, fake.actions())
+		})
+	}
+}
+
+func TestCreateSnapshotGroupMissingSnapshot(t *testing.T) {
+	// the volume was detached after it was described
+	fake := &fakeEC2{
+		attachments:     map[string]string{"vol-1": "i-1", "vol-2": "i-1"},
+		instanceVolumes: []string{"vol-1"},
+	}
+	b, stop := newFakeEC2BlockStore(t, fake)
+	defer stop()
+
+	_, _, err := b.CreateSnapshotGroup(map[string]string{"vol-1": "", "vol-2": ""}, nil)
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "expected snapshots of 2 volumes"))
+
+	// the group's snapshot that was taken is deleted
+	assert.Equal(t, []string{"DescribeVolumes", "CreateSnapshots", "DeleteSnapshot snap-vol-1"}, fake.actions())
+}
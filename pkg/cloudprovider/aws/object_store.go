@@ -70,6 +70,14 @@ func (o *objectStore) Init(config map[string]string) error {
 		WithRegion(region).
 		WithS3ForcePathStyle(s3ForcePathStyle)
 
+	httpClient, err := cloudprovider.NewProxyHTTPClient(config)
+	if err != nil {
+		return err
+	}
+	if httpClient != nil {
+		awsConfig = awsConfig.WithHTTPClient(httpClient)
+	}
+
 	if s3URL != "" {
 		awsConfig = awsConfig.WithEndpointResolver(
 			endpoints.ResolverFunc(func(service, region string, optFns ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
@@ -189,3 +197,9 @@ func (o *objectStore) CreateSignedURL(bucket, key string, ttl time.Duration) (st
 
 	return req.Presign(ttl)
 }
+
+// AvailableBytes always returns false, since S3 buckets have no capacity limit
+// to report.
+func (o *objectStore) AvailableBytes(bucket string) (int64, bool, error) {
+	return 0, false, nil
+}
@@ -19,6 +19,7 @@ package azure
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"regexp"
 	"strings"
@@ -101,6 +102,11 @@ func (b *blockStore) Init(config map[string]string) error {
 		apiTimeout = 2 * time.Minute
 	}
 
+	httpClient, err := cloudprovider.NewProxyHTTPClient(config)
+	if err != nil {
+		return err
+	}
+
 	cfg := getConfig()
 
 	spt, err := helpers.NewServicePrincipalTokenFromCredentials(cfg, azure.PublicCloud.ResourceManagerEndpoint)
@@ -114,6 +120,12 @@ func (b *blockStore) Init(config map[string]string) error {
 	disksClient.PollingDelay = 5 * time.Second
 	snapsClient.PollingDelay = 5 * time.Second
 
+	if httpClient != nil {
+		spt.SetSender(httpClient)
+		disksClient.Sender = httpClient
+		snapsClient.Sender = httpClient
+	}
+
 	authorizer := autorest.NewBearerAuthorizer(spt)
 	disksClient.Authorizer = authorizer
 	snapsClient.Authorizer = authorizer
@@ -127,7 +139,7 @@ func (b *blockStore) Init(config map[string]string) error {
 	return nil
 }
 
-func (b *blockStore) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64) (string, error) {
+func (b *blockStore) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64, tags map[string]string) (string, error) {
 	snapshotIdentifier, err := parseFullSnapshotName(snapshotID)
 	if err != nil {
 		return "", err
@@ -151,7 +163,7 @@ func (b *blockStore) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ s
 			},
 			AccountType: disk.StorageAccountTypes(volumeType),
 		},
-		Tags: snapshotInfo.Tags,
+		Tags: getSnapshotTags(tags, snapshotInfo.Tags),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), b.apiTimeout)
@@ -189,11 +201,11 @@ func (b *blockStore) IsVolumeReady(volumeID, volumeAZ string) (ready bool, err e
 	return *res.ProvisioningState == "Succeeded", nil
 }
 
-func (b *blockStore) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, error) {
+func (b *blockStore) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, string, error) {
 	// Lookup disk info for its Location
 	diskInfo, err := b.disks.Get(b.resourceGroup, volumeID)
 	if err != nil {
-		return "", errors.WithStack(err)
+		return "", "", errors.WithStack(err)
 	}
 
 	fullDiskName := getComputeResourceName(b.subscription, b.resourceGroup, disksResource, volumeID)
@@ -226,10 +238,10 @@ func (b *blockStore) CreateSnapshot(volumeID, volumeAZ string, tags map[string]s
 	err = <-errChan
 
 	if err != nil {
-		return "", errors.WithStack(err)
+		return "", "", errors.WithStack(err)
 	}
 
-	return getComputeResourceName(b.subscription, b.resourceGroup, snapshotsResource, snapshotName), nil
+	return getComputeResourceName(b.subscription, b.resourceGroup, snapshotsResource, snapshotName), "", nil
 }
 
 func getSnapshotTags(arkTags map[string]string, diskTags *map[string]*string) *map[string]*string {
@@ -279,6 +291,99 @@ func (b *blockStore) DeleteSnapshot(snapshotID string) error {
 	return errors.WithStack(err)
 }
 
+func (b *blockStore) SnapshotExists(snapshotID string) (bool, error) {
+	snapshotInfo, err := parseFullSnapshotName(snapshotID)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := b.snaps.Get(snapshotInfo.resourceGroup, snapshotInfo.name)
+	if res.Response.Response != nil && res.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return true, nil
+}
+
+// CreateSnapshotGroup returns an empty group ID, since Azure has no API for snapshotting
+// arbitrary volumes as a consistency group, so they're snapshotted individually.
+func (b *blockStore) CreateSnapshotGroup(volumes map[string]string, tags map[string]string) (string, map[string]string, error) {
+	return "", nil, nil
+}
+
+// DeleteSnapshots returns false, since Azure has no API for deleting snapshots in bulk, so
+// they're deleted individually.
+func (b *blockStore) DeleteSnapshots(snapshotIDs []string) (bool, error) {
+	return false, nil
+}
+
+// ListSnapshotsWithTag lists the snapshots in the resource group, looking for key with its
+// slashes replaced by dashes, as they are when snapshots are tagged.
+func (b *blockStore) ListSnapshotsWithTag(key string) (map[string]string, bool, error) {
+	key = strings.Replace(key, "/", "-", -1)
+
+	values := make(map[string]string)
+	res, err := b.snaps.ListByResourceGroup(b.resourceGroup)
+	for {
+		if err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+
+		if res.Value != nil {
+			for _, snapshot := range *res.Value {
+				if snapshot.Name == nil || snapshot.Tags == nil {
+					continue
+				}
+				if value, ok := (*snapshot.Tags)[key]; ok && value != nil {
+					values[getComputeResourceName(b.subscription, b.resourceGroup, snapshotsResource, *snapshot.Name)] = *value
+				}
+			}
+		}
+
+		if res.NextLink == nil || *res.NextLink == "" {
+			break
+		}
+		res, err = b.snaps.ListByResourceGroupNextResults(res)
+	}
+
+	return values, true, nil
+}
+
+// ListVolumesWithTag lists the disks in the resource group, looking for key with its
+// slashes replaced by dashes, as they are when disks are tagged.
+func (b *blockStore) ListVolumesWithTag(key string) (map[string]string, bool, error) {
+	key = strings.Replace(key, "/", "-", -1)
+
+	values := make(map[string]string)
+	res, err := b.disks.ListByResourceGroup(b.resourceGroup)
+	for {
+		if err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+
+		if res.Value != nil {
+			for _, disk := range *res.Value {
+				if disk.Name == nil || disk.Tags == nil {
+					continue
+				}
+				if value, ok := (*disk.Tags)[key]; ok && value != nil {
+					values[*disk.Name] = *value
+				}
+			}
+		}
+
+		if res.NextLink == nil || *res.NextLink == "" {
+			break
+		}
+		res, err = b.disks.ListByResourceGroupNextResults(res)
+	}
+
+	return values, true, nil
+}
+
 func getComputeResourceName(subscription, resourceGroup, resource, name string) string {
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/%s/%s", subscription, resourceGroup, resource, name)
 }
@@ -43,6 +43,14 @@ func (o *objectStore) Init(config map[string]string) error {
 		return errors.WithStack(err)
 	}
 
+	httpClient, err := cloudprovider.NewProxyHTTPClient(config)
+	if err != nil {
+		return err
+	}
+	if httpClient != nil {
+		storageClient.HTTPClient = httpClient
+	}
+
 	blobClient := storageClient.GetBlobService()
 
 	o.blobClient = &blobClient
@@ -161,6 +169,12 @@ func (o *objectStore) CreateSignedURL(bucket, key string, ttl time.Duration) (st
 	return blob.GetSASURI(time.Now().Add(ttl), sasURIReadPermission)
 }
 
+// AvailableBytes always returns false, since the blob storage API doesn't expose
+// how much of a storage account's capacity remains.
+func (o *objectStore) AvailableBytes(bucket string) (int64, bool, error) {
+	return 0, false, nil
+}
+
 func getContainerReference(blobClient *storage.BlobStorageClient, bucket string) (*storage.Container, error) {
 	container := blobClient.GetContainerReference(bucket)
 	if container == nil {
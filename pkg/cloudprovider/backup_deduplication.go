@@ -0,0 +1,598 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/heptio/ark/pkg/util/compression"
+)
+
+// Deduplicated backups store each distinct item once, in the contents directory, keyed by
+// the SHA-256 digest of its data, and a manifest of the entries in their tarball referencing
+// the digests. Backup names are DNS-1123 subdomains, so they can't collide with it.
+const (
+	contentsDir                      = "_contents"
+	contentsKeyFormatString          = contentsDir + "/%s"
+	backupManifestFileFormatString   = "%s/%s-contents.json"
+	backupDownloadFileFormatString   = "%s/%s-download.tar.gz"
+	deduplicatedContentsDigestPrefix = "sha256:"
+
+	// contentsWriterKey is the key of the record of the cluster that writes and deletes the
+	// bucket's deduplicated contents. Which contents uploads in progress rely on is only
+	// tracked in the memory of the server uploading them, so a server in another cluster
+	// deleting a backup could delete them, and only one cluster may write them.
+	contentsWriterKey = contentsDir + "/writer.json"
+)
+
+// contentsWriter is the record stored in contentsWriterKey.
+type contentsWriter struct {
+	ClusterID string `json:"clusterID"`
+}
+
+// getContentsWriter returns the ID of the cluster that writes the bucket's deduplicated
+// contents, and whether one does.
+func (br *backupService) getContentsWriter(bucket string) (string, bool, error) {
+	keys, err := br.objectStore.ListObjects(bucket, contentsWriterKey)
+	if err != nil {
+		return "", false, err
+	}
+	if !sets.NewString(keys...).Has(contentsWriterKey) {
+		return "", false, nil
+	}
+
+	res, err := br.objectStore.GetObject(bucket, contentsWriterKey)
+	if err != nil {
+		return "", false, err
+	}
+	defer res.Close()
+
+	var writer contentsWriter
+	if err := json.NewDecoder(res).Decode(&writer); err != nil {
+		return "", false, errors.Wrap(err, "error decoding deduplicated contents writer")
+	}
+	return writer.ClusterID, true, nil
+}
+
+// checkContentsWriter returns an error if the bucket's deduplicated contents are written by
+// a cluster other than the service's. If claim is true and no cluster writes them yet, the
+// service's cluster is recorded as their writer.
+func (br *backupService) checkContentsWriter(bucket string, claim bool) error {
+	clusterID, found, err := br.getContentsWriter(bucket)
+	if err != nil {
+		return err
+	}
+
+	if !found && claim {
+		if br.clusterID == "" {
+			return errors.New("a clusterID is required to write deduplicated contents")
+		}
+
+		data, err := json.Marshal(&contentsWriter{ClusterID: br.clusterID})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := br.objectStore.PutObject(bucket, contentsWriterKey, bytes.NewReader(data)); err != nil {
+			return errors.Wrap(err, "error recording deduplicated contents writer")
+		}
+
+		// another cluster may have recorded itself at the same time, in which case the
+		// record that was written last is the one that counts
+		if clusterID, found, err = br.getContentsWriter(bucket); err != nil {
+			return err
+		}
+	}
+
+	if found && clusterID != br.clusterID {
+		return errors.Errorf("the deduplicated contents in bucket %s are written by cluster %q, not this cluster (%q)", bucket, clusterID, br.clusterID)
+	}
+	return nil
+}
+
+func getBackupManifestKey(directory, backup string) string {
+	return fmt.Sprintf(backupManifestFileFormatString, directory, backup)
+}
+
+// getBackupDownloadKey returns the key of the tarball a deduplicated backup's contents are
+// assembled into to be downloaded directly from object storage.
+func getBackupDownloadKey(directory, backup string) string {
+	return fmt.Sprintf(backupDownloadFileFormatString, directory, backup)
+}
+
+// getContentsKey returns the key of the data with the specified digest, compressed in
+// format. Gzipped data has no suffix, since data was always gzipped before backups could
+// be compressed in other formats.
+func getContentsKey(digest, format string) string {
+	key := fmt.Sprintf(contentsKeyFormatString, strings.TrimPrefix(digest, deduplicatedContentsDigestPrefix))
+	if format != "" && format != compression.Gzip {
+		key += "." + format
+	}
+	return key
+}
+
+// contentsManifest lists the entries of a deduplicated backup's tarball, in order.
+type contentsManifest struct {
+	// CompressionFormat is the format the backup's tarball is compressed in. If empty,
+	// it's gzip.
+	CompressionFormat string                  `json:"compressionFormat,omitempty"`
+	Entries           []contentsManifestEntry `json:"entries"`
+}
+
+// contentsManifestEntry is the header of an entry in a deduplicated backup's tarball, and
+// the digest of its data, if it has any.
+type contentsManifestEntry struct {
+	Name     string    `json:"name"`
+	Typeflag byte      `json:"typeflag"`
+	Mode     int64     `json:"mode"`
+	ModTime  time.Time `json:"modTime"`
+	Size     int64     `json:"size"`
+	Digest   string    `json:"digest,omitempty"`
+	// Compression is the format the entry's data is stored compressed in, which is the
+	// format of the backup that first stored it. If empty, it's gzip.
+	Compression string `json:"compression,omitempty"`
+}
+
+func (e contentsManifestEntry) contentsKey() string {
+	return getContentsKey(e.Digest, e.Compression)
+}
+
+// contentsOperation is an upload or release of deduplicated contents that's in progress.
+// Operations run concurrently, so each records what the others did that it has to account
+// for: the data they deleted, which an upload can't rely on being stored, and the data
+// referenced by the manifests they uploaded, which a release mustn't delete.
+type contentsOperation struct {
+	deleted    sets.String
+	referenced sets.String
+}
+
+func (br *backupService) startContentsOperation() *contentsOperation {
+	op := &contentsOperation{deleted: sets.NewString(), referenced: sets.NewString()}
+
+	br.contentsLock.Lock()
+	defer br.contentsLock.Unlock()
+
+	if br.contentsOperations == nil {
+		br.contentsOperations = make(map[*contentsOperation]struct{})
+		br.pendingContents = make(map[string]int)
+	}
+	br.contentsOperations[op] = struct{}{}
+
+	return op
+}
+
+// finishContentsOperation ends op. If a manifest referencing keys was uploaded, the
+// operations still in progress are told about it.
+func (br *backupService) finishContentsOperation(op *contentsOperation, keys []string, uploaded bool) {
+	br.contentsLock.Lock()
+	defer br.contentsLock.Unlock()
+
+	delete(br.contentsOperations, op)
+	for _, key := range keys {
+		if br.pendingContents[key]--; br.pendingContents[key] <= 0 {
+			delete(br.pendingContents, key)
+		}
+	}
+
+	if !uploaded {
+		return
+	}
+	for other := range br.contentsOperations {
+		other.referenced.Insert(keys...)
+	}
+}
+
+// reuseContents returns the compression format of the data with the specified digest, if
+// it's stored in any format according to existing, the keys listed when op started, and
+// marks it as relied on by op's upload until the upload finishes.
+func (br *backupService) reuseContents(op *contentsOperation, existing sets.String, digest string) (string, bool) {
+	br.contentsLock.Lock()
+	defer br.contentsLock.Unlock()
+
+	for _, format := range compression.Formats() {
+		key := getContentsKey(digest, format)
+		if existing.Has(key) && !op.deleted.Has(key) {
+			br.pendingContents[key]++
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// reserveContents marks key, which op's upload is uploading, as relied on by the upload
+// until it finishes.
+func (br *backupService) reserveContents(key string) {
+	br.contentsLock.Lock()
+	defer br.contentsLock.Unlock()
+
+	br.pendingContents[key]++
+}
+
+// deleteContents deletes the data with the specified keys that no upload in progress
+// relies on and that no manifest uploaded since op started references, and returns the
+// number deleted.
+func (br *backupService) deleteContents(op *contentsOperation, bucket string, keys []string) (int, error) {
+	br.contentsLock.Lock()
+	defer br.contentsLock.Unlock()
+
+	var deleted int
+	for _, key := range keys {
+		if br.pendingContents[key] > 0 || op.referenced.Has(key) {
+			continue
+		}
+
+		if err := br.objectStore.DeleteObject(bucket, key); err != nil {
+			return deleted, err
+		}
+		deleted++
+
+		for other := range br.contentsOperations {
+			other.deleted.Insert(key)
+		}
+	}
+
+	return deleted, nil
+}
+
+// uploadDeduplicatedContents uploads the data of the entries in the backup's tarball,
+// compressed in format, that aren't already in object storage, and a manifest of its
+// entries. If the upload fails, the data it uploaded is deleted.
+func (br *backupService) uploadDeduplicatedContents(bucket, backupName, format string, backup io.Reader) (err error) {
+	log := br.logger.WithFields(logrus.Fields{"bucket": bucket, "backup": backupName})
+
+	codec, err := compression.CodecFor(format)
+	if err != nil {
+		return err
+	}
+
+	if err := seekToBeginning(backup); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := br.checkContentsWriter(bucket, true); err != nil {
+		return err
+	}
+
+	// the operation starts before the existing data is listed, so that data deleted after
+	// it's listed is known to be gone
+	op := br.startContentsOperation()
+
+	var (
+		relied   []string
+		uploaded []string
+	)
+	defer func() {
+		br.finishContentsOperation(op, relied, err == nil)
+		if err == nil {
+			return
+		}
+		if _, deleteErr := br.deleteContents(op, bucket, uploaded); deleteErr != nil {
+			log.WithError(deleteErr).Error("Error deleting contents of backup that failed to upload")
+		}
+	}()
+
+	existingKeys, err := br.objectStore.ListObjects(bucket, contentsDir+"/")
+	if err != nil {
+		return err
+	}
+	existing := sets.NewString(existingKeys...)
+
+	cr, err := codec.NewReader(backup)
+	if err != nil {
+		return errors.Wrap(err, "error reading backup contents")
+	}
+	defer cr.Close()
+
+	var (
+		manifest = contentsManifest{CompressionFormat: format}
+		tr       = tar.NewReader(cr)
+		shared   int
+	)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "error reading backup contents")
+		}
+
+		entry := contentsManifestEntry{
+			Name:     header.Name,
+			Typeflag: header.Typeflag,
+			Mode:     header.Mode,
+			ModTime:  header.ModTime,
+			Size:     header.Size,
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return errors.Wrap(err, "error reading backup contents")
+		}
+		if len(data) > 0 {
+			digest := sha256.Sum256(data)
+			entry.Digest = deduplicatedContentsDigestPrefix + hex.EncodeToString(digest[:])
+
+			if stored, found := br.reuseContents(op, existing, entry.Digest); found {
+				entry.Compression = stored
+				relied = append(relied, entry.contentsKey())
+				shared++
+			} else {
+				entry.Compression = format
+				key := entry.contentsKey()
+				br.reserveContents(key)
+				relied = append(relied, key)
+
+				compressed, err := compressBytes(codec, data)
+				if err != nil {
+					return err
+				}
+				if err := br.objectStore.PutObject(bucket, key, bytes.NewReader(compressed)); err != nil {
+					return err
+				}
+				uploaded = append(uploaded, key)
+				existing.Insert(key)
+			}
+		}
+
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := br.objectStore.PutObject(bucket, getBackupManifestKey(backupName, backupName), bytes.NewReader(manifestBytes)); err != nil {
+		return err
+	}
+
+	log.Infof("Uploaded %d of the backup's %d entries; the rest were already stored by other backups", len(uploaded), len(uploaded)+shared)
+	return nil
+}
+
+// downloadDeduplicatedContents returns the tarball of a deduplicated backup's contents,
+// assembled from its manifest as it's read.
+func (br *backupService) downloadDeduplicatedContents(bucket, backupName string) (io.ReadCloser, error) {
+	manifest, err := br.getContentsManifest(bucket, backupName)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(br.writeDeduplicatedContents(bucket, manifest, pw))
+	}()
+
+	return pr, nil
+}
+
+// createDeduplicatedContentsDownload assembles the tarball of a deduplicated backup into
+// the backup's directory in object storage, if it isn't there already, so that it can be
+// downloaded directly, and returns its key. It's deleted with the backup.
+func (br *backupService) createDeduplicatedContentsDownload(bucket, backupName string) (string, error) {
+	key := getBackupDownloadKey(backupName, backupName)
+
+	keys, err := br.objectStore.ListObjects(bucket, backupName+"/")
+	if err != nil {
+		return "", err
+	}
+	if sets.NewString(keys...).Has(key) {
+		return key, nil
+	}
+
+	manifest, err := br.getContentsManifest(bucket, backupName)
+	if err != nil {
+		return "", err
+	}
+
+	// the tarball is assembled into a temp file first, so its contents aren't being
+	// downloaded while it's uploaded
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	if err := br.writeDeduplicatedContents(bucket, manifest, file); err != nil {
+		return "", err
+	}
+	if err := seekToBeginning(file); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := br.objectStore.PutObject(bucket, key, file); err != nil {
+		return "", errors.Wrap(err, "error uploading assembled backup contents")
+	}
+	return key, nil
+}
+
+// writeDeduplicatedContents writes the tarball of the entries in manifest to w, compressed
+// in the manifest's format.
+func (br *backupService) writeDeduplicatedContents(bucket string, manifest *contentsManifest, w io.Writer) error {
+	codec, err := compression.CodecFor(manifest.CompressionFormat)
+	if err != nil {
+		return err
+	}
+
+	cw, err := codec.NewWriter(w)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	tw := tar.NewWriter(cw)
+
+	for _, entry := range manifest.Entries {
+		header := &tar.Header{
+			Name:     entry.Name,
+			Typeflag: entry.Typeflag,
+			Mode:     entry.Mode,
+			ModTime:  entry.ModTime,
+			Size:     entry.Size,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if entry.Digest == "" {
+			continue
+		}
+		if err := br.copyContents(bucket, entry, tw); err != nil {
+			return errors.Wrapf(err, "error getting contents of %s", entry.Name)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(cw.Close())
+}
+
+// copyContents copies the data of entry from object storage to w.
+func (br *backupService) copyContents(bucket string, entry contentsManifestEntry, w io.Writer) error {
+	codec, err := compression.CodecFor(entry.Compression)
+	if err != nil {
+		return err
+	}
+
+	res, err := br.objectStore.GetObject(bucket, entry.contentsKey())
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+
+	cr, err := codec.NewReader(res)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer cr.Close()
+
+	_, err = io.Copy(w, cr)
+	return errors.WithStack(err)
+}
+
+func (br *backupService) getContentsManifest(bucket, backupName string) (*contentsManifest, error) {
+	res, err := br.objectStore.GetObject(bucket, getBackupManifestKey(backupName, backupName))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	manifest := new(contentsManifest)
+	if err := json.NewDecoder(res).Decode(manifest); err != nil {
+		return nil, errors.Wrapf(err, "error decoding contents manifest of backup %s", backupName)
+	}
+	return manifest, nil
+}
+
+// releaseDeduplicatedContents deletes the data of a deduplicated backup that no other backup's
+// manifest references, so it can be deleted. The backup's manifest is left for the caller
+// to delete.
+func (br *backupService) releaseDeduplicatedContents(bucket, backupName string) error {
+	if err := br.checkContentsWriter(bucket, false); err != nil {
+		return err
+	}
+
+	// the operation starts before the other manifests are listed, so that manifests
+	// uploaded after they're listed are accounted for
+	op := br.startContentsOperation()
+	defer br.finishContentsOperation(op, nil, false)
+
+	manifest, err := br.getContentsManifest(bucket, backupName)
+	if err != nil {
+		return err
+	}
+
+	released := sets.NewString()
+	for _, entry := range manifest.Entries {
+		if entry.Digest != "" {
+			released.Insert(entry.contentsKey())
+		}
+	}
+
+	// the data's references are counted from the manifests of the other backups, so there
+	// are no counts that could get out of sync with them
+	prefixes, err := br.objectStore.ListCommonPrefixes(bucket, "/")
+	if err != nil {
+		return err
+	}
+	for _, prefix := range prefixes {
+		dir := strings.TrimSuffix(prefix, "/")
+		if dir == contentsDir || dir == backupName {
+			continue
+		}
+
+		keys, err := br.objectStore.ListObjects(bucket, dir+"/")
+		if err != nil {
+			return err
+		}
+		if !sets.NewString(keys...).Has(getBackupManifestKey(dir, dir)) {
+			continue
+		}
+
+		other, err := br.getContentsManifest(bucket, dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range other.Entries {
+			if entry.Digest != "" {
+				released.Delete(entry.contentsKey())
+			}
+		}
+	}
+
+	deleted, err := br.deleteContents(op, bucket, released.List())
+	if err != nil {
+		return err
+	}
+
+	br.logger.WithFields(logrus.Fields{"bucket": bucket, "backup": backupName}).
+		Infof("Deleted the %d items of the backup that no other backup references", deleted)
+	return nil
+}
+
+func compressBytes(codec compression.Codec, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	cw, err := codec.NewWriter(&buf)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if _, err := cw.Write(data); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := cw.Close(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return buf.Bytes(), nil
+}
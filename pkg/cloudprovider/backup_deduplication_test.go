@@ -0,0 +1,337 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/util/compression"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+type tarballEntry struct {
+	name string
+	data string
+}
+
+func newTarball(t *testing.T, entries ...tarballEntry) []byte {
+	return newCompressedTarball(t, compression.Gzip, entries...)
+}
+
+func newCompressedTarball(t *testing.T, format string, entries ...tarballEntry) []byte {
+	codec, err := compression.CodecFor(format)
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	cw, err := codec.NewWriter(buf)
+	require.NoError(t, err)
+	tw := tar.NewWriter(cw)
+
+	for _, entry := range entries {
+		hdr := &tar.Header{
+			Name:     entry.name,
+			Typeflag: tar.TypeReg,
+			Mode:     0755,
+			Size:     int64(len(entry.data)),
+			ModTime:  time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(entry.data))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, cw.Close())
+
+	return buf.Bytes()
+}
+
+func readTarball(t *testing.T, data []byte) []tarballEntry {
+	return readCompressedTarball(t, compression.Gzip, data)
+}
+
+func readCompressedTarball(t *testing.T, format string, data []byte) []tarballEntry {
+	codec, err := compression.CodecFor(format)
+	require.NoError(t, err)
+
+	cr, err := codec.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	tr := tar.NewReader(cr)
+
+	var entries []tarballEntry
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		entries = append(entries, tarballEntry{name: hdr.Name, data: string(data)})
+	}
+
+	return entries
+}
+
+func contentsKeys(objectStore *arktest.FakeObjectStore, bucket string) []string {
+	var keys []string
+	for key := range objectStore.Buckets[bucket] {
+		if strings.HasPrefix(key, contentsDir+"/") && key != contentsWriterKey {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// assertOnlyContentsWriter asserts that the only object left in the bucket is the record of
+// the cluster that writes its deduplicated contents.
+func assertOnlyContentsWriter(t *testing.T, objectStore *arktest.FakeObjectStore, bucket string) {
+	require.Len(t, objectStore.Buckets[bucket], 1)
+	assert.JSONEq(t, `{"clusterID":"cluster-1"}`, string(objectStore.Buckets[bucket][contentsWriterKey]))
+}
+
+func TestDeduplicatedBackups(t *testing.T) {
+	var (
+		bucket        = "bucket"
+		objectStore   = arktest.NewFakeObjectStore(bucket)
+		backupService = NewDeduplicatingBackupService(objectStore, "cluster-1", arktest.NewLogger())
+
+		backup1Entries = []tarballEntry{{"resources/pods/a.json", "a"}, {"resources/pods/b.json", "b"}}
+		backup2Entries = []tarballEntry{{"resources/pods/a.json", "a"}, {"resources/pods/c.json", "c"}}
+	)
+
+	for name, entries := range map[string][]tarballEntry{"backup-1": backup1Entries, "backup-2": backup2Entries} {
+		metadata := encodeToBytes(&api.Backup{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		err := backupService.UploadBackup(bucket, name, bytes.NewReader(metadata), bytes.NewReader(newTarball(t, entries...)), newStringReadSeeker("log"))
+		require.NoError(t, err)
+	}
+
+	// the data shared by the backups is only stored once
+	assert.Len(t, contentsKeys(objectStore, bucket), 3)
+	assert.NotContains(t, objectStore.Buckets[bucket], getBackupContentsKey("backup-1", "backup-1"))
+	assert.Contains(t, objectStore.Buckets[bucket], getBackupManifestKey("backup-1", "backup-1"))
+
+	exists, err := backupService.BackupContentsExist(bucket, "backup-2")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	backups, err := backupService.GetAllBackups(bucket)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, "backup-1", backups[0].Name)
+	assert.Equal(t, "backup-2", backups[1].Name)
+
+	res, err := backupService.DownloadBackup(bucket, "backup-2")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(res)
+	require.NoError(t, err)
+	require.NoError(t, res.Close())
+	assert.Equal(t, backup2Entries, readTarball(t, data))
+
+	// deleting a backup only deletes the data no other backup references
+	require.NoError(t, backupService.DeleteBackupDir(bucket, "backup-1"))
+	assert.Len(t, contentsKeys(objectStore, bucket), 2)
+
+	res, err = backupService.DownloadBackup(bucket, "backup-2")
+	require.NoError(t, err)
+	data, err = ioutil.ReadAll(res)
+	require.NoError(t, err)
+	assert.Equal(t, backup2Entries, readTarball(t, data))
+
+	require.NoError(t, backupService.DeleteBackupDir(bucket, "backup-2"))
+	assert.Empty(t, contentsKeys(objectStore, bucket))
+	assertOnlyContentsWriter(t, objectStore, bucket)
+}
+
+func TestUploadDeduplicatedBackupInvalidTarball(t *testing.T) {
+	var (
+		bucket        = "bucket"
+		objectStore   = arktest.NewFakeObjectStore(bucket)
+		backupService = NewDeduplicatingBackupService(objectStore, "cluster-1", arktest.NewLogger())
+	)
+
+	metadata := encodeToBytes(&api.Backup{ObjectMeta: metav1.ObjectMeta{Name: "backup-1"}})
+	err := backupService.UploadBackup(bucket, "backup-1", bytes.NewReader(metadata), newStringReadSeeker("not a tarball"), newStringReadSeeker("log"))
+	assert.Error(t, err)
+
+	// the metadata is deleted, and nothing is left in the contents directory
+	assert.NotContains(t, objectStore.Buckets[bucket], getMetadataKey("backup-1"))
+	assert.Empty(t, contentsKeys(objectStore, bucket))
+}
+
+func TestCreateSignedURLForDeduplicatedBackup(t *testing.T) {
+	var (
+		bucket        = "bucket"
+		objectStore   = arktest.NewFakeObjectStore(bucket)
+		backupService = NewDeduplicatingBackupService(objectStore, "cluster-1", arktest.NewLogger())
+	)
+
+	entries := []tarballEntry{{"a.json", "a"}}
+	metadata := encodeToBytes(&api.Backup{ObjectMeta: metav1.ObjectMeta{Name: "backup-1"}})
+	err := backupService.UploadBackup(bucket, "backup-1", bytes.NewReader(metadata), bytes.NewReader(newTarball(t, entries...)), newStringReadSeeker("log"))
+	require.NoError(t, err)
+
+	// the contents are assembled into a tarball that can be downloaded directly, even once
+	// deduplication is turned off
+	target := api.DownloadTarget{Kind: api.DownloadTargetKindBackupContents, Name: "backup-1"}
+	for _, backupService := range []BackupService{backupService, NewBackupService(objectStore, "", arktest.NewLogger())} {
+		url, err := backupService.CreateSignedURL(target, bucket, "backup-1", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, "https://bucket.example.com/backup-1/backup-1-download.tar.gz?ttl=1m0s", url)
+	}
+	assert.Equal(t, entries, readTarball(t, objectStore.Buckets[bucket][getBackupDownloadKey("backup-1", "backup-1")]))
+
+	// it's deleted with the backup
+	require.NoError(t, backupService.DeleteBackupDir(bucket, "backup-1"))
+	assertOnlyContentsWriter(t, objectStore, bucket)
+}
+
+func TestDeduplicatedBackupsWithCompressionFormats(t *testing.T) {
+	var (
+		bucket        = "bucket"
+		objectStore   = arktest.NewFakeObjectStore(bucket)
+		backupService = NewDeduplicatingBackupService(objectStore, "cluster-1", arktest.NewLogger())
+
+		backup1Entries = []tarballEntry{{"resources/pods/a.json", "a"}, {"resources/pods/b.json", "b"}}
+		backup2Entries = []tarballEntry{{"resources/pods/a.json", "a"}, {"resources/pods/c.json", "c"}}
+	)
+
+	for name, format := range map[string]string{"backup-1": compression.Gzip, "backup-2": compression.Zstd} {
+		backup := &api.Backup{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: api.BackupStatus{CompressionFormat: format}}
+		entries := backup1Entries
+		if name == "backup-2" {
+			entries = backup2Entries
+		}
+		err := backupService.UploadBackup(bucket, name, bytes.NewReader(encodeToBytes(backup)), bytes.NewReader(newCompressedTarball(t, format, entries...)), newStringReadSeeker("log"))
+		require.NoError(t, err)
+	}
+
+	// data stored by a backup in one format is shared with backups in other formats
+	assert.Len(t, contentsKeys(objectStore, bucket), 3)
+
+	// each backup is downloaded in its own format
+	res, err := backupService.DownloadBackup(bucket, "backup-2")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(res)
+	require.NoError(t, err)
+	assert.Equal(t, backup2Entries, readCompressedTarball(t, compression.Zstd, data))
+
+	res, err = backupService.DownloadBackup(bucket, "backup-1")
+	require.NoError(t, err)
+	data, err = ioutil.ReadAll(res)
+	require.NoError(t, err)
+	assert.Equal(t, backup1Entries, readTarball(t, data))
+
+	require.NoError(t, backupService.DeleteBackupDir(bucket, "backup-1"))
+	require.NoError(t, backupService.DeleteBackupDir(bucket, "backup-2"))
+	assertOnlyContentsWriter(t, objectStore, bucket)
+}
+
+func TestDeleteContentsKeepsContentsInUse(t *testing.T) {
+	var (
+		bucket        = "bucket"
+		objectStore   = arktest.NewFakeObjectStore(bucket)
+		backupService = NewDeduplicatingBackupService(objectStore, "cluster-1", arktest.NewLogger()).(*backupService)
+	)
+
+	for _, key := range []string{"_contents/a", "_contents/b", "_contents/c"} {
+		require.NoError(t, objectStore.PutObject(bucket, key, newStringReadSeeker(key)))
+	}
+
+	release := backupService.startContentsOperation()
+	upload := backupService.startContentsOperation()
+
+	// an upload in progress relies on a, and one that finished uploaded a manifest referencing b
+	_, reused := backupService.reuseContents(upload, sets.NewString("_contents/a"), "sha256:a")
+	require.True(t, reused)
+	finished := backupService.startContentsOperation()
+	backupService.finishContentsOperation(finished, []string{"_contents/b"}, true)
+
+	deleted, err := backupService.deleteContents(release, bucket, []string{"_contents/a", "_contents/b", "_contents/c"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Equal(t, []string{"_contents/a", "_contents/b"}, sortedStrings(contentsKeys(objectStore, bucket)))
+
+	// uploads in progress know not to rely on what was deleted
+	_, reused = backupService.reuseContents(upload, sets.NewString("_contents/c"), "sha256:c")
+	assert.False(t, reused)
+
+	backupService.finishContentsOperation(upload, []string{"_contents/a"}, false)
+	backupService.finishContentsOperation(release, nil, false)
+	assert.Empty(t, backupService.pendingContents)
+	assert.Empty(t, backupService.contentsOperations)
+}
+
+func sortedStrings(s []string) []string {
+	sort.Strings(s)
+	return s
+}
+
+func TestDeduplicatedContentsHaveOneWriter(t *testing.T) {
+	var (
+		bucket      = "bucket"
+		otherBucket = "other-bucket"
+		objectStore = arktest.NewFakeObjectStore(bucket, otherBucket)
+		entries     = []tarballEntry{{"resources/pods/a.json", "a"}}
+	)
+
+	upload := func(backupService BackupService, name string) error {
+		metadata := encodeToBytes(&api.Backup{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		return backupService.UploadBackup(bucket, name, bytes.NewReader(metadata), bytes.NewReader(newTarball(t, entries...)), newStringReadSeeker("log"))
+	}
+
+	// the first cluster to upload deduplicated contents to the bucket writes them
+	require.NoError(t, upload(NewDeduplicatingBackupService(objectStore, "cluster-1", arktest.NewLogger()), "backup-1"))
+
+	// other clusters can't upload or delete deduplicated backups
+	err := upload(NewDeduplicatingBackupService(objectStore, "cluster-2", arktest.NewLogger()), "backup-2")
+	assert.EqualError(t, err, `the deduplicated contents in bucket bucket are written by cluster "cluster-1", not this cluster ("cluster-2")`)
+	assert.NotContains(t, objectStore.Buckets[bucket], getMetadataKey("backup-2"))
+
+	for _, backupService := range []BackupService{
+		NewDeduplicatingBackupService(objectStore, "cluster-2", arktest.NewLogger()),
+		NewBackupService(objectStore, "", arktest.NewLogger()),
+	} {
+		assert.Error(t, backupService.DeleteBackupDir(bucket, "backup-1"))
+		assert.Len(t, contentsKeys(objectStore, bucket), 1)
+	}
+
+	// a service without a cluster ID can't start writing them
+	metadata := encodeToBytes(&api.Backup{ObjectMeta: metav1.ObjectMeta{Name: "backup-3"}})
+	err = NewDeduplicatingBackupService(objectStore, "", arktest.NewLogger()).
+		UploadBackup(otherBucket, "backup-3", bytes.NewReader(metadata), bytes.NewReader(newTarball(t, entries...)), newStringReadSeeker("log"))
+	assert.EqualError(t, err, "a clusterID is required to write deduplicated contents")
+	assert.NotContains(t, objectStore.Buckets[otherBucket], getMetadataKey("backup-3"))
+	assert.NotContains(t, objectStore.Buckets[otherBucket], contentsWriterKey)
+
+	// the writer's cluster can still delete them once deduplication is turned off
+	require.NoError(t, NewBackupService(objectStore, "cluster-1", arktest.NewLogger()).DeleteBackupDir(bucket, "backup-1"))
+	assert.Empty(t, contentsKeys(objectStore, bucket))
+	assert.NotContains(t, objectStore.Buckets[bucket], getMetadataKey("backup-1"))
+}
@@ -18,9 +18,12 @@ package cloudprovider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -41,6 +44,10 @@ type BackupService interface {
 	// an error if a problem is encountered accessing the file or performing the upload via the cloud API.
 	UploadBackup(bucket, name string, metadata, backup, log io.Reader) error
 
+	// UploadBackupMetadata replaces the metadata of an already-uploaded backup, so that changes
+	// to its status made after the upload are kept in object storage.
+	UploadBackupMetadata(bucket, name string, metadata io.Reader) error
+
 	// DownloadBackup downloads an Ark backup with the specified object key from object storage via the cloud API.
 	// It returns the snapshot metadata and data (separately), or an error if a problem is encountered
 	// downloading or reading the file from the cloud API.
@@ -49,13 +56,25 @@ type BackupService interface {
 	// DeleteBackupDir deletes all files in object storage for the given backup.
 	DeleteBackupDir(bucket, backupName string) error
 
+	// DeleteBackupContents deletes all files in object storage for the given backup except
+	// its log, so that the log of a backup that failed to upload can still be viewed.
+	DeleteBackupContents(bucket, backupName string) error
+
 	// GetBackup gets the specified api.Backup from the given bucket in object storage.
 	GetBackup(bucket, name string) (*api.Backup, error)
 
+	// BackupContentsExist returns whether the given backup's metadata and tarball of
+	// contents both exist in object storage.
+	BackupContentsExist(bucket, name string) (bool, error)
+
 	// CreateSignedURL creates a pre-signed URL that can be used to download a file from object
 	// storage. The URL expires after ttl.
 	CreateSignedURL(target api.DownloadTarget, bucket, directory string, ttl time.Duration) (string, error)
 
+	// AvailableBytes returns the storage still available in the bucket, and whether the
+	// object store reports it.
+	AvailableBytes(bucket string) (int64, bool, error)
+
 	// UploadRestoreLog uploads the restore's log file to object storage.
 	UploadRestoreLog(bucket, backup, restore string, log io.Reader) error
 
@@ -69,6 +88,9 @@ type BackupGetter interface {
 	GetAllBackups(bucket string) ([]*api.Backup, error)
 }
 
+// A backup is stored as a fixed set of objects, regardless of how many items it contains:
+// its metadata, a single tarball of its contents, and its log, plus a log and results
+// for each restore from it.
 const (
 	metadataFileFormatString       = "%s/ark-backup.json"
 	backupFileFormatString         = "%s/%s.tar.gz"
@@ -101,17 +123,47 @@ type backupService struct {
 	objectStore ObjectStore
 	decoder     runtime.Decoder
 	logger      logrus.FieldLogger
+	clusterID   string
+
+	// deduplicate is whether backups' contents are uploaded deduplicated. Deduplicated
+	// backups are downloaded and deleted regardless.
+	deduplicate bool
+	// contentsLock guards contentsOperations and pendingContents, which keep deduplicated
+	// contents from being deleted while an upload is relying on them being stored. It's
+	// only held while they're checked and updated, and while contents are deleted, so
+	// uploads and releases run concurrently.
+	contentsLock       sync.Mutex
+	contentsOperations map[*contentsOperation]struct{}
+	// pendingContents counts the uploads in progress that rely on each key of contents.
+	pendingContents map[string]int
 }
 
 var _ BackupService = &backupService{}
 var _ BackupGetter = &backupService{}
 
-// NewBackupService creates a backup service using the provided object store
-func NewBackupService(objectStore ObjectStore, logger logrus.FieldLogger) BackupService {
+// NewBackupService creates a backup service using the provided object store. clusterID is
+// the ID of the cluster the service runs in, which may be empty; deduplicated backups are only
+// deleted by the cluster that writes the bucket's deduplicated contents.
+func NewBackupService(objectStore ObjectStore, clusterID string, logger logrus.FieldLogger) BackupService {
+	return &backupService{
+		objectStore: objectStore,
+		decoder:     scheme.Codecs.UniversalDecoder(api.SchemeGroupVersion),
+		logger:      logger,
+		clusterID:   clusterID,
+	}
+}
+
+// NewDeduplicatingBackupService creates a backup service using the provided object store that
+// stores each distinct item in backups' contents once, shared by all of the backups that have
+// it, rather than a tarball per backup. Only one cluster may write deduplicated contents to a
+// bucket, so clusterID must be set, and uploads fail if another cluster already writes them.
+func NewDeduplicatingBackupService(objectStore ObjectStore, clusterID string, logger logrus.FieldLogger) BackupService {
 	return &backupService{
 		objectStore: objectStore,
 		decoder:     scheme.Codecs.UniversalDecoder(api.SchemeGroupVersion),
 		logger:      logger,
+		clusterID:   clusterID,
+		deduplicate: true,
 	}
 }
 
@@ -163,8 +215,17 @@ func (br *backupService) UploadBackup(bucket, backupName string, metadata, backu
 	}
 
 	if backup != nil {
-		// upload tar file
-		if err := br.seekAndPutObject(bucket, getBackupContentsKey(backupName, backupName), backup); err != nil {
+		// upload tar file, or its deduplicated contents
+		var err error
+		if br.deduplicate {
+			var format string
+			if format, err = compressionFormat(metadata); err == nil {
+				err = br.uploadDeduplicatedContents(bucket, backupName, format, backup)
+			}
+		} else {
+			err = br.seekAndPutObject(bucket, getBackupContentsKey(backupName, backupName), backup)
+		}
+		if err != nil {
 			// try to delete the metadata file since the data upload failed
 			deleteErr := br.objectStore.DeleteObject(bucket, metadataKey)
 
@@ -175,8 +236,55 @@ func (br *backupService) UploadBackup(bucket, backupName string, metadata, backu
 	return nil
 }
 
+// compressionFormat returns the compression format of the backup whose metadata is read
+// from metadata.
+func compressionFormat(metadata io.Reader) (string, error) {
+	if err := seekToBeginning(metadata); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	data, err := ioutil.ReadAll(metadata)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	backup := new(api.Backup)
+	if err := json.Unmarshal(data, backup); err != nil {
+		return "", errors.Wrap(err, "error decoding backup metadata")
+	}
+	return backup.Status.CompressionFormat, nil
+}
+
+func (br *backupService) UploadBackupMetadata(bucket, backupName string, metadata io.Reader) error {
+	return br.seekAndPutObject(bucket, getMetadataKey(backupName), metadata)
+}
+
 func (br *backupService) DownloadBackup(bucket, backupName string) (io.ReadCloser, error) {
-	return br.objectStore.GetObject(bucket, getBackupContentsKey(backupName, backupName))
+	res, err := br.objectStore.GetObject(bucket, getBackupContentsKey(backupName, backupName))
+	if err == nil {
+		return res, nil
+	}
+
+	// the backup may not have a tarball because its contents are deduplicated
+	if deduplicated, listErr := br.hasDeduplicatedContents(bucket, backupName); listErr == nil && deduplicated {
+		return br.downloadDeduplicatedContents(bucket, backupName)
+	}
+	return nil, err
+}
+
+// hasDeduplicatedContents returns whether the backup's contents are stored deduplicated.
+func (br *backupService) hasDeduplicatedContents(bucket, backupName string) (bool, error) {
+	keys, err := br.objectStore.ListObjects(bucket, backupName+"/")
+	if err != nil {
+		return false, err
+	}
+
+	for _, key := range keys {
+		if key == getBackupManifestKey(backupName, backupName) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 func (br *backupService) GetAllBackups(bucket string) ([]*api.Backup, error) {
@@ -191,6 +299,10 @@ func (br *backupService) GetAllBackups(bucket string) ([]*api.Backup, error) {
 	output := make([]*api.Backup, 0, len(prefixes))
 
 	for _, backupDir := range prefixes {
+		if strings.TrimSuffix(backupDir, "/") == contentsDir {
+			continue
+		}
+
 		backup, err := br.GetBackup(bucket, backupDir)
 		if err != nil {
 			br.logger.WithError(err).WithField("dir", backupDir).Error("Error reading backup directory")
@@ -230,14 +342,56 @@ func (br *backupService) GetBackup(bucket, backupName string) (*api.Backup, erro
 	return backup, nil
 }
 
+func (br *backupService) BackupContentsExist(bucket, backupName string) (bool, error) {
+	objects, err := br.objectStore.ListObjects(bucket, backupName+"/")
+	if err != nil {
+		return false, err
+	}
+
+	var metadataFound, contentsFound bool
+	for _, key := range objects {
+		switch key {
+		case getMetadataKey(backupName):
+			metadataFound = true
+		case getBackupContentsKey(backupName, backupName), getBackupManifestKey(backupName, backupName):
+			contentsFound = true
+		}
+	}
+
+	return metadataFound && contentsFound, nil
+}
+
 func (br *backupService) DeleteBackupDir(bucket, backupName string) error {
+	return br.deleteBackupObjects(bucket, backupName, "")
+}
+
+func (br *backupService) DeleteBackupContents(bucket, backupName string) error {
+	return br.deleteBackupObjects(bucket, backupName, getBackupLogKey(backupName, backupName))
+}
+
+// deleteBackupObjects deletes all objects in the backup's directory other than the one with
+// the key keep, if it's not empty.
+func (br *backupService) deleteBackupObjects(bucket, backupName, keep string) error {
 	objects, err := br.objectStore.ListObjects(bucket, backupName+"/")
 	if err != nil {
 		return err
 	}
 
+	// the backup's deduplicated contents are released before its manifest is deleted, so
+	// that it can be retried if they can't be
+	for _, key := range objects {
+		if key == getBackupManifestKey(backupName, backupName) {
+			if err := br.releaseDeduplicatedContents(bucket, backupName); err != nil {
+				return errors.Wrap(err, "error deleting deduplicated contents")
+			}
+		}
+	}
+
 	var errs []error
 	for _, key := range objects {
+		if key == keep {
+			continue
+		}
 		br.logger.WithFields(logrus.Fields{
 			"bucket": bucket,
 			"key":    key,
@@ -253,6 +407,19 @@ func (br *backupService) DeleteBackupDir(bucket, backupName string) error {
 func (br *backupService) CreateSignedURL(target api.DownloadTarget, bucket, directory string, ttl time.Duration) (string, error) {
 	switch target.Kind {
 	case api.DownloadTargetKindBackupContents:
+		// backups may have been deduplicated before deduplication was turned off, so
+		// they're checked for regardless
+		deduplicated, err := br.hasDeduplicatedContents(bucket, directory)
+		if err != nil {
+			return "", err
+		}
+		if deduplicated {
+			key, err := br.createDeduplicatedContentsDownload(bucket, directory)
+			if err != nil {
+				return "", err
+			}
+			return br.objectStore.CreateSignedURL(bucket, key, ttl)
+		}
 		return br.objectStore.CreateSignedURL(bucket, getBackupContentsKey(directory, target.Name), ttl)
 	case api.DownloadTargetKindBackupLog:
 		return br.objectStore.CreateSignedURL(bucket, getBackupLogKey(directory, target.Name), ttl)
@@ -265,6 +432,10 @@ func (br *backupService) CreateSignedURL(target api.DownloadTarget, bucket, dire
 	}
 }
 
+func (br *backupService) AvailableBytes(bucket string) (int64, bool, error) {
+	return br.objectStore.AvailableBytes(bucket)
+}
+
 func (br *backupService) UploadRestoreLog(bucket, backup, restore string, log io.Reader) error {
 	key := getRestoreLogKey(backup, restore)
 	return br.objectStore.PutObject(bucket, key, log)
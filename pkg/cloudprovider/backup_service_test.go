@@ -112,7 +112,7 @@ func TestUploadBackup(t *testing.T) {
 				objStore.On("DeleteObject", bucket, backupName+"/ark-backup.json").Return(nil)
 			}
 
-			backupService := NewBackupService(objStore, logger)
+			backupService := NewBackupService(objStore, "", logger)
 
 			err := backupService.UploadBackup(bucket, backupName, test.metadata, test.backup, test.log)
 
@@ -135,7 +135,7 @@ func TestDownloadBackup(t *testing.T) {
 	)
 	o.On("GetObject", bucket, backup+"/"+backup+".tar.gz").Return(ioutil.NopCloser(strings.NewReader("foo")), nil)
 
-	s := NewBackupService(o, logger)
+	s := NewBackupService(o, "", logger)
 	rc, err := s.DownloadBackup(bucket, backup)
 	require.NoError(t, err)
 	require.NotNil(t, rc)
@@ -182,7 +182,7 @@ func TestDeleteBackup(t *testing.T) {
 				objStore.On("DeleteObject", bucket, o).Return(err)
 			}
 
-			backupService := NewBackupService(objStore, logger)
+			backupService := NewBackupService(objStore, "", logger)
 
 			err := backupService.DeleteBackupDir(bucket, backup)
 
@@ -197,6 +197,91 @@ func TestDeleteBackup(t *testing.T) {
 	}
 }
 
+func TestUploadAndDeleteBackupDir(t *testing.T) {
+	objStore := arktest.NewFakeObjectStore("test-bucket")
+	objStore.Buckets["test-bucket"]["other-backup/ark-backup.json"] = []byte("{}")
+
+	backupService := NewBackupService(objStore, "", arktest.NewLogger())
+
+	require.NoError(t, backupService.UploadBackup("test-bucket", "test-backup", newStringReadSeeker("foo"), newStringReadSeeker("bar"), newStringReadSeeker("baz")))
+	assert.Equal(t, []byte("bar"), objStore.Buckets["test-bucket"]["test-backup/test-backup.tar.gz"])
+
+	require.NoError(t, backupService.DeleteBackupDir("test-bucket", "test-backup"))
+
+	keys, err := objStore.ListObjects("test-bucket", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other-backup/ark-backup.json"}, keys)
+
+	objStore.Errors["ListObjects"] = errors.New("list")
+	assert.EqualError(t, backupService.DeleteBackupDir("test-bucket", "other-backup"), "list")
+}
+
+func TestDeleteBackupContentsKeepsLog(t *testing.T) {
+	objStore := arktest.NewFakeObjectStore("test-bucket")
+	objStore.Buckets["test-bucket"]["other-backup/ark-backup.json"] = []byte("{}")
+
+	backupService := NewBackupService(objStore, "", arktest.NewLogger())
+
+	require.NoError(t, backupService.UploadBackup("test-bucket", "test-backup", newStringReadSeeker("foo"), newStringReadSeeker("bar"), newStringReadSeeker("baz")))
+
+	require.NoError(t, backupService.DeleteBackupContents("test-bucket", "test-backup"))
+
+	keys, err := objStore.ListObjects("test-bucket", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other-backup/ark-backup.json", "test-backup/test-backup-logs.gz"}, keys)
+}
+
+func TestBackupContentsExist(t *testing.T) {
+	tests := []struct {
+		name           string
+		objects        []string
+		listErr        error
+		expectedExists bool
+		expectedErr    string
+	}{
+		{
+			name:           "metadata and tarball exist",
+			objects:        []string{"bak/ark-backup.json", "bak/bak.tar.gz", "bak/bak-logs.gz"},
+			expectedExists: true,
+		},
+		{
+			name:    "tarball is missing",
+			objects: []string{"bak/ark-backup.json", "bak/bak-logs.gz"},
+		},
+		{
+			name:    "metadata is missing",
+			objects: []string{"bak/bak.tar.gz"},
+		},
+		{
+			name: "backup directory is missing",
+		},
+		{
+			name:        "error listing objects",
+			listErr:     errors.New("bad"),
+			expectedErr: "bad",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objStore := &testutil.ObjectStore{}
+			objStore.On("ListObjects", "bucket", "bak/").Return(test.objects, test.listErr)
+
+			backupService := NewBackupService(objStore, "", arktest.NewLogger())
+
+			exists, err := backupService.BackupContentsExist("bucket", "bak")
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedExists, exists)
+
+			objStore.AssertExpectations(t)
+		})
+	}
+}
+
 func TestGetAllBackups(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -248,7 +333,7 @@ func TestGetAllBackups(t *testing.T) {
 			objStore.On("GetObject", bucket, "backup-1/ark-backup.json").Return(ioutil.NopCloser(bytes.NewReader(test.storageData["backup-1/ark-backup.json"])), nil)
 			objStore.On("GetObject", bucket, "backup-2/ark-backup.json").Return(ioutil.NopCloser(bytes.NewReader(test.storageData["backup-2/ark-backup.json"])), nil)
 
-			backupService := NewBackupService(objStore, logger)
+			backupService := NewBackupService(objStore, "", logger)
 
 			res, err := backupService.GetAllBackups(bucket)
 
@@ -329,13 +414,16 @@ func TestCreateSignedURL(t *testing.T) {
 			var (
 				objectStorage = &testutil.ObjectStore{}
 				logger        = arktest.NewLogger()
-				backupService = NewBackupService(objectStorage, logger)
+				backupService = NewBackupService(objectStorage, "", logger)
 			)
 
 			target := api.DownloadTarget{
 				Kind: test.targetKind,
 				Name: test.targetName,
 			}
+			if test.targetKind == api.DownloadTargetKindBackupContents {
+				objectStorage.On("ListObjects", "bucket", test.directory+"/").Return([]string{test.expectedKey}, nil)
+			}
 			objectStorage.On("CreateSignedURL", "bucket", test.expectedKey, time.Duration(0)).Return("url", nil)
 			url, err := backupService.CreateSignedURL(target, "bucket", test.directory, 0)
 			require.NoError(t, err)
@@ -0,0 +1,75 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+// ObjectStoreStream uploads the tarballs of backups to a bucket while they're written,
+// with the keys the BackupService uploads them with, e.g. to replicate them to another
+// location without waiting for each backup to finish.
+type ObjectStoreStream struct {
+	objectStore ObjectStore
+	bucket      string
+}
+
+// NewObjectStoreStream returns an ObjectStoreStream that uploads backup tarballs to
+// bucket in objectStore.
+func NewObjectStoreStream(objectStore ObjectStore, bucket string) *ObjectStoreStream {
+	return &ObjectStoreStream{
+		objectStore: objectStore,
+		bucket:      bucket,
+	}
+}
+
+// Open starts uploading the tarball of backup, and returns the writer it's written to.
+// Closing the writer finishes the upload and returns its error, if any.
+func (s *ObjectStoreStream) Open(backup *api.Backup) (io.WriteCloser, error) {
+	reader, writer := io.Pipe()
+	upload := &streamUpload{
+		writer: writer,
+		done:   make(chan error, 1),
+	}
+
+	go func() {
+		err := s.objectStore.PutObject(s.bucket, getBackupContentsKey(backup.Name, backup.Name), reader)
+		// writes fail instead of blocking once the upload has stopped reading
+		reader.CloseWithError(errors.Wrap(err, "backup stream upload stopped"))
+		upload.done <- err
+	}()
+
+	return upload, nil
+}
+
+type streamUpload struct {
+	writer *io.PipeWriter
+	done   chan error
+}
+
+func (u *streamUpload) Write(p []byte) (int, error) {
+	return u.writer.Write(p)
+}
+
+func (u *streamUpload) Close() error {
+	u.writer.Close()
+	return errors.Wrap(<-u.done, "error uploading backup stream")
+}
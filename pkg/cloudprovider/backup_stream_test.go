@@ -0,0 +1,58 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"io"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestObjectStoreStream(t *testing.T) {
+	objectStore := arktest.NewFakeObjectStore("bucket")
+	stream := NewObjectStoreStream(objectStore, "bucket")
+
+	writer, err := stream.Open(arktest.NewTestBackup().WithName("backup-1").Backup)
+	require.NoError(t, err)
+
+	_, err = io.WriteString(writer, "tarball ")
+	require.NoError(t, err)
+	_, err = io.WriteString(writer, "contents")
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	assert.Equal(t, "tarball contents", string(objectStore.Buckets["bucket"]["backup-1/backup-1.tar.gz"]))
+}
+
+func TestObjectStoreStreamUploadError(t *testing.T) {
+	objectStore := arktest.NewFakeObjectStore("bucket")
+	objectStore.Errors["PutObject"] = errors.New("access denied")
+	stream := NewObjectStoreStream(objectStore, "bucket")
+
+	writer, err := stream.Open(arktest.NewTestBackup().WithName("backup-1").Backup)
+	require.NoError(t, err)
+
+	// writes don't block once the upload has failed
+	_, err = io.WriteString(writer, "tarball")
+	assert.Error(t, err)
+	assert.EqualError(t, writer.Close(), "error uploading backup stream: access denied")
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// availableBytes returns the number of bytes available to unprivileged users on
+// the filesystem containing dir.
+func availableBytes(dir string) (int64, bool, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, false, errors.WithStack(err)
+	}
+
+	return int64(stat.Bavail) * int64(stat.Bsize), true, nil
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/heptio/ark/pkg/cloudprovider"
+)
+
+const pathKey = "path"
+
+// objectStore is an ObjectStore that stores objects as files in a local or
+// mounted directory. Each bucket is a subdirectory of the configured path, and
+// each key is a path relative to its bucket's directory.
+type objectStore struct {
+	root string
+}
+
+func NewObjectStore() cloudprovider.ObjectStore {
+	return &objectStore{}
+}
+
+func (o *objectStore) Init(config map[string]string) error {
+	root := config[pathKey]
+	if root == "" {
+		return errors.Errorf("missing %s in filesystem configuration", pathKey)
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !info.IsDir() {
+		return errors.Errorf("%s %s is not a directory", pathKey, root)
+	}
+
+	o.root = root
+
+	return nil
+}
+
+// bucketPath returns the directory containing the bucket's objects.
+func (o *objectStore) bucketPath(bucket string) (string, error) {
+	return o.resolve(o.root, bucket)
+}
+
+// objectPath returns the file containing the object with the given key.
+func (o *objectStore) objectPath(bucket, key string) (string, error) {
+	bucketPath, err := o.bucketPath(bucket)
+	if err != nil {
+		return "", err
+	}
+
+	return o.resolve(bucketPath, key)
+}
+
+// resolve joins name to dir, returning an error if the result isn't within dir.
+func (o *objectStore) resolve(dir, name string) (string, error) {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if path == dir || !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+		return "", errors.Errorf("invalid name %q", name)
+	}
+
+	return path, nil
+}
+
+func (o *objectStore) PutObject(bucket string, key string, body io.Reader) error {
+	path, err := o.objectPath(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "error putting object %s", key)
+	}
+
+	// write to a temp file and rename it so that readers never see a partial object
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return errors.Wrapf(err, "error putting object %s", key)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "error putting object %s", key)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "error putting object %s", key)
+	}
+
+	return errors.Wrapf(os.Rename(tmp.Name(), path), "error putting object %s", key)
+}
+
+func (o *objectStore) GetObject(bucket string, key string) (io.ReadCloser, error) {
+	path, err := o.objectPath(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting object %s", key)
+	}
+
+	return file, nil
+}
+
+func (o *objectStore) ListCommonPrefixes(bucket string, delimiter string) ([]string, error) {
+	if delimiter != "/" {
+		return nil, errors.Errorf("unsupported delimiter %q", delimiter)
+	}
+
+	bucketPath, err := o.bucketPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(bucketPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var ret []string
+	for _, file := range files {
+		if file.IsDir() {
+			ret = append(ret, file.Name())
+		}
+	}
+
+	return ret, nil
+}
+
+func (o *objectStore) ListObjects(bucket, prefix string) ([]string, error) {
+	bucketPath, err := o.bucketPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	err = filepath.Walk(bucketPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(bucketPath, path)
+		if err != nil {
+			return err
+		}
+
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			ret = append(ret, key)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return ret, nil
+}
+
+func (o *objectStore) DeleteObject(bucket string, key string) error {
+	path, err := o.objectPath(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		return errors.Wrapf(err, "error deleting object %s", key)
+	}
+
+	// remove any directories left empty, so that deleted backups are no
+	// longer listed as common prefixes
+	bucketPath, err := o.bucketPath(bucket)
+	if err != nil {
+		return err
+	}
+	for dir := filepath.Dir(path); dir != bucketPath; dir = filepath.Dir(dir) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil || len(files) > 0 {
+			break
+		}
+		if err := os.Remove(dir); err != nil {
+			break
+		}
+	}
+
+	return nil
+}
+
+// CreateSignedURL returns a file:// URL for the object. Since the directory isn't
+// served over the network, the URL can only be used where the directory is mounted
+// at the same path, and ttl is ignored.
+func (o *objectStore) CreateSignedURL(bucket, key string, ttl time.Duration) (string, error) {
+	path, err := o.objectPath(bucket, key)
+	if err != nil {
+		return "", err
+	}
+
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+
+	return u.String(), nil
+}
+
+// AvailableBytes returns the free space on the filesystem holding the bucket's
+// directory, or the configured path if the bucket has no objects yet.
+func (o *objectStore) AvailableBytes(bucket string) (int64, bool, error) {
+	dir, err := o.bucketPath(bucket)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		dir = o.root
+	}
+
+	return availableBytes(dir)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestObjectStore(t *testing.T) (*objectStore, func()) {
+	dir, err := ioutil.TempDir("", "ark-filesystem")
+	require.NoError(t, err)
+
+	store := &objectStore{}
+	require.NoError(t, store.Init(map[string]string{pathKey: dir}))
+
+	return store, func() { os.RemoveAll(dir) }
+}
+
+func TestInit(t *testing.T) {
+	store := &objectStore{}
+	assert.Error(t, store.Init(map[string]string{}))
+	assert.Error(t, store.Init(map[string]string{pathKey: "/does/not/exist"}))
+}
+
+func TestPutGetListDelete(t *testing.T) {
+	store, cleanup := newTestObjectStore(t)
+	defer cleanup()
+
+	keys := []string{"backup-1/backup-1.tar.gz", "backup-1/ark-backup.json", "backup-2/ark-backup.json"}
+	for _, key := range keys {
+		require.NoError(t, store.PutObject("bucket", key, strings.NewReader(key)))
+	}
+
+	rc, err := store.GetObject("bucket", "backup-1/backup-1.tar.gz")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(rc)
+	rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "backup-1/backup-1.tar.gz", string(data))
+
+	prefixes, err := store.ListCommonPrefixes("bucket", "/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backup-1", "backup-2"}, prefixes)
+
+	objects, err := store.ListObjects("bucket", "backup-1/")
+	require.NoError(t, err)
+	sort.Strings(objects)
+	assert.Equal(t, []string{"backup-1/ark-backup.json", "backup-1/backup-1.tar.gz"}, objects)
+
+	url, err := store.CreateSignedURL("bucket", "backup-2/ark-backup.json", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "file://"+filepath.ToSlash(filepath.Join(store.root, "bucket", "backup-2", "ark-backup.json")), url)
+
+	// deleting the last object in a directory removes the directory
+	require.NoError(t, store.DeleteObject("bucket", "backup-2/ark-backup.json"))
+	prefixes, err = store.ListCommonPrefixes("bucket", "/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backup-1"}, prefixes)
+
+	_, err = store.GetObject("bucket", "backup-2/ark-backup.json")
+	assert.Error(t, err)
+}
+
+func TestKeysOutsideBucketAreRejected(t *testing.T) {
+	store, cleanup := newTestObjectStore(t)
+	defer cleanup()
+
+	assert.Error(t, store.PutObject("bucket", "../escaped", strings.NewReader("")))
+	assert.Error(t, store.PutObject("../bucket", "key", strings.NewReader("")))
+	_, err := store.GetObject("bucket", "")
+	assert.Error(t, err)
+}
+
+func TestAvailableBytes(t *testing.T) {
+	store, cleanup := newTestObjectStore(t)
+	defer cleanup()
+
+	// the bucket's directory doesn't exist until an object is put in it
+	available, supported, err := store.AvailableBytes("bucket")
+	require.NoError(t, err)
+	if !supported {
+		t.Skip("free space isn't reported on this platform")
+	}
+	assert.True(t, available > 0)
+
+	require.NoError(t, store.PutObject("bucket", "key", strings.NewReader("data")))
+	available, supported, err = store.AvailableBytes("bucket")
+	require.NoError(t, err)
+	assert.True(t, supported)
+	assert.True(t, available > 0)
+
+	_, _, err = store.AvailableBytes("../bucket")
+	assert.Error(t, err)
+}
@@ -17,8 +17,10 @@ limitations under the License.
 package gcp
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
+	"net/http"
 	"os"
 
 	"github.com/pkg/errors"
@@ -27,6 +29,7 @@ import (
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 
 	"k8s.io/apimachinery/pkg/runtime"
 
@@ -52,7 +55,12 @@ func (b *blockStore) Init(config map[string]string) error {
 		return err
 	}
 
-	client, err := google.DefaultClient(oauth2.NoContext, compute.ComputeScope)
+	ctx, err := contextWithProxy(config)
+	if err != nil {
+		return err
+	}
+
+	client, err := google.DefaultClient(ctx, compute.ComputeScope)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -78,6 +86,22 @@ func (b *blockStore) Init(config map[string]string) error {
 	return nil
 }
 
+// contextWithProxy returns a context for creating authenticated clients whose requests,
+// including those for tokens, are sent through the proxy in config, if it has one.
+func contextWithProxy(config map[string]string) (context.Context, error) {
+	ctx := context.Background()
+
+	httpClient, err := cloudprovider.NewProxyHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
+
+	return ctx, nil
+}
+
 func extractProjectFromCreds() (string, error) {
 	credsBytes, err := ioutil.ReadFile(os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"))
 	if err != nil {
@@ -100,7 +124,7 @@ func extractProjectFromCreds() (string, error) {
 	return creds.ProjectID, nil
 }
 
-func (b *blockStore) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64) (volumeID string, err error) {
+func (b *blockStore) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64, tags map[string]string) (volumeID string, err error) {
 	// get the snapshot so we can apply its tags to the volume
 	res, err := b.gce.Snapshots.Get(b.project, snapshotID).Do()
 	if err != nil {
@@ -111,12 +135,17 @@ func (b *blockStore) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ s
 	// tags.
 	//
 	// use the snapshot's description (which contains tags from the snapshotted disk
-	// plus Ark-specific tags) to set the new disk's description.
+	// plus Ark-specific tags) merged with tags to set the new disk's description.
+	description := res.Description
+	if len(tags) > 0 {
+		description = getSnapshotTags(tags, res.Description, b.log)
+	}
+
 	disk := &compute.Disk{
 		Name:           "restore-" + uuid.NewV4().String(),
 		SourceSnapshot: res.SelfLink,
 		Type:           volumeType,
-		Description:    res.Description,
+		Description:    description,
 	}
 
 	if _, err = b.gce.Disks.Insert(b.project, volumeAZ, disk).Do(); err != nil {
@@ -145,7 +174,7 @@ func (b *blockStore) IsVolumeReady(volumeID, volumeAZ string) (ready bool, err e
 	return disk.Status == "READY", nil
 }
 
-func (b *blockStore) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, error) {
+func (b *blockStore) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, string, error) {
 	// snapshot names must adhere to RFC1035 and be 1-63 characters
 	// long
 	var snapshotName string
@@ -159,7 +188,7 @@ func (b *blockStore) CreateSnapshot(volumeID, volumeAZ string, tags map[string]s
 
 	disk, err := b.gce.Disks.Get(b.project, volumeAZ, volumeID).Do()
 	if err != nil {
-		return "", errors.WithStack(err)
+		return "", "", errors.WithStack(err)
 	}
 
 	gceSnap := compute.Snapshot{
@@ -169,10 +198,10 @@ func (b *blockStore) CreateSnapshot(volumeID, volumeAZ string, tags map[string]s
 
 	_, err = b.gce.Disks.CreateSnapshot(b.project, volumeAZ, volumeID, &gceSnap).Do()
 	if err != nil {
-		return "", errors.WithStack(err)
+		return "", "", errors.WithStack(err)
 	}
 
-	return gceSnap.Name, nil
+	return gceSnap.Name, "", nil
 }
 
 func getSnapshotTags(arkTags map[string]string, diskDescription string, log logrus.FieldLogger) string {
@@ -215,6 +244,78 @@ func (b *blockStore) DeleteSnapshot(snapshotID string) error {
 	return errors.WithStack(err)
 }
 
+func (b *blockStore) SnapshotExists(snapshotID string) (bool, error) {
+	_, err := b.gce.Snapshots.Get(b.project, snapshotID).Do()
+	if gcpErr, ok := err.(*googleapi.Error); ok && gcpErr.Code == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return true, nil
+}
+
+// CreateSnapshotGroup returns an empty group ID, since GCE has no API for snapshotting
+// arbitrary volumes as a consistency group, so they're snapshotted individually.
+func (b *blockStore) CreateSnapshotGroup(volumes map[string]string, tags map[string]string) (string, map[string]string, error) {
+	return "", nil, nil
+}
+
+// DeleteSnapshots returns false, since GCE has no API for deleting snapshots in bulk, so
+// they're deleted individually.
+func (b *blockStore) DeleteSnapshots(snapshotIDs []string) (bool, error) {
+	return false, nil
+}
+
+// ListSnapshotsWithTag lists all of the project's snapshots, since GCE snapshots' tags are
+// stored as a JSON doc in their description, which can't be filtered on.
+func (b *blockStore) ListSnapshotsWithTag(key string) (map[string]string, bool, error) {
+	values := make(map[string]string)
+	err := b.gce.Snapshots.List(b.project).Pages(context.Background(), func(res *compute.SnapshotList) error {
+		for _, snapshot := range res.Items {
+			var tags map[string]string
+			if err := json.Unmarshal([]byte(snapshot.Description), &tags); err != nil {
+				continue
+			}
+			if value, ok := tags[key]; ok {
+				values[snapshot.Name] = value
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+
+	return values, true, nil
+}
+
+// ListVolumesWithTag lists all of the project's disks, in every zone, since GCE disks' tags
+// are stored as a JSON doc in their description, which can't be filtered on.
+func (b *blockStore) ListVolumesWithTag(key string) (map[string]string, bool, error) {
+	values := make(map[string]string)
+	err := b.gce.Disks.AggregatedList(b.project).Pages(context.Background(), func(res *compute.DiskAggregatedList) error {
+		for _, scoped := range res.Items {
+			for _, disk := range scoped.Disks {
+				var tags map[string]string
+				if err := json.Unmarshal([]byte(disk.Description), &tags); err != nil {
+					continue
+				}
+				if value, ok := tags[key]; ok {
+					values[disk.Name] = value
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+
+	return values, true, nil
+}
+
 func (b *blockStore) GetVolumeID(pv runtime.Unstructured) (string, error) {
 	if !collections.Exists(pv.UnstructuredContent(), "spec.gcePersistentDisk") {
 		return "", nil
@@ -70,7 +70,23 @@ func (o *objectStore) Init(config map[string]string) error {
 	o.googleAccessID = jwtConfig.Email
 	o.privateKey = jwtConfig.PrivateKey
 
-	client, err := storage.NewClient(context.Background(), option.WithScopes(storage.ScopeReadWrite))
+	ctx, err := contextWithProxy(config)
+	if err != nil {
+		return err
+	}
+
+	// the client's requests only go through the proxy if it's built on the
+	// context's HTTP client
+	opts := []option.ClientOption{option.WithScopes(storage.ScopeReadWrite)}
+	if config[cloudprovider.ProxyURLKey] != "" {
+		httpClient, err := google.DefaultClient(ctx, storage.ScopeReadWrite)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		opts = []option.ClientOption{option.WithHTTPClient(httpClient)}
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -155,3 +171,9 @@ func (o *objectStore) CreateSignedURL(bucket, key string, ttl time.Duration) (st
 		Expires:        time.Now().Add(ttl),
 	})
 }
+
+// AvailableBytes always returns false, since GCS buckets have no capacity limit
+// to report.
+func (o *objectStore) AvailableBytes(bucket string) (int64, bool, error) {
+	return 0, false, nil
+}
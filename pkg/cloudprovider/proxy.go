@@ -0,0 +1,63 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ProxyURLKey is the key in a cloud provider's config for the URL of the HTTP proxy
+// its requests are sent through. Without it, requests are sent through the proxy set
+// by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables, if any.
+const ProxyURLKey = "proxyURL"
+
+// NewProxyHTTPClient returns an HTTP client that sends all of its requests through
+// the proxy in config, or nil if config doesn't have one.
+func NewProxyHTTPClient(config map[string]string) (*http.Client, error) {
+	proxyURLVal := config[ProxyURLKey]
+	if proxyURLVal == "" {
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(proxyURLVal)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s (expected URL)", ProxyURLKey)
+	}
+	if (proxyURL.Scheme != "http" && proxyURL.Scheme != "https") || proxyURL.Host == "" {
+		return nil, errors.Errorf("%s must be an http or https URL with a host, got %q", ProxyURLKey, proxyURLVal)
+	}
+
+	// the same settings as http.DefaultTransport, other than the proxy
+	transport := &http.Transport{
+		Proxy: http.ProxyURL(proxyURL),
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
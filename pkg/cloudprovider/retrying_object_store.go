@@ -0,0 +1,167 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// IsRetryableFunc returns whether the object storage operation that returned err
+// may succeed if it's retried.
+type IsRetryableFunc func(err error) bool
+
+// NewRetryableErrorMatcher returns an IsRetryableFunc that matches error messages
+// against the regular expressions in patterns.
+func NewRetryableErrorMatcher(patterns []string) (IsRetryableFunc, error) {
+	var regexps []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid retryable error pattern %q", pattern)
+		}
+		regexps = append(regexps, re)
+	}
+
+	return func(err error) bool {
+		for _, re := range regexps {
+			if re.MatchString(err.Error()) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+const (
+	// objectStoreRetryAttempts is the number of times a retryable operation is
+	// attempted before its error is returned.
+	objectStoreRetryAttempts = 3
+
+	// objectStoreRetryBackoff is how long the first retry waits. Each retry
+	// after it waits twice as long as the one before.
+	objectStoreRetryBackoff = time.Second
+)
+
+type retryingObjectStore struct {
+	ObjectStore
+
+	isRetryable IsRetryableFunc
+	backoff     time.Duration
+	logger      logrus.FieldLogger
+}
+
+// NewRetryingObjectStore returns an ObjectStore that retries objectStore's uploads,
+// downloads and listings when they return errors that are retryable, according to
+// isRetryable. Uploads are only retried if their body can be seeked back to where
+// it started.
+//
+// Object stores run as plugins, and their errors only keep their messages over
+// gRPC, so errors are classified by matching their messages.
+func NewRetryingObjectStore(objectStore ObjectStore, isRetryable IsRetryableFunc, logger logrus.FieldLogger) ObjectStore {
+	return &retryingObjectStore{
+		ObjectStore: objectStore,
+		isRetryable: isRetryable,
+		backoff:     objectStoreRetryBackoff,
+		logger:      logger,
+	}
+}
+
+func (s *retryingObjectStore) retryable(err error) bool {
+	return s.isRetryable != nil && s.isRetryable(err)
+}
+
+// retry runs op until it succeeds, returns an error that isn't retryable, or has been
+// attempted objectStoreRetryAttempts times. prepare, if not nil, is run before each
+// retry, and its error is returned instead of retrying.
+func (s *retryingObjectStore) retry(operation string, op func() error, prepare func() error) error {
+	backoff := s.backoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = op(); err == nil || attempt == objectStoreRetryAttempts || !s.retryable(err) {
+			return err
+		}
+
+		if prepare != nil {
+			if prepareErr := prepare(); prepareErr != nil {
+				return err
+			}
+		}
+
+		s.logger.WithError(err).WithFields(logrus.Fields{"operation": operation, "attempt": attempt}).Warnf("Retryable object storage error, retrying in %s", backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (s *retryingObjectStore) PutObject(bucket string, key string, body io.Reader) error {
+	// the body can only be sent again if it can be seeked back to where it started
+	var prepare func() error
+	if seeker, ok := body.(io.Seeker); ok {
+		if start, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			prepare = func() error {
+				_, err := seeker.Seek(start, io.SeekStart)
+				return err
+			}
+		}
+	}
+	if prepare == nil {
+		return s.ObjectStore.PutObject(bucket, key, body)
+	}
+
+	return s.retry("PutObject", func() error {
+		return s.ObjectStore.PutObject(bucket, key, body)
+	}, prepare)
+}
+
+func (s *retryingObjectStore) GetObject(bucket string, key string) (io.ReadCloser, error) {
+	var res io.ReadCloser
+	err := s.retry("GetObject", func() error {
+		var err error
+		res, err = s.ObjectStore.GetObject(bucket, key)
+		return err
+	}, nil)
+
+	return res, err
+}
+
+func (s *retryingObjectStore) ListCommonPrefixes(bucket string, delimiter string) ([]string, error) {
+	var res []string
+	err := s.retry("ListCommonPrefixes", func() error {
+		var err error
+		res, err = s.ObjectStore.ListCommonPrefixes(bucket, delimiter)
+		return err
+	}, nil)
+
+	return res, err
+}
+
+func (s *retryingObjectStore) ListObjects(bucket, prefix string) ([]string, error) {
+	var res []string
+	err := s.retry("ListObjects", func() error {
+		var err error
+		res, err = s.ObjectStore.ListObjects(bucket, prefix)
+		return err
+	}, nil)
+
+	return res, err
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+// flakyObjectStore fails each operation with the next of its errors until they run out.
+type flakyObjectStore struct {
+	ObjectStore
+
+	errs   []error
+	calls  int
+	bodies []string
+}
+
+func (s *flakyObjectStore) nextErr() error {
+	s.calls++
+	if len(s.errs) == 0 {
+		return nil
+	}
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return err
+}
+
+func (s *flakyObjectStore) PutObject(bucket, key string, body io.Reader) error {
+	data, _ := ioutil.ReadAll(body)
+	s.bodies = append(s.bodies, string(data))
+	return s.nextErr()
+}
+
+func (s *flakyObjectStore) ListObjects(bucket, prefix string) ([]string, error) {
+	if err := s.nextErr(); err != nil {
+		return nil, err
+	}
+	return []string{prefix + "a"}, nil
+}
+
+func TestRetryingObjectStoreListObjects(t *testing.T) {
+	matcher, err := NewRetryableErrorMatcher([]string{"^503 ", "timeout"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		errs          []error
+		expectedCalls int
+		expectedErr   string
+	}{
+		{
+			name:          "errors matching a pattern are retried",
+			errs:          []error{errors.New("503 service unavailable"), errors.New("read timeout")},
+			expectedCalls: 3,
+		},
+		{
+			name:          "errors are returned after the last attempt",
+			errs:          []error{errors.New("read timeout"), errors.New("read timeout"), errors.New("read timeout")},
+			expectedCalls: 3,
+			expectedErr:   "read timeout",
+		},
+		{
+			name:          "errors that aren't retryable are returned right away",
+			errs:          []error{errors.New("access denied"), errors.New("read timeout")},
+			expectedCalls: 1,
+			expectedErr:   "access denied",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flaky := &flakyObjectStore{errs: test.errs}
+
+			store := NewRetryingObjectStore(flaky, matcher, arktest.NewLogger())
+			store.(*retryingObjectStore).backoff = 0
+
+			res, err := store.ListObjects("bucket", "backup/")
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"backup/a"}, res)
+			}
+			assert.Equal(t, test.expectedCalls, flaky.calls)
+		})
+	}
+}
+
+func TestRetryingObjectStorePutObject(t *testing.T) {
+	isRetryable := func(err error) bool { return true }
+
+	// seekable bodies are sent again from where they started
+	flaky := &flakyObjectStore{errs: []error{errors.New("reset")}}
+	store := NewRetryingObjectStore(flaky, isRetryable, arktest.NewLogger())
+	store.(*retryingObjectStore).backoff = 0
+
+	body := strings.NewReader("header:contents")
+	_, err := body.Seek(int64(len("header:")), io.SeekStart)
+	require.NoError(t, err)
+
+	require.NoError(t, store.PutObject("bucket", "key", body))
+	assert.Equal(t, []string{"contents", "contents"}, flaky.bodies)
+
+	// other bodies can't be sent again, so they aren't retried
+	flaky = &flakyObjectStore{errs: []error{errors.New("reset")}}
+	store = NewRetryingObjectStore(flaky, isRetryable, arktest.NewLogger())
+
+	assert.EqualError(t, store.PutObject("bucket", "key", ioutil.NopCloser(strings.NewReader("contents"))), "reset")
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestNewRetryableErrorMatcherInvalidPattern(t *testing.T) {
+	_, err := NewRetryableErrorMatcher([]string{"("})
+	assert.Error(t, err)
+}
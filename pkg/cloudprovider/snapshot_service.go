@@ -17,29 +17,49 @@ limitations under the License.
 package cloudprovider
 
 import (
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
 )
 
 // SnapshotService exposes Ark-specific operations for snapshotting and restoring block
 // volumes.
 type SnapshotService interface {
 	// CreateSnapshot triggers a snapshot for the specified cloud volume and tags it with metadata.
-	// it returns the cloud snapshot ID, or an error if a problem is encountered triggering the snapshot via
-	// the cloud API.
-	CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, error)
+	// it returns the cloud snapshot ID, and the ID of the earlier snapshot it's incremental to
+	// if any, or an error if a problem is encountered triggering the snapshot via the cloud API.
+	CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, string, error)
 
 	// CreateVolumeFromSnapshot triggers a restore operation to create a new cloud volume from the specified
-	// snapshot and volume characteristics. Returns the cloud volume ID, or an error if a problem is
-	// encountered triggering the restore via the cloud API.
-	CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64) (string, error)
+	// snapshot and volume characteristics, and tags it with metadata. Returns the cloud volume ID, or an
+	// error if a problem is encountered triggering the restore via the cloud API.
+	CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64, tags map[string]string) (string, error)
+
+	// FindVolumesWithTag returns the IDs of the cloud volumes tagged with key=value, sorted, and
+	// whether the cloud provider supports finding volumes by tag.
+	FindVolumesWithTag(key, value string) ([]string, bool, error)
 
 	// DeleteSnapshot triggers a deletion of the specified Ark snapshot via the cloud API. It returns an
 	// error if a problem is encountered triggering the deletion via the cloud API.
 	DeleteSnapshot(snapshotID string) error
 
+	// SnapshotExists returns whether the specified snapshot exists in the cloud provider.
+	SnapshotExists(snapshotID string) (bool, error)
+
+	// DeleteSnapshots triggers a deletion of the specified Ark snapshots with a single call to the
+	// cloud API. It returns false, without deleting any, if the cloud provider doesn't support
+	// deleting snapshots in bulk.
+	DeleteSnapshots(snapshotIDs []string) (bool, error)
+
+	// CreateSnapshotGroup snapshots the specified cloud volumes, a map of volume IDs to their
+	// availability zones, as a consistency group, and tags the snapshots with metadata. It
+	// returns the group's ID and the volumes' snapshot IDs, or an empty group ID if the cloud
+	// provider doesn't support consistency groups.
+	CreateSnapshotGroup(volumes map[string]string, tags map[string]string) (string, map[string]string, error)
+
 	// GetVolumeInfo gets the type and IOPS (if applicable) from the cloud API.
 	GetVolumeInfo(volumeID, volumeAZ string) (string, *int64, error)
 
@@ -48,28 +68,47 @@ type SnapshotService interface {
 
 	// SetVolumeID sets the cloud provider specific identifier for the PersistentVolume.
 	SetVolumeID(pv runtime.Unstructured, volumeID string) (runtime.Unstructured, error)
+
+	// RetainedSnapshots returns when the retention of each snapshot tagged with one ends,
+	// keyed by snapshot ID. If tags is non-empty, only snapshots that also have each of
+	// the tags are returned.
+	RetainedSnapshots(tags map[string]string) (map[string]time.Time, error)
 }
 
 const (
 	volumeCreateWaitTimeout  = 30 * time.Second
 	volumeCreatePollInterval = 1 * time.Second
+
+	// retainUntilTag is the tag applied to snapshots that are kept for longer
+	// than their backups, so that snapshots left in the cloud after their
+	// backup is deleted can be identified and cleaned up. The value is an
+	// RFC 3339 timestamp.
+	retainUntilTag = "ark.heptio.com/retain-until"
 )
 
 type snapshotService struct {
 	blockStore BlockStore
+	tags       map[string]string
+	ttl        time.Duration
+	clock      clock.Clock
 }
 
 var _ SnapshotService = &snapshotService{}
 
-// NewSnapshotService creates a snapshot service using the provided block store
-func NewSnapshotService(blockStore BlockStore) SnapshotService {
+// NewSnapshotService creates a snapshot service using the provided block store. Every
+// snapshot it creates is tagged with tags, in addition to the tags passed to CreateSnapshot.
+// If ttl is non-zero, snapshots are also tagged with when their retention ends.
+func NewSnapshotService(blockStore BlockStore, tags map[string]string, ttl time.Duration) SnapshotService {
 	return &snapshotService{
 		blockStore: blockStore,
+		tags:       tags,
+		ttl:        ttl,
+		clock:      clock.RealClock{},
 	}
 }
 
-func (sr *snapshotService) CreateVolumeFromSnapshot(snapshotID string, volumeType string, volumeAZ string, iops *int64) (string, error) {
-	volumeID, err := sr.blockStore.CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ, iops)
+func (sr *snapshotService) CreateVolumeFromSnapshot(snapshotID string, volumeType string, volumeAZ string, iops *int64, tags map[string]string) (string, error) {
+	volumeID, err := sr.blockStore.CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ, iops, tags)
 	if err != nil {
 		return "", err
 	}
@@ -92,14 +131,98 @@ func (sr *snapshotService) CreateVolumeFromSnapshot(snapshotID string, volumeTyp
 	}
 }
 
-func (sr *snapshotService) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, error) {
-	return sr.blockStore.CreateSnapshot(volumeID, volumeAZ, tags)
+func (sr *snapshotService) FindVolumesWithTag(key, value string) ([]string, bool, error) {
+	values, supported, err := sr.blockStore.ListVolumesWithTag(key)
+	if err != nil || !supported {
+		return nil, supported, err
+	}
+
+	var volumeIDs []string
+	for volumeID, tagValue := range values {
+		if tagValue == value {
+			volumeIDs = append(volumeIDs, volumeID)
+		}
+	}
+	sort.Strings(volumeIDs)
+
+	return volumeIDs, true, nil
+}
+
+func (sr *snapshotService) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, string, error) {
+	return sr.blockStore.CreateSnapshot(volumeID, volumeAZ, sr.allTags(tags))
+}
+
+func (sr *snapshotService) CreateSnapshotGroup(volumes map[string]string, tags map[string]string) (string, map[string]string, error) {
+	return sr.blockStore.CreateSnapshotGroup(volumes, sr.allTags(tags))
+}
+
+// allTags returns the service's tags merged with tags, which take precedence.
+func (sr *snapshotService) allTags(tags map[string]string) map[string]string {
+	allTags := make(map[string]string, len(sr.tags)+len(tags))
+	for k, v := range sr.tags {
+		allTags[k] = v
+	}
+	// tags for the specific snapshot take precedence
+	for k, v := range tags {
+		allTags[k] = v
+	}
+	if sr.ttl > 0 {
+		allTags[retainUntilTag] = sr.clock.Now().Add(sr.ttl).UTC().Format(time.RFC3339)
+	}
+
+	return allTags
 }
 
 func (sr *snapshotService) DeleteSnapshot(snapshotID string) error {
 	return sr.blockStore.DeleteSnapshot(snapshotID)
 }
 
+func (sr *snapshotService) DeleteSnapshots(snapshotIDs []string) (bool, error) {
+	return sr.blockStore.DeleteSnapshots(snapshotIDs)
+}
+
+func (sr *snapshotService) SnapshotExists(snapshotID string) (bool, error) {
+	return sr.blockStore.SnapshotExists(snapshotID)
+}
+
+func (sr *snapshotService) RetainedSnapshots(tags map[string]string) (map[string]time.Time, error) {
+	values, supported, err := sr.blockStore.ListSnapshotsWithTag(retainUntilTag)
+	if err != nil {
+		return nil, err
+	}
+	if !supported {
+		// snapshots are only tagged with when their retention ends if a ttl is set
+		if sr.ttl > 0 {
+			return nil, errors.New("the block store doesn't support listing snapshots by tag, so snapshots' retention can't be checked")
+		}
+		return nil, nil
+	}
+
+	for key, value := range tags {
+		tagged, _, err := sr.blockStore.ListSnapshotsWithTag(key)
+		if err != nil {
+			return nil, err
+		}
+		for snapshotID := range values {
+			if tagValue, ok := tagged[snapshotID]; !ok || tagValue != value {
+				delete(values, snapshotID)
+			}
+		}
+	}
+
+	retained := make(map[string]time.Time, len(values))
+	for snapshotID, value := range values {
+		retainUntil, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			// the tag wasn't applied by Ark, so the snapshot isn't retained
+			continue
+		}
+		retained[snapshotID] = retainUntil
+	}
+
+	return retained, nil
+}
+
 func (sr *snapshotService) GetVolumeInfo(volumeID, volumeAZ string) (string, *int64, error) {
 	return sr.blockStore.GetVolumeInfo(volumeID, volumeAZ)
 }
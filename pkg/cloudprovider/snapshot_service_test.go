@@ -0,0 +1,179 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestCreateSnapshotMergesTags(t *testing.T) {
+	blockStore := arktest.NewFakeBlockStore()
+	blockStore.Volumes["vol-1"] = &arktest.FakeVolume{AvailabilityZone: "us-east-1c"}
+
+	serviceTags := map[string]string{"cluster": "prod", "ark.heptio.com/pv": "overridden"}
+	service := NewSnapshotService(blockStore, serviceTags, 0)
+
+	snapshotID, _, err := service.CreateSnapshot("vol-1", "us-east-1c", map[string]string{"ark.heptio.com/pv": "pv-1"})
+	require.NoError(t, err)
+	require.Contains(t, blockStore.Snapshots, snapshotID)
+
+	expected := map[string]string{
+		"cluster":           "prod",
+		"ark.heptio.com/pv": "pv-1",
+	}
+	assert.Equal(t, expected, blockStore.Snapshots[snapshotID].Tags)
+	// the service's tags aren't modified
+	assert.Equal(t, "overridden", serviceTags["ark.heptio.com/pv"])
+}
+
+func TestCreateSnapshotTagsRetention(t *testing.T) {
+	blockStore := arktest.NewFakeBlockStore()
+	blockStore.Volumes["vol-1"] = &arktest.FakeVolume{AvailabilityZone: "us-east-1c"}
+
+	service := NewSnapshotService(blockStore, nil, 24*time.Hour).(*snapshotService)
+	service.clock = clock.NewFakeClock(time.Date(2018, 4, 5, 20, 12, 21, 0, time.UTC))
+
+	snapshotID, _, err := service.CreateSnapshot("vol-1", "us-east-1c", map[string]string{"ark.heptio.com/pv": "pv-1"})
+	require.NoError(t, err)
+
+	expected := map[string]string{
+		"ark.heptio.com/pv":           "pv-1",
+		"ark.heptio.com/retain-until": "2018-04-06T20:12:21Z",
+	}
+	assert.Equal(t, expected, blockStore.Snapshots[snapshotID].Tags)
+}
+
+func TestRetainedSnapshots(t *testing.T) {
+	blockStore := arktest.NewFakeBlockStore()
+	blockStore.Snapshots["snap-1"] = &arktest.FakeSnapshot{Tags: map[string]string{
+		"cluster":                     "prod",
+		"ark.heptio.com/retain-until": "2018-04-06T20:12:21Z",
+	}}
+	blockStore.Snapshots["snap-2"] = &arktest.FakeSnapshot{Tags: map[string]string{
+		"cluster":                     "staging",
+		"ark.heptio.com/retain-until": "2018-04-07T20:12:21Z",
+	}}
+	blockStore.Snapshots["snap-3"] = &arktest.FakeSnapshot{Tags: map[string]string{
+		"ark.heptio.com/retain-until": "not a timestamp",
+	}}
+	blockStore.Snapshots["snap-4"] = &arktest.FakeSnapshot{}
+
+	service := NewSnapshotService(blockStore, nil, 24*time.Hour)
+
+	// block stores that can't list snapshots by tag can't check their retention
+	_, err := service.RetainedSnapshots(nil)
+	assert.Error(t, err)
+	retained, err := NewSnapshotService(blockStore, nil, 0).RetainedSnapshots(nil)
+	require.NoError(t, err)
+	assert.Empty(t, retained)
+
+	blockStore.SupportsListingByTag = true
+	retained, err = service.RetainedSnapshots(nil)
+	require.NoError(t, err)
+	expected := map[string]time.Time{
+		"snap-1": time.Date(2018, 4, 6, 20, 12, 21, 0, time.UTC),
+		"snap-2": time.Date(2018, 4, 7, 20, 12, 21, 0, time.UTC),
+	}
+	assert.Equal(t, expected, retained)
+
+	retained, err = service.RetainedSnapshots(map[string]string{"cluster": "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Time{"snap-1": time.Date(2018, 4, 6, 20, 12, 21, 0, time.UTC)}, retained)
+}
+
+func TestFindVolumesWithTag(t *testing.T) {
+	blockStore := arktest.NewFakeBlockStore()
+	blockStore.Volumes["vol-1"] = &arktest.FakeVolume{Tags: map[string]string{"ark.heptio.com/restored-volume": "uid/pv-1"}}
+	blockStore.Volumes["vol-2"] = &arktest.FakeVolume{Tags: map[string]string{"ark.heptio.com/restored-volume": "uid/pv-2"}}
+	blockStore.Volumes["vol-3"] = &arktest.FakeVolume{Tags: map[string]string{"ark.heptio.com/restored-volume": "uid/pv-1"}}
+	blockStore.Volumes["vol-4"] = &arktest.FakeVolume{}
+	service := NewSnapshotService(blockStore, nil, 0)
+
+	volumeIDs, supported, err := service.FindVolumesWithTag("ark.heptio.com/restored-volume", "uid/pv-1")
+	require.NoError(t, err)
+	assert.False(t, supported)
+	assert.Empty(t, volumeIDs)
+
+	blockStore.SupportsListingByTag = true
+	volumeIDs, supported, err = service.FindVolumesWithTag("ark.heptio.com/restored-volume", "uid/pv-1")
+	require.NoError(t, err)
+	assert.True(t, supported)
+	assert.Equal(t, []string{"vol-1", "vol-3"}, volumeIDs)
+}
+
+func TestCreateSnapshotGroup(t *testing.T) {
+	blockStore := arktest.NewFakeBlockStore()
+	blockStore.Volumes["vol-1"] = &arktest.FakeVolume{AvailabilityZone: "us-east-1c"}
+	blockStore.Volumes["vol-2"] = &arktest.FakeVolume{AvailabilityZone: "us-east-1c"}
+
+	service := NewSnapshotService(blockStore, map[string]string{"cluster": "prod"}, 0)
+	volumes := map[string]string{"vol-1": "us-east-1c", "vol-2": "us-east-1c"}
+
+	// block stores without consistency groups don't snapshot anything
+	groupID, snapshotIDs, err := service.CreateSnapshotGroup(volumes, nil)
+	require.NoError(t, err)
+	assert.Empty(t, groupID)
+	assert.Empty(t, snapshotIDs)
+	assert.Empty(t, blockStore.Snapshots)
+
+	blockStore.SupportsSnapshotGroups = true
+	groupID, snapshotIDs, err = service.CreateSnapshotGroup(volumes, map[string]string{"ark.heptio.com/backup": "backup-1"})
+	require.NoError(t, err)
+	require.NotEmpty(t, groupID)
+	require.Len(t, snapshotIDs, 2)
+
+	expectedTags := map[string]string{
+		"cluster":               "prod",
+		"ark.heptio.com/backup": "backup-1",
+	}
+	for volumeID, snapshotID := range snapshotIDs {
+		require.Contains(t, blockStore.Snapshots, snapshotID)
+		snapshot := blockStore.Snapshots[snapshotID]
+		assert.Equal(t, volumeID, snapshot.VolumeID)
+		assert.Equal(t, groupID, snapshot.GroupID)
+		assert.Equal(t, expectedTags, snapshot.Tags)
+	}
+}
+
+func TestCreateVolumeFromSnapshotAndDeleteSnapshot(t *testing.T) {
+	blockStore := arktest.NewFakeBlockStore()
+	blockStore.Snapshots["snap-1"] = &arktest.FakeSnapshot{}
+	service := NewSnapshotService(blockStore, nil, 0)
+
+	volumeID, err := service.CreateVolumeFromSnapshot("snap-1", "gp2", "us-east-1c", nil, map[string]string{"ark.heptio.com/restored-volume": "uid/pv-1"})
+	require.NoError(t, err)
+	assert.Equal(t, &arktest.FakeVolume{Type: "gp2", AvailabilityZone: "us-east-1c", SnapshotID: "snap-1", Tags: map[string]string{"ark.heptio.com/restored-volume": "uid/pv-1"}}, blockStore.Volumes[volumeID])
+
+	blockStore.Errors["DeleteSnapshot"] = errors.New("throttled")
+	assert.EqualError(t, service.DeleteSnapshot("snap-1"), "throttled")
+	assert.Contains(t, blockStore.Snapshots, "snap-1")
+
+	delete(blockStore.Errors, "DeleteSnapshot")
+	require.NoError(t, service.DeleteSnapshot("snap-1"))
+	assert.Empty(t, blockStore.Snapshots)
+
+	assert.Equal(t, []string{"CreateVolumeFromSnapshot", "IsVolumeReady", "DeleteSnapshot", "DeleteSnapshot"}, blockStore.Calls)
+}
@@ -55,6 +55,11 @@ type ObjectStore interface {
 
 	// CreateSignedURL creates a pre-signed URL for the given bucket and key that expires after ttl.
 	CreateSignedURL(bucket, key string, ttl time.Duration) (string, error)
+
+	// AvailableBytes returns the number of bytes of storage still available in the
+	// given bucket, and whether the object store can report it; object stores whose
+	// backends don't expose their capacity return false and no error.
+	AvailableBytes(bucket string) (available int64, supported bool, err error)
 }
 
 // BlockStore exposes basic block-storage operations required
@@ -68,7 +73,9 @@ type BlockStore interface {
 	// CreateVolumeFromSnapshot creates a new block volume in the specified
 	// availability zone, initialized from the provided snapshot,
 	// and with the specified type and IOPS (if using provisioned IOPS).
-	CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64) (volumeID string, err error)
+	// The volume is tagged with the snapshot's tags and the provided set of
+	// tags, which take precedence.
+	CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64, tags map[string]string) (volumeID string, err error)
 
 	// GetVolumeID returns the cloud provider specific identifier for the PersistentVolume.
 	GetVolumeID(pv runtime.Unstructured) (string, error)
@@ -84,9 +91,39 @@ type BlockStore interface {
 	IsVolumeReady(volumeID, volumeAZ string) (ready bool, err error)
 
 	// CreateSnapshot creates a snapshot of the specified block volume, and applies the provided
-	// set of tags to the snapshot.
-	CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (snapshotID string, err error)
+	// set of tags to the snapshot. If the new snapshot is incremental, i.e. it only stores the
+	// blocks that changed since an earlier snapshot of the volume taken by Ark, that snapshot's
+	// ID is returned as parentSnapshotID; otherwise parentSnapshotID is empty.
+	CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (snapshotID, parentSnapshotID string, err error)
 
 	// DeleteSnapshot deletes the specified volume snapshot.
 	DeleteSnapshot(snapshotID string) error
+
+	// SnapshotExists returns whether the specified volume snapshot exists. It returns false,
+	// rather than an error, if the snapshot isn't found.
+	SnapshotExists(snapshotID string) (bool, error)
+
+	// CreateSnapshotGroup snapshots the specified block volumes, given as a map of volume IDs
+	// to their availability zones, at the same instant, as a consistency group, and applies
+	// the provided set of tags to the snapshots. It returns the ID of the group and a map of
+	// volume IDs to the IDs of their snapshots. Block stores that don't support consistency
+	// groups, or can't snapshot these volumes together, return an empty group ID and no
+	// error, and the volumes are then snapshotted individually.
+	CreateSnapshotGroup(volumes map[string]string, tags map[string]string) (groupID string, snapshotIDs map[string]string, err error)
+
+	// DeleteSnapshots deletes the specified volume snapshots with a single call to the
+	// cloud API. It returns whether they were deleted; block stores that don't support
+	// deleting snapshots in bulk return false and no error, without deleting any, and
+	// the snapshots are then deleted individually.
+	DeleteSnapshots(snapshotIDs []string) (bool, error)
+
+	// ListSnapshotsWithTag returns the value of the tag key on each volume snapshot that
+	// has it, keyed by snapshot ID, and whether the block store supports listing snapshots
+	// by tag; block stores that don't return false and no error.
+	ListSnapshotsWithTag(key string) (map[string]string, bool, error)
+
+	// ListVolumesWithTag returns the value of the tag key on each block volume that has
+	// it, keyed by volume ID, and whether the block store supports listing volumes by
+	// tag; block stores that don't return false and no error.
+	ListVolumesWithTag(key string) (map[string]string, bool, error)
 }
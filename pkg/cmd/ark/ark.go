@@ -24,7 +24,9 @@ import (
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cmd/cli/backup"
 	cliclient "github.com/heptio/ark/pkg/cmd/cli/client"
+	"github.com/heptio/ark/pkg/cmd/cli/completion"
 	"github.com/heptio/ark/pkg/cmd/cli/create"
+	"github.com/heptio/ark/pkg/cmd/cli/debug"
 	"github.com/heptio/ark/pkg/cmd/cli/delete"
 	"github.com/heptio/ark/pkg/cmd/cli/describe"
 	"github.com/heptio/ark/pkg/cmd/cli/get"
@@ -33,6 +35,7 @@ import (
 	"github.com/heptio/ark/pkg/cmd/cli/schedule"
 	"github.com/heptio/ark/pkg/cmd/server"
 	runplugin "github.com/heptio/ark/pkg/cmd/server/plugin"
+	"github.com/heptio/ark/pkg/cmd/util/downloadrequest"
 	"github.com/heptio/ark/pkg/cmd/version"
 )
 
@@ -51,13 +54,14 @@ operations can also be performed as 'ark backup get' and 'ark schedule create'.`
 
 	f := client.NewFactory(name)
 	f.BindFlags(c.PersistentFlags())
+	downloadrequest.BindFlags(c.PersistentFlags())
 
 	c.AddCommand(
 		backup.NewCommand(f),
 		schedule.NewCommand(f),
 		restore.NewCommand(f),
 		server.NewCommand(),
-		version.NewCommand(),
+		version.NewCommand(f),
 		get.NewCommand(f),
 		describe.NewCommand(f),
 		create.NewCommand(f),
@@ -65,6 +69,8 @@ operations can also be performed as 'ark backup get' and 'ark schedule create'.`
 		plugin.NewCommand(f),
 		delete.NewCommand(f),
 		cliclient.NewCommand(),
+		completion.NewCommand(),
+		debug.NewCommand(f),
 	)
 
 	// add the glog flags
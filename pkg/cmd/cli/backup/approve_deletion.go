@@ -0,0 +1,102 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	pkgbackup "github.com/heptio/ark/pkg/backup"
+	"github.com/heptio/ark/pkg/client"
+	"github.com/heptio/ark/pkg/cmd"
+)
+
+// NewApproveDeletionCommand creates a new command that approves the pending
+// deletion of a backup that requires approval to be deleted.
+func NewApproveDeletionCommand(f client.Factory, use string) *cobra.Command {
+	c := &cobra.Command{
+		Use:   fmt.Sprintf("%s NAME", use),
+		Short: "Approve the deletion of a backup",
+		Long: fmt.Sprintf(`Approve the deletion of a backup.
+
+Backups labeled %s=true are only deleted once their DeleteBackupRequests are approved.
+This approves all of the backup's DeleteBackupRequests that haven't been processed yet,
+by adding their UIDs to the backup's %s annotation.`, v1.DeletionApprovalRequiredLabel, v1.DeletionApprovedAnnotation),
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			arkClient, err := f.Client()
+			cmd.CheckError(err)
+
+			backup, err := arkClient.ArkV1().Backups(f.Namespace()).Get(args[0], metav1.GetOptions{})
+			cmd.CheckError(err)
+
+			reqs, err := arkClient.ArkV1().DeleteBackupRequests(f.Namespace()).List(pkgbackup.NewDeleteBackupRequestListOptions(backup.Name, string(backup.UID)))
+			cmd.CheckError(err)
+
+			approved := sets.NewString()
+			if existing := backup.Annotations[v1.DeletionApprovedAnnotation]; existing != "" {
+				approved.Insert(strings.Split(existing, ",")...)
+			}
+
+			var pending []v1.DeleteBackupRequest
+			for _, req := range reqs.Items {
+				if req.Status.Phase == v1.DeleteBackupRequestPhaseProcessed || approved.Has(string(req.UID)) {
+					continue
+				}
+				pending = append(pending, req)
+				approved.Insert(string(req.UID))
+			}
+
+			if len(pending) == 0 {
+				cmd.CheckError(errors.Errorf("backup %q has no DeleteBackupRequests awaiting approval", backup.Name))
+			}
+
+			patch, err := json.Marshal(map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations":     map[string]string{v1.DeletionApprovedAnnotation: strings.Join(approved.List(), ",")},
+					"resourceVersion": backup.ResourceVersion,
+				},
+			})
+			cmd.CheckError(errors.WithStack(err))
+
+			_, err = arkClient.ArkV1().Backups(backup.Namespace).Patch(backup.Name, types.MergePatchType, patch)
+			cmd.CheckError(errors.Wrapf(err, "error approving deletion of backup %s", backup.Name))
+
+			// requests waiting for approval are only processed again once they're back in New
+			for _, req := range pending {
+				if req.Status.Phase != v1.DeleteBackupRequestPhasePendingApproval {
+					continue
+				}
+
+				_, err := arkClient.ArkV1().DeleteBackupRequests(req.Namespace).Patch(req.Name, types.MergePatchType, []byte(`{"status":{"phase":"New"}}`))
+				cmd.CheckError(errors.Wrapf(err, "error updating DeleteBackupRequest %s", req.Name))
+			}
+
+			fmt.Printf("Deletion of backup %q approved.\n", backup.Name)
+		},
+	}
+
+	return c
+}
@@ -32,10 +32,15 @@ func NewCommand(f client.Factory) *cobra.Command {
 	c.AddCommand(
 		NewCreateCommand(f, "create"),
 		NewGetCommand(f, "get"),
+		NewGetSetCommand(f, "get-set"),
 		NewLogsCommand(f),
 		NewDescribeCommand(f, "describe"),
+		NewStatusCommand(f, "status"),
 		NewDownloadCommand(f),
+		NewExtractCommand(f),
 		NewDeleteCommand(f, "delete"),
+		NewApproveDeletionCommand(f, "approve-deletion"),
+		NewRecoverCommand(f, "recover"),
 	)
 
 	return c
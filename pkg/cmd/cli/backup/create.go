@@ -18,27 +18,32 @@ package backup
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	pkgbackup "github.com/heptio/ark/pkg/backup"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cmd"
 	"github.com/heptio/ark/pkg/cmd/util/flag"
 	"github.com/heptio/ark/pkg/cmd/util/output"
+	"github.com/heptio/ark/pkg/util/compression"
 )
 
 func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 	o := NewCreateOptions()
 
 	c := &cobra.Command{
-		Use:   use + " NAME",
+		Use:   use + " [NAME]",
 		Short: "Create a backup",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		Run: func(c *cobra.Command, args []string) {
 			cmd.CheckError(o.Complete(args))
 			cmd.CheckError(o.Validate(c, args))
@@ -47,6 +52,8 @@ func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 	}
 
 	o.BindFlags(c.Flags())
+	c.Flags().BoolVar(&o.UseNameTemplate, "use-name-template", o.UseNameTemplate, "name the backup with the backupNameTemplate from the Ark server's Config, with an empty schedule, instead of NAME")
+	c.Flags().StringVar(&o.BackupSet, "backup-set", o.BackupSet, "ID of a set of backups taken together, e.g. of each cluster in a federation, to label the backup with; see 'ark backup get-set'")
 	output.BindFlags(c.Flags())
 	output.ClearOutputFlagDefault(c)
 
@@ -54,32 +61,51 @@ func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 }
 
 type CreateOptions struct {
-	Name                    string
-	TTL                     time.Duration
-	SnapshotVolumes         flag.OptionalBool
-	IncludeNamespaces       flag.StringArray
-	ExcludeNamespaces       flag.StringArray
-	IncludeResources        flag.StringArray
-	ExcludeResources        flag.StringArray
-	Labels                  flag.Map
-	Selector                flag.LabelSelector
-	IncludeClusterResources flag.OptionalBool
+	Name                         string
+	TTL                          time.Duration
+	SnapshotVolumes              flag.OptionalBool
+	IncludeNamespaces            flag.StringArray
+	ExcludeNamespaces            flag.StringArray
+	IncludeResources             flag.StringArray
+	ExcludeResources             flag.StringArray
+	Labels                       flag.Map
+	Selector                     flag.LabelSelector
+	VolumeSnapshotSelector       flag.LabelSelector
+	VolumeGroupLabel             string
+	IncludeClusterResources      flag.OptionalBool
+	ConsistentListing            bool
+	IncludeTerminatingNamespaces bool
+	IncludeServiceAccountTokens  bool
+	ExcludeSecretAnnotations     flag.Map
+	ExcludeSecretOwnerKinds      flag.StringArray
+	ExcludeOwnerKinds            flag.StringArray
+	MinItems                     int
+	ModifiedSince                time.Duration
+	IncludeOwnerReferences       bool
+	RequireIncludeAnnotation     bool
+	BaseBackup                   string
+	ExcludeFields                flag.StringArray
+	CompressionFormat            string
+	ResourceRequestTimeout       time.Duration
+	UseNameTemplate              bool
+	BackupSet                    string
 }
 
 func NewCreateOptions() *CreateOptions {
 	return &CreateOptions{
-		TTL:                     30 * 24 * time.Hour,
-		IncludeNamespaces:       flag.NewStringArray("*"),
-		Labels:                  flag.NewMap(),
-		SnapshotVolumes:         flag.NewOptionalBool(nil),
-		IncludeClusterResources: flag.NewOptionalBool(nil),
+		TTL:                      30 * 24 * time.Hour,
+		IncludeNamespaces:        flag.NewStringArray("*"),
+		Labels:                   flag.NewMap(),
+		ExcludeSecretAnnotations: flag.NewMap(),
+		SnapshotVolumes:          flag.NewOptionalBool(nil),
+		IncludeClusterResources:  flag.NewOptionalBool(nil),
 	}
 }
 
 func (o *CreateOptions) BindFlags(flags *pflag.FlagSet) {
 	flags.DurationVar(&o.TTL, "ttl", o.TTL, "how long before the backup can be garbage collected")
-	flags.Var(&o.IncludeNamespaces, "include-namespaces", "namespaces to include in the backup (use '*' for all namespaces)")
-	flags.Var(&o.ExcludeNamespaces, "exclude-namespaces", "namespaces to exclude from the backup")
+	flags.Var(&o.IncludeNamespaces, "include-namespaces", "namespaces to include in the backup (use '*' for all namespaces). Glob patterns, e.g. 'pr-*', and regular expressions between slashes, e.g. '/^pr-[0-9]+$/', are matched against the cluster's namespaces when the backup runs")
+	flags.Var(&o.ExcludeNamespaces, "exclude-namespaces", "namespaces to exclude from the backup. Like --include-namespaces, these may be patterns")
 	flags.Var(&o.IncludeResources, "include-resources", "resources to include in the backup, formatted as resource.group, such as storageclasses.storage.k8s.io (use '*' for all resources)")
 	flags.Var(&o.ExcludeResources, "exclude-resources", "resources to exclude from the backup, formatted as resource.group, such as storageclasses.storage.k8s.io")
 	flags.Var(&o.Labels, "labels", "labels to apply to the backup")
@@ -88,9 +114,26 @@ func (o *CreateOptions) BindFlags(flags *pflag.FlagSet) {
 	// this allows the user to just specify "--snapshot-volumes" as shorthand for "--snapshot-volumes=true"
 	// like a normal bool flag
 	f.NoOptDefVal = "true"
+	flags.Var(&o.VolumeSnapshotSelector, "volume-snapshot-selector", "only take snapshots of PersistentVolumes that match this label selector, or whose PersistentVolumeClaims do")
+	flags.StringVar(&o.VolumeGroupLabel, "volume-group-label", o.VolumeGroupLabel, "key of a label on PersistentVolumeClaims whose volumes are snapshotted together, by cloud providers that support consistency groups, with those of the claims in the same namespace with the same value")
 
 	f = flags.VarPF(&o.IncludeClusterResources, "include-cluster-resources", "", "include cluster-scoped resources in the backup")
 	f.NoOptDefVal = "true"
+
+	flags.BoolVar(&o.ConsistentListing, "consistent-listing", o.ConsistentListing, "list all resources at a single resourceVersion captured when the backup starts, where the API server supports it")
+	flags.BoolVar(&o.IncludeTerminatingNamespaces, "include-terminating-namespaces", o.IncludeTerminatingNamespaces, "include namespaces that are being deleted in the backup")
+	flags.BoolVar(&o.IncludeServiceAccountTokens, "include-service-account-tokens", o.IncludeServiceAccountTokens, "include service account token secrets that were created automatically by Kubernetes in the backup")
+	flags.Var(&o.ExcludeSecretAnnotations, "exclude-secret-annotations", "exclude secrets with any of these annotations from the backup, in the form key1=value1,key2=,... (an empty value matches any value)")
+	flags.Var(&o.ExcludeSecretOwnerKinds, "exclude-secret-owner-kinds", "exclude secrets owned by objects of these kinds from the backup, such as ExternalSecret")
+	flags.Var(&o.ExcludeOwnerKinds, "exclude-owner-kinds", "exclude items of any resource owned by objects of these kinds from the backup, such as the children of an operator's custom resources, formatted as kind or kind.group")
+	flags.IntVar(&o.MinItems, "min-items", o.MinItems, "minimum number of items the backup must contain; backups with fewer items are marked Skipped and their contents are discarded")
+	flags.DurationVar(&o.ModifiedSince, "modified-since", o.ModifiedSince, "only back up items modified within this long before the backup is created, going by their managedFields times; items without managedFields are always backed up")
+	flags.BoolVar(&o.IncludeOwnerReferences, "include-owner-references", o.IncludeOwnerReferences, "also back up the owners of each backed-up item, such as a pod's replica set and deployment, even if they don't match the label selector")
+	flags.BoolVar(&o.RequireIncludeAnnotation, "require-include-annotation", o.RequireIncludeAnnotation, "only back up items annotated with backup.ark.heptio.com/include=true, along with namespaces and the items backed up with them")
+	flags.Var(&o.ExcludeFields, "exclude-fields", "fields to remove from backed-up items, as JSON pointers, in the form resource1=/pointer1,resource2=/pointer2,... (use '*' as the resource for all resources)")
+	flags.DurationVar(&o.ResourceRequestTimeout, "resource-request-timeout", o.ResourceRequestTimeout, "how long each request to list or get a resource's items can take before the resource is skipped with a warning (defaults to the server's setting)")
+	flags.StringVar(&o.CompressionFormat, "compression-format", o.CompressionFormat, fmt.Sprintf("format to compress the backup tarball with, one of %s (default %s)", strings.Join(compression.Formats(), ", "), compression.Gzip))
+	flags.StringVar(&o.BaseBackup, "base-backup", o.BaseBackup, "name of a completed backup this backup is incremental to; it must be annotated with "+api.IncrementalBaseAnnotation+"=true or have a base backup itself")
 }
 
 func (o *CreateOptions) Validate(c *cobra.Command, args []string) error {
@@ -98,11 +141,56 @@ func (o *CreateOptions) Validate(c *cobra.Command, args []string) error {
 		return err
 	}
 
+	if _, err := o.ExcludedFields(); err != nil {
+		return err
+	}
+
+	if _, err := compression.CodecFor(o.CompressionFormat); err != nil {
+		return err
+	}
+
+	if o.BackupSet != "" {
+		if errs := validation.IsValidLabelValue(o.BackupSet); len(errs) > 0 {
+			return errors.Errorf("invalid --backup-set %q: %s", o.BackupSet, strings.Join(errs, "; "))
+		}
+	}
+
+	switch {
+	case o.UseNameTemplate && o.Name != "":
+		return errors.New("NAME can't be specified with --use-name-template")
+	case !o.UseNameTemplate && o.Name == "":
+		return errors.New("NAME is required unless --use-name-template is specified")
+	}
+
 	return nil
 }
 
+// ExcludedFields returns the fields given by --exclude-fields, keyed by resource.
+func (o *CreateOptions) ExcludedFields() (map[string][]string, error) {
+	if len(o.ExcludeFields) == 0 {
+		return nil, nil
+	}
+
+	excludedFields := make(map[string][]string)
+	for _, entry := range o.ExcludeFields {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid --exclude-fields entry %q: must be in the form resource=/pointer", entry)
+		}
+		if err := pkgbackup.ValidateExcludedField(parts[1]); err != nil {
+			return nil, errors.Wrapf(err, "invalid --exclude-fields entry %q", entry)
+		}
+
+		excludedFields[parts[0]] = append(excludedFields[parts[0]], parts[1])
+	}
+
+	return excludedFields, nil
+}
+
 func (o *CreateOptions) Complete(args []string) error {
-	o.Name = args[0]
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
 	return nil
 }
 
@@ -112,33 +200,84 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 		return err
 	}
 
+	excludedFields, err := o.ExcludedFields()
+	if err != nil {
+		return err
+	}
+
+	if o.UseNameTemplate {
+		config, err := arkClient.ArkV1().Configs(f.Namespace()).Get("default", metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrap(err, "error getting the Ark server's Config")
+		}
+		if config.BackupNameTemplate == "" {
+			return errors.New("the Ark server's Config doesn't have a backupNameTemplate")
+		}
+
+		nameTemplate, err := pkgbackup.NewNameTemplate(config.BackupNameTemplate)
+		if err != nil {
+			return err
+		}
+		if o.Name, err = nameTemplate.Name(pkgbackup.NewNameTemplateData("", time.Now(), config.ClusterID)); err != nil {
+			return err
+		}
+	}
+
+	labels := o.Labels.Data()
+	if o.BackupSet != "" {
+		labels[api.BackupSetLabel] = o.BackupSet
+	}
+
 	backup := &api.Backup{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: f.Namespace(),
 			Name:      o.Name,
-			Labels:    o.Labels.Data(),
+			Labels:    labels,
 		},
 		Spec: api.BackupSpec{
-			IncludedNamespaces: o.IncludeNamespaces,
-			ExcludedNamespaces: o.ExcludeNamespaces,
-			IncludedResources:  o.IncludeResources,
-			ExcludedResources:  o.ExcludeResources,
-			LabelSelector:      o.Selector.LabelSelector,
-			SnapshotVolumes:    o.SnapshotVolumes.Value,
-			TTL:                metav1.Duration{Duration: o.TTL},
-			IncludeClusterResources: o.IncludeClusterResources.Value,
+			IncludedNamespaces:           o.IncludeNamespaces,
+			ExcludedNamespaces:           o.ExcludeNamespaces,
+			IncludedResources:            o.IncludeResources,
+			ExcludedResources:            o.ExcludeResources,
+			LabelSelector:                o.Selector.LabelSelector,
+			SnapshotVolumes:              o.SnapshotVolumes.Value,
+			VolumeSnapshotSelector:       o.VolumeSnapshotSelector.LabelSelector,
+			VolumeGroupLabel:             o.VolumeGroupLabel,
+			TTL:                          metav1.Duration{Duration: o.TTL},
+			IncludeClusterResources:      o.IncludeClusterResources.Value,
+			ConsistentListing:            o.ConsistentListing,
+			IncludeTerminatingNamespaces: o.IncludeTerminatingNamespaces,
+			IncludeServiceAccountTokens:  o.IncludeServiceAccountTokens,
+			ExcludedSecretAnnotations:    o.ExcludeSecretAnnotations.Data(),
+			ExcludedSecretOwnerKinds:     o.ExcludeSecretOwnerKinds,
+			ExcludedOwnerKinds:           o.ExcludeOwnerKinds,
+			MinItems:                     o.MinItems,
+			ModifiedSince:                metav1.Duration{Duration: o.ModifiedSince},
+			IncludeOwnerReferences:       o.IncludeOwnerReferences,
+			RequireIncludeAnnotation:     o.RequireIncludeAnnotation,
+			BaseBackup:                   o.BaseBackup,
+			ExcludedFields:               excludedFields,
+			CompressionFormat:            o.CompressionFormat,
+			ResourceRequestTimeout:       metav1.Duration{Duration: o.ResourceRequestTimeout},
 		},
 	}
 
-	if printed, err := output.PrintWithFormat(c, backup); printed || err != nil {
-		return err
+	if !output.IsNameOutput(c) {
+		if printed, err := output.PrintWithFormat(c, backup); printed || err != nil {
+			return err
+		}
 	}
 
-	_, err = arkClient.ArkV1().Backups(backup.Namespace).Create(backup)
+	backup, err = arkClient.ArkV1().Backups(backup.Namespace).Create(backup)
 	if err != nil {
 		return err
 	}
 
+	if output.IsNameOutput(c) {
+		fmt.Println(backup.Name)
+		return nil
+	}
+
 	fmt.Printf("Backup request %q submitted successfully.\n", backup.Name)
 	fmt.Printf("Run `ark backup describe %s` for more details.\n", backup.Name)
 	return nil
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/backup"
@@ -56,8 +57,9 @@ func NewDeleteCommand(f client.Factory, use string) *cobra.Command {
 
 // DeleteOptions contains parameters for deleting a backup.
 type DeleteOptions struct {
-	Name    string
-	Confirm bool
+	Name       string
+	Confirm    bool
+	RequestTTL time.Duration
 
 	client    clientset.Interface
 	namespace string
@@ -67,6 +69,7 @@ type DeleteOptions struct {
 // BindFlags binds options for this command to flags.
 func (o *DeleteOptions) BindFlags(flags *pflag.FlagSet) {
 	flags.BoolVar(&o.Confirm, "confirm", o.Confirm, "Confirm deletion")
+	flags.DurationVar(&o.RequestTTL, "request-ttl", o.RequestTTL, "how long to keep the delete backup request once it's been processed (0 keeps it for 24 hours after it's submitted)")
 }
 
 // Complete fills out the remainder of the parameters based on user input.
@@ -100,17 +103,22 @@ func (o *DeleteOptions) Validate(c *cobra.Command, args []string, f client.Facto
 		return errors.New("backup is not set; unable to proceed")
 	}
 
+	if o.RequestTTL < 0 {
+		return errors.New("--request-ttl must not be negative")
+	}
+
 	return nil
 }
 
 // Run performs the delete backup operation.
 func (o *DeleteOptions) Run() error {
-	if !o.Confirm && !getConfirmation() {
+	if !o.Confirm && !GetConfirmation() {
 		// Don't do anything unless we get confirmation
 		return nil
 	}
 
 	deleteRequest := backup.NewDeleteBackupRequest(o.backup.Name, string(o.backup.UID))
+	deleteRequest.Spec.TTLAfterProcessed.Duration = o.RequestTTL
 
 	if _, err := o.client.ArkV1().DeleteBackupRequests(o.namespace).Create(deleteRequest); err != nil {
 		return err
@@ -120,7 +128,9 @@ func (o *DeleteOptions) Run() error {
 	return nil
 }
 
-func getConfirmation() bool {
+// GetConfirmation prompts the user to confirm that they want to continue, returning
+// whether they did.
+func GetConfirmation() bool {
 	reader := bufio.NewReader(os.Stdin)
 
 	for {
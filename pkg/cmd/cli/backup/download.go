@@ -17,7 +17,10 @@ limitations under the License.
 package backup
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
@@ -26,6 +29,8 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cmd"
@@ -36,7 +41,7 @@ func NewDownloadCommand(f client.Factory) *cobra.Command {
 	o := NewDownloadOptions()
 	c := &cobra.Command{
 		Use:   "download NAME",
-		Short: "Download a backup",
+		Short: "Download a backup's contents",
 		Args:  cobra.ExactArgs(1),
 		Run: func(c *cobra.Command, args []string) {
 			cmd.CheckError(o.Complete(args))
@@ -97,18 +102,70 @@ func (o *DownloadOptions) Run(c *cobra.Command, f client.Factory) error {
 	arkClient, err := f.Client()
 	cmd.CheckError(err)
 
+	backup, err := arkClient.ArkV1().Backups(f.Namespace()).Get(o.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
 	backupDest, err := os.OpenFile(o.Output, o.writeOptions, 0600)
 	if err != nil {
 		return err
 	}
 	defer backupDest.Close()
 
-	err = downloadrequest.Stream(arkClient.ArkV1(), f.Namespace(), o.Name, v1.DownloadTargetKindBackupContents, backupDest, o.Timeout)
+	hash := sha256.New()
+	progress := &progressWriter{out: os.Stderr}
+
+	err = downloadrequest.Stream(arkClient.ArkV1(), f.Namespace(), o.Name, v1.DownloadTargetKindBackupContents, io.MultiWriter(backupDest, hash, progress), o.Timeout)
+	progress.finish()
 	if err != nil {
 		os.Remove(o.Output)
 		cmd.CheckError(err)
 	}
 
+	// backups taken before checksums were recorded can't be verified
+	if expected := backup.Status.ContentsSHA256; expected != "" {
+		if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+			os.Remove(o.Output)
+			return errors.Errorf("checksum mismatch for backup %s: expected SHA-256 %s, got %s", o.Name, expected, actual)
+		}
+		fmt.Printf("Verified SHA-256 checksum %s\n", expected)
+	}
+
 	fmt.Printf("Backup %s has been successfully downloaded to %s\n", o.Name, backupDest.Name())
 	return nil
 }
+
+// progressWriter reports the number of bytes written to it to out, at most
+// once per progressInterval.
+type progressWriter struct {
+	out        io.Writer
+	written    int64
+	lastReport time.Time
+}
+
+const progressInterval = 500 * time.Millisecond
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+
+	if now := time.Now(); now.Sub(w.lastReport) >= progressInterval {
+		w.report()
+		w.lastReport = now
+	}
+
+	return len(p), nil
+}
+
+func (w *progressWriter) report() {
+	fmt.Fprintf(w.out, "\rDownloaded %d bytes", w.written)
+}
+
+// finish reports the final number of bytes written and ends the progress line.
+func (w *progressWriter) finish() {
+	if w.written == 0 {
+		return
+	}
+	w.report()
+	fmt.Fprintln(w.out)
+}
@@ -0,0 +1,347 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	pkgbackup "github.com/heptio/ark/pkg/backup"
+	"github.com/heptio/ark/pkg/client"
+	"github.com/heptio/ark/pkg/cmd"
+	"github.com/heptio/ark/pkg/cmd/util/downloadrequest"
+	"github.com/heptio/ark/pkg/cmd/util/flag"
+	arkdiscovery "github.com/heptio/ark/pkg/discovery"
+	"github.com/heptio/ark/pkg/util/collections"
+	"github.com/heptio/ark/pkg/util/compression"
+)
+
+func NewExtractCommand(f client.Factory) *cobra.Command {
+	o := NewExtractOptions()
+	c := &cobra.Command{
+		Use:   "extract NAME",
+		Short: "Extract individual items from a backup's contents",
+		Long: `Extract individual items from a backup's contents without restoring them.
+
+The backup's contents are downloaded and read as a stream, and only the items that
+match the filters are written out, as JSON files laid out the same way they are in
+the backup: <DIR>/resources/<RESOURCE>/namespaces/<NAMESPACE>/<NAME>.json for
+namespaced items and <DIR>/resources/<RESOURCE>/cluster/<NAME>.json for
+cluster-scoped ones.
+
+To restore a few items to the cluster instead, use 'ark restore create' with the
+same filters; only the items a restore includes are extracted by the server.`,
+		Example: `  # list the configmaps in namespace "app" in backup "backup-1"
+  ark backup extract backup-1 --include-resources configmaps --include-namespaces app --list
+
+  # extract the configmap labeled name=foo from backup "backup-1" to ./backup-1-items
+  ark backup extract backup-1 --include-resources configmaps --selector name=foo`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Complete(args))
+			cmd.CheckError(o.Validate(c, args))
+			cmd.CheckError(o.Run(c, f))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+
+	return c
+}
+
+type ExtractOptions struct {
+	Name              string
+	OutputDir         string
+	List              bool
+	Force             bool
+	IncludeNamespaces flag.StringArray
+	ExcludeNamespaces flag.StringArray
+	IncludeResources  flag.StringArray
+	ExcludeResources  flag.StringArray
+	Selector          flag.LabelSelector
+	Timeout           time.Duration
+}
+
+func NewExtractOptions() *ExtractOptions {
+	return &ExtractOptions{
+		IncludeNamespaces: flag.NewStringArray("*"),
+		Timeout:           time.Minute,
+	}
+}
+
+func (o *ExtractOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&o.OutputDir, "output-dir", "d", o.OutputDir, "directory to write the extracted items to. Defaults to <NAME>-items in the current directory")
+	flags.BoolVar(&o.List, "list", o.List, "list the items that match instead of writing them out")
+	flags.BoolVar(&o.Force, "force", o.Force, "overwrite extracted items that already exist in the output directory")
+	flags.Var(&o.IncludeNamespaces, "include-namespaces", "namespaces to extract items from (use '*' for all namespaces)")
+	flags.Var(&o.ExcludeNamespaces, "exclude-namespaces", "namespaces not to extract items from")
+	flags.Var(&o.IncludeResources, "include-resources", "resources to extract, formatted as resource.group, such as storageclasses.storage.k8s.io (use '*' for all resources)")
+	flags.Var(&o.ExcludeResources, "exclude-resources", "resources not to extract, formatted as resource.group, such as storageclasses.storage.k8s.io")
+	flags.VarP(&o.Selector, "selector", "l", "only extract items matching this label selector")
+	flags.DurationVar(&o.Timeout, "timeout", o.Timeout, "maximum time to wait to process download request")
+}
+
+func (o *ExtractOptions) Validate(c *cobra.Command, args []string) error {
+	if errs := collections.ValidateIncludesExcludes(o.IncludeNamespaces, o.ExcludeNamespaces); len(errs) > 0 {
+		return errors.Wrap(errs[0], "invalid included/excluded namespace lists")
+	}
+	if errs := collections.ValidateIncludesExcludes(o.IncludeResources, o.ExcludeResources); len(errs) > 0 {
+		return errors.Wrap(errs[0], "invalid included/excluded resource lists")
+	}
+	return nil
+}
+
+func (o *ExtractOptions) Complete(args []string) error {
+	o.Name = args[0]
+
+	if o.OutputDir == "" {
+		path, err := os.Getwd()
+		if err != nil {
+			return errors.Wrapf(err, "error getting current directory")
+		}
+		o.OutputDir = filepath.Join(path, fmt.Sprintf("%s-items", o.Name))
+	}
+
+	return nil
+}
+
+func (o *ExtractOptions) Run(c *cobra.Command, f client.Factory) error {
+	arkClient, err := f.Client()
+	cmd.CheckError(err)
+
+	backup, err := arkClient.ArkV1().Backups(f.Namespace()).Get(o.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	kubeClient, err := f.KubeClient()
+	if err != nil {
+		return err
+	}
+	discoveryHelper, err := arkdiscovery.NewHelper(kubeClient.Discovery(), logrus.New())
+	if err != nil {
+		return errors.Wrap(err, "error discovering the cluster's resources")
+	}
+
+	filter, err := o.filter(discoveryHelper)
+	if err != nil {
+		return err
+	}
+	filter.compressionFormat = backup.Status.CompressionFormat
+	if !o.List {
+		filter.outputDir = o.OutputDir
+		filter.force = o.Force
+	}
+
+	// the contents are extracted while they're downloaded, rather than saved first
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(downloadrequest.Stream(arkClient.ArkV1(), f.Namespace(), o.Name, v1.DownloadTargetKindBackupContents, pw, o.Timeout))
+	}()
+
+	items, err := filter.extract(pr)
+	// stop the download if the extraction failed before reading all of it
+	pr.CloseWithError(err)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		fmt.Println(item)
+	}
+
+	if o.List {
+		return nil
+	}
+	if len(items) == 0 {
+		fmt.Printf("No items in backup %s match\n", o.Name)
+		return nil
+	}
+	fmt.Printf("Extracted %d items from backup %s to %s\n", len(items), o.Name, o.OutputDir)
+	return nil
+}
+
+// filter returns an itemFilter for the options. The included and excluded resources are
+// resolved with the discovery helper, so that short names and resources without their
+// group match the items of the resources they refer to; resources the cluster doesn't
+// have are matched as they're given.
+func (o *ExtractOptions) filter(discoveryHelper arkdiscovery.Helper) (*itemFilter, error) {
+	selector := labels.Everything()
+	if o.Selector.LabelSelector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(o.Selector.LabelSelector); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return &itemFilter{
+		namespaces: collections.NewIncludesExcludes().Includes(o.IncludeNamespaces...).Excludes(o.ExcludeNamespaces...),
+		resources: collections.GenerateIncludesExcludes(o.IncludeResources, o.ExcludeResources, func(item string) string {
+			gvr, _, err := discoveryHelper.ResourceFor(schema.ParseGroupResource(item).WithVersion(""))
+			if err != nil {
+				return item
+			}
+			gr := gvr.GroupResource()
+			return gr.String()
+		}),
+		selector: selector,
+	}, nil
+}
+
+// itemFilter extracts the items in a backup's contents that match its namespaces,
+// resources and selector.
+type itemFilter struct {
+	namespaces *collections.IncludesExcludes
+	resources  *collections.IncludesExcludes
+	selector   labels.Selector
+
+	// outputDir is the directory the items are written to. If empty, they're only listed.
+	outputDir string
+	// force is whether items that already exist in outputDir are overwritten.
+	force bool
+	// compressionFormat is the format the backup tarball is compressed with. If
+	// empty, it's gzip.
+	compressionFormat string
+}
+
+// extract reads a compressed backup tarball and writes the items that match the filter to its
+// output directory, if it has one. It returns the path of each matching item in the tarball.
+func (f *itemFilter) extract(r io.Reader) ([]string, error) {
+	codec, err := compression.CodecFor(f.compressionFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	cr, err := codec.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer cr.Close()
+
+	var items []string
+
+	tr := tar.NewReader(cr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if header.Typeflag != tar.TypeReg || !f.matchesPath(header.Name) {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading %s", header.Name)
+		}
+
+		matches, err := f.matchesLabels(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading %s", header.Name)
+		}
+		if !matches {
+			continue
+		}
+
+		if f.outputDir != "" {
+			if err := f.write(header.Name, data); err != nil {
+				return nil, err
+			}
+		}
+
+		items = append(items, header.Name)
+	}
+
+	return items, nil
+}
+
+// matchesPath returns true if the tarball entry with the specified name is an item of an
+// included resource in an included namespace. Cluster-scoped items aren't filtered by
+// namespace.
+func (f *itemFilter) matchesPath(name string) bool {
+	path, ok := pkgbackup.ParseItemPath(name)
+	if !ok || !path.IsItem() || !f.resources.ShouldInclude(path.Resource) {
+		return false
+	}
+
+	if path.Namespace != "" {
+		return f.namespaces.ShouldInclude(path.Namespace)
+	}
+	return true
+}
+
+func (f *itemFilter) matchesLabels(data []byte) (bool, error) {
+	if f.selector.Empty() {
+		return true, nil
+	}
+
+	var obj unstructured.Unstructured
+	if err := json.Unmarshal(data, &obj.Object); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return f.selector.Matches(labels.Set(obj.GetLabels())), nil
+}
+
+func (f *itemFilter) write(name string, data []byte) error {
+	target := filepath.Join(f.outputDir, filepath.FromSlash(name))
+	// don't let a malformed entry name write outside of the output directory
+	if !strings.HasPrefix(target, filepath.Clean(f.outputDir)+string(filepath.Separator)) {
+		return errors.Errorf("invalid item path %s", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if f.force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(target, flags, 0600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return errors.WithStack(err)
+}
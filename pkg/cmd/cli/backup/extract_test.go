@@ -0,0 +1,233 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/heptio/ark/pkg/cmd/util/flag"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+var backupContents = map[string]string{
+	"metadata/version": `1`,
+	"resources/configmaps/namespaces/ns-1/cm-1.json":           `{"metadata":{"name":"cm-1","labels":{"name":"foo"}}}`,
+	"resources/configmaps/namespaces/ns-1/cm-2.json":           `{"metadata":{"name":"cm-2","labels":{"name":"bar"}}}`,
+	"resources/configmaps/namespaces/ns-2/cm-1.json":           `{"metadata":{"name":"cm-1","labels":{"name":"foo"}}}`,
+	"resources/pods/namespaces/ns-1/pod-1.json":                `{"metadata":{"name":"pod-1","labels":{"name":"foo"}}}`,
+	"resources/persistentvolumes/cluster/pv-1.json":            `{"metadata":{"name":"pv-1"}}`,
+	"resources/deployments.apps/namespaces/ns-1/deploy-1.json": `{"metadata":{"name":"deploy-1"}}`,
+}
+
+// discoveryHelper resolves resources the way the cluster's discovery API does, including
+// short names and resources without their group.
+func discoveryHelper() *arktest.FakeDiscoveryHelper {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	return arktest.NewFakeDiscoveryHelper(false, map[schema.GroupVersionResource]schema.GroupVersionResource{
+		{Resource: "configmaps"}:                 configMaps,
+		{Resource: "cm"}:                         configMaps,
+		{Resource: "pods"}:                       {Version: "v1", Resource: "pods"},
+		{Resource: "persistentvolumes"}:          {Version: "v1", Resource: "persistentvolumes"},
+		{Resource: "deployments"}:                deployments,
+		{Group: "apps", Resource: "deployments"}: deployments,
+		{Resource: "deploy"}:                     deployments,
+	})
+}
+
+func newBackupContents(t *testing.T) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	gzw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gzw)
+
+	for _, name := range []string{
+		"metadata/version",
+		"resources/configmaps/namespaces/ns-1/cm-1.json",
+		"resources/configmaps/namespaces/ns-1/cm-2.json",
+		"resources/configmaps/namespaces/ns-2/cm-1.json",
+		"resources/pods/namespaces/ns-1/pod-1.json",
+		"resources/persistentvolumes/cluster/pv-1.json",
+		"resources/deployments.apps/namespaces/ns-1/deploy-1.json",
+	} {
+		data := backupContents[name]
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(data))}))
+		_, err := tw.Write([]byte(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+
+	return buf
+}
+
+func TestExtractItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  func(o *ExtractOptions)
+		expected []string
+	}{
+		{
+			name:    "everything",
+			options: func(o *ExtractOptions) {},
+			expected: []string{
+				"resources/configmaps/namespaces/ns-1/cm-1.json",
+				"resources/configmaps/namespaces/ns-1/cm-2.json",
+				"resources/configmaps/namespaces/ns-2/cm-1.json",
+				"resources/pods/namespaces/ns-1/pod-1.json",
+				"resources/persistentvolumes/cluster/pv-1.json",
+				"resources/deployments.apps/namespaces/ns-1/deploy-1.json",
+			},
+		},
+		{
+			name: "included resources and namespaces",
+			options: func(o *ExtractOptions) {
+				o.IncludeResources = flag.NewStringArray("configmaps")
+				o.IncludeNamespaces = flag.NewStringArray("ns-1")
+			},
+			expected: []string{
+				"resources/configmaps/namespaces/ns-1/cm-1.json",
+				"resources/configmaps/namespaces/ns-1/cm-2.json",
+			},
+		},
+		{
+			name: "excluded namespaces don't apply to cluster-scoped items",
+			options: func(o *ExtractOptions) {
+				o.ExcludeNamespaces = flag.NewStringArray("ns-1", "ns-2")
+			},
+			expected: []string{
+				"resources/persistentvolumes/cluster/pv-1.json",
+			},
+		},
+		{
+			name: "grouped resources match without their group",
+			options: func(o *ExtractOptions) {
+				o.IncludeResources = flag.NewStringArray("deployments")
+			},
+			expected: []string{
+				"resources/deployments.apps/namespaces/ns-1/deploy-1.json",
+			},
+		},
+		{
+			name: "short names match their resources",
+			options: func(o *ExtractOptions) {
+				o.IncludeResources = flag.NewStringArray("cm", "deploy")
+				o.IncludeNamespaces = flag.NewStringArray("ns-1")
+			},
+			expected: []string{
+				"resources/configmaps/namespaces/ns-1/cm-1.json",
+				"resources/configmaps/namespaces/ns-1/cm-2.json",
+				"resources/deployments.apps/namespaces/ns-1/deploy-1.json",
+			},
+		},
+		{
+			name: "excluded resources are resolved too",
+			options: func(o *ExtractOptions) {
+				o.ExcludeResources = flag.NewStringArray("cm", "deployments.apps", "pods", "persistentvolumes")
+			},
+			expected: nil,
+		},
+		{
+			name: "resources the cluster doesn't have are matched as they're given",
+			options: func(o *ExtractOptions) {
+				o.IncludeResources = flag.NewStringArray("deployments.apps")
+				o.ExcludeResources = flag.NewStringArray("widgets.example.com")
+			},
+			expected: []string{
+				"resources/deployments.apps/namespaces/ns-1/deploy-1.json",
+			},
+		},
+		{
+			name: "label selector",
+			options: func(o *ExtractOptions) {
+				o.IncludeResources = flag.NewStringArray("configmaps")
+				require.NoError(t, o.Selector.Set("name=foo"))
+			},
+			expected: []string{
+				"resources/configmaps/namespaces/ns-1/cm-1.json",
+				"resources/configmaps/namespaces/ns-2/cm-1.json",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := NewExtractOptions()
+			test.options(o)
+
+			filter, err := o.filter(discoveryHelper())
+			require.NoError(t, err)
+
+			dir, err := ioutil.TempDir("", "")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+			filter.outputDir = dir
+
+			items, err := filter.extract(newBackupContents(t))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, items)
+
+			for _, item := range items {
+				data, err := ioutil.ReadFile(filepath.Join(dir, item))
+				require.NoError(t, err)
+				assert.Equal(t, backupContents[item], string(data))
+			}
+		})
+	}
+}
+
+func TestExtractItemsListOnly(t *testing.T) {
+	filter, err := NewExtractOptions().filter(discoveryHelper())
+	require.NoError(t, err)
+
+	items, err := filter.extract(newBackupContents(t))
+	require.NoError(t, err)
+	assert.Len(t, items, 6)
+}
+
+func TestExtractItemsDoesNotOverwrite(t *testing.T) {
+	o := NewExtractOptions()
+	o.IncludeResources = flag.NewStringArray("persistentvolumes")
+	filter, err := o.filter(discoveryHelper())
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filter.outputDir = dir
+
+	_, err = filter.extract(newBackupContents(t))
+	require.NoError(t, err)
+
+	_, err = filter.extract(newBackupContents(t))
+	assert.Error(t, err)
+
+	filter.force = true
+	_, err = filter.extract(newBackupContents(t))
+	assert.NoError(t, err)
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package backup
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -24,11 +26,17 @@ import (
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cmd"
+	"github.com/heptio/ark/pkg/cmd/util/flag"
 	"github.com/heptio/ark/pkg/cmd/util/output"
 )
 
 func NewGetCommand(f client.Factory, use string) *cobra.Command {
-	var listOptions metav1.ListOptions
+	var (
+		listOptions         metav1.ListOptions
+		since               flag.Time
+		until               flag.Time
+		showPendingDeletion bool
+	)
 
 	c := &cobra.Command{
 		Use:   use,
@@ -51,16 +59,59 @@ func NewGetCommand(f client.Factory, use string) *cobra.Command {
 			} else {
 				backups, err = arkClient.ArkV1().Backups(f.Namespace()).List(listOptions)
 				cmd.CheckError(err)
+
+				if !showPendingDeletion {
+					backups.Items = filterBackupsPendingDeletion(backups.Items)
+				}
 			}
 
+			backups.Items = filterBackupsByCreationTime(backups.Items, since.Time, until.Time)
+
 			_, err = output.PrintWithFormat(c, backups)
 			cmd.CheckError(err)
 		},
 	}
 
 	c.Flags().StringVarP(&listOptions.LabelSelector, "selector", "l", listOptions.LabelSelector, "only show items matching this label selector")
+	c.Flags().Var(&since, "since", "only show backups created at or after this time, specified as an RFC3339 timestamp or a duration before now (e.g. 168h)")
+	c.Flags().Var(&until, "until", "only show backups created at or before this time, specified as an RFC3339 timestamp or a duration before now (e.g. 24h)")
+	c.Flags().BoolVar(&showPendingDeletion, "show-pending-deletion", showPendingDeletion, "also show backups in the recycle bin, which are pending deletion")
 
 	output.BindFlags(c.Flags())
 
 	return c
 }
+
+// filterBackupsPendingDeletion returns the backups that aren't in the recycle bin.
+func filterBackupsPendingDeletion(backups []api.Backup) []api.Backup {
+	var filtered []api.Backup
+	for _, backup := range backups {
+		if backup.Status.Phase != api.BackupPhasePendingDeletion {
+			filtered = append(filtered, backup)
+		}
+	}
+
+	return filtered
+}
+
+// filterBackupsByCreationTime returns the backups created within the range [since, until]. A zero
+// since or until leaves that end of the range unbounded.
+func filterBackupsByCreationTime(backups []api.Backup, since, until time.Time) []api.Backup {
+	if since.IsZero() && until.IsZero() {
+		return backups
+	}
+
+	var filtered []api.Backup
+	for _, backup := range backups {
+		created := backup.CreationTimestamp.Time
+		if !since.IsZero() && created.Before(since) {
+			continue
+		}
+		if !until.IsZero() && created.After(until) {
+			continue
+		}
+		filtered = append(filtered, backup)
+	}
+
+	return filtered
+}
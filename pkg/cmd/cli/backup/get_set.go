@@ -0,0 +1,121 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/client"
+	"github.com/heptio/ark/pkg/cmd"
+	"github.com/heptio/ark/pkg/cmd/util/flag"
+)
+
+// NewGetSetCommand creates a new command that lists the backups in a backup set.
+func NewGetSetCommand(f client.Factory, use string) *cobra.Command {
+	var expectedClusters flag.StringArray
+
+	c := &cobra.Command{
+		Use:   fmt.Sprintf("%s SET_ID", use),
+		Short: "Get the backups in a backup set",
+		Long: fmt.Sprintf(`Get the backups in a backup set, along with the clusters they were taken from.
+
+Backups are added to a set by creating them with --backup-set, which labels them
+with %s=<SET_ID>. When the Ark servers of several clusters share a bucket, each
+server syncs every cluster's backups from it, so a set's backups can be listed
+from any of them. Use --expected-clusters to list the clusters that don't have
+a backup in the set.`, api.BackupSetLabel),
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			arkClient, err := f.Client()
+			cmd.CheckError(err)
+
+			selector := labels.SelectorFromSet(labels.Set{api.BackupSetLabel: args[0]})
+			backups, err := arkClient.ArkV1().Backups(f.Namespace()).List(metav1.ListOptions{LabelSelector: selector.String()})
+			cmd.CheckError(err)
+
+			cmd.CheckError(printBackupSet(os.Stdout, args[0], backups.Items, expectedClusters))
+		},
+	}
+
+	c.Flags().Var(&expectedClusters, "expected-clusters", "IDs of the clusters that should have a backup in the set, as given by their servers' clusterID; missing clusters are listed")
+
+	return c
+}
+
+// printBackupSet writes a table of the backups in the set, ordered by cluster
+// and name, followed by the expected clusters that don't have a backup in it.
+func printBackupSet(w io.Writer, id string, backups []api.Backup, expectedClusters []string) error {
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].Status.ClusterID != backups[j].Status.ClusterID {
+			return backups[i].Status.ClusterID < backups[j].Status.ClusterID
+		}
+		return backups[i].Name < backups[j].Name
+	})
+
+	clusters := make(map[string]bool)
+	for _, backup := range backups {
+		clusters[backup.Status.ClusterID] = true
+	}
+
+	var missing []string
+	for _, cluster := range expectedClusters {
+		if !clusters[cluster] {
+			missing = append(missing, cluster)
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Backup set:\t%s\n", id)
+	fmt.Fprintf(tw, "Backups:\t%d, from %d clusters\n", len(backups), len(clusters))
+	if len(expectedClusters) > 0 {
+		missingClusters := "<none>"
+		if len(missing) > 0 {
+			missingClusters = strings.Join(missing, ", ")
+		}
+		fmt.Fprintf(tw, "Missing clusters:\t%s\n", missingClusters)
+	}
+
+	if len(backups) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "NAME\tCLUSTER\tSTATUS\tCREATED")
+		for _, backup := range backups {
+			cluster := backup.Status.ClusterID
+			if cluster == "" {
+				cluster = "<none>"
+			}
+			phase := backup.Status.Phase
+			if phase == "" {
+				phase = api.BackupPhaseNew
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", backup.Name, cluster, phase, backup.CreationTimestamp.Time)
+		}
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+func TestPrintBackupSet(t *testing.T) {
+	created := metav1.NewTime(time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC))
+	newBackup := func(name, cluster string, phase api.BackupPhase) api.Backup {
+		return api.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: created},
+			Status:     api.BackupStatus{ClusterID: cluster, Phase: phase},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		backups          []api.Backup
+		expectedClusters []string
+		expected         string
+	}{
+		{
+			name: "backups are ordered by cluster and name",
+			backups: []api.Backup{
+				newBackup("dr-west", "west", api.BackupPhaseInProgress),
+				newBackup("dr-east-2", "east", api.BackupPhaseCompleted),
+				newBackup("dr-east-1", "east", api.BackupPhaseFailed),
+			},
+			expected: `Backup set:  dr
+Backups:     3, from 2 clusters
+
+NAME       CLUSTER  STATUS      CREATED
+dr-east-1  east     Failed      2018-06-01 00:00:00 +0000 UTC
+dr-east-2  east     Completed   2018-06-01 00:00:00 +0000 UTC
+dr-west    west     InProgress  2018-06-01 00:00:00 +0000 UTC
+`,
+		},
+		{
+			name: "expected clusters without a backup are listed as missing",
+			backups: []api.Backup{
+				newBackup("dr-east", "east", api.BackupPhaseCompleted),
+				newBackup("dr-unknown", "", ""),
+			},
+			expectedClusters: []string{"east", "west", "north"},
+			expected: `Backup set:        dr
+Backups:           2, from 2 clusters
+Missing clusters:  west, north
+
+NAME        CLUSTER  STATUS     CREATED
+dr-unknown  <none>   New        2018-06-01 00:00:00 +0000 UTC
+dr-east     east     Completed  2018-06-01 00:00:00 +0000 UTC
+`,
+		},
+		{
+			name:             "a set without backups has every expected cluster missing",
+			expectedClusters: []string{"east"},
+			expected: `Backup set:        dr
+Backups:           0, from 0 clusters
+Missing clusters:  east
+`,
+		},
+		{
+			name:             "a complete set has no missing clusters",
+			backups:          []api.Backup{newBackup("dr-east", "east", api.BackupPhaseCompleted)},
+			expectedClusters: []string{"east"},
+			expected: `Backup set:        dr
+Backups:           1, from 1 clusters
+Missing clusters:  <none>
+
+NAME     CLUSTER  STATUS     CREATED
+dr-east  east     Completed  2018-06-01 00:00:00 +0000 UTC
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, printBackupSet(&buf, "dr", test.backups, test.expectedClusters))
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/client"
+	"github.com/heptio/ark/pkg/cmd"
+)
+
+// NewRecoverCommand creates a new command that recovers a backup from the GC
+// controller's recycle bin.
+func NewRecoverCommand(f client.Factory, use string) *cobra.Command {
+	var ttl time.Duration
+
+	c := &cobra.Command{
+		Use:   fmt.Sprintf("%s NAME", use),
+		Short: "Recover a backup from the recycle bin",
+		Long: fmt.Sprintf(`Recover a backup from the recycle bin.
+
+When the server's gcRecycleBinRetention is set, expired backups are moved to the
+recycle bin, with their phase set to %s, and only deleted once it elapses. This
+returns such a backup to its previous phase and sets it to expire again after --ttl,
+or after its own TTL if --ttl isn't specified.`, v1.BackupPhasePendingDeletion),
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			arkClient, err := f.Client()
+			cmd.CheckError(err)
+
+			backup, err := arkClient.ArkV1().Backups(f.Namespace()).Get(args[0], metav1.GetOptions{})
+			cmd.CheckError(err)
+
+			if backup.Status.Phase != v1.BackupPhasePendingDeletion {
+				cmd.CheckError(errors.Errorf("backup %q is not in the recycle bin", backup.Name))
+			}
+
+			previousPhase := v1.BackupPhaseCompleted
+			if backup.Status.RecycleBin != nil && backup.Status.RecycleBin.PreviousPhase != "" {
+				previousPhase = backup.Status.RecycleBin.PreviousPhase
+			}
+
+			if ttl == 0 {
+				ttl = backup.Spec.TTL.Duration
+			}
+
+			patch, err := json.Marshal(map[string]interface{}{
+				"status": map[string]interface{}{
+					"phase":      previousPhase,
+					"expiration": metav1.NewTime(time.Now().Add(ttl)),
+					"recycleBin": nil,
+				},
+			})
+			cmd.CheckError(errors.WithStack(err))
+
+			_, err = arkClient.ArkV1().Backups(backup.Namespace).Patch(backup.Name, types.MergePatchType, patch)
+			cmd.CheckError(errors.Wrapf(err, "error recovering backup %s", backup.Name))
+
+			fmt.Printf("Backup %q recovered from the recycle bin.\n", backup.Name)
+		},
+	}
+
+	c.Flags().DurationVar(&ttl, "ttl", ttl, "how long before the recovered backup expires again. Defaults to the backup's TTL")
+
+	return c
+}
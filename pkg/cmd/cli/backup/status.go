@@ -0,0 +1,66 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/heptio/ark/pkg/client"
+	"github.com/heptio/ark/pkg/cmd"
+	"github.com/heptio/ark/pkg/cmd/util/backupstatus"
+)
+
+// NewStatusCommand creates a new command that shows a backup's phase and progress.
+func NewStatusCommand(f client.Factory, use string) *cobra.Command {
+	var watch bool
+
+	c := &cobra.Command{
+		Use:   fmt.Sprintf("%s NAME", use),
+		Short: "Show a backup's phase and progress",
+		Long: `Show a backup's phase and progress, and the errors and warnings it finished with.
+
+With --watch, a line is printed each time the backup's phase or progress changes,
+until the backup finishes.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			arkClient, err := f.Client()
+			cmd.CheckError(err)
+
+			if watch {
+				cmd.CheckError(backupstatus.Watch(arkClient.ArkV1(), f.Namespace(), args[0], os.Stdout))
+				return
+			}
+
+			backup, err := arkClient.ArkV1().Backups(f.Namespace()).Get(args[0], metav1.GetOptions{})
+			cmd.CheckError(err)
+
+			fmt.Println(backupstatus.Line(backup))
+			if backupstatus.IsFinished(backup.Status.Phase) {
+				backupstatus.PrintResult(os.Stdout, backup)
+			}
+		},
+	}
+
+	c.Flags().BoolVarP(&watch, "watch", "w", watch, "print the backup's status each time it changes, until the backup finishes")
+
+	return c
+}
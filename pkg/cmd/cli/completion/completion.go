@@ -0,0 +1,250 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package completion
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/heptio/ark/pkg/cmd"
+)
+
+// nameArg is a command that takes the names of resources of one type as arguments.
+type nameArg struct {
+	// path is the command's path, relative to the root command.
+	path     string
+	resource string
+}
+
+// nameFlag is a command's flag that takes the name of a resource.
+type nameFlag struct {
+	path     string
+	flag     string
+	resource string
+}
+
+// nameArgs are the commands whose arguments are completed with the names of existing
+// resources.
+var nameArgs = []nameArg{
+	{"backup delete", "backups"},
+	{"backup describe", "backups"},
+	{"backup download", "backups"},
+	{"backup get", "backups"},
+	{"backup logs", "backups"},
+	{"delete backup", "backups"},
+	{"describe backups", "backups"},
+	{"get backups", "backups"},
+	{"restore delete", "restores"},
+	{"restore describe", "restores"},
+	{"restore get", "restores"},
+	{"restore logs", "restores"},
+	{"delete restore", "restores"},
+	{"describe restores", "restores"},
+	{"get restores", "restores"},
+	{"schedule delete", "schedules"},
+	{"schedule describe", "schedules"},
+	{"schedule get", "schedules"},
+	{"delete schedule", "schedules"},
+	{"describe schedules", "schedules"},
+	{"get schedules", "schedules"},
+}
+
+// nameFlags are the flags whose values are completed with the names of existing resources.
+var nameFlags = []nameFlag{
+	{"restore create", "from-backup", "backups"},
+	{"create restore", "from-backup", "backups"},
+}
+
+func NewCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "completion SHELL",
+		Short: "Output shell completion code for the specified shell (bash, zsh, or fish)",
+		Long: `Output shell completion code for the specified shell (bash, zsh, or fish).
+
+The code must be evaluated to provide interactive completion of ark commands and
+flags. In bash and fish, the names of backups, restores, and schedules are also
+completed, by listing them with 'ark get'.
+
+Bash completion requires the bash-completion package.`,
+		Example: `  # load completion into the current bash shell
+  source <(ark completion bash)
+
+  # load completion into the current zsh shell
+  source <(ark completion zsh)
+
+  # load completion into the current fish shell
+  ark completion fish | source
+
+  # load completion into every new bash shell
+  ark completion bash > /etc/bash_completion.d/ark`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(Generate(c.Root(), args[0], os.Stdout))
+		},
+	}
+
+	return c
+}
+
+// Generate writes the completion code for root and its subcommands for the specified shell
+// to w.
+func Generate(root *cobra.Command, shell string, w io.Writer) error {
+	for _, na := range nameArgs {
+		if _, err := findCommand(root, na.path); err != nil {
+			return err
+		}
+	}
+	for _, nf := range nameFlags {
+		c, err := findCommand(root, nf.path)
+		if err != nil {
+			return err
+		}
+		if c.Flags().Lookup(nf.flag) == nil {
+			return errors.Errorf("command %q has no flag %s", nf.path, nf.flag)
+		}
+	}
+
+	switch shell {
+	case "bash":
+		if err := markNameFlags(root); err != nil {
+			return err
+		}
+		root.BashCompletionFunction = bashCompletionFunction(root.Name())
+		return errors.WithStack(root.GenBashCompletion(w))
+	case "zsh":
+		return errors.WithStack(root.GenZshCompletion(w))
+	case "fish":
+		return genFishCompletion(root, w)
+	default:
+		return errors.Errorf("unsupported shell %q, must be one of bash, zsh, or fish", shell)
+	}
+}
+
+// findCommand returns the subcommand of root with the specified path.
+func findCommand(root *cobra.Command, path string) (*cobra.Command, error) {
+	c, rest, err := root.Find(strings.Fields(path))
+	if err != nil || len(rest) > 0 || c == root {
+		return nil, errors.Errorf("command %q not found", path)
+	}
+	return c, nil
+}
+
+// markNameFlags annotates each of the nameFlags so that bash completes their values by
+// calling the function that lists resources.
+func markNameFlags(root *cobra.Command) error {
+	for _, nf := range nameFlags {
+		c, err := findCommand(root, nf.path)
+		if err != nil {
+			return err
+		}
+		funcName := fmt.Sprintf("__%s_get_resource %s", bashName(root.Name()), nf.resource)
+		if err := c.MarkFlagCustom(nf.flag, funcName); err != nil {
+			return errors.Wrapf(err, "error marking flag %s of command %q", nf.flag, nf.path)
+		}
+	}
+	return nil
+}
+
+// bashName returns name with the characters that can't be used in bash function names
+// replaced, the same way cobra does.
+func bashName(name string) string {
+	return strings.NewReplacer(" ", "_", ":", "__").Replace(name)
+}
+
+// bashCompletionFunction returns the bash functions that complete resource names for the
+// command named name. cobra calls __custom_func when it has no other completions.
+func bashCompletionFunction(name string) string {
+	prefix := bashName(name)
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, `__%[1]s_override_flags()
+{
+    local two_word_of= w
+    for w in "${words[@]}"; do
+        if [[ -n ${two_word_of} ]]; then
+            echo "${two_word_of}=${w}"
+            two_word_of=
+            continue
+        fi
+        case "${w}" in
+            --namespace=* | --kubeconfig=* | --kubecontext=*)
+                echo "${w}"
+                ;;
+            --namespace | -n)
+                two_word_of="--namespace"
+                ;;
+            --kubeconfig | --kubecontext)
+                two_word_of="${w}"
+                ;;
+        esac
+    done
+}
+
+__%[1]s_get_resource()
+{
+    local %[1]s_out
+    if %[1]s_out=$(%[2]s get "$1" $(__%[1]s_override_flags) 2>/dev/null | awk 'NR > 1 { print $1 }'); then
+        COMPREPLY=( $( compgen -W "${%[1]s_out[*]}" -- "$cur" ) )
+    fi
+}
+
+__custom_func()
+{
+    case ${last_command} in
+`, prefix, name)
+
+	resources, commands := groupNameArgs()
+	for _, resource := range resources {
+		var names []string
+		for _, path := range commands[resource] {
+			names = append(names, bashName(name+" "+path))
+		}
+		fmt.Fprintf(buf, "        %s)\n", strings.Join(names, " | "))
+		fmt.Fprintf(buf, "            __%s_get_resource %s\n", prefix, resource)
+		fmt.Fprintf(buf, "            return\n")
+		fmt.Fprintf(buf, "            ;;\n")
+	}
+
+	fmt.Fprintf(buf, `        *)
+            ;;
+    esac
+}
+`)
+
+	return buf.String()
+}
+
+// groupNameArgs returns the resources named by nameArgs, in order, and the paths of the
+// commands that take each one's names.
+func groupNameArgs() ([]string, map[string][]string) {
+	var resources []string
+	commands := make(map[string][]string)
+	for _, na := range nameArgs {
+		if _, ok := commands[na.resource]; !ok {
+			resources = append(resources, na.resource)
+		}
+		commands[na.resource] = append(commands[na.resource], na.path)
+	}
+	return resources, commands
+}
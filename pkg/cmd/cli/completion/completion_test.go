@@ -0,0 +1,73 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package completion_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/heptio/ark/pkg/cmd/ark"
+	"github.com/heptio/ark/pkg/cmd/cli/completion"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		shell    string
+		expected []string
+	}{
+		{
+			shell: "bash",
+			expected: []string{
+				"ark_backup_describe | ark_backup_download",
+				"__ark_get_resource restores",
+				`flags_completion+=("__ark_get_resource backups")`,
+			},
+		},
+		{
+			shell:    "zsh",
+			expected: []string{"#compdef ark"},
+		},
+		{
+			shell: "fish",
+			expected: []string{
+				`complete -c ark -n "__ark_at ''" -f -a 'backup' -d 'Work with backups'`,
+				`complete -c ark -n "__ark_at 'describe schedules'" -f -a '(__ark_get_resource schedules)'`,
+				`complete -c ark -n "__ark_at 'restore create'" -l from-backup -r -d 'backup to restore from' -f -a '(__ark_get_resource backups)'`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.shell, func(t *testing.T) {
+			// the command tree is built for each shell, since generating bash completion
+			// modifies it
+			buf := new(bytes.Buffer)
+			require.NoError(t, completion.Generate(ark.NewCommand("ark"), test.shell, buf))
+
+			for _, s := range test.expected {
+				assert.Contains(t, buf.String(), s)
+			}
+		})
+	}
+}
+
+func TestGenerateUnsupportedShell(t *testing.T) {
+	assert.Error(t, completion.Generate(ark.NewCommand("ark"), "tcsh", new(bytes.Buffer)))
+}
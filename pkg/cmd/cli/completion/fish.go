@@ -0,0 +1,195 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package completion
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// genFishCompletion writes fish completion code for root and its subcommands to w. The
+// vendored cobra can't generate it, so the code is built from the command tree: each
+// completion applies when the command path on the command line, worked out by
+// __<root>_command_path, is the command it belongs to.
+func genFishCompletion(root *cobra.Command, w io.Writer) error {
+	name := root.Name()
+	prefix := bashName(name)
+
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, "# fish completion for %s\n\n", name)
+
+	fmt.Fprintf(buf, "set -g __%s_commands", prefix)
+	for _, path := range commandPaths(root) {
+		fmt.Fprintf(buf, " %s", fishQuote(path))
+	}
+	fmt.Fprintf(buf, "\n\n")
+
+	fmt.Fprintf(buf, `function __%[1]s_command_path
+    set -l path
+    for w in (commandline -opc)[2..-1]
+        if contains -- (string join ' ' $path $w) $__%[1]s_commands
+            set path $path $w
+        end
+    end
+    string join ' ' $path
+end
+
+function __%[1]s_at
+    set -l path (__%[1]s_command_path)
+    test "$path" = "$argv[1]"
+end
+
+function __%[1]s_override_flags
+    set -l words (commandline -opc)
+    for i in (seq (count $words))
+        switch $words[$i]
+            case '--namespace=*' '--kubeconfig=*' '--kubecontext=*'
+                echo $words[$i]
+            case --namespace -n --kubeconfig --kubecontext
+                if test $i -lt (count $words)
+                    set -l flag $words[$i]
+                    if test $flag = -n
+                        set flag --namespace
+                    end
+                    echo "$flag="$words[(math $i + 1)]
+                end
+        end
+    end
+end
+
+function __%[1]s_get_resource
+    %[2]s get $argv[1] (__%[1]s_override_flags) 2>/dev/null | awk 'NR > 1 { print $1 }'
+end
+
+`, prefix, name)
+
+	for _, f := range flags(root.PersistentFlags()) {
+		fmt.Fprintf(buf, "complete -c %s%s\n", name, fishFlag(f))
+	}
+
+	resourceFlags := make(map[string]map[string]string)
+	for _, nf := range nameFlags {
+		if resourceFlags[nf.path] == nil {
+			resourceFlags[nf.path] = make(map[string]string)
+		}
+		resourceFlags[nf.path][nf.flag] = nf.resource
+	}
+
+	resourceArgs := make(map[string]string)
+	for _, na := range nameArgs {
+		resourceArgs[na.path] = na.resource
+	}
+
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		path := relativePath(root, c)
+		condition := fmt.Sprintf(`-n "__%s_at %s"`, prefix, fishQuote(path))
+
+		for _, sub := range c.Commands() {
+			if !sub.IsAvailableCommand() {
+				continue
+			}
+			fmt.Fprintf(buf, "complete -c %s %s -f -a %s -d %s\n", name, condition, fishQuote(sub.Name()), fishQuote(sub.Short))
+		}
+
+		if c != root {
+			for _, f := range flags(c.NonInheritedFlags()) {
+				line := fmt.Sprintf("complete -c %s %s%s", name, condition, fishFlag(f))
+				if resource, ok := resourceFlags[path][f.Name]; ok {
+					line += fmt.Sprintf(" -f -a '(__%s_get_resource %s)'", prefix, resource)
+				}
+				fmt.Fprintln(buf, line)
+			}
+		}
+
+		if resource, ok := resourceArgs[path]; ok {
+			fmt.Fprintf(buf, "complete -c %s %s -f -a '(__%s_get_resource %s)'\n", name, condition, prefix, resource)
+		}
+
+		for _, sub := range c.Commands() {
+			if sub.IsAvailableCommand() {
+				walk(sub)
+			}
+		}
+	}
+	walk(root)
+
+	_, err := buf.WriteTo(w)
+	return errors.WithStack(err)
+}
+
+// commandPaths returns the paths, relative to root, of root's available subcommands and
+// all of theirs.
+func commandPaths(root *cobra.Command) []string {
+	var paths []string
+
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		for _, sub := range c.Commands() {
+			if !sub.IsAvailableCommand() {
+				continue
+			}
+			paths = append(paths, relativePath(root, sub))
+			walk(sub)
+		}
+	}
+	walk(root)
+
+	return paths
+}
+
+// relativePath returns the path of c relative to root, such as "backup create", or "" for
+// root itself. It's the format __<root>_command_path prints.
+func relativePath(root, c *cobra.Command) string {
+	return strings.TrimPrefix(strings.TrimPrefix(c.CommandPath(), root.Name()), " ")
+}
+
+// flags returns the flags in fs that aren't hidden.
+func flags(fs *pflag.FlagSet) []*pflag.Flag {
+	var res []*pflag.Flag
+	fs.VisitAll(func(f *pflag.Flag) {
+		if !f.Hidden {
+			res = append(res, f)
+		}
+	})
+	return res
+}
+
+// fishFlag returns the options of a fish complete command that describe f.
+func fishFlag(f *pflag.Flag) string {
+	res := " -l " + f.Name
+	if f.Shorthand != "" {
+		res += " -s " + f.Shorthand
+	}
+	// flags that aren't booleans take a value
+	if f.NoOptDefVal == "" {
+		res += " -r"
+	}
+	return res + " -d " + fishQuote(f.Usage)
+}
+
+// fishQuote returns s as a single-quoted fish string.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
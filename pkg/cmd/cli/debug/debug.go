@@ -0,0 +1,349 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/client"
+	"github.com/heptio/ark/pkg/cmd"
+	"github.com/heptio/ark/pkg/cmd/server"
+	"github.com/heptio/ark/pkg/cmd/util/downloadrequest"
+	clientset "github.com/heptio/ark/pkg/generated/clientset/versioned"
+	"github.com/heptio/ark/pkg/util/encode"
+)
+
+// serverPodSelector selects the Ark server's pods, as labeled in the example deployments.
+const serverPodSelector = "component=ark"
+
+func NewCommand(f client.Factory) *cobra.Command {
+	o := NewOptions()
+
+	c := &cobra.Command{
+		Use:   "debug",
+		Short: "Collect a diagnostic bundle for troubleshooting Ark",
+		Long: `Collect a diagnostic bundle for troubleshooting Ark, such as for a support ticket.
+
+The bundle is a gzipped tarball containing the Backup, Restore, and Schedule API
+objects, the Ark server's effective Config and the garbage-collection settings its
+GC controller runs with, and the recent logs of the Ark server's pods. If --backup
+is specified, only that backup, its restores, and its schedule are collected, along
+with the backup's log.
+
+Secrets and signed URLs are redacted from everything before it's written to the
+bundle. Anything that can't be collected is listed in the bundle's errors.txt.`,
+		Example: `  # collect a diagnostic bundle for the whole Ark installation
+  ark debug
+
+  # collect a diagnostic bundle for backup "backup-1" to backup-1-debug.tar.gz
+  ark debug --backup backup-1 --output-file backup-1-debug.tar.gz`,
+		Args: cobra.NoArgs,
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Complete())
+			cmd.CheckError(o.Run(f))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+
+	return c
+}
+
+type Options struct {
+	Backup         string
+	OutputFile     string
+	ServerLogLines int64
+	Timeout        time.Duration
+}
+
+func NewOptions() *Options {
+	return &Options{
+		ServerLogLines: 1000,
+		Timeout:        time.Minute,
+	}
+}
+
+func (o *Options) BindFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.Backup, "backup", o.Backup, "only collect the API objects related to this backup, and its log")
+	flags.StringVarP(&o.OutputFile, "output-file", "o", o.OutputFile, "file to write the bundle to. Defaults to ark-debug-<TIMESTAMP>.tar.gz in the current directory")
+	flags.Int64Var(&o.ServerLogLines, "server-log-lines", o.ServerLogLines, "number of recent lines of each Ark server pod's log to collect")
+	flags.DurationVar(&o.Timeout, "timeout", o.Timeout, "maximum time to wait to download the backup's log")
+}
+
+func (o *Options) Complete() error {
+	if o.OutputFile == "" {
+		o.OutputFile = fmt.Sprintf("ark-debug-%s.tar.gz", time.Now().Format("20060102150405"))
+	}
+
+	return nil
+}
+
+func (o *Options) Run(f client.Factory) error {
+	arkClient, err := f.Client()
+	if err != nil {
+		return err
+	}
+
+	kubeClient, err := f.KubeClient()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(o.OutputFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	bundle := newBundle(file)
+	o.collect(bundle, arkClient, kubeClient, f.Namespace())
+
+	if err := bundle.close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Diagnostic bundle written to %s\n", o.OutputFile)
+	return nil
+}
+
+// collect adds everything to the bundle, recording what can't be collected rather than
+// stopping, since a partial bundle is still useful for troubleshooting.
+func (o *Options) collect(bundle *bundle, arkClient clientset.Interface, kubeClient kubernetes.Interface, namespace string) {
+	ark := arkClient.ArkV1()
+
+	if config, err := ark.Configs(namespace).Get("default", metav1.GetOptions{}); err != nil {
+		bundle.addError("config", err)
+	} else {
+		effective := server.EffectiveConfig(config)
+		bundle.addObject("config.yaml", effective)
+		bundle.addYAML("gc-config.yaml", gcConfigFor(effective))
+	}
+
+	backups, err := ark.Backups(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		bundle.addError("backups", err)
+		backups = &v1.BackupList{}
+	}
+
+	restores, err := ark.Restores(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		bundle.addError("restores", err)
+		restores = &v1.RestoreList{}
+	}
+
+	schedules, err := ark.Schedules(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		bundle.addError("schedules", err)
+		schedules = &v1.ScheduleList{}
+	}
+
+	if o.Backup != "" {
+		backups, restores, schedules = relatedTo(o.Backup, backups, restores, schedules)
+		if len(backups.Items) == 0 {
+			bundle.addError("backups", errors.Errorf("backup %q not found", o.Backup))
+		}
+
+		var buf bytes.Buffer
+		if err := downloadrequest.Stream(ark, namespace, o.Backup, v1.DownloadTargetKindBackupLog, &buf, o.Timeout); err != nil {
+			bundle.addError("backup log", err)
+		} else {
+			bundle.addFile(filepath.Join("logs", "backup-"+o.Backup+".log"), buf.Bytes())
+		}
+	}
+
+	bundle.addObject("backups.yaml", backups)
+	bundle.addObject("restores.yaml", restores)
+	bundle.addObject("schedules.yaml", schedules)
+
+	pods, err := kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: serverPodSelector})
+	if err != nil {
+		bundle.addError("server pods", err)
+		return
+	}
+
+	for _, pod := range pods.Items {
+		logs, err := kubeClient.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{TailLines: &o.ServerLogLines}).DoRaw()
+		if err != nil {
+			bundle.addError("logs of server pod "+pod.Name, err)
+			continue
+		}
+		bundle.addFile(filepath.Join("logs", pod.Name+".log"), logs)
+	}
+}
+
+// relatedTo returns the given backup, the restores from it, and the schedule that created it.
+func relatedTo(backupName string, backups *v1.BackupList, restores *v1.RestoreList, schedules *v1.ScheduleList) (*v1.BackupList, *v1.RestoreList, *v1.ScheduleList) {
+	var (
+		relatedBackups   = &v1.BackupList{}
+		relatedRestores  = &v1.RestoreList{}
+		relatedSchedules = &v1.ScheduleList{}
+		scheduleName     string
+	)
+
+	for _, backup := range backups.Items {
+		if backup.Name == backupName {
+			relatedBackups.Items = append(relatedBackups.Items, backup)
+			scheduleName = backup.Labels[v1.ScheduleNameLabel]
+		}
+	}
+
+	for _, restore := range restores.Items {
+		if restore.Spec.BackupName == backupName {
+			relatedRestores.Items = append(relatedRestores.Items, restore)
+		}
+	}
+
+	for _, schedule := range schedules.Items {
+		if scheduleName != "" && schedule.Name == scheduleName {
+			relatedSchedules.Items = append(relatedSchedules.Items, schedule)
+		}
+	}
+
+	return relatedBackups, relatedRestores, relatedSchedules
+}
+
+// gcConfig is the configuration the Ark server's GC controller runs with.
+type gcConfig struct {
+	Enabled                     bool                  `json:"enabled"`
+	GCSyncPeriod                metav1.Duration       `json:"gcSyncPeriod"`
+	GCKeepLastScheduledBackup   bool                  `json:"gcKeepLastScheduledBackup"`
+	GCMinRetention              metav1.Duration       `json:"gcMinRetention"`
+	GCFailedBackupTTL           metav1.Duration       `json:"gcFailedBackupTTL"`
+	GCSnapshotsMissingBackupTTL metav1.Duration       `json:"gcSnapshotsMissingBackupTTL"`
+	GCMaintenanceWindow         *v1.MaintenanceWindow `json:"gcMaintenanceWindow,omitempty"`
+	GCMissingBackupContents     bool                  `json:"gcMissingBackupContents"`
+	GCRecycleBinRetention       metav1.Duration       `json:"gcRecycleBinRetention"`
+}
+
+func gcConfigFor(config *v1.Config) gcConfig {
+	return gcConfig{
+		// the GC controller doesn't run in restore-only mode
+		Enabled:                     !config.RestoreOnlyMode,
+		GCSyncPeriod:                config.GCSyncPeriod,
+		GCKeepLastScheduledBackup:   config.GCKeepLastScheduledBackup,
+		GCMinRetention:              config.GCMinRetention,
+		GCFailedBackupTTL:           config.GCFailedBackupTTL,
+		GCSnapshotsMissingBackupTTL: config.GCSnapshotsMissingBackupTTL,
+		GCMaintenanceWindow:         config.GCMaintenanceWindow,
+		GCMissingBackupContents:     config.GCMissingBackupContents,
+		GCRecycleBinRetention:       config.GCRecycleBinRetention,
+	}
+}
+
+// bundle writes redacted files to a gzipped tarball.
+type bundle struct {
+	gzw    *gzip.Writer
+	tw     *tar.Writer
+	errs   []string
+	err    error
+	now    time.Time
+	prefix string
+}
+
+func newBundle(w io.Writer) *bundle {
+	gzw := gzip.NewWriter(w)
+	now := time.Now()
+
+	return &bundle{
+		gzw:    gzw,
+		tw:     tar.NewWriter(gzw),
+		now:    now,
+		prefix: "ark-debug-" + now.Format("20060102150405"),
+	}
+}
+
+// addFile writes data, redacted, to the bundle at path. Once a write fails, the bundle
+// can't be written to anymore, so all later writes are skipped and close returns the error.
+func (b *bundle) addFile(path string, data []byte) {
+	if b.err != nil {
+		return
+	}
+
+	data = redact(data)
+
+	hdr := &tar.Header{
+		Name:     filepath.Join(b.prefix, path),
+		Size:     int64(len(data)),
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		ModTime:  b.now,
+	}
+
+	if err := b.tw.WriteHeader(hdr); err != nil {
+		b.err = errors.WithStack(err)
+		return
+	}
+	if _, err := b.tw.Write(data); err != nil {
+		b.err = errors.WithStack(err)
+	}
+}
+
+func (b *bundle) addObject(path string, obj runtime.Object) {
+	data, err := encode.Encode(obj, "yaml")
+	if err != nil {
+		b.addError(path, err)
+		return
+	}
+	b.addFile(path, data)
+}
+
+func (b *bundle) addYAML(path string, obj interface{}) {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		b.addError(path, errors.WithStack(err))
+		return
+	}
+	b.addFile(path, data)
+}
+
+// addError records that what couldn't be collected and why, to be written to the bundle's errors.txt.
+func (b *bundle) addError(what string, err error) {
+	b.errs = append(b.errs, fmt.Sprintf("%s: %v", what, err))
+}
+
+func (b *bundle) close() error {
+	if len(b.errs) > 0 {
+		b.addFile("errors.txt", []byte(strings.Join(b.errs, "\n")+"\n"))
+	}
+
+	if b.err != nil {
+		return b.err
+	}
+	if err := b.tw.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(b.gzw.Close())
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"regexp"
+)
+
+const redacted = "REDACTED"
+
+var (
+	// urlQueryRegexp matches URLs with query strings, which is where signed URLs carry their
+	// credentials.
+	urlQueryRegexp = regexp.MustCompile(`(https?://[^\s"'?]+)\?[^\s"']+`)
+
+	// secretValueRegexp matches the values of keys that look like they name secrets, in JSON,
+	// YAML, and logfmt.
+	secretValueRegexp = regexp.MustCompile(`(?i)([\w.-]*(?:password|passwd|secret|token|credential|access[_-]?key|api[_-]?key)[\w.-]*["']?\s*[:=]\s*["']?)([^\s"',}]+)`)
+)
+
+// redact replaces the query strings of URLs and the values of keys that look like they name
+// secrets in data. Values that are booleans or null are kept, since they can't be secrets and
+// settings such as includeServiceAccountTokens are useful for troubleshooting.
+func redact(data []byte) []byte {
+	data = urlQueryRegexp.ReplaceAll(data, []byte("${1}?"+redacted))
+
+	return secretValueRegexp.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := secretValueRegexp.FindSubmatch(match)
+		switch string(groups[2]) {
+		case "true", "false", "null":
+			return match
+		}
+		return append(append([]byte{}, groups[1]...), redacted...)
+	})
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "signed URL's query string is redacted",
+			input:    `downloadURL: https://bucket.s3.amazonaws.com/backup-1/backup-1-logs.gz?X-Amz-Signature=abc&X-Amz-Credential=def`,
+			expected: `downloadURL: https://bucket.s3.amazonaws.com/backup-1/backup-1-logs.gz?REDACTED`,
+		},
+		{
+			name:     "URL without a query string is kept",
+			input:    `s3Url: https://minio.example.com:9000`,
+			expected: `s3Url: https://minio.example.com:9000`,
+		},
+		{
+			name:     "YAML secret value is redacted",
+			input:    "config:\n  secretAccessKey: abc123\n  region: us-east-1\n",
+			expected: "config:\n  secretAccessKey: REDACTED\n  region: us-east-1\n",
+		},
+		{
+			name:     "JSON secret value is redacted",
+			input:    `{"password": "hunter2", "name": "foo"}`,
+			expected: `{"password": "REDACTED", "name": "foo"}`,
+		},
+		{
+			name:     "logfmt secret value is redacted",
+			input:    `level=info msg="connecting" apiKey=abc123 backup=heptio-ark/backup-1`,
+			expected: `level=info msg="connecting" apiKey=REDACTED backup=heptio-ark/backup-1`,
+		},
+		{
+			name:     "boolean values are kept",
+			input:    `includeServiceAccountTokens: false`,
+			expected: `includeServiceAccountTokens: false`,
+		},
+		{
+			name:     "unrelated values are kept",
+			input:    `phase: Completed`,
+			expected: `phase: Completed`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, string(redact([]byte(test.input))))
+		})
+	}
+}
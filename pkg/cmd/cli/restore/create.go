@@ -17,23 +17,41 @@ limitations under the License.
 package restore
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cmd"
+	"github.com/heptio/ark/pkg/cmd/util/downloadrequest"
 	"github.com/heptio/ark/pkg/cmd/util/flag"
 	"github.com/heptio/ark/pkg/cmd/util/output"
+	arkdiscovery "github.com/heptio/ark/pkg/discovery"
 	arkclient "github.com/heptio/ark/pkg/generated/clientset/versioned"
+	"github.com/heptio/ark/pkg/util/collections"
 )
 
+// summaryDownloadTimeout is the maximum time to wait for the backup's contents to
+// become available for download when summarizing them.
+const summaryDownloadTimeout = time.Minute
+
 func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 	o := NewCreateOptions()
 
@@ -44,7 +62,13 @@ func NewCreateCommand(f client.Factory, use string) *cobra.Command {
   ark restore create restore-1 --from-backup backup-1
 
   # create a restore with a default name ("backup-1-<timestamp>") from backup "backup-1"
-  ark restore create --from-backup backup-1`,
+  ark restore create --from-backup backup-1
+
+  # create a restore from backup "backup-1" without being prompted when it targets existing namespaces
+  ark restore create --from-backup backup-1 --confirm
+
+  # create a restore from backup "backup-1" and print only its name, e.g. for use in scripts
+  ark restore create --from-backup backup-1 --confirm --output name`,
 		Args: cobra.MaximumNArgs(1),
 		Run: func(c *cobra.Command, args []string) {
 			cmd.CheckError(o.Complete(args, f))
@@ -61,28 +85,68 @@ func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 }
 
 type CreateOptions struct {
-	BackupName              string
-	RestoreName             string
-	RestoreVolumes          flag.OptionalBool
-	Labels                  flag.Map
-	IncludeNamespaces       flag.StringArray
-	ExcludeNamespaces       flag.StringArray
-	IncludeResources        flag.StringArray
-	ExcludeResources        flag.StringArray
-	NamespaceMappings       flag.Map
-	Selector                flag.LabelSelector
-	IncludeClusterResources flag.OptionalBool
-
-	client arkclient.Interface
+	BackupName               string
+	RestoreName              string
+	RestoreVolumes           flag.OptionalBool
+	Labels                   flag.Map
+	IncludeNamespaces        flag.StringArray
+	ExcludeNamespaces        flag.StringArray
+	IncludeResources         flag.StringArray
+	ExcludeResources         flag.StringArray
+	NamespaceMappings        flag.Map
+	ImageRegistryMappings    flag.Map
+	ResourceRenames          flag.Map
+	MergeStrategies          flag.Map
+	ApplyMethod              *flag.Enum
+	ApplyConflictPolicy      *flag.Enum
+	FieldManager             string
+	ExistingNamespacePolicy  *flag.Enum
+	MissingCRDPolicy         *flag.Enum
+	ResourceModifiersFile    string
+	Selector                 flag.LabelSelector
+	NamespaceSelector        flag.LabelSelector
+	IncludeClusterResources  flag.OptionalBool
+	RestoreWebhooksLast      flag.OptionalBool
+	LabelRestoredItems       flag.OptionalBool
+	AddLabels                flag.Map
+	AddAnnotations           flag.Map
+	Confirm                  bool
+	Force                    bool
+	ScaleToZero              bool
+	ContainerResourcesFactor float64
+	Verify                   bool
+	Prune                    bool
+	PreserveUIDs             bool
+	SkipLiveOwned            bool
+	DefaultStorageClass      string
+	LatestRevisionsOnly      bool
+	BatchSize                int
+
+	client            arkclient.Interface
+	kubeClient        kubernetes.Interface
+	dynamicFactory    client.DynamicFactory
+	resourceModifiers []api.ResourceModifier
 }
 
 func NewCreateOptions() *CreateOptions {
 	return &CreateOptions{
-		Labels:                  flag.NewMap(),
-		IncludeNamespaces:       flag.NewStringArray("*"),
-		NamespaceMappings:       flag.NewMap().WithEntryDelimiter(",").WithKeyValueDelimiter(":"),
-		RestoreVolumes:          flag.NewOptionalBool(nil),
-		IncludeClusterResources: flag.NewOptionalBool(nil),
+		Labels:                   flag.NewMap(),
+		IncludeNamespaces:        flag.NewStringArray("*"),
+		NamespaceMappings:        flag.NewMap().WithEntryDelimiter(",").WithKeyValueDelimiter(":"),
+		ImageRegistryMappings:    flag.NewMap(),
+		ResourceRenames:          flag.NewMap(),
+		MergeStrategies:          flag.NewMap(),
+		AddLabels:                flag.NewMap(),
+		AddAnnotations:           flag.NewMap(),
+		ApplyMethod:              flag.NewEnum(string(api.RestoreApplyMethodCreate), string(api.RestoreApplyMethodCreate), string(api.RestoreApplyMethodServerSideApply)),
+		ApplyConflictPolicy:      flag.NewEnum(string(api.ApplyConflictPolicySkip), string(api.ApplyConflictPolicySkip), string(api.ApplyConflictPolicyForce)),
+		ExistingNamespacePolicy:  flag.NewEnum(string(api.ExistingNamespacePolicyLeave), string(api.ExistingNamespacePolicyLeave), string(api.ExistingNamespacePolicyMerge)),
+		MissingCRDPolicy:         flag.NewEnum(string(api.MissingCRDPolicySkip), string(api.MissingCRDPolicySkip), string(api.MissingCRDPolicyWarn), string(api.MissingCRDPolicyFail)),
+		RestoreVolumes:           flag.NewOptionalBool(nil),
+		IncludeClusterResources:  flag.NewOptionalBool(nil),
+		RestoreWebhooksLast:      flag.NewOptionalBool(nil),
+		LabelRestoredItems:       flag.NewOptionalBool(nil),
+		ContainerResourcesFactor: 1,
 	}
 }
 
@@ -91,10 +155,18 @@ func (o *CreateOptions) BindFlags(flags *pflag.FlagSet) {
 	flags.Var(&o.IncludeNamespaces, "include-namespaces", "namespaces to include in the restore (use '*' for all namespaces)")
 	flags.Var(&o.ExcludeNamespaces, "exclude-namespaces", "namespaces to exclude from the restore")
 	flags.Var(&o.NamespaceMappings, "namespace-mappings", "namespace mappings from name in the backup to desired restored name in the form src1:dst1,src2:dst2,...")
+	flags.Var(&o.MergeStrategies, "merge-strategies", fmt.Sprintf("strategies for merging restored configmaps and secrets into existing ones, in the form configmaps=%s,secrets=%s", api.MergeStrategyRestoredWins, api.MergeStrategyExistingWins))
+	flags.Var(o.ApplyMethod, "apply-method", fmt.Sprintf("how restored items are written to the cluster. Valid values are %s (create items, leaving existing ones unchanged) and %s (server-side apply, falling back to create for resources that don't support it).", api.RestoreApplyMethodCreate, api.RestoreApplyMethodServerSideApply))
+	flags.Var(o.ApplyConflictPolicy, "apply-conflict-policy", fmt.Sprintf("what to do with items whose server-side apply conflicts with fields managed by another field manager. Valid values are %s (don't restore them, and warn) and %s (take ownership of the conflicting fields).", api.ApplyConflictPolicySkip, api.ApplyConflictPolicyForce))
+	flags.StringVar(&o.FieldManager, "field-manager", o.FieldManager, fmt.Sprintf("field manager to server-side apply restored items as, such as a GitOps tool's, so the tool adopts them (default ark-restore); requires --apply-method %s", api.RestoreApplyMethodServerSideApply))
+	flags.Var(o.ExistingNamespacePolicy, "existing-namespace-policy", fmt.Sprintf("what to do with the labels and annotations of restored namespaces that already exist. Valid values are %s (leave the namespaces as they are) and %s (add the backed-up labels and annotations the namespaces don't have).", api.ExistingNamespacePolicyLeave, api.ExistingNamespacePolicyMerge))
+	flags.Var(o.MissingCRDPolicy, "missing-crd-policy", fmt.Sprintf("what to do with backed-up items of resources the cluster doesn't have, such as custom resources whose CustomResourceDefinition isn't installed. Valid values are %s (skip them), %s (skip them, and warn) and %s (don't restore anything, and report errors).", api.MissingCRDPolicySkip, api.MissingCRDPolicyWarn, api.MissingCRDPolicyFail))
+	flags.StringVar(&o.ResourceModifiersFile, "resource-modifiers-file", "", "path to a YAML or JSON file containing a list of resource modifiers, i.e. JSON patches to apply to matching items before they're restored")
 	flags.Var(&o.Labels, "labels", "labels to apply to the restore")
 	flags.Var(&o.IncludeResources, "include-resources", "resources to include in the restore, formatted as resource.group, such as storageclasses.storage.k8s.io (use '*' for all resources)")
 	flags.Var(&o.ExcludeResources, "exclude-resources", "resources to exclude from the restore, formatted as resource.group, such as storageclasses.storage.k8s.io")
 	flags.VarP(&o.Selector, "selector", "l", "only restore resources matching this label selector")
+	flags.Var(&o.NamespaceSelector, "namespace-selector", "only restore namespaces whose labels, as they were backed up, match this label selector")
 	f := flags.VarPF(&o.RestoreVolumes, "restore-volumes", "", "whether to restore volumes from snapshots")
 	// this allows the user to just specify "--restore-volumes" as shorthand for "--restore-volumes=true"
 	// like a normal bool flag
@@ -102,6 +174,42 @@ func (o *CreateOptions) BindFlags(flags *pflag.FlagSet) {
 
 	f = flags.VarPF(&o.IncludeClusterResources, "include-cluster-resources", "", "include cluster-scoped resources in the restore")
 	f.NoOptDefVal = "true"
+
+	f = flags.VarPF(&o.RestoreWebhooksLast, "restore-webhooks-last", "", "restore APIServices and webhook configurations after all other resources, so the workloads backing them exist first (default true)")
+	f.NoOptDefVal = "true"
+
+	f = flags.VarPF(&o.LabelRestoredItems, "label-restored-items", "", fmt.Sprintf("label restored items with %s=<RESTORE NAME> and %s=<BACKUP NAME> (default true)", api.RestoreLabelKey, api.RestoredFromBackupLabel))
+	f.NoOptDefVal = "true"
+
+	flags.Var(&o.AddLabels, "add-labels", "labels to add to restored items, such as a GitOps tool's tracking labels, in the form key1=value1,key2=value2,...")
+	flags.Var(&o.AddAnnotations, "add-annotations", "annotations to add to restored items, such as a GitOps tool's tracking annotations, in the form key1=value1,key2=value2,...")
+	flags.BoolVar(&o.ScaleToZero, "scale-to-zero", o.ScaleToZero, "scale restored deployments and statefulsets to zero replicas and suspend restored cronjobs, recording the original values in annotations")
+	flags.Float64Var(&o.ContainerResourcesFactor, "container-resources-factor", o.ContainerResourcesFactor, "multiply the resource requests and limits of restored containers by this factor, between 0 and 1 (0 removes them), recording the original values in an annotation")
+	flags.Var(&o.ImageRegistryMappings, "image-registry-mappings", "registry prefixes of restored containers' images, and the prefixes that replace them, in the form src1=dst1,src2=dst2,..., recording the original images in an annotation")
+	flags.Var(&o.ResourceRenames, "resource-renames", "items to restore with new names, and their new names, in the form resource1/name1=new-name1,resource2/name2=new-name2,..., recording the original names in an annotation")
+	flags.StringVar(&o.DefaultStorageClass, "default-storage-class", o.DefaultStorageClass, "storage class to assign to restored persistent volume claims that don't have one, instead of leaving them to the cluster's default, recording it in an annotation")
+	flags.IntVar(&o.BatchSize, "batch-size", o.BatchSize, "number of items to restore between checkpoints of the restore's progress; a restore that's interrupted resumes from its last checkpoint (0 disables checkpoints)")
+	flags.BoolVar(&o.LatestRevisionsOnly, "latest-revisions-only", o.LatestRevisionsOnly, "only restore the latest revision of versioned resources, such as the release secrets of each Helm release, skipping older revisions")
+	flags.BoolVar(&o.Verify, "verify", o.Verify, "after restoring, read each restored item back from the cluster and add a warning for each one that differs from the backup")
+	flags.BoolVar(&o.Prune, "prune", o.Prune, "after restoring, delete the items in the namespaces restored into that an earlier restore created but that aren't in the backup, so the namespaces match it; nothing is deleted if the restore has errors")
+	flags.BoolVar(&o.PreserveUIDs, "preserve-uids", o.PreserveUIDs, "create restored items with the UIDs they were backed up with, where the cluster allows it, recording the items restored with new UIDs in the restore's status")
+	flags.BoolVar(&o.SkipLiveOwned, "skip-live-owned", o.SkipLiveOwned, "skip namespaced items whose owners already exist in the namespaces they're restored into, such as the replica sets of a live deployment, so the owners' controllers manage them; skipped items are reported as warnings")
+	flags.BoolVar(&o.Force, "force", o.Force, "restore the backup even if it was taken from a different cluster than the one the Ark server is configured for")
+	flags.BoolVar(&o.Confirm, "confirm", o.Confirm, "skip the summary of the backup's contents and the confirmation prompt shown when the restore targets namespaces that already contain resources")
+}
+
+// mergeStrategies converts the --merge-strategies flag into the restore's merge strategies.
+func (o *CreateOptions) mergeStrategies() map[string]api.MergeStrategy {
+	if len(o.MergeStrategies.Data()) == 0 {
+		return nil
+	}
+
+	strategies := make(map[string]api.MergeStrategy)
+	for resource, strategy := range o.MergeStrategies.Data() {
+		strategies[resource] = api.MergeStrategy(strategy)
+	}
+
+	return strategies
 }
 
 func (o *CreateOptions) Validate(c *cobra.Command, args []string, f client.Factory) error {
@@ -113,6 +221,15 @@ func (o *CreateOptions) Validate(c *cobra.Command, args []string, f client.Facto
 		return err
 	}
 
+	// the summary and confirmation prompt would be mixed in with the name
+	if output.IsNameOutput(c) && !o.Confirm {
+		return errors.New("--confirm is required with --output name")
+	}
+
+	if o.ContainerResourcesFactor < 0 || o.ContainerResourcesFactor > 1 {
+		return errors.New("--container-resources-factor must be between 0 and 1")
+	}
+
 	if o.client == nil {
 		// This should never happen
 		return errors.New("Ark client is not set; unable to proceed")
@@ -138,6 +255,28 @@ func (o *CreateOptions) Complete(args []string, f client.Factory) error {
 	}
 	o.client = client
 
+	kubeClient, err := f.KubeClient()
+	if err != nil {
+		return err
+	}
+	o.kubeClient = kubeClient
+
+	dynamicFactory, err := f.DynamicFactory()
+	if err != nil {
+		return err
+	}
+	o.dynamicFactory = dynamicFactory
+
+	if o.ResourceModifiersFile != "" {
+		data, err := ioutil.ReadFile(o.ResourceModifiersFile)
+		if err != nil {
+			return errors.Wrapf(err, "error reading resource modifiers file")
+		}
+		if err := yaml.Unmarshal(data, &o.resourceModifiers); err != nil {
+			return errors.Wrapf(err, "error decoding resource modifiers file")
+		}
+	}
+
 	return nil
 }
 
@@ -163,11 +302,47 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 			LabelSelector:           o.Selector.LabelSelector,
 			RestorePVs:              o.RestoreVolumes.Value,
 			IncludeClusterResources: o.IncludeClusterResources.Value,
+			MergeStrategies:         o.mergeStrategies(),
+			ApplyMethod:             api.RestoreApplyMethod(o.ApplyMethod.String()),
+			ApplyConflictPolicy:     api.ApplyConflictPolicy(o.ApplyConflictPolicy.String()),
+			FieldManager:            o.FieldManager,
+			ExistingNamespacePolicy: api.ExistingNamespacePolicy(o.ExistingNamespacePolicy.String()),
+			MissingCRDPolicy:        api.MissingCRDPolicy(o.MissingCRDPolicy.String()),
+			ResourceModifiers:       o.resourceModifiers,
+			AllowClusterMismatch:    o.Force,
+			RestoreWebhooksLast:     o.RestoreWebhooksLast.Value,
+			LabelRestoredItems:      o.LabelRestoredItems.Value,
+			AddedLabels:             o.AddLabels.Data(),
+			AddedAnnotations:        o.AddAnnotations.Data(),
+			ScaleToZero:             o.ScaleToZero,
+			Verify:                  o.Verify,
+			Prune:                   o.Prune,
+			PreserveUIDs:            o.PreserveUIDs,
+			SkipLiveOwnedItems:      o.SkipLiveOwned,
+			DefaultStorageClass:     o.DefaultStorageClass,
+			ImageRegistryMapping:    o.ImageRegistryMappings.Data(),
+			ResourceRenames:         o.ResourceRenames.Data(),
+			NamespaceSelector:       o.NamespaceSelector.LabelSelector,
+			LatestRevisionsOnly:     o.LatestRevisionsOnly,
+			BatchSize:               o.BatchSize,
 		},
 	}
 
-	if printed, err := output.PrintWithFormat(c, restore); printed || err != nil {
-		return err
+	// the default factor of 1 leaves resources unchanged, so it isn't set on the restore
+	if o.ContainerResourcesFactor != 1 {
+		restore.Spec.ContainerResourcesFactor = &o.ContainerResourcesFactor
+	}
+
+	if !output.IsNameOutput(c) {
+		if printed, err := output.PrintWithFormat(c, restore); printed || err != nil {
+			return err
+		}
+	}
+
+	if !o.Confirm {
+		if err := o.confirmRestore(f.Namespace(), os.Stdin); err != nil {
+			return err
+		}
 	}
 
 	restore, err := o.client.ArkV1().Restores(restore.Namespace).Create(restore)
@@ -175,7 +350,156 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 		return err
 	}
 
+	if output.IsNameOutput(c) {
+		fmt.Println(restore.Name)
+		return nil
+	}
+
 	fmt.Printf("Restore request %q submitted successfully.\n", restore.Name)
 	fmt.Printf("Run `ark restore describe %s` for more details.\n", restore.Name)
 	return nil
 }
+
+// confirmRestore prints a summary of the backup's contents that will be restored. If any of the
+// namespaces being restored into already contain items of the resources being restored, the user
+// is asked to confirm before proceeding.
+func (o *CreateOptions) confirmRestore(namespace string, in io.Reader) error {
+	backup, err := o.client.ArkV1().Backups(namespace).Get(o.BackupName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "error getting backup %s (use --confirm to skip)", o.BackupName)
+	}
+
+	// backups taken before their item counts were recorded have to be downloaded to be summarized
+	var summary *backupContentsSummary
+	if backup.Status.ItemCounts != nil {
+		summary = summarizeItemCounts(backup.Status.ItemCounts)
+	} else {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(downloadrequest.Stream(o.client.ArkV1(), namespace, o.BackupName, api.DownloadTargetKindBackupContents, pw, summaryDownloadTimeout))
+		}()
+
+		summary, err = summarizeBackupContents(pr, backup.Status.CompressionFormat)
+		pr.Close()
+		if err != nil {
+			return errors.WithMessage(err, "error summarizing backup contents (use --confirm to skip)")
+		}
+	}
+
+	discoveryHelper, err := arkdiscovery.NewHelper(o.kubeClient.Discovery(), logrus.New())
+	if err != nil {
+		return errors.WithMessage(err, "error discovering the cluster's resources (use --confirm to skip)")
+	}
+
+	namespaces := collections.NewIncludesExcludes().Includes(o.IncludeNamespaces...).Excludes(o.ExcludeNamespaces...)
+	namespaceMapping := o.NamespaceMappings.Data()
+
+	// the resources restored into each target namespace, which more than one of the backup's
+	// namespaces may be mapped to
+	targetResources := make(map[string]sets.String)
+	var targets []string
+	for _, ns := range summary.includedNamespaces(namespaces) {
+		target := ns
+		if mapped, ok := namespaceMapping[ns]; ok {
+			target = mapped
+		}
+
+		if targetResources[target] == nil {
+			targetResources[target] = sets.NewString()
+			targets = append(targets, target)
+		}
+		for resource := range summary.namespaces[ns] {
+			targetResources[target].Insert(resource)
+		}
+	}
+
+	existing := make(map[string]bool)
+	var existingNames []string
+	for _, target := range targets {
+		containsItems, err := namespaceContainsItems(discoveryHelper, o.dynamicFactory, target, targetResources[target].List())
+		if err != nil {
+			return errors.WithMessage(err, "error checking whether the namespaces being restored into contain resources (use --confirm to skip)")
+		}
+
+		if containsItems {
+			existing[target] = true
+			existingNames = append(existingNames, target)
+		}
+	}
+
+	summary.print(os.Stdout, o.BackupName, namespaces, namespaceMapping, existing)
+
+	if len(existingNames) == 0 {
+		return nil
+	}
+
+	fmt.Printf("The restore targets namespaces that already contain resources (%s); they may be affected.\n", strings.Join(existingNames, ", "))
+	fmt.Print("Do you want to proceed? [y/N]: ")
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return errors.WithStack(err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return errors.New("restore not created; re-run with --confirm to proceed without prompting")
+	}
+}
+
+// namespaceContainsItems returns whether namespace contains any items of resources, which are
+// group-resources, other than those Kubernetes creates in every namespace. Resources the cluster
+// doesn't serve have no items.
+func namespaceContainsItems(discoveryHelper arkdiscovery.Helper, dynamicFactory client.DynamicFactory, namespace string, resources []string) (bool, error) {
+	for _, resource := range resources {
+		gvr, apiResource, err := discoveryHelper.ResourceFor(schema.ParseGroupResource(resource).WithVersion(""))
+		if err != nil {
+			continue
+		}
+
+		dynamicClient, err := dynamicFactory.ClientForGroupVersionResource(gvr.GroupVersion(), apiResource, namespace)
+		if err != nil {
+			return false, err
+		}
+
+		list, err := dynamicClient.List(metav1.ListOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "error listing %s in namespace %s", resource, namespace)
+		}
+
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+
+		for _, item := range items {
+			metadata, err := meta.Accessor(item)
+			if err != nil {
+				return false, errors.WithStack(err)
+			}
+
+			if !createdWithNamespace(gvr.Resource, metadata) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// createdWithNamespace returns whether an item of resource is one Kubernetes creates in every
+// namespace: the default service account and its token, and the cluster's root CA certificate.
+func createdWithNamespace(resource string, metadata metav1.Object) bool {
+	switch resource {
+	case "serviceaccounts":
+		return metadata.GetName() == "default"
+	case "secrets":
+		return metadata.GetAnnotations()["kubernetes.io/service-account.name"] == "default"
+	case "configmaps":
+		return metadata.GetName() == "kube-root-ca.crt"
+	default:
+		return false
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestNamespaceContainsItems(t *testing.T) {
+	tests := []struct {
+		name          string
+		items         map[string][]string
+		listError     error
+		expected      bool
+		expectedError bool
+	}{
+		{
+			name: "no items",
+			items: map[string][]string{
+				"serviceaccounts":  nil,
+				"configmaps":       nil,
+				"deployments.apps": nil,
+			},
+			expected: false,
+		},
+		{
+			name: "only items created with the namespace",
+			items: map[string][]string{
+				"serviceaccounts":  {`{"metadata": {"name": "default"}}`},
+				"configmaps":       {`{"metadata": {"name": "kube-root-ca.crt"}}`},
+				"deployments.apps": nil,
+			},
+			expected: false,
+		},
+		{
+			name: "a service account other than the default",
+			items: map[string][]string{
+				"serviceaccounts":  {`{"metadata": {"name": "default"}}`, `{"metadata": {"name": "app"}}`},
+				"configmaps":       nil,
+				"deployments.apps": nil,
+			},
+			expected: true,
+		},
+		{
+			name: "items of a grouped resource",
+			items: map[string][]string{
+				"serviceaccounts":  nil,
+				"configmaps":       nil,
+				"deployments.apps": {`{"metadata": {"name": "app"}}`},
+			},
+			expected: true,
+		},
+		{
+			name: "error listing items",
+			items: map[string][]string{
+				"serviceaccounts":  nil,
+				"configmaps":       nil,
+				"deployments.apps": nil,
+			},
+			listError:     errors.New("list failed"),
+			expectedError: true,
+		},
+	}
+
+	resources := map[string]schema.GroupVersionResource{
+		"serviceaccounts":  {Version: "v1", Resource: "serviceaccounts"},
+		"configmaps":       {Version: "v1", Resource: "configmaps"},
+		"deployments.apps": {Group: "apps", Version: "v1", Resource: "deployments"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mappings := make(map[schema.GroupVersionResource]schema.GroupVersionResource)
+			dynamicFactory := &arktest.FakeDynamicFactory{}
+			for resource, gvr := range resources {
+				mappings[schema.ParseGroupResource(resource).WithVersion("")] = gvr
+
+				list := &unstructured.UnstructuredList{}
+				for _, item := range test.items[resource] {
+					list.Items = append(list.Items, *unstructuredOrDie(item))
+				}
+
+				dynamicClient := &arktest.FakeDynamicClient{}
+				dynamicClient.On("List", metav1.ListOptions{}).Return(list, test.listError)
+				dynamicFactory.On("ClientForGroupVersionResource", gvr.GroupVersion(), metav1.APIResource{Name: gvr.Resource}, "ns-1").Return(dynamicClient, nil)
+			}
+			discoveryHelper := arktest.NewFakeDiscoveryHelper(false, mappings)
+
+			// resources the cluster doesn't serve are skipped
+			containsItems, err := namespaceContainsItems(discoveryHelper, dynamicFactory, "ns-1", []string{"configmaps", "deployments.apps", "serviceaccounts", "widgets.example.com"})
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, containsItems)
+		})
+	}
+}
+
+func unstructuredOrDie(data string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal([]byte(data), &obj.Object); err != nil {
+		panic(err)
+	}
+	return obj
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/backup"
+	"github.com/heptio/ark/pkg/util/collections"
+	"github.com/heptio/ark/pkg/util/compression"
+)
+
+// backupContentsSummary counts the items in a backup's contents by namespace and resource.
+type backupContentsSummary struct {
+	// namespaces maps each namespace to the number of items of each resource in it.
+	namespaces map[string]map[string]int
+	// clusterResources maps each cluster-scoped resource to its number of items.
+	clusterResources map[string]int
+}
+
+// summarizeBackupContents reads a backup tarball compressed in the compression format named
+// format and counts the items it contains.
+func summarizeBackupContents(r io.Reader, format string) (*backupContentsSummary, error) {
+	codec, err := compression.CodecFor(format)
+	if err != nil {
+		return nil, err
+	}
+
+	cr, err := codec.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer cr.Close()
+
+	summary := &backupContentsSummary{
+		namespaces:       make(map[string]map[string]int),
+		clusterResources: make(map[string]int),
+	}
+
+	tr := tar.NewReader(cr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		path, ok := backup.ParseItemPath(header.Name)
+		if !ok || !path.IsItem() {
+			continue
+		}
+
+		if path.Namespace != "" {
+			if summary.namespaces[path.Namespace] == nil {
+				summary.namespaces[path.Namespace] = make(map[string]int)
+			}
+			summary.namespaces[path.Namespace][path.Resource]++
+		} else {
+			summary.clusterResources[path.Resource]++
+		}
+	}
+
+	return summary, nil
+}
+
+// summarizeItemCounts returns the summary of a backup whose item counts are recorded in its
+// status.
+func summarizeItemCounts(counts *api.BackupItemCounts) *backupContentsSummary {
+	summary := &backupContentsSummary{
+		namespaces:       make(map[string]map[string]int),
+		clusterResources: counts.ClusterResources,
+	}
+	for _, ns := range counts.Namespaces {
+		summary.namespaces[ns.Namespace] = ns.Resources
+	}
+	if summary.clusterResources == nil {
+		summary.clusterResources = make(map[string]int)
+	}
+
+	return summary
+}
+
+// includedNamespaces returns the sorted names of the summarized namespaces that are
+// included by ie.
+func (s *backupContentsSummary) includedNamespaces(ie *collections.IncludesExcludes) []string {
+	var namespaces []string
+	for ns := range s.namespaces {
+		if ie.ShouldInclude(ns) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+
+	return namespaces
+}
+
+// print writes the number of items per included namespace and resource, along with the namespace
+// each one will be restored into and whether that namespace already contains resources.
+func (s *backupContentsSummary) print(w io.Writer, backupName string, ie *collections.IncludesExcludes, namespaceMapping map[string]string, existing map[string]bool) {
+	fmt.Fprintf(w, "Backup %q contains:\n", backupName)
+
+	for _, ns := range s.includedNamespaces(ie) {
+		target := ns
+		if mapped, ok := namespaceMapping[ns]; ok {
+			target = mapped
+		}
+
+		description := fmt.Sprintf("Namespace %s", ns)
+		if target != ns {
+			description += fmt.Sprintf(" (restored into %s)", target)
+		}
+		if existing[target] {
+			description += " [target namespace already contains resources]"
+		}
+		fmt.Fprintf(w, "  %s:\n", description)
+		printCounts(w, s.namespaces[ns])
+	}
+
+	if len(s.clusterResources) > 0 {
+		fmt.Fprintln(w, "  Cluster-scoped resources:")
+		printCounts(w, s.clusterResources)
+	}
+}
+
+func printCounts(w io.Writer, counts map[string]int) {
+	var resources []string
+	for resource := range counts {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	for _, resource := range resources {
+		fmt.Fprintf(w, "    %s: %d\n", resource, counts[resource])
+	}
+}
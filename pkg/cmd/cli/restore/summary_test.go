@@ -0,0 +1,93 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/util/collections"
+	"github.com/heptio/ark/pkg/util/compression"
+)
+
+func TestSummarizeBackupContents(t *testing.T) {
+	for _, format := range compression.Formats() {
+		t.Run(format, func(t *testing.T) {
+			testSummarizeBackupContents(t, format)
+		})
+	}
+}
+
+func testSummarizeBackupContents(t *testing.T, format string) {
+	codec, err := compression.CodecFor(format)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	cw, err := codec.NewWriter(&buf)
+	require.NoError(t, err)
+	tw := tar.NewWriter(cw)
+
+	for _, name := range []string{
+		"resources/pods/namespaces/ns-1/pod-1.json",
+		"resources/pods/namespaces/ns-1/pod-2.json",
+		"resources/configmaps/namespaces/ns-1/cm-1.json",
+		"resources/pods/namespaces/ns-2/pod-1.json",
+		"resources/namespaces/cluster/ns-1.json",
+		"resources/persistentvolumes/cluster/pv-1.json",
+		"metadata/unexpected.json",
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0755, Size: 2}))
+		_, err := tw.Write([]byte("{}"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, cw.Close())
+
+	summary, err := summarizeBackupContents(&buf, format)
+	require.NoError(t, err)
+
+	expectedNamespaces := map[string]map[string]int{
+		"ns-1": {"pods": 2, "configmaps": 1},
+		"ns-2": {"pods": 1},
+	}
+	assert.Equal(t, expectedNamespaces, summary.namespaces)
+	assert.Equal(t, map[string]int{"namespaces": 1, "persistentvolumes": 1}, summary.clusterResources)
+
+	ie := collections.NewIncludesExcludes().Includes("*").Excludes("ns-2")
+	assert.Equal(t, []string{"ns-1"}, summary.includedNamespaces(ie))
+}
+
+func TestSummarizeItemCounts(t *testing.T) {
+	summary := summarizeItemCounts(&api.BackupItemCounts{
+		Namespaces: []api.NamespaceItemCounts{
+			{Namespace: "ns-1", Resources: map[string]int{"pods": 2, "configmaps": 1}},
+			{Namespace: "ns-2", Resources: map[string]int{"pods": 1}},
+		},
+	})
+
+	expectedNamespaces := map[string]map[string]int{
+		"ns-1": {"pods": 2, "configmaps": 1},
+		"ns-2": {"pods": 1},
+	}
+	assert.Equal(t, expectedNamespaces, summary.namespaces)
+	assert.Equal(t, map[string]int{}, summary.clusterResources)
+}
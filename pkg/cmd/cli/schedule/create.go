@@ -18,12 +18,15 @@ package schedule
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/robfig/cron"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/client"
@@ -65,8 +68,9 @@ func NewCreateCommand(f client.Factory, use string) *cobra.Command {
 }
 
 type CreateOptions struct {
-	BackupOptions *backup.CreateOptions
-	Schedule      string
+	BackupOptions  *backup.CreateOptions
+	Schedule       string
+	RetentionClass string
 
 	labelSelector *metav1.LabelSelector
 }
@@ -80,6 +84,7 @@ func NewCreateOptions() *CreateOptions {
 func (o *CreateOptions) BindFlags(flags *pflag.FlagSet) {
 	o.BackupOptions.BindFlags(flags)
 	flags.StringVar(&o.Schedule, "schedule", o.Schedule, "a cron expression specifying a recurring schedule for this backup to run")
+	flags.StringVar(&o.RetentionClass, "retention-class", o.RetentionClass, "the retention class, from the server's gcRetentionClasses, whose TTL the schedule's backups are garbage-collected after instead of --ttl")
 }
 
 func (o *CreateOptions) Validate(c *cobra.Command, args []string) error {
@@ -87,6 +92,17 @@ func (o *CreateOptions) Validate(c *cobra.Command, args []string) error {
 		return errors.New("--schedule is required")
 	}
 
+	// the server validates the schedule too, but it's more helpful to fail here
+	if _, err := cron.ParseStandard(o.Schedule); err != nil {
+		return errors.Wrapf(err, "invalid --schedule %q", o.Schedule)
+	}
+
+	if o.RetentionClass != "" {
+		if errs := validation.IsValidLabelValue(o.RetentionClass); len(errs) > 0 {
+			return errors.Errorf("invalid --retention-class %q: %s", o.RetentionClass, strings.Join(errs, "; "))
+		}
+	}
+
 	return o.BackupOptions.Validate(c, args)
 }
 
@@ -100,6 +116,11 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 		return err
 	}
 
+	excludedFields, err := o.BackupOptions.ExcludedFields()
+	if err != nil {
+		return err
+	}
+
 	schedule := &api.Schedule{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: f.Namespace(),
@@ -107,27 +128,54 @@ func (o *CreateOptions) Run(c *cobra.Command, f client.Factory) error {
 		},
 		Spec: api.ScheduleSpec{
 			Template: api.BackupSpec{
-				IncludedNamespaces: o.BackupOptions.IncludeNamespaces,
-				ExcludedNamespaces: o.BackupOptions.ExcludeNamespaces,
-				IncludedResources:  o.BackupOptions.IncludeResources,
-				ExcludedResources:  o.BackupOptions.ExcludeResources,
-				LabelSelector:      o.BackupOptions.Selector.LabelSelector,
-				SnapshotVolumes:    o.BackupOptions.SnapshotVolumes.Value,
-				TTL:                metav1.Duration{Duration: o.BackupOptions.TTL},
+				IncludedNamespaces:           o.BackupOptions.IncludeNamespaces,
+				ExcludedNamespaces:           o.BackupOptions.ExcludeNamespaces,
+				IncludedResources:            o.BackupOptions.IncludeResources,
+				ExcludedResources:            o.BackupOptions.ExcludeResources,
+				LabelSelector:                o.BackupOptions.Selector.LabelSelector,
+				SnapshotVolumes:              o.BackupOptions.SnapshotVolumes.Value,
+				VolumeSnapshotSelector:       o.BackupOptions.VolumeSnapshotSelector.LabelSelector,
+				VolumeGroupLabel:             o.BackupOptions.VolumeGroupLabel,
+				TTL:                          metav1.Duration{Duration: o.BackupOptions.TTL},
+				ConsistentListing:            o.BackupOptions.ConsistentListing,
+				IncludeTerminatingNamespaces: o.BackupOptions.IncludeTerminatingNamespaces,
+				IncludeServiceAccountTokens:  o.BackupOptions.IncludeServiceAccountTokens,
+				ExcludedSecretAnnotations:    o.BackupOptions.ExcludeSecretAnnotations.Data(),
+				ExcludedSecretOwnerKinds:     o.BackupOptions.ExcludeSecretOwnerKinds,
+				ExcludedOwnerKinds:           o.BackupOptions.ExcludeOwnerKinds,
+				MinItems:                     o.BackupOptions.MinItems,
+				ModifiedSince:                metav1.Duration{Duration: o.BackupOptions.ModifiedSince},
+				IncludeOwnerReferences:       o.BackupOptions.IncludeOwnerReferences,
+				RequireIncludeAnnotation:     o.BackupOptions.RequireIncludeAnnotation,
+				BaseBackup:                   o.BackupOptions.BaseBackup,
+				ExcludedFields:               excludedFields,
+				CompressionFormat:            o.BackupOptions.CompressionFormat,
+				ResourceRequestTimeout:       metav1.Duration{Duration: o.BackupOptions.ResourceRequestTimeout},
 			},
 			Schedule: o.Schedule,
 		},
 	}
 
-	if printed, err := output.PrintWithFormat(c, schedule); printed || err != nil {
-		return err
+	if o.RetentionClass != "" {
+		schedule.Annotations = map[string]string{api.RetentionClassAnnotation: o.RetentionClass}
+	}
+
+	if !output.IsNameOutput(c) {
+		if printed, err := output.PrintWithFormat(c, schedule); printed || err != nil {
+			return err
+		}
 	}
 
-	_, err = arkClient.ArkV1().Schedules(schedule.Namespace).Create(schedule)
+	schedule, err = arkClient.ArkV1().Schedules(schedule.Namespace).Create(schedule)
 	if err != nil {
 		return err
 	}
 
+	if output.IsNameOutput(c) {
+		fmt.Println(schedule.Name)
+		return nil
+	}
+
 	fmt.Printf("Schedule %q created successfully.\n", schedule.Name)
 	return nil
 }
@@ -19,29 +19,140 @@ package schedule
 import (
 	"fmt"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	pkgbackup "github.com/heptio/ark/pkg/backup"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cmd"
+	"github.com/heptio/ark/pkg/cmd/cli/backup"
+	clientset "github.com/heptio/ark/pkg/generated/clientset/versioned"
 )
 
 func NewDeleteCommand(f client.Factory, use string) *cobra.Command {
+	o := &DeleteOptions{}
+
 	c := &cobra.Command{
 		Use:   fmt.Sprintf("%s NAME", use),
 		Short: "Delete a schedule",
+		Long:  "Delete a schedule. With --delete-backups, a request to delete each of the backups it created is also submitted.",
 		Args:  cobra.ExactArgs(1),
 		Run: func(c *cobra.Command, args []string) {
-			arkClient, err := f.Client()
-			cmd.CheckError(err)
+			cmd.CheckError(o.Complete(f, args))
+			cmd.CheckError(o.Run())
+		},
+	}
+
+	o.BindFlags(c.Flags())
 
-			name := args[0]
+	return c
+}
 
-			err = arkClient.ArkV1().Schedules(f.Namespace()).Delete(name, nil)
-			cmd.CheckError(err)
+// DeleteOptions contains parameters for deleting a schedule.
+type DeleteOptions struct {
+	Name          string
+	DeleteBackups bool
+	Confirm       bool
 
-			fmt.Printf("Schedule %q deleted\n", name)
-		},
+	client    clientset.Interface
+	namespace string
+}
+
+// BindFlags binds options for this command to flags.
+func (o *DeleteOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.DeleteBackups, "delete-backups", o.DeleteBackups, fmt.Sprintf("also delete the backups the schedule created, labeled %s=<NAME>", v1.ScheduleNameLabel))
+	flags.BoolVar(&o.Confirm, "confirm", o.Confirm, "confirm deletion of the schedule's backups without prompting")
+}
+
+// Complete fills out the remainder of the parameters based on user input.
+func (o *DeleteOptions) Complete(f client.Factory, args []string) error {
+	o.Name = args[0]
+	o.namespace = f.Namespace()
+
+	client, err := f.Client()
+	if err != nil {
+		return err
 	}
+	o.client = client
 
-	return c
+	return nil
+}
+
+// Run deletes the schedule, and submits requests to delete its backups if --delete-backups
+// is set.
+func (o *DeleteOptions) Run() error {
+	if !o.DeleteBackups {
+		if err := o.client.ArkV1().Schedules(o.namespace).Delete(o.Name, nil); err != nil {
+			return err
+		}
+
+		fmt.Printf("Schedule %q deleted\n", o.Name)
+		return nil
+	}
+
+	if _, err := o.client.ArkV1().Schedules(o.namespace).Get(o.Name, metav1.GetOptions{}); err != nil {
+		return err
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{v1.ScheduleNameLabel: o.Name})
+	backups, err := o.client.ArkV1().Backups(o.namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return errors.Wrap(err, "error listing the schedule's backups")
+	}
+
+	var toDelete []v1.Backup
+	for _, b := range backups.Items {
+		if b.Status.Phase == v1.BackupPhaseDeleting {
+			continue
+		}
+		toDelete = append(toDelete, b)
+	}
+
+	if len(toDelete) > 0 {
+		fmt.Printf("Schedule %q and its %d backups will be deleted:\n", o.Name, len(toDelete))
+		for _, b := range toDelete {
+			fmt.Printf("  %s\n", b.Name)
+		}
+	} else {
+		fmt.Printf("Schedule %q has no backups to delete.\n", o.Name)
+	}
+
+	if len(toDelete) > 0 && !o.Confirm && !backup.GetConfirmation() {
+		// Don't do anything unless we get confirmation
+		return nil
+	}
+
+	// the schedule is deleted first so that it doesn't create any more backups
+	if err := o.client.ArkV1().Schedules(o.namespace).Delete(o.Name, nil); err != nil {
+		return err
+	}
+	fmt.Printf("Schedule %q deleted\n", o.Name)
+
+	var errs []error
+	for _, b := range toDelete {
+		req := pkgbackup.NewDeleteBackupRequest(b.Name, string(b.UID))
+		req.Spec.Reason = fmt.Sprintf("Schedule %s was deleted with its backups", o.Name)
+
+		if _, err := o.client.ArkV1().DeleteBackupRequests(o.namespace).Create(req); err != nil {
+			errs = append(errs, errors.Wrapf(err, "error submitting request to delete backup %q", b.Name))
+			continue
+		}
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Printf("%v\n", err)
+		}
+		return errors.Errorf("%d of the schedule's %d backups couldn't be deleted", len(errs), len(toDelete))
+	}
+
+	if len(toDelete) > 0 {
+		fmt.Printf("Requests to delete the schedule's %d backups submitted successfully.\nEach backup will be fully deleted after all associated data (disk snapshots, backup files, restores) are removed.\n", len(toDelete))
+	}
+	return nil
 }
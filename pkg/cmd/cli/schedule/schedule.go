@@ -34,6 +34,7 @@ func NewCommand(f client.Factory) *cobra.Command {
 		NewGetCommand(f, "get"),
 		NewDescribeCommand(f, "describe"),
 		NewDeleteCommand(f, "delete"),
+		NewTriggerCommand(f, "trigger"),
 	)
 
 	return c
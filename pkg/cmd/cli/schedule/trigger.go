@@ -0,0 +1,137 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	pkgbackup "github.com/heptio/ark/pkg/backup"
+	"github.com/heptio/ark/pkg/client"
+	"github.com/heptio/ark/pkg/cmd"
+	"github.com/heptio/ark/pkg/cmd/util/output"
+	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
+)
+
+func NewTriggerCommand(f client.Factory, use string) *cobra.Command {
+	c := &cobra.Command{
+		Use:   use + " NAME",
+		Short: "Create a backup from a schedule now",
+		Long: `Create a backup from a schedule's template now, labeled with the schedule like the backups it
+creates on its own. The backup has the schedule's TTL, and the schedule's next run isn't affected.
+It's named like the schedule's backups, with the server's backupNameTemplate if it has one. If a
+backup with that name already exists, e.g. because the template's names only change once a day,
+the name gets a random suffix.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(output.ValidateFlags(c))
+			cmd.CheckError(runTrigger(c, f, args[0]))
+		},
+	}
+
+	output.BindFlags(c.Flags())
+	output.ClearOutputFlagDefault(c)
+
+	return c
+}
+
+func runTrigger(c *cobra.Command, f client.Factory, name string) error {
+	arkClient, err := f.Client()
+	if err != nil {
+		return err
+	}
+
+	schedule, err := arkClient.ArkV1().Schedules(f.Namespace()).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	config, err := arkClient.ArkV1().Configs(f.Namespace()).Get("default", metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		config = nil
+	} else if err != nil {
+		return errors.Wrap(err, "error getting the Ark server's Config")
+	}
+
+	// the schedule isn't updated, so its LastBackup, and so its next run, stays the same
+	backup, err := newTriggeredBackup(schedule, config, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !output.IsNameOutput(c) {
+		if printed, err := output.PrintWithFormat(c, backup); printed || err != nil {
+			return err
+		}
+	}
+
+	backup, err = createTriggeredBackup(arkClient.ArkV1(), backup)
+	if err != nil {
+		return err
+	}
+
+	if output.IsNameOutput(c) {
+		fmt.Println(backup.Name)
+		return nil
+	}
+
+	fmt.Printf("Backup request %q submitted successfully.\n", backup.Name)
+	fmt.Printf("Run `ark backup describe %s` for more details.\n", backup.Name)
+	return nil
+}
+
+// newTriggeredBackup returns the backup of schedule triggered at now, named with config's
+// backupNameTemplate, if config isn't nil and has one, like the schedule's own backups.
+func newTriggeredBackup(schedule *api.Schedule, config *api.Config, now time.Time) (*api.Backup, error) {
+	backup := pkgbackup.NewScheduledBackup(schedule, now)
+
+	if config == nil || config.BackupNameTemplate == "" {
+		return backup, nil
+	}
+
+	nameTemplate, err := pkgbackup.NewNameTemplate(config.BackupNameTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if backup.Name, err = nameTemplate.Name(pkgbackup.NewNameTemplateData(schedule.Name, now, config.ClusterID)); err != nil {
+		return nil, err
+	}
+
+	return backup, nil
+}
+
+// createTriggeredBackup creates backup. If a backup with its name already exists, it's
+// created with a name generated from it instead.
+func createTriggeredBackup(client arkv1client.BackupsGetter, backup *api.Backup) (*api.Backup, error) {
+	created, err := client.Backups(backup.Namespace).Create(backup)
+	if !apierrors.IsAlreadyExists(err) {
+		return created, err
+	}
+
+	backup = backup.DeepCopy()
+	backup.GenerateName = backup.Name + "-"
+	backup.Name = ""
+
+	return client.Backups(backup.Namespace).Create(backup)
+}
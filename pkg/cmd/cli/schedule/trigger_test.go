@@ -0,0 +1,79 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	core "k8s.io/client-go/testing"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestNewTriggeredBackup(t *testing.T) {
+	schedule := arktest.NewTestSchedule("ns", "daily").Schedule
+	now := time.Date(2018, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	backup, err := newTriggeredBackup(schedule, nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, "daily-20180601123000", backup.Name)
+	assert.Equal(t, "daily", backup.Labels[api.ScheduleNameLabel])
+
+	config := &api.Config{
+		BackupNameTemplate: `{{.ClusterID}}-{{.Schedule}}-{{.Time.Format "20060102"}}`,
+		ClusterID:          "prod",
+	}
+	backup, err = newTriggeredBackup(schedule, config, now)
+	require.NoError(t, err)
+	assert.Equal(t, "prod-daily-20180601", backup.Name)
+}
+
+func TestCreateTriggeredBackup(t *testing.T) {
+	existing := arktest.NewTestBackup().WithNamespace("ns").WithName("daily-20180601").Backup
+	client := fake.NewSimpleClientset(existing)
+
+	var created []*api.Backup
+	client.PrependReactor("create", "backups", func(action core.Action) (bool, runtime.Object, error) {
+		backup := action.(core.CreateAction).GetObject().(*api.Backup)
+		created = append(created, backup)
+		if backup.Name == existing.Name {
+			return true, nil, apierrors.NewAlreadyExists(api.Resource("backups"), backup.Name)
+		}
+		return true, backup, nil
+	})
+
+	// a backup whose name is taken gets a generated one
+	backup, err := createTriggeredBackup(client.ArkV1(), arktest.NewTestBackup().WithNamespace("ns").WithName("daily-20180601").Backup)
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+	assert.Empty(t, backup.Name)
+	assert.Equal(t, "daily-20180601-", backup.GenerateName)
+
+	created = nil
+	backup, err = createTriggeredBackup(client.ArkV1(), arktest.NewTestBackup().WithNamespace("ns").WithName("daily-20180602").Backup)
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	assert.Equal(t, "daily-20180602", backup.Name)
+}
@@ -18,13 +18,18 @@ package plugin
 
 import (
 	plugin "github.com/hashicorp/go-plugin"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"k8s.io/client-go/kubernetes"
+
 	"github.com/heptio/ark/pkg/backup"
+	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cloudprovider"
 	"github.com/heptio/ark/pkg/cloudprovider/aws"
 	"github.com/heptio/ark/pkg/cloudprovider/azure"
+	"github.com/heptio/ark/pkg/cloudprovider/filesystem"
 	"github.com/heptio/ark/pkg/cloudprovider/gcp"
 	arkplugin "github.com/heptio/ark/pkg/plugin"
 	"github.com/heptio/ark/pkg/restore"
@@ -34,9 +39,10 @@ func NewCommand() *cobra.Command {
 	logger := arkplugin.NewLogger()
 
 	objectStores := map[string]cloudprovider.ObjectStore{
-		"aws":   aws.NewObjectStore(),
-		"gcp":   gcp.NewObjectStore(),
-		"azure": azure.NewObjectStore(),
+		"aws":        aws.NewObjectStore(),
+		"gcp":        gcp.NewObjectStore(),
+		"azure":      azure.NewObjectStore(),
+		"filesystem": filesystem.NewObjectStore(),
 	}
 
 	blockStores := map[string]cloudprovider.BlockStore{
@@ -45,15 +51,21 @@ func NewCommand() *cobra.Command {
 		"azure": azure.NewBlockStore(),
 	}
 
-	backupItemActions := map[string]backup.ItemAction{
-		"pv":  backup.NewBackupPVAction(logger),
-		"pod": backup.NewPodAction(logger),
+	backupItemActions := map[string]func() (backup.ItemAction, error){
+		"pv":           func() (backup.ItemAction, error) { return backup.NewBackupPVAction(logger), nil },
+		"pod":          func() (backup.ItemAction, error) { return backup.NewPodAction(logger), nil },
+		"helm-release": func() (backup.ItemAction, error) { return newHelmReleaseBackupItemAction(logger) },
 	}
 
 	restoreItemActions := map[string]restore.ItemAction{
-		"job": restore.NewJobAction(logger),
-		"pod": restore.NewPodAction(logger),
-		"svc": restore.NewServiceAction(logger),
+		"job":                   restore.NewJobAction(logger),
+		"pod":                   restore.NewPodAction(logger),
+		"svc":                   restore.NewServiceAction(logger),
+		"rolebinding":           restore.NewRoleBindingAction(logger),
+		"scale-to-zero":         restore.NewScaleToZeroAction(logger),
+		"container-resources":   restore.NewContainerResourcesAction(logger),
+		"image-registry":        restore.NewImageRegistryAction(logger),
+		"default-storage-class": restore.NewDefaultStorageClassAction(logger),
 	}
 
 	c := &cobra.Command{
@@ -76,26 +88,39 @@ func NewCommand() *cobra.Command {
 
 			switch kind {
 			case "cloudprovider":
-				objectStore, found := objectStores[name]
-				if !found {
-					logger.Fatalf("Unrecognized plugin name")
+				serveConfig.Plugins = map[string]plugin.Plugin{}
+
+				// not every cloud provider implements both an object store and a block store
+				if objectStore, found := objectStores[name]; found {
+					serveConfig.Plugins[string(arkplugin.PluginKindObjectStore)] = arkplugin.NewObjectStorePlugin(objectStore)
+				}
+				if blockStore, found := blockStores[name]; found {
+					serveConfig.Plugins[string(arkplugin.PluginKindBlockStore)] = arkplugin.NewBlockStorePlugin(blockStore)
 				}
 
-				blockStore, found := blockStores[name]
+				if len(serveConfig.Plugins) == 0 {
+					logger.Fatalf("Unrecognized plugin name")
+				}
+			case arkplugin.PluginKindObjectStore.String():
+				objectStore, found := arkplugin.NewRegisteredObjectStore(name)
 				if !found {
 					logger.Fatalf("Unrecognized plugin name")
 				}
 
 				serveConfig.Plugins = map[string]plugin.Plugin{
-					string(arkplugin.PluginKindObjectStore): arkplugin.NewObjectStorePlugin(objectStore),
-					string(arkplugin.PluginKindBlockStore):  arkplugin.NewBlockStorePlugin(blockStore),
+					kind: arkplugin.NewObjectStorePlugin(objectStore),
 				}
 			case arkplugin.PluginKindBackupItemAction.String():
-				action, found := backupItemActions[name]
+				newAction, found := backupItemActions[name]
 				if !found {
 					logger.Fatalf("Unrecognized plugin name")
 				}
 
+				action, err := newAction()
+				if err != nil {
+					logger.WithError(err).Fatalf("Unable to initialize plugin")
+				}
+
 				serveConfig.Plugins = map[string]plugin.Plugin{
 					kind: arkplugin.NewBackupItemActionPlugin(action),
 				}
@@ -118,3 +143,19 @@ func NewCommand() *cobra.Command {
 
 	return c
 }
+
+// newHelmReleaseBackupItemAction creates a Helm release backup item action with a
+// Kubernetes client for looking up the release's secrets.
+func newHelmReleaseBackupItemAction(logger logrus.FieldLogger) (backup.ItemAction, error) {
+	clientConfig, err := client.Config("", "", "ark-helm-release-action")
+	if err != nil {
+		return nil, err
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return backup.NewHelmReleaseAction(logger, kubeClient.CoreV1()), nil
+}
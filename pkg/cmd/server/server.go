@@ -21,14 +21,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/heptio/ark/pkg/buildinfo"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -36,6 +42,7 @@ import (
 	"k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -45,6 +52,7 @@ import (
 	kcorev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/backup"
@@ -53,22 +61,30 @@ import (
 	"github.com/heptio/ark/pkg/cmd"
 	"github.com/heptio/ark/pkg/cmd/util/flag"
 	"github.com/heptio/ark/pkg/controller"
+	"github.com/heptio/ark/pkg/csi"
 	arkdiscovery "github.com/heptio/ark/pkg/discovery"
 	clientset "github.com/heptio/ark/pkg/generated/clientset/versioned"
 	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
 	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	"github.com/heptio/ark/pkg/metrics"
 	"github.com/heptio/ark/pkg/plugin"
 	"github.com/heptio/ark/pkg/restore"
 	"github.com/heptio/ark/pkg/util/kube"
 	"github.com/heptio/ark/pkg/util/logging"
 	"github.com/heptio/ark/pkg/util/stringslice"
+	"github.com/heptio/ark/pkg/webhook"
 )
 
 func NewCommand() *cobra.Command {
 	var (
 		sortedLogLevels = getSortedLogLevels()
 		logLevelFlag    = flag.NewEnum(logrus.InfoLevel.String(), sortedLogLevels...)
+		logFormatFlag   = flag.NewEnum(string(logging.FormatText), logging.Formats()...)
 		pluginDir       = "/plugins"
+		metricsAddress  = defaultMetricsAddress
+		webhook         = webhookServerConfig{address: defaultWebhookAddress}
+
+		informerResyncPeriod time.Duration
 	)
 
 	var command = &cobra.Command{
@@ -88,7 +104,9 @@ func NewCommand() *cobra.Command {
 			}
 			logrus.Infof("setting log-level to %s", strings.ToUpper(logLevel.String()))
 
-			logger := newLogger(logLevel, &logging.ErrorLocationHook{}, &logging.LogLocationHook{})
+			logFormat := logging.Format(logFormatFlag.String())
+
+			logger := newLogger(logLevel, logFormat, &logging.ErrorLocationHook{}, &logging.LogLocationHook{})
 			logger.Infof("Starting Ark server %s", buildinfo.FormattedGitSHA())
 
 			// NOTE: the namespace flag is bound to ark's persistent flags when the root ark command
@@ -105,7 +123,11 @@ func NewCommand() *cobra.Command {
 			}
 			namespace := getServerNamespace(namespaceFlag)
 
-			s, err := newServer(namespace, fmt.Sprintf("%s-%s", c.Parent().Name(), c.Name()), pluginDir, logger)
+			if informerResyncPeriod < 0 {
+				cmd.CheckError(errors.New("--informer-resync-period must not be negative"))
+			}
+
+			s, err := newServer(namespace, fmt.Sprintf("%s-%s", c.Parent().Name(), c.Name()), pluginDir, metricsAddress, webhook, informerResyncPeriod, logger)
 
 			cmd.CheckError(err)
 
@@ -114,7 +136,13 @@ func NewCommand() *cobra.Command {
 	}
 
 	command.Flags().Var(logLevelFlag, "log-level", fmt.Sprintf("the level at which to log. Valid values are %s.", strings.Join(sortedLogLevels, ", ")))
+	command.Flags().Var(logFormatFlag, "log-format", fmt.Sprintf("the format for log output. Valid values are %s.", strings.Join(logging.Formats(), ", ")))
 	command.Flags().StringVar(&pluginDir, "plugin-dir", pluginDir, "directory containing Ark plugins")
+	command.Flags().StringVar(&metricsAddress, "metrics-address", metricsAddress, "the address to expose prometheus metrics")
+	command.Flags().StringVar(&webhook.address, "webhook-address", webhook.address, "the address to serve admission webhooks on, over TLS")
+	command.Flags().StringVar(&webhook.certFile, "webhook-tls-cert-file", webhook.certFile, "the TLS certificate to serve admission webhooks with. Required if the config's namespaceDeletionBackupTemplate is set.")
+	command.Flags().StringVar(&webhook.keyFile, "webhook-tls-private-key-file", webhook.keyFile, "the private key of --webhook-tls-cert-file")
+	command.Flags().DurationVar(&informerResyncPeriod, "informer-resync-period", informerResyncPeriod, "how often the informers caching Ark API objects redeliver every cached object to the controllers. This is separate from the sync periods in the Ark config. If 0, informers only deliver changes.")
 
 	return command
 }
@@ -133,9 +161,10 @@ func getServerNamespace(namespaceFlag *pflag.Flag) string {
 	return api.DefaultNamespace
 }
 
-func newLogger(level logrus.Level, hooks ...logrus.Hook) *logrus.Logger {
+func newLogger(level logrus.Level, format logging.Format, hooks ...logrus.Hook) *logrus.Logger {
 	logger := logrus.New()
 	logger.Level = level
+	logger.Formatter = logging.Formatter(format)
 
 	for _, hook := range hooks {
 		logger.Hooks.Add(hook)
@@ -170,7 +199,10 @@ type server struct {
 	kubeClient            kubernetes.Interface
 	arkClient             clientset.Interface
 	backupService         cloudprovider.BackupService
+	backupStorageMirrors  []controller.BackupStorageMirror
+	backupStream          *controller.BackupStream
 	snapshotService       cloudprovider.SnapshotService
+	csiSnapshotter        csi.Snapshotter
 	discoveryClient       discovery.DiscoveryInterface
 	clientPool            dynamic.ClientPool
 	sharedInformerFactory informers.SharedInformerFactory
@@ -178,9 +210,19 @@ type server struct {
 	cancelFunc            context.CancelFunc
 	logger                logrus.FieldLogger
 	pluginManager         plugin.Manager
+	metricsAddress        string
+	metrics               *metrics.ServerMetrics
+	webhook               webhookServerConfig
+}
+
+// webhookServerConfig is where and how the server serves admission webhooks.
+type webhookServerConfig struct {
+	address  string
+	certFile string
+	keyFile  string
 }
 
-func newServer(namespace, baseName, pluginDir string, logger *logrus.Logger) (*server, error) {
+func newServer(namespace, baseName, pluginDir, metricsAddress string, webhook webhookServerConfig, informerResyncPeriod time.Duration, logger *logrus.Logger) (*server, error) {
 	clientConfig, err := client.Config("", "", baseName)
 	if err != nil {
 		return nil, err
@@ -210,11 +252,13 @@ func newServer(namespace, baseName, pluginDir string, logger *logrus.Logger) (*s
 		arkClient:             arkClient,
 		discoveryClient:       arkClient.Discovery(),
 		clientPool:            dynamic.NewDynamicClientPool(clientConfig),
-		sharedInformerFactory: informers.NewFilteredSharedInformerFactory(arkClient, 0, namespace, nil),
-		ctx:           ctx,
-		cancelFunc:    cancelFunc,
-		logger:        logger,
-		pluginManager: pluginManager,
+		sharedInformerFactory: informers.NewFilteredSharedInformerFactory(arkClient, informerResyncPeriod, namespace, nil),
+		ctx:                   ctx,
+		cancelFunc:            cancelFunc,
+		logger:                logger,
+		pluginManager:         pluginManager,
+		metricsAddress:        metricsAddress,
+		webhook:               webhook,
 	}
 
 	return s, nil
@@ -236,6 +280,9 @@ func (s *server) run() error {
 	applyConfigDefaults(config, s.logger)
 
 	s.watchConfig(originalConfig)
+	s.handleShutdownSignals()
+
+	s.runMetricsServer()
 
 	if err := s.initBackupService(config); err != nil {
 		return err
@@ -244,6 +291,7 @@ func (s *server) run() error {
 	if err := s.initSnapshotService(config); err != nil {
 		return err
 	}
+	s.initCSISnapshotter(config)
 
 	if err := s.runControllers(config); err != nil {
 		return err
@@ -295,9 +343,37 @@ func (s *server) loadConfig() (*api.Config, error) {
 }
 
 const (
+	defaultMetricsAddress = ":8085"
+	defaultWebhookAddress = ":8443"
+
 	defaultGCSyncPeriod       = 60 * time.Minute
 	defaultBackupSyncPeriod   = 60 * time.Minute
 	defaultScheduleSyncPeriod = time.Minute
+	defaultBackupRetryBackoff = time.Minute
+
+	// defaultGCTransientErrorBackoff keeps the GC controller from retrying
+	// against an API server that's throttling or overloaded in a tight loop.
+	defaultGCTransientErrorBackoff = 30 * time.Second
+
+	// defaultCSISnapshotTimeout is how long to wait for a CSI driver to take a
+	// snapshot before failing to back up its volume.
+	defaultCSISnapshotTimeout = 10 * time.Minute
+
+	// defaultShutdownGracePeriod leaves time for in-progress backups to be marked
+	// as failed within a pod's default termination grace period of 30s.
+	defaultShutdownGracePeriod = 20 * time.Second
+
+	// defaultStaleBackupTimeout is long enough that only backups whose server
+	// stopped running them are marked as failed.
+	defaultStaleBackupTimeout = time.Hour
+
+	// defaultRestoreNamespaceConcurrency keeps restores into many namespaces
+	// from hitting the API server's rate limits when creating them.
+	defaultRestoreNamespaceConcurrency = 10
+
+	// defaultSnapshotDeletionBatchSize keeps bulk deletions of snapshots well
+	// within the request size limits of cloud APIs.
+	defaultSnapshotDeletionBatchSize = 50
 )
 
 var defaultResourcePriorities = []string{
@@ -310,11 +386,32 @@ var defaultResourcePriorities = []string{
 	"limitranges",
 }
 
+var defaultVersionedResources = []string{
+	"secrets",
+	"configmaps",
+	"controllerrevisions.apps",
+}
+
+// EffectiveConfig returns a copy of config with the defaults the server applies to it, as
+// its controllers run with it.
+func EffectiveConfig(config *api.Config) *api.Config {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	effective := config.DeepCopy()
+	applyConfigDefaults(effective, logger)
+	return effective
+}
+
 func applyConfigDefaults(c *api.Config, logger logrus.FieldLogger) {
 	if c.GCSyncPeriod.Duration == 0 {
 		c.GCSyncPeriod.Duration = defaultGCSyncPeriod
 	}
 
+	if c.GCTransientErrorBackoff.Duration == 0 {
+		c.GCTransientErrorBackoff.Duration = defaultGCTransientErrorBackoff
+	}
+
 	if c.BackupSyncPeriod.Duration == 0 {
 		c.BackupSyncPeriod.Duration = defaultBackupSyncPeriod
 	}
@@ -323,12 +420,41 @@ func applyConfigDefaults(c *api.Config, logger logrus.FieldLogger) {
 		c.ScheduleSyncPeriod.Duration = defaultScheduleSyncPeriod
 	}
 
+	if c.BackupRetryBackoff.Duration == 0 {
+		c.BackupRetryBackoff.Duration = defaultBackupRetryBackoff
+	}
+
+	if c.ShutdownGracePeriod.Duration == 0 {
+		c.ShutdownGracePeriod.Duration = defaultShutdownGracePeriod
+	}
+
+	if c.StaleBackupTimeout.Duration == 0 {
+		c.StaleBackupTimeout.Duration = defaultStaleBackupTimeout
+	}
+
 	if len(c.ResourcePriorities) == 0 {
 		c.ResourcePriorities = defaultResourcePriorities
 		logger.WithField("priorities", c.ResourcePriorities).Info("Using default resource priorities")
 	} else {
 		logger.WithField("priorities", c.ResourcePriorities).Info("Using resource priorities from config")
 	}
+
+	if len(c.VersionedResources) == 0 {
+		c.VersionedResources = defaultVersionedResources
+	}
+}
+
+// handleShutdownSignals invokes s.cancelFunc when the server receives SIGINT or SIGTERM,
+// so that the controllers can finish their in-progress work before it exits.
+func (s *server) handleShutdownSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		s.logger.WithField("signal", sig.String()).Info("Received signal, shutting down gracefully")
+		s.cancelFunc()
+	}()
 }
 
 // watchConfig adds an update event handler to the Config shared informer, invoking s.cancelFunc
@@ -364,6 +490,45 @@ func (s *server) watchConfig(config *api.Config) {
 	})
 }
 
+// runMetricsServer registers the server's prometheus metrics and starts serving
+// them on the metrics address in a separate goroutine.
+func (s *server) runMetricsServer() {
+	s.metrics = metrics.NewServerMetrics()
+	s.metrics.RegisterAllMetrics()
+
+	// the controllers' work queues are created after this, so they all report their metrics
+	workqueue.SetProvider(s.metrics.WorkqueueMetricsProvider())
+
+	go func() {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", promhttp.Handler())
+		s.logger.Infof("Starting metric server at address [%s]", s.metricsAddress)
+		if err := http.ListenAndServe(s.metricsAddress, metricsMux); err != nil {
+			s.logger.Fatalf("Failed to start metric server at [%s]: %v", s.metricsAddress, err)
+		}
+	}()
+}
+
+// runWebhookServer starts serving the namespace deletion webhook on the webhook
+// address, over TLS, in a separate goroutine, until ctx is done.
+func (s *server) runWebhookServer(ctx context.Context, namespaceDeletion http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle(webhook.NamespaceDeletionPath, namespaceDeletion)
+	webhookServer := &http.Server{Addr: s.webhook.address, Handler: mux}
+
+	go func() {
+		s.logger.Infof("Starting webhook server at address [%s]", s.webhook.address)
+		if err := webhookServer.ListenAndServeTLS(s.webhook.certFile, s.webhook.keyFile); err != nil && err != http.ErrServerClosed {
+			s.logger.Fatalf("Failed to start webhook server at [%s]: %v", s.webhook.address, err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		webhookServer.Close()
+	}()
+}
+
 func (s *server) initBackupService(config *api.Config) error {
 	s.logger.Info("Configuring cloud provider for backup service")
 	objectStore, err := getObjectStore(config.BackupStorageProvider.CloudProviderConfig, s.pluginManager)
@@ -371,7 +536,64 @@ func (s *server) initBackupService(config *api.Config) error {
 		return err
 	}
 
-	s.backupService = cloudprovider.NewBackupService(objectStore, s.logger)
+	if objectStore, err = retryingObjectStore(objectStore, config.BackupStorageProvider.RetryableErrors, s.logger); err != nil {
+		return errors.Wrap(err, "invalid backupStorageProvider")
+	}
+
+	newBackupService := cloudprovider.NewBackupService
+	if config.DeduplicateBackupContents {
+		if config.ClusterID == "" {
+			return errors.New("clusterID must be set when deduplicateBackupContents is enabled")
+		}
+		s.logger.Info("Deduplicating the contents of backups in object storage")
+		newBackupService = cloudprovider.NewDeduplicatingBackupService
+	}
+
+	s.backupService = newBackupService(objectStore, config.ClusterID, s.logger)
+
+	for i, mirrorConfig := range config.BackupStorageMirrors {
+		s.logger.WithField("bucket", mirrorConfig.Bucket).Info("Configuring cloud provider for backup storage mirror")
+		if mirrorConfig.Name == "" {
+			return errors.Errorf("backup storage mirror %d: object storage provider name must not be empty", i)
+		}
+
+		objectStore, err := s.pluginManager.GetMirrorObjectStore(mirrorConfig.Name, strconv.Itoa(i))
+		if err != nil {
+			return errors.Wrapf(err, "backup storage mirror %d", i)
+		}
+		if err := objectStore.Init(mirrorConfig.Config); err != nil {
+			return errors.Wrapf(err, "backup storage mirror %d", i)
+		}
+		if objectStore, err = retryingObjectStore(objectStore, mirrorConfig.RetryableErrors, s.logger); err != nil {
+			return errors.Wrapf(err, "backup storage mirror %d", i)
+		}
+
+		s.backupStorageMirrors = append(s.backupStorageMirrors, controller.BackupStorageMirror{
+			BackupService: newBackupService(objectStore, config.ClusterID, s.logger),
+			Bucket:        mirrorConfig.Bucket,
+		})
+	}
+
+	if streamConfig := config.BackupStream; streamConfig != nil {
+		s.logger.WithField("bucket", streamConfig.Bucket).Info("Configuring cloud provider for backup stream")
+		if streamConfig.Name == "" {
+			return errors.New("backup stream: object storage provider name must not be empty")
+		}
+
+		objectStore, err := s.pluginManager.GetStreamObjectStore(streamConfig.Name)
+		if err != nil {
+			return errors.Wrap(err, "backup stream")
+		}
+		if err := objectStore.Init(streamConfig.Config); err != nil {
+			return errors.Wrap(err, "backup stream")
+		}
+
+		s.backupStream = &controller.BackupStream{
+			Consumer: cloudprovider.NewObjectStoreStream(objectStore, streamConfig.Bucket),
+			Required: streamConfig.Required,
+		}
+	}
+
 	return nil
 }
 
@@ -382,14 +604,34 @@ func (s *server) initSnapshotService(config *api.Config) error {
 	}
 
 	s.logger.Info("Configuring cloud provider for snapshot service")
-	blockStore, err := getBlockStore(*config.PersistentVolumeProvider, s.pluginManager)
+	blockStore, err := getBlockStore(config.PersistentVolumeProvider.CloudProviderConfig, s.pluginManager)
 	if err != nil {
 		return err
 	}
-	s.snapshotService = cloudprovider.NewSnapshotService(blockStore)
+	s.snapshotService = cloudprovider.NewSnapshotService(blockStore, config.SnapshotTags, config.PersistentVolumeProvider.SnapshotTTL.Duration)
 	return nil
 }
 
+func (s *server) initCSISnapshotter(config *api.Config) {
+	if !config.CSISnapshots {
+		return
+	}
+
+	s.logger.Info("Configuring CSI snapshots")
+	s.csiSnapshotter = csi.NewSnapshotter(client.NewDynamicFactory(s.clientPool, s.kubeClientConfig), defaultCSISnapshotTimeout)
+}
+
+// retryingObjectStore returns objectStore wrapped to retry operations that fail with errors
+// matching retryableErrors.
+func retryingObjectStore(objectStore cloudprovider.ObjectStore, retryableErrors []string, logger logrus.FieldLogger) (cloudprovider.ObjectStore, error) {
+	isRetryable, err := cloudprovider.NewRetryableErrorMatcher(retryableErrors)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloudprovider.NewRetryingObjectStore(objectStore, isRetryable, logger), nil
+}
+
 func getObjectStore(cloudConfig api.CloudProviderConfig, manager plugin.Manager) (cloudprovider.ObjectStore, error) {
 	if cloudConfig.Name == "" {
 		return nil, errors.New("object storage provider name must not be empty")
@@ -448,9 +690,11 @@ func (s *server) runControllers(config *api.Config) error {
 
 	backupSyncController := controller.NewBackupSyncController(
 		s.arkClient.ArkV1(),
+		s.sharedInformerFactory.Ark().V1().Backups(),
 		s.backupService,
 		config.BackupStorageProvider.Bucket,
 		config.BackupSyncPeriod.Duration,
+		config.ReconcileBackupExpiration,
 		s.logger,
 	)
 	wg.Add(1)
@@ -478,7 +722,7 @@ func (s *server) runControllers(config *api.Config) error {
 	} else {
 		backupTracker := controller.NewBackupTracker()
 
-		backupper, err := newBackupper(discoveryHelper, s.clientPool, s.backupService, s.snapshotService, s.kubeClientConfig, s.kubeClient.CoreV1())
+		backupper, err := newBackupper(discoveryHelper, s.clientPool, s.backupService, s.snapshotService, s.csiSnapshotter, config.MaxConcurrentSnapshots, config.MaxConcurrentSnapshotsPerStorageClass, s.kubeClientConfig, s.kubeClient.CoreV1(), config.BackupResourcePriorities, config.BackupResourceRequestTimeout.Duration)
 		cmd.CheckError(err)
 		backupController := controller.NewBackupController(
 			s.sharedInformerFactory.Ark().V1().Backups(),
@@ -486,23 +730,44 @@ func (s *server) runControllers(config *api.Config) error {
 			backupper,
 			s.backupService,
 			config.BackupStorageProvider.Bucket,
-			s.snapshotService != nil,
+			config.DeduplicateBackupContents,
+			s.backupStorageMirrors,
+			config.BackupStorageQuorum,
+			config.MinAvailableBackupStorage.Value(),
+			s.backupStream,
+			s.snapshotService != nil || s.csiSnapshotter != nil,
+			config.DefaultBackupTTL.Duration,
+			config.ShutdownGracePeriod.Duration,
+			config.StaleBackupTimeout.Duration,
+			config.ClusterID,
+			config.DefaultExcludedResources,
 			s.logger,
 			s.pluginManager,
 			backupTracker,
+			s.metrics,
 		)
 		wg.Add(1)
 		go func() {
-			backupController.Run(ctx, 1)
+			backupController.Run(ctx, maxConcurrentBackups(config))
 			wg.Done()
 		}()
 
+		var backupNameTemplate *backup.NameTemplate
+		if config.BackupNameTemplate != "" {
+			backupNameTemplate, err = backup.NewNameTemplate(config.BackupNameTemplate)
+			if err != nil {
+				return errors.Wrap(err, "invalid backupNameTemplate")
+			}
+		}
+
 		scheduleController := controller.NewScheduleController(
 			s.namespace,
 			s.arkClient.ArkV1(),
 			s.arkClient.ArkV1(),
 			s.sharedInformerFactory.Ark().V1().Schedules(),
 			config.ScheduleSyncPeriod.Duration,
+			backupNameTemplate,
+			config.ClusterID,
 			s.logger,
 		)
 		wg.Add(1)
@@ -511,11 +776,92 @@ func (s *server) runControllers(config *api.Config) error {
 			wg.Done()
 		}()
 
+		var namespaceInformer cache.SharedIndexInformer
+		if len(config.NamespaceSchedulePolicies) > 0 || config.NamespaceDeletionBackupTemplate != nil {
+			namespaceInformer = cache.NewSharedIndexInformer(
+				cache.NewListWatchFromClient(s.kubeClient.CoreV1().RESTClient(), "namespaces", metav1.NamespaceAll, fields.Everything()),
+				&v1.Namespace{},
+				0,
+				cache.Indexers{},
+			)
+		}
+
+		if len(config.NamespaceSchedulePolicies) > 0 {
+			namespaceScheduleController := controller.NewNamespaceScheduleController(
+				s.logger,
+				s.namespace,
+				namespaceInformer,
+				s.sharedInformerFactory.Ark().V1().Schedules(),
+				s.arkClient.ArkV1(),
+				config.NamespaceSchedulePolicies,
+				config.ScheduleSyncPeriod.Duration,
+				s.metrics,
+			)
+			wg.Add(1)
+			go func() {
+				namespaceScheduleController.Run(ctx, 1)
+				wg.Done()
+			}()
+		}
+
+		if config.NamespaceDeletionBackupTemplate != nil {
+			if s.webhook.certFile == "" || s.webhook.keyFile == "" {
+				return errors.New("namespaceDeletionBackupTemplate requires --webhook-tls-cert-file and --webhook-tls-private-key-file")
+			}
+			s.runWebhookServer(ctx, webhook.NewNamespaceDeletionHandler(
+				s.logger,
+				s.namespace,
+				namespaceInformer.GetStore(),
+				s.sharedInformerFactory.Ark().V1().Backups().Lister(),
+				s.arkClient.ArkV1(),
+				*config.NamespaceDeletionBackupTemplate,
+			))
+		}
+
+		if namespaceInformer != nil {
+			go namespaceInformer.Run(ctx.Done())
+		}
+
+		var gcMaintenanceWindow *controller.MaintenanceWindow
+		if config.GCMaintenanceWindow != nil {
+			gcMaintenanceWindow, err = controller.ParseMaintenanceWindow(*config.GCMaintenanceWindow)
+			if err != nil {
+				return errors.Wrap(err, "invalid gcMaintenanceWindow")
+			}
+		}
+
+		gcRetentionClasses := make(map[string]time.Duration, len(config.GCRetentionClasses))
+		for class, ttl := range config.GCRetentionClasses {
+			if ttl.Duration <= 0 {
+				return errors.Errorf("invalid gcRetentionClasses: the TTL of retention class %s must be positive", class)
+			}
+			gcRetentionClasses[class] = ttl.Duration
+		}
+
 		gcController := controller.NewGCController(
 			s.logger,
+			s.namespace,
 			s.sharedInformerFactory.Ark().V1().Backups(),
+			s.sharedInformerFactory.Ark().V1().Schedules(),
 			s.arkClient.ArkV1(),
+			s.arkClient.ArkV1(),
+			s.backupService,
+			config.BackupStorageProvider.Bucket,
 			config.GCSyncPeriod.Duration,
+			config.GCKeepLastScheduledBackup,
+			config.GCMinRetention.Duration,
+			config.GCFailedBackupTTL.Duration,
+			config.GCSnapshotsMissingBackupTTL.Duration,
+			gcMaintenanceWindow,
+			config.GCMissingBackupContents,
+			config.GCRecycleBinRetention.Duration,
+			config.GCOrphanedScheduleBackupRetention.Duration,
+			gcRetentionClasses,
+			s.arkClient.ArkV1(),
+			config.GCReportPeriod.Duration,
+			config.GCReportRetention.Duration,
+			config.GCTransientErrorBackoff.Duration,
+			s.metrics,
 		)
 		wg.Add(1)
 		go func() {
@@ -523,21 +869,97 @@ func (s *server) runControllers(config *api.Config) error {
 			wg.Done()
 		}()
 
+		if config.MaxBackups > 0 {
+			backupCapacityController := controller.NewBackupCapacityController(
+				s.logger,
+				s.namespace,
+				config.MaxBackups,
+				s.sharedInformerFactory.Ark().V1().Backups(),
+				s.sharedInformerFactory.Ark().V1().DeleteBackupRequests(),
+				s.arkClient.ArkV1(),
+				config.GCSyncPeriod.Duration,
+				s.metrics,
+			)
+			wg.Add(1)
+			go func() {
+				backupCapacityController.Run(ctx, 1)
+				wg.Done()
+			}()
+		}
+
+		if config.BackupRetries > 0 {
+			backupRetryController := controller.NewBackupRetryController(
+				s.logger,
+				config.BackupRetries,
+				config.BackupRetryBackoff.Duration,
+				s.sharedInformerFactory.Ark().V1().Backups(),
+				s.arkClient.ArkV1(),
+				s.metrics,
+			)
+			wg.Add(1)
+			go func() {
+				backupRetryController.Run(ctx, 1)
+				wg.Done()
+			}()
+		}
+
+		if config.SnapshotCheckPeriod.Duration > 0 && (s.snapshotService != nil || s.csiSnapshotter != nil) {
+			snapshotCheckController := controller.NewSnapshotCheckController(
+				s.logger,
+				s.namespace,
+				s.sharedInformerFactory.Ark().V1().Backups(),
+				s.arkClient.ArkV1(),
+				s.snapshotService,
+				s.csiSnapshotter,
+				config.SnapshotCheckPeriod.Duration,
+				s.metrics,
+			)
+			wg.Add(1)
+			go func() {
+				snapshotCheckController.Run(ctx, 1)
+				wg.Done()
+			}()
+		}
+
+		if s.snapshotService != nil && config.PersistentVolumeProvider.SnapshotTTL.Duration > 0 {
+			snapshotSweepController := controller.NewSnapshotSweepController(
+				s.logger,
+				s.namespace,
+				s.sharedInformerFactory.Ark().V1().Backups(),
+				s.snapshotService,
+				config.SnapshotTags,
+				time.Hour,
+				s.metrics,
+			)
+			wg.Add(1)
+			go func() {
+				snapshotSweepController.Run(ctx, 1)
+				wg.Done()
+			}()
+		}
+
 		backupDeletionController := controller.NewBackupDeletionController(
 			s.logger,
 			s.sharedInformerFactory.Ark().V1().DeleteBackupRequests(),
 			s.arkClient.ArkV1(), // deleteBackupRequestClient
 			s.arkClient.ArkV1(), // backupClient
 			s.snapshotService,
+			s.csiSnapshotter,
+			snapshotDeletionBatchSize(config),
 			s.backupService,
 			config.BackupStorageProvider.Bucket,
+			s.backupStorageMirrors,
 			s.sharedInformerFactory.Ark().V1().Restores(),
 			s.arkClient.ArkV1(), // restoreClient
 			backupTracker,
+			s.sharedInformerFactory.Ark().V1().BackupTombstones(),
+			s.arkClient.ArkV1(), // backupTombstoneClient
+			config.BackupTombstoneRetention.Duration,
+			s.metrics,
 		)
 		wg.Add(1)
 		go func() {
-			backupDeletionController.Run(ctx, 1)
+			backupDeletionController.Run(ctx, backupDeletionConcurrency(config))
 			wg.Done()
 		}()
 
@@ -546,9 +968,13 @@ func (s *server) runControllers(config *api.Config) error {
 	restorer, err := newRestorer(
 		discoveryHelper,
 		s.clientPool,
+		s.kubeClientConfig,
 		s.backupService,
 		s.snapshotService,
+		s.csiSnapshotter,
 		config.ResourcePriorities,
+		restoreNamespaceConcurrency(config),
+		config.VersionedResources,
 		s.arkClient.ArkV1(),
 		s.kubeClient,
 		s.logger,
@@ -561,10 +987,13 @@ func (s *server) runControllers(config *api.Config) error {
 		s.arkClient.ArkV1(),
 		s.arkClient.ArkV1(),
 		restorer,
+		discoveryHelper,
 		s.backupService,
 		config.BackupStorageProvider.Bucket,
 		s.sharedInformerFactory.Ark().V1().Backups(),
-		s.snapshotService != nil,
+		s.snapshotService != nil || s.csiSnapshotter != nil,
+		config.ShutdownGracePeriod.Duration,
+		config.ClusterID,
 		s.logger,
 		s.pluginManager,
 	)
@@ -588,6 +1017,18 @@ func (s *server) runControllers(config *api.Config) error {
 		wg.Done()
 	}()
 
+	serverStatusRequestController := controller.NewServerStatusRequestController(
+		s.logger,
+		s.arkClient.ArkV1(),
+		s.sharedInformerFactory.Ark().V1().ServerStatusRequests(),
+		s.metrics,
+	)
+	wg.Add(1)
+	go func() {
+		serverStatusRequestController.Run(ctx, 1)
+		wg.Done()
+	}()
+
 	// SHARED INFORMERS HAVE TO BE STARTED AFTER ALL CONTROLLERS
 	go s.sharedInformerFactory.Start(ctx.Done())
 
@@ -644,38 +1085,91 @@ func (s *server) removeDeprecatedGCFinalizer() {
 	}
 }
 
+// maxConcurrentBackups returns the number of backups that may run at once, which
+// defaults to one if it's not configured.
+func maxConcurrentBackups(config *api.Config) int {
+	if config.MaxConcurrentBackups < 1 {
+		return 1
+	}
+	return config.MaxConcurrentBackups
+}
+
+// backupDeletionConcurrency returns the number of DeleteBackupRequests that may be
+// processed at once, which defaults to one if it's not configured.
+func backupDeletionConcurrency(config *api.Config) int {
+	if config.BackupDeletionConcurrency < 1 {
+		return 1
+	}
+	return config.BackupDeletionConcurrency
+}
+
+// snapshotDeletionBatchSize returns the number of snapshots that may be deleted with a
+// single call to the cloud API, which defaults to defaultSnapshotDeletionBatchSize if it's
+// not configured.
+func snapshotDeletionBatchSize(config *api.Config) int {
+	if config.SnapshotDeletionBatchSize < 1 {
+		return defaultSnapshotDeletionBatchSize
+	}
+	return config.SnapshotDeletionBatchSize
+}
+
+// restoreNamespaceConcurrency returns the number of namespaces a restore may create at
+// once, which defaults to defaultRestoreNamespaceConcurrency if it's not configured.
+func restoreNamespaceConcurrency(config *api.Config) int {
+	if config.RestoreNamespaceConcurrency < 1 {
+		return defaultRestoreNamespaceConcurrency
+	}
+	return config.RestoreNamespaceConcurrency
+}
+
 func newBackupper(
 	discoveryHelper arkdiscovery.Helper,
 	clientPool dynamic.ClientPool,
 	backupService cloudprovider.BackupService,
 	snapshotService cloudprovider.SnapshotService,
+	csiSnapshotter csi.Snapshotter,
+	maxConcurrentSnapshots int,
+	maxConcurrentSnapshotsPerStorageClass map[string]int,
 	kubeClientConfig *rest.Config,
 	kubeCoreV1Client kcorev1client.CoreV1Interface,
+	resourcePriorities []string,
+	resourceRequestTimeout time.Duration,
 ) (backup.Backupper, error) {
 	return backup.NewKubernetesBackupper(
 		discoveryHelper,
-		client.NewDynamicFactory(clientPool),
+		client.NewDynamicFactory(clientPool, kubeClientConfig),
 		backup.NewPodCommandExecutor(kubeClientConfig, kubeCoreV1Client.RESTClient()),
 		snapshotService,
+		csiSnapshotter,
+		backup.NewSnapshotThrottle(maxConcurrentSnapshots, maxConcurrentSnapshotsPerStorageClass),
+		resourcePriorities,
+		resourceRequestTimeout,
 	)
 }
 
 func newRestorer(
 	discoveryHelper arkdiscovery.Helper,
 	clientPool dynamic.ClientPool,
+	kubeClientConfig *rest.Config,
 	backupService cloudprovider.BackupService,
 	snapshotService cloudprovider.SnapshotService,
+	csiSnapshotter csi.Snapshotter,
 	resourcePriorities []string,
+	namespaceConcurrency int,
+	versionedResources []string,
 	backupClient arkv1client.BackupsGetter,
 	kubeClient kubernetes.Interface,
 	logger logrus.FieldLogger,
 ) (restore.Restorer, error) {
 	return restore.NewKubernetesRestorer(
 		discoveryHelper,
-		client.NewDynamicFactory(clientPool),
+		client.NewDynamicFactory(clientPool, kubeClientConfig),
 		backupService,
 		snapshotService,
+		csiSnapshotter,
 		resourcePriorities,
+		namespaceConcurrency,
+		versionedResources,
 		backupClient,
 		kubeClient.CoreV1().Namespaces(),
 		logger,
@@ -22,6 +22,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/heptio/ark/pkg/apis/ark/v1"
 	arktest "github.com/heptio/ark/pkg/util/test"
 )
@@ -38,16 +40,34 @@ func TestApplyConfigDefaults(t *testing.T) {
 	assert.Equal(t, defaultBackupSyncPeriod, c.BackupSyncPeriod.Duration)
 	assert.Equal(t, defaultScheduleSyncPeriod, c.ScheduleSyncPeriod.Duration)
 	assert.Equal(t, defaultResourcePriorities, c.ResourcePriorities)
+	assert.Equal(t, defaultShutdownGracePeriod, c.ShutdownGracePeriod.Duration)
+	assert.Equal(t, defaultStaleBackupTimeout, c.StaleBackupTimeout.Duration)
+	assert.Equal(t, defaultVersionedResources, c.VersionedResources)
 
 	// make sure defaulting doesn't overwrite real values
 	c.GCSyncPeriod.Duration = 5 * time.Minute
 	c.BackupSyncPeriod.Duration = 4 * time.Minute
 	c.ScheduleSyncPeriod.Duration = 3 * time.Minute
 	c.ResourcePriorities = []string{"a", "b"}
+	c.StaleBackupTimeout.Duration = 2 * time.Hour
+	c.VersionedResources = []string{"c"}
 
 	applyConfigDefaults(c, logger)
 	assert.Equal(t, 5*time.Minute, c.GCSyncPeriod.Duration)
 	assert.Equal(t, 4*time.Minute, c.BackupSyncPeriod.Duration)
 	assert.Equal(t, 3*time.Minute, c.ScheduleSyncPeriod.Duration)
 	assert.Equal(t, []string{"a", "b"}, c.ResourcePriorities)
+	assert.Equal(t, 2*time.Hour, c.StaleBackupTimeout.Duration)
+	assert.Equal(t, []string{"c"}, c.VersionedResources)
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	c := &v1.Config{GCMinRetention: metav1.Duration{Duration: time.Hour}}
+
+	effective := EffectiveConfig(c)
+	assert.Equal(t, defaultGCSyncPeriod, effective.GCSyncPeriod.Duration)
+	assert.Equal(t, time.Hour, effective.GCMinRetention.Duration)
+
+	// the given config isn't modified
+	assert.Equal(t, time.Duration(0), c.GCSyncPeriod.Duration)
 }
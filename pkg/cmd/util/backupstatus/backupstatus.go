@@ -0,0 +1,177 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupstatus
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	arkclientv1 "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
+)
+
+// IsFinished returns whether a backup in the given phase has finished running.
+func IsFinished(phase v1.BackupPhase) bool {
+	switch phase {
+	case "", v1.BackupPhaseNew, v1.BackupPhaseInProgress:
+		return false
+	default:
+		return true
+	}
+}
+
+// Line returns a one-line summary of the backup's phase and progress.
+func Line(backup *v1.Backup) string {
+	phase := backup.Status.Phase
+	if phase == "" {
+		phase = v1.BackupPhaseNew
+	}
+
+	line := fmt.Sprintf("%s: %s", backup.Name, phase)
+	if progress := backup.Status.Progress; progress != nil && progress.TotalItems > 0 {
+		line += fmt.Sprintf(" (%d of %d items backed up, %d%%)", progress.ItemsBackedUp, progress.TotalItems, progress.ItemsBackedUp*100/progress.TotalItems)
+	}
+
+	return line
+}
+
+// PrintResult writes the reasons a finished backup failed, and the errors and
+// warnings it finished with, to w.
+func PrintResult(w io.Writer, backup *v1.Backup) {
+	if backup.Status.FailureReason != "" {
+		fmt.Fprintf(w, "Failure reason: %s\n", backup.Status.FailureReason)
+	}
+	printList(w, "Validation errors", backup.Status.ValidationErrors)
+	printList(w, "Errors", backup.Status.Errors)
+	printList(w, "Warnings", backup.Status.Warnings)
+}
+
+func printList(w io.Writer, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, item := range items {
+		fmt.Fprintf(w, "  %s\n", item)
+	}
+}
+
+// Watch writes the backup's status line to w, and again each time its phase or
+// progress changes, until it finishes, then writes its result. Watches closed by
+// the API server, or whose resource version has expired, are restarted.
+func Watch(client arkclientv1.BackupsGetter, namespace, name string, w io.Writer) error {
+	backup, err := client.Backups(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	p := &linePrinter{w: w}
+	p.print(backup)
+
+	for !IsFinished(backup.Status.Phase) {
+		if backup, err = watchUntilClosed(client, backup, p); err != nil {
+			return err
+		}
+	}
+
+	PrintResult(w, backup)
+
+	return nil
+}
+
+// watchUntilClosed watches the backup from its resource version, printing each
+// update, until it finishes or the watch is closed, and returns the backup as
+// last seen.
+func watchUntilClosed(client arkclientv1.BackupsGetter, backup *v1.Backup, p *linePrinter) (*v1.Backup, error) {
+	listOptions := metav1.ListOptions{
+		// TODO: once the minimum supported Kubernetes version is v1.9.0, uncomment the following line.
+		// See http://issue.k8s.io/51046 for details.
+		//FieldSelector:   "metadata.name=" + backup.Name
+		ResourceVersion: backup.ResourceVersion,
+	}
+	watcher, err := client.Backups(backup.Namespace).Watch(listOptions)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer watcher.Stop()
+
+	for e := range watcher.ResultChan() {
+		if e.Type == watch.Error {
+			statusErr := apierrors.FromObject(e.Object)
+			if !apierrors.IsGone(statusErr) && !apierrors.IsResourceExpired(statusErr) {
+				return nil, errors.WithStack(statusErr)
+			}
+
+			// the resource version is too old to watch from, so start again
+			// from the backup's current one
+			updated, err := client.Backups(backup.Namespace).Get(backup.Name, metav1.GetOptions{})
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			p.print(updated)
+			return updated, nil
+		}
+
+		updated, ok := e.Object.(*v1.Backup)
+		if !ok {
+			return nil, errors.Errorf("unexpected type %T", e.Object)
+		}
+
+		// TODO: once the minimum supported Kubernetes version is v1.9.0, remove the following check.
+		// See http://issue.k8s.io/51046 for details.
+		if updated.Name != backup.Name {
+			continue
+		}
+
+		switch e.Type {
+		case watch.Deleted:
+			return nil, errors.Errorf("backup %s was deleted", backup.Name)
+		case watch.Added, watch.Modified:
+			backup = updated
+			p.print(backup)
+			if IsFinished(backup.Status.Phase) {
+				return backup, nil
+			}
+		}
+	}
+
+	return backup, nil
+}
+
+// linePrinter writes backups' status lines, skipping lines that are the same as
+// the last one written.
+type linePrinter struct {
+	w    io.Writer
+	last string
+}
+
+func (p *linePrinter) print(backup *v1.Backup) {
+	line := Line(backup)
+	if line == p.last {
+		return
+	}
+
+	fmt.Fprintln(p.w, line)
+	p.last = line
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupstatus
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	core "k8s.io/client-go/testing"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestLine(t *testing.T) {
+	backup := arktest.NewTestBackup().WithName("backup-1").Backup
+	assert.Equal(t, "backup-1: New", Line(backup))
+
+	backup.Status.Phase = v1.BackupPhaseInProgress
+	backup.Status.Progress = &v1.BackupProgress{}
+	assert.Equal(t, "backup-1: InProgress", Line(backup))
+
+	backup.Status.Progress = &v1.BackupProgress{TotalItems: 40, ItemsBackedUp: 10}
+	assert.Equal(t, "backup-1: InProgress (10 of 40 items backed up, 25%)", Line(backup))
+}
+
+func TestWatch(t *testing.T) {
+	newBackup := func(phase v1.BackupPhase, backedUp int, resourceVersion string) *v1.Backup {
+		backup := arktest.NewTestBackup().WithNamespace("ns").WithName("backup-1").WithPhase(phase).WithResourceVersion(resourceVersion).Backup
+		backup.Status.Progress = &v1.BackupProgress{TotalItems: 4, ItemsBackedUp: backedUp}
+		return backup
+	}
+
+	tests := []struct {
+		name           string
+		backup         *v1.Backup
+		refreshed      *v1.Backup
+		watches        [][]watch.Event
+		expectedOutput string
+		expectedError  string
+		expectedWatch  []string
+	}{
+		{
+			name:           "finished backups aren't watched",
+			backup:         newBackup(v1.BackupPhaseCompleted, 4, "1"),
+			expectedOutput: "backup-1: Completed (4 of 4 items backed up, 100%)\n",
+		},
+		{
+			name:   "updates are printed until the backup finishes",
+			backup: newBackup(v1.BackupPhaseNew, 0, "1"),
+			watches: [][]watch.Event{
+				{
+					{Type: watch.Modified, Object: newBackup(v1.BackupPhaseInProgress, 0, "2")},
+					{Type: watch.Modified, Object: arktest.NewTestBackup().WithNamespace("ns").WithName("other").WithPhase(v1.BackupPhaseCompleted).Backup},
+					{Type: watch.Modified, Object: newBackup(v1.BackupPhaseInProgress, 2, "3")},
+					{Type: watch.Modified, Object: newBackup(v1.BackupPhaseInProgress, 2, "4")},
+					{Type: watch.Modified, Object: func() *v1.Backup {
+						backup := newBackup(v1.BackupPhasePartiallyFailed, 4, "5")
+						backup.Status.Errors = []string{"snapshot failed"}
+						return backup
+					}()},
+				},
+			},
+			expectedOutput: `backup-1: New (0 of 4 items backed up, 0%)
+backup-1: InProgress (0 of 4 items backed up, 0%)
+backup-1: InProgress (2 of 4 items backed up, 50%)
+backup-1: PartiallyFailed (4 of 4 items backed up, 100%)
+Errors:
+  snapshot failed
+`,
+			expectedWatch: []string{"1"},
+		},
+		{
+			name:   "closed watches are restarted from the last resource version",
+			backup: newBackup(v1.BackupPhaseInProgress, 1, "1"),
+			watches: [][]watch.Event{
+				{{Type: watch.Modified, Object: newBackup(v1.BackupPhaseInProgress, 2, "2")}},
+				{{Type: watch.Modified, Object: newBackup(v1.BackupPhaseCompleted, 4, "3")}},
+			},
+			expectedOutput: `backup-1: InProgress (1 of 4 items backed up, 25%)
+backup-1: InProgress (2 of 4 items backed up, 50%)
+backup-1: Completed (4 of 4 items backed up, 100%)
+`,
+			expectedWatch: []string{"1", "2"},
+		},
+		{
+			name:      "expired watches are restarted from the backup's current resource version",
+			backup:    newBackup(v1.BackupPhaseInProgress, 1, "1"),
+			refreshed: newBackup(v1.BackupPhaseInProgress, 3, "7"),
+			watches: [][]watch.Event{
+				{{Type: watch.Error, Object: &apierrors.NewGone("too old").ErrStatus}},
+				{{Type: watch.Modified, Object: newBackup(v1.BackupPhaseFailed, 3, "8")}},
+			},
+			expectedOutput: `backup-1: InProgress (1 of 4 items backed up, 25%)
+backup-1: InProgress (3 of 4 items backed up, 75%)
+backup-1: Failed (3 of 4 items backed up, 75%)
+`,
+			expectedWatch: []string{"1", "7"},
+		},
+		{
+			name:   "other watch errors are returned",
+			backup: newBackup(v1.BackupPhaseInProgress, 1, "1"),
+			watches: [][]watch.Event{
+				{{Type: watch.Error, Object: &apierrors.NewInternalError(assert.AnError).ErrStatus}},
+			},
+			expectedOutput: "backup-1: InProgress (1 of 4 items backed up, 25%)\n",
+			expectedError:  "Internal error occurred: " + assert.AnError.Error(),
+			expectedWatch:  []string{"1"},
+		},
+		{
+			name:   "deleted backups are an error",
+			backup: newBackup(v1.BackupPhaseInProgress, 1, "1"),
+			watches: [][]watch.Event{
+				{{Type: watch.Deleted, Object: newBackup(v1.BackupPhaseInProgress, 1, "2")}},
+			},
+			expectedOutput: "backup-1: InProgress (1 of 4 items backed up, 25%)\n",
+			expectedError:  "backup backup-1 was deleted",
+			expectedWatch:  []string{"1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+
+			gets := []*v1.Backup{test.backup, test.refreshed}
+			client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+				backup := gets[0]
+				gets = gets[1:]
+				return true, backup, nil
+			})
+
+			var watchedVersions []string
+			client.PrependWatchReactor("backups", func(action core.Action) (bool, watch.Interface, error) {
+				require.True(t, len(watchedVersions) < len(test.watches), "unexpected watch")
+
+				watchedVersions = append(watchedVersions, action.(core.WatchAction).GetWatchRestrictions().ResourceVersion)
+
+				events := test.watches[len(watchedVersions)-1]
+				fakeWatch := watch.NewFakeWithChanSize(len(events), false)
+				for _, e := range events {
+					fakeWatch.Action(e.Type, e.Object)
+				}
+				// the watch is closed once its events have been received
+				fakeWatch.Stop()
+
+				return true, fakeWatch, nil
+			})
+
+			var out bytes.Buffer
+			err := Watch(client.ArkV1(), "ns", "backup-1", &out)
+
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedOutput, out.String())
+			assert.Equal(t, test.expectedWatch, watchedVersions)
+		})
+	}
+}
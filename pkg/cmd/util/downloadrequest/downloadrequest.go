@@ -20,7 +20,7 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"net"
 	"net/http"
 	"time"
 
@@ -101,37 +101,19 @@ Loop:
 		return errors.New("file not found")
 	}
 
-	httpClient := new(http.Client)
+	httpClient := &http.Client{Transport: newTransport(timeout)}
 
-	httpReq, err := http.NewRequest("GET", req.Status.DownloadURL, nil)
-	if err != nil {
-		return err
-	}
-
-	// Manually set this header so the net/http library does not automatically try to decompress. We
-	// need to handle this manually because it's not currently possible to set the MIME type for the
-	// pre-signed URLs for GCP or Azure.
-	httpReq.Header.Set("Accept-Encoding", "gzip")
+	body := newRetryingReader(httpClient, req.Status.DownloadURL)
+	defer body.Close()
 
-	resp, err := httpClient.Do(httpReq)
-	if err != nil {
+	if err := body.Open(); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return errors.Wrapf(err, "request failed: unable to decode response body")
-		}
-
-		return errors.Errorf("request failed: %v", string(body))
-	}
 
-	reader := resp.Body
+	var reader io.Reader = body
 	if kind != v1.DownloadTargetKindBackupContents {
 		// need to decompress logs
-		gzipReader, err := gzip.NewReader(resp.Body)
+		gzipReader, err := gzip.NewReader(body)
 		if err != nil {
 			return err
 		}
@@ -142,3 +124,27 @@ Loop:
 	_, err = io.Copy(w, reader)
 	return err
 }
+
+// newTransport returns the transport the download is read with. Connecting to
+// the download URL's host, the TLS handshake, and waiting for the response's
+// headers each time out after timeout, so an unresponsive object store fails
+// the command rather than hanging it; reading the body isn't limited, since
+// backups can take arbitrarily long to download.
+//
+// The filesystem object store returns file:// URLs, which can be read when
+// its directory is mounted locally. Downloads go through the proxy set by
+// HTTP_PROXY/HTTPS_PROXY, unless NO_PROXY excludes the URL's host.
+func newTransport(timeout time.Duration) *http.Transport {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+	}
+	transport.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+
+	return transport
+}
@@ -96,14 +96,25 @@ func TestStream(t *testing.T) {
 			name:          "http error",
 			kind:          v1.DownloadTargetKindBackupLog,
 			updateWithURL: true,
-			statusCode:    http.StatusInternalServerError,
+			statusCode:    http.StatusNotFound,
 			body:          "some error",
 			expectedError: "request failed: some error",
 		},
+		{
+			name:          "http server error is retried",
+			kind:          v1.DownloadTargetKindBackupLog,
+			updateWithURL: true,
+			statusCode:    http.StatusInternalServerError,
+			body:          "some error",
+			expectedError: "download failed after 4 attempts: request failed: some error",
+		},
 	}
 
 	const testTimeout = 30 * time.Second
 
+	defer func(backoff time.Duration) { retryBackoff = backoff }(retryBackoff)
+	retryBackoff = time.Millisecond
+
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			client := fake.NewSimpleClientset()
@@ -208,6 +219,21 @@ func TestStream(t *testing.T) {
 	}
 }
 
+func TestNewTransportTimesOutWaitingForHeaders(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	httpClient := &http.Client{Transport: newTransport(10 * time.Millisecond)}
+
+	_, err := httpClient.Get(server.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout awaiting response headers")
+}
+
 type downloadRequest struct {
 	*v1.DownloadRequest
 }
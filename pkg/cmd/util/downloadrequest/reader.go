@@ -0,0 +1,234 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloadrequest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+var (
+	// readTimeout is how long a download may go without receiving any data
+	// before it's retried.
+	readTimeout = 30 * time.Second
+
+	// readRetries is how many times a download that fails or stalls is retried.
+	readRetries = 3
+
+	// retryBackoff is how long to wait before the first retry. It doubles with
+	// each retry after that.
+	retryBackoff = time.Second
+)
+
+// BindFlags binds the flags that configure how files are downloaded from
+// object storage to the passed-in FlagSet.
+func BindFlags(flags *pflag.FlagSet) {
+	flags.DurationVar(&readTimeout, "download-read-timeout", readTimeout, "how long a download from object storage may go without receiving data before it's retried")
+	flags.IntVar(&readRetries, "download-retries", readRetries, "how many times to retry a download from object storage that fails or stalls")
+}
+
+// requestError is returned when object storage responds to a download with an
+// unexpected status code.
+type requestError struct {
+	statusCode int
+	body       string
+}
+
+func (e *requestError) Error() string {
+	return fmt.Sprintf("request failed: %s", e.body)
+}
+
+// errResumeUnsupported is returned when a retried download can't continue where
+// the previous attempt stopped, because object storage ignored the Range header.
+var errResumeUnsupported = errors.New("unable to resume download: object storage doesn't support range requests")
+
+// retryingReader reads the body of a GET request to url. A request that fails,
+// or that receives no data for readTimeout, is retried with exponential backoff
+// up to retries times, continuing from the last byte read.
+type retryingReader struct {
+	httpClient  *http.Client
+	url         string
+	readTimeout time.Duration
+	retries     int
+	backoff     time.Duration
+
+	body     io.ReadCloser
+	cancel   context.CancelFunc
+	offset   int64
+	attempts int
+}
+
+func newRetryingReader(httpClient *http.Client, url string) *retryingReader {
+	return &retryingReader{
+		httpClient:  httpClient,
+		url:         url,
+		readTimeout: readTimeout,
+		retries:     readRetries,
+		backoff:     retryBackoff,
+	}
+}
+
+// Open sends the first request, retrying it if needed, so that errors such as
+// a missing file are returned before any data is read.
+func (r *retryingReader) Open() error {
+	for {
+		err := r.open()
+		if err == nil {
+			return nil
+		}
+		if err := r.waitToRetry(err); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *retryingReader) Read(p []byte) (int, error) {
+	for {
+		if r.body == nil {
+			if err := r.open(); err != nil {
+				if err := r.waitToRetry(err); err != nil {
+					return 0, err
+				}
+				continue
+			}
+		}
+
+		n, err := r.read(p)
+		r.offset += int64(n)
+		if err == nil || err == io.EOF {
+			return n, err
+		}
+
+		r.closeBody()
+		if n > 0 {
+			// the next Read reopens the body from the new offset
+			return n, nil
+		}
+		if err := r.waitToRetry(err); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (r *retryingReader) Close() error {
+	r.closeBody()
+	return nil
+}
+
+func (r *retryingReader) open() error {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req, err := http.NewRequest("GET", r.url, nil)
+	if err != nil {
+		cancel()
+		return errors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+
+	// Manually set this header so the net/http library does not automatically try to decompress. We
+	// need to handle this manually because it's not currently possible to set the MIME type for the
+	// pre-signed URLs for GCP or Azure.
+	req.Header.Set("Accept-Encoding", "gzip")
+	if r.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", r.offset))
+	}
+
+	timer := time.AfterFunc(r.readTimeout, cancel)
+	resp, err := r.httpClient.Do(req)
+	timedOut := !timer.Stop()
+	if err != nil {
+		cancel()
+		if timedOut {
+			return errors.Errorf("no response received within %s", r.readTimeout)
+		}
+		return errors.WithStack(err)
+	}
+
+	switch {
+	case r.offset == 0 && resp.StatusCode == http.StatusOK:
+	case r.offset > 0 && resp.StatusCode == http.StatusPartialContent:
+	case r.offset > 0 && resp.StatusCode == http.StatusOK:
+		resp.Body.Close()
+		cancel()
+		return errResumeUnsupported
+	default:
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		cancel()
+		if err != nil {
+			return errors.Wrapf(err, "request failed: unable to decode response body")
+		}
+		return &requestError{statusCode: resp.StatusCode, body: string(body)}
+	}
+
+	r.body = resp.Body
+	r.cancel = cancel
+	return nil
+}
+
+// read reads from the current body, cancelling the request if no data arrives
+// within the read timeout.
+func (r *retryingReader) read(p []byte) (int, error) {
+	timer := time.AfterFunc(r.readTimeout, r.cancel)
+	n, err := r.body.Read(p)
+	if !timer.Stop() && err != nil && err != io.EOF {
+		err = errors.Errorf("no data received for %s", r.readTimeout)
+	}
+	return n, err
+}
+
+func (r *retryingReader) closeBody() {
+	if r.body == nil {
+		return
+	}
+	r.body.Close()
+	r.cancel()
+	r.body = nil
+	r.cancel = nil
+}
+
+// waitToRetry waits before the next attempt if err can be retried and there are
+// retries left. Otherwise it returns the error to surface to the caller.
+func (r *retryingReader) waitToRetry(err error) error {
+	if !isRetryable(err) || r.attempts >= r.retries {
+		if r.attempts > 0 {
+			return errors.Wrapf(err, "download failed after %d attempts", r.attempts+1)
+		}
+		return err
+	}
+
+	time.Sleep(r.backoff << uint(r.attempts))
+	r.attempts++
+	return nil
+}
+
+// isRetryable returns whether a download that failed with err might succeed if
+// it's retried.
+func isRetryable(err error) bool {
+	switch e := err.(type) {
+	case *requestError:
+		return e.statusCode >= http.StatusInternalServerError
+	}
+	return err != errResumeUnsupported
+}
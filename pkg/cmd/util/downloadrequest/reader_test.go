@@ -0,0 +1,156 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloadrequest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryingReader(t *testing.T) {
+	const content = "0123456789"
+
+	// offset parses the start of a "bytes=N-" Range header
+	offset := func(req *http.Request) int {
+		rangeHeader := req.Header.Get("Range")
+		if rangeHeader == "" {
+			return 0
+		}
+		start, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rangeHeader, "bytes="), "-"))
+		require.NoError(t, err)
+		return start
+	}
+
+	tests := []struct {
+		name          string
+		retries       int
+		handler       func(attempt int, w http.ResponseWriter, req *http.Request)
+		expected      string
+		expectedError string
+	}{
+		{
+			name:    "successful read",
+			retries: 2,
+			handler: func(attempt int, w http.ResponseWriter, req *http.Request) {
+				fmt.Fprint(w, content)
+			},
+			expected: content,
+		},
+		{
+			name:    "server error is retried",
+			retries: 2,
+			handler: func(attempt int, w http.ResponseWriter, req *http.Request) {
+				if attempt == 0 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				fmt.Fprint(w, content)
+			},
+			expected: content,
+		},
+		{
+			name:    "stalled read resumes from the last byte read",
+			retries: 2,
+			handler: func(attempt int, w http.ResponseWriter, req *http.Request) {
+				if attempt == 0 {
+					w.WriteHeader(http.StatusOK)
+					fmt.Fprint(w, content[:4])
+					w.(http.Flusher).Flush()
+					<-req.Context().Done()
+					return
+				}
+				w.WriteHeader(http.StatusPartialContent)
+				fmt.Fprint(w, content[offset(req):])
+			},
+			expected: content,
+		},
+		{
+			name:    "final error is returned when retries are exhausted",
+			retries: 2,
+			handler: func(attempt int, w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, "some error")
+			},
+			expectedError: "download failed after 3 attempts: request failed: some error",
+		},
+		{
+			name:    "client error isn't retried",
+			retries: 2,
+			handler: func(attempt int, w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, "expired")
+			},
+			expectedError: "request failed: expired",
+		},
+		{
+			name:    "ignored range isn't retried",
+			retries: 2,
+			handler: func(attempt int, w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, content[:4])
+				if attempt == 0 {
+					w.(http.Flusher).Flush()
+					<-req.Context().Done()
+				}
+			},
+			expectedError: "download failed after 2 attempts: unable to resume download: object storage doesn't support range requests",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				attempt := attempts
+				attempts++
+				test.handler(attempt, w, req)
+			}))
+			defer server.Close()
+
+			r := &retryingReader{
+				httpClient:  server.Client(),
+				url:         server.URL,
+				readTimeout: 50 * time.Millisecond,
+				retries:     test.retries,
+				backoff:     time.Millisecond,
+			}
+			defer r.Close()
+
+			err := r.Open()
+			var data []byte
+			if err == nil {
+				data, err = ioutil.ReadAll(r)
+			}
+
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Time is a Cobra-compatible wrapper for defining a flag containing
+// a point in time, specified either as an RFC3339 timestamp or as a
+// duration relative to the current time (e.g. "24h" means 24 hours ago).
+type Time struct {
+	Time time.Time
+}
+
+// String returns the RFC3339 representation of the flag's value,
+// or the empty string if it's not set.
+func (t *Time) String() string {
+	if t.Time.IsZero() {
+		return ""
+	}
+	return t.Time.Format(time.RFC3339)
+}
+
+// Set parses the provided string as an RFC3339 timestamp or, failing
+// that, as a duration before the current time, and assigns the result
+// to the receiver. It returns an error if the string is neither.
+func (t *Time) Set(s string) error {
+	if parsed, err := time.Parse(time.RFC3339, s); err == nil {
+		t.Time = parsed
+		return nil
+	}
+
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.Errorf("%q is not an RFC3339 timestamp or a duration", s)
+	}
+
+	t.Time = time.Now().Add(-duration)
+
+	return nil
+}
+
+// Type returns a string representation of the
+// Time type.
+func (t *Time) Type() string {
+	return "time"
+}
@@ -18,6 +18,7 @@ package output
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/heptio/ark/pkg/apis/ark/v1"
@@ -35,6 +36,9 @@ func DescribeBackup(backup *v1.Backup, deleteRequests []v1.DeleteBackupRequest)
 			phase = v1.BackupPhaseNew
 		}
 		d.Printf("Phase:\t%s\n", phase)
+		if backup.Status.FailureReason != "" {
+			d.Printf("Failure reason:\t%s\n", backup.Status.FailureReason)
+		}
 
 		d.Println()
 		DescribeBackupSpec(d, backup.Spec)
@@ -66,6 +70,7 @@ func DescribeBackupSpec(d *Describer, spec v1.BackupSpec) {
 		s = strings.Join(spec.ExcludedNamespaces, ", ")
 	}
 	d.Printf("\tExcluded:\t%s\n", s)
+	d.Printf("\tTerminating:\t%s\n", BoolPointerString(&spec.IncludeTerminatingNamespaces, "excluded", "included", ""))
 
 	d.Println()
 	d.Printf("Resources:\n")
@@ -83,6 +88,7 @@ func DescribeBackupSpec(d *Describer, spec v1.BackupSpec) {
 	d.Printf("\tExcluded:\t%s\n", s)
 
 	d.Printf("\tCluster-scoped:\t%s\n", BoolPointerString(spec.IncludeClusterResources, "excluded", "included", "auto"))
+	d.Printf("\tService account tokens:\t%s\n", BoolPointerString(&spec.IncludeServiceAccountTokens, "excluded", "included", ""))
 
 	d.Println()
 	s = "<none>"
@@ -91,12 +97,77 @@ func DescribeBackupSpec(d *Describer, spec v1.BackupSpec) {
 	}
 	d.Printf("Label selector:\t%s\n", s)
 
+	if len(spec.ExcludedSecretAnnotations) > 0 || len(spec.ExcludedSecretOwnerKinds) > 0 {
+		d.Println()
+		d.DescribeMap("Excluded secret annotations", spec.ExcludedSecretAnnotations)
+		d.DescribeSlice(0, "Excluded secret owner kinds", spec.ExcludedSecretOwnerKinds)
+	}
+
+	if len(spec.ExcludedOwnerKinds) > 0 {
+		d.Println()
+		d.DescribeSlice(0, "Excluded owner kinds", spec.ExcludedOwnerKinds)
+	}
+
 	d.Println()
 	d.Printf("Snapshot PVs:\t%s\n", BoolPointerString(spec.SnapshotVolumes, "false", "true", "auto"))
+	if spec.VolumeSnapshotSelector != nil {
+		d.Printf("Volume snapshot selector:\t%s\n", metav1.FormatLabelSelector(spec.VolumeSnapshotSelector))
+	}
+	if spec.VolumeGroupLabel != "" {
+		d.Printf("Volume group label:\t%s\n", spec.VolumeGroupLabel)
+	}
 
 	d.Println()
 	d.Printf("TTL:\t%s\n", spec.TTL.Duration)
 
+	if spec.MinItems > 0 {
+		d.Println()
+		d.Printf("Minimum items:\t%d\n", spec.MinItems)
+	}
+
+	if spec.ModifiedSince.Duration > 0 {
+		d.Println()
+		d.Printf("Modified since:\t%s before creation\n", spec.ModifiedSince.Duration)
+	}
+
+	if spec.ResourceRequestTimeout.Duration > 0 {
+		d.Println()
+		d.Printf("Resource request timeout:\t%s\n", spec.ResourceRequestTimeout.Duration)
+	}
+
+	if spec.BaseBackup != "" {
+		d.Println()
+		d.Printf("Base backup:\t%s\n", spec.BaseBackup)
+	}
+
+	if spec.IncludeOwnerReferences {
+		d.Println()
+		d.Printf("Include owner references:\ttrue\n")
+	}
+
+	if spec.RequireIncludeAnnotation {
+		d.Println()
+		d.Printf("Require include annotation:\ttrue\n")
+	}
+
+	if len(spec.ExcludedFields) > 0 {
+		d.Println()
+		d.Printf("Excluded fields:\n")
+		resources := make([]string, 0, len(spec.ExcludedFields))
+		for resource := range spec.ExcludedFields {
+			resources = append(resources, resource)
+		}
+		sort.Strings(resources)
+		for _, resource := range resources {
+			d.Printf("\t%s:\t%s\n", resource, strings.Join(spec.ExcludedFields[resource], ", "))
+		}
+	}
+
+	if spec.CompressionFormat != "" {
+		d.Println()
+		d.Printf("Compression format:\t%s\n", spec.CompressionFormat)
+	}
+
 	d.Println()
 	if len(spec.Hooks.Resources) == 0 {
 		d.Printf("Hooks:\t<none>\n")
@@ -161,9 +232,104 @@ func DescribeBackupSpec(d *Describer, spec v1.BackupSpec) {
 func DescribeBackupStatus(d *Describer, status v1.BackupStatus) {
 	d.Printf("Backup Format Version:\t%d\n", status.Version)
 
+	if status.ClusterID != "" {
+		d.Println()
+		d.Printf("Cluster ID:\t%s\n", status.ClusterID)
+	}
+
+	if !status.StartTimestamp.IsZero() {
+		d.Println()
+		d.Printf("Started:\t%s\n", status.StartTimestamp.Time)
+	}
+
+	if !status.CompletionTimestamp.IsZero() {
+		d.Printf("Completed:\t%s\n", status.CompletionTimestamp.Time)
+	}
+
+	if stages := status.StageDurations; stages != nil {
+		d.Println()
+		d.Printf("Stage durations:\n")
+		d.Printf("\tItem collection:\t%s\n", stages.ItemCollection.Duration)
+		d.Printf("\tVolume snapshots:\t%s\n", stages.VolumeSnapshots.Duration)
+		d.Printf("\tUpload:\t%s\n", stages.Upload.Duration)
+	}
+
 	d.Println()
 	d.Printf("Expiration:\t%s\n", status.Expiration.Time)
 
+	if status.RecycleBin != nil {
+		d.Println()
+		d.Printf("In recycle bin since:\t%s\n", status.RecycleBin.Timestamp.Time)
+		d.Printf("Previous phase:\t%s\n", status.RecycleBin.PreviousPhase)
+		if status.RecycleBin.Reason != "" {
+			d.Printf("Deletion reason:\t%s\n", status.RecycleBin.Reason)
+		}
+	}
+
+	if status.Progress != nil {
+		d.Println()
+		d.Printf("Progress:\t%d of %d items backed up\n", status.Progress.ItemsBackedUp, status.Progress.TotalItems)
+	}
+
+	if status.ContentsSHA256 != "" {
+		d.Println()
+		d.Printf("Contents SHA-256:\t%s\n", status.ContentsSHA256)
+	}
+
+	if status.StorageCleanup != "" {
+		d.Println()
+		d.Printf("Storage Cleanup:\t%s\n", status.StorageCleanup)
+	}
+
+	if len(status.Conditions) > 0 {
+		d.Println()
+		d.Printf("Conditions:\n")
+		for _, condition := range status.Conditions {
+			d.Printf("\t%s:\t%s (since %s)\n", condition.Type, condition.Status, condition.LastTransitionTime.Time)
+			if condition.Message != "" {
+				d.Printf("\t\t%s\n", condition.Message)
+			}
+		}
+	}
+
+	if len(status.BackupChain) > 0 {
+		d.Println()
+		d.DescribeSlice(0, "Backup chain", status.BackupChain)
+	}
+
+	if len(status.IncludedOwners) > 0 {
+		d.Println()
+		d.DescribeSlice(0, "Owners included", status.IncludedOwners)
+	}
+
+	if len(status.HookResults) > 0 {
+		d.Println()
+		d.Printf("Hook results:\n")
+		for _, result := range status.HookResults {
+			outcome := "succeeded"
+			if !result.Succeeded {
+				outcome = "failed: " + result.Error
+			}
+			d.Printf("\t%s (%s), pod %s, container %s:\t%s: %s\n", result.Hook, result.Phase, result.Pod, result.Container, strings.Join(result.Command, " "), outcome)
+		}
+	}
+
+	if len(status.Warnings) > 0 {
+		d.Println()
+		d.Printf("Warnings:\n")
+		for _, warning := range status.Warnings {
+			d.Printf("\t%s\n", warning)
+		}
+	}
+
+	if len(status.Errors) > 0 {
+		d.Println()
+		d.Printf("Errors:\n")
+		for _, err := range status.Errors {
+			d.Printf("\t%s\n", err)
+		}
+	}
+
 	d.Println()
 	d.Printf("Validation errors:")
 	if len(status.ValidationErrors) == 0 {
@@ -182,6 +348,18 @@ func DescribeBackupStatus(d *Describer, status v1.BackupStatus) {
 		for pvName, info := range status.VolumeBackups {
 			d.Printf("\t%s:\n", pvName)
 			d.Printf("\t\tSnapshot ID:\t%s\n", info.SnapshotID)
+			if info.ParentSnapshotID != "" {
+				d.Printf("\t\tParent Snapshot ID:\t%s\n", info.ParentSnapshotID)
+			}
+			if info.SnapshotGroupID != "" {
+				d.Printf("\t\tSnapshot Group ID:\t%s\n", info.SnapshotGroupID)
+			}
+			if info.CSISnapshot != nil {
+				d.Printf("\t\tCSI Driver:\t%s\n", info.CSISnapshot.Driver)
+				d.Printf("\t\tVolumeSnapshot:\t%s/%s\n", info.CSISnapshot.VolumeSnapshotNamespace, info.CSISnapshot.VolumeSnapshotName)
+				d.Printf("\t\tVolumeSnapshotContent:\t%s\n", info.CSISnapshot.VolumeSnapshotContentName)
+				continue
+			}
 			d.Printf("\t\tType:\t%s\n", info.Type)
 			d.Printf("\t\tAvailability Zone:\t%s\n", info.AvailabilityZone)
 			iops := "<N/A>"
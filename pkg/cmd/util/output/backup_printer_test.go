@@ -17,9 +17,13 @@ limitations under the License.
 package output
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -87,3 +91,32 @@ func TestSortBackups(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintBackupsWithLabelColumns(t *testing.T) {
+	cmd := &cobra.Command{}
+	BindFlags(cmd.Flags())
+	require.NoError(t, cmd.Flags().Parse([]string{"-L", "team", "--label-columns", "env,ark.heptio.com/schedule-name"}))
+
+	printer, err := NewPrinter(cmd)
+	require.NoError(t, err)
+	printer.Handler(backupColumns, nil, printBackupList)
+
+	list := &v1.BackupList{Items: []v1.Backup{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{"team": "storage", "env": "prod"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: map[string]string{"ark.heptio.com/schedule-name": "daily"}}},
+	}}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, printer.PrintObj(list, buf))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	lastFields := func(line string, n int) []string {
+		fields := strings.Fields(line)
+		return fields[len(fields)-n:]
+	}
+	assert.Equal(t, []string{"SELECTOR", "TEAM", "ENV", "SCHEDULE-NAME"}, lastFields(lines[0], 4))
+	assert.Equal(t, []string{"storage", "prod", "<none>"}, lastFields(lines[1], 3))
+	assert.Equal(t, []string{"<none>", "<none>", "daily"}, lastFields(lines[2], 3))
+}
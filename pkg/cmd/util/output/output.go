@@ -35,9 +35,8 @@ import (
 // BindFlags defines a set of output-specific flags within the provided
 // FlagSet.
 func BindFlags(flags *pflag.FlagSet) {
-	flags.StringP("output", "o", "table", "Output display format. For create commands, display the object but do not send it to the server. Valid formats are 'table', 'json', and 'yaml'.")
-	labelColumns := flag.NewStringArray()
-	flags.Var(&labelColumns, "label-columns", "a comma-separated list of labels to be displayed as columns")
+	flags.StringP("output", "o", "table", "Output display format. For create commands, display the object but do not send it to the server, except with 'name', which creates the object and prints only its name. Valid formats are 'table', 'json', 'yaml', and 'name'.")
+	flags.StringSliceP("label-columns", "L", nil, "a comma-separated list of labels to be displayed as columns; can be specified more than once, e.g. -L team -L env")
 	flags.Bool("show-labels", false, "show labels in the last column")
 }
 
@@ -58,10 +57,21 @@ func GetOutputFlagValue(cmd *cobra.Command) string {
 	return flag.GetOptionalStringFlag(cmd, "output")
 }
 
+// IsNameOutput returns whether the "output" flag in the provided command
+// requests only the names of objects. Create commands send the object to the
+// server and print only its name, rather than just displaying it.
+func IsNameOutput(cmd *cobra.Command) bool {
+	return GetOutputFlagValue(cmd) == "name"
+}
+
 // GetLabelColumnsValues returns the value of the "label-columns" flag
 // in the provided command, or the zero value if not present.
 func GetLabelColumnsValues(cmd *cobra.Command) []string {
-	return flag.GetOptionalStringArrayFlag(cmd, "label-columns")
+	labelColumns, err := cmd.Flags().GetStringSlice("label-columns")
+	if err != nil {
+		return nil
+	}
+	return labelColumns
 }
 
 // GetShowLabelsValue returns the value of the "show-labels" flag
@@ -82,9 +92,9 @@ func ValidateFlags(cmd *cobra.Command) error {
 func validateOutputFlag(cmd *cobra.Command) error {
 	output := GetOutputFlagValue(cmd)
 	switch output {
-	case "", "table", "json", "yaml":
+	case "", "table", "json", "yaml", "name":
 	default:
-		return errors.Errorf("invalid output format %q - valid values are 'table', 'json', and 'yaml'", output)
+		return errors.Errorf("invalid output format %q - valid values are 'table', 'json', 'yaml', and 'name'", output)
 	}
 	return nil
 }
@@ -102,9 +112,33 @@ func PrintWithFormat(c *cobra.Command, obj runtime.Object) (bool, error) {
 		return printTable(c, obj)
 	case "json", "yaml":
 		return printEncoded(obj, format)
+	case "name":
+		return printNames(obj)
+	}
+
+	return false, errors.Errorf("unsupported output format %q; valid values are 'table', 'json', 'yaml', and 'name'", format)
+}
+
+// printNames prints the name of obj, or of each item if obj is a list, one per line.
+func printNames(obj runtime.Object) (bool, error) {
+	objs := []runtime.Object{obj}
+	if meta.IsListType(obj) {
+		list, err := meta.ExtractList(obj)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		objs = list
 	}
 
-	return false, errors.Errorf("unsupported output format %q; valid values are 'table', 'json', and 'yaml'", format)
+	for _, item := range objs {
+		metadata, err := meta.Accessor(item)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		fmt.Println(metadata.GetName())
+	}
+
+	return true, nil
 }
 
 func printEncoded(obj runtime.Object, format string) (bool, error) {
@@ -78,12 +78,171 @@ func DescribeRestore(restore *v1.Restore, arkClient clientset.Interface) string
 		}
 		d.Printf("Label selector:\t%s\n", s)
 
+		if restore.Spec.NamespaceSelector != nil {
+			d.Println()
+			d.Printf("Namespace selector:\t%s\n", metav1.FormatLabelSelector(restore.Spec.NamespaceSelector))
+		}
+
 		d.Println()
 		d.Printf("Restore PVs:\t%s\n", BoolPointerString(restore.Spec.RestorePVs, "false", "true", "auto"))
 
+		d.Println()
+		d.Printf("Restore webhooks last:\t%s\n", BoolPointerString(restore.Spec.RestoreWebhooksLast, "false", "true", "true"))
+
+		d.Println()
+		d.Printf("Label restored items:\t%s\n", BoolPointerString(restore.Spec.LabelRestoredItems, "false", "true", "true"))
+
+		if len(restore.Spec.AddedLabels) > 0 {
+			d.Println()
+			d.DescribeMap("Added labels", restore.Spec.AddedLabels)
+		}
+
+		if len(restore.Spec.AddedAnnotations) > 0 {
+			d.Println()
+			d.DescribeMap("Added annotations", restore.Spec.AddedAnnotations)
+		}
+
+		if restore.Spec.ScaleToZero {
+			d.Println()
+			d.Printf("Scale to zero:\ttrue\n")
+		}
+
+		if factor := restore.Spec.ContainerResourcesFactor; factor != nil {
+			d.Println()
+			d.Printf("Container resources factor:\t%v\n", *factor)
+		}
+
+		if len(restore.Spec.ImageRegistryMapping) > 0 {
+			d.Println()
+			d.DescribeMap("Image registry mappings", restore.Spec.ImageRegistryMapping)
+		}
+
+		if len(restore.Spec.ResourceRenames) > 0 {
+			d.Println()
+			d.DescribeMap("Resource renames", restore.Spec.ResourceRenames)
+		}
+
+		if restore.Spec.DefaultStorageClass != "" {
+			d.Println()
+			d.Printf("Default storage class:\t%s\n", restore.Spec.DefaultStorageClass)
+		}
+
+		if restore.Spec.BatchSize > 0 {
+			d.Println()
+			d.Printf("Batch size:\t%d\n", restore.Spec.BatchSize)
+		}
+
+		if restore.Spec.LatestRevisionsOnly {
+			d.Println()
+			d.Printf("Latest revisions only:\ttrue\n")
+		}
+
+		if restore.Spec.Verify {
+			d.Println()
+			d.Printf("Verify:\ttrue\n")
+		}
+
+		if restore.Spec.Prune {
+			d.Println()
+			d.Printf("Prune:\ttrue\n")
+		}
+
+		if restore.Spec.AllowClusterMismatch {
+			d.Println()
+			d.Printf("Allow cluster mismatch:\ttrue\n")
+		}
+
+		d.Println()
+		applyMethod := restore.Spec.ApplyMethod
+		if applyMethod == "" {
+			applyMethod = v1.RestoreApplyMethodCreate
+		}
+		d.Printf("Apply method:\t%s\n", applyMethod)
+		if applyMethod == v1.RestoreApplyMethodServerSideApply {
+			applyConflictPolicy := restore.Spec.ApplyConflictPolicy
+			if applyConflictPolicy == "" {
+				applyConflictPolicy = v1.ApplyConflictPolicySkip
+			}
+			d.Printf("Apply conflict policy:\t%s\n", applyConflictPolicy)
+
+			fieldManager := restore.Spec.FieldManager
+			if fieldManager == "" {
+				fieldManager = "ark-restore"
+			}
+			d.Printf("Field manager:\t%s\n", fieldManager)
+		}
+
+		existingNamespacePolicy := restore.Spec.ExistingNamespacePolicy
+		if existingNamespacePolicy == "" {
+			existingNamespacePolicy = v1.ExistingNamespacePolicyLeave
+		}
+		d.Println()
+		d.Printf("Existing namespace policy:\t%s\n", existingNamespacePolicy)
+
+		missingCRDPolicy := restore.Spec.MissingCRDPolicy
+		if missingCRDPolicy == "" {
+			missingCRDPolicy = v1.MissingCRDPolicySkip
+		}
+		d.Println()
+		d.Printf("Missing CRD policy:\t%s\n", missingCRDPolicy)
+
+		if restore.Spec.PreserveUIDs {
+			d.Println()
+			d.Printf("Preserve UIDs:\ttrue\n")
+		}
+
+		if restore.Spec.SkipLiveOwnedItems {
+			d.Println()
+			d.Printf("Skip live-owned items:\ttrue\n")
+		}
+
+		d.Println()
+		mergeStrategies := make(map[string]string)
+		for resource, strategy := range restore.Spec.MergeStrategies {
+			mergeStrategies[resource] = string(strategy)
+		}
+		d.DescribeMap("Merge strategies", mergeStrategies)
+
+		d.Println()
+		describeResourceModifiers(d, restore.Spec.ResourceModifiers)
+
 		d.Println()
 		d.Printf("Phase:\t%s\n", restore.Status.Phase)
 
+		if progress := restore.Status.Progress; progress != nil {
+			d.Println()
+			d.Printf("Progress:\t%d of %d items restored\n", progress.ItemsRestored, progress.TotalItems)
+			if progress.SkippedNamespaces > 0 {
+				d.Printf("Skipped namespaces:\t%d (not matching the namespace selector)\n", progress.SkippedNamespaces)
+			}
+		}
+
+		if checkpoint := restore.Status.Checkpoint; checkpoint != nil {
+			d.Println()
+			item := checkpoint.Name
+			if checkpoint.Namespace != "" {
+				item = checkpoint.Namespace + "/" + item
+			}
+			d.Printf("Checkpoint:\t%s %s (%d items restored)\n", checkpoint.Resource, item, checkpoint.ItemsRestored)
+		}
+
+		if len(restore.Status.RestoredVolumes) > 0 {
+			d.Println()
+			d.DescribeMap("Restored volumes", restore.Status.RestoredVolumes)
+		}
+
+		if len(restore.Status.UIDMappings) > 0 {
+			d.Println()
+			d.Printf("Restored with new UIDs:\n")
+			for _, mapping := range restore.Status.UIDMappings {
+				item := mapping.Name
+				if mapping.Namespace != "" {
+					item = mapping.Namespace + "/" + item
+				}
+				d.Printf("\t%s %s:\t%s -> %s\n", mapping.Resource, item, mapping.OriginalUID, mapping.UID)
+			}
+		}
+
 		d.Println()
 		d.Printf("Validation errors:")
 		if len(restore.Status.ValidationErrors) == 0 {
@@ -99,6 +258,45 @@ func DescribeRestore(restore *v1.Restore, arkClient clientset.Interface) string
 	})
 }
 
+func describeResourceModifiers(d *Describer, modifiers []v1.ResourceModifier) {
+	if len(modifiers) == 0 {
+		d.Printf("Resource modifiers:\t<none>\n")
+		return
+	}
+
+	d.Printf("Resource modifiers:\n")
+	for i, modifier := range modifiers {
+		resources := "*"
+		if len(modifier.Resources) > 0 {
+			resources = strings.Join(modifier.Resources, ", ")
+		}
+		namespaces := "*"
+		if len(modifier.Namespaces) > 0 {
+			namespaces = strings.Join(modifier.Namespaces, ", ")
+		}
+		selector := "<none>"
+		if modifier.LabelSelector != nil {
+			selector = metav1.FormatLabelSelector(modifier.LabelSelector)
+		}
+
+		d.Printf("\t%d:\n", i)
+		d.Printf("\t\tResources:\t%s\n", resources)
+		d.Printf("\t\tNamespaces:\t%s\n", namespaces)
+		d.Printf("\t\tLabel selector:\t%s\n", selector)
+		d.Printf("\t\tPatches:\n")
+		for _, patch := range modifier.Patches {
+			d.Printf("\t\t\t%s %s", patch.Op, patch.Path)
+			if patch.From != "" {
+				d.Printf(" from %s", patch.From)
+			}
+			if patch.Value != "" {
+				d.Printf(" %s", patch.Value)
+			}
+			d.Println()
+		}
+	}
+}
+
 func describeRestoreResults(d *Describer, restore *v1.Restore, arkClient clientset.Interface) {
 	if restore.Status.Warnings == 0 && restore.Status.Errors == 0 {
 		d.Printf("Warnings:\t<none>\nErrors:\t<none>\n")
@@ -0,0 +1,151 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serverstatus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	core "k8s.io/client-go/testing"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+)
+
+func TestGetServerStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		timeout       time.Duration
+		createError   error
+		watchError    error
+		watchEvents   []watch.Event
+		process       bool
+		expectedError string
+	}{
+		{
+			name:          "error creating req",
+			createError:   errors.New("foo"),
+			expectedError: "foo",
+		},
+		{
+			name:          "error creating watch",
+			watchError:    errors.New("bar"),
+			expectedError: "bar",
+		},
+		{
+			name:          "timed out",
+			timeout:       time.Millisecond,
+			expectedError: "timed out waiting for server status request to be processed",
+		},
+		{
+			name:          "unexpected watch type",
+			watchEvents:   []watch.Event{{Type: watch.Added, Object: &v1.Backup{}}},
+			expectedError: "unexpected type *v1.Backup",
+		},
+		{
+			name: "request deleted",
+			watchEvents: []watch.Event{
+				{Type: watch.Deleted, Object: &v1.ServerStatusRequest{}},
+			},
+			expectedError: "server status request was unexpectedly deleted",
+		},
+		{
+			name: "other requests and unprocessed updates are ignored",
+			watchEvents: []watch.Event{
+				{Type: watch.Modified, Object: newProcessedRequest("other")},
+				{Type: watch.Modified, Object: &v1.ServerStatusRequest{}},
+			},
+			process: true,
+		},
+	}
+
+	const testTimeout = 30 * time.Second
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+
+			client.PrependReactor("create", "serverstatusrequests", func(action core.Action) (bool, runtime.Object, error) {
+				return true, action.(core.CreateAction).GetObject(), test.createError
+			})
+
+			fakeWatch := watch.NewFake()
+			client.PrependWatchReactor("serverstatusrequests", core.DefaultWatchReactor(fakeWatch, test.watchError))
+
+			deleted := make(chan struct{}, 1)
+			client.PrependReactor("delete", "serverstatusrequests", func(action core.Action) (bool, runtime.Object, error) {
+				deleted <- struct{}{}
+				return true, nil, nil
+			})
+
+			timeout := test.timeout
+			if timeout == 0 {
+				timeout = testTimeout
+			}
+
+			type result struct {
+				req *v1.ServerStatusRequest
+				err error
+			}
+			resultCh := make(chan result)
+			go func() {
+				req, err := GetServerStatus(client.ArkV1(), "namespace", timeout)
+				resultCh <- result{req, err}
+			}()
+
+			for _, e := range test.watchEvents {
+				fakeWatch.Action(e.Type, e.Object)
+			}
+			if test.process {
+				fakeWatch.Modify(newProcessedRequest(""))
+			}
+
+			var res result
+			select {
+			case res = <-resultCh:
+			case <-time.After(testTimeout):
+				t.Fatal("test timed out")
+			}
+
+			if test.expectedError != "" {
+				require.EqualError(t, res.err, test.expectedError)
+				return
+			}
+			require.NoError(t, res.err)
+			assert.Equal(t, "v1.2.3", res.req.Status.ServerVersion)
+
+			select {
+			case <-deleted:
+			case <-time.After(testTimeout):
+				t.Fatal("request was not deleted")
+			}
+		})
+	}
+}
+
+func newProcessedRequest(name string) *v1.ServerStatusRequest {
+	req := &v1.ServerStatusRequest{}
+	req.Name = name
+	req.Status.Phase = v1.ServerStatusRequestPhaseProcessed
+	req.Status.ServerVersion = "v1.2.3"
+	return req
+}
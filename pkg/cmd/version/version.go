@@ -18,22 +18,52 @@ package version
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/heptio/ark/pkg/backup"
 	"github.com/heptio/ark/pkg/buildinfo"
+	"github.com/heptio/ark/pkg/client"
+	"github.com/heptio/ark/pkg/cmd"
+	"github.com/heptio/ark/pkg/cmd/util/serverstatus"
 )
 
-func NewCommand() *cobra.Command {
+func NewCommand(f client.Factory) *cobra.Command {
+	clientOnly := false
+	timeout := 5 * time.Second
+
 	c := &cobra.Command{
 		Use:   "version",
 		Short: "Print the ark version and associated image",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Printf("Version: %s\n", buildinfo.Version)
-			fmt.Printf("Git commit: %s\n", buildinfo.GitSHA)
-			fmt.Printf("Git tree state: %s\n", buildinfo.GitTreeState)
+		Long:  "Print the version of the ark client and, unless --client-only is set, of the ark server it's connected to",
+		Run: func(c *cobra.Command, args []string) {
+			fmt.Println("Client:")
+			fmt.Printf("\tVersion: %s\n", buildinfo.Version)
+			fmt.Printf("\tGit commit: %s\n", buildinfo.GitSHA)
+			fmt.Printf("\tGit tree state: %s\n", buildinfo.GitTreeState)
+			fmt.Printf("\tBackup format version: %d\n", backup.FormatVersion)
+
+			if clientOnly {
+				return
+			}
+
+			arkClient, err := f.Client()
+			cmd.CheckError(err)
+
+			status, err := serverstatus.GetServerStatus(arkClient.ArkV1(), f.Namespace(), timeout)
+			cmd.CheckError(err)
+
+			fmt.Println()
+			fmt.Println("Server:")
+			fmt.Printf("\tVersion: %s\n", status.Status.ServerVersion)
+			fmt.Printf("\tGit commit: %s\n", status.Status.ServerGitCommit)
+			fmt.Printf("\tBackup format version: %d\n", status.Status.BackupFormatVersion)
 		},
 	}
 
+	c.Flags().BoolVar(&clientOnly, "client-only", clientOnly, "only print the client version, without contacting the server")
+	c.Flags().DurationVar(&timeout, "timeout", timeout, "how long to wait for the server to report its version")
+
 	return c
 }
@@ -0,0 +1,174 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	pkgbackup "github.com/heptio/ark/pkg/backup"
+	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions/ark/v1"
+	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
+	"github.com/heptio/ark/pkg/metrics"
+)
+
+// backupCapacityController creates DeleteBackupRequests for the oldest completed or partially
+// failed backups whenever there are more backups than the configured maximum.
+type backupCapacityController struct {
+	*genericController
+
+	namespace                 string
+	maxBackups                int
+	backupLister              listers.BackupLister
+	deleteBackupRequestLister listers.DeleteBackupRequestLister
+	deleteBackupRequestClient arkv1client.DeleteBackupRequestsGetter
+}
+
+// NewBackupCapacityController constructs a new backupCapacityController that keeps the
+// number of backups in namespace (or in all namespaces, if it's empty) at or below
+// maxBackups.
+func NewBackupCapacityController(
+	logger logrus.FieldLogger,
+	namespace string,
+	maxBackups int,
+	backupInformer informers.BackupInformer,
+	deleteBackupRequestInformer informers.DeleteBackupRequestInformer,
+	deleteBackupRequestClient arkv1client.DeleteBackupRequestsGetter,
+	syncPeriod time.Duration,
+	metrics *metrics.ServerMetrics,
+) Interface {
+	if syncPeriod < time.Minute {
+		logger.WithField("syncPeriod", syncPeriod).Info("Provided backup capacity sync period is too short. Setting to 1 minute")
+		syncPeriod = time.Minute
+	}
+
+	c := &backupCapacityController{
+		genericController:         newGenericController("backup-capacity", logger),
+		namespace:                 namespace,
+		maxBackups:                maxBackups,
+		backupLister:              backupInformer.Lister(),
+		deleteBackupRequestLister: deleteBackupRequestInformer.Lister(),
+		deleteBackupRequestClient: deleteBackupRequestClient,
+	}
+
+	c.syncHandler = c.processQueueItem
+	c.metrics = metrics
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters,
+		backupInformer.Informer().HasSynced,
+		deleteBackupRequestInformer.Informer().HasSynced,
+	)
+
+	c.resyncPeriod = syncPeriod
+	c.resyncFunc = c.enqueueNamespace
+
+	// capacity is enforced for the namespace as a whole, so every new backup
+	// enqueues the same key
+	backupInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(_ interface{}) { c.enqueueNamespace() },
+		},
+	)
+
+	return c
+}
+
+func (c *backupCapacityController) enqueueNamespace() {
+	c.queue.Add(c.namespace)
+}
+
+func (c *backupCapacityController) processQueueItem(namespace string) error {
+	log := c.logger.WithField("maxBackups", c.maxBackups)
+
+	backups, err := c.backupLister.Backups(namespace).List(labels.Everything())
+	if err != nil {
+		return errors.Wrap(err, "error listing backups")
+	}
+
+	var (
+		count      int
+		candidates []*api.Backup
+	)
+	for _, backup := range backups {
+		pending, err := c.isBeingDeleted(backup)
+		if err != nil {
+			return err
+		}
+		if pending {
+			continue
+		}
+		count++
+
+		if hasUsableContents(backup) && backup.Annotations[api.ProtectedBackupAnnotation] != "true" {
+			candidates = append(candidates, backup)
+		}
+	}
+
+	if count <= c.maxBackups {
+		return nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return completionTime(candidates[i]).Before(completionTime(candidates[j]))
+	})
+
+	excess := count - c.maxBackups
+	if excess > len(candidates) {
+		log.WithField("backups", count).Warn("Too many backups, but not enough of them can be deleted to get under the maximum")
+		excess = len(candidates)
+	}
+
+	for _, backup := range candidates[:excess] {
+		log.WithField("backup", backup.Namespace+"/"+backup.Name).Info("Maximum number of backups exceeded. Creating a DeleteBackupRequest for the oldest backup.")
+
+		req := pkgbackup.NewDeleteBackupRequest(backup.Name, string(backup.UID))
+		if _, err := c.deleteBackupRequestClient.DeleteBackupRequests(backup.Namespace).Create(req); err != nil {
+			return errors.Wrap(err, "error creating DeleteBackupRequest")
+		}
+	}
+
+	return nil
+}
+
+// isBeingDeleted returns true if backup is being deleted, or has an unprocessed
+// DeleteBackupRequest, so it shouldn't count towards the maximum.
+func (c *backupCapacityController) isBeingDeleted(backup *api.Backup) (bool, error) {
+	if backup.Status.Phase == api.BackupPhaseDeleting || backup.DeletionTimestamp != nil {
+		return true, nil
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{api.BackupNameLabel: backup.Name})
+	reqs, err := c.deleteBackupRequestLister.DeleteBackupRequests(backup.Namespace).List(selector)
+	if err != nil {
+		return false, errors.Wrap(err, "error listing DeleteBackupRequests")
+	}
+
+	for _, req := range reqs {
+		if req.Status.Phase != api.DeleteBackupRequestPhaseProcessed {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
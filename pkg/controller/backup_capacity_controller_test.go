@@ -0,0 +1,174 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	core "k8s.io/client-go/testing"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	pkgbackup "github.com/heptio/ark/pkg/backup"
+	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	"github.com/heptio/ark/pkg/metrics"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestBackupCapacityControllerProcessQueueItem(t *testing.T) {
+	now := time.Now()
+
+	newBackup := func(name string, age time.Duration) *arktest.TestBackup {
+		return arktest.NewTestBackup().WithNamespace(api.DefaultNamespace).WithName(name).
+			WithPhase(api.BackupPhaseCompleted).
+			WithCreationTimestamp(now.Add(-age))
+	}
+
+	processedReq := pkgbackup.NewDeleteBackupRequest("backup-3", "")
+	processedReq.Namespace = api.DefaultNamespace
+	processedReq.Status.Phase = api.DeleteBackupRequestPhaseProcessed
+
+	pendingReq := pkgbackup.NewDeleteBackupRequest("backup-3", "")
+	pendingReq.Namespace = api.DefaultNamespace
+
+	tests := []struct {
+		name              string
+		maxBackups        int
+		backups           []*api.Backup
+		deleteRequests    []*api.DeleteBackupRequest
+		expectedDeletions []string
+	}{
+		{
+			name:       "under the maximum",
+			maxBackups: 3,
+			backups: []*api.Backup{
+				newBackup("backup-1", 1*time.Hour).Backup,
+				newBackup("backup-2", 2*time.Hour).Backup,
+			},
+		},
+		{
+			name:       "oldest completed backups are deleted",
+			maxBackups: 2,
+			backups: []*api.Backup{
+				newBackup("backup-1", 1*time.Hour).Backup,
+				newBackup("backup-2", 4*time.Hour).Backup,
+				newBackup("backup-3", 3*time.Hour).Backup,
+				newBackup("backup-4", 2*time.Hour).Backup,
+				newBackup("backup-5", 5*time.Hour).WithPhase(api.BackupPhaseFailed).Backup,
+			},
+			expectedDeletions: []string{"backup-2", "backup-3", "backup-4"},
+		},
+		{
+			name:       "oldest backups are the ones that completed first",
+			maxBackups: 1,
+			backups: []*api.Backup{
+				newBackup("backup-1", 3*time.Hour).WithCompletionTimestamp(now.Add(-1 * time.Hour)).Backup,
+				newBackup("backup-2", 2*time.Hour).WithCompletionTimestamp(now.Add(-90 * time.Minute)).Backup,
+			},
+			expectedDeletions: []string{"backup-2"},
+		},
+		{
+			name:       "partially failed backups are deleted like completed ones",
+			maxBackups: 1,
+			backups: []*api.Backup{
+				newBackup("backup-1", 1*time.Hour).Backup,
+				newBackup("backup-2", 2*time.Hour).WithPhase(api.BackupPhasePartiallyFailed).Backup,
+			},
+			expectedDeletions: []string{"backup-2"},
+		},
+		{
+			name:       "protected backups are exempt",
+			maxBackups: 1,
+			backups: []*api.Backup{
+				newBackup("backup-1", 1*time.Hour).Backup,
+				newBackup("backup-2", 2*time.Hour).WithAnnotation(api.ProtectedBackupAnnotation, "true").Backup,
+			},
+			expectedDeletions: []string{"backup-1"},
+		},
+		{
+			name:       "backups being deleted don't count",
+			maxBackups: 2,
+			backups: []*api.Backup{
+				newBackup("backup-1", 1*time.Hour).Backup,
+				newBackup("backup-2", 2*time.Hour).Backup,
+				newBackup("backup-3", 3*time.Hour).Backup,
+				newBackup("backup-4", 4*time.Hour).WithPhase(api.BackupPhaseDeleting).Backup,
+			},
+			deleteRequests: []*api.DeleteBackupRequest{pendingReq},
+		},
+		{
+			name:       "processed delete requests are ignored",
+			maxBackups: 2,
+			backups: []*api.Backup{
+				newBackup("backup-1", 1*time.Hour).Backup,
+				newBackup("backup-2", 2*time.Hour).Backup,
+				newBackup("backup-3", 3*time.Hour).Backup,
+			},
+			deleteRequests:    []*api.DeleteBackupRequest{processedReq},
+			expectedDeletions: []string{"backup-3"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+			)
+
+			controller := NewBackupCapacityController(
+				arktest.NewLogger(),
+				api.DefaultNamespace,
+				test.maxBackups,
+				sharedInformers.Ark().V1().Backups(),
+				sharedInformers.Ark().V1().DeleteBackupRequests(),
+				client.ArkV1(),
+				time.Minute,
+				metrics.NewServerMetrics(),
+			).(*backupCapacityController)
+
+			for _, backup := range test.backups {
+				require.NoError(t, sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(backup))
+			}
+			for _, req := range test.deleteRequests {
+				require.NoError(t, sharedInformers.Ark().V1().DeleteBackupRequests().Informer().GetStore().Add(req))
+			}
+
+			// the fake clientset doesn't generate names, so don't store the requests
+			client.PrependReactor("create", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+				return true, action.(core.CreateAction).GetObject(), nil
+			})
+
+			require.NoError(t, controller.processQueueItem(api.DefaultNamespace))
+
+			var deleted []string
+			for _, action := range client.Actions() {
+				createAction, ok := action.(core.CreateAction)
+				require.True(t, ok)
+				deleted = append(deleted, createAction.GetObject().(*api.DeleteBackupRequest).Spec.BackupName)
+			}
+			sort.Strings(deleted)
+			assert.Equal(t, test.expectedDeletions, deleted)
+		})
+	}
+}
@@ -19,21 +19,29 @@ package controller
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/clock"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
@@ -45,19 +53,37 @@ import (
 	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
 	informers "github.com/heptio/ark/pkg/generated/informers/externalversions/ark/v1"
 	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
+	"github.com/heptio/ark/pkg/metrics"
 	"github.com/heptio/ark/pkg/plugin"
 	"github.com/heptio/ark/pkg/util/collections"
+	"github.com/heptio/ark/pkg/util/compression"
 	"github.com/heptio/ark/pkg/util/encode"
 	kubeutil "github.com/heptio/ark/pkg/util/kube"
 )
 
-const backupVersion = 1
+// backupFormatVersion returns the format version recorded in new backups. It's aliased
+// here because processBackup's backup variable shadows the backup package.
+var backupFormatVersion = backup.FormatVersionFor
+
+// BackupStorageMirror is a bucket, in addition to the backup storage provider's, that
+// backups are uploaded to and deleted from.
+type BackupStorageMirror struct {
+	BackupService cloudprovider.BackupService
+	Bucket        string
+}
 
 type backupController struct {
-	backupper        backup.Backupper
-	backupService    cloudprovider.BackupService
-	bucket           string
+	backupper     backup.Backupper
+	backupService cloudprovider.BackupService
+	bucket        string
+	// deduplicate is whether the backup service stores backups' contents deduplicated,
+	// which determines their format version
+	deduplicate      bool
+	mirrors          []BackupStorageMirror
+	storageQuorum    int
+	stream           *BackupStream
 	pvProviderExists bool
+	defaultTTL       time.Duration
 	lister           listers.BackupLister
 	listerSynced     cache.InformerSynced
 	client           arkv1client.BackupsGetter
@@ -67,6 +93,30 @@ type backupController struct {
 	logger           logrus.FieldLogger
 	pluginManager    plugin.Manager
 	backupTracker    BackupTracker
+	metrics          *metrics.ServerMetrics
+
+	// shutdownGracePeriod is how long in-progress backups are given to finish
+	// when the controller is stopped, before they're marked as failed.
+	shutdownGracePeriod time.Duration
+
+	// staleBackupTimeout is how long a backup can be in progress without being
+	// run by one of this controller's workers before it's marked as failed.
+	staleBackupTimeout time.Duration
+
+	// clusterID is recorded in each backup as the cluster it was taken from.
+	clusterID string
+
+	// defaultExcludedResources are excluded from every backup that doesn't
+	// explicitly include them.
+	defaultExcludedResources []string
+
+	// minAvailableStorage is the number of bytes that must be available in the
+	// bucket for a backup to start, if the object store reports it.
+	minAvailableStorage int64
+
+	// inProgress holds the backups being run by workers, as last patched, by key.
+	inProgressLock sync.Mutex
+	inProgress     map[string]*api.Backup
 }
 
 func NewBackupController(
@@ -75,24 +125,48 @@ func NewBackupController(
 	backupper backup.Backupper,
 	backupService cloudprovider.BackupService,
 	bucket string,
+	deduplicateContents bool,
+	mirrors []BackupStorageMirror,
+	storageQuorum int,
+	minAvailableStorage int64,
+	stream *BackupStream,
 	pvProviderExists bool,
+	defaultTTL time.Duration,
+	shutdownGracePeriod time.Duration,
+	staleBackupTimeout time.Duration,
+	clusterID string,
+	defaultExcludedResources []string,
 	logger logrus.FieldLogger,
 	pluginManager plugin.Manager,
 	backupTracker BackupTracker,
+	metrics *metrics.ServerMetrics,
 ) Interface {
 	c := &backupController{
 		backupper:        backupper,
 		backupService:    backupService,
 		bucket:           bucket,
+		deduplicate:      deduplicateContents,
+		mirrors:          mirrors,
+		storageQuorum:    storageQuorumOrAll(storageQuorum, len(mirrors)+1),
+		stream:           stream,
 		pvProviderExists: pvProviderExists,
+		defaultTTL:       defaultTTL,
 		lister:           backupInformer.Lister(),
 		listerSynced:     backupInformer.Informer().HasSynced,
 		client:           client,
 		queue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "backup"),
 		clock:            &clock.RealClock{},
-		logger:           logger,
+		logger:           logger.WithField("controller", "backup"),
 		pluginManager:    pluginManager,
 		backupTracker:    backupTracker,
+		metrics:          metrics,
+
+		shutdownGracePeriod:      shutdownGracePeriod,
+		staleBackupTimeout:       staleBackupTimeout,
+		clusterID:                clusterID,
+		defaultExcludedResources: defaultExcludedResources,
+		minAvailableStorage:      minAvailableStorage,
+		inProgress:               make(map[string]*api.Backup),
 	}
 
 	c.syncHandler = c.processBackup
@@ -127,8 +201,11 @@ func NewBackupController(
 }
 
 // Run is a blocking function that runs the specified number of worker goroutines
-// to process items in the work queue. It will return when it receives on the
-// ctx.Done() channel.
+// to process items in the work queue. Each worker runs one backup at a time, so
+// numWorkers is the maximum number of concurrent backups. When it receives on the
+// ctx.Done() channel, it stops starting new backups and waits for the workers to
+// finish the ones in progress. Backups that don't finish within the shutdown grace
+// period are marked as failed before it returns.
 func (controller *backupController) Run(ctx context.Context, numWorkers int) error {
 	var wg sync.WaitGroup
 
@@ -140,10 +217,13 @@ func (controller *backupController) Run(ctx context.Context, numWorkers int) err
 		// We have to wait here in the deferred function instead of at the bottom of the function body
 		// because we have to shut down the queue in order for the workers to shut down gracefully, and
 		// we want to shut down the queue via defer and not at the end of the body.
-		wg.Wait()
+		if !waitForWorkers(&wg, controller.shutdownGracePeriod) {
+			controller.logger.WithField("shutdownGracePeriod", controller.shutdownGracePeriod).Warn("Timed out waiting for workers to finish, marking in-progress backups as failed")
+			controller.failInProgressBackups()
+			return
+		}
 
 		controller.logger.Info("All workers have finished")
-
 	}()
 
 	controller.logger.Info("Starting BackupController")
@@ -163,6 +243,14 @@ func (controller *backupController) Run(ctx context.Context, numWorkers int) err
 		}()
 	}
 
+	// the first check runs right away, to fail backups left in progress by a
+	// server that crashed
+	wg.Add(1)
+	go func() {
+		wait.Until(controller.failStaleBackups, time.Minute, ctx.Done())
+		wg.Done()
+	}()
+
 	<-ctx.Done()
 
 	return nil
@@ -184,6 +272,12 @@ func (controller *backupController) processNextWorkItem() bool {
 	// it back with rate-limiting below
 	defer controller.queue.Done(key)
 
+	// a queue that's been shut down still hands out the items added to it
+	// before, so don't start on them; they're left for the next server
+	if controller.queue.ShuttingDown() {
+		return false
+	}
+
 	err := controller.syncHandler(key.(string))
 	if err == nil {
 		// If you had no error, tell the queue to stop tracking history for your key. This will reset
@@ -237,18 +331,43 @@ func (controller *backupController) processBackup(key string) error {
 	backup = backup.DeepCopy()
 
 	// set backup version
-	backup.Status.Version = backupVersion
+	backup.Status.Version = backupFormatVersion(backup.Spec.CompressionFormat, controller.deduplicate)
+	backup.Status.ClusterID = controller.clusterID
 
-	// calculate expiration
-	if backup.Spec.TTL.Duration > 0 {
-		backup.Status.Expiration = metav1.NewTime(controller.clock.Now().Add(backup.Spec.TTL.Duration))
+	// an empty list of included namespaces means all namespaces, so record
+	// that explicitly in the spec
+	if len(backup.Spec.IncludedNamespaces) == 0 {
+		backup.Spec.IncludedNamespaces = []string{"*"}
+	}
+
+	// merge the server's default excluded resources into the backup's own excludes, so
+	// the backup records what it actually excluded
+	backup.Spec.ExcludedResources = mergeExcludedResources(backup.Spec.IncludedResources, backup.Spec.ExcludedResources, controller.defaultExcludedResources)
+
+	// calculate expiration, using the default TTL for backups without one
+	ttl := backup.Spec.TTL.Duration
+	if ttl == 0 {
+		ttl = controller.defaultTTL
+	}
+	if ttl > 0 {
+		backup.Status.Expiration = metav1.NewTime(controller.clock.Now().Add(ttl))
 	}
 
 	// validation
-	if backup.Status.ValidationErrors = controller.getValidationErrors(backup); len(backup.Status.ValidationErrors) > 0 {
+	backup.Status.ValidationErrors = controller.getValidationErrors(backup)
+	if backup.Spec.BaseBackup != "" {
+		chain, err := controller.backupChain(backup)
+		if err != nil {
+			backup.Status.ValidationErrors = append(backup.Status.ValidationErrors, fmt.Sprintf("Invalid base backup: %v", err))
+		}
+		backup.Status.BackupChain = chain
+	}
+
+	if len(backup.Status.ValidationErrors) > 0 {
 		backup.Status.Phase = api.BackupPhaseFailedValidation
 	} else {
 		backup.Status.Phase = api.BackupPhaseInProgress
+		backup.Status.StartTimestamp = metav1.NewTime(controller.clock.Now())
 	}
 
 	// update status
@@ -267,12 +386,26 @@ func (controller *backupController) processBackup(key string) error {
 	controller.backupTracker.Add(backup.Namespace, backup.Name)
 	defer controller.backupTracker.Delete(backup.Namespace, backup.Name)
 
+	controller.metrics.RegisterBackupStarted()
+	defer controller.metrics.RegisterBackupFinished()
+
+	controller.addInProgress(key, original)
+
 	logContext.Debug("Running backup")
 	// execution & upload of backup
 	if err := controller.runBackup(backup, controller.bucket); err != nil {
 		logContext.WithError(err).Error("backup failed")
 		backup.Status.Phase = api.BackupPhaseFailed
 	}
+	if backup.Status.CompletionTimestamp.IsZero() {
+		backup.Status.CompletionTimestamp = metav1.NewTime(controller.clock.Now())
+	}
+	controller.recordDurations(backup, logContext)
+
+	if !controller.removeInProgress(key) {
+		logContext.Info("Backup was marked as failed while the server was shutting down, not updating its final status")
+		return nil
+	}
 
 	logContext.Debug("Updating backup's final status")
 	if _, err := patchBackup(original, backup, controller.client); err != nil {
@@ -282,6 +415,109 @@ func (controller *backupController) processBackup(key string) error {
 	return nil
 }
 
+func (controller *backupController) addInProgress(key string, backup *api.Backup) {
+	controller.inProgressLock.Lock()
+	defer controller.inProgressLock.Unlock()
+
+	controller.inProgress[key] = backup
+}
+
+// removeInProgress stops tracking the in-progress backup identified by key,
+// returning false if it was no longer tracked because failInProgressBackups
+// has already marked it as failed.
+func (controller *backupController) removeInProgress(key string) bool {
+	controller.inProgressLock.Lock()
+	defer controller.inProgressLock.Unlock()
+
+	if _, found := controller.inProgress[key]; !found {
+		return false
+	}
+	delete(controller.inProgress, key)
+
+	return true
+}
+
+// failInProgressBackups marks every backup still being run by a worker as failed,
+// so that none are left in progress once the server has stopped.
+func (controller *backupController) failInProgressBackups() {
+	controller.inProgressLock.Lock()
+	defer controller.inProgressLock.Unlock()
+
+	for key, original := range controller.inProgress {
+		delete(controller.inProgress, key)
+
+		backup := original.DeepCopy()
+		backup.Status.Phase = api.BackupPhaseFailed
+
+		if _, err := patchBackup(original, backup, controller.client); err != nil {
+			controller.logger.WithError(err).WithField("backup", key).Error("Error marking in-progress backup as failed")
+		}
+	}
+}
+
+// failStaleBackups marks backups as failed that have been in progress for longer than
+// staleBackupTimeout without being run by one of this controller's workers, so that
+// backups that were being run when a server crashed can be retried and garbage-collected.
+func (controller *backupController) failStaleBackups() {
+	backups, err := controller.lister.List(labels.Everything())
+	if err != nil {
+		controller.logger.WithError(errors.WithStack(err)).Error("Error listing backups to check for stale ones")
+		return
+	}
+
+	for _, backup := range backups {
+		key := kubeutil.NamespaceAndName(backup)
+		if !controller.isStale(backup) || controller.isInProgress(key) {
+			continue
+		}
+		log := controller.logger.WithField("backup", key)
+
+		// the informer's copy may be out of date, for instance if another server has just
+		// finished running the backup, so check the latest one before failing it
+		backup, err := controller.client.Backups(backup.Namespace).Get(backup.Name, metav1.GetOptions{})
+		if err != nil {
+			log.WithError(errors.WithStack(err)).Error("Error getting backup to check whether it's stale")
+			continue
+		}
+		if !controller.isStale(backup) {
+			continue
+		}
+
+		log.WithField("started", backupStarted(backup).Time).Warn("Backup has been in progress without being run for longer than the stale backup timeout, marking it as failed")
+
+		// the resourceVersion makes the patch fail if the backup has changed since it was checked
+		patch := fmt.Sprintf(`{"metadata":{"resourceVersion":%q},"status":{"phase":%q}}`, backup.ResourceVersion, api.BackupPhaseFailed)
+		if _, err := controller.client.Backups(backup.Namespace).Patch(backup.Name, types.MergePatchType, []byte(patch)); err != nil {
+			log.WithError(errors.WithStack(err)).Error("Error marking stale backup as failed")
+		}
+	}
+}
+
+// isStale returns whether the backup has been in progress for longer than staleBackupTimeout.
+func (controller *backupController) isStale(backup *api.Backup) bool {
+	return backup.Status.Phase == api.BackupPhaseInProgress &&
+		controller.clock.Now().Sub(backupStarted(backup).Time) >= controller.staleBackupTimeout
+}
+
+// backupStarted returns when the backup started running. Backups started by older versions
+// of Ark don't record their start time, so their creation time is used instead.
+func backupStarted(backup *api.Backup) metav1.Time {
+	if backup.Status.StartTimestamp.IsZero() {
+		return backup.CreationTimestamp
+	}
+	return backup.Status.StartTimestamp
+}
+
+// isInProgress returns whether the backup identified by key is being run by one of
+// this controller's workers.
+func (controller *backupController) isInProgress(key string) bool {
+	controller.inProgressLock.Lock()
+	defer controller.inProgressLock.Unlock()
+
+	_, found := controller.inProgress[key]
+	return found
+}
+
 func patchBackup(original, updated *api.Backup, client arkv1client.BackupsGetter) (*api.Backup, error) {
 	origBytes, err := json.Marshal(original)
 	if err != nil {
@@ -317,17 +553,109 @@ func (controller *backupController) getValidationErrors(itm *api.Backup) []strin
 		validationErrors = append(validationErrors, fmt.Sprintf("Invalid included/excluded namespace lists: %v", err))
 	}
 
+	for _, ns := range append(append([]string{}, itm.Spec.IncludedNamespaces...), itm.Spec.ExcludedNamespaces...) {
+		if err := collections.ValidatePattern(ns); err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("Invalid namespace pattern: %v", err))
+		}
+	}
+
 	if !controller.pvProviderExists && itm.Spec.SnapshotVolumes != nil && *itm.Spec.SnapshotVolumes {
 		validationErrors = append(validationErrors, "Server is not configured for PV snapshots")
 	}
 
+	if itm.Spec.VolumeSnapshotSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(itm.Spec.VolumeSnapshotSelector); err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("Invalid volume snapshot selector: %v", err))
+		}
+	}
+
+	if itm.Spec.MinItems < 0 {
+		validationErrors = append(validationErrors, fmt.Sprintf("Invalid minimum item count %d: must not be negative", itm.Spec.MinItems))
+	}
+
+	if itm.Spec.ModifiedSince.Duration < 0 {
+		validationErrors = append(validationErrors, fmt.Sprintf("Invalid modifiedSince %s: must not be negative", itm.Spec.ModifiedSince.Duration))
+	}
+
+	if itm.Spec.ResourceRequestTimeout.Duration < 0 {
+		validationErrors = append(validationErrors, fmt.Sprintf("Invalid resourceRequestTimeout %s: must not be negative", itm.Spec.ResourceRequestTimeout.Duration))
+	}
+
+	if _, err := compression.CodecFor(itm.Spec.CompressionFormat); err != nil {
+		validationErrors = append(validationErrors, fmt.Sprintf("Invalid compression format: %v", err))
+	}
+
+	for resource, fields := range itm.Spec.ExcludedFields {
+		for _, field := range fields {
+			if err := backup.ValidateExcludedField(field); err != nil {
+				validationErrors = append(validationErrors, fmt.Sprintf("Invalid excluded field for %s: %v", resource, err))
+			}
+		}
+	}
+
 	return validationErrors
 }
 
+// backupChain returns the names of the backups that backup is incremental to, base first: its
+// base backup's chain, followed by its base backup.
+func (controller *backupController) backupChain(backup *api.Backup) ([]string, error) {
+	base, err := controller.lister.Backups(backup.Namespace).Get(backup.Spec.BaseBackup)
+	if apierrors.IsNotFound(err) {
+		return nil, errors.Errorf("backup %s not found", backup.Spec.BaseBackup)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting base backup")
+	}
+
+	if base.Status.Phase != api.BackupPhaseCompleted {
+		return nil, errors.Errorf("backup %s is not completed", base.Name)
+	}
+
+	// a base's own chain was resolved when it ran, so it needn't be walked again
+	if base.Spec.BaseBackup == "" && base.Annotations[api.IncrementalBaseAnnotation] != "true" {
+		return nil, errors.Errorf("backup %s is not annotated with %s=true", base.Name, api.IncrementalBaseAnnotation)
+	}
+
+	chain := make([]string, 0, len(base.Status.BackupChain)+1)
+	chain = append(chain, base.Status.BackupChain...)
+	return append(chain, base.Name), nil
+}
+
+// checkAvailableStorage returns an error, recorded as the backup's failure reason, if
+// the bucket has less than the minimum available storage. Object stores that don't
+// report their available storage, or fail to, aren't checked.
+func (controller *backupController) checkAvailableStorage(backup *api.Backup, bucket string, log logrus.FieldLogger) error {
+	if controller.minAvailableStorage <= 0 {
+		return nil
+	}
+
+	available, supported, err := controller.backupService.AvailableBytes(bucket)
+	if err != nil {
+		log.WithError(err).Warn("Error getting available backup storage, continuing without checking it")
+		return nil
+	}
+	if !supported {
+		log.Debug("Backup storage doesn't report its available storage, not checking it")
+		return nil
+	}
+
+	if available < controller.minAvailableStorage {
+		backup.Status.FailureReason = fmt.Sprintf("backup storage has %s available, less than the minimum of %s",
+			resource.NewQuantity(available, resource.BinarySI), resource.NewQuantity(controller.minAvailableStorage, resource.BinarySI))
+		return errors.New(backup.Status.FailureReason)
+	}
+
+	return nil
+}
+
 func (controller *backupController) runBackup(backup *api.Backup, bucket string) error {
 	log := controller.logger.WithField("backup", kubeutil.NamespaceAndName(backup))
 	log.Info("Starting backup")
 
+	if err := controller.checkAvailableStorage(backup, bucket, log); err != nil {
+		return err
+	}
+
 	logFile, err := ioutil.TempFile("", "")
 	if err != nil {
 		return errors.Wrap(err, "error creating temp file for backup log")
@@ -350,26 +678,166 @@ func (controller *backupController) runBackup(backup *api.Backup, bucket string)
 
 	var backupJsonToUpload, backupFileToUpload io.Reader
 
-	// Do the actual backup
-	if err := controller.backupper.Backup(backup, backupFile, logFile, actions); err != nil {
+	// Do the actual backup, computing the tarball's checksum as it's written
+	contentsHash := sha256.New()
+	tarball := io.MultiWriter(backupFile, contentsHash)
+
+	// tee the tarball to the stream consumer, if there is one, as it's written
+	var (
+		tee          *streamTee
+		streamWriter io.WriteCloser
+	)
+	if controller.stream != nil {
+		if streamWriter, err = controller.stream.Consumer.Open(backup); err != nil {
+			if controller.stream.Required {
+				return errors.Wrap(err, "error opening backup stream")
+			}
+			log.WithError(err).Warn("Error opening backup stream, continuing without it")
+			backup.Status.Warnings = append(backup.Status.Warnings, fmt.Sprintf("backup wasn't streamed: %v", err))
+		} else {
+			tee = newStreamTee(tarball, streamWriter, controller.stream.Required)
+			tarball = tee
+		}
+	}
+
+	progress, stopProgressUpdates := controller.startProgressUpdates(backup.Namespace, backup.Name)
+	collectionStarted := controller.clock.Now()
+	err = controller.backupper.Backup(backup, tarball, logFile, actions, progress)
+	stopProgressUpdates()
+
+	if tee != nil {
+		streamErr := tee.Err()
+		if closeErr := streamWriter.Close(); closeErr != nil && streamErr == nil {
+			streamErr = errors.Wrap(closeErr, "error closing backup stream")
+		}
+
+		switch {
+		case streamErr == nil:
+		case controller.stream.Required:
+			// a write error has already failed the backup; a close error hasn't
+			if err == nil {
+				err = streamErr
+			}
+		default:
+			log.WithError(streamErr).Warn("Error streaming backup, continuing without it")
+			backup.Status.Warnings = append(backup.Status.Warnings, fmt.Sprintf("backup wasn't completely streamed: %v", streamErr))
+		}
+	}
+	finalProgress := progress.Get()
+	backup.Status.Progress = &finalProgress
+
+	stageDurations := &api.BackupStageDurations{
+		VolumeSnapshots: metav1.Duration{Duration: progress.SnapshotsDuration()},
+	}
+	if collection := controller.clock.Now().Sub(collectionStarted) - stageDurations.VolumeSnapshots.Duration; collection > 0 {
+		stageDurations.ItemCollection.Duration = collection
+	}
+	backup.Status.StageDurations = stageDurations
+
+	switch {
+	case isNothingSelected(err):
+		// the backup's settings don't select anything to back up, which can only be
+		// determined against the cluster's namespaces and resources, so it's failed
+		// validation rather than a failed backup
+		log.WithError(err).Info("Backup failed validation")
+		backup.Status.Phase = api.BackupPhaseFailedValidation
+		backup.Status.ValidationErrors = append(backup.Status.ValidationErrors, err.Error())
+
+		return nil
+	case isBelowMinItems(err):
+		// skipped backups' contents aren't uploaded, so they don't take up storage, but their
+		// metadata is, since backup directories without it can't be synced
+		log.WithError(err).Info("Skipping backup")
+		backup.Status.Phase = api.BackupPhaseSkipped
+		backup.Status.CompletionTimestamp = metav1.NewTime(controller.clock.Now())
+
+		backupJson := new(bytes.Buffer)
+		if err := encode.EncodeTo(backup, "json", backupJson); err != nil {
+			return errors.Wrap(err, "error encoding backup")
+		}
+
+		uploadStarted := controller.clock.Now()
+		defer func() {
+			stageDurations.Upload.Duration = controller.clock.Now().Sub(uploadStarted)
+		}()
+
+		return controller.backupService.UploadBackup(bucket, backup.Name, backupJson, nil, logFile)
+	case err != nil:
 		errs = append(errs, err)
 
 		backup.Status.Phase = api.BackupPhaseFailed
-	} else {
+	default:
 		backup.Status.Phase = api.BackupPhaseCompleted
+		backup.Status.ContentsSHA256 = hex.EncodeToString(contentsHash.Sum(nil))
+	}
+
+	if missing := removeMissingSnapshots(backup); len(missing) > 0 {
+		log.WithField("persistentVolumes", missing).Error("Snapshots of some persistent volumes weren't created")
+		for _, pvName := range missing {
+			backup.Status.Errors = append(backup.Status.Errors, fmt.Sprintf("snapshot of persistent volume %s wasn't created: the block store returned an empty snapshot ID", pvName))
+		}
+
+		if backup.Status.Phase == api.BackupPhaseCompleted {
+			backup.Status.Phase = api.BackupPhasePartiallyFailed
+		}
 	}
 
 	backupJson := new(bytes.Buffer)
+	var backupMetadata []byte
 	if err := encode.EncodeTo(backup, "json", backupJson); err != nil {
 		errs = append(errs, errors.Wrap(err, "error encoding backup"))
 	} else {
 		// Only upload the json and backup tarball if encoding to json succeeded.
 		backupJsonToUpload = backupJson
 		backupFileToUpload = backupFile
+		// captured before the upload reads the buffer, for the mirror uploads
+		backupMetadata = backupJson.Bytes()
 	}
 
+	var (
+		uploadStarted = controller.clock.Now()
+		uploaded      bool
+		mirrors       []BackupStorageMirror
+	)
 	if err := controller.backupService.UploadBackup(bucket, backup.Name, backupJsonToUpload, backupFileToUpload, logFile); err != nil {
 		errs = append(errs, err)
+
+		// a backup that wasn't completely uploaded can't be used, so don't leave the parts
+		// of it that were behind in object storage
+		if err := controller.backupService.DeleteBackupContents(bucket, backup.Name); err != nil {
+			log.WithError(err).Error("Error deleting objects of failed backup from object storage")
+			backup.Status.StorageCleanup = api.BackupStorageCleanupFailed
+		} else {
+			backup.Status.StorageCleanup = api.BackupStorageCleanupSucceeded
+		}
+	} else if backupMetadata != nil && len(controller.mirrors) == 0 {
+		uploaded = true
+	} else if backupMetadata != nil {
+		var failures []string
+		mirrors, failures = controller.uploadToMirrors(backup, backupMetadata, backupFile.Name(), logFile.Name(), log)
+
+		if succeeded := len(mirrors) + 1; succeeded < controller.storageQuorum {
+			errs = append(errs, errors.Errorf("backup was uploaded to %d of the %d buckets required: %s", succeeded, controller.storageQuorum, strings.Join(failures, "; ")))
+
+			// a backup that isn't in enough buckets is failed, so don't leave copies of it
+			// that look completed in the buckets it was uploaded to
+			backup.Status.Phase = api.BackupPhaseFailed
+			controller.deleteUploadedContents(backup, bucket, mirrors, log)
+		} else {
+			uploaded = true
+			backup.Status.Warnings = append(backup.Status.Warnings, failures...)
+		}
+	}
+	stageDurations.Upload.Duration = controller.clock.Now().Sub(uploadStarted)
+	backup.Status.CompletionTimestamp = metav1.NewTime(controller.clock.Now())
+
+	// the metadata was uploaded before the upload's duration, the backup's completion time and
+	// any mirror upload warnings were known, so update it with them
+	if uploaded {
+		if err := controller.uploadMetadata(backup, bucket, mirrors); err != nil {
+			log.WithError(err).Error("Error updating backup metadata in object storage")
+			backup.Status.Warnings = append(backup.Status.Warnings, fmt.Sprintf("backup metadata in object storage wasn't updated after the upload: %v", err))
+		}
 	}
 
 	log.Info("Backup completed")
@@ -377,6 +845,195 @@ func (controller *backupController) runBackup(backup *api.Backup, bucket string)
 	return kerrors.NewAggregate(errs)
 }
 
+// recordDurations logs how long the finished backup took to run, and each of its stages,
+// and records them as metrics.
+func (controller *backupController) recordDurations(backup *api.Backup, log logrus.FieldLogger) {
+	duration := backup.Status.CompletionTimestamp.Sub(backup.Status.StartTimestamp.Time)
+	controller.metrics.RegisterBackupDuration(duration)
+
+	fields := logrus.Fields{"duration": duration}
+	if stages := backup.Status.StageDurations; stages != nil {
+		controller.metrics.RegisterBackupStageDuration(metrics.BackupStageItemCollection, stages.ItemCollection.Duration)
+		controller.metrics.RegisterBackupStageDuration(metrics.BackupStageVolumeSnapshots, stages.VolumeSnapshots.Duration)
+		controller.metrics.RegisterBackupStageDuration(metrics.BackupStageUpload, stages.Upload.Duration)
+
+		fields["itemCollectionDuration"] = stages.ItemCollection.Duration
+		fields["volumeSnapshotsDuration"] = stages.VolumeSnapshots.Duration
+		fields["uploadDuration"] = stages.Upload.Duration
+	}
+
+	log.WithFields(fields).Info("Backup finished running")
+}
+
+// removeMissingSnapshots removes the volumes the backup intended to snapshot but got
+// no snapshot ID for from its status's VolumeBackups, so there's nothing to restore or
+// delete for them, and returns their persistent volumes' names, sorted.
+func removeMissingSnapshots(backup *api.Backup) []string {
+	var missing []string
+	for pvName, info := range backup.Status.VolumeBackups {
+		if info == nil || info.SnapshotID == "" {
+			missing = append(missing, pvName)
+			delete(backup.Status.VolumeBackups, pvName)
+		}
+	}
+	sort.Strings(missing)
+
+	return missing
+}
+
+// storageQuorumOrAll returns quorum, or buckets if quorum is less than 1 or more
+// than the number of buckets.
+func storageQuorumOrAll(quorum, buckets int) int {
+	if quorum < 1 || quorum > buckets {
+		return buckets
+	}
+	return quorum
+}
+
+// uploadToMirrors uploads the backup to each of the controller's mirrors concurrently,
+// and returns the mirrors it was uploaded to and a description of each failed upload.
+func (controller *backupController) uploadToMirrors(backup *api.Backup, metadata []byte, backupPath, logPath string, log logrus.FieldLogger) ([]BackupStorageMirror, []string) {
+	var (
+		wg         sync.WaitGroup
+		uploadErrs = make([]error, len(controller.mirrors))
+	)
+
+	for i := range controller.mirrors {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			uploadErrs[i] = uploadToMirror(controller.mirrors[i], backup.Name, metadata, backupPath, logPath)
+		}(i)
+	}
+	wg.Wait()
+
+	var (
+		uploaded []BackupStorageMirror
+		failures []string
+	)
+	for i, err := range uploadErrs {
+		if err == nil {
+			uploaded = append(uploaded, controller.mirrors[i])
+			continue
+		}
+
+		log.WithError(err).WithField("bucket", controller.mirrors[i].Bucket).Warn("Error uploading backup to mirror")
+		failures = append(failures, fmt.Sprintf("error uploading backup to mirror bucket %s: %v", controller.mirrors[i].Bucket, err))
+	}
+
+	return uploaded, failures
+}
+
+// deleteUploadedContents deletes the backup's contents from the primary bucket and the
+// given mirrors, and records whether that succeeded in its status.
+func (controller *backupController) deleteUploadedContents(backup *api.Backup, bucket string, mirrors []BackupStorageMirror, log logrus.FieldLogger) {
+	backup.Status.StorageCleanup = api.BackupStorageCleanupSucceeded
+
+	if err := controller.backupService.DeleteBackupContents(bucket, backup.Name); err != nil {
+		log.WithError(err).Error("Error deleting objects of failed backup from object storage")
+		backup.Status.StorageCleanup = api.BackupStorageCleanupFailed
+	}
+
+	for _, mirror := range mirrors {
+		if err := mirror.BackupService.DeleteBackupContents(mirror.Bucket, backup.Name); err != nil {
+			log.WithError(err).WithField("bucket", mirror.Bucket).Error("Error deleting objects of failed backup from mirror")
+			backup.Status.StorageCleanup = api.BackupStorageCleanupFailed
+		}
+	}
+}
+
+// uploadMetadata replaces the backup's metadata in the primary bucket and the given
+// mirrors with its current state.
+func (controller *backupController) uploadMetadata(backup *api.Backup, bucket string, mirrors []BackupStorageMirror) error {
+	metadata := new(bytes.Buffer)
+	if err := encode.EncodeTo(backup, "json", metadata); err != nil {
+		return errors.Wrap(err, "error encoding backup")
+	}
+
+	var errs []error
+	if err := controller.backupService.UploadBackupMetadata(bucket, backup.Name, bytes.NewReader(metadata.Bytes())); err != nil {
+		errs = append(errs, errors.Wrap(err, "error uploading backup metadata"))
+	}
+	for _, mirror := range mirrors {
+		if err := mirror.BackupService.UploadBackupMetadata(mirror.Bucket, backup.Name, bytes.NewReader(metadata.Bytes())); err != nil {
+			errs = append(errs, errors.Wrapf(err, "error uploading backup metadata to mirror bucket %s", mirror.Bucket))
+		}
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
+// uploadToMirror uploads the backup metadata, and the tarball and log at the given paths,
+// to the mirror's bucket.
+func uploadToMirror(mirror BackupStorageMirror, backupName string, metadata []byte, backupPath, logPath string) error {
+	backupFile, err := os.Open(backupPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer backupFile.Close()
+
+	logFile, err := os.Open(logPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer logFile.Close()
+
+	return mirror.BackupService.UploadBackup(mirror.Bucket, backupName, bytes.NewReader(metadata), backupFile, logFile)
+}
+
+// mergeExcludedResources returns excludes with each of defaults appended that isn't already in
+// it and isn't explicitly listed in includes. A wildcard include doesn't override the defaults,
+// and the backup's own excludes always apply.
+func mergeExcludedResources(includes, excludes, defaults []string) []string {
+	explicit := sets.NewString(includes...)
+	excluded := sets.NewString(excludes...)
+
+	res := excludes
+	for _, resource := range defaults {
+		if explicit.Has(resource) || excluded.Has(resource) {
+			continue
+		}
+		excluded.Insert(resource)
+		res = append(res, resource)
+	}
+	return res
+}
+
+// startProgressUpdates returns a Progress for the named backup and starts periodically patching
+// the backup's status.progress from it. The returned func stops the updates.
+func (controller *backupController) startProgressUpdates(namespace, name string) (*backup.Progress, func()) {
+	progress := new(backup.Progress)
+	log := controller.logger.WithField("backup", namespace+"/"+name)
+
+	stop := runPeriodically(progressUpdatePeriod, func() {
+		patch, err := progressPatch(progress.Get())
+		if err != nil {
+			log.WithError(err).Warn("Error updating backup progress")
+			return
+		}
+
+		if _, err := controller.client.Backups(namespace).Patch(name, types.MergePatchType, patch); err != nil {
+			log.WithError(errors.WithStack(err)).Warn("Error updating backup progress")
+		}
+	})
+
+	return progress, stop
+}
+
+// isBelowMinItems returns whether err indicates that a backup contained too few items
+// to be kept.
+func isBelowMinItems(err error) bool {
+	_, ok := errors.Cause(err).(*backup.BelowMinItemsError)
+	return ok
+}
+
+// isNothingSelected returns whether err indicates that a backup's include/exclude
+// settings select no namespaces and no cluster-scoped resources.
+func isNothingSelected(err error) bool {
+	_, ok := errors.Cause(err).(*backup.NothingSelectedError)
+	return ok
+}
+
 func closeAndRemoveFile(file *os.File, log logrus.FieldLogger) {
 	if err := file.Close(); err != nil {
 		log.WithError(err).WithField("file", file.Name()).Error("error closing file")
@@ -18,10 +18,14 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"sort"
 	"testing"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/clock"
 	core "k8s.io/client-go/testing"
@@ -35,6 +39,7 @@ import (
 	"github.com/heptio/ark/pkg/cloudprovider"
 	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
 	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	"github.com/heptio/ark/pkg/metrics"
 	"github.com/heptio/ark/pkg/restore"
 	"github.com/heptio/ark/pkg/util/collections"
 	arktest "github.com/heptio/ark/pkg/util/test"
@@ -44,7 +49,7 @@ type fakeBackupper struct {
 	mock.Mock
 }
 
-func (b *fakeBackupper) Backup(backup *v1.Backup, data, log io.Writer, actions []backup.ItemAction) error {
+func (b *fakeBackupper) Backup(backup *v1.Backup, data, log io.Writer, actions []backup.ItemAction, progress *backup.Progress) error {
 	args := b.Called(backup, data, log, actions)
 	return args.Error(0)
 }
@@ -59,6 +64,12 @@ func TestProcessBackup(t *testing.T) {
 		backup           *arktest.TestBackup
 		expectBackup     bool
 		allowSnapshots   bool
+		defaultTTL       time.Duration
+		// defaultExcludes are the server's default excluded resources
+		defaultExcludes []string
+		// expectedVersion is the format version recorded in the backup, 1 if unset
+		expectedVersion     int
+		deduplicateContents bool
 	}{
 		{
 			name:        "bad key",
@@ -112,6 +123,69 @@ func TestProcessBackup(t *testing.T) {
 			backup:       arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithIncludedNamespaces("foo").WithExcludedNamespaces("foo"),
 			expectBackup: false,
 		},
+		{
+			name:         "invalid namespace regexp pattern fails validation",
+			key:          "heptio-ark/backup1",
+			backup:       arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithExcludedNamespaces("/pr-(/"),
+			expectBackup: false,
+		},
+		{
+			name:         "invalid namespace glob pattern fails validation",
+			key:          "heptio-ark/backup1",
+			backup:       arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithIncludedNamespaces("pr-[1"),
+			expectBackup: false,
+		},
+		{
+			name:         "negative MinItems fails validation",
+			key:          "heptio-ark/backup1",
+			backup:       arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithMinItems(-1),
+			expectBackup: false,
+		},
+		{
+			name:         "negative ModifiedSince fails validation",
+			key:          "heptio-ark/backup1",
+			backup:       arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithModifiedSince(-time.Hour),
+			expectBackup: false,
+		},
+		{
+			name:         "excluding a required field fails validation",
+			key:          "heptio-ark/backup1",
+			backup:       arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithExcludedFields("pods", "/status", "/metadata/name"),
+			expectBackup: false,
+		},
+		{
+			name:         "unsupported compression format fails validation",
+			key:          "heptio-ark/backup1",
+			backup:       arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithCompressionFormat("rar"),
+			expectBackup: false,
+		},
+		{
+			name:            "backup compressed with zstd gets a format version older servers reject",
+			key:             "heptio-ark/backup1",
+			backup:          arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithCompressionFormat("zstd"),
+			expectBackup:    true,
+			expectedVersion: 2,
+		},
+		{
+			name:                "backup with deduplicated contents gets a format version older servers reject",
+			key:                 "heptio-ark/backup1",
+			backup:              arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew),
+			deduplicateContents: true,
+			expectBackup:        true,
+			expectedVersion:     3,
+		},
+		{
+			name:         "backup compressed with gzip keeps the original format version",
+			key:          "heptio-ark/backup1",
+			backup:       arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithCompressionFormat("gzip"),
+			expectBackup: true,
+		},
+		{
+			name:         "missing base backup fails validation",
+			key:          "heptio-ark/backup1",
+			backup:       arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithBaseBackup("missing"),
+			expectBackup: false,
+		},
 		{
 			name:             "make sure specified included and excluded resources are honored",
 			key:              "heptio-ark/backup1",
@@ -120,6 +194,15 @@ func TestProcessBackup(t *testing.T) {
 			expectedExcludes: []string{"k", "l"},
 			expectBackup:     true,
 		},
+		{
+			name:             "default excluded resources are merged into the backup's excludes",
+			key:              "heptio-ark/backup1",
+			backup:           arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithIncludedResources("nodes").WithExcludedResources("k"),
+			defaultExcludes:  []string{"events", "nodes", "k"},
+			expectedIncludes: []string{"nodes"},
+			expectedExcludes: []string{"k", "events"},
+			expectBackup:     true,
+		},
 		{
 			name:         "if includednamespaces are specified, don't default to *",
 			key:          "heptio-ark/backup1",
@@ -132,6 +215,20 @@ func TestProcessBackup(t *testing.T) {
 			backup:       arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithTTL(10 * time.Minute),
 			expectBackup: true,
 		},
+		{
+			name:         "default ttl is used for backups without one",
+			key:          "heptio-ark/backup1",
+			backup:       arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew),
+			defaultTTL:   24 * time.Hour,
+			expectBackup: true,
+		},
+		{
+			name:         "ttl overrides the default ttl",
+			key:          "heptio-ark/backup1",
+			backup:       arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithTTL(10 * time.Minute),
+			defaultTTL:   24 * time.Hour,
+			expectBackup: true,
+		},
 		{
 			name:         "backup with SnapshotVolumes when allowSnapshots=false fails validation",
 			key:          "heptio-ark/backup1",
@@ -145,10 +242,22 @@ func TestProcessBackup(t *testing.T) {
 			allowSnapshots: true,
 			expectBackup:   true,
 		},
+		{
+			name:           "backup with SnapshotVolumes=false when allowSnapshots=true gets executed",
+			key:            "heptio-ark/backup1",
+			backup:         arktest.NewTestBackup().WithName("backup1").WithPhase(v1.BackupPhaseNew).WithSnapshotVolumes(false),
+			allowSnapshots: true,
+			expectBackup:   true,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			expectedVersion := test.expectedVersion
+			if expectedVersion == 0 {
+				expectedVersion = 1
+			}
+
 			var (
 				client          = fake.NewSimpleClientset()
 				backupper       = &fakeBackupper{}
@@ -164,10 +273,21 @@ func TestProcessBackup(t *testing.T) {
 				backupper,
 				cloudBackups,
 				"bucket",
+				test.deduplicateContents,
+				nil,
+				0,
+				0,
+				nil,
 				test.allowSnapshots,
+				test.defaultTTL,
+				time.Minute,
+				time.Hour,
+				"cluster-1",
+				test.defaultExcludes,
 				logger,
 				pluginManager,
 				NewBackupTracker(),
+				metrics.NewServerMetrics(),
 			).(*backupController)
 			c.clock = clock.NewFakeClock(time.Now())
 
@@ -178,8 +298,12 @@ func TestProcessBackup(t *testing.T) {
 				// start the shared informers.
 				sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(test.backup.Backup)
 
-				if test.backup.Spec.TTL.Duration > 0 {
-					expiration = c.clock.Now().Add(test.backup.Spec.TTL.Duration)
+				ttl := test.backup.Spec.TTL.Duration
+				if ttl == 0 {
+					ttl = test.defaultTTL
+				}
+				if ttl > 0 {
+					expiration = c.clock.Now().Add(ttl)
 				}
 
 				// set up a Backup object to represent what we expect to be passed to backupper.Backup()
@@ -187,13 +311,25 @@ func TestProcessBackup(t *testing.T) {
 				backup.Spec.IncludedResources = test.expectedIncludes
 				backup.Spec.ExcludedResources = test.expectedExcludes
 				backup.Spec.IncludedNamespaces = test.backup.Spec.IncludedNamespaces
+				if len(backup.Spec.IncludedNamespaces) == 0 {
+					backup.Spec.IncludedNamespaces = []string{"*"}
+				}
 				backup.Spec.SnapshotVolumes = test.backup.Spec.SnapshotVolumes
 				backup.Status.Phase = v1.BackupPhaseInProgress
+				backup.Status.StartTimestamp = metav1.NewTime(c.clock.Now())
 				backup.Status.Expiration.Time = expiration
-				backup.Status.Version = 1
+				backup.Status.Version = expectedVersion
+				backup.Status.ClusterID = "cluster-1"
 				backupper.On("Backup", backup, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 				cloudBackups.On("UploadBackup", "bucket", backup.Name, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				// the final metadata should have when the backup completed and how long its upload took
+				cloudBackups.On("UploadBackupMetadata", "bucket", backup.Name, mock.MatchedBy(func(metadata io.Reader) bool {
+					var uploaded v1.Backup
+					return json.NewDecoder(metadata).Decode(&uploaded) == nil &&
+						uploaded.Status.CompletionTimestamp.Time.Equal(c.clock.Now().Truncate(time.Second)) &&
+						uploaded.Status.StageDurations != nil
+				})).Return(nil)
 
 				pluginManager.On("GetBackupItemActions", backup.Name).Return(nil, nil)
 				pluginManager.On("CloseBackupItemActions", backup.Name).Return(nil)
@@ -223,9 +359,25 @@ func TestProcessBackup(t *testing.T) {
 
 				// these are the fields that we expect to be set by
 				// the controller
-				res.Status.Version = 1
+				if namespaces, err := collections.GetSlice(patchMap, "spec.includedNamespaces"); err == nil {
+					res.Spec.IncludedNamespaces = nil
+					for _, ns := range namespaces {
+						res.Spec.IncludedNamespaces = append(res.Spec.IncludedNamespaces, ns.(string))
+					}
+				}
+				if resources, err := collections.GetSlice(patchMap, "spec.excludedResources"); err == nil {
+					res.Spec.ExcludedResources = nil
+					for _, resource := range resources {
+						res.Spec.ExcludedResources = append(res.Spec.ExcludedResources, resource.(string))
+					}
+				}
+				res.Status.Version = expectedVersion
+				res.Status.ClusterID = "cluster-1"
 				res.Status.Expiration.Time = expiration
 				res.Status.Phase = v1.BackupPhase(phase)
+				if phase == string(v1.BackupPhaseInProgress) {
+					res.Status.StartTimestamp = metav1.NewTime(c.clock.Now())
+				}
 
 				return true, res, nil
 			})
@@ -255,17 +407,37 @@ func TestProcessBackup(t *testing.T) {
 			patch := make(map[string]interface{})
 			require.NoError(t, json.Unmarshal(patchAction.GetPatch(), &patch), "cannot unmarshal patch")
 
-			// should have status
-			assert.Equal(t, 1, len(patch), "patch has wrong number of keys")
+			// should have status, and spec if the included namespaces were defaulted or
+			// default excluded resources were merged in
+			expectedKeys := 1
+			if len(test.backup.Spec.IncludedNamespaces) == 0 {
+				namespaces, err := collections.GetSlice(patch, "spec.includedNamespaces")
+				require.NoError(t, err)
+				assert.Equal(t, []interface{}{"*"}, namespaces, "patch's spec.includedNamespaces does not match")
+				expectedKeys = 2
+			}
+			if len(test.expectedExcludes) > len(test.backup.Spec.ExcludedResources) {
+				resources, err := collections.GetSlice(patch, "spec.excludedResources")
+				require.NoError(t, err)
+				var expected []interface{}
+				for _, resource := range test.expectedExcludes {
+					expected = append(expected, resource)
+				}
+				assert.Equal(t, expected, resources, "patch's spec.excludedResources does not match")
+				expectedKeys = 2
+			}
+			assert.Equal(t, expectedKeys, len(patch), "patch has wrong number of keys")
 
-			expectedStatusKeys := 2
-			if test.backup.Spec.TTL.Duration > 0 {
+			expectedStatusKeys := 4
+			if !expiration.IsZero() {
 				assert.True(t, collections.HasKeyAndVal(patch, "status.expiration", expiration.UTC().Format(time.RFC3339)), "patch's status.expiration does not match")
-				expectedStatusKeys = 3
+				expectedStatusKeys = 5
 			}
 
-			assert.True(t, collections.HasKeyAndVal(patch, "status.version", float64(1)))
+			assert.True(t, collections.HasKeyAndVal(patch, "status.version", float64(expectedVersion)))
+			assert.True(t, collections.HasKeyAndVal(patch, "status.clusterID", "cluster-1"), "patch's status.clusterID does not match")
 			assert.True(t, collections.HasKeyAndVal(patch, "status.phase", string(v1.BackupPhaseInProgress)), "patch's status.phase does not match")
+			assert.True(t, collections.HasKeyAndVal(patch, "status.startTimestamp", c.clock.Now().UTC().Format(time.RFC3339)), "patch's status.startTimestamp does not match")
 
 			res, _ := collections.GetMap(patch, "status")
 			assert.Equal(t, expectedStatusKeys, len(res), "patch's status has the wrong number of keys")
@@ -280,12 +452,747 @@ func TestProcessBackup(t *testing.T) {
 			assert.Equal(t, 1, len(patch), "patch has wrong number of keys")
 
 			res, _ = collections.GetMap(patch, "status")
-			assert.Equal(t, 1, len(res), "patch's status has the wrong number of keys")
+			assert.Equal(t, 5, len(res), "patch's status has the wrong number of keys")
 			assert.True(t, collections.HasKeyAndVal(patch, "status.phase", string(v1.BackupPhaseCompleted)), "patch's status.phase does not match")
+			assert.True(t, collections.HasKeyAndVal(patch, "status.completionTimestamp", c.clock.Now().UTC().Format(time.RFC3339)), "patch's status.completionTimestamp does not match")
+			// the fake clock doesn't advance while the backup runs
+			stageDurations, err := collections.GetMap(patch, "status.stageDurations")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{"itemCollection": "0s", "volumeSnapshots": "0s", "upload": "0s"}, stageDurations, "patch's status.stageDurations does not match")
+			// the mock backupper doesn't back anything up
+			progress, err := collections.GetMap(patch, "status.progress")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{"totalItems": float64(0), "itemsBackedUp": float64(0)}, progress, "patch's status.progress does not match")
+			// the mock backupper doesn't write anything, so this is the checksum of an empty tarball
+			assert.True(t, collections.HasKeyAndVal(patch, "status.contentsSHA256", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"), "patch's status.contentsSHA256 does not match")
+		})
+	}
+}
+
+func TestMergeExcludedResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		includes []string
+		excludes []string
+		defaults []string
+		expected []string
+	}{
+		{
+			name:     "no defaults",
+			excludes: []string{"a"},
+			expected: []string{"a"},
+		},
+		{
+			name:     "defaults are appended to the backup's excludes",
+			excludes: []string{"a"},
+			defaults: []string{"b", "c"},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "defaults already excluded aren't duplicated",
+			excludes: []string{"a", "b"},
+			defaults: []string{"b", "c", "c"},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "explicitly included defaults aren't excluded",
+			includes: []string{"b"},
+			defaults: []string{"b", "c"},
+			expected: []string{"c"},
+		},
+		{
+			name:     "a wildcard include doesn't override the defaults",
+			includes: []string{"*"},
+			defaults: []string{"b"},
+			expected: []string{"b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, mergeExcludedResources(test.includes, test.excludes, test.defaults))
 		})
 	}
 }
 
+func TestRunBackupNothingSelected(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		backupper       = &fakeBackupper{}
+		cloudBackups    = &arktest.BackupService{}
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		pluginManager   = &MockManager{}
+	)
+
+	c := NewBackupController(
+		sharedInformers.Ark().V1().Backups(),
+		client.ArkV1(),
+		backupper,
+		cloudBackups,
+		"bucket",
+		false,
+		nil,
+		0,
+		0,
+		nil,
+		false,
+		0,
+		time.Minute,
+		time.Hour,
+		"",
+		nil,
+		arktest.NewLogger(),
+		pluginManager,
+		NewBackupTracker(),
+		metrics.NewServerMetrics(),
+	).(*backupController)
+
+	testBackup := arktest.NewTestBackup().WithName("backup-1").WithPhase(v1.BackupPhaseInProgress).WithIncludedNamespaces("missing").Backup
+
+	pluginManager.On("GetBackupItemActions", testBackup.Name).Return(nil, nil)
+	pluginManager.On("CloseBackupItemActions", testBackup.Name).Return(nil)
+	backupper.On("Backup", testBackup, mock.Anything, mock.Anything, mock.Anything).Return(&backup.NothingSelectedError{})
+
+	require.NoError(t, c.runBackup(testBackup, "bucket"))
+
+	assert.Equal(t, v1.BackupPhaseFailedValidation, testBackup.Status.Phase)
+	assert.Equal(t, []string{(&backup.NothingSelectedError{}).Error()}, testBackup.Status.ValidationErrors)
+	// nothing is uploaded for backups that fail validation
+	cloudBackups.AssertNotCalled(t, "UploadBackup", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRunBackupMissingSnapshots(t *testing.T) {
+	tests := []struct {
+		name                  string
+		backupErr             error
+		snapshots             map[string]string
+		expectedPhase         v1.BackupPhase
+		expectedErrors        []string
+		expectedVolumeBackups []string
+	}{
+		{
+			name:                  "all snapshots created",
+			snapshots:             map[string]string{"pv-1": "snap-1", "pv-2": "snap-2"},
+			expectedPhase:         v1.BackupPhaseCompleted,
+			expectedVolumeBackups: []string{"pv-1", "pv-2"},
+		},
+		{
+			name:          "missing snapshots partially fail the backup",
+			snapshots:     map[string]string{"pv-1": "snap-1", "pv-2": "", "pv-3": ""},
+			expectedPhase: v1.BackupPhasePartiallyFailed,
+			expectedErrors: []string{
+				"snapshot of persistent volume pv-2 wasn't created: the block store returned an empty snapshot ID",
+				"snapshot of persistent volume pv-3 wasn't created: the block store returned an empty snapshot ID",
+			},
+			expectedVolumeBackups: []string{"pv-1"},
+		},
+		{
+			name:          "failed backups stay failed",
+			backupErr:     errors.New("backup"),
+			snapshots:     map[string]string{"pv-1": ""},
+			expectedPhase: v1.BackupPhaseFailed,
+			expectedErrors: []string{
+				"snapshot of persistent volume pv-1 wasn't created: the block store returned an empty snapshot ID",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				backupper       = &fakeBackupper{}
+				cloudBackups    = &arktest.BackupService{}
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &MockManager{}
+			)
+
+			c := NewBackupController(
+				sharedInformers.Ark().V1().Backups(),
+				client.ArkV1(),
+				backupper,
+				cloudBackups,
+				"bucket",
+				false,
+				nil,
+				0,
+				0,
+				nil,
+				false,
+				0,
+				time.Minute,
+				time.Hour,
+				"",
+				nil,
+				arktest.NewLogger(),
+				pluginManager,
+				NewBackupTracker(),
+				metrics.NewServerMetrics(),
+			).(*backupController)
+
+			testBackup := arktest.NewTestBackup().WithName("backup-1").WithPhase(v1.BackupPhaseInProgress)
+			for pv, snapshot := range test.snapshots {
+				testBackup.WithSnapshot(pv, snapshot)
+			}
+
+			pluginManager.On("GetBackupItemActions", testBackup.Name).Return(nil, nil)
+			pluginManager.On("CloseBackupItemActions", testBackup.Name).Return(nil)
+			backupper.On("Backup", testBackup.Backup, mock.Anything, mock.Anything, mock.Anything).Return(test.backupErr)
+			cloudBackups.On("UploadBackup", "bucket", testBackup.Name, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			cloudBackups.On("UploadBackupMetadata", "bucket", testBackup.Name, mock.Anything).Return(nil)
+
+			err := c.runBackup(testBackup.Backup, "bucket")
+			if test.backupErr != nil {
+				assert.EqualError(t, err, test.backupErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expectedPhase, testBackup.Status.Phase)
+			assert.Equal(t, test.expectedErrors, testBackup.Status.Errors)
+
+			var volumeBackups []string
+			for pv := range testBackup.Status.VolumeBackups {
+				volumeBackups = append(volumeBackups, pv)
+			}
+			sort.Strings(volumeBackups)
+			assert.Equal(t, test.expectedVolumeBackups, volumeBackups)
+		})
+	}
+}
+
+func TestRunBackupUploadFailureCleansUpStorage(t *testing.T) {
+	tests := []struct {
+		name            string
+		cleanupErr      error
+		expectedCleanup v1.BackupStorageCleanup
+	}{
+		{
+			name:            "cleanup succeeds",
+			expectedCleanup: v1.BackupStorageCleanupSucceeded,
+		},
+		{
+			name:            "cleanup fails",
+			cleanupErr:      errors.New("delete"),
+			expectedCleanup: v1.BackupStorageCleanupFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				backupper       = &fakeBackupper{}
+				cloudBackups    = &arktest.BackupService{}
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &MockManager{}
+			)
+
+			c := NewBackupController(
+				sharedInformers.Ark().V1().Backups(),
+				client.ArkV1(),
+				backupper,
+				cloudBackups,
+				"bucket",
+				false,
+				nil,
+				0,
+				0,
+				nil,
+				false,
+				0,
+				time.Minute,
+				time.Hour,
+				"",
+				nil,
+				arktest.NewLogger(),
+				pluginManager,
+				NewBackupTracker(),
+				metrics.NewServerMetrics(),
+			).(*backupController)
+
+			testBackup := arktest.NewTestBackup().WithName("backup-1").WithPhase(v1.BackupPhaseInProgress).Backup
+
+			pluginManager.On("GetBackupItemActions", testBackup.Name).Return(nil, nil)
+			pluginManager.On("CloseBackupItemActions", testBackup.Name).Return(nil)
+			backupper.On("Backup", testBackup, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			cloudBackups.On("UploadBackup", "bucket", testBackup.Name, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("upload"))
+			cloudBackups.On("DeleteBackupContents", "bucket", testBackup.Name).Return(test.cleanupErr)
+
+			assert.EqualError(t, c.runBackup(testBackup, "bucket"), "upload")
+
+			assert.Equal(t, test.expectedCleanup, testBackup.Status.StorageCleanup)
+			cloudBackups.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunBackupBelowMinItemsUploadsMetadata(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		backupper       = &fakeBackupper{}
+		cloudBackups    = &arktest.BackupService{}
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		pluginManager   = &MockManager{}
+	)
+
+	c := NewBackupController(
+		sharedInformers.Ark().V1().Backups(),
+		client.ArkV1(),
+		backupper,
+		cloudBackups,
+		"bucket",
+		false,
+		nil,
+		0,
+		0,
+		nil,
+		false,
+		0,
+		time.Minute,
+		time.Hour,
+		"",
+		nil,
+		arktest.NewLogger(),
+		pluginManager,
+		NewBackupTracker(),
+		metrics.NewServerMetrics(),
+	).(*backupController)
+	c.clock = clock.NewFakeClock(time.Now())
+
+	testBackup := arktest.NewTestBackup().WithName("backup-1").WithPhase(v1.BackupPhaseInProgress).WithMinItems(10).Backup
+
+	pluginManager.On("GetBackupItemActions", testBackup.Name).Return(nil, nil)
+	pluginManager.On("CloseBackupItemActions", testBackup.Name).Return(nil)
+	backupper.On("Backup", testBackup, mock.Anything, mock.Anything, mock.Anything).Return(&backup.BelowMinItemsError{Items: 1, MinItems: 10})
+
+	// the metadata is uploaded with the log, so the backup's directory can be synced, but
+	// not its contents
+	isSkippedBackup := mock.MatchedBy(func(metadata io.Reader) bool {
+		var uploaded v1.Backup
+		return metadata != nil && json.NewDecoder(metadata).Decode(&uploaded) == nil &&
+			uploaded.Name == testBackup.Name &&
+			uploaded.Status.Phase == v1.BackupPhaseSkipped &&
+			uploaded.Status.CompletionTimestamp.Time.Equal(c.clock.Now().Truncate(time.Second))
+	})
+	cloudBackups.On("UploadBackup", "bucket", testBackup.Name, isSkippedBackup, nil, mock.Anything).Return(nil)
+
+	require.NoError(t, c.runBackup(testBackup, "bucket"))
+
+	assert.Equal(t, v1.BackupPhaseSkipped, testBackup.Status.Phase)
+	cloudBackups.AssertExpectations(t)
+}
+
+func TestRunBackupChecksAvailableStorage(t *testing.T) {
+	tests := []struct {
+		name                  string
+		minAvailableStorage   int64
+		available             int64
+		supported             bool
+		availableErr          error
+		expectedErr           string
+		expectedFailureReason string
+	}{
+		{
+			name: "no minimum isn't checked",
+		},
+		{
+			name:                "enough available storage runs the backup",
+			minAvailableStorage: 1024,
+			available:           2048,
+			supported:           true,
+		},
+		{
+			name:                "unreported available storage runs the backup",
+			minAvailableStorage: 1024,
+		},
+		{
+			name:                "error getting available storage runs the backup",
+			minAvailableStorage: 1024,
+			availableErr:        errors.New("statfs"),
+		},
+		{
+			name:                  "too little available storage fails the backup",
+			minAvailableStorage:   2048,
+			available:             1024,
+			supported:             true,
+			expectedErr:           "backup storage has 1Ki available, less than the minimum of 2Ki",
+			expectedFailureReason: "backup storage has 1Ki available, less than the minimum of 2Ki",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				backupper       = &fakeBackupper{}
+				cloudBackups    = &arktest.BackupService{}
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &MockManager{}
+			)
+
+			c := NewBackupController(
+				sharedInformers.Ark().V1().Backups(),
+				client.ArkV1(),
+				backupper,
+				cloudBackups,
+				"bucket",
+				false,
+				nil,
+				0,
+				test.minAvailableStorage,
+				nil,
+				false,
+				0,
+				time.Minute,
+				time.Hour,
+				"",
+				nil,
+				arktest.NewLogger(),
+				pluginManager,
+				NewBackupTracker(),
+				metrics.NewServerMetrics(),
+			).(*backupController)
+
+			testBackup := arktest.NewTestBackup().WithName("backup-1").WithPhase(v1.BackupPhaseInProgress)
+
+			pluginManager.On("GetBackupItemActions", testBackup.Name).Return(nil, nil)
+			pluginManager.On("CloseBackupItemActions", testBackup.Name).Return(nil)
+			backupper.On("Backup", testBackup.Backup, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			cloudBackups.On("UploadBackup", "bucket", testBackup.Name, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			cloudBackups.On("UploadBackupMetadata", "bucket", testBackup.Name, mock.Anything).Return(nil)
+			cloudBackups.On("AvailableBytes", "bucket").Return(test.available, test.supported, test.availableErr)
+
+			err := c.runBackup(testBackup.Backup, "bucket")
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				backupper.AssertNotCalled(t, "Backup", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				backupper.AssertCalled(t, "Backup", testBackup.Backup, mock.Anything, mock.Anything, mock.Anything)
+			}
+			assert.Equal(t, test.expectedFailureReason, testBackup.Status.FailureReason)
+
+			if test.minAvailableStorage == 0 {
+				cloudBackups.AssertNotCalled(t, "AvailableBytes", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestBackupChain(t *testing.T) {
+	tests := []struct {
+		name          string
+		backup        *v1.Backup
+		existing      []*v1.Backup
+		expectedChain []string
+		expectedErr   string
+	}{
+		{
+			name:        "base backup doesn't exist",
+			backup:      arktest.NewTestBackup().WithName("delta-1").WithBaseBackup("base").Backup,
+			expectedErr: "backup base not found",
+		},
+		{
+			name:   "base backup isn't completed",
+			backup: arktest.NewTestBackup().WithName("delta-1").WithBaseBackup("base").Backup,
+			existing: []*v1.Backup{
+				arktest.NewTestBackup().WithName("base").WithPhase(v1.BackupPhaseFailed).WithAnnotation(v1.IncrementalBaseAnnotation, "true").Backup,
+			},
+			expectedErr: "backup base is not completed",
+		},
+		{
+			name:   "base backup isn't annotated as a base",
+			backup: arktest.NewTestBackup().WithName("delta-1").WithBaseBackup("base").Backup,
+			existing: []*v1.Backup{
+				arktest.NewTestBackup().WithName("base").WithPhase(v1.BackupPhaseCompleted).Backup,
+			},
+			expectedErr: "backup base is not annotated with ark.heptio.com/incremental-base=true",
+		},
+		{
+			name:   "backup incremental to an annotated base",
+			backup: arktest.NewTestBackup().WithName("delta-1").WithBaseBackup("base").Backup,
+			existing: []*v1.Backup{
+				arktest.NewTestBackup().WithName("base").WithPhase(v1.BackupPhaseCompleted).WithAnnotation(v1.IncrementalBaseAnnotation, "true").Backup,
+			},
+			expectedChain: []string{"base"},
+		},
+		{
+			name:   "backup incremental to another incremental backup extends its chain",
+			backup: arktest.NewTestBackup().WithName("delta-2").WithBaseBackup("delta-1").Backup,
+			existing: []*v1.Backup{
+				arktest.NewTestBackup().WithName("delta-1").WithPhase(v1.BackupPhaseCompleted).WithBaseBackup("base").WithBackupChain("base").Backup,
+			},
+			expectedChain: []string{"base", "delta-1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				c               = &backupController{lister: sharedInformers.Ark().V1().Backups().Lister()}
+			)
+
+			for _, backup := range test.existing {
+				require.NoError(t, sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(backup))
+			}
+
+			chain, err := c.backupChain(test.backup)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedChain, chain)
+		})
+	}
+}
+
+func TestRunBackupUploadsToMirrors(t *testing.T) {
+	tests := []struct {
+		name             string
+		quorum           int
+		mirrorErrs       []error
+		expectedErr      string
+		expectedPhase    v1.BackupPhase
+		expectedWarnings []string
+		expectedCleanup  v1.BackupStorageCleanup
+	}{
+		{
+			name:          "all uploads succeed",
+			mirrorErrs:    []error{nil, nil},
+			expectedPhase: v1.BackupPhaseCompleted,
+		},
+		{
+			name:            "a failed mirror upload fails the backup when all buckets are required",
+			mirrorErrs:      []error{nil, errors.New("upload")},
+			expectedErr:     "backup was uploaded to 2 of the 3 buckets required: error uploading backup to mirror bucket mirror-1: upload",
+			expectedPhase:   v1.BackupPhaseFailed,
+			expectedCleanup: v1.BackupStorageCleanupSucceeded,
+		},
+		{
+			name:             "a failed mirror upload is a warning when the quorum is met",
+			quorum:           2,
+			mirrorErrs:       []error{errors.New("upload"), nil},
+			expectedPhase:    v1.BackupPhaseCompleted,
+			expectedWarnings: []string{"error uploading backup to mirror bucket mirror-0: upload"},
+		},
+		{
+			name:            "failed mirror uploads fail the backup when the quorum isn't met",
+			quorum:          2,
+			mirrorErrs:      []error{errors.New("upload"), errors.New("timeout")},
+			expectedErr:     "backup was uploaded to 1 of the 2 buckets required: error uploading backup to mirror bucket mirror-0: upload; error uploading backup to mirror bucket mirror-1: timeout",
+			expectedPhase:   v1.BackupPhaseFailed,
+			expectedCleanup: v1.BackupStorageCleanupSucceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				backupper       = &fakeBackupper{}
+				cloudBackups    = &arktest.BackupService{}
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &MockManager{}
+				mirrors         []BackupStorageMirror
+				mirrorBackups   []*arktest.BackupService
+			)
+
+			testBackup := arktest.NewTestBackup().WithName("backup-1").WithPhase(v1.BackupPhaseInProgress).Backup
+
+			// each mirror should get the same backup metadata as the primary bucket
+			isBackupMetadata := mock.MatchedBy(func(metadata io.Reader) bool {
+				var uploaded v1.Backup
+				return json.NewDecoder(metadata).Decode(&uploaded) == nil && uploaded.Name == testBackup.Name
+			})
+
+			// the final metadata should have the mirror uploads' warnings
+			hasWarnings := mock.MatchedBy(func(metadata io.Reader) bool {
+				var uploaded v1.Backup
+				return json.NewDecoder(metadata).Decode(&uploaded) == nil && assert.ObjectsAreEqual(test.expectedWarnings, uploaded.Status.Warnings)
+			})
+
+			for i, err := range test.mirrorErrs {
+				mirrorBackupService := &arktest.BackupService{}
+				bucket := fmt.Sprintf("mirror-%d", i)
+				mirrorBackupService.On("UploadBackup", bucket, testBackup.Name, isBackupMetadata, mock.Anything, mock.Anything).Return(err)
+
+				// a backup that's failed because it's missing from too many buckets should be
+				// deleted from the ones it was uploaded to
+				if err == nil && test.expectedCleanup != "" {
+					mirrorBackupService.On("DeleteBackupContents", bucket, testBackup.Name).Return(nil)
+				}
+				if err == nil && test.expectedCleanup == "" {
+					mirrorBackupService.On("UploadBackupMetadata", bucket, testBackup.Name, hasWarnings).Return(nil)
+				}
+
+				mirrors = append(mirrors, BackupStorageMirror{BackupService: mirrorBackupService, Bucket: bucket})
+				mirrorBackups = append(mirrorBackups, mirrorBackupService)
+			}
+
+			c := NewBackupController(
+				sharedInformers.Ark().V1().Backups(),
+				client.ArkV1(),
+				backupper,
+				cloudBackups,
+				"bucket",
+				false,
+				mirrors,
+				test.quorum,
+				0,
+				nil,
+				false,
+				0,
+				time.Minute,
+				time.Hour,
+				"",
+				nil,
+				arktest.NewLogger(),
+				pluginManager,
+				NewBackupTracker(),
+				metrics.NewServerMetrics(),
+			).(*backupController)
+
+			pluginManager.On("GetBackupItemActions", testBackup.Name).Return(nil, nil)
+			pluginManager.On("CloseBackupItemActions", testBackup.Name).Return(nil)
+			backupper.On("Backup", testBackup, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			cloudBackups.On("UploadBackup", "bucket", testBackup.Name, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			if test.expectedCleanup != "" {
+				cloudBackups.On("DeleteBackupContents", "bucket", testBackup.Name).Return(nil)
+			} else {
+				cloudBackups.On("UploadBackupMetadata", "bucket", testBackup.Name, hasWarnings).Return(nil)
+			}
+
+			err := c.runBackup(testBackup, "bucket")
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expectedPhase, testBackup.Status.Phase)
+			assert.Equal(t, test.expectedWarnings, testBackup.Status.Warnings)
+			assert.Equal(t, test.expectedCleanup, testBackup.Status.StorageCleanup)
+			cloudBackups.AssertExpectations(t)
+			for _, mirrorBackupService := range mirrorBackups {
+				mirrorBackupService.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestFailInProgressBackups(t *testing.T) {
+	var (
+		testBackup = arktest.NewTestBackup().WithNamespace(v1.DefaultNamespace).WithName("backup-1").WithPhase(v1.BackupPhaseInProgress).Backup
+		client     = fake.NewSimpleClientset(testBackup)
+		c          = &backupController{
+			client:     client.ArkV1(),
+			logger:     arktest.NewLogger(),
+			inProgress: make(map[string]*v1.Backup),
+		}
+		key = v1.DefaultNamespace + "/backup-1"
+	)
+
+	c.addInProgress(key, testBackup)
+	c.failInProgressBackups()
+
+	actions := client.Actions()
+	require.Len(t, actions, 1)
+
+	patchAction, ok := actions[0].(core.PatchAction)
+	require.True(t, ok, "action is not a PatchAction")
+
+	patch := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(patchAction.GetPatch(), &patch), "cannot unmarshal patch")
+	assert.Equal(t, map[string]interface{}{"status": map[string]interface{}{"phase": string(v1.BackupPhaseFailed)}}, patch)
+
+	// the worker running the backup doesn't update its final status once it's
+	// been marked as failed
+	assert.False(t, c.removeInProgress(key))
+	assert.Empty(t, c.inProgress)
+}
+
+func TestFailStaleBackups(t *testing.T) {
+	now := time.Date(2018, 4, 5, 20, 12, 21, 0, time.UTC)
+
+	newBackup := func(name string, phase v1.BackupPhase) *arktest.TestBackup {
+		return arktest.NewTestBackup().WithNamespace(v1.DefaultNamespace).WithName(name).WithPhase(phase)
+	}
+
+	backups := []*v1.Backup{
+		newBackup("stale", v1.BackupPhaseInProgress).WithStartTimestamp(now.Add(-2 * time.Hour)).Backup,
+		newBackup("recent", v1.BackupPhaseInProgress).WithStartTimestamp(now.Add(-30 * time.Minute)).Backup,
+		newBackup("running", v1.BackupPhaseInProgress).WithStartTimestamp(now.Add(-2 * time.Hour)).Backup,
+		newBackup("completed", v1.BackupPhaseCompleted).WithStartTimestamp(now.Add(-2 * time.Hour)).Backup,
+		// backups from before start times were recorded fall back to their creation time
+		newBackup("stale-without-start-time", v1.BackupPhaseInProgress).WithCreationTimestamp(now.Add(-2 * time.Hour)).Backup,
+		newBackup("recent-without-start-time", v1.BackupPhaseInProgress).WithCreationTimestamp(now.Add(-30 * time.Minute)).Backup,
+	}
+
+	// the informer's copy of this backup is out of date, and it's since completed
+	completedSinceCached := newBackup("completed-since-cached", v1.BackupPhaseCompleted).WithStartTimestamp(now.Add(-2 * time.Hour)).Backup
+
+	var objs []runtime.Object
+	for _, backup := range backups {
+		objs = append(objs, backup)
+	}
+	objs = append(objs, completedSinceCached)
+
+	var (
+		client          = fake.NewSimpleClientset(objs...)
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		c               = &backupController{
+			lister:             sharedInformers.Ark().V1().Backups().Lister(),
+			client:             client.ArkV1(),
+			clock:              clock.NewFakeClock(now),
+			logger:             arktest.NewLogger(),
+			staleBackupTimeout: time.Hour,
+			inProgress:         make(map[string]*v1.Backup),
+		}
+	)
+
+	for _, backup := range backups {
+		require.NoError(t, sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(backup))
+	}
+	cached := completedSinceCached.DeepCopy()
+	cached.Status.Phase = v1.BackupPhaseInProgress
+	require.NoError(t, sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(cached))
+	c.addInProgress(v1.DefaultNamespace+"/running", backups[2])
+
+	c.failStaleBackups()
+
+	var failed []string
+	for _, action := range client.Actions() {
+		patchAction, ok := action.(core.PatchAction)
+		if !ok {
+			continue
+		}
+
+		patch := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(patchAction.GetPatch(), &patch), "cannot unmarshal patch")
+		assert.Equal(t, map[string]interface{}{"phase": string(v1.BackupPhaseFailed)}, patch["status"])
+
+		// the patch is conditional on the backup not having changed since it was checked
+		_, err := collections.GetString(patch, "metadata.resourceVersion")
+		assert.NoError(t, err)
+
+		failed = append(failed, patchAction.GetName())
+	}
+	sort.Strings(failed)
+	assert.Equal(t, []string{"stale", "stale-without-start-time"}, failed)
+}
+
+func TestRemoveInProgress(t *testing.T) {
+	c := &backupController{inProgress: make(map[string]*v1.Backup)}
+
+	c.addInProgress("ns/backup-1", &v1.Backup{})
+	assert.True(t, c.removeInProgress("ns/backup-1"))
+	assert.False(t, c.removeInProgress("ns/backup-1"))
+}
+
 // MockManager is an autogenerated mock type for the Manager type
 type MockManager struct {
 	mock.Mock
@@ -388,6 +1295,52 @@ func (_m *MockManager) GetBlockStore(name string) (cloudprovider.BlockStore, err
 	return r0, r1
 }
 
+// GetMirrorObjectStore provides a mock function with given fields: name, mirror
+func (_m *MockManager) GetMirrorObjectStore(name string, mirror string) (cloudprovider.ObjectStore, error) {
+	ret := _m.Called(name, mirror)
+
+	var r0 cloudprovider.ObjectStore
+	if rf, ok := ret.Get(0).(func(string, string) cloudprovider.ObjectStore); ok {
+		r0 = rf(name, mirror)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(cloudprovider.ObjectStore)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(name, mirror)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStreamObjectStore provides a mock function with given fields: name
+func (_m *MockManager) GetStreamObjectStore(name string) (cloudprovider.ObjectStore, error) {
+	ret := _m.Called(name)
+
+	var r0 cloudprovider.ObjectStore
+	if rf, ok := ret.Get(0).(func(string) cloudprovider.ObjectStore); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(cloudprovider.ObjectStore)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetObjectStore provides a mock function with given fields: name
 func (_m *MockManager) GetObjectStore(name string) (cloudprovider.ObjectStore, error) {
 	ret := _m.Called(name)
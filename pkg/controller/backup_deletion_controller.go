@@ -18,15 +18,20 @@ package controller
 
 import (
 	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/heptio/ark/pkg/apis/ark/v1"
 	pkgbackup "github.com/heptio/ark/pkg/backup"
 	"github.com/heptio/ark/pkg/cloudprovider"
+	"github.com/heptio/ark/pkg/csi"
 	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
 	informers "github.com/heptio/ark/pkg/generated/informers/externalversions/ark/v1"
 	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
+	"github.com/heptio/ark/pkg/metrics"
 	"github.com/heptio/ark/pkg/util/kube"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -35,6 +40,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -45,11 +51,22 @@ type backupDeletionController struct {
 	deleteBackupRequestLister listers.DeleteBackupRequestLister
 	backupClient              arkv1client.BackupsGetter
 	snapshotService           cloudprovider.SnapshotService
+	csiSnapshotter            csi.Snapshotter
+	snapshotDeletionBatchSize int
 	backupService             cloudprovider.BackupService
 	bucket                    string
+	mirrors                   []BackupStorageMirror
 	restoreLister             listers.RestoreLister
 	restoreClient             arkv1client.RestoresGetter
 	backupTracker             BackupTracker
+	backupTombstoneLister     listers.BackupTombstoneLister
+	backupTombstoneClient     arkv1client.BackupTombstonesGetter
+	backupTombstoneRetention  time.Duration
+
+	// deletionsLock guards backupsBeingDeleted, the backups whose requests are being
+	// processed, so that workers don't process requests for the same backup at once.
+	deletionsLock       sync.Mutex
+	backupsBeingDeleted sets.String
 
 	processRequestFunc func(*v1.DeleteBackupRequest) error
 	clock              clock.Clock
@@ -62,11 +79,18 @@ func NewBackupDeletionController(
 	deleteBackupRequestClient arkv1client.DeleteBackupRequestsGetter,
 	backupClient arkv1client.BackupsGetter,
 	snapshotService cloudprovider.SnapshotService,
+	csiSnapshotter csi.Snapshotter,
+	snapshotDeletionBatchSize int,
 	backupService cloudprovider.BackupService,
 	bucket string,
+	mirrors []BackupStorageMirror,
 	restoreInformer informers.RestoreInformer,
 	restoreClient arkv1client.RestoresGetter,
 	backupTracker BackupTracker,
+	backupTombstoneInformer informers.BackupTombstoneInformer,
+	backupTombstoneClient arkv1client.BackupTombstonesGetter,
+	backupTombstoneRetention time.Duration,
+	metrics *metrics.ServerMetrics,
 ) Interface {
 	c := &backupDeletionController{
 		genericController:         newGenericController("backup-deletion", logger),
@@ -74,16 +98,24 @@ func NewBackupDeletionController(
 		deleteBackupRequestLister: deleteBackupRequestInformer.Lister(),
 		backupClient:              backupClient,
 		snapshotService:           snapshotService,
+		csiSnapshotter:            csiSnapshotter,
+		snapshotDeletionBatchSize: snapshotDeletionBatchSize,
 		backupService:             backupService,
 		bucket:                    bucket,
+		mirrors:                   mirrors,
 		restoreLister:             restoreInformer.Lister(),
 		restoreClient:             restoreClient,
 		backupTracker:             backupTracker,
+		backupTombstoneLister:     backupTombstoneInformer.Lister(),
+		backupTombstoneClient:     backupTombstoneClient,
+		backupTombstoneRetention:  backupTombstoneRetention,
+		backupsBeingDeleted:       sets.NewString(),
 		clock:                     &clock.RealClock{},
 	}
 
 	c.syncHandler = c.processQueueItem
-	c.cacheSyncWaiters = append(c.cacheSyncWaiters, deleteBackupRequestInformer.Informer().HasSynced, restoreInformer.Informer().HasSynced)
+	c.metrics = metrics
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters, deleteBackupRequestInformer.Informer().HasSynced, restoreInformer.Informer().HasSynced, backupTombstoneInformer.Informer().HasSynced)
 	c.processRequestFunc = c.processRequest
 
 	deleteBackupRequestInformer.Informer().AddEventHandler(
@@ -94,7 +126,10 @@ func NewBackupDeletionController(
 	)
 
 	c.resyncPeriod = time.Hour
-	c.resyncFunc = c.deleteExpiredRequests
+	c.resyncFunc = func() {
+		c.deleteExpiredRequests()
+		c.deleteExpiredBackupTombstones()
+	}
 
 	return c
 }
@@ -120,6 +155,9 @@ func (c *backupDeletionController) processQueueItem(key string) error {
 	switch req.Status.Phase {
 	case v1.DeleteBackupRequestPhaseProcessed:
 		// Don't do anything because it's already been processed
+	case v1.DeleteBackupRequestPhasePendingApproval:
+		// Approving the request moves it back to New, so it's processed again then
+		log.Debug("DeleteBackupRequest is waiting for approval")
 	default:
 		// Don't mutate the shared cache
 		reqCopy := req.DeepCopy()
@@ -140,19 +178,22 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 
 	// Make sure we have the backup name
 	if req.Spec.BackupName == "" {
-		_, err = c.patchDeleteBackupRequest(req, func(r *v1.DeleteBackupRequest) {
-			r.Status.Phase = v1.DeleteBackupRequestPhaseProcessed
-			r.Status.Errors = []string{"spec.backupName is required"}
-		})
+		_, err = c.patchProcessed(req, []string{"spec.backupName is required"})
 		return err
 	}
 
+	// Requests for a backup that another worker is deleting wait for it to finish
+	backupKey := req.Namespace + "/" + req.Spec.BackupName
+	if !c.startDeleting(backupKey) {
+		log.Debug("Backup is being deleted by another request, requeueing")
+		c.queue.AddAfter(kube.NamespaceAndName(req), time.Second)
+		return nil
+	}
+	defer c.finishDeleting(backupKey)
+
 	// Don't allow deleting an in-progress backup
 	if c.backupTracker.Contains(req.Namespace, req.Spec.BackupName) {
-		_, err = c.patchDeleteBackupRequest(req, func(r *v1.DeleteBackupRequest) {
-			r.Status.Phase = v1.DeleteBackupRequestPhaseProcessed
-			r.Status.Errors = []string{"backup is still in progress"}
-		})
+		_, err = c.patchProcessed(req, []string{"backup is still in progress"})
 
 		return err
 	}
@@ -160,6 +201,7 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 	// Update status to InProgress and set backup-name label if needed
 	req, err = c.patchDeleteBackupRequest(req, func(r *v1.DeleteBackupRequest) {
 		r.Status.Phase = v1.DeleteBackupRequestPhaseInProgress
+		r.Status.StartTimestamp = metav1.NewTime(c.clock.Now())
 
 		if req.Labels[v1.BackupNameLabel] == "" {
 			req.Labels[v1.BackupNameLabel] = req.Spec.BackupName
@@ -173,10 +215,7 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 	backup, err := c.backupClient.Backups(req.Namespace).Get(req.Spec.BackupName, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		// Couldn't find backup - update status to Processed and record the not-found error
-		req, err = c.patchDeleteBackupRequest(req, func(r *v1.DeleteBackupRequest) {
-			r.Status.Phase = v1.DeleteBackupRequestPhaseProcessed
-			r.Status.Errors = []string{"backup not found"}
-		})
+		req, err = c.patchProcessed(req, []string{"backup not found"})
 
 		return err
 	}
@@ -194,17 +233,50 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 		}
 	}
 
+	// Backups that require approval to be deleted are kept until the request is approved
+	if backup.Labels[v1.DeletionApprovalRequiredLabel] == "true" && !isDeletionApproved(backup, req) {
+		log.Info("Backup requires approval to be deleted, waiting for the DeleteBackupRequest to be approved")
+		_, err = c.patchDeleteBackupRequest(req, func(r *v1.DeleteBackupRequest) {
+			r.Status.Phase = v1.DeleteBackupRequestPhasePendingApproval
+		})
+
+		return err
+	}
+
+	var snapshotIDs []string
+	var csiSnapshots []*v1.CSISnapshotInfo
+	for _, volumeBackup := range backup.Status.VolumeBackups {
+		if volumeBackup.CSISnapshot != nil {
+			csiSnapshots = append(csiSnapshots, volumeBackup.CSISnapshot)
+		} else {
+			snapshotIDs = append(snapshotIDs, volumeBackup.SnapshotID)
+		}
+	}
+
 	// If the backup includes snapshots but we don't currently have a PVProvider, we don't
 	// want to orphan the snapshots so skip deletion.
-	if c.snapshotService == nil && len(backup.Status.VolumeBackups) > 0 {
-		req, err = c.patchDeleteBackupRequest(req, func(r *v1.DeleteBackupRequest) {
-			r.Status.Phase = v1.DeleteBackupRequestPhaseProcessed
-			r.Status.Errors = []string{"unable to delete backup because it includes PV snapshots and Ark is not configured with a PersistentVolumeProvider"}
-		})
+	if c.snapshotService == nil && len(snapshotIDs) > 0 {
+		req, err = c.patchProcessed(req, []string{"unable to delete backup because it includes PV snapshots and Ark is not configured with a PersistentVolumeProvider"})
+
+		return err
+	}
+	if c.csiSnapshotter == nil && len(csiSnapshots) > 0 {
+		req, err = c.patchProcessed(req, []string{"unable to delete backup because it includes CSI snapshots and Ark is not configured with csiSnapshots"})
 
 		return err
 	}
 
+	// Snapshots whose retention hasn't ended are left in the cloud, so if their retention
+	// can't be checked the backup is kept rather than risk deleting them early
+	var retainedSnapshots map[string]time.Time
+	if len(snapshotIDs) > 0 {
+		if retainedSnapshots, err = c.snapshotService.RetainedSnapshots(nil); err != nil {
+			req, err = c.patchProcessed(req, []string{errors.Wrap(err, "error checking the retention of the backup's snapshots").Error()})
+
+			return err
+		}
+	}
+
 	// Set backup status to Deleting
 	backup, err = c.patchBackup(backup, func(b *v1.Backup) {
 		b.Status.Phase = v1.BackupPhaseDeleting
@@ -215,20 +287,40 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 
 	var errs []string
 
-	// Try to delete snapshots
+	// Try to delete snapshots, unless they're retained for longer than the backup
 	log.Info("Removing PV snapshots")
-	for _, volumeBackup := range backup.Status.VolumeBackups {
-		log.WithField("snapshotID", volumeBackup.SnapshotID).Info("Removing snapshot associated with backup")
-		if err := c.snapshotService.DeleteSnapshot(volumeBackup.SnapshotID); err != nil {
-			errs = append(errs, errors.Wrapf(err, "error deleting snapshot %s", volumeBackup.SnapshotID).Error())
+	snapshotsToDelete := make([]string, 0, len(snapshotIDs))
+	for _, snapshotID := range snapshotIDs {
+		if retainUntil, retained := retainedSnapshots[snapshotID]; retained && c.clock.Now().Before(retainUntil) {
+			log.WithFields(logrus.Fields{
+				"snapshotID":  snapshotID,
+				"retainUntil": retainUntil,
+			}).Info("Snapshot's retention hasn't ended, leaving it in the cloud")
+			continue
 		}
+		snapshotsToDelete = append(snapshotsToDelete, snapshotID)
 	}
 
+	// VolumeBackups is a map, so sort them to batch them consistently
+	sort.Strings(snapshotsToDelete)
+	sort.Slice(csiSnapshots, func(i, j int) bool {
+		return csiSnapshots[i].SnapshotHandle < csiSnapshots[j].SnapshotHandle
+	})
+
+	errs = append(errs, c.deleteSnapshots(log, snapshotsToDelete)...)
+	errs = append(errs, c.deleteCSISnapshots(log, csiSnapshots)...)
+
 	// Try to delete backup from object storage
 	log.Info("Removing backup from object storage")
 	if err := c.backupService.DeleteBackupDir(c.bucket, backup.Name); err != nil {
 		errs = append(errs, errors.Wrap(err, "error deleting backup from object storage").Error())
 	}
+	for _, mirror := range c.mirrors {
+		log.WithField("bucket", mirror.Bucket).Info("Removing backup from backup storage mirror")
+		if err := mirror.BackupService.DeleteBackupDir(mirror.Bucket, backup.Name); err != nil {
+			errs = append(errs, errors.Wrapf(err, "error deleting backup from mirror bucket %s", mirror.Bucket).Error())
+		}
+	}
 
 	// Try to delete restores
 	log.Info("Removing restores")
@@ -249,6 +341,14 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 		}
 	}
 
+	// Garbage-collected backups are recorded before they're deleted, so there's an audit trail
+	// of them. If the tombstone can't be created, the backup is kept until it can be.
+	if len(errs) == 0 && req.Spec.Reason != "" && c.backupTombstoneRetention > 0 {
+		if err := c.createBackupTombstone(backup, req.Spec.Reason); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
 	if len(errs) == 0 {
 		// Only try to delete the backup object from kube if everything preceding went smoothly
 		err = c.backupClient.Backups(backup.Namespace).Delete(backup.Name, nil)
@@ -257,19 +357,17 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 		}
 	}
 
-	// Update status to processed and record errors
-	req, err = c.patchDeleteBackupRequest(req, func(r *v1.DeleteBackupRequest) {
-		r.Status.Phase = v1.DeleteBackupRequestPhaseProcessed
-		r.Status.Errors = errs
-	})
-	if err != nil {
+	// Update status to processed and record errors. If the backup was deleted, the request may
+	// already have been garbage collected along with it, since it's owned by the backup.
+	req, err = c.patchProcessed(req, errs)
+	if err != nil && !(len(errs) == 0 && apierrors.IsNotFound(errors.Cause(err))) {
 		return err
 	}
 
 	// Everything deleted correctly, so we can delete all DeleteBackupRequests for this backup
 	if len(errs) == 0 {
 		listOptions := pkgbackup.NewDeleteBackupRequestListOptions(backup.Name, string(backup.UID))
-		err = c.deleteBackupRequestClient.DeleteBackupRequests(req.Namespace).DeleteCollection(nil, listOptions)
+		err = c.deleteBackupRequestClient.DeleteBackupRequests(backup.Namespace).DeleteCollection(nil, listOptions)
 		if err != nil {
 			// If this errors, all we can do is log it.
 			c.logger.WithField("backup", kube.NamespaceAndName(backup)).Error("error deleting all associated DeleteBackupRequests after successfully deleting the backup")
@@ -279,6 +377,129 @@ func (c *backupDeletionController) processRequest(req *v1.DeleteBackupRequest) e
 	return nil
 }
 
+// isDeletionApproved returns whether the backup's deletion approvals include the request.
+func isDeletionApproved(backup *v1.Backup, req *v1.DeleteBackupRequest) bool {
+	if req.UID == "" {
+		return false
+	}
+
+	for _, uid := range strings.Split(backup.Annotations[v1.DeletionApprovedAnnotation], ",") {
+		if uid == string(req.UID) {
+			return true
+		}
+	}
+	return false
+}
+
+// startDeleting records that the backup with the given key is being deleted, returning
+// false if it already was.
+func (c *backupDeletionController) startDeleting(backupKey string) bool {
+	c.deletionsLock.Lock()
+	defer c.deletionsLock.Unlock()
+
+	if c.backupsBeingDeleted.Has(backupKey) {
+		return false
+	}
+	c.backupsBeingDeleted.Insert(backupKey)
+	return true
+}
+
+func (c *backupDeletionController) finishDeleting(backupKey string) {
+	c.deletionsLock.Lock()
+	defer c.deletionsLock.Unlock()
+
+	c.backupsBeingDeleted.Delete(backupKey)
+}
+
+// deleteSnapshots deletes the snapshots, in batches of up to snapshotDeletionBatchSize if
+// the cloud provider supports deleting snapshots in bulk, and returns any errors. Snapshots
+// in batches that can't be deleted in bulk are deleted individually.
+func (c *backupDeletionController) deleteSnapshots(log logrus.FieldLogger, snapshotIDs []string) []string {
+	var errs []string
+
+	for len(snapshotIDs) > 0 {
+		batch := snapshotIDs
+		if c.snapshotDeletionBatchSize > 0 && len(batch) > c.snapshotDeletionBatchSize {
+			batch = batch[:c.snapshotDeletionBatchSize]
+		}
+		snapshotIDs = snapshotIDs[len(batch):]
+
+		if len(batch) > 1 && c.snapshotDeletionBatchSize > 1 {
+			log.WithField("snapshotIDs", batch).Info("Removing snapshots associated with backup")
+			deleted, err := c.snapshotService.DeleteSnapshots(batch)
+			if err != nil {
+				// e.g. one of the snapshots was deleted out-of-band, so find out which
+				log.WithError(err).Warn("Error removing snapshots in bulk, removing them individually")
+			}
+			if deleted && err == nil {
+				continue
+			}
+		}
+
+		for _, snapshotID := range batch {
+			log.WithField("snapshotID", snapshotID).Info("Removing snapshot associated with backup")
+			if err := c.snapshotService.DeleteSnapshot(snapshotID); err != nil {
+				// the snapshot may have been deleted out-of-band, in which case there's nothing to delete
+				if exists, existsErr := c.snapshotService.SnapshotExists(snapshotID); existsErr == nil && !exists {
+					log.WithField("snapshotID", snapshotID).Info("Snapshot no longer exists")
+					continue
+				}
+				errs = append(errs, errors.Wrapf(err, "error deleting snapshot %s", snapshotID).Error())
+			}
+		}
+	}
+
+	return errs
+}
+
+// deleteCSISnapshots deletes the CSI snapshots, along with their VolumeSnapshots and
+// VolumeSnapshotContents, and returns any errors.
+func (c *backupDeletionController) deleteCSISnapshots(log logrus.FieldLogger, snapshots []*v1.CSISnapshotInfo) []string {
+	var errs []string
+
+	for _, snapshot := range snapshots {
+		log.WithFields(logrus.Fields{
+			"snapshotID":            snapshot.SnapshotHandle,
+			"volumeSnapshotContent": snapshot.VolumeSnapshotContentName,
+		}).Info("Removing CSI snapshot associated with backup")
+		if err := c.csiSnapshotter.DeleteSnapshot(snapshot); err != nil {
+			errs = append(errs, errors.Wrapf(err, "error deleting CSI snapshot %s", snapshot.SnapshotHandle).Error())
+		}
+	}
+
+	return errs
+}
+
+// createBackupTombstone creates a BackupTombstone recording that backup is being deleted, and why.
+func (c *backupDeletionController) createBackupTombstone(backup *v1.Backup, reason string) error {
+	tombstone := &v1.BackupTombstone{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    backup.Namespace,
+			GenerateName: backup.Name + "-",
+			Labels: map[string]string{
+				v1.BackupNameLabel: backup.Name,
+				v1.BackupUIDLabel:  string(backup.UID),
+			},
+		},
+		Spec: v1.BackupTombstoneSpec{
+			BackupName:              backup.Name,
+			BackupUID:               string(backup.UID),
+			ScheduleName:            backup.Labels[v1.ScheduleNameLabel],
+			BackupCreationTimestamp: backup.CreationTimestamp,
+			BackupExpiration:        backup.Status.Expiration,
+			Reason:                  reason,
+		},
+	}
+
+	if _, err := c.backupTombstoneClient.BackupTombstones(backup.Namespace).Create(tombstone); err != nil {
+		return errors.Wrap(err, "error creating BackupTombstone")
+	}
+
+	return nil
+}
+
+// deleteBackupRequestMaxAge is how long after they're created processed DeleteBackupRequests
+// without a spec.ttlAfterProcessed are deleted.
 const deleteBackupRequestMaxAge = 24 * time.Hour
 
 func (c *backupDeletionController) deleteExpiredRequests() {
@@ -299,8 +520,7 @@ func (c *backupDeletionController) deleteExpiredRequests() {
 			continue
 		}
 
-		age := now.Sub(req.CreationTimestamp.Time)
-		if age >= deleteBackupRequestMaxAge {
+		if isExpiredDeleteBackupRequest(req, now) {
 			reqLog := c.logger.WithFields(logrus.Fields{"namespace": req.Namespace, "name": req.Name})
 			reqLog.Info("Deleting expired DeleteBackupRequest")
 
@@ -312,6 +532,55 @@ func (c *backupDeletionController) deleteExpiredRequests() {
 	}
 }
 
+// isExpiredDeleteBackupRequest returns whether the processed request req should be deleted
+// at now: when its spec.ttlAfterProcessed has passed since it was processed, or, if it
+// doesn't have one, deleteBackupRequestMaxAge after it was created.
+func isExpiredDeleteBackupRequest(req *v1.DeleteBackupRequest, now time.Time) bool {
+	ttl := req.Spec.TTLAfterProcessed.Duration
+	if ttl <= 0 {
+		return now.Sub(req.CreationTimestamp.Time) >= deleteBackupRequestMaxAge
+	}
+
+	processed := req.Status.CompletionTimestamp.Time
+	if processed.IsZero() {
+		processed = req.CreationTimestamp.Time
+	}
+
+	return now.Sub(processed) >= ttl
+}
+
+// deleteExpiredBackupTombstones deletes the BackupTombstones that are older than the
+// configured retention. If tombstones are disabled, any that already exist are kept.
+func (c *backupDeletionController) deleteExpiredBackupTombstones() {
+	if c.backupTombstoneRetention <= 0 {
+		return
+	}
+
+	c.logger.Info("Checking for expired BackupTombstones")
+	defer c.logger.Info("Done checking for expired BackupTombstones")
+
+	tombstones, err := c.backupTombstoneLister.List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("unable to check for expired BackupTombstones")
+		return
+	}
+
+	now := c.clock.Now()
+
+	for _, tombstone := range tombstones {
+		if now.Sub(tombstone.CreationTimestamp.Time) < c.backupTombstoneRetention {
+			continue
+		}
+
+		tombstoneLog := c.logger.WithFields(logrus.Fields{"namespace": tombstone.Namespace, "name": tombstone.Name})
+		tombstoneLog.Info("Deleting expired BackupTombstone")
+
+		if err := c.backupTombstoneClient.BackupTombstones(tombstone.Namespace).Delete(tombstone.Name, nil); err != nil {
+			tombstoneLog.WithError(errors.WithStack(err)).Error("Error deleting BackupTombstone")
+		}
+	}
+}
+
 func (c *backupDeletionController) patchDeleteBackupRequest(req *v1.DeleteBackupRequest, mutate func(*v1.DeleteBackupRequest)) (*v1.DeleteBackupRequest, error) {
 	// Record original json
 	oldData, err := json.Marshal(req)
@@ -341,6 +610,28 @@ func (c *backupDeletionController) patchDeleteBackupRequest(req *v1.DeleteBackup
 	return req, nil
 }
 
+// patchProcessed updates req's status to Processed with the specified errors, and records
+// how long it took to process.
+func (c *backupDeletionController) patchProcessed(req *v1.DeleteBackupRequest, errs []string) (*v1.DeleteBackupRequest, error) {
+	now := c.clock.Now()
+	created := req.CreationTimestamp
+
+	req, err := c.patchDeleteBackupRequest(req, func(r *v1.DeleteBackupRequest) {
+		r.Status.Phase = v1.DeleteBackupRequestPhaseProcessed
+		r.Status.Errors = errs
+		r.Status.CompletionTimestamp = metav1.NewTime(now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !created.IsZero() {
+		c.metrics.RegisterBackupDeletionDuration(now.Sub(created.Time))
+	}
+
+	return req, nil
+}
+
 func (c *backupDeletionController) patchBackup(backup *v1.Backup, mutate func(*v1.Backup)) (*v1.Backup, error) {
 	// Record original json
 	oldData, err := json.Marshal(backup)
@@ -19,6 +19,8 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,6 +28,7 @@ import (
 	pkgbackup "github.com/heptio/ark/pkg/backup"
 	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
 	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	"github.com/heptio/ark/pkg/metrics"
 	"github.com/heptio/ark/pkg/util/kube"
 	arktest "github.com/heptio/ark/pkg/util/test"
 	"github.com/pkg/errors"
@@ -59,11 +62,18 @@ func TestBackupDeletionControllerControllerHasUpdateFunc(t *testing.T) {
 		client.ArkV1(), // deleteBackupRequestClient
 		client.ArkV1(), // backupClient
 		nil,            // snapshotService
+		nil,            // csiSnapshotter
+		0,              // snapshotDeletionBatchSize
 		nil,            // backupService
 		"bucket",
+		nil,
 		sharedInformers.Ark().V1().Restores(),
 		client.ArkV1(), // restoreClient
 		NewBackupTracker(),
+		sharedInformers.Ark().V1().BackupTombstones(),
+		client.ArkV1(), // backupTombstoneClient
+		0,              // backupTombstoneRetention
+		metrics.NewServerMetrics(),
 	).(*backupDeletionController)
 
 	// disable resync handler since we don't want to test it here
@@ -112,11 +122,18 @@ func TestBackupDeletionControllerProcessQueueItem(t *testing.T) {
 		client.ArkV1(), // deleteBackupRequestClient
 		client.ArkV1(), // backupClient
 		nil,            // snapshotService
+		nil,            // csiSnapshotter
+		0,              // snapshotDeletionBatchSize
 		nil,            // backupService
 		"bucket",
+		nil,
 		sharedInformers.Ark().V1().Restores(),
 		client.ArkV1(), // restoreClient
 		NewBackupTracker(),
+		sharedInformers.Ark().V1().BackupTombstones(),
+		client.ArkV1(), // backupTombstoneClient
+		0,              // backupTombstoneRetention
+		metrics.NewServerMetrics(),
 	).(*backupDeletionController)
 
 	// Error splitting key
@@ -136,6 +153,20 @@ func TestBackupDeletionControllerProcessQueueItem(t *testing.T) {
 	err = controller.processQueueItem("foo/bar")
 	assert.NoError(t, err)
 
+	// Pending approval
+	t.Run("phase=PendingApproval", func(t *testing.T) {
+		req.Status.Phase = v1.DeleteBackupRequestPhasePendingApproval
+		sharedInformers.Ark().V1().DeleteBackupRequests().Informer().GetStore().Add(req)
+
+		controller.processRequestFunc = func(r *v1.DeleteBackupRequest) error {
+			t.Error("processRequestFunc was called")
+			return nil
+		}
+
+		err = controller.processQueueItem("foo/foo-abcde")
+		assert.NoError(t, err)
+	})
+
 	// Invoke processRequestFunc
 	for _, phase := range []v1.DeleteBackupRequestPhase{"", v1.DeleteBackupRequestPhaseNew, v1.DeleteBackupRequestPhaseInProgress} {
 		t.Run(fmt.Sprintf("phase=%s", phase), func(t *testing.T) {
@@ -171,6 +202,7 @@ type backupDeletionControllerTestData struct {
 	sharedInformers informers.SharedInformerFactory
 	backupService   *arktest.BackupService
 	snapshotService *arktest.FakeSnapshotService
+	csiSnapshotter  *arktest.FakeCSISnapshotter
 	controller      *backupDeletionController
 	req             *v1.DeleteBackupRequest
 }
@@ -180,6 +212,7 @@ func setupBackupDeletionControllerTest(objects ...runtime.Object) *backupDeletio
 	sharedInformers := informers.NewSharedInformerFactory(client, 0)
 	backupService := &arktest.BackupService{}
 	snapshotService := &arktest.FakeSnapshotService{SnapshotsTaken: sets.NewString()}
+	csiSnapshotter := &arktest.FakeCSISnapshotter{}
 	req := pkgbackup.NewDeleteBackupRequest("foo", "uid")
 
 	data := &backupDeletionControllerTestData{
@@ -187,17 +220,25 @@ func setupBackupDeletionControllerTest(objects ...runtime.Object) *backupDeletio
 		sharedInformers: sharedInformers,
 		backupService:   backupService,
 		snapshotService: snapshotService,
+		csiSnapshotter:  csiSnapshotter,
 		controller: NewBackupDeletionController(
 			arktest.NewLogger(),
 			sharedInformers.Ark().V1().DeleteBackupRequests(),
 			client.ArkV1(), // deleteBackupRequestClient
 			client.ArkV1(), // backupClient
 			snapshotService,
+			csiSnapshotter,
+			0, // snapshotDeletionBatchSize
 			backupService,
 			"bucket",
+			nil,
 			sharedInformers.Ark().V1().Restores(),
 			client.ArkV1(), // restoreClient
 			NewBackupTracker(),
+			sharedInformers.Ark().V1().BackupTombstones(),
+			client.ArkV1(), // backupTombstoneClient
+			0,              // backupTombstoneRetention
+			metrics.NewServerMetrics(),
 		).(*backupDeletionController),
 
 		req: req,
@@ -205,6 +246,8 @@ func setupBackupDeletionControllerTest(objects ...runtime.Object) *backupDeletio
 	req.Namespace = "heptio-ark"
 	req.Name = "foo-abcde"
 
+	data.controller.clock = clock.NewFakeClock(time.Date(2018, 4, 5, 20, 12, 21, 0, time.UTC))
+
 	return data
 }
 
@@ -223,7 +266,7 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
 				td.req.Namespace,
 				td.req.Name,
-				[]byte(`{"status":{"errors":["spec.backupName is required"],"phase":"Processed"}}`),
+				[]byte(`{"status":{"completionTimestamp":"2018-04-05T20:12:21Z","errors":["spec.backupName is required"],"phase":"Processed"}}`),
 			),
 		}
 
@@ -244,7 +287,7 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
 				td.req.Namespace,
 				td.req.Name,
-				[]byte(`{"status":{"errors":["backup is still in progress"],"phase":"Processed"}}`),
+				[]byte(`{"status":{"completionTimestamp":"2018-04-05T20:12:21Z","errors":["backup is still in progress"],"phase":"Processed"}}`),
 			),
 		}
 
@@ -283,7 +326,94 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
 				td.req.Namespace,
 				td.req.Name,
-				[]byte(`{"status":{"phase":"InProgress"}}`),
+				[]byte(`{"status":{"phase":"InProgress","startTimestamp":"2018-04-05T20:12:21Z"}}`),
+			),
+			core.NewGetAction(
+				v1.SchemeGroupVersion.WithResource("backups"),
+				td.req.Namespace,
+				td.req.Spec.BackupName,
+			),
+			core.NewPatchAction(
+				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
+				td.req.Namespace,
+				td.req.Name,
+				[]byte(`{"status":{"completionTimestamp":"2018-04-05T20:12:21Z","errors":["backup not found"],"phase":"Processed"}}`),
+			),
+		}
+
+		assert.Equal(t, expectedActions, td.client.Actions())
+	})
+
+	t.Run("backup requiring deletion approval waits for approval", func(t *testing.T) {
+		td := setupBackupDeletionControllerTest()
+		defer td.backupService.AssertExpectations(t)
+
+		td.req.UID = "uid-2"
+
+		// only another request's deletion has been approved
+		td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			backup := arktest.NewTestBackup().WithName("foo").WithLabel(v1.DeletionApprovalRequiredLabel, "true").Backup
+			backup.Annotations = map[string]string{v1.DeletionApprovedAnnotation: "uid-1"}
+			return true, backup, nil
+		})
+
+		td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+			return true, td.req, nil
+		})
+
+		err := td.controller.processRequest(td.req)
+		require.NoError(t, err)
+
+		expectedActions := []core.Action{
+			core.NewPatchAction(
+				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
+				td.req.Namespace,
+				td.req.Name,
+				[]byte(`{"status":{"phase":"InProgress","startTimestamp":"2018-04-05T20:12:21Z"}}`),
+			),
+			core.NewGetAction(
+				v1.SchemeGroupVersion.WithResource("backups"),
+				td.req.Namespace,
+				td.req.Spec.BackupName,
+			),
+			core.NewPatchAction(
+				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
+				td.req.Namespace,
+				td.req.Name,
+				[]byte(`{"status":{"phase":"PendingApproval"}}`),
+			),
+		}
+
+		assert.Equal(t, expectedActions, td.client.Actions())
+	})
+
+	t.Run("approved request for backup requiring deletion approval proceeds", func(t *testing.T) {
+		td := setupBackupDeletionControllerTest()
+		td.controller.snapshotService = nil
+		defer td.backupService.AssertExpectations(t)
+
+		td.req.UID = "uid-2"
+
+		td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			backup := arktest.NewTestBackup().WithName("foo").WithLabel(v1.DeletionApprovalRequiredLabel, "true").WithSnapshot("pv-1", "snap-1").Backup
+			backup.Annotations = map[string]string{v1.DeletionApprovedAnnotation: "uid-1,uid-2"}
+			return true, backup, nil
+		})
+
+		td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+			return true, td.req, nil
+		})
+
+		err := td.controller.processRequest(td.req)
+		require.NoError(t, err)
+
+		// the request gets past the approval check, to the check for a snapshot service
+		expectedActions := []core.Action{
+			core.NewPatchAction(
+				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
+				td.req.Namespace,
+				td.req.Name,
+				[]byte(`{"status":{"phase":"InProgress","startTimestamp":"2018-04-05T20:12:21Z"}}`),
 			),
 			core.NewGetAction(
 				v1.SchemeGroupVersion.WithResource("backups"),
@@ -294,7 +424,7 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
 				td.req.Namespace,
 				td.req.Name,
-				[]byte(`{"status":{"errors":["backup not found"],"phase":"Processed"}}`),
+				[]byte(`{"status":{"completionTimestamp":"2018-04-05T20:12:21Z","errors":["unable to delete backup because it includes PV snapshots and Ark is not configured with a PersistentVolumeProvider"],"phase":"Processed"}}`),
 			),
 		}
 
@@ -323,7 +453,48 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
 				td.req.Namespace,
 				td.req.Name,
-				[]byte(`{"status":{"phase":"InProgress"}}`),
+				[]byte(`{"status":{"phase":"InProgress","startTimestamp":"2018-04-05T20:12:21Z"}}`),
+			),
+			core.NewGetAction(
+				v1.SchemeGroupVersion.WithResource("backups"),
+				td.req.Namespace,
+				td.req.Spec.BackupName,
+			),
+			core.NewPatchAction(
+				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
+				td.req.Namespace,
+				td.req.Name,
+				[]byte(`{"status":{"completionTimestamp":"2018-04-05T20:12:21Z","errors":["unable to delete backup because it includes PV snapshots and Ark is not configured with a PersistentVolumeProvider"],"phase":"Processed"}}`),
+			),
+		}
+
+		assert.Equal(t, expectedActions, td.client.Actions())
+	})
+
+	t.Run("no CSI snapshotter, backup has CSI snapshots", func(t *testing.T) {
+		td := setupBackupDeletionControllerTest()
+		td.controller.csiSnapshotter = nil
+		defer td.backupService.AssertExpectations(t)
+
+		td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			backup := arktest.NewTestBackup().WithName("backup-1").WithSnapshot("pv-1", "snap-1").Backup
+			backup.Status.VolumeBackups["pv-1"].CSISnapshot = &v1.CSISnapshotInfo{SnapshotHandle: "snap-1"}
+			return true, backup, nil
+		})
+
+		td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+			return true, td.req, nil
+		})
+
+		err := td.controller.processRequest(td.req)
+		require.NoError(t, err)
+
+		expectedActions := []core.Action{
+			core.NewPatchAction(
+				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
+				td.req.Namespace,
+				td.req.Name,
+				[]byte(`{"status":{"phase":"InProgress","startTimestamp":"2018-04-05T20:12:21Z"}}`),
 			),
 			core.NewGetAction(
 				v1.SchemeGroupVersion.WithResource("backups"),
@@ -334,7 +505,7 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
 				td.req.Namespace,
 				td.req.Name,
-				[]byte(`{"status":{"errors":["unable to delete backup because it includes PV snapshots and Ark is not configured with a PersistentVolumeProvider"],"phase":"Processed"}}`),
+				[]byte(`{"status":{"completionTimestamp":"2018-04-05T20:12:21Z","errors":["unable to delete backup because it includes CSI snapshots and Ark is not configured with csiSnapshots"],"phase":"Processed"}}`),
 			),
 		}
 
@@ -383,7 +554,7 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
 				td.req.Namespace,
 				td.req.Name,
-				[]byte(`{"metadata":{"labels":{"ark.heptio.com/backup-name":"foo"}},"status":{"phase":"InProgress"}}`),
+				[]byte(`{"metadata":{"labels":{"ark.heptio.com/backup-name":"foo"}},"status":{"phase":"InProgress","startTimestamp":"2018-04-05T20:12:21Z"}}`),
 			),
 			core.NewGetAction(
 				v1.SchemeGroupVersion.WithResource("backups"),
@@ -421,7 +592,7 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
 				td.req.Namespace,
 				td.req.Name,
-				[]byte(`{"status":{"phase":"Processed"}}`),
+				[]byte(`{"status":{"completionTimestamp":"2018-04-05T20:12:21Z","phase":"Processed"}}`),
 			),
 			core.NewDeleteCollectionAction(
 				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
@@ -435,6 +606,399 @@ func TestBackupDeletionControllerProcessRequest(t *testing.T) {
 		// Make sure snapshot was deleted
 		assert.Equal(t, 0, td.snapshotService.SnapshotsTaken.Len())
 	})
+
+	t.Run("request garbage collected along with the backup doesn't cause an error", func(t *testing.T) {
+		backup := arktest.NewTestBackup().WithName("foo").Backup
+		backup.UID = "uid"
+
+		td := setupBackupDeletionControllerTest(backup)
+		defer td.backupService.AssertExpectations(t)
+
+		td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+		td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+			if strings.Contains(string(action.(core.PatchAction).GetPatch()), "Processed") {
+				return true, nil, apierrors.NewNotFound(v1.SchemeGroupVersion.WithResource("deletebackuprequests").GroupResource(), td.req.Name)
+			}
+			return true, td.req, nil
+		})
+		td.client.PrependReactor("patch", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+
+		td.backupService.On("DeleteBackupDir", td.controller.bucket, td.req.Spec.BackupName).Return(nil)
+
+		require.NoError(t, td.controller.processRequest(td.req))
+
+		actions := td.client.Actions()
+		arktest.CompareActions(t, []core.Action{
+			core.NewDeleteCollectionAction(
+				v1.SchemeGroupVersion.WithResource("deletebackuprequests"),
+				td.req.Namespace,
+				pkgbackup.NewDeleteBackupRequestListOptions(td.req.Spec.BackupName, "uid"),
+			),
+		}, actions[len(actions)-1:])
+	})
+
+	t.Run("garbage-collected backup gets a tombstone before it's deleted", func(t *testing.T) {
+		created := time.Date(2018, 4, 1, 20, 12, 21, 0, time.UTC)
+		expiration := time.Date(2018, 4, 5, 20, 0, 0, 0, time.UTC)
+		backup := arktest.NewTestBackup().WithName("foo").WithLabel(v1.ScheduleNameLabel, "daily").
+			WithCreationTimestamp(created).WithExpiration(expiration).Backup
+		backup.UID = "uid"
+
+		td := setupBackupDeletionControllerTest(backup)
+		defer td.backupService.AssertExpectations(t)
+		td.controller.backupTombstoneRetention = 24 * time.Hour
+		td.req.Spec.Reason = "Backup expired"
+
+		td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+		td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+			return true, td.req, nil
+		})
+		td.client.PrependReactor("patch", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+
+		td.backupService.On("DeleteBackupDir", td.controller.bucket, td.req.Spec.BackupName).Return(nil)
+
+		require.NoError(t, td.controller.processRequest(td.req))
+
+		var tombstone *v1.BackupTombstone
+		for _, action := range td.client.Actions() {
+			if create, ok := action.(core.CreateAction); ok && create.GetResource().Resource == "backuptombstones" {
+				tombstone = create.GetObject().(*v1.BackupTombstone)
+			}
+			if action.GetVerb() == "delete" && action.GetResource().Resource == "backups" {
+				require.NotNil(t, tombstone, "backup was deleted before its tombstone was created")
+			}
+		}
+		require.NotNil(t, tombstone)
+
+		assert.Equal(t, "heptio-ark", tombstone.Namespace)
+		assert.Equal(t, "foo-", tombstone.GenerateName)
+		assert.Equal(t, map[string]string{v1.BackupNameLabel: "foo", v1.BackupUIDLabel: "uid"}, tombstone.Labels)
+		assert.Equal(t, v1.BackupTombstoneSpec{
+			BackupName:              "foo",
+			BackupUID:               "uid",
+			ScheduleName:            "daily",
+			BackupCreationTimestamp: metav1.NewTime(created),
+			BackupExpiration:        metav1.NewTime(expiration),
+			Reason:                  "Backup expired",
+		}, tombstone.Spec)
+	})
+
+	t.Run("backup isn't deleted if its tombstone can't be created", func(t *testing.T) {
+		backup := arktest.NewTestBackup().WithName("foo").Backup
+		backup.UID = "uid"
+
+		td := setupBackupDeletionControllerTest(backup)
+		defer td.backupService.AssertExpectations(t)
+		td.controller.backupTombstoneRetention = 24 * time.Hour
+		td.req.Spec.Reason = "Backup expired"
+
+		td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+		td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+			return true, td.req, nil
+		})
+		td.client.PrependReactor("patch", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+		td.client.PrependReactor("create", "backuptombstones", func(action core.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("foo")
+		})
+
+		td.backupService.On("DeleteBackupDir", td.controller.bucket, td.req.Spec.BackupName).Return(nil)
+
+		require.NoError(t, td.controller.processRequest(td.req))
+
+		for _, action := range td.client.Actions() {
+			assert.False(t, action.GetVerb() == "delete" && action.GetResource().Resource == "backups", "backup was deleted")
+		}
+	})
+
+	t.Run("backup deleted by a user doesn't get a tombstone", func(t *testing.T) {
+		backup := arktest.NewTestBackup().WithName("foo").Backup
+		backup.UID = "uid"
+
+		td := setupBackupDeletionControllerTest(backup)
+		defer td.backupService.AssertExpectations(t)
+		td.controller.backupTombstoneRetention = 24 * time.Hour
+
+		td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+		td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+			return true, td.req, nil
+		})
+		td.client.PrependReactor("patch", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+
+		td.backupService.On("DeleteBackupDir", td.controller.bucket, td.req.Spec.BackupName).Return(nil)
+
+		require.NoError(t, td.controller.processRequest(td.req))
+
+		for _, action := range td.client.Actions() {
+			assert.NotEqual(t, "backuptombstones", action.GetResource().Resource)
+		}
+	})
+
+	t.Run("snapshot that no longer exists doesn't cause an error", func(t *testing.T) {
+		backup := arktest.NewTestBackup().WithName("foo").WithSnapshot("pv-1", "snap-1").Backup
+		backup.UID = "uid"
+
+		td := setupBackupDeletionControllerTest(backup)
+		defer td.backupService.AssertExpectations(t)
+
+		td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+		td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+			return true, td.req, nil
+		})
+		td.client.PrependReactor("patch", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+
+		td.backupService.On("DeleteBackupDir", td.controller.bucket, td.req.Spec.BackupName).Return(nil)
+
+		require.NoError(t, td.controller.processRequest(td.req))
+
+		var lastReqPatch core.PatchAction
+		for _, action := range td.client.Actions() {
+			if patch, ok := action.(core.PatchAction); ok && patch.GetResource().Resource == "deletebackuprequests" {
+				lastReqPatch = patch
+			}
+		}
+		require.NotNil(t, lastReqPatch)
+		assert.Equal(t, `{"status":{"completionTimestamp":"2018-04-05T20:12:21Z","phase":"Processed"}}`, string(lastReqPatch.GetPatch()))
+	})
+
+	t.Run("backup is deleted from mirrors", func(t *testing.T) {
+		backup := arktest.NewTestBackup().WithName("foo").Backup
+		backup.UID = "uid"
+
+		td := setupBackupDeletionControllerTest(backup)
+		defer td.backupService.AssertExpectations(t)
+
+		mirror0, mirror1 := &arktest.BackupService{}, &arktest.BackupService{}
+		defer mirror0.AssertExpectations(t)
+		defer mirror1.AssertExpectations(t)
+		td.controller.mirrors = []BackupStorageMirror{
+			{BackupService: mirror0, Bucket: "mirror-0"},
+			{BackupService: mirror1, Bucket: "mirror-1"},
+		}
+
+		td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+		td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+			return true, td.req, nil
+		})
+		td.client.PrependReactor("patch", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+
+		td.backupService.On("DeleteBackupDir", td.controller.bucket, td.req.Spec.BackupName).Return(nil)
+		mirror0.On("DeleteBackupDir", "mirror-0", td.req.Spec.BackupName).Return(nil)
+		mirror1.On("DeleteBackupDir", "mirror-1", td.req.Spec.BackupName).Return(errors.New("unavailable"))
+
+		require.NoError(t, td.controller.processRequest(td.req))
+
+		var lastReqPatch core.PatchAction
+		for _, action := range td.client.Actions() {
+			if patch, ok := action.(core.PatchAction); ok && patch.GetResource().Resource == "deletebackuprequests" {
+				lastReqPatch = patch
+			}
+		}
+		require.NotNil(t, lastReqPatch)
+		assert.Equal(t, `{"status":{"completionTimestamp":"2018-04-05T20:12:21Z","errors":["error deleting backup from mirror bucket mirror-1: unavailable"],"phase":"Processed"}}`, string(lastReqPatch.GetPatch()))
+	})
+
+	t.Run("snapshots are only deleted once their retention has ended", func(t *testing.T) {
+		tests := []struct {
+			name              string
+			retainedUntil     map[string]time.Time
+			expectedSnapshots int
+		}{
+			{
+				name:              "retention hasn't ended",
+				retainedUntil:     map[string]time.Time{"snap-1": time.Date(2018, 4, 6, 0, 0, 0, 0, time.UTC)},
+				expectedSnapshots: 1,
+			},
+			{
+				name:              "retention has ended",
+				retainedUntil:     map[string]time.Time{"snap-1": time.Date(2018, 4, 5, 0, 0, 0, 0, time.UTC)},
+				expectedSnapshots: 0,
+			},
+			{
+				name:              "snapshot isn't retained",
+				expectedSnapshots: 0,
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				backup := arktest.NewTestBackup().WithName("foo").WithSnapshot("pv-1", "snap-1").Backup
+				backup.UID = "uid"
+
+				td := setupBackupDeletionControllerTest(backup)
+				td.snapshotService.RetainedUntil = test.retainedUntil
+
+				td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+					return true, backup, nil
+				})
+				td.snapshotService.SnapshotsTaken.Insert("snap-1")
+
+				td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+					return true, td.req, nil
+				})
+
+				td.client.PrependReactor("patch", "backups", func(action core.Action) (bool, runtime.Object, error) {
+					return true, backup, nil
+				})
+
+				td.backupService.On("DeleteBackupDir", td.controller.bucket, td.req.Spec.BackupName).Return(nil)
+
+				require.NoError(t, td.controller.processRequest(td.req))
+
+				assert.Equal(t, test.expectedSnapshots, td.snapshotService.SnapshotsTaken.Len())
+
+				// the backup is deleted either way
+				var deleted bool
+				for _, action := range td.client.Actions() {
+					if action.Matches("delete", "backups") {
+						deleted = true
+					}
+				}
+				assert.True(t, deleted, "backup wasn't deleted")
+			})
+		}
+	})
+
+	t.Run("snapshots are deleted in batches when the cloud provider supports it", func(t *testing.T) {
+		backup := arktest.NewTestBackup().WithName("foo").
+			WithSnapshot("pv-1", "snap-1").
+			WithSnapshot("pv-2", "snap-2").
+			WithSnapshot("pv-3", "snap-3").
+			Backup
+		backup.UID = "uid"
+
+		td := setupBackupDeletionControllerTest(backup)
+		defer td.backupService.AssertExpectations(t)
+		td.controller.snapshotDeletionBatchSize = 2
+		td.snapshotService.SupportsBulkDeletes = true
+		td.snapshotService.SnapshotsTaken.Insert("snap-1", "snap-2", "snap-3")
+
+		td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+		td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+			return true, td.req, nil
+		})
+		td.client.PrependReactor("patch", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+
+		td.backupService.On("DeleteBackupDir", td.controller.bucket, td.req.Spec.BackupName).Return(nil)
+
+		require.NoError(t, td.controller.processRequest(td.req))
+
+		// the last snapshot is in a batch by itself, so it's deleted individually
+		assert.Equal(t, [][]string{{"snap-1", "snap-2"}}, td.snapshotService.BulkDeletes)
+		assert.Equal(t, 0, td.snapshotService.SnapshotsTaken.Len())
+	})
+
+	t.Run("CSI snapshots are deleted with the CSI snapshotter", func(t *testing.T) {
+		backup := arktest.NewTestBackup().WithName("foo").
+			WithSnapshot("pv-1", "snap-1").
+			WithSnapshot("pv-2", "snap-2").
+			Backup
+		backup.UID = "uid"
+		backup.Status.VolumeBackups["pv-2"].CSISnapshot = &v1.CSISnapshotInfo{SnapshotHandle: "snap-2", VolumeSnapshotContentName: "snapcontent-2"}
+
+		td := setupBackupDeletionControllerTest(backup)
+		defer td.backupService.AssertExpectations(t)
+		td.snapshotService.SnapshotsTaken.Insert("snap-1")
+		td.csiSnapshotter.Snapshots = map[string]bool{"snapcontent-2": true}
+
+		td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+		td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+			return true, td.req, nil
+		})
+		td.client.PrependReactor("patch", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+
+		td.backupService.On("DeleteBackupDir", td.controller.bucket, td.req.Spec.BackupName).Return(nil)
+
+		require.NoError(t, td.controller.processRequest(td.req))
+
+		assert.Equal(t, 0, td.snapshotService.SnapshotsTaken.Len())
+		assert.Equal(t, []string{"snapcontent-2"}, td.csiSnapshotter.SnapshotsDeleted)
+	})
+
+	t.Run("snapshots are deleted individually when they can't be deleted in bulk", func(t *testing.T) {
+		backup := arktest.NewTestBackup().WithName("foo").
+			WithSnapshot("pv-1", "snap-1").
+			WithSnapshot("pv-2", "snap-2").
+			Backup
+		backup.UID = "uid"
+
+		td := setupBackupDeletionControllerTest(backup)
+		defer td.backupService.AssertExpectations(t)
+		td.controller.snapshotDeletionBatchSize = 2
+		td.snapshotService.SupportsBulkDeletes = true
+		// snap-2 was deleted out-of-band, so the bulk deletion fails
+		td.snapshotService.SnapshotsTaken.Insert("snap-1")
+
+		td.client.PrependReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+		td.client.PrependReactor("patch", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+			return true, td.req, nil
+		})
+		td.client.PrependReactor("patch", "backups", func(action core.Action) (bool, runtime.Object, error) {
+			return true, backup, nil
+		})
+
+		td.backupService.On("DeleteBackupDir", td.controller.bucket, td.req.Spec.BackupName).Return(nil)
+
+		require.NoError(t, td.controller.processRequest(td.req))
+
+		assert.Len(t, td.snapshotService.BulkDeletes, 0)
+		assert.Equal(t, 0, td.snapshotService.SnapshotsTaken.Len())
+
+		var lastReqPatch core.PatchAction
+		for _, action := range td.client.Actions() {
+			if patch, ok := action.(core.PatchAction); ok && patch.GetResource().Resource == "deletebackuprequests" {
+				lastReqPatch = patch
+			}
+		}
+		require.NotNil(t, lastReqPatch)
+		assert.Equal(t, `{"status":{"completionTimestamp":"2018-04-05T20:12:21Z","phase":"Processed"}}`, string(lastReqPatch.GetPatch()))
+	})
+
+	t.Run("request for a backup that's being deleted by another request waits", func(t *testing.T) {
+		td := setupBackupDeletionControllerTest()
+		td.req.Spec.BackupName = "foo"
+		require.True(t, td.controller.startDeleting("heptio-ark/foo"))
+
+		require.NoError(t, td.controller.processRequest(td.req))
+		assert.Len(t, td.client.Actions(), 0)
+
+		// once the other request is done, it can be processed
+		td.controller.finishDeleting("heptio-ark/foo")
+		assert.True(t, td.controller.startDeleting("heptio-ark/foo"))
+	})
 }
 
 func TestBackupDeletionControllerDeleteExpiredRequests(t *testing.T) {
@@ -543,6 +1107,53 @@ func TestBackupDeletionControllerDeleteExpiredRequests(t *testing.T) {
 			},
 			expectedDeletions: []string{"expired-1", "expired-2"},
 		},
+		{
+			name: "requests with a ttl after processed expire that long after they were processed",
+			requests: []*v1.DeleteBackupRequest{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:         "ns",
+						Name:              "unexpired-1",
+						CreationTimestamp: metav1.Time{Time: expired2},
+					},
+					Spec: v1.DeleteBackupRequestSpec{
+						TTLAfterProcessed: metav1.Duration{Duration: 2 * time.Hour},
+					},
+					Status: v1.DeleteBackupRequestStatus{
+						Phase:               v1.DeleteBackupRequestPhaseProcessed,
+						CompletionTimestamp: metav1.Time{Time: unexpired1},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:         "ns",
+						Name:              "expired-1",
+						CreationTimestamp: metav1.Time{Time: unexpired1},
+					},
+					Spec: v1.DeleteBackupRequestSpec{
+						TTLAfterProcessed: metav1.Duration{Duration: time.Hour},
+					},
+					Status: v1.DeleteBackupRequestStatus{
+						Phase:               v1.DeleteBackupRequestPhaseProcessed,
+						CompletionTimestamp: metav1.Time{Time: unexpired1},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:         "ns",
+						Name:              "unprocessed",
+						CreationTimestamp: metav1.Time{Time: expired2},
+					},
+					Spec: v1.DeleteBackupRequestSpec{
+						TTLAfterProcessed: metav1.Duration{Duration: time.Hour},
+					},
+					Status: v1.DeleteBackupRequestStatus{
+						Phase: v1.DeleteBackupRequestPhaseInProgress,
+					},
+				},
+			},
+			expectedDeletions: []string{"expired-1"},
+		},
 	}
 
 	for _, test := range tests {
@@ -556,11 +1167,18 @@ func TestBackupDeletionControllerDeleteExpiredRequests(t *testing.T) {
 				client.ArkV1(), // deleteBackupRequestClient
 				client.ArkV1(), // backupClient
 				nil,            // snapshotService
+				nil,            // csiSnapshotter
+				0,              // snapshotDeletionBatchSize
 				nil,            // backupService
 				"bucket",
+				nil,
 				sharedInformers.Ark().V1().Restores(),
 				client.ArkV1(), // restoreClient
 				NewBackupTracker(),
+				sharedInformers.Ark().V1().BackupTombstones(),
+				client.ArkV1(), // backupTombstoneClient
+				0,              // backupTombstoneRetention
+				metrics.NewServerMetrics(),
 			).(*backupDeletionController)
 
 			fakeClock := &clock.FakeClock{}
@@ -582,3 +1200,87 @@ func TestBackupDeletionControllerDeleteExpiredRequests(t *testing.T) {
 		})
 	}
 }
+
+func TestBackupDeletionControllerDeleteExpiredBackupTombstones(t *testing.T) {
+	now := time.Date(2018, 4, 4, 12, 0, 0, 0, time.UTC)
+
+	tombstone := func(name string, created time.Time) *v1.BackupTombstone {
+		return &v1.BackupTombstone{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "ns",
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(created),
+			},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		retention         time.Duration
+		tombstones        []*v1.BackupTombstone
+		expectedDeletions []string
+	}{
+		{
+			name:      "no tombstones",
+			retention: 24 * time.Hour,
+		},
+		{
+			name:      "tombstones older than the retention are deleted",
+			retention: 24 * time.Hour,
+			tombstones: []*v1.BackupTombstone{
+				tombstone("unexpired", now.Add(-23*time.Hour)),
+				tombstone("expired-1", now.Add(-24*time.Hour)),
+				tombstone("expired-2", now.Add(-30*24*time.Hour)),
+			},
+			expectedDeletions: []string{"expired-1", "expired-2"},
+		},
+		{
+			name: "tombstones are kept when they're disabled",
+			tombstones: []*v1.BackupTombstone{
+				tombstone("old", now.Add(-30*24*time.Hour)),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			sharedInformers := informers.NewSharedInformerFactory(client, 0)
+
+			controller := NewBackupDeletionController(
+				arktest.NewLogger(),
+				sharedInformers.Ark().V1().DeleteBackupRequests(),
+				client.ArkV1(), // deleteBackupRequestClient
+				client.ArkV1(), // backupClient
+				nil,            // snapshotService
+				nil,            // csiSnapshotter
+				0,              // snapshotDeletionBatchSize
+				nil,            // backupService
+				"bucket",
+				nil,
+				sharedInformers.Ark().V1().Restores(),
+				client.ArkV1(), // restoreClient
+				NewBackupTracker(),
+				sharedInformers.Ark().V1().BackupTombstones(),
+				client.ArkV1(), // backupTombstoneClient
+				test.retention,
+				metrics.NewServerMetrics(),
+			).(*backupDeletionController)
+			controller.clock = clock.NewFakeClock(now)
+
+			for i := range test.tombstones {
+				sharedInformers.Ark().V1().BackupTombstones().Informer().GetStore().Add(test.tombstones[i])
+			}
+
+			controller.deleteExpiredBackupTombstones()
+
+			var actualDeletions []string
+			for _, action := range client.Actions() {
+				actualDeletions = append(actualDeletions, action.(core.DeleteAction).GetName())
+			}
+			sort.Strings(actualDeletions)
+
+			assert.Equal(t, test.expectedDeletions, actualDeletions)
+		})
+	}
+}
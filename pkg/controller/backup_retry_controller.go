@@ -0,0 +1,217 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions/ark/v1"
+	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
+	"github.com/heptio/ark/pkg/metrics"
+	kubeutil "github.com/heptio/ark/pkg/util/kube"
+)
+
+// backupRetryController retries backups that fail by creating new backups with the
+// same spec, waiting longer before each retry.
+type backupRetryController struct {
+	*genericController
+
+	retries      int
+	backoff      time.Duration
+	backupLister listers.BackupLister
+	backupClient arkv1client.BackupsGetter
+}
+
+// NewBackupRetryController constructs a new backupRetryController that retries each
+// failed backup up to retries times, waiting backoff before the first retry and twice
+// as long before each one after that.
+func NewBackupRetryController(
+	logger logrus.FieldLogger,
+	retries int,
+	backoff time.Duration,
+	backupInformer informers.BackupInformer,
+	backupClient arkv1client.BackupsGetter,
+	metrics *metrics.ServerMetrics,
+) Interface {
+	c := &backupRetryController{
+		genericController: newGenericController("backup-retry", logger),
+		retries:           retries,
+		backoff:           backoff,
+		backupLister:      backupInformer.Lister(),
+		backupClient:      backupClient,
+	}
+
+	c.syncHandler = c.processQueueItem
+	c.metrics = metrics
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters, backupInformer.Informer().HasSynced)
+
+	// Only backups seen to fail are retried, rather than every failed backup
+	// that exists when the server starts, so that backups that failed long ago
+	// aren't retried again once their retries have been garbage-collected.
+	backupInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oldBackup := oldObj.(*api.Backup)
+				newBackup := newObj.(*api.Backup)
+
+				if oldBackup.Status.Phase == api.BackupPhaseFailed || newBackup.Status.Phase != api.BackupPhaseFailed {
+					return
+				}
+
+				key, err := cache.MetaNamespaceKeyFunc(newBackup)
+				if err != nil {
+					c.logger.WithError(errors.WithStack(err)).WithField("backup", newBackup).Error("Error creating queue key, item not added to queue")
+					return
+				}
+
+				attempt, err := retryAttempt(newBackup)
+				if err != nil {
+					c.logger.WithError(err).WithField("backup", key).Error("Error getting backup's retry attempt, not retrying it")
+					return
+				}
+
+				c.queue.AddAfter(key, c.retryDelay(attempt))
+			},
+		},
+	)
+
+	return c
+}
+
+// retryDelay returns how long to wait before retrying a backup that failed on the
+// specified attempt, where the first backup is attempt 0.
+func (c *backupRetryController) retryDelay(attempt int) time.Duration {
+	return c.backoff * time.Duration(1<<uint(attempt))
+}
+
+func (c *backupRetryController) processQueueItem(key string) error {
+	log := c.logger.WithField("backup", key)
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return errors.Wrap(err, "error splitting queue key")
+	}
+
+	backup, err := c.backupLister.Backups(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Debug("Unable to find backup")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting backup")
+	}
+
+	// only failed backups are retried; backups that failed validation, or were
+	// skipped, would just fail again
+	if backup.Status.Phase != api.BackupPhaseFailed {
+		return nil
+	}
+
+	attempt, err := retryAttempt(backup)
+	if err != nil {
+		return err
+	}
+
+	if attempt >= c.retries {
+		log.WithField("retries", attempt).Info("Backup failed and has been retried the maximum number of times")
+		return nil
+	}
+
+	retry := newBackupRetry(backup, attempt+1)
+	log = log.WithField("retry", kubeutil.NamespaceAndName(retry))
+
+	log.Info("Retrying failed backup")
+	if _, err := c.backupClient.Backups(retry.Namespace).Create(retry); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			log.Debug("Backup has already been retried")
+			return nil
+		}
+		return errors.Wrap(err, "error creating backup")
+	}
+
+	return nil
+}
+
+// shortenWithHash returns s if it's no longer than maxLength, or else its first characters
+// followed by a hash of it, to keep shortened values unique, maxLength characters in all.
+func shortenWithHash(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+
+	sum := sha256.Sum256([]byte(s))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+	return s[:maxLength-len(suffix)] + suffix
+}
+
+// retryAttempt returns which retry of a failed backup backup is, or 0 if it's not a retry.
+func retryAttempt(backup *api.Backup) (int, error) {
+	value, found := backup.Labels[api.RetryAttemptLabel]
+	if !found {
+		return 0, nil
+	}
+
+	attempt, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error parsing %s label", api.RetryAttemptLabel)
+	}
+
+	return attempt, nil
+}
+
+// newBackupRetry returns a new backup with the same spec and labels as backup, labeled as
+// the specified retry attempt of the first backup to fail. Backup names are used as label
+// values, so the label and the retry's name are shortened to fit in one if needed.
+func newBackupRetry(backup *api.Backup, attempt int) *api.Backup {
+	original := backup.Labels[api.RetryOfLabel]
+	if original == "" {
+		original = shortenWithHash(backup.Name, validation.LabelValueMaxLength)
+	}
+	suffix := fmt.Sprintf("-retry-%d", attempt)
+
+	labels := make(map[string]string)
+	for k, v := range backup.Labels {
+		labels[k] = v
+	}
+	labels[api.RetryOfLabel] = original
+	labels[api.RetryAttemptLabel] = strconv.Itoa(attempt)
+
+	return &api.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: backup.Namespace,
+			// the name is fixed for each attempt, so the same retry can't be
+			// created twice
+			Name:   shortenWithHash(original, validation.LabelValueMaxLength-len(suffix)) + suffix,
+			Labels: labels,
+		},
+		Spec: *backup.Spec.DeepCopy(),
+	}
+}
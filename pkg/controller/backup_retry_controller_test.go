@@ -0,0 +1,223 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	core "k8s.io/client-go/testing"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	"github.com/heptio/ark/pkg/metrics"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestBackupRetryControllerProcessQueueItem(t *testing.T) {
+	newBackup := func(name string, phase api.BackupPhase) *arktest.TestBackup {
+		return arktest.NewTestBackup().WithNamespace(api.DefaultNamespace).WithName(name).
+			WithPhase(phase).WithIncludedNamespaces("ns-1")
+	}
+
+	tests := []struct {
+		name          string
+		backup        *api.Backup
+		existing      *api.Backup
+		expectedRetry *api.Backup
+		expectErr     bool
+	}{
+		{
+			name: "backup that doesn't exist isn't retried",
+		},
+		{
+			name:   "completed backup isn't retried",
+			backup: newBackup("backup-1", api.BackupPhaseCompleted).Backup,
+		},
+		{
+			name:   "backup that failed validation isn't retried",
+			backup: newBackup("backup-1", api.BackupPhaseFailedValidation).Backup,
+		},
+		{
+			name:   "failed backup is retried",
+			backup: newBackup("backup-1", api.BackupPhaseFailed).WithLabel(api.ScheduleNameLabel, "schedule-1").Backup,
+			expectedRetry: newBackup("backup-1-retry-1", "").
+				WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithLabel(api.RetryOfLabel, "backup-1").
+				WithLabel(api.RetryAttemptLabel, "1").
+				Backup,
+		},
+		{
+			name: "failed retry is retried",
+			backup: newBackup("backup-1-retry-1", api.BackupPhaseFailed).
+				WithLabel(api.RetryOfLabel, "backup-1").
+				WithLabel(api.RetryAttemptLabel, "1").
+				Backup,
+			expectedRetry: newBackup("backup-1-retry-2", "").
+				WithLabel(api.RetryOfLabel, "backup-1").
+				WithLabel(api.RetryAttemptLabel, "2").
+				Backup,
+		},
+		{
+			name: "backup that's been retried the maximum number of times isn't retried",
+			backup: newBackup("backup-1-retry-2", api.BackupPhaseFailed).
+				WithLabel(api.RetryOfLabel, "backup-1").
+				WithLabel(api.RetryAttemptLabel, "2").
+				Backup,
+		},
+		{
+			name:     "backup that's already been retried isn't retried again",
+			backup:   newBackup("backup-1", api.BackupPhaseFailed).Backup,
+			existing: newBackup("backup-1-retry-1", api.BackupPhaseInProgress).Backup,
+		},
+		{
+			name:      "invalid retry attempt label returns an error",
+			backup:    newBackup("backup-1", api.BackupPhaseFailed).WithLabel(api.RetryAttemptLabel, "one").Backup,
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+			)
+
+			if test.existing != nil {
+				client = fake.NewSimpleClientset(test.existing)
+			}
+
+			controller := NewBackupRetryController(
+				arktest.NewLogger(),
+				2,
+				time.Minute,
+				sharedInformers.Ark().V1().Backups(),
+				client.ArkV1(),
+				metrics.NewServerMetrics(),
+			).(*backupRetryController)
+
+			if test.backup != nil {
+				require.NoError(t, sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(test.backup))
+			}
+
+			key := api.DefaultNamespace + "/backup-1"
+			if test.backup != nil {
+				key = api.DefaultNamespace + "/" + test.backup.Name
+			}
+
+			err := controller.processQueueItem(key)
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var created []*api.Backup
+			for _, action := range client.Actions() {
+				if createAction, ok := action.(core.CreateAction); ok {
+					created = append(created, createAction.GetObject().(*api.Backup))
+				}
+			}
+
+			if test.existing != nil {
+				// the retry is attempted, but the existing one isn't replaced
+				require.Len(t, created, 1)
+				assert.Equal(t, test.existing.Name, created[0].Name)
+
+				res, err := client.ArkV1().Backups(api.DefaultNamespace).Get(test.existing.Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				assert.Equal(t, test.existing, res)
+				return
+			}
+
+			if test.expectedRetry == nil {
+				assert.Empty(t, created)
+				return
+			}
+
+			require.Len(t, created, 1)
+			assert.Equal(t, test.expectedRetry, created[0])
+		})
+	}
+}
+
+func TestBackupRetryControllerRetryDelay(t *testing.T) {
+	controller := &backupRetryController{backoff: time.Minute}
+
+	assert.Equal(t, time.Minute, controller.retryDelay(0))
+	assert.Equal(t, 2*time.Minute, controller.retryDelay(1))
+	assert.Equal(t, 4*time.Minute, controller.retryDelay(2))
+}
+
+func TestNewBackupRetryCopiesSpec(t *testing.T) {
+	backup := arktest.NewTestBackup().WithNamespace(api.DefaultNamespace).WithName("backup-1").
+		WithPhase(api.BackupPhaseFailed).WithTTL(time.Hour).Backup
+	backup.Annotations = map[string]string{"foo": "bar"}
+	backup.Status.ValidationErrors = []string{"error"}
+
+	retry := newBackupRetry(backup, 1)
+
+	assert.Equal(t, metav1.Duration{Duration: time.Hour}, retry.Spec.TTL)
+	assert.Empty(t, retry.Annotations)
+	assert.Equal(t, api.BackupStatus{}, retry.Status)
+
+	// the retry's spec is a copy
+	retry.Spec.IncludedNamespaces = append(retry.Spec.IncludedNamespaces, "ns-2")
+	assert.Empty(t, backup.Spec.IncludedNamespaces)
+}
+
+func TestNewBackupRetryShortensLongNames(t *testing.T) {
+	// a name that fits in a label value is the label's value, but the retry's name is
+	// shortened to fit in one too
+	name := strings.Repeat("a", 63)
+	backup := arktest.NewTestBackup().WithNamespace(api.DefaultNamespace).WithName(name).
+		WithPhase(api.BackupPhaseFailed).Backup
+
+	retry := newBackupRetry(backup, 1)
+
+	assert.Equal(t, name, retry.Labels[api.RetryOfLabel])
+	assert.Empty(t, validation.IsValidLabelValue(retry.Name))
+	assert.True(t, strings.HasPrefix(retry.Name, strings.Repeat("a", 46)+"-"))
+	assert.True(t, strings.HasSuffix(retry.Name, "-retry-1"))
+
+	// retries of the retry keep its label, and their names differ only in the attempt
+	retry.Status.Phase = api.BackupPhaseFailed
+	next := newBackupRetry(retry, 2)
+
+	assert.Equal(t, name, next.Labels[api.RetryOfLabel])
+	assert.Empty(t, validation.IsValidLabelValue(next.Name))
+	assert.Equal(t, strings.TrimSuffix(retry.Name, "-retry-1")+"-retry-2", next.Name)
+
+	// a name that doesn't fit in a label value is shortened for the label
+	backup.Name = strings.Repeat("a", 100)
+	retry = newBackupRetry(backup, 1)
+
+	assert.Empty(t, validation.IsValidLabelValue(retry.Labels[api.RetryOfLabel]))
+	assert.NotEqual(t, name, retry.Labels[api.RetryOfLabel])
+	assert.Empty(t, validation.IsValidLabelValue(retry.Name))
+
+	// names that fit aren't shortened
+	assert.Equal(t, "backup-1-retry-1", newBackupRetry(arktest.NewTestBackup().WithName("backup-1").Backup, 1).Name)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+// BackupStreamConsumer receives the tarball of each backup as it's written, e.g. to
+// replicate it elsewhere in real time.
+type BackupStreamConsumer interface {
+	// Open returns the writer the backup's tarball is streamed to. It's closed once
+	// the backup has been written.
+	Open(backup *api.Backup) (io.WriteCloser, error)
+}
+
+// BackupStream is a consumer the tarball of each backup is streamed to while it's
+// uploaded. If Required, failing to stream a backup fails it; otherwise streaming is
+// best-effort, and the backup is only warned about.
+type BackupStream struct {
+	Consumer BackupStreamConsumer
+	Required bool
+}
+
+// streamTee writes to a primary writer and tees what it writes to a secondary one.
+// Everything is written to the primary writer before the secondary one, so errors
+// writing to the secondary writer never affect what the primary one gets.
+type streamTee struct {
+	primary   io.Writer
+	secondary io.Writer
+	required  bool
+	err       error
+}
+
+func newStreamTee(primary, secondary io.Writer, required bool) *streamTee {
+	return &streamTee{
+		primary:   primary,
+		secondary: secondary,
+		required:  required,
+	}
+}
+
+// Write writes p to the primary writer, then to the secondary one. An error writing
+// to the secondary writer is only returned if it's required; otherwise it's recorded,
+// and nothing more is written to the secondary writer.
+func (t *streamTee) Write(p []byte) (int, error) {
+	n, err := t.primary.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	if t.err != nil {
+		if t.required {
+			return n, t.err
+		}
+		return n, nil
+	}
+
+	if m, err := t.secondary.Write(p); err != nil {
+		t.err = errors.Wrap(err, "error streaming backup")
+	} else if m != len(p) {
+		t.err = errors.Wrap(io.ErrShortWrite, "error streaming backup")
+	}
+
+	if t.err != nil && t.required {
+		return n, t.err
+	}
+	return n, nil
+}
+
+// Err returns the error writing to the secondary writer, if any.
+func (t *streamTee) Err() error {
+	return t.err
+}
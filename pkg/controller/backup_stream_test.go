@@ -0,0 +1,232 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/backup"
+	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	"github.com/heptio/ark/pkg/metrics"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+type fakeStreamWriter struct {
+	bytes.Buffer
+	writeErr error
+	closeErr error
+	closed   bool
+}
+
+func (w *fakeStreamWriter) Write(p []byte) (int, error) {
+	if w.writeErr != nil {
+		return 0, w.writeErr
+	}
+	return w.Buffer.Write(p)
+}
+
+func (w *fakeStreamWriter) Close() error {
+	w.closed = true
+	return w.closeErr
+}
+
+type fakeStreamConsumer struct {
+	writer  *fakeStreamWriter
+	openErr error
+}
+
+func (c *fakeStreamConsumer) Open(backup *v1.Backup) (io.WriteCloser, error) {
+	if c.openErr != nil {
+		return nil, c.openErr
+	}
+	return c.writer, nil
+}
+
+// writingBackupper writes data as a backup's tarball, returning the error, if any.
+type writingBackupper struct {
+	data []byte
+}
+
+func (b *writingBackupper) Backup(backup *v1.Backup, data, log io.Writer, actions []backup.ItemAction, progress *backup.Progress) error {
+	_, err := data.Write(b.data)
+	return err
+}
+
+func TestStreamTee(t *testing.T) {
+	tests := []struct {
+		name             string
+		required         bool
+		writeErr         error
+		expectedErr      bool
+		expectedStreamed string
+	}{
+		{
+			name:             "everything is written to both writers",
+			expectedStreamed: "foobar",
+		},
+		{
+			name:     "best-effort stream error isn't returned",
+			writeErr: errors.New("stream"),
+		},
+		{
+			name:        "required stream error is returned",
+			required:    true,
+			writeErr:    errors.New("stream"),
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				primary   = new(bytes.Buffer)
+				secondary = &fakeStreamWriter{writeErr: test.writeErr}
+				tee       = newStreamTee(primary, secondary, test.required)
+			)
+
+			_, err1 := tee.Write([]byte("foo"))
+			_, err2 := tee.Write([]byte("bar"))
+
+			assert.Equal(t, test.expectedErr, err1 != nil)
+			assert.Equal(t, test.expectedErr, err2 != nil)
+			assert.Equal(t, test.writeErr != nil, tee.Err() != nil)
+			assert.Equal(t, "foobar", primary.String())
+			assert.Equal(t, test.expectedStreamed, secondary.String())
+		})
+	}
+}
+
+func TestRunBackupStream(t *testing.T) {
+	tests := []struct {
+		name             string
+		required         bool
+		openErr          error
+		writeErr         error
+		closeErr         error
+		expectErr        bool
+		expectedPhase    v1.BackupPhase
+		expectedWarnings int
+		expectedStreamed string
+	}{
+		{
+			name:             "backup is streamed",
+			expectedPhase:    v1.BackupPhaseCompleted,
+			expectedStreamed: "tarball",
+		},
+		{
+			name:             "best-effort stream that can't be opened only warns",
+			openErr:          errors.New("open"),
+			expectedPhase:    v1.BackupPhaseCompleted,
+			expectedWarnings: 1,
+		},
+		{
+			name:             "best-effort stream write error only warns",
+			writeErr:         errors.New("write"),
+			expectedPhase:    v1.BackupPhaseCompleted,
+			expectedWarnings: 1,
+		},
+		{
+			name:      "required stream that can't be opened fails the backup",
+			required:  true,
+			openErr:   errors.New("open"),
+			expectErr: true,
+			// the backup is failed by processBackup when runBackup returns an error
+			expectedPhase: v1.BackupPhaseInProgress,
+		},
+		{
+			name:          "required stream write error fails the backup",
+			required:      true,
+			writeErr:      errors.New("write"),
+			expectErr:     true,
+			expectedPhase: v1.BackupPhaseFailed,
+		},
+		{
+			name:             "required stream close error fails the backup",
+			required:         true,
+			closeErr:         errors.New("close"),
+			expectErr:        true,
+			expectedPhase:    v1.BackupPhaseFailed,
+			expectedStreamed: "tarball",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				cloudBackups    = &arktest.BackupService{}
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				pluginManager   = &MockManager{}
+				writer          = &fakeStreamWriter{writeErr: test.writeErr, closeErr: test.closeErr}
+				stream          = &BackupStream{
+					Consumer: &fakeStreamConsumer{writer: writer, openErr: test.openErr},
+					Required: test.required,
+				}
+			)
+
+			c := NewBackupController(
+				sharedInformers.Ark().V1().Backups(),
+				client.ArkV1(),
+				&writingBackupper{data: []byte("tarball")},
+				cloudBackups,
+				"bucket",
+				false,
+				nil,
+				0,
+				0,
+				stream,
+				false,
+				0,
+				time.Minute,
+				time.Hour,
+				"",
+				nil,
+				arktest.NewLogger(),
+				pluginManager,
+				NewBackupTracker(),
+				metrics.NewServerMetrics(),
+			).(*backupController)
+
+			testBackup := arktest.NewTestBackup().WithName("backup-1").WithPhase(v1.BackupPhaseInProgress).Backup
+
+			pluginManager.On("GetBackupItemActions", testBackup.Name).Return(nil, nil)
+			pluginManager.On("CloseBackupItemActions", testBackup.Name).Return(nil)
+			cloudBackups.On("UploadBackup", "bucket", testBackup.Name, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			cloudBackups.On("UploadBackupMetadata", "bucket", testBackup.Name, mock.Anything).Return(nil)
+
+			err := c.runBackup(testBackup, "bucket")
+			assert.Equal(t, test.expectErr, err != nil)
+
+			assert.Equal(t, test.expectedPhase, testBackup.Status.Phase)
+			assert.Len(t, testBackup.Status.Warnings, test.expectedWarnings)
+			assert.Equal(t, test.expectedStreamed, writer.String())
+			if test.openErr == nil {
+				require.True(t, writer.closed)
+			}
+		})
+	}
+}
@@ -25,25 +25,34 @@ import (
 
 	kuberrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
 
+	"github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/cloudprovider"
 	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions/ark/v1"
+	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
 	"github.com/heptio/ark/pkg/util/kube"
 )
 
 type backupSyncController struct {
-	client        arkv1client.BackupsGetter
-	backupService cloudprovider.BackupService
-	bucket        string
-	syncPeriod    time.Duration
-	logger        logrus.FieldLogger
+	client              arkv1client.BackupsGetter
+	backupLister        listers.BackupLister
+	backupListerSynced  cache.InformerSynced
+	backupService       cloudprovider.BackupService
+	bucket              string
+	syncPeriod          time.Duration
+	reconcileExpiration bool
+	logger              logrus.FieldLogger
 }
 
 func NewBackupSyncController(
 	client arkv1client.BackupsGetter,
+	backupInformer informers.BackupInformer,
 	backupService cloudprovider.BackupService,
 	bucket string,
 	syncPeriod time.Duration,
+	reconcileExpiration bool,
 	logger logrus.FieldLogger,
 ) Interface {
 	if syncPeriod < time.Minute {
@@ -51,11 +60,14 @@ func NewBackupSyncController(
 		syncPeriod = time.Minute
 	}
 	return &backupSyncController{
-		client:        client,
-		backupService: backupService,
-		bucket:        bucket,
-		syncPeriod:    syncPeriod,
-		logger:        logger,
+		client:              client,
+		backupLister:        backupInformer.Lister(),
+		backupListerSynced:  backupInformer.Informer().HasSynced,
+		backupService:       backupService,
+		bucket:              bucket,
+		syncPeriod:          syncPeriod,
+		reconcileExpiration: reconcileExpiration,
+		logger:              logger.WithField("controller", "backup-sync"),
 	}
 }
 
@@ -64,6 +76,13 @@ func NewBackupSyncController(
 // receives on the ctx.Done() channel.
 func (c *backupSyncController) Run(ctx context.Context, workers int) error {
 	c.logger.Info("Running backup sync controller")
+
+	c.logger.Info("Waiting for caches to sync")
+	if !cache.WaitForCacheSync(ctx.Done(), c.backupListerSynced) {
+		return errors.New("timed out waiting for caches to sync")
+	}
+	c.logger.Info("Caches are synced")
+
 	wait.Until(c.run, c.syncPeriod, ctx.Done())
 	return nil
 }
@@ -82,8 +101,58 @@ func (c *backupSyncController) run() {
 		logContext.Info("Syncing backup")
 
 		cloudBackup.ResourceVersion = ""
-		if _, err := c.client.Backups(cloudBackup.Namespace).Create(cloudBackup); err != nil && !kuberrs.IsAlreadyExists(err) {
+		if cloudBackup.Annotations == nil {
+			cloudBackup.Annotations = make(map[string]string)
+		}
+		cloudBackup.Annotations[v1.SyncedFromObjectStorageAnnotation] = "true"
+		_, err := c.client.Backups(cloudBackup.Namespace).Create(cloudBackup)
+		switch {
+		case kuberrs.IsAlreadyExists(err):
+			if err := c.reconcileBackupExpiration(cloudBackup, logContext); err != nil {
+				logContext.WithError(err).Error("Error reconciling backup expiration with object storage")
+			}
+		case err != nil:
 			logContext.WithError(errors.WithStack(err)).Error("Error syncing backup from object storage")
 		}
 	}
 }
+
+// reconcileBackupExpiration compares the expiration of an existing Backup API object
+// with the expiration recorded in object storage, which is the source of truth. If they
+// differ, a warning is logged and, if the controller is configured to do so, the API
+// object is patched to match object storage.
+func (c *backupSyncController) reconcileBackupExpiration(cloudBackup *v1.Backup, log logrus.FieldLogger) error {
+	backup, err := c.backupLister.Backups(cloudBackup.Namespace).Get(cloudBackup.Name)
+	if kuberrs.IsNotFound(err) {
+		// the informer hasn't seen the backup yet; it'll be reconciled on the next sync
+		return nil
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if backup.Status.Expiration.Equal(&cloudBackup.Status.Expiration) {
+		return nil
+	}
+
+	log = log.WithFields(logrus.Fields{
+		"expiration":            backup.Status.Expiration.Time,
+		"objectStoreExpiration": cloudBackup.Status.Expiration.Time,
+	})
+
+	if !c.reconcileExpiration {
+		log.Warn("Backup's expiration differs from its metadata in object storage")
+		return nil
+	}
+
+	log.Warn("Backup's expiration differs from its metadata in object storage, updating it to match")
+
+	updated := backup.DeepCopy()
+	updated.Status.Expiration = cloudBackup.Status.Expiration
+
+	if _, err := patchBackup(backup, updated, c.client); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -22,11 +22,13 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	core "k8s.io/client-go/testing"
 
 	"github.com/heptio/ark/pkg/apis/ark/v1"
 	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
 	arktest "github.com/heptio/ark/pkg/util/test"
 )
 
@@ -56,16 +58,19 @@ func TestBackupSyncControllerRun(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			var (
-				bs     = &arktest.BackupService{}
-				client = fake.NewSimpleClientset()
-				logger = arktest.NewLogger()
+				bs              = &arktest.BackupService{}
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				logger          = arktest.NewLogger()
 			)
 
 			c := NewBackupSyncController(
 				client.ArkV1(),
+				sharedInformers.Ark().V1().Backups(),
 				bs,
 				"bucket",
 				time.Duration(0),
+				false,
 				logger,
 			).(*backupSyncController)
 
@@ -86,6 +91,78 @@ func TestBackupSyncControllerRun(t *testing.T) {
 				expectedActions = append(expectedActions, action)
 			}
 
+			assert.Equal(t, expectedActions, client.Actions())
+			for _, cloudBackup := range test.cloudBackups {
+				assert.Equal(t, "true", cloudBackup.Annotations[v1.SyncedFromObjectStorageAnnotation])
+			}
+			bs.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBackupSyncControllerReconcileExpiration(t *testing.T) {
+	fakeClock := time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                string
+		existingExpiration  time.Time
+		reconcileExpiration bool
+		expectedPatch       string
+	}{
+		{
+			name:                "matching expiration is not patched",
+			existingExpiration:  fakeClock,
+			reconcileExpiration: true,
+		},
+		{
+			name:               "differing expiration is not patched when reconciliation is disabled",
+			existingExpiration: fakeClock.Add(time.Hour),
+		},
+		{
+			name:                "differing expiration is patched when reconciliation is enabled",
+			existingExpiration:  fakeClock.Add(time.Hour),
+			reconcileExpiration: true,
+			expectedPatch:       `{"status":{"expiration":"2018-04-01T12:00:00Z"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				bs              = &arktest.BackupService{}
+				existing        = arktest.NewTestBackup().WithNamespace("ns-1").WithName("backup-1").WithExpiration(test.existingExpiration).Backup
+				cloudBackup     = arktest.NewTestBackup().WithNamespace("ns-1").WithName("backup-1").WithExpiration(fakeClock).Backup
+				client          = fake.NewSimpleClientset(existing)
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+			)
+
+			c := NewBackupSyncController(
+				client.ArkV1(),
+				sharedInformers.Ark().V1().Backups(),
+				bs,
+				"bucket",
+				time.Duration(0),
+				test.reconcileExpiration,
+				arktest.NewLogger(),
+			).(*backupSyncController)
+
+			require.NoError(t, sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(existing))
+			bs.On("GetAllBackups", "bucket").Return([]*v1.Backup{cloudBackup}, nil)
+
+			c.run()
+
+			expectedActions := []core.Action{
+				core.NewCreateAction(v1.SchemeGroupVersion.WithResource("backups"), "ns-1", cloudBackup),
+			}
+			if test.expectedPatch != "" {
+				expectedActions = append(expectedActions, core.NewPatchAction(
+					v1.SchemeGroupVersion.WithResource("backups"),
+					"ns-1",
+					"backup-1",
+					[]byte(test.expectedPatch),
+				))
+			}
+
 			assert.Equal(t, expectedActions, client.Actions())
 			bs.AssertExpectations(t)
 		})
@@ -76,7 +76,7 @@ func NewDownloadRequestController(
 		bucket:                      bucket,
 		queue:                       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "downloadrequest"),
 		clock:                       &clock.RealClock{},
-		logger:                      logger,
+		logger:                      logger.WithField("controller", "download-request"),
 	}
 
 	c.syncHandler = c.processDownloadRequest
@@ -17,6 +17,7 @@ limitations under the License.
 package controller
 
 import (
+	"sort"
 	"time"
 
 	pkgbackup "github.com/heptio/ark/pkg/backup"
@@ -24,33 +25,91 @@ import (
 	"github.com/sirupsen/logrus"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/client-go/tools/cache"
 
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/cloudprovider"
 	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
 	informers "github.com/heptio/ark/pkg/generated/informers/externalversions/ark/v1"
 	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
+	"github.com/heptio/ark/pkg/metrics"
 )
 
-// gcController creates DeleteBackupRequests for expired backups.
+// gcController creates DeleteBackupRequests for expired backups, or first moves them to its
+// recycle bin if a recycle bin retention is configured.
 type gcController struct {
 	*genericController
 
-	logger                    logrus.FieldLogger
+	namespace                 string
 	backupLister              listers.BackupLister
+	scheduleLister            listers.ScheduleLister
+	backupClient              arkv1client.BackupsGetter
 	deleteBackupRequestClient arkv1client.DeleteBackupRequestsGetter
+	backupService             cloudprovider.BackupService
+	bucket                    string
 	syncPeriod                time.Duration
+	keepLastScheduledBackup   bool
+	minRetention              time.Duration
+	failedBackupTTL           time.Duration
+	snapshotsMissingTTL       time.Duration
+	maintenanceWindow         *MaintenanceWindow
+	deleteMissingContents     bool
+	recycleBinRetention       time.Duration
+	orphanedBackupRetention   time.Duration
+	retentionClasses          map[string]time.Duration
+	gcReportClient            arkv1client.GCReportsGetter
+	reportPeriod              time.Duration
+	reportRetention           time.Duration
+	transientErrorBackoff     time.Duration
+	report                    *gcReport
+	// reportObject is the GCReport the current period's decisions were last saved to,
+	// and reportResumed is whether the decisions of the period in progress when the
+	// server started were loaded from their GCReport.
+	reportObject     *api.GCReport
+	reportResumed    bool
+	enqueueChunkSize int
+	enqueueWindow    time.Duration
 
 	clock clock.Clock
 }
 
+const (
+	// gcEnqueueChunkSize is the number of backups each resync enqueues at a time.
+	gcEnqueueChunkSize = 500
+
+	// gcEnqueueWindow is the longest each resync spreads enqueuing its chunks of backups
+	// over, so that very large numbers of backups aren't all processed in a burst.
+	gcEnqueueWindow = time.Minute
+)
+
 // NewGCController constructs a new gcController.
 func NewGCController(
 	logger logrus.FieldLogger,
+	namespace string,
 	backupInformer informers.BackupInformer,
+	scheduleInformer informers.ScheduleInformer,
+	backupClient arkv1client.BackupsGetter,
 	deleteBackupRequestClient arkv1client.DeleteBackupRequestsGetter,
+	backupService cloudprovider.BackupService,
+	bucket string,
 	syncPeriod time.Duration,
+	keepLastScheduledBackup bool,
+	minRetention time.Duration,
+	failedBackupTTL time.Duration,
+	snapshotsMissingTTL time.Duration,
+	maintenanceWindow *MaintenanceWindow,
+	deleteMissingContents bool,
+	recycleBinRetention time.Duration,
+	orphanedBackupRetention time.Duration,
+	retentionClasses map[string]time.Duration,
+	gcReportClient arkv1client.GCReportsGetter,
+	reportPeriod time.Duration,
+	reportRetention time.Duration,
+	transientErrorBackoff time.Duration,
+	metrics *metrics.ServerMetrics,
 ) Interface {
 	if syncPeriod < time.Minute {
 		logger.WithField("syncPeriod", syncPeriod).Info("Provided GC sync period is too short. Setting to 1 minute")
@@ -59,15 +118,39 @@ func NewGCController(
 
 	c := &gcController{
 		genericController:         newGenericController("gc-controller", logger),
+		namespace:                 namespace,
 		syncPeriod:                syncPeriod,
+		keepLastScheduledBackup:   keepLastScheduledBackup,
+		minRetention:              minRetention,
+		failedBackupTTL:           failedBackupTTL,
+		snapshotsMissingTTL:       snapshotsMissingTTL,
+		maintenanceWindow:         maintenanceWindow,
+		deleteMissingContents:     deleteMissingContents,
+		recycleBinRetention:       recycleBinRetention,
+		orphanedBackupRetention:   orphanedBackupRetention,
+		retentionClasses:          retentionClasses,
+		gcReportClient:            gcReportClient,
+		reportPeriod:              reportPeriod,
+		reportRetention:           reportRetention,
+		transientErrorBackoff:     transientErrorBackoff,
+		enqueueChunkSize:          gcEnqueueChunkSize,
+		enqueueWindow:             gcEnqueueWindow,
 		clock:                     clock.RealClock{},
 		backupLister:              backupInformer.Lister(),
+		scheduleLister:            scheduleInformer.Lister(),
+		backupClient:              backupClient,
 		deleteBackupRequestClient: deleteBackupRequestClient,
-		logger: logger,
+		backupService:             backupService,
+		bucket:                    bucket,
+	}
+
+	if reportPeriod > 0 {
+		c.report = newGCReport(c.clock.Now())
 	}
 
 	c.syncHandler = c.processQueueItem
-	c.cacheSyncWaiters = append(c.cacheSyncWaiters, backupInformer.Informer().HasSynced)
+	c.metrics = metrics
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters, backupInformer.Informer().HasSynced, scheduleInformer.Informer().HasSynced)
 
 	c.resyncPeriod = syncPeriod
 	c.resyncFunc = c.enqueueAllBackups
@@ -82,23 +165,119 @@ func NewGCController(
 	return c
 }
 
-// enqueueAllBackups lists all backups from cache and enqueues all of them so we can check each one
-// for expiration.
+// enqueueAllBackups lists all backups in the controller's namespace (or in all namespaces, if
+// it's empty) from cache and enqueues all of them so we can check each one for expiration.
+// The first chunk of backups is enqueued right away, and the rest are enqueued after evenly
+// spaced delays within the enqueue window, or half the sync period if that's shorter.
 func (c *gcController) enqueueAllBackups() {
 	c.logger.Debug("gcController.enqueueAllBackups")
 
-	backups, err := c.backupLister.List(labels.Everything())
+	c.syncGCReport()
+
+	backups, err := c.backupLister.Backups(c.namespace).List(labels.Everything())
 	if err != nil {
 		c.logger.WithError(errors.WithStack(err)).Error("error listing backups")
 		return
 	}
 
+	keys := make([]string, 0, len(backups))
 	for _, backup := range backups {
-		c.enqueue(backup)
+		key, err := cache.MetaNamespaceKeyFunc(backup)
+		if err != nil {
+			c.logger.WithError(errors.WithStack(err)).Error("Error creating queue key, item not added to queue")
+			continue
+		}
+		keys = append(keys, key)
+	}
+	// sorted so each chunk holds the same backups from one resync to the next
+	sort.Strings(keys)
+
+	chunkSize := c.enqueueChunkSize
+	if chunkSize < 1 {
+		chunkSize = len(keys)
+	}
+	var chunks int
+	if len(keys) > 0 {
+		chunks = (len(keys) + chunkSize - 1) / chunkSize
+	}
+
+	window := c.enqueueWindow
+	if window > c.syncPeriod/2 {
+		window = c.syncPeriod / 2
+	}
+	var interval time.Duration
+	if chunks > 1 {
+		interval = window / time.Duration(chunks)
+	}
+
+	for i, key := range keys {
+		if delay := time.Duration(i/chunkSize) * interval; delay > 0 {
+			c.queue.AddAfter(key, delay)
+		} else {
+			c.queue.Add(key)
+		}
+	}
+
+	if chunks > 1 {
+		c.logger.WithFields(logrus.Fields{"backups": len(keys), "chunks": chunks, "interval": interval}).Debug("Enqueued backups in chunks")
+	}
+	if c.metrics != nil {
+		c.metrics.RegisterControllerResyncItemsEnqueued(c.name, len(keys))
 	}
 }
 
+// processQueueItem processes the backup with the given key. If that fails with a transient
+// API error, such as throttling, the backup is re-added to the queue after a backoff rather
+// than being retried right away.
 func (c *gcController) processQueueItem(key string) error {
+	err := c.processBackup(key)
+	if err == nil {
+		return nil
+	}
+
+	backoff, transient := c.transientErrorBackoffFor(err)
+	if !transient {
+		return err
+	}
+
+	c.logger.WithError(err).WithFields(logrus.Fields{"backup": key, "backoff": backoff}).Warn("Transient API error processing backup, re-adding it to the queue after a backoff")
+	c.queue.AddAfter(key, backoff)
+
+	return nil
+}
+
+// transientErrorBackoffFor returns whether err is, or wraps, an API error that a later
+// retry may not get, e.g. because the API server was throttling requests or overloaded,
+// and if so how long to wait before retrying: the transient error backoff, or the delay
+// the API server asked for if that's longer. Without a transient error backoff, no
+// errors are treated as transient.
+func (c *gcController) transientErrorBackoffFor(err error) (time.Duration, bool) {
+	if c.transientErrorBackoff <= 0 {
+		return 0, false
+	}
+
+	err = errors.Cause(err)
+
+	transient := apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err)
+	if !transient {
+		return 0, false
+	}
+
+	backoff := c.transientErrorBackoff
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok {
+		if delay := time.Duration(seconds) * time.Second; delay > backoff {
+			backoff = delay
+		}
+	}
+
+	return backoff, true
+}
+
+func (c *gcController) processBackup(key string) error {
 	log := c.logger.WithField("backup", key)
 
 	ns, name, err := cache.SplitMetaNamespaceKey(key)
@@ -106,6 +285,11 @@ func (c *gcController) processQueueItem(key string) error {
 		return errors.Wrap(err, "error splitting queue key")
 	}
 
+	if c.namespace != "" && ns != c.namespace {
+		log.Debug("Backup is not in the controller's namespace, skipping")
+		return nil
+	}
+
 	backup, err := c.backupLister.Backups(ns).Get(name)
 	if apierrors.IsNotFound(err) {
 		log.Debug("Unable to find backup")
@@ -115,29 +299,479 @@ func (c *gcController) processQueueItem(key string) error {
 		return errors.Wrap(err, "error getting backup")
 	}
 
+	if backup.Status.Phase == api.BackupPhasePendingDeletion {
+		return c.processRecycledBackup(log, backup)
+	}
+
+	// a backup whose contents have been deleted from object storage out-of-band
+	// can't be restored, so there's no reason to wait for it to expire
+	if c.deleteMissingContents && hasUsableContents(backup) && backup.DeletionTimestamp == nil {
+		exists, err := c.backupService.BackupContentsExist(c.bucket, backup.Name)
+		if err != nil {
+			return errors.Wrap(err, "error checking whether backup's contents exist in object storage")
+		}
+
+		if !exists {
+			log.Info("Backup's contents are missing from object storage. Creating a DeleteBackupRequest.")
+			return c.createDeleteBackupRequest(log, backup, "Backup's contents are missing from object storage")
+		}
+	}
+
+	if class := backup.Labels[api.RetentionClassLabel]; class != "" {
+		if _, found := c.retentionClasses[class]; !found {
+			log.WithField("retentionClass", class).Warn("Backup's retention class isn't configured in gcRetentionClasses, using its expiration")
+		}
+	}
+
+	expiration, reason := c.expiration(backup)
+	expireBy := func(t time.Time, why string) {
+		if expiration.IsZero() || t.Before(expiration) {
+			expiration = t
+			reason = why
+		}
+	}
+
+	// failed backups can't be restored, so they're garbage-collected sooner if configured,
+	// along with anything they left in object storage
+	if backup.Status.Phase == api.BackupPhaseFailed && c.failedBackupTTL > 0 {
+		expireBy(backup.CreationTimestamp.Add(c.failedBackupTTL), "Backup failed and its gcFailedBackupTTL elapsed")
+	}
+
+	// neither can all of the persistent volumes of backups whose snapshots are missing
+	if condition := getBackupCondition(backup, api.BackupConditionSnapshotsMissing); condition != nil && condition.Status == api.ConditionTrue && c.snapshotsMissingTTL > 0 {
+		expireBy(condition.LastTransitionTime.Add(c.snapshotsMissingTTL), "Backup's snapshots are missing and its gcSnapshotsMissingBackupTTL elapsed")
+	}
+
+	// backups of schedules that have been deleted are garbage-collected sooner if configured.
+	// Synced backups are skipped, since their schedules may not have been created in this
+	// cluster yet.
+	scheduleName := backup.Labels[api.ScheduleNameLabel]
+	var scheduleDeleted bool
+	if scheduleName != "" && c.orphanedBackupRetention > 0 && backup.Annotations[api.SyncedFromObjectStorageAnnotation] != "true" {
+		if _, err := c.scheduleLister.Schedules(backup.Namespace).Get(scheduleName); apierrors.IsNotFound(err) {
+			scheduleDeleted = true
+			expireBy(backup.CreationTimestamp.Add(c.orphanedBackupRetention), "Backup's schedule "+scheduleName+" was deleted and its gcOrphanedScheduleBackupRetention elapsed")
+		} else if err != nil {
+			return errors.Wrap(err, "error getting backup's schedule")
+		}
+	}
+
 	log = c.logger.WithFields(
 		logrus.Fields{
 			"backup":     key,
-			"expiration": backup.Status.Expiration.Time,
+			"expiration": expiration,
 		},
 	)
 
 	now := c.clock.Now()
 
-	expiration := backup.Status.Expiration.Time
 	if expiration.IsZero() || expiration.After(now) {
 		log.Debug("Backup has not expired yet, skipping")
 		return nil
 	}
 
+	if retainUntil := completionTime(backup).Add(c.minRetention); retainUntil.After(now) {
+		log.WithFields(logrus.Fields{
+			"minRetention": c.minRetention,
+			"retainUntil":  retainUntil,
+		}).Info("Backup has expired but is within the minimum retention period, not deleting it")
+		c.report.record(backup, api.GCReportDecisionRetained, "Backup has expired but is within its gcMinRetention", now)
+		return nil
+	}
+
+	// there's no schedule to keep the last backup of once it's been deleted
+	if scheduleName != "" && !scheduleDeleted && hasUsableContents(backup) {
+		last, err := c.isLastBackupOfSchedule(backup, scheduleName)
+		if err != nil {
+			return err
+		}
+
+		if last {
+			log = log.WithField("schedule", scheduleName)
+			if c.keepLastScheduledBackup {
+				log.Info("Backup has expired but is the last remaining backup of its schedule, not deleting it")
+				c.report.record(backup, api.GCReportDecisionRetained, "Backup has expired but is the last remaining backup of its schedule "+scheduleName, now)
+				return nil
+			}
+			log.Warn("Backup has expired and is the last remaining backup of its schedule; deleting it will leave the schedule with no restorable backups")
+		}
+	}
+
+	if c.recycleBinRetention > 0 {
+		log.Info("Backup has expired. Moving it to the recycle bin.")
+		if err := c.moveToRecycleBin(backup, reason); err != nil {
+			return err
+		}
+		c.report.record(backup, api.GCReportDecisionRecycled, reason, now)
+		return nil
+	}
+
+	if c.maintenanceWindow != nil && !c.maintenanceWindow.contains(now) {
+		log.Info("Backup has expired but it's outside the GC maintenance window, deferring its deletion")
+		c.report.record(backup, api.GCReportDecisionDeferred, reason+"; outside the gcMaintenanceWindow", now)
+		return nil
+	}
+
 	log.Info("Backup has expired. Creating a DeleteBackupRequest.")
 
+	return c.createDeleteBackupRequest(log, backup, reason)
+}
+
+// expiration returns when the backup expires, and why: its retention class's TTL after
+// it started, if it's labeled with a configured class, or else its status.expiration.
+// The start time is used rather than the creation time because backups synced from
+// object storage are created again, while their status is kept. Backups that never
+// started fall back to their creation time.
+func (c *gcController) expiration(backup *api.Backup) (time.Time, string) {
+	if class := backup.Labels[api.RetentionClassLabel]; class != "" {
+		if ttl, found := c.retentionClasses[class]; found {
+			start := backup.Status.StartTimestamp.Time
+			if start.IsZero() {
+				start = backup.CreationTimestamp.Time
+			}
+			return start.Add(ttl), "Backup's retention class " + class + " TTL elapsed"
+		}
+	}
+
+	return backup.Status.Expiration.Time, "Backup expired"
+}
+
+// moveToRecycleBin sets the backup's phase to PendingDeletion, recording its previous phase
+// so it can be recovered, and why it's being deleted.
+func (c *gcController) moveToRecycleBin(backup *api.Backup, reason string) error {
+	updated := backup.DeepCopy()
+	updated.Status.RecycleBin = &api.BackupRecycleBinStatus{
+		Timestamp:     metav1.NewTime(c.clock.Now()),
+		PreviousPhase: backup.Status.Phase,
+		Reason:        reason,
+	}
+	updated.Status.Phase = api.BackupPhasePendingDeletion
+
+	if _, err := patchBackup(backup, updated, c.backupClient); err != nil {
+		return errors.Wrap(err, "error moving backup to the recycle bin")
+	}
+
+	return nil
+}
+
+// processRecycledBackup creates a DeleteBackupRequest for a backup in the recycle bin once the
+// recycle bin retention has elapsed. If the recycle bin has since been disabled, that's right away.
+func (c *gcController) processRecycledBackup(log logrus.FieldLogger, backup *api.Backup) error {
+	now := c.clock.Now()
+
+	reason := "Backup was in the recycle bin"
+	if recycleBin := backup.Status.RecycleBin; recycleBin != nil {
+		if deleteAt := recycleBin.Timestamp.Add(c.recycleBinRetention); deleteAt.After(now) {
+			log.WithField("deleteAt", deleteAt).Debug("Backup is in the recycle bin until its gcRecycleBinRetention elapses, skipping")
+			return nil
+		}
+		if recycleBin.Reason != "" {
+			reason = recycleBin.Reason
+		}
+	}
+
+	if c.maintenanceWindow != nil && !c.maintenanceWindow.contains(now) {
+		log.Info("Backup's gcRecycleBinRetention has elapsed but it's outside the GC maintenance window, deferring its deletion")
+		c.report.record(backup, api.GCReportDecisionDeferred, reason+"; its gcRecycleBinRetention elapsed; outside the gcMaintenanceWindow", now)
+		return nil
+	}
+
+	log.Info("Backup's gcRecycleBinRetention has elapsed. Creating a DeleteBackupRequest.")
+
+	return c.createDeleteBackupRequest(log, backup, reason+"; its gcRecycleBinRetention elapsed")
+}
+
+// createDeleteBackupRequest creates a DeleteBackupRequest for backup with the given reason.
+func (c *gcController) createDeleteBackupRequest(log logrus.FieldLogger, backup *api.Backup, reason string) error {
+	// requests for backups that require approval to be deleted wait until they're approved,
+	// so don't create another one while one is still outstanding
+	if backup.Labels[api.DeletionApprovalRequiredLabel] == "true" {
+		reqs, err := c.deleteBackupRequestClient.DeleteBackupRequests(backup.Namespace).List(pkgbackup.NewDeleteBackupRequestListOptions(backup.Name, string(backup.UID)))
+		if err != nil {
+			return errors.Wrap(err, "error listing DeleteBackupRequests")
+		}
+
+		for _, req := range reqs.Items {
+			if req.Status.Phase != api.DeleteBackupRequestPhaseProcessed {
+				log.Info("Backup already has a DeleteBackupRequest awaiting approval or processing, not creating another")
+				c.report.record(backup, api.GCReportDecisionDeferred, reason+"; awaiting approval of its DeleteBackupRequest", c.clock.Now())
+				return nil
+			}
+		}
+	}
+
 	req := pkgbackup.NewDeleteBackupRequest(backup.Name, string(backup.UID))
+	req.Spec.Reason = reason
 
-	_, err = c.deleteBackupRequestClient.DeleteBackupRequests(ns).Create(req)
-	if err != nil {
+	if _, err := c.deleteBackupRequestClient.DeleteBackupRequests(backup.Namespace).Create(req); err != nil {
 		return errors.Wrap(err, "error creating DeleteBackupRequest")
 	}
+	c.report.record(backup, api.GCReportDecisionDeleted, reason, c.clock.Now())
 
 	return nil
 }
+
+// gcReportName returns the name of the GCReport of the period that started at start.
+func gcReportName(start time.Time) string {
+	return "gc-report-" + start.UTC().Format("20060102150405")
+}
+
+// syncGCReport saves the decisions recorded so far in the current period to its GCReport, so
+// they aren't lost if the server restarts, or, if the period has elapsed, ends it, and deletes
+// the GCReports older than the report retention.
+func (c *gcController) syncGCReport() {
+	if c.report == nil {
+		return
+	}
+
+	if !c.reportResumed {
+		c.resumeGCReport()
+	}
+
+	if c.report.due(c.reportPeriod, c.clock.Now()) {
+		c.writeGCReport()
+		c.deleteExpiredGCReports()
+		return
+	}
+
+	spec := c.report.snapshot()
+	if len(spec.Entries) == 0 && c.reportObject == nil {
+		// nothing to save yet
+		return
+	}
+	if err := c.saveGCReport(spec); err != nil {
+		c.logger.WithError(err).WithField("gcReport", gcReportName(spec.StartTimestamp.Time)).Error("Error saving GCReport")
+	}
+}
+
+// resumeGCReport returns the decisions saved to the GCReport of the period that was in
+// progress when the server started, if there is one, to the report, and continues its period.
+func (c *gcController) resumeGCReport() {
+	reports, err := c.gcReportClient.GCReports(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error listing GCReports to resume")
+		return
+	}
+	c.reportResumed = true
+
+	var latest *api.GCReport
+	for i := range reports.Items {
+		report := &reports.Items[i]
+		if !report.Spec.EndTimestamp.IsZero() {
+			continue
+		}
+		if latest == nil || latest.Spec.StartTimestamp.Before(&report.Spec.StartTimestamp) {
+			latest = report
+		}
+	}
+	if latest == nil {
+		return
+	}
+
+	c.logger.WithFields(logrus.Fields{"gcReport": latest.Name, "entries": len(latest.Spec.Entries)}).Info("Resuming GCReport")
+	c.report.unflush(latest.Spec)
+	c.reportObject = latest
+}
+
+// writeGCReport ends the current period, saving the decisions recorded since the last one to
+// its GCReport. If they can't be saved, they're kept for the next attempt.
+func (c *gcController) writeGCReport() {
+	spec := c.report.flush(c.clock.Now())
+
+	log := c.logger.WithFields(logrus.Fields{"gcReport": gcReportName(spec.StartTimestamp.Time), "entries": len(spec.Entries)})
+
+	if err := c.saveGCReport(spec); err != nil {
+		log.WithError(err).Error("Error saving GCReport")
+		c.report.unflush(spec)
+		return
+	}
+
+	log.Info("Saved GCReport")
+}
+
+// saveGCReport creates or updates the GCReport of spec's period.
+func (c *gcController) saveGCReport(spec api.GCReportSpec) error {
+	reports := c.gcReportClient.GCReports(c.namespace)
+	name := gcReportName(spec.StartTimestamp.Time)
+
+	existing := c.reportObject
+	if existing == nil || existing.Name != name {
+		report := &api.GCReport{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: c.namespace,
+				Name:      name,
+			},
+			Spec: spec,
+		}
+
+		created, err := reports.Create(report)
+		if err == nil {
+			c.reportObject = created
+			return nil
+		}
+		if !apierrors.IsAlreadyExists(err) {
+			return errors.Wrap(err, "error creating GCReport")
+		}
+
+		if existing, err = reports.Get(name, metav1.GetOptions{}); err != nil {
+			return errors.Wrap(err, "error getting GCReport")
+		}
+	}
+
+	updated := existing.DeepCopy()
+	updated.Spec = spec
+
+	res, err := reports.Update(updated)
+	if err != nil {
+		// it's fetched again next time, in case it was modified
+		c.reportObject = nil
+		return errors.Wrap(err, "error updating GCReport")
+	}
+	c.reportObject = res
+
+	return nil
+}
+
+// deleteExpiredGCReports deletes the GCReports whose periods ended longer than the report
+// retention ago. Reports of periods that never ended, e.g. because the report period was
+// changed, expire from when they started.
+func (c *gcController) deleteExpiredGCReports() {
+	if c.reportRetention <= 0 {
+		return
+	}
+
+	reports, err := c.gcReportClient.GCReports(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("Error listing GCReports to delete")
+		return
+	}
+
+	for _, report := range reports.Items {
+		expiresFrom := report.Spec.EndTimestamp
+		if expiresFrom.IsZero() {
+			if c.reportObject != nil && report.Name == c.reportObject.Name {
+				continue
+			}
+			expiresFrom = report.Spec.StartTimestamp
+		}
+		if c.clock.Since(expiresFrom.Time) < c.reportRetention {
+			continue
+		}
+
+		log := c.logger.WithField("gcReport", report.Name)
+		if err := c.gcReportClient.GCReports(c.namespace).Delete(report.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			log.WithError(errors.WithStack(err)).Error("Error deleting expired GCReport")
+			continue
+		}
+		log.Info("Deleted expired GCReport")
+	}
+}
+
+// completionTime returns when the backup completed, or when it was created for backups that
+// don't record when they completed.
+func completionTime(backup *api.Backup) time.Time {
+	if !backup.Status.CompletionTimestamp.IsZero() {
+		return backup.Status.CompletionTimestamp.Time
+	}
+	return backup.CreationTimestamp.Time
+}
+
+// hasUsableContents returns true if the backup's contents were uploaded and can be restored,
+// which is the case for partially failed backups as well as completed ones.
+func hasUsableContents(backup *api.Backup) bool {
+	return backup.Status.Phase == api.BackupPhaseCompleted || backup.Status.Phase == api.BackupPhasePartiallyFailed
+}
+
+// isLastBackupOfSchedule returns true if no other restorable backup of the schedule would remain
+// after deleting the given expired backup, i.e. every other completed or partially failed backup
+// of the schedule is either being deleted or has expired and is older than this one.
+func (c *gcController) isLastBackupOfSchedule(backup *api.Backup, scheduleName string) (bool, error) {
+	selector := labels.SelectorFromSet(labels.Set{api.ScheduleNameLabel: scheduleName})
+
+	backups, err := c.backupLister.Backups(backup.Namespace).List(selector)
+	if err != nil {
+		return false, errors.Wrap(err, "error listing backups for schedule")
+	}
+
+	now := c.clock.Now()
+
+	for _, other := range backups {
+		if other.Name == backup.Name {
+			continue
+		}
+
+		if !hasUsableContents(other) || other.DeletionTimestamp != nil {
+			continue
+		}
+
+		expiration, _ := c.expiration(other)
+		if expiration.IsZero() || expiration.After(now) {
+			// this backup will remain after the current one is deleted
+			return false, nil
+		}
+
+		// creation timestamps only have second precision, so backups created in the same second
+		// are ordered by name, which makes exactly one of them the last one
+		if other.CreationTimestamp.After(backup.CreationTimestamp.Time) ||
+			(other.CreationTimestamp.Time.Equal(backup.CreationTimestamp.Time) && other.Name > backup.Name) {
+			// a newer expired backup exists, so that's the one to treat as the last one
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// MaintenanceWindow is a parsed api.MaintenanceWindow.
+type MaintenanceWindow struct {
+	// start and end are the times of day the window opens and closes, as the
+	// time since midnight.
+	start, end time.Duration
+	location   *time.Location
+}
+
+// ParseMaintenanceWindow parses the start and end times of window and loads its time zone.
+func ParseMaintenanceWindow(window api.MaintenanceWindow) (*MaintenanceWindow, error) {
+	location, err := time.LoadLocation(window.TimeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid time zone %q", window.TimeZone)
+	}
+
+	start, err := parseTimeOfDay(window.Start)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid start")
+	}
+
+	end, err := parseTimeOfDay(window.End)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid end")
+	}
+
+	if start == end {
+		return nil, errors.New("start and end must be different")
+	}
+
+	return &MaintenanceWindow{start: start, end: end, location: location}, nil
+}
+
+// parseTimeOfDay parses s, in HH:MM form, as the time since midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.Errorf("%q is not a time of day in HH:MM form", s)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains returns whether t is within the window on its day, in the window's time zone.
+func (w *MaintenanceWindow) contains(t time.Time) bool {
+	t = t.In(w.location)
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+
+	if w.start < w.end {
+		return sinceMidnight >= w.start && sinceMidnight < w.end
+	}
+	// the window spans midnight
+	return sinceMidnight >= w.start || sinceMidnight < w.end
+}
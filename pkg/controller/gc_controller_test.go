@@ -18,6 +18,7 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"testing"
@@ -25,15 +26,22 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	core "k8s.io/client-go/testing"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	pkgbackup "github.com/heptio/ark/pkg/backup"
 	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
 	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	"github.com/heptio/ark/pkg/metrics"
 	"github.com/heptio/ark/pkg/util/kube"
 	arktest "github.com/heptio/ark/pkg/util/test"
 )
@@ -45,9 +53,28 @@ func TestGCControllerEnqueueAllBackups(t *testing.T) {
 
 		controller = NewGCController(
 			arktest.NewLogger(),
+			"",
 			sharedInformers.Ark().V1().Backups(),
+			sharedInformers.Ark().V1().Schedules(),
 			client.ArkV1(),
+			client.ArkV1(),
+			nil,
+			"bucket",
 			1*time.Millisecond,
+			false,
+			0,
+			0,
+			0,
+			nil,
+			false,
+			0,
+			0,
+			nil,
+			client.ArkV1(),
+			0,
+			0,
+			0,
+			metrics.NewServerMetrics(),
 		).(*gcController)
 	)
 
@@ -94,6 +121,109 @@ Loop:
 	assert.Equal(t, expected, received)
 }
 
+func TestGCControllerEnqueueAllBackupsInNamespace(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+
+		controller = NewGCController(
+			arktest.NewLogger(),
+			"ns-1",
+			sharedInformers.Ark().V1().Backups(),
+			sharedInformers.Ark().V1().Schedules(),
+			client.ArkV1(),
+			client.ArkV1(),
+			nil,
+			"bucket",
+			1*time.Millisecond,
+			false,
+			0,
+			0,
+			0,
+			nil,
+			false,
+			0,
+			0,
+			nil,
+			client.ArkV1(),
+			0,
+			0,
+			0,
+			metrics.NewServerMetrics(),
+		).(*gcController)
+	)
+
+	for _, ns := range []string{"ns-1", "ns-2"} {
+		backup := arktest.NewTestBackup().WithNamespace(ns).WithName("backup").Backup
+		sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(backup)
+	}
+
+	controller.enqueueAllBackups()
+
+	require.Equal(t, 1, controller.queue.Len())
+	key, _ := controller.queue.Get()
+	assert.Equal(t, "ns-1/backup", key)
+
+	// backups in other namespaces are ignored even if they make it onto the queue
+	assert.NoError(t, controller.processQueueItem("ns-2/backup"))
+	assert.Empty(t, client.Actions())
+}
+
+func TestGCControllerEnqueueAllBackupsInChunks(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		controller      = NewGCController(
+			arktest.NewLogger(),
+			"ns-1",
+			sharedInformers.Ark().V1().Backups(),
+			sharedInformers.Ark().V1().Schedules(),
+			client.ArkV1(),
+			client.ArkV1(),
+			nil,
+			"bucket",
+			time.Minute,
+			false,
+			0,
+			0,
+			0,
+			nil,
+			false,
+			0,
+			0,
+			nil,
+			client.ArkV1(),
+			0,
+			0,
+			0,
+			metrics.NewServerMetrics(),
+		).(*gcController)
+	)
+	controller.enqueueChunkSize = 2
+	controller.enqueueWindow = 300 * time.Millisecond
+
+	for i := 0; i < 5; i++ {
+		backup := arktest.NewTestBackup().WithNamespace("ns-1").WithName(fmt.Sprintf("backup-%d", i)).Backup
+		sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(backup)
+	}
+
+	controller.enqueueAllBackups()
+
+	// only the first chunk is enqueued right away; the other two are enqueued 100ms apart
+	assert.Equal(t, 2, controller.queue.Len())
+
+	require.NoError(t, wait.Poll(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return controller.queue.Len() == 5, nil
+	}))
+
+	var keys []string
+	for i := 0; i < 5; i++ {
+		key, _ := controller.queue.Get()
+		keys = append(keys, key.(string))
+	}
+	assert.Equal(t, []string{"ns-1/backup-0", "ns-1/backup-1", "ns-1/backup-2", "ns-1/backup-3", "ns-1/backup-4"}, keys)
+}
+
 func TestGCControllerHasUpdateFunc(t *testing.T) {
 	backup := arktest.NewTestBackup().WithName("backup").Backup
 	expected := kube.NamespaceAndName(backup)
@@ -108,9 +238,28 @@ func TestGCControllerHasUpdateFunc(t *testing.T) {
 
 	controller := NewGCController(
 		arktest.NewLogger(),
+		"",
 		sharedInformers.Ark().V1().Backups(),
+		sharedInformers.Ark().V1().Schedules(),
+		client.ArkV1(),
 		client.ArkV1(),
+		nil,
+		"bucket",
 		1*time.Millisecond,
+		false,
+		0,
+		0,
+		0,
+		nil,
+		false,
+		0,
+		0,
+		nil,
+		client.ArkV1(),
+		0,
+		0,
+		0,
+		metrics.NewServerMetrics(),
 	).(*gcController)
 
 	keys := make(chan string)
@@ -147,12 +296,25 @@ func TestGCControllerHasUpdateFunc(t *testing.T) {
 }
 
 func TestGCControllerProcessQueueItem(t *testing.T) {
-	fakeClock := clock.NewFakeClock(time.Now())
+	fakeClock := clock.NewFakeClock(time.Date(2018, 4, 5, 20, 12, 21, 0, time.UTC))
 
 	tests := []struct {
 		name                           string
 		backup                         *api.Backup
+		otherBackups                   []*api.Backup
+		keepLastScheduledBackup        bool
+		minRetention                   time.Duration
+		failedBackupTTL                time.Duration
+		snapshotsMissingTTL            time.Duration
+		maintenanceWindow              *MaintenanceWindow
+		deleteMissingContents          bool
+		orphanedBackupRetention        time.Duration
+		retentionClasses               map[string]time.Duration
+		schedules                      []*api.Schedule
+		backupContentsMissing          bool
+		backupContentsExistError       error
 		expectDeletion                 bool
+		expectedReason                 string
 		createDeleteBackupRequestError bool
 		expectError                    bool
 	}{
@@ -165,6 +327,7 @@ func TestGCControllerProcessQueueItem(t *testing.T) {
 				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
 				Backup,
 			expectDeletion: true,
+			expectedReason: "Backup expired",
 		},
 		{
 			name: "unexpired backup is not deleted",
@@ -173,6 +336,98 @@ func TestGCControllerProcessQueueItem(t *testing.T) {
 				Backup,
 			expectDeletion: false,
 		},
+		{
+			name: "expired backup within minRetention is not deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithCreationTimestamp(fakeClock.Now().Add(-30 * time.Minute)).
+				WithExpiration(fakeClock.Now().Add(-29 * time.Minute)).
+				Backup,
+			minRetention:   time.Hour,
+			expectDeletion: false,
+		},
+		{
+			name: "expired backup older than minRetention is deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithCreationTimestamp(fakeClock.Now().Add(-2 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(-119 * time.Minute)).
+				Backup,
+			minRetention:   time.Hour,
+			expectDeletion: true,
+		},
+		{
+			name: "expired backup completed within minRetention is not deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithCreationTimestamp(fakeClock.Now().Add(-2 * time.Hour)).
+				WithCompletionTimestamp(fakeClock.Now().Add(-30 * time.Minute)).
+				WithExpiration(fakeClock.Now().Add(-29 * time.Minute)).
+				Backup,
+			minRetention:   time.Hour,
+			expectDeletion: false,
+		},
+		{
+			name: "unexpired failed backup older than failedBackupTTL is deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseFailed).
+				WithCreationTimestamp(fakeClock.Now().Add(-2 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			failedBackupTTL: time.Hour,
+			expectDeletion:  true,
+			expectedReason:  "Backup failed and its gcFailedBackupTTL elapsed",
+		},
+		{
+			name: "failed backup without expiration older than failedBackupTTL is deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseFailed).
+				WithCreationTimestamp(fakeClock.Now().Add(-2 * time.Hour)).
+				Backup,
+			failedBackupTTL: time.Hour,
+			expectDeletion:  true,
+		},
+		{
+			name: "failed backup newer than failedBackupTTL is not deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseFailed).
+				WithCreationTimestamp(fakeClock.Now().Add(-30 * time.Minute)).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			failedBackupTTL: time.Hour,
+			expectDeletion:  false,
+		},
+		{
+			name: "completed backup older than failedBackupTTL is not deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseCompleted).
+				WithCreationTimestamp(fakeClock.Now().Add(-2 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			failedBackupTTL: time.Hour,
+			expectDeletion:  false,
+		},
+		{
+			name: "unexpired backup with snapshots missing longer than snapshotsMissingTTL is deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(1*time.Hour)).
+				WithCondition(api.BackupConditionSnapshotsMissing, api.ConditionTrue, fakeClock.Now().Add(-2*time.Hour)).
+				Backup,
+			snapshotsMissingTTL: time.Hour,
+			expectDeletion:      true,
+			expectedReason:      "Backup's snapshots are missing and its gcSnapshotsMissingBackupTTL elapsed",
+		},
+		{
+			name: "backup with snapshots missing for less than snapshotsMissingTTL is not deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(1*time.Hour)).
+				WithCondition(api.BackupConditionSnapshotsMissing, api.ConditionTrue, fakeClock.Now().Add(-30*time.Minute)).
+				Backup,
+			snapshotsMissingTTL: time.Hour,
+			expectDeletion:      false,
+		},
+		{
+			name: "backup whose snapshots are no longer missing is not deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(1*time.Hour)).
+				WithCondition(api.BackupConditionSnapshotsMissing, api.ConditionFalse, fakeClock.Now().Add(-2*time.Hour)).
+				Backup,
+			snapshotsMissingTTL: time.Hour,
+			expectDeletion:      false,
+		},
 		{
 			name: "create DeleteBackupRequest error returns an error",
 			backup: arktest.NewTestBackup().WithName("backup-1").
@@ -182,6 +437,311 @@ func TestGCControllerProcessQueueItem(t *testing.T) {
 			createDeleteBackupRequestError: true,
 			expectError:                    true,
 		},
+		{
+			name: "last expired backup of schedule is kept when keepLastScheduledBackup is true",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithCreationTimestamp(fakeClock.Now().Add(-1 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			otherBackups: []*api.Backup{
+				arktest.NewTestBackup().WithName("backup-0").WithLabel(api.ScheduleNameLabel, "schedule-1").
+					WithPhase(api.BackupPhaseCompleted).
+					WithCreationTimestamp(fakeClock.Now().Add(-2 * time.Hour)).
+					WithExpiration(fakeClock.Now().Add(-1 * time.Hour)).
+					Backup,
+				arktest.NewTestBackup().WithName("backup-2").WithLabel(api.ScheduleNameLabel, "schedule-1").
+					WithPhase(api.BackupPhaseFailed).
+					WithCreationTimestamp(fakeClock.Now()).
+					Backup,
+				arktest.NewTestBackup().WithName("other-schedule").WithLabel(api.ScheduleNameLabel, "schedule-2").
+					WithPhase(api.BackupPhaseCompleted).
+					Backup,
+			},
+			keepLastScheduledBackup: true,
+			expectDeletion:          false,
+		},
+		{
+			name: "last expired partially failed backup of schedule is kept when keepLastScheduledBackup is true",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhasePartiallyFailed).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			keepLastScheduledBackup: true,
+			expectDeletion:          false,
+		},
+		{
+			name: "last expired backup of schedule is deleted when keepLastScheduledBackup is false",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			keepLastScheduledBackup: false,
+			expectDeletion:          true,
+		},
+		{
+			name: "expired backup of schedule is deleted when an unexpired backup remains",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			otherBackups: []*api.Backup{
+				arktest.NewTestBackup().WithName("backup-2").WithLabel(api.ScheduleNameLabel, "schedule-1").
+					WithPhase(api.BackupPhaseCompleted).
+					WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+					Backup,
+			},
+			keepLastScheduledBackup: true,
+			expectDeletion:          true,
+		},
+		{
+			name: "expired backup of schedule is deleted when an unexpired partially failed backup remains",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			otherBackups: []*api.Backup{
+				arktest.NewTestBackup().WithName("backup-2").WithLabel(api.ScheduleNameLabel, "schedule-1").
+					WithPhase(api.BackupPhasePartiallyFailed).
+					WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+					Backup,
+			},
+			keepLastScheduledBackup: true,
+			expectDeletion:          true,
+		},
+		{
+			name: "older expired backup of schedule is deleted when a newer expired backup exists",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithCreationTimestamp(fakeClock.Now().Add(-2 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Hour)).
+				Backup,
+			otherBackups: []*api.Backup{
+				arktest.NewTestBackup().WithName("backup-2").WithLabel(api.ScheduleNameLabel, "schedule-1").
+					WithPhase(api.BackupPhaseCompleted).
+					WithCreationTimestamp(fakeClock.Now().Add(-1 * time.Hour)).
+					WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+					Backup,
+			},
+			keepLastScheduledBackup: true,
+			expectDeletion:          true,
+		},
+		{
+			name: "expired backup of schedule is deleted when an expired backup created at the same time has a later name",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithCreationTimestamp(fakeClock.Now().Add(-1 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			otherBackups: []*api.Backup{
+				arktest.NewTestBackup().WithName("backup-2").WithLabel(api.ScheduleNameLabel, "schedule-1").
+					WithPhase(api.BackupPhaseCompleted).
+					WithCreationTimestamp(fakeClock.Now().Add(-1 * time.Hour)).
+					WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+					Backup,
+			},
+			keepLastScheduledBackup: true,
+			expectDeletion:          true,
+		},
+		{
+			name: "expired backup of schedule is kept when an expired backup created at the same time has an earlier name",
+			backup: arktest.NewTestBackup().WithName("backup-2").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithCreationTimestamp(fakeClock.Now().Add(-1 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			otherBackups: []*api.Backup{
+				arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+					WithPhase(api.BackupPhaseCompleted).
+					WithCreationTimestamp(fakeClock.Now().Add(-1 * time.Hour)).
+					WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+					Backup,
+			},
+			keepLastScheduledBackup: true,
+			expectDeletion:          false,
+		},
+		{
+			name: "unexpired backup of deleted schedule older than orphanedBackupRetention is deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithCreationTimestamp(fakeClock.Now().Add(-2 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			orphanedBackupRetention: time.Hour,
+			expectDeletion:          true,
+			expectedReason:          "Backup's schedule schedule-1 was deleted and its gcOrphanedScheduleBackupRetention elapsed",
+		},
+		{
+			name: "unexpired synced backup of missing schedule older than orphanedBackupRetention is not deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithAnnotation(api.SyncedFromObjectStorageAnnotation, "true").
+				WithPhase(api.BackupPhaseCompleted).
+				WithCreationTimestamp(fakeClock.Now().Add(-2 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			orphanedBackupRetention: time.Hour,
+			expectDeletion:          false,
+		},
+		{
+			name: "unexpired backup of existing schedule older than orphanedBackupRetention is not deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithCreationTimestamp(fakeClock.Now().Add(-2 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			schedules:               []*api.Schedule{arktest.NewTestSchedule(api.DefaultNamespace, "schedule-1").Schedule},
+			orphanedBackupRetention: time.Hour,
+			expectDeletion:          false,
+		},
+		{
+			name: "unexpired backup of deleted schedule is not deleted when orphanedBackupRetention is disabled",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithCreationTimestamp(fakeClock.Now().Add(-2 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			expectDeletion: false,
+		},
+		{
+			name: "backup of deleted schedule newer than orphanedBackupRetention is not deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithCreationTimestamp(fakeClock.Now().Add(-30 * time.Minute)).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			orphanedBackupRetention: time.Hour,
+			expectDeletion:          false,
+		},
+		{
+			name: "last backup of deleted schedule is deleted when keepLastScheduledBackup is true",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.ScheduleNameLabel, "schedule-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithCreationTimestamp(fakeClock.Now().Add(-2 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			keepLastScheduledBackup: true,
+			orphanedBackupRetention: time.Hour,
+			expectDeletion:          true,
+		},
+		{
+			name: "unexpired backup that started longer than its retention class's TTL ago is deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.RetentionClassLabel, "config").
+				WithStartTimestamp(fakeClock.Now().Add(-8 * 24 * time.Hour)).
+				WithCreationTimestamp(fakeClock.Now().Add(-1 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(22 * 24 * time.Hour)).
+				Backup,
+			retentionClasses: map[string]time.Duration{"config": 7 * 24 * time.Hour, "data": 30 * 24 * time.Hour},
+			expectDeletion:   true,
+			expectedReason:   "Backup's retention class config TTL elapsed",
+		},
+		{
+			name: "unexpired backup that never started and was created longer than its retention class's TTL ago is deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.RetentionClassLabel, "config").
+				WithCreationTimestamp(fakeClock.Now().Add(-8 * 24 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(22 * 24 * time.Hour)).
+				Backup,
+			retentionClasses: map[string]time.Duration{"config": 7 * 24 * time.Hour},
+			expectDeletion:   true,
+			expectedReason:   "Backup's retention class config TTL elapsed",
+		},
+		{
+			name: "expired backup newer than its retention class's TTL is not deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.RetentionClassLabel, "data").
+				WithStartTimestamp(fakeClock.Now().Add(-8 * 24 * time.Hour)).
+				WithCreationTimestamp(fakeClock.Now().Add(-8 * 24 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			retentionClasses: map[string]time.Duration{"config": 7 * 24 * time.Hour, "data": 30 * 24 * time.Hour},
+			expectDeletion:   false,
+		},
+		{
+			name: "expired backup of an unconfigured retention class is deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithLabel(api.RetentionClassLabel, "logs").
+				WithCreationTimestamp(fakeClock.Now().Add(-8 * 24 * time.Hour)).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			retentionClasses: map[string]time.Duration{"config": 7 * 24 * time.Hour},
+			expectDeletion:   true,
+			expectedReason:   "Backup expired",
+		},
+		{
+			name: "expired backup is deleted within the maintenance window",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			maintenanceWindow: &MaintenanceWindow{start: 20 * time.Hour, end: 21 * time.Hour, location: time.UTC},
+			expectDeletion:    true,
+		},
+		{
+			name: "expired backup is not deleted outside the maintenance window",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			maintenanceWindow: &MaintenanceWindow{start: time.Hour, end: 5 * time.Hour, location: time.UTC},
+			expectDeletion:    false,
+		},
+		{
+			name: "unexpired partially failed backup whose contents are missing is deleted when enabled",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithPhase(api.BackupPhasePartiallyFailed).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			deleteMissingContents: true,
+			backupContentsMissing: true,
+			expectDeletion:        true,
+			expectedReason:        "Backup's contents are missing from object storage",
+		},
+		{
+			name: "unexpired completed backup whose contents are missing is deleted when enabled",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			minRetention:          24 * time.Hour,
+			deleteMissingContents: true,
+			backupContentsMissing: true,
+			expectDeletion:        true,
+			expectedReason:        "Backup's contents are missing from object storage",
+		},
+		{
+			name: "unexpired completed backup whose contents are missing is not deleted when disabled",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			backupContentsMissing: true,
+			expectDeletion:        false,
+		},
+		{
+			name: "unexpired completed backup whose contents exist is not deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			deleteMissingContents: true,
+			expectDeletion:        false,
+		},
+		{
+			name: "in-progress backup whose contents are missing is not deleted",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithPhase(api.BackupPhaseInProgress).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			deleteMissingContents: true,
+			backupContentsMissing: true,
+			expectDeletion:        false,
+		},
+		{
+			name: "error checking whether backup's contents exist",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(1 * time.Hour)).
+				Backup,
+			deleteMissingContents:    true,
+			backupContentsExistError: errors.New("foo"),
+			expectDeletion:           false,
+			expectError:              true,
+		},
 	}
 
 	for _, test := range tests {
@@ -189,13 +749,35 @@ func TestGCControllerProcessQueueItem(t *testing.T) {
 			var (
 				client          = fake.NewSimpleClientset()
 				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				backupService   = &arktest.BackupService{}
 			)
 
+			backupService.On("BackupContentsExist", "bucket", mock.Anything).Return(!test.backupContentsMissing, test.backupContentsExistError)
+
 			controller := NewGCController(
 				arktest.NewLogger(),
+				"",
 				sharedInformers.Ark().V1().Backups(),
+				sharedInformers.Ark().V1().Schedules(),
 				client.ArkV1(),
+				client.ArkV1(),
+				backupService,
+				"bucket",
 				1*time.Millisecond,
+				test.keepLastScheduledBackup,
+				test.minRetention,
+				test.failedBackupTTL,
+				test.snapshotsMissingTTL,
+				test.maintenanceWindow,
+				test.deleteMissingContents,
+				0,
+				test.orphanedBackupRetention,
+				test.retentionClasses,
+				client.ArkV1(),
+				0,
+				0,
+				0,
+				metrics.NewServerMetrics(),
 			).(*gcController)
 			controller.clock = fakeClock
 
@@ -205,6 +787,14 @@ func TestGCControllerProcessQueueItem(t *testing.T) {
 				sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(test.backup)
 			}
 
+			for _, backup := range test.otherBackups {
+				sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(backup)
+			}
+
+			for _, schedule := range test.schedules {
+				sharedInformers.Ark().V1().Schedules().Informer().GetStore().Add(schedule)
+			}
+
 			if test.createDeleteBackupRequestError {
 				client.PrependReactor("create", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
 					return true, nil, errors.New("foo")
@@ -216,10 +806,526 @@ func TestGCControllerProcessQueueItem(t *testing.T) {
 			assert.Equal(t, test.expectError, gotErr)
 
 			if test.expectDeletion {
-				assert.Len(t, client.Actions(), 1)
+				require.Len(t, client.Actions(), 1)
+
+				if test.expectedReason != "" {
+					req := client.Actions()[0].(core.CreateAction).GetObject().(*api.DeleteBackupRequest)
+					assert.Equal(t, test.expectedReason, req.Spec.Reason)
+				}
 			} else {
 				assert.Len(t, client.Actions(), 0)
 			}
 		})
 	}
 }
+
+func TestGCControllerWaitsForPendingDeletionApproval(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Date(2018, 4, 5, 20, 12, 21, 0, time.UTC))
+
+	tests := []struct {
+		name           string
+		existingPhase  api.DeleteBackupRequestPhase
+		expectDeletion bool
+	}{
+		{
+			name:           "no existing request - request is created",
+			expectDeletion: true,
+		},
+		{
+			name:           "existing request pending approval - no request is created",
+			existingPhase:  api.DeleteBackupRequestPhasePendingApproval,
+			expectDeletion: false,
+		},
+		{
+			name:           "existing processed request - request is created",
+			existingPhase:  api.DeleteBackupRequestPhaseProcessed,
+			expectDeletion: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backup := arktest.NewTestBackup().WithName("backup-1").
+				WithLabel(api.DeletionApprovalRequiredLabel, "true").
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup
+			backup.UID = "uid"
+
+			var objects []runtime.Object
+			if test.existingPhase != "" {
+				req := pkgbackup.NewDeleteBackupRequest(backup.Name, string(backup.UID))
+				req.Namespace = backup.Namespace
+				req.Name = "backup-1-abcde"
+				req.Status.Phase = test.existingPhase
+				objects = append(objects, req)
+			}
+
+			var (
+				client          = fake.NewSimpleClientset(objects...)
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+			)
+
+			controller := NewGCController(
+				arktest.NewLogger(),
+				"",
+				sharedInformers.Ark().V1().Backups(),
+				sharedInformers.Ark().V1().Schedules(),
+				client.ArkV1(),
+				client.ArkV1(),
+				nil,
+				"bucket",
+				1*time.Millisecond,
+				false,
+				0,
+				0,
+				0,
+				nil,
+				false,
+				0,
+				0,
+				nil,
+				client.ArkV1(),
+				0,
+				0,
+				0,
+				metrics.NewServerMetrics(),
+			).(*gcController)
+			controller.clock = fakeClock
+
+			// the fake clientset doesn't support generateName
+			client.PrependReactor("create", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+				return true, action.(core.CreateAction).GetObject(), nil
+			})
+
+			sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(backup)
+
+			err := controller.processQueueItem(kube.NamespaceAndName(backup))
+			require.NoError(t, err)
+
+			var created bool
+			for _, action := range client.Actions() {
+				if action.GetVerb() == "create" {
+					created = true
+				}
+			}
+			assert.Equal(t, test.expectDeletion, created)
+		})
+	}
+}
+
+func TestGCControllerTransientErrorBackoffFor(t *testing.T) {
+	groupResource := api.SchemeGroupVersion.WithResource("deletebackuprequests").GroupResource()
+
+	tests := []struct {
+		name              string
+		backoff           time.Duration
+		err               error
+		expectedBackoff   time.Duration
+		expectedTransient bool
+	}{
+		{
+			name:    "non-API error isn't transient",
+			backoff: time.Minute,
+			err:     errors.New("foo"),
+		},
+		{
+			name:    "not found error isn't transient",
+			backoff: time.Minute,
+			err:     apierrors.NewNotFound(groupResource, "backup-1"),
+		},
+		{
+			name:    "invalid error isn't transient",
+			backoff: time.Minute,
+			err:     apierrors.NewInvalid(api.SchemeGroupVersion.WithKind("DeleteBackupRequest").GroupKind(), "backup-1", nil),
+		},
+		{
+			name:              "throttling error is transient",
+			backoff:           time.Minute,
+			err:               apierrors.NewTooManyRequests("throttled", 0),
+			expectedBackoff:   time.Minute,
+			expectedTransient: true,
+		},
+		{
+			name:              "wrapped throttling error is transient",
+			backoff:           time.Minute,
+			err:               errors.Wrap(apierrors.NewTooManyRequests("throttled", 0), "error creating DeleteBackupRequest"),
+			expectedBackoff:   time.Minute,
+			expectedTransient: true,
+		},
+		{
+			name:              "server's retry-after delay is used if it's longer than the backoff",
+			backoff:           time.Minute,
+			err:               apierrors.NewTooManyRequests("throttled", 120),
+			expectedBackoff:   2 * time.Minute,
+			expectedTransient: true,
+		},
+		{
+			name:              "server's retry-after delay is ignored if it's shorter than the backoff",
+			backoff:           time.Minute,
+			err:               apierrors.NewServerTimeout(groupResource, "create", 1),
+			expectedBackoff:   time.Minute,
+			expectedTransient: true,
+		},
+		{
+			name:              "service unavailable error is transient",
+			backoff:           time.Minute,
+			err:               apierrors.NewServiceUnavailable("unavailable"),
+			expectedBackoff:   time.Minute,
+			expectedTransient: true,
+		},
+		{
+			name:              "internal error is transient",
+			backoff:           time.Minute,
+			err:               apierrors.NewInternalError(errors.New("foo")),
+			expectedBackoff:   time.Minute,
+			expectedTransient: true,
+		},
+		{
+			name: "no errors are transient without a backoff",
+			err:  apierrors.NewTooManyRequests("throttled", 0),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := &gcController{transientErrorBackoff: test.backoff}
+
+			backoff, transient := controller.transientErrorBackoffFor(test.err)
+			assert.Equal(t, test.expectedTransient, transient)
+			assert.Equal(t, test.expectedBackoff, backoff)
+		})
+	}
+}
+
+func TestGCControllerBacksOffAfterTransientAPIError(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Date(2018, 4, 5, 20, 12, 21, 0, time.UTC))
+
+	tests := []struct {
+		name        string
+		createError error
+		expectError bool
+		expectRetry bool
+	}{
+		{
+			name:        "transient error - backup is re-added to the queue after the backoff",
+			createError: apierrors.NewTooManyRequests("throttled", 0),
+			expectRetry: true,
+		},
+		{
+			name:        "permanent error - error is returned",
+			createError: errors.New("foo"),
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				backup          = arktest.NewTestBackup().WithName("backup-1").WithExpiration(fakeClock.Now().Add(-1 * time.Second)).Backup
+			)
+
+			controller := NewGCController(
+				arktest.NewLogger(),
+				"",
+				sharedInformers.Ark().V1().Backups(),
+				sharedInformers.Ark().V1().Schedules(),
+				client.ArkV1(),
+				client.ArkV1(),
+				nil,
+				"bucket",
+				1*time.Millisecond,
+				false,
+				0,
+				0,
+				0,
+				nil,
+				false,
+				0,
+				0,
+				nil,
+				client.ArkV1(),
+				0,
+				0,
+				50*time.Millisecond,
+				metrics.NewServerMetrics(),
+			).(*gcController)
+			controller.clock = fakeClock
+
+			client.PrependReactor("create", "deletebackuprequests", func(action core.Action) (bool, runtime.Object, error) {
+				return true, nil, test.createError
+			})
+
+			sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(backup)
+
+			err := controller.processQueueItem(kube.NamespaceAndName(backup))
+			assert.Equal(t, test.expectError, err != nil)
+
+			if !test.expectRetry {
+				assert.Equal(t, 0, controller.queue.Len())
+				return
+			}
+
+			// the backup isn't re-added until the backoff has elapsed
+			assert.Equal(t, 0, controller.queue.Len())
+			require.NoError(t, wait.Poll(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+				return controller.queue.Len() == 1, nil
+			}))
+			key, _ := controller.queue.Get()
+			assert.Equal(t, kube.NamespaceAndName(backup), key)
+		})
+	}
+}
+
+func TestGCControllerRecycleBin(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Date(2018, 4, 5, 20, 12, 21, 0, time.UTC))
+
+	recycled := func(since time.Duration) *api.Backup {
+		backup := arktest.NewTestBackup().WithName("backup-1").
+			WithPhase(api.BackupPhasePendingDeletion).
+			WithExpiration(fakeClock.Now().Add(-48 * time.Hour)).
+			Backup
+		backup.Status.RecycleBin = &api.BackupRecycleBinStatus{
+			Timestamp:     metav1.NewTime(fakeClock.Now().Add(-since)),
+			PreviousPhase: api.BackupPhaseCompleted,
+			Reason:        "Backup expired",
+		}
+		return backup
+	}
+
+	tests := []struct {
+		name                string
+		backup              *api.Backup
+		recycleBinRetention time.Duration
+		maintenanceWindow   *MaintenanceWindow
+		expectRecycle       bool
+		expectDeletion      bool
+		expectedReason      string
+	}{
+		{
+			name: "expired backup is moved to the recycle bin",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			recycleBinRetention: 24 * time.Hour,
+			expectRecycle:       true,
+		},
+		{
+			name: "expired backup is moved to the recycle bin outside the maintenance window",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(-1 * time.Second)).
+				Backup,
+			recycleBinRetention: 24 * time.Hour,
+			maintenanceWindow:   &MaintenanceWindow{start: time.Hour, end: 5 * time.Hour, location: time.UTC},
+			expectRecycle:       true,
+		},
+		{
+			name: "unexpired backup is not moved to the recycle bin",
+			backup: arktest.NewTestBackup().WithName("backup-1").
+				WithPhase(api.BackupPhaseCompleted).
+				WithExpiration(fakeClock.Now().Add(1 * time.Second)).
+				Backup,
+			recycleBinRetention: 24 * time.Hour,
+		},
+		{
+			name:                "recycled backup is not deleted before the recycle bin retention elapses",
+			backup:              recycled(23 * time.Hour),
+			recycleBinRetention: 24 * time.Hour,
+		},
+		{
+			name:                "recycled backup is deleted once the recycle bin retention elapses",
+			backup:              recycled(25 * time.Hour),
+			recycleBinRetention: 24 * time.Hour,
+			expectDeletion:      true,
+			expectedReason:      "Backup expired; its gcRecycleBinRetention elapsed",
+		},
+		{
+			name:           "recycled backup is deleted right away once the recycle bin is disabled",
+			backup:         recycled(time.Hour),
+			expectDeletion: true,
+			expectedReason: "Backup expired; its gcRecycleBinRetention elapsed",
+		},
+		{
+			name:                "recycled backup is not deleted outside the maintenance window",
+			backup:              recycled(25 * time.Hour),
+			recycleBinRetention: 24 * time.Hour,
+			maintenanceWindow:   &MaintenanceWindow{start: time.Hour, end: 5 * time.Hour, location: time.UTC},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset(test.backup)
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+			)
+
+			controller := NewGCController(
+				arktest.NewLogger(),
+				"",
+				sharedInformers.Ark().V1().Backups(),
+				sharedInformers.Ark().V1().Schedules(),
+				client.ArkV1(),
+				client.ArkV1(),
+				nil,
+				"bucket",
+				1*time.Millisecond,
+				false,
+				0,
+				0,
+				0,
+				test.maintenanceWindow,
+				false,
+				test.recycleBinRetention,
+				0,
+				nil,
+				client.ArkV1(),
+				0,
+				0,
+				0,
+				metrics.NewServerMetrics(),
+			).(*gcController)
+			controller.clock = fakeClock
+
+			sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(test.backup)
+
+			require.NoError(t, controller.processQueueItem(kube.NamespaceAndName(test.backup)))
+
+			var (
+				patch *api.Backup
+				req   *api.DeleteBackupRequest
+			)
+			for _, action := range client.Actions() {
+				switch action.GetVerb() {
+				case "patch":
+					patch = new(api.Backup)
+					require.NoError(t, json.Unmarshal(action.(core.PatchAction).GetPatch(), patch))
+				case "create":
+					req = action.(core.CreateAction).GetObject().(*api.DeleteBackupRequest)
+				}
+			}
+
+			if test.expectRecycle {
+				require.NotNil(t, patch)
+				assert.Equal(t, api.BackupPhasePendingDeletion, patch.Status.Phase)
+				require.NotNil(t, patch.Status.RecycleBin)
+				assert.Equal(t, api.BackupPhaseCompleted, patch.Status.RecycleBin.PreviousPhase)
+				assert.Equal(t, "Backup expired", patch.Status.RecycleBin.Reason)
+				assert.Equal(t, fakeClock.Now().Unix(), patch.Status.RecycleBin.Timestamp.Unix())
+			} else {
+				assert.Nil(t, patch)
+			}
+
+			if test.expectDeletion {
+				require.NotNil(t, req)
+				assert.Equal(t, test.expectedReason, req.Spec.Reason)
+			} else {
+				assert.Nil(t, req)
+			}
+		})
+	}
+}
+
+func TestParseMaintenanceWindow(t *testing.T) {
+	tests := []struct {
+		name        string
+		window      api.MaintenanceWindow
+		expected    *MaintenanceWindow
+		expectedErr string
+	}{
+		{
+			name:     "time zone defaults to UTC",
+			window:   api.MaintenanceWindow{Start: "01:30", End: "05:00"},
+			expected: &MaintenanceWindow{start: 90 * time.Minute, end: 5 * time.Hour, location: time.UTC},
+		},
+		{
+			name:        "invalid start",
+			window:      api.MaintenanceWindow{Start: "1am", End: "05:00"},
+			expectedErr: `invalid start: "1am" is not a time of day in HH:MM form`,
+		},
+		{
+			name:        "invalid end",
+			window:      api.MaintenanceWindow{Start: "01:00", End: "24:00"},
+			expectedErr: `invalid end: "24:00" is not a time of day in HH:MM form`,
+		},
+		{
+			name:        "start and end are the same",
+			window:      api.MaintenanceWindow{Start: "01:00", End: "01:00"},
+			expectedErr: "start and end must be different",
+		},
+		{
+			name:        "invalid time zone",
+			window:      api.MaintenanceWindow{Start: "01:00", End: "05:00", TimeZone: "Nowhere/Special"},
+			expectedErr: `invalid time zone "Nowhere/Special": unknown time zone Nowhere/Special`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			window, err := ParseMaintenanceWindow(test.window)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, window)
+		})
+	}
+}
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	eastern := time.FixedZone("EST", -5*60*60)
+
+	tests := []struct {
+		name     string
+		window   *MaintenanceWindow
+		time     time.Time
+		expected bool
+	}{
+		{
+			name:     "start is in the window",
+			window:   &MaintenanceWindow{start: time.Hour, end: 5 * time.Hour, location: time.UTC},
+			time:     time.Date(2018, 4, 5, 1, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "end isn't in the window",
+			window:   &MaintenanceWindow{start: time.Hour, end: 5 * time.Hour, location: time.UTC},
+			time:     time.Date(2018, 4, 5, 5, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "window spanning midnight contains times after midnight",
+			window:   &MaintenanceWindow{start: 22 * time.Hour, end: 6 * time.Hour, location: time.UTC},
+			time:     time.Date(2018, 4, 5, 2, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "window spanning midnight doesn't contain midday",
+			window:   &MaintenanceWindow{start: 22 * time.Hour, end: 6 * time.Hour, location: time.UTC},
+			time:     time.Date(2018, 4, 5, 12, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "times are compared in the window's time zone",
+			window:   &MaintenanceWindow{start: 22 * time.Hour, end: 6 * time.Hour, location: eastern},
+			time:     time.Date(2018, 4, 5, 4, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "times outside the window in its time zone aren't in it",
+			window:   &MaintenanceWindow{start: 22 * time.Hour, end: 6 * time.Hour, location: eastern},
+			time:     time.Date(2018, 4, 5, 12, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.window.contains(test.time))
+		})
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+// gcReportKey identifies the entries of a GCReport that are merged when the same decision is
+// made about a backup at each sync.
+type gcReportKey struct {
+	namespace string
+	name      string
+	decision  api.GCReportDecision
+	reason    string
+}
+
+// gcReport accumulates the GC controller's decisions until they're written to a GCReport. A
+// nil gcReport records nothing.
+type gcReport struct {
+	sync.Mutex
+
+	start   time.Time
+	entries map[gcReportKey]*api.GCReportEntry
+}
+
+func newGCReport(start time.Time) *gcReport {
+	return &gcReport{
+		start:   start,
+		entries: make(map[gcReportKey]*api.GCReportEntry),
+	}
+}
+
+// record records a decision about backup made at now.
+func (r *gcReport) record(backup *api.Backup, decision api.GCReportDecision, reason string, now time.Time) {
+	if r == nil {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	key := gcReportKey{namespace: backup.Namespace, name: backup.Name, decision: decision, reason: reason}
+	if entry, found := r.entries[key]; found {
+		entry.LastTimestamp = metav1.NewTime(now)
+		return
+	}
+
+	r.entries[key] = &api.GCReportEntry{
+		BackupName:      backup.Name,
+		BackupNamespace: backup.Namespace,
+		Decision:        decision,
+		Reason:          reason,
+		FirstTimestamp:  metav1.NewTime(now),
+		LastTimestamp:   metav1.NewTime(now),
+	}
+}
+
+// due returns whether the report's period has elapsed at now.
+func (r *gcReport) due(period time.Duration, now time.Time) bool {
+	if r == nil {
+		return false
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	return !now.Before(r.start.Add(period))
+}
+
+// snapshot returns the spec of a GCReport of the decisions recorded so far in the current
+// period, which hasn't ended.
+func (r *gcReport) snapshot() api.GCReportSpec {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.spec()
+}
+
+// flush returns the spec of a GCReport of the decisions recorded until end, and starts a new
+// period at end.
+func (r *gcReport) flush(end time.Time) api.GCReportSpec {
+	r.Lock()
+	defer r.Unlock()
+
+	spec := r.spec()
+	spec.EndTimestamp = metav1.NewTime(end)
+
+	r.start = end
+	r.entries = make(map[gcReportKey]*api.GCReportEntry)
+
+	return spec
+}
+
+// spec returns the spec of a GCReport of the decisions recorded so far, without an end.
+// Callers must hold the lock.
+func (r *gcReport) spec() api.GCReportSpec {
+	spec := api.GCReportSpec{
+		StartTimestamp: metav1.NewTime(r.start),
+		Entries:        make([]api.GCReportEntry, 0, len(r.entries)),
+	}
+	for _, entry := range r.entries {
+		spec.Entries = append(spec.Entries, *entry)
+	}
+	sort.Slice(spec.Entries, func(i, j int) bool {
+		a, b := spec.Entries[i], spec.Entries[j]
+		if !a.FirstTimestamp.Equal(&b.FirstTimestamp) {
+			return a.FirstTimestamp.Before(&b.FirstTimestamp)
+		}
+		if a.BackupNamespace != b.BackupNamespace {
+			return a.BackupNamespace < b.BackupNamespace
+		}
+		if a.BackupName != b.BackupName {
+			return a.BackupName < b.BackupName
+		}
+		return a.Decision < b.Decision
+	})
+
+	return spec
+}
+
+// unflush returns the decisions in spec, which flush or snapshot returned, to the report, and
+// restarts its period when spec's did, e.g. because the GCReport couldn't be saved, or the
+// server restarted during spec's period.
+func (r *gcReport) unflush(spec api.GCReportSpec) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.start = spec.StartTimestamp.Time
+	for i := range spec.Entries {
+		entry := spec.Entries[i]
+		key := gcReportKey{namespace: entry.BackupNamespace, name: entry.BackupName, decision: entry.Decision, reason: entry.Reason}
+
+		if existing, found := r.entries[key]; found {
+			existing.FirstTimestamp = entry.FirstTimestamp
+			continue
+		}
+		r.entries[key] = &entry
+	}
+}
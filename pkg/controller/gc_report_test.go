@@ -0,0 +1,352 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	core "k8s.io/client-go/testing"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	"github.com/heptio/ark/pkg/metrics"
+	"github.com/heptio/ark/pkg/util/kube"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestGCReportRecordAndFlush(t *testing.T) {
+	var (
+		start   = time.Date(2018, 4, 1, 0, 0, 0, 0, time.UTC)
+		report  = newGCReport(start)
+		backup1 = arktest.NewTestBackup().WithName("backup-1").Backup
+		backup2 = arktest.NewTestBackup().WithName("backup-2").Backup
+	)
+
+	report.record(backup2, api.GCReportDecisionRetained, "within gcMinRetention", start.Add(1*time.Hour))
+	report.record(backup1, api.GCReportDecisionDeferred, "outside the gcMaintenanceWindow", start.Add(2*time.Hour))
+	report.record(backup2, api.GCReportDecisionRetained, "within gcMinRetention", start.Add(3*time.Hour))
+	report.record(backup1, api.GCReportDecisionDeleted, "Backup expired", start.Add(4*time.Hour))
+
+	assert.False(t, report.due(24*time.Hour, start.Add(23*time.Hour)))
+	assert.True(t, report.due(24*time.Hour, start.Add(24*time.Hour)))
+
+	end := start.Add(24 * time.Hour)
+	expected := api.GCReportSpec{
+		StartTimestamp: metav1.NewTime(start),
+		EndTimestamp:   metav1.NewTime(end),
+		Entries: []api.GCReportEntry{
+			{
+				BackupName:      "backup-2",
+				BackupNamespace: api.DefaultNamespace,
+				Decision:        api.GCReportDecisionRetained,
+				Reason:          "within gcMinRetention",
+				FirstTimestamp:  metav1.NewTime(start.Add(1 * time.Hour)),
+				LastTimestamp:   metav1.NewTime(start.Add(3 * time.Hour)),
+			},
+			{
+				BackupName:      "backup-1",
+				BackupNamespace: api.DefaultNamespace,
+				Decision:        api.GCReportDecisionDeferred,
+				Reason:          "outside the gcMaintenanceWindow",
+				FirstTimestamp:  metav1.NewTime(start.Add(2 * time.Hour)),
+				LastTimestamp:   metav1.NewTime(start.Add(2 * time.Hour)),
+			},
+			{
+				BackupName:      "backup-1",
+				BackupNamespace: api.DefaultNamespace,
+				Decision:        api.GCReportDecisionDeleted,
+				Reason:          "Backup expired",
+				FirstTimestamp:  metav1.NewTime(start.Add(4 * time.Hour)),
+				LastTimestamp:   metav1.NewTime(start.Add(4 * time.Hour)),
+			},
+		},
+	}
+
+	spec := report.flush(end)
+	assert.Equal(t, expected, spec)
+
+	// the next period starts when the last one ended, with no entries
+	assert.False(t, report.due(24*time.Hour, end.Add(23*time.Hour)))
+	assert.Empty(t, report.flush(end.Add(24*time.Hour)).Entries)
+
+	// decisions that weren't reported are returned to the report
+	report = newGCReport(end)
+	report.record(backup2, api.GCReportDecisionRetained, "within gcMinRetention", end.Add(1*time.Hour))
+	report.unflush(spec)
+
+	spec = report.flush(end.Add(24 * time.Hour))
+	assert.Equal(t, metav1.NewTime(start), spec.StartTimestamp)
+	require.Len(t, spec.Entries, 3)
+	assert.Equal(t, metav1.NewTime(start.Add(1*time.Hour)), spec.Entries[0].FirstTimestamp)
+	assert.Equal(t, metav1.NewTime(end.Add(1*time.Hour)), spec.Entries[0].LastTimestamp)
+
+	// a nil report records nothing
+	var disabled *gcReport
+	disabled.record(backup1, api.GCReportDecisionDeleted, "Backup expired", start)
+	assert.False(t, disabled.due(24*time.Hour, start))
+}
+
+func TestGCControllerWritesGCReport(t *testing.T) {
+	var (
+		fakeClock       = clock.NewFakeClock(time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC))
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		backup          = arktest.NewTestBackup().WithName("backup-1").WithExpiration(fakeClock.Now().Add(-1 * time.Hour)).Backup
+	)
+
+	controller := NewGCController(
+		arktest.NewLogger(),
+		api.DefaultNamespace,
+		sharedInformers.Ark().V1().Backups(),
+		sharedInformers.Ark().V1().Schedules(),
+		client.ArkV1(),
+		client.ArkV1(),
+		nil,
+		"bucket",
+		1*time.Minute,
+		false,
+		0,
+		0,
+		0,
+		nil,
+		false,
+		0,
+		0,
+		nil,
+		client.ArkV1(),
+		24*time.Hour,
+		0,
+		0,
+		metrics.NewServerMetrics(),
+	).(*gcController)
+	controller.clock = fakeClock
+	controller.report = newGCReport(fakeClock.Now())
+
+	sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(backup)
+
+	require.NoError(t, controller.processQueueItem(kube.NamespaceAndName(backup)))
+
+	// the decisions so far are saved before the period has elapsed
+	controller.enqueueAllBackups()
+	report, err := client.ArkV1().GCReports(api.DefaultNamespace).Get("gc-report-20180401120000", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, report.Spec.EndTimestamp.IsZero())
+	require.Len(t, report.Spec.Entries, 1)
+
+	// a report that can't be saved is retried at the next sync
+	fakeClock.Step(24 * time.Hour)
+	client.PrependReactor("update", "gcreports", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("foo")
+	})
+	controller.enqueueAllBackups()
+	client.ReactionChain = client.ReactionChain[1:]
+
+	report, err = client.ArkV1().GCReports(api.DefaultNamespace).Get("gc-report-20180401120000", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, report.Spec.EndTimestamp.IsZero())
+
+	fakeClock.Step(1 * time.Hour)
+	controller.enqueueAllBackups()
+
+	// the next period isn't saved until it has decisions
+	reports, err := client.ArkV1().GCReports(api.DefaultNamespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, reports.Items, 1)
+
+	report = &reports.Items[0]
+	assert.Equal(t, "gc-report-20180401120000", report.Name)
+	assert.Equal(t, metav1.NewTime(time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC)), report.Spec.StartTimestamp)
+	assert.Equal(t, metav1.NewTime(fakeClock.Now()), report.Spec.EndTimestamp)
+	require.Len(t, report.Spec.Entries, 1)
+	assert.Equal(t, "backup-1", report.Spec.Entries[0].BackupName)
+	assert.Equal(t, api.GCReportDecisionDeleted, report.Spec.Entries[0].Decision)
+	assert.Equal(t, "Backup expired", report.Spec.Entries[0].Reason)
+}
+
+func TestGCControllerResumesGCReport(t *testing.T) {
+	var (
+		fakeClock       = clock.NewFakeClock(time.Date(2018, 4, 1, 18, 0, 0, 0, time.UTC))
+		start           = time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC)
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		backup          = arktest.NewTestBackup().WithName("backup-2").WithExpiration(fakeClock.Now().Add(-1 * time.Hour)).Backup
+	)
+
+	// a report of a period in progress saved before the server restarted, and an ended one
+	_, err := client.ArkV1().GCReports(api.DefaultNamespace).Create(&api.GCReport{
+		ObjectMeta: metav1.ObjectMeta{Namespace: api.DefaultNamespace, Name: "gc-report-20180401120000"},
+		Spec: api.GCReportSpec{
+			StartTimestamp: metav1.NewTime(start),
+			Entries: []api.GCReportEntry{
+				{
+					BackupNamespace: api.DefaultNamespace,
+					BackupName:      "backup-1",
+					Decision:        api.GCReportDecisionDeleted,
+					Reason:          "Backup expired",
+					FirstTimestamp:  metav1.NewTime(start),
+					LastTimestamp:   metav1.NewTime(start),
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	_, err = client.ArkV1().GCReports(api.DefaultNamespace).Create(&api.GCReport{
+		ObjectMeta: metav1.ObjectMeta{Namespace: api.DefaultNamespace, Name: "gc-report-20180331120000"},
+		Spec: api.GCReportSpec{
+			StartTimestamp: metav1.NewTime(start.Add(-24 * time.Hour)),
+			EndTimestamp:   metav1.NewTime(start),
+		},
+	})
+	require.NoError(t, err)
+
+	controller := NewGCController(
+		arktest.NewLogger(),
+		api.DefaultNamespace,
+		sharedInformers.Ark().V1().Backups(),
+		sharedInformers.Ark().V1().Schedules(),
+		client.ArkV1(),
+		client.ArkV1(),
+		nil,
+		"bucket",
+		1*time.Minute,
+		false,
+		0,
+		0,
+		0,
+		nil,
+		false,
+		0,
+		0,
+		nil,
+		client.ArkV1(),
+		24*time.Hour,
+		0,
+		0,
+		metrics.NewServerMetrics(),
+	).(*gcController)
+	controller.clock = fakeClock
+	controller.report = newGCReport(fakeClock.Now())
+
+	sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(backup)
+
+	require.NoError(t, controller.processQueueItem(kube.NamespaceAndName(backup)))
+	controller.enqueueAllBackups()
+
+	report, err := client.ArkV1().GCReports(api.DefaultNamespace).Get("gc-report-20180401120000", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, metav1.NewTime(start), report.Spec.StartTimestamp)
+	assert.True(t, report.Spec.EndTimestamp.IsZero())
+	require.Len(t, report.Spec.Entries, 2)
+	assert.Equal(t, "backup-1", report.Spec.Entries[0].BackupName)
+	assert.Equal(t, "backup-2", report.Spec.Entries[1].BackupName)
+
+	// the resumed period ends a period after it started
+	fakeClock.SetTime(start.Add(24 * time.Hour))
+	controller.enqueueAllBackups()
+
+	report, err = client.ArkV1().GCReports(api.DefaultNamespace).Get("gc-report-20180401120000", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, metav1.NewTime(start.Add(24*time.Hour)), report.Spec.EndTimestamp)
+	assert.Len(t, report.Spec.Entries, 2)
+}
+
+func TestGCControllerDeletesExpiredGCReports(t *testing.T) {
+	var (
+		fakeClock       = clock.NewFakeClock(time.Date(2018, 4, 10, 12, 0, 0, 0, time.UTC))
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+	)
+
+	existing := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+	}{
+		// ended longer than the retention ago
+		{name: "gc-report-20180401120000", start: time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC), end: time.Date(2018, 4, 2, 12, 0, 0, 0, time.UTC)},
+		// ended within the retention
+		{name: "gc-report-20180405120000", start: time.Date(2018, 4, 5, 12, 0, 0, 0, time.UTC), end: time.Date(2018, 4, 6, 12, 0, 0, 0, time.UTC)},
+		// never ended, and started longer than the retention ago
+		{name: "gc-report-20180301120000", start: time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)},
+		// the period in progress, which is resumed and ends
+		{name: "gc-report-20180409120000", start: time.Date(2018, 4, 9, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, r := range existing {
+		_, err := client.ArkV1().GCReports(api.DefaultNamespace).Create(&api.GCReport{
+			ObjectMeta: metav1.ObjectMeta{Namespace: api.DefaultNamespace, Name: r.name},
+			Spec: api.GCReportSpec{
+				StartTimestamp: metav1.NewTime(r.start),
+				EndTimestamp:   metav1.NewTime(r.end),
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	controller := NewGCController(
+		arktest.NewLogger(),
+		api.DefaultNamespace,
+		sharedInformers.Ark().V1().Backups(),
+		sharedInformers.Ark().V1().Schedules(),
+		client.ArkV1(),
+		client.ArkV1(),
+		nil,
+		"bucket",
+		1*time.Minute,
+		false,
+		0,
+		0,
+		0,
+		nil,
+		false,
+		0,
+		0,
+		nil,
+		client.ArkV1(),
+		24*time.Hour,
+		7*24*time.Hour,
+		0,
+		metrics.NewServerMetrics(),
+	).(*gcController)
+	controller.clock = fakeClock
+	controller.report = newGCReport(fakeClock.Now())
+
+	controller.enqueueAllBackups()
+
+	reports, err := client.ArkV1().GCReports(api.DefaultNamespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+
+	var names []string
+	for _, r := range reports.Items {
+		names = append(names, r.Name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"gc-report-20180405120000", "gc-report-20180409120000"}, names)
+
+	report, err := client.ArkV1().GCReports(api.DefaultNamespace).Get("gc-report-20180409120000", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, metav1.NewTime(fakeClock.Now()), report.Spec.EndTimestamp)
+}
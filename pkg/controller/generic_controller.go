@@ -27,8 +27,14 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
+
+	"github.com/heptio/ark/pkg/metrics"
 )
 
+// defaultMaxRetries is the number of times an item is retried before it's
+// dropped from the queue.
+const defaultMaxRetries = 15
+
 type genericController struct {
 	name             string
 	queue            workqueue.RateLimitingInterface
@@ -37,21 +43,25 @@ type genericController struct {
 	resyncFunc       func()
 	resyncPeriod     time.Duration
 	cacheSyncWaiters []cache.InformerSynced
+	maxRetries       int
+	metrics          *metrics.ServerMetrics
 }
 
 func newGenericController(name string, logger logrus.FieldLogger) *genericController {
 	c := &genericController{
-		name:   name,
-		queue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name),
-		logger: logger.WithField("controller", name),
+		name:       name,
+		queue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name),
+		logger:     logger.WithField("controller", name),
+		maxRetries: defaultMaxRetries,
 	}
 
 	return c
 }
 
 // Run is a blocking function that runs the specified number of worker goroutines
-// to process items in the work queue. It will return when it receives on the
-// ctx.Done() channel.
+// to process items in the work queue. When it receives on the ctx.Done() channel,
+// it stops handing out new items and returns once the workers have finished the
+// items they're processing.
 func (c *genericController) Run(ctx context.Context, numWorkers int) error {
 	if c.syncHandler == nil {
 		// programmer error
@@ -99,7 +109,7 @@ func (c *genericController) Run(ctx context.Context, numWorkers int) error {
 
 		wg.Add(1)
 		go func() {
-			wait.Until(c.resyncFunc, c.resyncPeriod, ctx.Done())
+			wait.Until(c.resync, c.resyncPeriod, ctx.Done())
 			wg.Done()
 		}()
 	}
@@ -109,6 +119,18 @@ func (c *genericController) Run(ctx context.Context, numWorkers int) error {
 	return nil
 }
 
+// resync runs resyncFunc, recording when it finished and how long it took so that resyncs
+// that stall or fall behind can be alerted on.
+func (c *genericController) resync() {
+	start := time.Now()
+	c.resyncFunc()
+	finished := time.Now()
+
+	if c.metrics != nil {
+		c.metrics.RegisterControllerResync(c.name, finished, finished.Sub(start))
+	}
+}
+
 func (c *genericController) runWorker() {
 	// continually take items off the queue (waits if it's
 	// empty) until we get a shutdown signal from the queue
@@ -125,6 +147,12 @@ func (c *genericController) processNextWorkItem() bool {
 	// it back with rate-limiting below
 	defer c.queue.Done(key)
 
+	// a queue that's been shut down still hands out the items added to it
+	// before, so don't start on them; they're left for the next server
+	if c.queue.ShuttingDown() {
+		return false
+	}
+
 	err := c.syncHandler(key.(string))
 	if err == nil {
 		// If you had no error, tell the queue to stop tracking history for your key. This will reset
@@ -133,7 +161,21 @@ func (c *genericController) processNextWorkItem() bool {
 		return true
 	}
 
-	c.logger.WithError(err).WithField("key", key).Error("Error in syncHandler, re-adding item to queue")
+	log := c.logger.WithError(err).WithField("key", key)
+
+	if requeues := c.queue.NumRequeues(key); requeues >= c.maxRetries {
+		// the item has failed too many times, so stop retrying it so that it
+		// doesn't block the controller or flood the logs. It may still be
+		// re-enqueued by an informer event or a resync.
+		log.WithField("retries", requeues).Warn("Error in syncHandler, dropping item from queue after exceeding maximum retries")
+		c.queue.Forget(key)
+		if c.metrics != nil {
+			c.metrics.RegisterControllerDroppedItem(c.name)
+		}
+		return true
+	}
+
+	log.Error("Error in syncHandler, re-adding item to queue")
 	// we had an error processing the item so add it back
 	// into the queue for re-processing with rate-limiting
 	c.queue.AddRateLimited(key)
@@ -141,6 +183,23 @@ func (c *genericController) processNextWorkItem() bool {
 	return true
 }
 
+// waitForWorkers waits for wg to be done, returning false if it isn't done within
+// gracePeriod.
+func waitForWorkers(wg *sync.WaitGroup, gracePeriod time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(gracePeriod):
+		return false
+	}
+}
+
 func (c *genericController) enqueue(obj interface{}) {
 	key, err := cache.MetaNamespaceKeyFunc(obj)
 	if err != nil {
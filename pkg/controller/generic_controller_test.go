@@ -0,0 +1,103 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/heptio/ark/pkg/metrics"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestGenericControllerDropsItemAfterMaxRetries(t *testing.T) {
+	c := newGenericController("test", arktest.NewLogger())
+	c.maxRetries = 2
+	c.metrics = metrics.NewServerMetrics()
+
+	var calls int
+	c.syncHandler = func(key string) error {
+		calls++
+		return errors.New("sync failed")
+	}
+
+	c.queue.Add("ns/name")
+
+	// the first maxRetries failures re-add the item to the queue with rate-limiting
+	for i := 0; i < c.maxRetries; i++ {
+		assert.True(t, c.processNextWorkItem())
+		assert.Equal(t, i+1, c.queue.NumRequeues("ns/name"))
+	}
+
+	// the next failure drops the item
+	assert.True(t, c.processNextWorkItem())
+	assert.Equal(t, c.maxRetries+1, calls)
+	assert.Equal(t, 0, c.queue.NumRequeues("ns/name"))
+	assert.Equal(t, 0, c.queue.Len())
+}
+
+func TestGenericControllerForgetsItemOnSuccess(t *testing.T) {
+	c := newGenericController("test", arktest.NewLogger())
+
+	fail := true
+	c.syncHandler = func(key string) error {
+		if fail {
+			fail = false
+			return errors.New("sync failed")
+		}
+		return nil
+	}
+
+	c.queue.Add("ns/name")
+
+	assert.True(t, c.processNextWorkItem())
+	assert.Equal(t, 1, c.queue.NumRequeues("ns/name"))
+
+	assert.True(t, c.processNextWorkItem())
+	assert.Equal(t, 0, c.queue.NumRequeues("ns/name"))
+	assert.Equal(t, 0, c.queue.Len())
+}
+
+func TestGenericControllerDoesNotStartItemsAfterShutDown(t *testing.T) {
+	c := newGenericController("test", arktest.NewLogger())
+
+	var calls int
+	c.syncHandler = func(key string) error {
+		calls++
+		return nil
+	}
+
+	c.queue.Add("ns/name")
+	c.queue.ShutDown()
+
+	assert.False(t, c.processNextWorkItem())
+	assert.Equal(t, 0, calls)
+}
+
+func TestWaitForWorkers(t *testing.T) {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	assert.False(t, waitForWorkers(&wg, time.Millisecond))
+
+	wg.Done()
+	assert.True(t, waitForWorkers(&wg, time.Minute))
+}
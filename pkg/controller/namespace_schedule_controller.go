@@ -0,0 +1,237 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions/ark/v1"
+	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
+	"github.com/heptio/ark/pkg/metrics"
+)
+
+// namespaceScheduleController generates a schedule for each namespace annotated with
+// the name of one of the server's namespace schedule policies, and deletes it once the
+// annotation is removed or the namespace is deleted.
+type namespaceScheduleController struct {
+	*genericController
+
+	namespace      string
+	namespaceStore cache.Store
+	scheduleLister listers.ScheduleLister
+	scheduleClient arkv1client.SchedulesGetter
+	policies       map[string]api.NamespaceSchedulePolicy
+}
+
+// NewNamespaceScheduleController constructs a new namespaceScheduleController that
+// generates schedules in namespace for the namespaces in namespaceInformer, which are
+// reconciled again every syncPeriod.
+func NewNamespaceScheduleController(
+	logger logrus.FieldLogger,
+	namespace string,
+	namespaceInformer cache.SharedIndexInformer,
+	scheduleInformer informers.ScheduleInformer,
+	scheduleClient arkv1client.SchedulesGetter,
+	policies map[string]api.NamespaceSchedulePolicy,
+	syncPeriod time.Duration,
+	metrics *metrics.ServerMetrics,
+) Interface {
+	if syncPeriod < time.Minute {
+		logger.WithField("syncPeriod", syncPeriod).Info("Provided namespace schedule sync period is too short. Setting to 1 minute")
+		syncPeriod = time.Minute
+	}
+
+	c := &namespaceScheduleController{
+		genericController: newGenericController("namespace-schedule", logger),
+		namespace:         namespace,
+		namespaceStore:    namespaceInformer.GetStore(),
+		scheduleLister:    scheduleInformer.Lister(),
+		scheduleClient:    scheduleClient,
+		policies:          policies,
+	}
+
+	c.syncHandler = c.processQueueItem
+	c.metrics = metrics
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters, namespaceInformer.HasSynced, scheduleInformer.Informer().HasSynced)
+
+	// generated schedules that are deleted or changed, and those of namespaces deleted
+	// while the server wasn't running, are reconciled by the resync
+	c.resyncPeriod = syncPeriod
+	c.resyncFunc = c.enqueueAllNamespaces
+
+	namespaceInformer.AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: c.enqueue,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oldNamespace := oldObj.(*v1.Namespace)
+				newNamespace := newObj.(*v1.Namespace)
+
+				if oldNamespace.Annotations[api.NamespaceScheduleAnnotation] == newNamespace.Annotations[api.NamespaceScheduleAnnotation] &&
+					oldNamespace.DeletionTimestamp.Equal(newNamespace.DeletionTimestamp) {
+					return
+				}
+				c.enqueue(newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+				key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+				if err != nil {
+					c.logger.WithError(errors.WithStack(err)).Error("Error creating queue key, item not added to queue")
+					return
+				}
+				c.queue.Add(key)
+			},
+		},
+	)
+
+	return c
+}
+
+// enqueueAllNamespaces enqueues every namespace, and the namespaces of all generated
+// schedules, which may no longer exist.
+func (c *namespaceScheduleController) enqueueAllNamespaces() {
+	for _, obj := range c.namespaceStore.List() {
+		c.enqueue(obj)
+	}
+
+	schedules, err := c.scheduleLister.Schedules(c.namespace).List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("error listing schedules")
+		return
+	}
+
+	for _, schedule := range schedules {
+		if ns := schedule.Labels[api.ScheduleNamespaceLabel]; ns != "" {
+			c.queue.Add(ns)
+		}
+	}
+}
+
+// namespaceScheduleName returns the name of the schedule generated for namespace.
+// Backups are labeled with the name of their schedule, so names that would be too
+// long for a label value are truncated, and end in a hash of the namespace to keep
+// them unique.
+func namespaceScheduleName(namespace string) string {
+	name := "namespace-" + namespace
+	if len(name) <= validation.LabelValueMaxLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(namespace))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+	return name[:validation.LabelValueMaxLength-len(suffix)] + suffix
+}
+
+func (c *namespaceScheduleController) processQueueItem(key string) error {
+	log := c.logger.WithField("namespace", key)
+
+	var policyName string
+	obj, exists, err := c.namespaceStore.GetByKey(key)
+	if err != nil {
+		return errors.Wrap(err, "error getting namespace")
+	}
+	if exists {
+		if namespace := obj.(*v1.Namespace); namespace.DeletionTimestamp == nil {
+			policyName = namespace.Annotations[api.NamespaceScheduleAnnotation]
+		}
+	}
+
+	scheduleName := namespaceScheduleName(key)
+	log = log.WithField("schedule", c.namespace+"/"+scheduleName)
+
+	existing, err := c.scheduleLister.Schedules(c.namespace).Get(scheduleName)
+	switch {
+	case apierrors.IsNotFound(err):
+		existing = nil
+	case err != nil:
+		return errors.Wrap(err, "error getting schedule")
+	case existing.Labels[api.ScheduleNamespaceLabel] != key:
+		log.Warn("Schedule exists but wasn't generated for the namespace, skipping")
+		return nil
+	}
+
+	if policyName == "" {
+		if existing == nil {
+			return nil
+		}
+
+		log.Info("Deleting schedule of namespace that's no longer annotated")
+		if err := c.scheduleClient.Schedules(c.namespace).Delete(scheduleName, nil); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrap(err, "error deleting schedule")
+		}
+		return nil
+	}
+
+	policy, found := c.policies[policyName]
+	if !found {
+		log.WithField("policy", policyName).Warn("Namespace is annotated with a schedule policy that doesn't exist, not updating its schedule")
+		return nil
+	}
+
+	desired := &api.Schedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   c.namespace,
+			Name:        scheduleName,
+			Labels:      map[string]string{api.ScheduleNamespaceLabel: key},
+			Annotations: map[string]string{api.NamespaceScheduleAnnotation: policyName},
+		},
+		Spec: api.ScheduleSpec{
+			Schedule: policy.Schedule,
+			Template: *policy.Template.DeepCopy(),
+		},
+	}
+	desired.Spec.Template.IncludedNamespaces = []string{key}
+
+	if existing == nil {
+		log.WithField("policy", policyName).Info("Creating schedule for namespace")
+		if _, err := c.scheduleClient.Schedules(c.namespace).Create(desired); err != nil {
+			return errors.Wrap(err, "error creating schedule")
+		}
+		return nil
+	}
+
+	if existing.Annotations[api.NamespaceScheduleAnnotation] == policyName && equality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
+		return nil
+	}
+
+	updated := existing.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = make(map[string]string)
+	}
+	updated.Annotations[api.NamespaceScheduleAnnotation] = policyName
+	updated.Spec = desired.Spec
+
+	log.WithField("policy", policyName).Info("Updating schedule for namespace")
+	if _, err := patchSchedule(existing, updated, c.scheduleClient); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	"github.com/heptio/ark/pkg/metrics"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestNamespaceScheduleControllerProcessQueueItem(t *testing.T) {
+	policies := map[string]api.NamespaceSchedulePolicy{
+		"daily": {
+			Schedule: "0 1 * * *",
+			Template: api.BackupSpec{TTL: metav1.Duration{Duration: 72 * time.Hour}},
+		},
+		"hourly": {
+			Schedule: "0 * * * *",
+			Template: api.BackupSpec{IncludedNamespaces: []string{"ignored"}},
+		},
+	}
+
+	generated := func(namespace, policy string) *api.Schedule {
+		template := policies[policy].Template
+		schedule := &api.Schedule{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   api.DefaultNamespace,
+				Name:        "namespace-" + namespace,
+				Labels:      map[string]string{api.ScheduleNamespaceLabel: namespace},
+				Annotations: map[string]string{api.NamespaceScheduleAnnotation: policy},
+			},
+			Spec: api.ScheduleSpec{
+				Schedule: policies[policy].Schedule,
+				Template: *template.DeepCopy(),
+			},
+		}
+		schedule.Spec.Template.IncludedNamespaces = []string{namespace}
+		return schedule
+	}
+
+	namespace := func(name, policy string) *v1.Namespace {
+		ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if policy != "" {
+			ns.Annotations = map[string]string{api.NamespaceScheduleAnnotation: policy}
+		}
+		return ns
+	}
+
+	deleting := namespace("team-a", "daily")
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+
+	tests := []struct {
+		name            string
+		namespace       *v1.Namespace
+		schedule        *api.Schedule
+		expectedCreate  *api.Schedule
+		expectPatch     bool
+		expectDeletion  bool
+		expectNoActions bool
+	}{
+		{
+			name:           "annotated namespace without a schedule gets one",
+			namespace:      namespace("team-a", "daily"),
+			expectedCreate: generated("team-a", "daily"),
+		},
+		{
+			name:           "the policy's included namespaces are replaced",
+			namespace:      namespace("team-a", "hourly"),
+			expectedCreate: generated("team-a", "hourly"),
+		},
+		{
+			name:            "annotated namespace with an up-to-date schedule is left alone",
+			namespace:       namespace("team-a", "daily"),
+			schedule:        generated("team-a", "daily"),
+			expectNoActions: true,
+		},
+		{
+			name:        "schedule is updated when the namespace's policy changes",
+			namespace:   namespace("team-a", "hourly"),
+			schedule:    generated("team-a", "daily"),
+			expectPatch: true,
+		},
+		{
+			name:           "schedule is deleted when the annotation is removed",
+			namespace:      namespace("team-a", ""),
+			schedule:       generated("team-a", "daily"),
+			expectDeletion: true,
+		},
+		{
+			name:           "schedule is deleted when the namespace is deleted",
+			schedule:       generated("team-a", "daily"),
+			expectDeletion: true,
+		},
+		{
+			name:           "schedule is deleted when the namespace is being deleted",
+			namespace:      deleting,
+			schedule:       generated("team-a", "daily"),
+			expectDeletion: true,
+		},
+		{
+			name:            "namespace annotated with an unknown policy is left alone",
+			namespace:       namespace("team-a", "weekly"),
+			schedule:        generated("team-a", "daily"),
+			expectNoActions: true,
+		},
+		{
+			name:            "schedule that wasn't generated isn't touched",
+			namespace:       namespace("team-a", ""),
+			schedule:        arktest.NewTestSchedule(api.DefaultNamespace, "namespace-team-a").Schedule,
+			expectNoActions: true,
+		},
+		{
+			name:            "namespace without the annotation or a schedule is left alone",
+			namespace:       namespace("team-a", ""),
+			expectNoActions: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client            = fake.NewSimpleClientset()
+				sharedInformers   = informers.NewSharedInformerFactory(client, 0)
+				namespaceInformer = cache.NewSharedIndexInformer(&cache.ListWatch{}, &v1.Namespace{}, 0, cache.Indexers{})
+			)
+
+			c := NewNamespaceScheduleController(
+				arktest.NewLogger(),
+				api.DefaultNamespace,
+				namespaceInformer,
+				sharedInformers.Ark().V1().Schedules(),
+				client.ArkV1(),
+				policies,
+				time.Minute,
+				metrics.NewServerMetrics(),
+			).(*namespaceScheduleController)
+
+			if test.namespace != nil {
+				require.NoError(t, namespaceInformer.GetStore().Add(test.namespace))
+			}
+			if test.schedule != nil {
+				require.NoError(t, sharedInformers.Ark().V1().Schedules().Informer().GetStore().Add(test.schedule))
+			}
+
+			require.NoError(t, c.processQueueItem("team-a"))
+
+			actions := client.Actions()
+			switch {
+			case test.expectNoActions:
+				assert.Len(t, actions, 0)
+			case test.expectedCreate != nil:
+				require.Len(t, actions, 1)
+				assert.Equal(t, core.NewCreateAction(api.SchemeGroupVersion.WithResource("schedules"), api.DefaultNamespace, test.expectedCreate), actions[0])
+			case test.expectPatch:
+				require.Len(t, actions, 1)
+				assert.Equal(t, "patch", actions[0].GetVerb())
+				assert.Contains(t, string(actions[0].(core.PatchAction).GetPatch()), `"schedule":"0 * * * *"`)
+			case test.expectDeletion:
+				require.Len(t, actions, 1)
+				assert.Equal(t, core.NewDeleteAction(api.SchemeGroupVersion.WithResource("schedules"), api.DefaultNamespace, "namespace-team-a"), actions[0])
+			}
+		})
+	}
+}
+
+func TestNamespaceScheduleName(t *testing.T) {
+	assert.Equal(t, "namespace-team-a", namespaceScheduleName("team-a"))
+
+	// names too long for a label value are truncated and stay unique
+	long := strings.Repeat("a", 60)
+	name := namespaceScheduleName(long + "-1")
+	assert.Empty(t, validation.IsValidLabelValue(name))
+	assert.True(t, strings.HasPrefix(name, "namespace-aaaa"))
+	assert.NotEqual(t, name, namespaceScheduleName(long+"-2"))
+}
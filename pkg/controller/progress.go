@@ -0,0 +1,73 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// progressUpdatePeriod is how often the status.progress of a running backup
+// or restore is updated.
+const progressUpdatePeriod = 10 * time.Second
+
+// runPeriodically calls f every period in a new goroutine until the returned
+// func is called. The returned func waits for any in-progress call to f to
+// return, so it's safe to update the same object afterwards.
+func runPeriodically(period time.Duration, f func()) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				f()
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// progressPatch returns a merge patch that sets an object's status.progress.
+func progressPatch(progress interface{}) ([]byte, error) {
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"progress": progress,
+		},
+	}
+
+	bytes, err := json.Marshal(patch)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding progress patch")
+	}
+
+	return bytes, nil
+}
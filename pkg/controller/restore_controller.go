@@ -24,6 +24,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,15 +32,19 @@ import (
 	"github.com/sirupsen/logrus"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	pkgbackup "github.com/heptio/ark/pkg/backup"
 	"github.com/heptio/ark/pkg/cloudprovider"
+	"github.com/heptio/ark/pkg/discovery"
 	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
 	informers "github.com/heptio/ark/pkg/generated/informers/externalversions/ark/v1"
 	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
@@ -53,11 +58,18 @@ import (
 // included here are explicitly excluded from the restoration process.
 var nonRestorableResources = []string{"nodes", "events", "events.events.k8s.io"}
 
+// mergeableResources are the resources for which a restore may specify a merge strategy.
+var mergeableResources = sets.NewString("configmaps", "secrets")
+
+// maxFieldManagerLength is the longest field manager name the API server accepts.
+const maxFieldManagerLength = 128
+
 type restoreController struct {
 	namespace           string
 	restoreClient       arkv1client.RestoresGetter
 	backupClient        arkv1client.BackupsGetter
 	restorer            restore.Restorer
+	discoveryHelper     discovery.Helper
 	backupService       cloudprovider.BackupService
 	bucket              string
 	pvProviderExists    bool
@@ -69,6 +81,20 @@ type restoreController struct {
 	queue               workqueue.RateLimitingInterface
 	logger              logrus.FieldLogger
 	pluginManager       plugin.Manager
+
+	// shutdownGracePeriod is how long in-progress restores are given to finish
+	// when the controller is stopped. Restores that don't finish are left in
+	// progress, and resumed when the server starts again.
+	shutdownGracePeriod time.Duration
+
+	// clusterID is the ID of the cluster restores are made to. Restores of backups
+	// taken from other clusters are only allowed if their spec says so.
+	clusterID string
+
+	// interrupted holds the keys of restores that were in progress when the
+	// server started, which are the only in-progress restores that are run.
+	interruptedLock sync.Mutex
+	interrupted     sets.String
 }
 
 func NewRestoreController(
@@ -77,10 +103,13 @@ func NewRestoreController(
 	restoreClient arkv1client.RestoresGetter,
 	backupClient arkv1client.BackupsGetter,
 	restorer restore.Restorer,
+	discoveryHelper discovery.Helper,
 	backupService cloudprovider.BackupService,
 	bucket string,
 	backupInformer informers.BackupInformer,
 	pvProviderExists bool,
+	shutdownGracePeriod time.Duration,
+	clusterID string,
 	logger logrus.FieldLogger,
 	pluginManager plugin.Manager,
 ) Interface {
@@ -89,6 +118,7 @@ func NewRestoreController(
 		restoreClient:       restoreClient,
 		backupClient:        backupClient,
 		restorer:            restorer,
+		discoveryHelper:     discoveryHelper,
 		backupService:       backupService,
 		bucket:              bucket,
 		pvProviderExists:    pvProviderExists,
@@ -97,8 +127,11 @@ func NewRestoreController(
 		restoreLister:       restoreInformer.Lister(),
 		restoreListerSynced: restoreInformer.Informer().HasSynced,
 		queue:               workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "restore"),
-		logger:              logger,
+		logger:              logger.WithField("controller", "restore"),
 		pluginManager:       pluginManager,
+		shutdownGracePeriod: shutdownGracePeriod,
+		clusterID:           clusterID,
+		interrupted:         sets.NewString(),
 	}
 
 	c.syncHandler = c.processRestore
@@ -108,9 +141,22 @@ func NewRestoreController(
 			AddFunc: func(obj interface{}) {
 				restore := obj.(*api.Restore)
 
+				key, err := cache.MetaNamespaceKeyFunc(restore)
+				if err != nil {
+					c.logger.WithError(errors.WithStack(err)).WithField("restore", restore).Error("Error creating queue key, item not added to queue")
+					return
+				}
+
 				switch restore.Status.Phase {
 				case "", api.RestorePhaseNew:
 					// only process new restores
+				case api.RestorePhaseInProgress:
+					// restores are only added in progress when the server starts
+					// after being stopped part way through them, so resume them
+					c.logger.WithField("restore", key).Info("Restore was interrupted, resuming it")
+					c.interruptedLock.Lock()
+					c.interrupted.Insert(key)
+					c.interruptedLock.Unlock()
 				default:
 					c.logger.WithFields(logrus.Fields{
 						"restore": kubeutil.NamespaceAndName(restore),
@@ -119,11 +165,6 @@ func NewRestoreController(
 					return
 				}
 
-				key, err := cache.MetaNamespaceKeyFunc(restore)
-				if err != nil {
-					c.logger.WithError(errors.WithStack(err)).WithField("restore", restore).Error("Error creating queue key, item not added to queue")
-					return
-				}
 				c.queue.Add(key)
 			},
 		},
@@ -133,8 +174,9 @@ func NewRestoreController(
 }
 
 // Run is a blocking function that runs the specified number of worker goroutines
-// to process items in the work queue. It will return when it receives on the
-// ctx.Done() channel.
+// to process items in the work queue. When it receives on the ctx.Done() channel,
+// it stops starting new restores and returns once the workers have finished the
+// ones in progress, or the shutdown grace period has passed.
 func (controller *restoreController) Run(ctx context.Context, numWorkers int) error {
 	var wg sync.WaitGroup
 
@@ -146,7 +188,10 @@ func (controller *restoreController) Run(ctx context.Context, numWorkers int) er
 		// We have to wait here in the deferred function instead of at the bottom of the function body
 		// because we have to shut down the queue in order for the workers to shut down gracefully, and
 		// we want to shut down the queue via defer and not at the end of the body.
-		wg.Wait()
+		if !waitForWorkers(&wg, controller.shutdownGracePeriod) {
+			controller.logger.WithField("shutdownGracePeriod", controller.shutdownGracePeriod).Warn("Timed out waiting for workers to finish, in-progress restores will be resumed when the server starts")
+			return
+		}
 
 		controller.logger.Info("All workers have finished")
 	}()
@@ -189,6 +234,12 @@ func (controller *restoreController) processNextWorkItem() bool {
 	// it back with rate-limiting below
 	defer controller.queue.Done(key)
 
+	// a queue that's been shut down still hands out the items added to it
+	// before, so don't start on them; they're left for the next server
+	if controller.queue.ShuttingDown() {
+		return false
+	}
+
 	err := controller.syncHandler(key.(string))
 	if err == nil {
 		// If you had no error, tell the queue to stop tracking history for your key. This will reset
@@ -230,6 +281,13 @@ func (controller *restoreController) processRestore(key string) error {
 	switch restore.Status.Phase {
 	case "", api.RestorePhaseNew:
 		// only process new restores
+	case api.RestorePhaseInProgress:
+		if !controller.takeInterrupted(key) {
+			return nil
+		}
+		// an interrupted restore was validated before it started, so just run it
+		// again; volumes it already created from snapshots are reused
+		return controller.completeRestore(restore, restore.DeepCopy())
 	default:
 		return nil
 	}
@@ -267,6 +325,26 @@ func (controller *restoreController) processRestore(key string) error {
 		return nil
 	}
 
+	return controller.completeRestore(original, restore)
+}
+
+// takeInterrupted returns whether the restore identified by key was interrupted and hasn't
+// been resumed yet, and marks it as resumed.
+func (controller *restoreController) takeInterrupted(key string) bool {
+	controller.interruptedLock.Lock()
+	defer controller.interruptedLock.Unlock()
+
+	if !controller.interrupted.Has(key) {
+		return false
+	}
+	controller.interrupted.Delete(key)
+	return true
+}
+
+// completeRestore runs an in-progress restore and patches its final status.
+func (controller *restoreController) completeRestore(original, restore *api.Restore) error {
+	logContext := controller.logger.WithField("key", kubeutil.NamespaceAndName(restore))
+
 	logContext.Debug("Running restore")
 	// execution & upload of restore
 	restoreWarnings, restoreErrors := controller.runRestore(restore, controller.bucket)
@@ -285,7 +363,7 @@ func (controller *restoreController) processRestore(key string) error {
 	restore.Status.Phase = api.RestorePhaseCompleted
 
 	logContext.Debug("Updating Restore final status")
-	if _, err = patchRestore(original, restore, controller.restoreClient); err != nil {
+	if _, err := patchRestore(original, restore, controller.restoreClient); err != nil {
 		logContext.WithError(errors.WithStack(err)).Info("Error updating Restore final status")
 	}
 
@@ -297,8 +375,16 @@ func (controller *restoreController) getValidationErrors(itm *api.Restore) []str
 
 	if itm.Spec.BackupName == "" {
 		validationErrors = append(validationErrors, "BackupName must be non-empty and correspond to the name of a backup in object storage.")
-	} else if _, err := controller.fetchBackup(controller.bucket, itm.Spec.BackupName); err != nil {
+	} else if backup, err := controller.fetchBackup(controller.bucket, itm.Spec.BackupName); err != nil {
 		validationErrors = append(validationErrors, fmt.Sprintf("Error retrieving backup: %v", err))
+	} else if backup.Status.Phase == api.BackupPhasePendingDeletion {
+		validationErrors = append(validationErrors, "Backup is pending deletion in the recycle bin. Recover it (ark backup recover) to restore it.")
+	} else if !pkgbackup.IsSupportedFormatVersion(backup.Status.Version) {
+		// restoring a backup with a newer layout would silently skip or misread the parts
+		// of it this server doesn't understand, so don't try
+		validationErrors = append(validationErrors, fmt.Sprintf("Backup has format version %d, but this server only supports versions up to %d. Upgrade Ark to restore it.", backup.Status.Version, pkgbackup.FormatVersion))
+	} else if controller.isClusterMismatch(backup) && !itm.Spec.AllowClusterMismatch {
+		validationErrors = append(validationErrors, fmt.Sprintf("Backup was taken from cluster %q, but this server is configured for cluster %q. Set spec.allowClusterMismatch (ark restore create --force) to restore it anyway.", backup.Status.ClusterID, controller.clusterID))
 	}
 
 	includedResources := sets.NewString(itm.Spec.IncludedResources...)
@@ -316,6 +402,112 @@ func (controller *restoreController) getValidationErrors(itm *api.Restore) []str
 		validationErrors = append(validationErrors, fmt.Sprintf("Invalid included/excluded resource lists: %v", err))
 	}
 
+	for _, resource := range sets.StringKeySet(itm.Spec.MergeStrategies).List() {
+		strategy := itm.Spec.MergeStrategies[resource]
+		if !mergeableResources.Has(resource) {
+			validationErrors = append(validationErrors, fmt.Sprintf("Merge strategies are not supported for %s", resource))
+		}
+		if strategy != api.MergeStrategyRestoredWins && strategy != api.MergeStrategyExistingWins {
+			validationErrors = append(validationErrors, fmt.Sprintf("Invalid merge strategy %q for %s", strategy, resource))
+		}
+	}
+
+	switch itm.Spec.ApplyMethod {
+	case "", api.RestoreApplyMethodCreate, api.RestoreApplyMethodServerSideApply:
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("Invalid apply method %q", itm.Spec.ApplyMethod))
+	}
+
+	switch itm.Spec.ApplyConflictPolicy {
+	case "", api.ApplyConflictPolicySkip, api.ApplyConflictPolicyForce:
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("Invalid apply conflict policy %q", itm.Spec.ApplyConflictPolicy))
+	}
+
+	if itm.Spec.FieldManager != "" {
+		if itm.Spec.ApplyMethod != api.RestoreApplyMethodServerSideApply {
+			validationErrors = append(validationErrors, fmt.Sprintf("Field manager %q can only be used with apply method %q", itm.Spec.FieldManager, api.RestoreApplyMethodServerSideApply))
+		} else if len(itm.Spec.FieldManager) > maxFieldManagerLength {
+			validationErrors = append(validationErrors, fmt.Sprintf("Invalid field manager %q: must be no more than %d characters", itm.Spec.FieldManager, maxFieldManagerLength))
+		}
+	}
+
+	switch itm.Spec.ExistingNamespacePolicy {
+	case "", api.ExistingNamespacePolicyLeave, api.ExistingNamespacePolicyMerge:
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("Invalid existing namespace policy %q", itm.Spec.ExistingNamespacePolicy))
+	}
+
+	switch itm.Spec.MissingCRDPolicy {
+	case "", api.MissingCRDPolicySkip, api.MissingCRDPolicyWarn, api.MissingCRDPolicyFail:
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("Invalid missing CRD policy %q", itm.Spec.MissingCRDPolicy))
+	}
+
+	for i, modifier := range itm.Spec.ResourceModifiers {
+		if modifier.LabelSelector == nil {
+			continue
+		}
+		if _, err := metav1.LabelSelectorAsSelector(modifier.LabelSelector); err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("Invalid label selector for resource modifier %d: %v", i, err))
+		}
+	}
+
+	if itm.Spec.NamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(itm.Spec.NamespaceSelector); err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("Invalid namespace selector: %v", err))
+		}
+	}
+
+	if factor := itm.Spec.ContainerResourcesFactor; factor != nil && (*factor < 0 || *factor > 1) {
+		validationErrors = append(validationErrors, fmt.Sprintf("Invalid container resources factor %v: must be between 0 and 1", *factor))
+	}
+
+	for _, src := range sets.StringKeySet(itm.Spec.ImageRegistryMapping).List() {
+		dst := itm.Spec.ImageRegistryMapping[src]
+		if src == "" || dst == "" || strings.HasSuffix(src, "/") || strings.HasSuffix(dst, "/") {
+			validationErrors = append(validationErrors, fmt.Sprintf("Invalid image registry mapping %q to %q: registry prefixes must not be empty or end with /", src, dst))
+		}
+	}
+
+	for _, key := range sets.StringKeySet(itm.Spec.AddedLabels).List() {
+		value := itm.Spec.AddedLabels[key]
+		if errs := append(validation.IsQualifiedName(key), validation.IsValidLabelValue(value)...); len(errs) > 0 {
+			validationErrors = append(validationErrors, fmt.Sprintf("Invalid added label %s=%q: %s", key, value, strings.Join(errs, "; ")))
+		}
+	}
+
+	for _, key := range sets.StringKeySet(itm.Spec.AddedAnnotations).List() {
+		if errs := validation.IsQualifiedName(strings.ToLower(key)); len(errs) > 0 {
+			validationErrors = append(validationErrors, fmt.Sprintf("Invalid added annotation %s: %s", key, strings.Join(errs, "; ")))
+		}
+	}
+
+	validRenames := true
+	for _, key := range sets.StringKeySet(itm.Spec.ResourceRenames).List() {
+		name := itm.Spec.ResourceRenames[key]
+		if _, _, err := restore.ParseResourceRename(key); err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("Invalid resource rename: %v", err))
+			validRenames = false
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			validationErrors = append(validationErrors, fmt.Sprintf("Invalid resource rename of %s to %q: %s", key, name, strings.Join(errs, "; ")))
+			validRenames = false
+		}
+	}
+	// collisions are checked on the resolved resources, so the same resource named
+	// in different forms is caught
+	if validRenames {
+		if err := restore.ValidateResourceRenames(itm.Spec.ResourceRenames, controller.discoveryHelper); err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("Invalid resource renames: %v", err))
+		}
+	}
+
+	if itm.Spec.BatchSize < 0 {
+		validationErrors = append(validationErrors, fmt.Sprintf("Invalid batch size %d: must not be negative", itm.Spec.BatchSize))
+	}
+
 	if !controller.pvProviderExists && itm.Spec.RestorePVs != nil && *itm.Spec.RestorePVs {
 		validationErrors = append(validationErrors, "Server is not configured for PV snapshot restores")
 	}
@@ -323,6 +515,13 @@ func (controller *restoreController) getValidationErrors(itm *api.Restore) []str
 	return validationErrors
 }
 
+// isClusterMismatch returns true if backup was taken from a different cluster than the one
+// the controller restores to. Backups without a cluster ID, and all backups when the
+// controller doesn't have one, match.
+func (controller *restoreController) isClusterMismatch(backup *api.Backup) bool {
+	return controller.clusterID != "" && backup.Status.ClusterID != "" && backup.Status.ClusterID != controller.clusterID
+}
+
 func (controller *restoreController) fetchBackup(bucket, name string) (*api.Backup, error) {
 	backup, err := controller.backupLister.Backups(controller.namespace).Get(name)
 	if err == nil {
@@ -333,7 +532,7 @@ func (controller *restoreController) fetchBackup(bucket, name string) (*api.Back
 		return nil, errors.WithStack(err)
 	}
 
-	logContext := controller.logger.WithField("backupName", name)
+	logContext := controller.logger.WithField("backup", name)
 
 	logContext.Debug("Backup not found in backupLister, checking object storage directly")
 	backup, err = controller.backupService.GetBackup(bucket, name)
@@ -416,7 +615,18 @@ func (controller *restoreController) runRestore(restore *api.Restore, bucket str
 	defer controller.pluginManager.CloseRestoreItemActions(restore.Name)
 
 	logContext.Info("starting restore")
-	restoreWarnings, restoreErrors = controller.restorer.Restore(restore, backup, backupFile, logFile, actions)
+	progress, stopProgressUpdates := controller.startProgressUpdates(restore.Namespace, restore.Name)
+	if restore.Spec.BatchSize > 0 {
+		progress.CheckpointEvery(restore.Spec.BatchSize, controller.recordCheckpoint(restore.Namespace, restore.Name))
+	}
+	volumes := &restoreVolumeRecorder{restoreClient: controller.restoreClient, namespace: restore.Namespace, name: restore.Name}
+	restoreWarnings, restoreErrors = controller.restorer.Restore(restore, backup, backupFile, logFile, actions, progress, volumes)
+	if controller.isClusterMismatch(backup) {
+		restoreWarnings.Ark = append(restoreWarnings.Ark, fmt.Sprintf("backup was taken from cluster %q, not this cluster (%q)", backup.Status.ClusterID, controller.clusterID))
+	}
+	stopProgressUpdates()
+	finalProgress := progress.Get()
+	restore.Status.Progress = &finalProgress
 	logContext.Info("restore completed")
 
 	// Try to upload the log file. This is best-effort. If we fail, we'll add to the ark errors.
@@ -486,6 +696,75 @@ func downloadToTempFile(backupName string, backupService cloudprovider.BackupSer
 	return file, nil
 }
 
+// startProgressUpdates returns a Progress for the named restore and starts periodically patching
+// the restore's status.progress from it. The returned func stops the updates.
+func (controller *restoreController) startProgressUpdates(namespace, name string) (*restore.Progress, func()) {
+	progress := new(restore.Progress)
+	log := controller.logger.WithField("restore", namespace+"/"+name)
+
+	stop := runPeriodically(progressUpdatePeriod, func() {
+		patch, err := progressPatch(progress.Get())
+		if err != nil {
+			log.WithError(err).Warn("Error updating restore progress")
+			return
+		}
+
+		if _, err := controller.restoreClient.Restores(namespace).Patch(name, types.MergePatchType, patch); err != nil {
+			log.WithError(errors.WithStack(err)).Warn("Error updating restore progress")
+		}
+	})
+
+	return progress, stop
+}
+
+// recordCheckpoint returns a func that patches the named restore's status.progress and
+// status.checkpoint after each batch of items it restores.
+func (controller *restoreController) recordCheckpoint(namespace, name string) func(api.RestoreProgress, api.RestoreCheckpoint) {
+	log := controller.logger.WithField("restore", namespace+"/"+name)
+
+	return func(progress api.RestoreProgress, checkpoint api.RestoreCheckpoint) {
+		patch, err := json.Marshal(map[string]interface{}{
+			"status": map[string]interface{}{
+				"progress":   progress,
+				"checkpoint": checkpoint,
+			},
+		})
+		if err != nil {
+			log.WithError(errors.WithStack(err)).Warn("Error encoding restore checkpoint")
+			return
+		}
+
+		if _, err := controller.restoreClient.Restores(namespace).Patch(name, types.MergePatchType, patch); err != nil {
+			log.WithError(errors.WithStack(err)).Warn("Error recording restore checkpoint")
+		}
+	}
+}
+
+// restoreVolumeRecorder records the volumes created for a restore in its status.restoredVolumes
+// as soon as they're created.
+type restoreVolumeRecorder struct {
+	restoreClient arkv1client.RestoresGetter
+	namespace     string
+	name          string
+}
+
+func (r *restoreVolumeRecorder) RecordVolume(pvName, volumeID string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"restoredVolumes": map[string]string{pvName: volumeID},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "error marshalling restored volume patch")
+	}
+
+	if _, err := r.restoreClient.Restores(r.namespace).Patch(r.name, types.MergePatchType, patch); err != nil {
+		return errors.Wrap(err, "error patching restore")
+	}
+
+	return nil
+}
+
 func patchRestore(original, updated *api.Restore, client arkv1client.RestoresGetter) (*api.Restore, error) {
 	origBytes, err := json.Marshal(original)
 	if err != nil {
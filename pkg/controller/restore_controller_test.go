@@ -23,12 +23,15 @@ import (
 	"io"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	core "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 
@@ -79,6 +82,7 @@ func TestFetchBackup(t *testing.T) {
 				backupSvc       = &arktest.BackupService{}
 				logger          = arktest.NewLogger()
 				pluginManager   = &MockManager{}
+				discoveryHelper = arktest.NewFakeDiscoveryHelper(true, nil)
 			)
 
 			c := NewRestoreController(
@@ -87,10 +91,13 @@ func TestFetchBackup(t *testing.T) {
 				client.ArkV1(),
 				client.ArkV1(),
 				restorer,
+				discoveryHelper,
 				backupSvc,
 				"bucket",
 				sharedInformers.Ark().V1().Backups(),
 				false,
+				time.Minute,
+				"",
 				logger,
 				pluginManager,
 			).(*restoreController)
@@ -126,6 +133,7 @@ func TestProcessRestore(t *testing.T) {
 		expectedPhase               string
 		expectedValidationErrors    []string
 		expectedRestoreErrors       int
+		expectedRestoreWarnings     int
 		expectedRestorerCall        *api.Restore
 		backupServiceGetBackupError error
 		uploadLogError              error
@@ -187,6 +195,47 @@ func TestProcessRestore(t *testing.T) {
 			expectedValidationErrors:    []string{"Error retrieving backup: no backup here"},
 			backupServiceGetBackupError: errors.New("no backup here"),
 		},
+		{
+			name:                     "restore of a backup with an unsupported format version fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").WithVersion(4).Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Backup has format version 4, but this server only supports versions up to 3. Upgrade Ark to restore it."},
+		},
+		{
+			name:                     "restore of a backup pending deletion fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhasePendingDeletion).Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Backup is pending deletion in the recycle bin. Recover it (ark backup recover) to restore it."},
+		},
+		{
+			name:                     "restore of a backup from another cluster fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").WithClusterID("cluster-2").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{`Backup was taken from cluster "cluster-2", but this server is configured for cluster "cluster-1". Set spec.allowClusterMismatch (ark restore create --force) to restore it anyway.`},
+		},
+		{
+			name:                    "restore of a backup from another cluster that allows it gets executed, with a warning",
+			restore:                 NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).WithAllowClusterMismatch(true).Restore,
+			backup:                  arktest.NewTestBackup().WithName("backup-1").WithClusterID("cluster-2").Backup,
+			expectedErr:             false,
+			expectedPhase:           string(api.RestorePhaseInProgress),
+			expectedRestoreWarnings: 1,
+			expectedRestorerCall:    NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseInProgress).WithAllowClusterMismatch(true).Restore,
+		},
+		{
+			name:                 "restore of a backup from the same cluster gets executed",
+			restore:              NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).Restore,
+			backup:               arktest.NewTestBackup().WithName("backup-1").WithClusterID("cluster-1").Backup,
+			expectedErr:          false,
+			expectedPhase:        string(api.RestorePhaseInProgress),
+			expectedRestorerCall: NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseInProgress).Restore,
+		},
 		{
 			name:                  "restorer throwing an error causes the restore to fail",
 			restore:               NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).Restore,
@@ -222,6 +271,147 @@ func TestProcessRestore(t *testing.T) {
 			expectedPhase:            string(api.RestorePhaseFailedValidation),
 			expectedValidationErrors: []string{"Server is not configured for PV snapshot restores"},
 		},
+		{
+			name:          "restore with invalid merge strategies fails validation",
+			restore:       NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).WithMergeStrategy("pods", api.MergeStrategyRestoredWins).WithMergeStrategy("secrets", "Replace").Restore,
+			backup:        arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:   false,
+			expectedPhase: string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{
+				"Merge strategies are not supported for pods",
+				"Invalid merge strategy \"Replace\" for secrets",
+			},
+		},
+		{
+			name:                     "restore with invalid apply method fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).WithApplyMethod("replace").Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid apply method \"replace\""},
+		},
+		{
+			name:                     "restore with invalid apply conflict policy fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).WithApplyMethod(api.RestoreApplyMethodServerSideApply).WithApplyConflictPolicy("Overwrite").Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid apply conflict policy \"Overwrite\""},
+		},
+		{
+			name:                     "restore with a field manager but without server-side apply fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).WithFieldManager("argocd-controller").Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Field manager \"argocd-controller\" can only be used with apply method \"ssa\""},
+		},
+		{
+			name: "restore with invalid added labels and annotations fails validation",
+			restore: NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).
+				WithAddedLabel("app.kubernetes.io/instance", "my app").
+				WithAddedLabel("bad key", "value").
+				WithAddedAnnotation("argocd.argoproj.io/compare-options", "IgnoreExtraneous").
+				WithAddedAnnotation("/no-name", "value").
+				Restore,
+			backup:        arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:   false,
+			expectedPhase: string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{
+				"Invalid added label app.kubernetes.io/instance=\"my app\": a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')",
+				"Invalid added label bad key=\"value\": name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')",
+				"Invalid added annotation /no-name: prefix part must be non-empty",
+			},
+		},
+		{
+			name:                     "restore with invalid existing namespace policy fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).WithExistingNamespacePolicy("Replace").Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid existing namespace policy \"Replace\""},
+		},
+		{
+			name:                     "restore with invalid missing CRD policy fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).WithMissingCRDPolicy("Abort").Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid missing CRD policy \"Abort\""},
+		},
+		{
+			name:                     "restore with a container resources factor above 1 fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).WithContainerResourcesFactor(1.5).Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid container resources factor 1.5: must be between 0 and 1"},
+		},
+		{
+			name:                     "restore with an image registry mapping ending in / fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).WithImageRegistryMapping("registry.old.example.com/", "registry.new.example.com").Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid image registry mapping \"registry.old.example.com/\" to \"registry.new.example.com\": registry prefixes must not be empty or end with /"},
+		},
+		{
+			name:                     "restore with a resource rename without a name fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).WithResourceRename("widgets.example.com", "staging-app").Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid resource rename: \"widgets.example.com\" must be in the form <resource>/<name>"},
+		},
+		{
+			name:                     "restore with a resource rename to an invalid name fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).WithResourceRename("widgets.example.com/prod-app", "Staging_App").Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid resource rename of widgets.example.com/prod-app to \"Staging_App\": a DNS-1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')"},
+		},
+		{
+			name: "restore with two items of the same resource renamed to the same name fails validation",
+			restore: NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).
+				WithResourceRename("widgets.example.com/prod-app", "staging-app").
+				WithResourceRename("widgets.example.com/qa-app", "staging-app").
+				WithResourceRename("gadgets.example.com/prod-app", "staging-app").
+				Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid resource renames: widgets.example.com/prod-app and widgets.example.com/qa-app are both renamed to staging-app"},
+		},
+		{
+			name: "restore with two items of the same resource, named in different forms, renamed to the same name fails validation",
+			restore: NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).
+				WithResourceRename("widgets/prod-app", "staging-app").
+				WithResourceRename("widgets.example.com/qa-app", "staging-app").
+				Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid resource renames: widgets.example.com/qa-app and widgets/prod-app are both renamed to staging-app"},
+		},
+		{
+			name: "restore with an invalid namespace selector fails validation",
+			restore: NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).
+				WithNamespaceSelector(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Near"}}}).
+				Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid namespace selector: \"Near\" is not a valid pod selector operator"},
+		},
+		{
+			name:                     "restore with a negative batch size fails validation",
+			restore:                  NewRestore("foo", "bar", "backup-1", "ns-1", "", api.RestorePhaseNew).WithBatchSize(-1).Restore,
+			backup:                   arktest.NewTestBackup().WithName("backup-1").Backup,
+			expectedErr:              false,
+			expectedPhase:            string(api.RestorePhaseFailedValidation),
+			expectedValidationErrors: []string{"Invalid batch size -1: must not be negative"},
+		},
 		{
 			name:          "restoration of nodes is not supported",
 			restore:       NewRestore("foo", "bar", "backup-1", "ns-1", "nodes", api.RestorePhaseNew).Restore,
@@ -266,6 +456,11 @@ func TestProcessRestore(t *testing.T) {
 				backupSvc       = &arktest.BackupService{}
 				logger          = arktest.NewLogger()
 				pluginManager   = &MockManager{}
+				discoveryHelper = arktest.NewFakeDiscoveryHelper(false, map[schema.GroupVersionResource]schema.GroupVersionResource{
+					{Resource: "widgets"}:                       {Group: "example.com", Version: "v1", Resource: "widgets"},
+					{Group: "example.com", Resource: "widgets"}: {Group: "example.com", Version: "v1", Resource: "widgets"},
+					{Group: "example.com", Resource: "gadgets"}: {Group: "example.com", Version: "v1", Resource: "gadgets"},
+				})
 			)
 
 			defer restorer.AssertExpectations(t)
@@ -277,10 +472,13 @@ func TestProcessRestore(t *testing.T) {
 				client.ArkV1(),
 				client.ArkV1(),
 				restorer,
+				discoveryHelper,
 				backupSvc,
 				"bucket",
 				sharedInformers.Ark().V1().Backups(),
 				test.allowRestoreSnapshots,
+				time.Minute,
+				"cluster-1",
 				logger,
 				pluginManager,
 			).(*restoreController)
@@ -418,15 +616,25 @@ func TestProcessRestore(t *testing.T) {
 			assert.Equal(t, 1, len(patch), "patch has wrong number of keys")
 
 			res, _ = collections.GetMap(patch, "status")
-			expectedStatusKeys = 1
+			expectedStatusKeys = 2
 
 			assert.True(t, collections.HasKeyAndVal(patch, "status.phase", string(api.RestorePhaseCompleted)), "patch's status.phase does not match")
 
+			// the mock restorer doesn't restore anything
+			progress, err := collections.GetMap(patch, "status.progress")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{"totalItems": float64(0), "itemsRestored": float64(0)}, progress, "patch's status.progress does not match")
+
 			if test.expectedRestoreErrors != 0 {
 				assert.True(t, collections.HasKeyAndVal(patch, "status.errors", float64(test.expectedRestoreErrors)), "patch's status.errors does not match")
 				expectedStatusKeys++
 			}
 
+			if test.expectedRestoreWarnings != 0 {
+				assert.True(t, collections.HasKeyAndVal(patch, "status.warnings", float64(test.expectedRestoreWarnings)), "patch's status.warnings does not match")
+				expectedStatusKeys++
+			}
+
 			assert.Equal(t, expectedStatusKeys, len(res), "patch's status has wrong number of keys")
 
 			// explicitly capturing the argument passed to Restore myself because
@@ -437,6 +645,85 @@ func TestProcessRestore(t *testing.T) {
 	}
 }
 
+func TestProcessInterruptedRestore(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		restorer        = &fakeRestorer{}
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		backupSvc       = &arktest.BackupService{}
+		pluginManager   = &MockManager{}
+	)
+
+	c := NewRestoreController(
+		api.DefaultNamespace,
+		sharedInformers.Ark().V1().Restores(),
+		client.ArkV1(),
+		client.ArkV1(),
+		restorer,
+		arktest.NewFakeDiscoveryHelper(true, nil),
+		backupSvc,
+		"bucket",
+		sharedInformers.Ark().V1().Backups(),
+		true,
+		time.Minute,
+		"",
+		arktest.NewLogger(),
+		pluginManager,
+	).(*restoreController)
+
+	restore := NewRestore(api.DefaultNamespace, "restore-1", "backup-1", "ns-1", "", api.RestorePhaseInProgress).
+		WithRestoredVolume("pv-1", "volume-1").
+		Restore
+	key := restore.Namespace + "/" + restore.Name
+
+	sharedInformers.Ark().V1().Restores().Informer().GetStore().Add(restore)
+	sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(arktest.NewTestBackup().WithName("backup-1").Backup)
+
+	// an in-progress restore isn't run unless it was interrupted
+	require.NoError(t, c.processRestore(key))
+	assert.Empty(t, restorer.Calls)
+
+	backupSvc.On("DownloadBackup", mock.Anything, mock.Anything).Return(ioutil.NopCloser(bytes.NewReader([]byte("hello world"))), nil)
+	restorer.On("Restore", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(api.RestoreResult{}, api.RestoreResult{})
+	backupSvc.On("UploadRestoreLog", "bucket", "backup-1", "restore-1", mock.Anything).Return(nil)
+	backupSvc.On("UploadRestoreResults", "bucket", "backup-1", "restore-1", mock.Anything).Return(nil)
+	pluginManager.On("GetRestoreItemActions", "restore-1").Return(nil, nil)
+	pluginManager.On("CloseRestoreItemActions", "restore-1").Return(nil)
+
+	c.interrupted.Insert(key)
+	require.NoError(t, c.processRestore(key))
+
+	restorer.AssertExpectations(t)
+	backupSvc.AssertExpectations(t)
+	// the restore is run with the volumes restored by the interrupted run
+	assert.Equal(t, map[string]string{"pv-1": "volume-1"}, restorer.calledWithArg.Status.RestoredVolumes)
+
+	// the only update is the final status
+	actions := client.Actions()
+	require.Len(t, actions, 1)
+	patch := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(actions[0].(core.PatchAction).GetPatch(), &patch))
+	assert.True(t, collections.HasKeyAndVal(patch, "status.phase", string(api.RestorePhaseCompleted)))
+
+	// it's only resumed once
+	require.NoError(t, c.processRestore(key))
+	assert.Len(t, restorer.Calls, 1)
+}
+
+func TestRestoreVolumeRecorder(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	recorder := &restoreVolumeRecorder{restoreClient: client.ArkV1(), namespace: "ns-1", name: "restore-1"}
+
+	require.NoError(t, recorder.RecordVolume("pv-1", "volume-1"))
+
+	actions := client.Actions()
+	require.Len(t, actions, 1)
+	patchAction := actions[0].(core.PatchAction)
+	assert.Equal(t, "restore-1", patchAction.GetName())
+	assert.Equal(t, "ns-1", patchAction.GetNamespace())
+	assert.JSONEq(t, `{"status":{"restoredVolumes":{"pv-1":"volume-1"}}}`, string(patchAction.GetPatch()))
+}
+
 func NewRestore(ns, name, backup, includeNS, includeResource string, phase api.RestorePhase) *arktest.TestRestore {
 	restore := arktest.NewTestRestore(ns, name, phase).WithBackup(backup)
 
@@ -466,6 +753,8 @@ func (r *fakeRestorer) Restore(
 	backupReader io.Reader,
 	logger io.Writer,
 	actions []restore.ItemAction,
+	progress *restore.Progress,
+	volumes restore.VolumeRecorder,
 ) (api.RestoreResult, api.RestoreResult) {
 	res := r.Called(restore, backup, backupReader, logger)
 
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
@@ -38,6 +39,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	pkgbackup "github.com/heptio/ark/pkg/backup"
 	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
 	informers "github.com/heptio/ark/pkg/generated/informers/externalversions/ark/v1"
 	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
@@ -53,6 +55,8 @@ type scheduleController struct {
 	syncHandler           func(scheduleName string) error
 	queue                 workqueue.RateLimitingInterface
 	syncPeriod            time.Duration
+	nameTemplate          *pkgbackup.NameTemplate
+	clusterID             string
 	clock                 clock.Clock
 	logger                logrus.FieldLogger
 }
@@ -63,6 +67,8 @@ func NewScheduleController(
 	backupsClient arkv1client.BackupsGetter,
 	schedulesInformer informers.ScheduleInformer,
 	syncPeriod time.Duration,
+	nameTemplate *pkgbackup.NameTemplate,
+	clusterID string,
 	logger logrus.FieldLogger,
 ) *scheduleController {
 	if syncPeriod < time.Minute {
@@ -76,10 +82,12 @@ func NewScheduleController(
 		backupsClient:         backupsClient,
 		schedulesLister:       schedulesInformer.Lister(),
 		schedulesListerSynced: schedulesInformer.Informer().HasSynced,
-		queue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "schedule"),
-		syncPeriod: syncPeriod,
-		clock:      clock.RealClock{},
-		logger:     logger,
+		queue:                 workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "schedule"),
+		syncPeriod:            syncPeriod,
+		nameTemplate:          nameTemplate,
+		clusterID:             clusterID,
+		clock:                 clock.RealClock{},
+		logger:                logger.WithField("controller", "schedule"),
 	}
 
 	c.syncHandler = c.processSchedule
@@ -90,7 +98,7 @@ func NewScheduleController(
 				schedule := obj.(*api.Schedule)
 
 				switch schedule.Status.Phase {
-				case "", api.SchedulePhaseNew, api.SchedulePhaseEnabled:
+				case "", api.SchedulePhaseNew, api.SchedulePhaseEnabled, api.SchedulePhaseFailedValidation:
 					// add to work queue
 				default:
 					c.logger.WithFields(logrus.Fields{
@@ -107,6 +115,23 @@ func NewScheduleController(
 				}
 				c.queue.Add(key)
 			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oldSchedule := oldObj.(*api.Schedule)
+				newSchedule := newObj.(*api.Schedule)
+
+				// re-validate schedules whose cron expression was changed, so that a schedule
+				// that failed validation is enabled once it's fixed
+				if oldSchedule.Spec.Schedule == newSchedule.Spec.Schedule {
+					return
+				}
+
+				key, err := cache.MetaNamespaceKeyFunc(newSchedule)
+				if err != nil {
+					c.logger.WithError(errors.WithStack(err)).WithField("schedule", newSchedule).Error("Error creating queue key, item not added to queue")
+					return
+				}
+				c.queue.Add(key)
+			},
 		},
 	)
 
@@ -229,7 +254,7 @@ func (controller *scheduleController) processSchedule(key string) error {
 	}
 
 	switch schedule.Status.Phase {
-	case "", api.SchedulePhaseNew, api.SchedulePhaseEnabled:
+	case "", api.SchedulePhaseNew, api.SchedulePhaseEnabled, api.SchedulePhaseFailedValidation:
 		// valid phase for processing
 	default:
 		return nil
@@ -242,19 +267,27 @@ func (controller *scheduleController) processSchedule(key string) error {
 	schedule = schedule.DeepCopy()
 
 	// validation - even if the item is Enabled, we can't trust it
-	// so re-validate
+	// so re-validate, and a schedule that failed validation may
+	// have been fixed since
 	currentPhase := schedule.Status.Phase
+	currentErrs := schedule.Status.ValidationErrors
 
 	cronSchedule, errs := parseCronSchedule(schedule, controller.logger)
+	if len(errs) == 0 && controller.nameTemplate != nil {
+		if err := controller.nameTemplate.CheckInterval(shortestInterval(cronSchedule, controller.clock.Now())); err != nil {
+			errs = append(errs, fmt.Sprintf("backups can't be named with the server's backupNameTemplate: %v", err))
+		}
+	}
 	if len(errs) > 0 {
 		schedule.Status.Phase = api.SchedulePhaseFailedValidation
 		schedule.Status.ValidationErrors = errs
 	} else {
 		schedule.Status.Phase = api.SchedulePhaseEnabled
+		schedule.Status.ValidationErrors = nil
 	}
 
 	// update status if it's changed
-	if currentPhase != schedule.Status.Phase {
+	if currentPhase != schedule.Status.Phase || !reflect.DeepEqual(currentErrs, schedule.Status.ValidationErrors) {
 		updatedSchedule, err := patchSchedule(original, schedule, controller.schedulesClient)
 		if err != nil {
 			return errors.Wrapf(err, "error updating Schedule phase to %s", schedule.Status.Phase)
@@ -333,7 +366,14 @@ func (controller *scheduleController) submitBackupIfDue(item *api.Schedule, cron
 	// backups so that we don't overlap runs (for disk snapshots in particular, this can
 	// lead to performance issues).
 	logContext.WithField("nextRunTime", nextRunTime).Info("Schedule is due, submitting Backup")
-	backup := getBackup(item, now)
+	backup := pkgbackup.NewScheduledBackup(item, now)
+	if controller.nameTemplate != nil {
+		name, err := controller.nameTemplate.Name(pkgbackup.NewNameTemplateData(item.Name, now, controller.clusterID))
+		if err != nil {
+			return err
+		}
+		backup.Name = name
+	}
 	if _, err := controller.backupsClient.Backups(backup.Namespace).Create(backup); err != nil {
 		return errors.Wrap(err, "error creating Backup")
 	}
@@ -350,6 +390,27 @@ func (controller *scheduleController) submitBackupIfDue(item *api.Schedule, cron
 	return nil
 }
 
+// shortestInterval returns the shortest time between the next runs of cronSchedule after
+// from, which cron schedules like "0 1 * * 1-5" don't all have the same time between.
+func shortestInterval(cronSchedule cron.Schedule, from time.Time) time.Duration {
+	var shortest time.Duration
+
+	last := cronSchedule.Next(from)
+	for i := 0; i < 100 && !last.IsZero(); i++ {
+		next := cronSchedule.Next(last)
+		if next.IsZero() {
+			break
+		}
+
+		if interval := next.Sub(last); shortest == 0 || interval < shortest {
+			shortest = interval
+		}
+		last = next
+	}
+
+	return shortest
+}
+
 func getNextRunTime(schedule *api.Schedule, cronSchedule cron.Schedule, asOf time.Time) (bool, time.Time) {
 	// get the latest run time (if the schedule hasn't run yet, this will be the zero value which will trigger
 	// an immediate backup)
@@ -360,21 +421,6 @@ func getNextRunTime(schedule *api.Schedule, cronSchedule cron.Schedule, asOf tim
 	return asOf.After(nextRunTime), nextRunTime
 }
 
-func getBackup(item *api.Schedule, timestamp time.Time) *api.Backup {
-	backup := &api.Backup{
-		Spec: item.Spec.Template,
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: item.Namespace,
-			Name:      fmt.Sprintf("%s-%s", item.Name, timestamp.Format("20060102150405")),
-			Labels: map[string]string{
-				"ark-schedule": item.Name,
-			},
-		},
-	}
-
-	return backup
-}
-
 func patchSchedule(original, updated *api.Schedule, client arkv1client.SchedulesGetter) (*api.Schedule, error) {
 	origBytes, err := json.Marshal(original)
 	if err != nil {
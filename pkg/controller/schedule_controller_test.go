@@ -33,6 +33,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	pkgbackup "github.com/heptio/ark/pkg/backup"
 	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
 	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
 	"github.com/heptio/ark/pkg/util/collections"
@@ -48,8 +49,11 @@ func TestProcessSchedule(t *testing.T) {
 		expectedErr             bool
 		expectedPhase           string
 		expectedValidationError string
-		expectedBackupCreate    *api.Backup
-		expectedLastBackup      string
+		// expectedValidationErrorsCleared is whether the schedule's existing validation
+		// errors are expected to be removed
+		expectedValidationErrorsCleared bool
+		expectedBackupCreate            *api.Backup
+		expectedLastBackup              string
 	}{
 		{
 			name:        "invalid key returns error",
@@ -62,10 +66,22 @@ func TestProcessSchedule(t *testing.T) {
 			expectedErr: false,
 		},
 		{
-			name:        "schedule with phase FailedValidation does not get processed",
-			schedule:    arktest.NewTestSchedule("ns", "name").WithPhase(api.SchedulePhaseFailedValidation).Schedule,
+			name: "schedule with phase FailedValidation whose errors haven't changed is not updated",
+			schedule: arktest.NewTestSchedule("ns", "name").WithPhase(api.SchedulePhaseFailedValidation).
+				WithValidationError("Schedule must be a non-empty valid Cron expression").Schedule,
 			expectedErr: false,
 		},
+		{
+			name: "schedule with phase FailedValidation gets enabled and triggers a backup once it's valid",
+			schedule: arktest.NewTestSchedule("ns", "name").WithPhase(api.SchedulePhaseFailedValidation).
+				WithValidationError("Schedule must be a non-empty valid Cron expression").WithCronSchedule("@every 5m").Schedule,
+			fakeClockTime:                   "2017-01-01 12:00:00",
+			expectedErr:                     false,
+			expectedPhase:                   string(api.SchedulePhaseEnabled),
+			expectedValidationErrorsCleared: true,
+			expectedBackupCreate:            arktest.NewTestBackup().WithNamespace("ns").WithName("name-20170101120000").WithLabel("ark-schedule", "name").Backup,
+			expectedLastBackup:              "2017-01-01 12:00:00",
+		},
 		{
 			name:                    "schedule with phase New gets validated and failed if invalid",
 			schedule:                arktest.NewTestSchedule("ns", "name").WithPhase(api.SchedulePhaseNew).Schedule,
@@ -129,6 +145,8 @@ func TestProcessSchedule(t *testing.T) {
 				client.ArkV1(),
 				sharedInformers.Ark().V1().Schedules(),
 				time.Duration(0),
+				nil,
+				"",
 				logger,
 			)
 
@@ -217,6 +235,14 @@ func TestProcessSchedule(t *testing.T) {
 					expectedStatusKeys++
 				}
 
+				if test.expectedValidationErrorsCleared {
+					errs, err := collections.GetValue(patch, "status.validationErrors")
+					require.NoError(t, err, "error getting patch's status.validationErrors")
+					assert.Nil(t, errs, "patch's status.validationErrors is not cleared")
+
+					expectedStatusKeys++
+				}
+
 				res, _ := collections.GetMap(patch, "status")
 				assert.Equal(t, expectedStatusKeys, len(res), "patch's status has the wrong number of keys")
 
@@ -270,6 +296,126 @@ func parseTime(timeString string) time.Time {
 	return res
 }
 
+func TestProcessScheduleUpdatesValidationErrors(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		schedule        = arktest.NewTestSchedule("ns", "name").WithPhase(api.SchedulePhaseFailedValidation).
+				WithValidationError("Schedule must be a non-empty valid Cron expression").WithCronSchedule("not a cron").Schedule
+	)
+
+	c := NewScheduleController(
+		"namespace",
+		client.ArkV1(),
+		client.ArkV1(),
+		sharedInformers.Ark().V1().Schedules(),
+		time.Duration(0),
+		nil,
+		"",
+		arktest.NewLogger(),
+	)
+	sharedInformers.Ark().V1().Schedules().Informer().GetStore().Add(schedule)
+
+	require.NoError(t, c.processSchedule("ns/name"))
+
+	actions := client.Actions()
+	require.Len(t, actions, 1)
+	patchAction, ok := actions[0].(core.PatchAction)
+	require.True(t, ok, "action is not a PatchAction")
+
+	// the schedule is still invalid, so only its errors change
+	assert.Equal(t, `{"status":{"validationErrors":["invalid schedule: Expected exactly 5 fields, found 3: not a cron"]}}`, string(patchAction.GetPatch()))
+}
+
+func TestProcessScheduleChecksNameTemplate(t *testing.T) {
+	nameTemplate, err := pkgbackup.NewNameTemplate(`{{.Schedule}}-{{.Time.Format "2006-01-02"}}`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		cronSchedule  string
+		expectedPhase api.SchedulePhase
+	}{
+		{name: "daily schedule is enabled", cronSchedule: "0 1 * * *", expectedPhase: api.SchedulePhaseEnabled},
+		{name: "weekday schedule is enabled", cronSchedule: "0 1 * * 1-5", expectedPhase: api.SchedulePhaseEnabled},
+		{name: "hourly schedule fails validation", cronSchedule: "0 * * * *", expectedPhase: api.SchedulePhaseFailedValidation},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				schedule        = arktest.NewTestSchedule("ns", "name").WithPhase(api.SchedulePhaseNew).WithCronSchedule(test.cronSchedule).Schedule
+				client          = fake.NewSimpleClientset(schedule)
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+			)
+
+			c := NewScheduleController(
+				"namespace",
+				client.ArkV1(),
+				client.ArkV1(),
+				sharedInformers.Ark().V1().Schedules(),
+				time.Duration(0),
+				nameTemplate,
+				"",
+				arktest.NewLogger(),
+			)
+			c.clock = clock.NewFakeClock(parseTime("2017-01-01 12:00:00"))
+			sharedInformers.Ark().V1().Schedules().Informer().GetStore().Add(schedule)
+
+			require.NoError(t, c.processSchedule("ns/name"))
+
+			// the first action patches the schedule's phase
+			require.NotEmpty(t, client.Actions())
+			patchAction, ok := client.Actions()[0].(core.PatchAction)
+			require.True(t, ok, "action is not a PatchAction")
+
+			var patch struct {
+				Status api.ScheduleStatus `json:"status"`
+			}
+			require.NoError(t, json.Unmarshal(patchAction.GetPatch(), &patch))
+			assert.Equal(t, test.expectedPhase, patch.Status.Phase)
+			if test.expectedPhase == api.SchedulePhaseFailedValidation {
+				require.Len(t, patch.Status.ValidationErrors, 1)
+				assert.Contains(t, patch.Status.ValidationErrors[0], "backupNameTemplate")
+			}
+		})
+	}
+}
+
+func TestSubmitBackupIfDueWithNameTemplate(t *testing.T) {
+	var (
+		schedule        = arktest.NewTestSchedule("ns", "daily").WithPhase(api.SchedulePhaseEnabled).WithCronSchedule("@every 5m").Schedule
+		client          = fake.NewSimpleClientset(schedule)
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+	)
+
+	nameTemplate, err := pkgbackup.NewNameTemplate("{{.ClusterID}}-{{.Schedule}}-{{.Timestamp}}")
+	require.NoError(t, err)
+
+	c := NewScheduleController(
+		"namespace",
+		client.ArkV1(),
+		client.ArkV1(),
+		sharedInformers.Ark().V1().Schedules(),
+		time.Duration(0),
+		nameTemplate,
+		"prod",
+		arktest.NewLogger(),
+	)
+	c.clock = clock.NewFakeClock(parseTime("2017-01-01 12:00:00"))
+
+	cronSchedule, err := cron.ParseStandard(schedule.Spec.Schedule)
+	require.NoError(t, err)
+
+	require.NoError(t, c.submitBackupIfDue(schedule, cronSchedule))
+
+	backups, err := client.ArkV1().Backups("ns").List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, backups.Items, 1)
+	assert.Equal(t, "prod-daily-20170101120000", backups.Items[0].Name)
+	assert.Equal(t, "daily", backups.Items[0].Labels[api.ScheduleNameLabel])
+}
+
 func TestGetNextRunTime(t *testing.T) {
 	tests := []struct {
 		name                      string
@@ -393,100 +539,3 @@ func TestParseCronSchedule(t *testing.T) {
 	assert.False(t, due)
 	assert.Equal(t, time.Date(2017, 8, 12, 9, 0, 0, 0, time.UTC), next)
 }
-
-func TestGetBackup(t *testing.T) {
-	tests := []struct {
-		name           string
-		schedule       *api.Schedule
-		testClockTime  string
-		expectedBackup *api.Backup
-	}{
-		{
-			name: "ensure name is formatted correctly (AM time)",
-			schedule: &api.Schedule{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "foo",
-					Name:      "bar",
-				},
-				Spec: api.ScheduleSpec{
-					Template: api.BackupSpec{},
-				},
-			},
-			testClockTime: "2017-07-25 09:15:00",
-			expectedBackup: &api.Backup{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "foo",
-					Name:      "bar-20170725091500",
-				},
-				Spec: api.BackupSpec{},
-			},
-		},
-		{
-			name: "ensure name is formatted correctly (PM time)",
-			schedule: &api.Schedule{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "foo",
-					Name:      "bar",
-				},
-				Spec: api.ScheduleSpec{
-					Template: api.BackupSpec{},
-				},
-			},
-			testClockTime: "2017-07-25 14:15:00",
-			expectedBackup: &api.Backup{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "foo",
-					Name:      "bar-20170725141500",
-				},
-				Spec: api.BackupSpec{},
-			},
-		},
-		{
-			name: "ensure schedule backup template is copied",
-			schedule: &api.Schedule{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "foo",
-					Name:      "bar",
-				},
-				Spec: api.ScheduleSpec{
-					Template: api.BackupSpec{
-						IncludedNamespaces: []string{"ns-1", "ns-2"},
-						ExcludedNamespaces: []string{"ns-3"},
-						IncludedResources:  []string{"foo", "bar"},
-						ExcludedResources:  []string{"baz"},
-						LabelSelector:      &metav1.LabelSelector{MatchLabels: map[string]string{"label": "value"}},
-						TTL:                metav1.Duration{Duration: time.Duration(300)},
-					},
-				},
-			},
-			testClockTime: "2017-07-25 09:15:00",
-			expectedBackup: &api.Backup{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "foo",
-					Name:      "bar-20170725091500",
-				},
-				Spec: api.BackupSpec{
-					IncludedNamespaces: []string{"ns-1", "ns-2"},
-					ExcludedNamespaces: []string{"ns-3"},
-					IncludedResources:  []string{"foo", "bar"},
-					ExcludedResources:  []string{"baz"},
-					LabelSelector:      &metav1.LabelSelector{MatchLabels: map[string]string{"label": "value"}},
-					TTL:                metav1.Duration{Duration: time.Duration(300)},
-				},
-			},
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			testTime, err := time.Parse("2006-01-02 15:04:05", test.testClockTime)
-			require.NoError(t, err, "unable to parse test.testClockTime: %v", err)
-
-			backup := getBackup(test.schedule, clock.NewFakeClock(testTime).Now())
-
-			assert.Equal(t, test.expectedBackup.Namespace, backup.Namespace)
-			assert.Equal(t, test.expectedBackup.Name, backup.Name)
-			assert.Equal(t, test.expectedBackup.Spec, backup.Spec)
-		})
-	}
-}
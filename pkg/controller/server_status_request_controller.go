@@ -0,0 +1,188 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/backup"
+	"github.com/heptio/ark/pkg/buildinfo"
+	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions/ark/v1"
+	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
+	"github.com/heptio/ark/pkg/metrics"
+	"github.com/heptio/ark/pkg/util/kube"
+)
+
+// serverStatusRequestMaxAge is how long a processed ServerStatusRequest is kept
+// before it's deleted, in case the client that created it didn't delete it.
+const serverStatusRequestMaxAge = time.Minute
+
+// serverStatusRequestController fills in the status of ServerStatusRequests with
+// information about the running server, such as its version.
+type serverStatusRequestController struct {
+	*genericController
+
+	serverStatusRequestClient arkv1client.ServerStatusRequestsGetter
+	serverStatusRequestLister listers.ServerStatusRequestLister
+	clock                     clock.Clock
+}
+
+// NewServerStatusRequestController creates a new server status request controller.
+func NewServerStatusRequestController(
+	logger logrus.FieldLogger,
+	serverStatusRequestClient arkv1client.ServerStatusRequestsGetter,
+	serverStatusRequestInformer informers.ServerStatusRequestInformer,
+	metrics *metrics.ServerMetrics,
+) Interface {
+	c := &serverStatusRequestController{
+		genericController:         newGenericController("server-status-request", logger),
+		serverStatusRequestClient: serverStatusRequestClient,
+		serverStatusRequestLister: serverStatusRequestInformer.Lister(),
+		clock:                     &clock.RealClock{},
+	}
+
+	c.syncHandler = c.processQueueItem
+	c.metrics = metrics
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters, serverStatusRequestInformer.Informer().HasSynced)
+
+	serverStatusRequestInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				req := obj.(*v1.ServerStatusRequest)
+
+				key, err := cache.MetaNamespaceKeyFunc(req)
+				if err != nil {
+					c.logger.WithError(errors.WithStack(err)).WithField("serverStatusRequest", req.Name).Error("Error creating queue key, item not added to queue")
+					return
+				}
+
+				c.queue.Add(key)
+			},
+		},
+	)
+
+	c.resyncPeriod = time.Minute
+	c.resyncFunc = c.deleteExpiredRequests
+
+	return c
+}
+
+func (c *serverStatusRequestController) processQueueItem(key string) error {
+	log := c.logger.WithField("key", key)
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return errors.Wrap(err, "error splitting queue key")
+	}
+
+	req, err := c.serverStatusRequestLister.ServerStatusRequests(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Debug("Unable to find ServerStatusRequest")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting ServerStatusRequest")
+	}
+
+	if req.Status.Phase != "" && req.Status.Phase != v1.ServerStatusRequestPhaseNew {
+		return nil
+	}
+
+	log.Debug("Processing ServerStatusRequest")
+	_, err = c.patchServerStatusRequest(req.DeepCopy(), func(r *v1.ServerStatusRequest) {
+		r.Status.Phase = v1.ServerStatusRequestPhaseProcessed
+		r.Status.ProcessedTimestamp = metav1.NewTime(c.clock.Now())
+		r.Status.ServerVersion = buildinfo.Version
+		r.Status.ServerGitCommit = buildinfo.GitSHA
+		r.Status.BackupFormatVersion = backup.FormatVersion
+	})
+	return err
+}
+
+// deleteExpiredRequests deletes processed ServerStatusRequests that are older than
+// serverStatusRequestMaxAge.
+func (c *serverStatusRequestController) deleteExpiredRequests() {
+	// Our shared informer factory filters on a single namespace, so asking for all is ok here.
+	requests, err := c.serverStatusRequestLister.List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(err).Error("unable to check for expired ServerStatusRequests")
+		return
+	}
+
+	now := c.clock.Now()
+
+	for _, req := range requests {
+		if req.Status.Phase != v1.ServerStatusRequestPhaseProcessed {
+			continue
+		}
+
+		if now.Sub(req.Status.ProcessedTimestamp.Time) < serverStatusRequestMaxAge {
+			continue
+		}
+
+		log := c.logger.WithField("serverStatusRequest", kube.NamespaceAndName(req))
+		log.Debug("Deleting expired ServerStatusRequest")
+
+		err := c.serverStatusRequestClient.ServerStatusRequests(req.Namespace).Delete(req.Name, nil)
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.WithError(err).Error("Error deleting ServerStatusRequest")
+		}
+	}
+}
+
+func (c *serverStatusRequestController) patchServerStatusRequest(req *v1.ServerStatusRequest, mutate func(*v1.ServerStatusRequest)) (*v1.ServerStatusRequest, error) {
+	// Record original json
+	oldData, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling original ServerStatusRequest")
+	}
+
+	// Mutate
+	mutate(req)
+
+	// Record new json
+	newData, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling updated ServerStatusRequest")
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating json merge patch for ServerStatusRequest")
+	}
+
+	req, err = c.serverStatusRequestClient.ServerStatusRequests(req.Namespace).Patch(req.Name, types.MergePatchType, patchBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "error patching ServerStatusRequest")
+	}
+
+	return req, nil
+}
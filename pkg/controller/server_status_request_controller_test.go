@@ -0,0 +1,167 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
+	core "k8s.io/client-go/testing"
+
+	"github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/backup"
+	"github.com/heptio/ark/pkg/buildinfo"
+	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	"github.com/heptio/ark/pkg/metrics"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func newServerStatusRequest(name string, phase v1.ServerStatusRequestPhase, processed time.Time) *v1.ServerStatusRequest {
+	return &v1.ServerStatusRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: v1.DefaultNamespace,
+			Name:      name,
+		},
+		Status: v1.ServerStatusRequestStatus{
+			Phase:              phase,
+			ProcessedTimestamp: metav1.NewTime(processed),
+		},
+	}
+}
+
+func TestServerStatusRequestControllerProcessQueueItem(t *testing.T) {
+	now := time.Date(2018, 4, 5, 20, 12, 21, 0, time.UTC)
+
+	defer func(version, sha string) {
+		buildinfo.Version = version
+		buildinfo.GitSHA = sha
+	}(buildinfo.Version, buildinfo.GitSHA)
+	buildinfo.Version = "v1.2.3"
+	buildinfo.GitSHA = "abc123"
+
+	tests := []struct {
+		name          string
+		req           *v1.ServerStatusRequest
+		expectedPatch bool
+	}{
+		{
+			name: "request that doesn't exist is ignored",
+		},
+		{
+			name:          "request with no phase is processed",
+			req:           newServerStatusRequest("req-1", "", time.Time{}),
+			expectedPatch: true,
+		},
+		{
+			name:          "new request is processed",
+			req:           newServerStatusRequest("req-1", v1.ServerStatusRequestPhaseNew, time.Time{}),
+			expectedPatch: true,
+		},
+		{
+			name: "processed request isn't processed again",
+			req:  newServerStatusRequest("req-1", v1.ServerStatusRequestPhaseProcessed, now),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+			)
+
+			controller := NewServerStatusRequestController(
+				arktest.NewLogger(),
+				client.ArkV1(),
+				sharedInformers.Ark().V1().ServerStatusRequests(),
+				metrics.NewServerMetrics(),
+			).(*serverStatusRequestController)
+			controller.clock = clock.NewFakeClock(now)
+
+			if test.req != nil {
+				require.NoError(t, sharedInformers.Ark().V1().ServerStatusRequests().Informer().GetStore().Add(test.req))
+			}
+
+			require.NoError(t, controller.processQueueItem(v1.DefaultNamespace+"/req-1"))
+
+			if !test.expectedPatch {
+				assert.Empty(t, client.Actions())
+				return
+			}
+
+			require.Len(t, client.Actions(), 1)
+			action, ok := client.Actions()[0].(core.PatchAction)
+			require.True(t, ok, "expected a patch action, got %T", client.Actions()[0])
+			assert.Equal(t, schema.GroupVersionResource{Group: "ark.heptio.com", Version: "v1", Resource: "serverstatusrequests"}, action.GetResource())
+			assert.Equal(t, "req-1", action.GetName())
+
+			var patch map[string]interface{}
+			require.NoError(t, json.Unmarshal(action.GetPatch(), &patch))
+			expected := map[string]interface{}{
+				"status": map[string]interface{}{
+					"phase":               string(v1.ServerStatusRequestPhaseProcessed),
+					"processedTimestamp":  now.Format(time.RFC3339),
+					"serverVersion":       "v1.2.3",
+					"serverGitCommit":     "abc123",
+					"backupFormatVersion": float64(backup.FormatVersion),
+				},
+			}
+			assert.Equal(t, expected, patch)
+		})
+	}
+}
+
+func TestServerStatusRequestControllerDeleteExpiredRequests(t *testing.T) {
+	now := time.Date(2018, 4, 5, 20, 12, 21, 0, time.UTC)
+
+	var (
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+	)
+
+	controller := NewServerStatusRequestController(
+		arktest.NewLogger(),
+		client.ArkV1(),
+		sharedInformers.Ark().V1().ServerStatusRequests(),
+		metrics.NewServerMetrics(),
+	).(*serverStatusRequestController)
+	controller.clock = clock.NewFakeClock(now)
+
+	requests := []*v1.ServerStatusRequest{
+		newServerStatusRequest("new", v1.ServerStatusRequestPhaseNew, time.Time{}),
+		newServerStatusRequest("recent", v1.ServerStatusRequestPhaseProcessed, now.Add(-30*time.Second)),
+		newServerStatusRequest("expired", v1.ServerStatusRequestPhaseProcessed, now.Add(-serverStatusRequestMaxAge)),
+	}
+	for _, req := range requests {
+		require.NoError(t, sharedInformers.Ark().V1().ServerStatusRequests().Informer().GetStore().Add(req))
+	}
+
+	controller.deleteExpiredRequests()
+
+	expected := []core.Action{
+		core.NewDeleteAction(v1.SchemeGroupVersion.WithResource("serverstatusrequests"), v1.DefaultNamespace, "expired"),
+	}
+	assert.Equal(t, expected, client.Actions())
+}
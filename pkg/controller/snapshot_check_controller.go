@@ -0,0 +1,212 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/cloudprovider"
+	"github.com/heptio/ark/pkg/csi"
+	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions/ark/v1"
+	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
+	"github.com/heptio/ark/pkg/metrics"
+)
+
+// snapshotCheckController periodically checks that the volume snapshots of completed and
+// partially failed backups still exist, and sets their SnapshotsMissing condition accordingly.
+type snapshotCheckController struct {
+	*genericController
+
+	namespace       string
+	backupLister    listers.BackupLister
+	backupClient    arkv1client.BackupsGetter
+	snapshotService cloudprovider.SnapshotService
+	csiSnapshotter  csi.Snapshotter
+
+	clock clock.Clock
+}
+
+// NewSnapshotCheckController constructs a new snapshotCheckController that checks the backups in
+// namespace (or in all namespaces, if it's empty) every checkPeriod.
+func NewSnapshotCheckController(
+	logger logrus.FieldLogger,
+	namespace string,
+	backupInformer informers.BackupInformer,
+	backupClient arkv1client.BackupsGetter,
+	snapshotService cloudprovider.SnapshotService,
+	csiSnapshotter csi.Snapshotter,
+	checkPeriod time.Duration,
+	metrics *metrics.ServerMetrics,
+) Interface {
+	if checkPeriod < time.Minute {
+		logger.WithField("checkPeriod", checkPeriod).Info("Provided snapshot check period is too short. Setting to 1 minute")
+		checkPeriod = time.Minute
+	}
+
+	c := &snapshotCheckController{
+		genericController: newGenericController("snapshot-check", logger),
+		namespace:         namespace,
+		backupLister:      backupInformer.Lister(),
+		backupClient:      backupClient,
+		snapshotService:   snapshotService,
+		csiSnapshotter:    csiSnapshotter,
+		clock:             clock.RealClock{},
+	}
+
+	c.syncHandler = c.processQueueItem
+	c.metrics = metrics
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters, backupInformer.Informer().HasSynced)
+
+	// snapshots are deleted out-of-band, so there's no event to react to; every backup is
+	// checked each period instead
+	c.resyncPeriod = checkPeriod
+	c.resyncFunc = c.enqueueAllBackups
+
+	return c
+}
+
+func (c *snapshotCheckController) enqueueAllBackups() {
+	backups, err := c.backupLister.Backups(c.namespace).List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(errors.WithStack(err)).Error("error listing backups")
+		return
+	}
+
+	for _, backup := range backups {
+		c.enqueue(backup)
+	}
+}
+
+func (c *snapshotCheckController) processQueueItem(key string) error {
+	log := c.logger.WithField("backup", key)
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return errors.Wrap(err, "error splitting queue key")
+	}
+
+	backup, err := c.backupLister.Backups(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Debug("Unable to find backup")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error getting backup")
+	}
+
+	if !hasUsableContents(backup) || backup.DeletionTimestamp != nil || len(backup.Status.VolumeBackups) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for pvName, volumeBackup := range backup.Status.VolumeBackups {
+		var (
+			exists bool
+			err    error
+		)
+		switch {
+		case volumeBackup.CSISnapshot != nil && c.csiSnapshotter != nil:
+			exists, err = c.csiSnapshotter.SnapshotExists(volumeBackup.CSISnapshot)
+		case volumeBackup.CSISnapshot == nil && c.snapshotService != nil:
+			exists, err = c.snapshotService.SnapshotExists(volumeBackup.SnapshotID)
+		default:
+			// snapshots Ark isn't configured to take can't be checked
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error checking snapshot %s of persistent volume %s", volumeBackup.SnapshotID, pvName)
+		}
+		if !exists {
+			missing = append(missing, pvName)
+		}
+	}
+	sort.Strings(missing)
+
+	status, message := api.ConditionFalse, ""
+	if len(missing) > 0 {
+		status = api.ConditionTrue
+		message = fmt.Sprintf("The snapshots of persistent volumes %s no longer exist", strings.Join(missing, ", "))
+	}
+
+	updated := backup.DeepCopy()
+	if !setBackupCondition(updated, api.BackupConditionSnapshotsMissing, status, message, c.clock.Now()) {
+		return nil
+	}
+
+	if status == api.ConditionTrue {
+		log.WithField("persistentVolumes", missing).Warn("Backup's snapshots no longer exist, so its persistent volumes can't all be restored")
+	} else {
+		log.Info("Backup's snapshots exist again")
+	}
+
+	if _, err := patchBackup(backup, updated, c.backupClient); err != nil {
+		return errors.Wrap(err, "error updating backup's conditions")
+	}
+
+	return nil
+}
+
+// getBackupCondition returns the backup's condition of the specified type, or nil if it
+// doesn't have one.
+func getBackupCondition(backup *api.Backup, conditionType api.BackupConditionType) *api.BackupCondition {
+	for i := range backup.Status.Conditions {
+		if backup.Status.Conditions[i].Type == conditionType {
+			return &backup.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// setBackupCondition sets the backup's condition of the specified type, updating its
+// transition time if its status changed. A condition the backup doesn't already have isn't
+// added with a False status. It returns true if the backup was changed.
+func setBackupCondition(backup *api.Backup, conditionType api.BackupConditionType, status api.ConditionStatus, message string, now time.Time) bool {
+	condition := getBackupCondition(backup, conditionType)
+	if condition == nil {
+		if status == api.ConditionFalse {
+			return false
+		}
+		backup.Status.Conditions = append(backup.Status.Conditions, api.BackupCondition{Type: conditionType})
+		condition = &backup.Status.Conditions[len(backup.Status.Conditions)-1]
+	}
+
+	if condition.Status == status && condition.Message == message {
+		return false
+	}
+
+	if condition.Status != status {
+		condition.Status = status
+		condition.LastTransitionTime = metav1.NewTime(now)
+	}
+	condition.Message = message
+
+	return true
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+	core "k8s.io/client-go/testing"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	"github.com/heptio/ark/pkg/metrics"
+	"github.com/heptio/ark/pkg/util/kube"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestSnapshotCheckControllerProcessQueueItem(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+
+	tests := []struct {
+		name               string
+		backup             *api.Backup
+		snapshots          []string
+		csiSnapshots       []string
+		expectedConditions []api.BackupCondition
+	}{
+		{
+			name: "backup whose snapshots exist isn't changed",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseCompleted).
+				WithSnapshot("pv-1", "snap-1").
+				Backup,
+			snapshots: []string{"snap-1"},
+		},
+		{
+			name: "backup whose snapshots are missing gets a SnapshotsMissing condition",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseCompleted).
+				WithSnapshot("pv-1", "snap-1").
+				WithSnapshot("pv-2", "snap-2").
+				WithSnapshot("pv-3", "snap-3").
+				Backup,
+			snapshots: []string{"snap-2"},
+			expectedConditions: []api.BackupCondition{
+				{
+					Type:    api.BackupConditionSnapshotsMissing,
+					Status:  api.ConditionTrue,
+					Message: "The snapshots of persistent volumes pv-1, pv-3 no longer exist",
+				},
+			},
+		},
+		{
+			name: "backup that already has the condition isn't changed",
+			backup: func() *api.Backup {
+				backup := arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseCompleted).
+					WithSnapshot("pv-1", "snap-1").
+					WithCondition(api.BackupConditionSnapshotsMissing, api.ConditionTrue, fakeClock.Now().Add(-time.Hour)).
+					Backup
+				backup.Status.Conditions[0].Message = "The snapshots of persistent volumes pv-1 no longer exist"
+				return backup
+			}(),
+		},
+		{
+			name: "backup whose snapshots exist again has the condition cleared",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseCompleted).
+				WithSnapshot("pv-1", "snap-1").
+				WithCondition(api.BackupConditionSnapshotsMissing, api.ConditionTrue, fakeClock.Now().Add(-time.Hour)).
+				Backup,
+			snapshots: []string{"snap-1"},
+			expectedConditions: []api.BackupCondition{
+				{
+					Type:   api.BackupConditionSnapshotsMissing,
+					Status: api.ConditionFalse,
+				},
+			},
+		},
+		{
+			name: "backup whose CSI snapshots are missing gets a SnapshotsMissing condition",
+			backup: func() *api.Backup {
+				backup := arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseCompleted).
+					WithSnapshot("pv-1", "snap-1").
+					WithSnapshot("pv-2", "snap-2").
+					WithSnapshot("pv-3", "snap-3").
+					Backup
+				backup.Status.VolumeBackups["pv-2"].CSISnapshot = &api.CSISnapshotInfo{SnapshotHandle: "snap-2", VolumeSnapshotContentName: "snapcontent-2"}
+				backup.Status.VolumeBackups["pv-3"].CSISnapshot = &api.CSISnapshotInfo{SnapshotHandle: "snap-3", VolumeSnapshotContentName: "snapcontent-3"}
+				return backup
+			}(),
+			snapshots:    []string{"snap-1"},
+			csiSnapshots: []string{"snapcontent-3"},
+			expectedConditions: []api.BackupCondition{
+				{
+					Type:    api.BackupConditionSnapshotsMissing,
+					Status:  api.ConditionTrue,
+					Message: "The snapshots of persistent volumes pv-2 no longer exist",
+				},
+			},
+		},
+		{
+			name: "partially failed backup whose snapshots are missing gets a SnapshotsMissing condition",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhasePartiallyFailed).
+				WithSnapshot("pv-1", "snap-1").
+				Backup,
+			expectedConditions: []api.BackupCondition{
+				{
+					Type:    api.BackupConditionSnapshotsMissing,
+					Status:  api.ConditionTrue,
+					Message: "The snapshots of persistent volumes pv-1 no longer exist",
+				},
+			},
+		},
+		{
+			name: "backup that isn't completed isn't checked",
+			backup: arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseFailed).
+				WithSnapshot("pv-1", "snap-1").
+				Backup,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset(test.backup)
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				snapshotService = &arktest.FakeSnapshotService{SnapshotsTaken: sets.NewString(test.snapshots...)}
+				csiSnapshotter  = &arktest.FakeCSISnapshotter{Snapshots: make(map[string]bool)}
+			)
+			for _, snapshot := range test.csiSnapshots {
+				csiSnapshotter.Snapshots[snapshot] = true
+			}
+
+			controller := NewSnapshotCheckController(
+				arktest.NewLogger(),
+				"",
+				sharedInformers.Ark().V1().Backups(),
+				client.ArkV1(),
+				snapshotService,
+				csiSnapshotter,
+				time.Minute,
+				metrics.NewServerMetrics(),
+			).(*snapshotCheckController)
+			controller.clock = fakeClock
+
+			sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(test.backup)
+
+			require.NoError(t, controller.processQueueItem(kube.NamespaceAndName(test.backup)))
+
+			var patches []core.PatchAction
+			for _, action := range client.Actions() {
+				if patch, ok := action.(core.PatchAction); ok {
+					patches = append(patches, patch)
+				}
+			}
+
+			if test.expectedConditions == nil {
+				assert.Empty(t, patches)
+				return
+			}
+			require.Len(t, patches, 1)
+
+			var patch struct {
+				Status struct {
+					Conditions []api.BackupCondition `json:"conditions"`
+				} `json:"status"`
+			}
+			require.NoError(t, json.Unmarshal(patches[0].GetPatch(), &patch))
+
+			for i := range test.expectedConditions {
+				test.expectedConditions[i].LastTransitionTime.Time = fakeClock.Now()
+			}
+			require.Len(t, patch.Status.Conditions, len(test.expectedConditions))
+			for i, expected := range test.expectedConditions {
+				actual := patch.Status.Conditions[i]
+				assert.Equal(t, expected.Type, actual.Type)
+				assert.Equal(t, expected.Status, actual.Status)
+				assert.Equal(t, expected.Message, actual.Message)
+				assert.True(t, expected.LastTransitionTime.Time.Truncate(time.Second).Equal(actual.LastTransitionTime.Time), "lastTransitionTime does not match")
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/heptio/ark/pkg/cloudprovider"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions/ark/v1"
+	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
+	"github.com/heptio/ark/pkg/metrics"
+)
+
+// snapshotSweepController periodically deletes the volume snapshots that were left in the
+// cloud when their backups were deleted, once their retention has ended.
+type snapshotSweepController struct {
+	*genericController
+
+	namespace       string
+	backupLister    listers.BackupLister
+	snapshotService cloudprovider.SnapshotService
+	snapshotTags    map[string]string
+
+	clock clock.Clock
+}
+
+// NewSnapshotSweepController constructs a new snapshotSweepController that sweeps the retained
+// snapshots with all of snapshotTags every sweepPeriod. Snapshots referenced by the backups in
+// namespace (or in all namespaces, if it's empty) aren't deleted.
+func NewSnapshotSweepController(
+	logger logrus.FieldLogger,
+	namespace string,
+	backupInformer informers.BackupInformer,
+	snapshotService cloudprovider.SnapshotService,
+	snapshotTags map[string]string,
+	sweepPeriod time.Duration,
+	metrics *metrics.ServerMetrics,
+) Interface {
+	if sweepPeriod < time.Minute {
+		logger.WithField("sweepPeriod", sweepPeriod).Info("Provided snapshot sweep period is too short. Setting to 1 minute")
+		sweepPeriod = time.Minute
+	}
+
+	c := &snapshotSweepController{
+		genericController: newGenericController("snapshot-sweep", logger),
+		namespace:         namespace,
+		backupLister:      backupInformer.Lister(),
+		snapshotService:   snapshotService,
+		snapshotTags:      snapshotTags,
+		clock:             clock.RealClock{},
+	}
+
+	c.syncHandler = c.processQueueItem
+	c.metrics = metrics
+	c.cacheSyncWaiters = append(c.cacheSyncWaiters, backupInformer.Informer().HasSynced)
+
+	// retention ends without any event to react to, so the retained snapshots are
+	// listed each period instead
+	c.resyncPeriod = sweepPeriod
+	c.resyncFunc = c.enqueueExpiredSnapshots
+
+	return c
+}
+
+// referencedSnapshots returns the IDs of the snapshots referenced by backups.
+func (c *snapshotSweepController) referencedSnapshots() (sets.String, error) {
+	backups, err := c.backupLister.Backups(c.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	referenced := sets.NewString()
+	for _, backup := range backups {
+		for _, volumeBackup := range backup.Status.VolumeBackups {
+			if volumeBackup.CSISnapshot == nil {
+				referenced.Insert(volumeBackup.SnapshotID)
+			}
+		}
+	}
+
+	return referenced, nil
+}
+
+func (c *snapshotSweepController) enqueueExpiredSnapshots() {
+	retained, err := c.snapshotService.RetainedSnapshots(c.snapshotTags)
+	if err != nil {
+		c.logger.WithError(err).Error("error listing retained snapshots")
+		return
+	}
+
+	referenced, err := c.referencedSnapshots()
+	if err != nil {
+		c.logger.WithError(err).Error("error listing backups")
+		return
+	}
+
+	now := c.clock.Now()
+	for snapshotID, retainUntil := range retained {
+		if now.Before(retainUntil) || referenced.Has(snapshotID) {
+			continue
+		}
+		c.queue.Add(snapshotID)
+	}
+}
+
+func (c *snapshotSweepController) processQueueItem(snapshotID string) error {
+	log := c.logger.WithField("snapshotID", snapshotID)
+
+	// a backup referencing the snapshot may have been synced from the bucket since it
+	// was enqueued
+	referenced, err := c.referencedSnapshots()
+	if err != nil {
+		return errors.Wrap(err, "error listing backups")
+	}
+	if referenced.Has(snapshotID) {
+		log.Debug("Snapshot is referenced by a backup, not deleting it")
+		return nil
+	}
+
+	exists, err := c.snapshotService.SnapshotExists(snapshotID)
+	if err != nil {
+		return errors.Wrap(err, "error checking whether snapshot exists")
+	}
+	if !exists {
+		log.Debug("Snapshot no longer exists")
+		return nil
+	}
+
+	log.Info("Deleting snapshot whose retention has ended")
+	if err := c.snapshotService.DeleteSnapshot(snapshotID); err != nil {
+		return errors.Wrap(err, "error deleting snapshot")
+	}
+
+	return nil
+}
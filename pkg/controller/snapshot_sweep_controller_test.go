@@ -0,0 +1,89 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	"github.com/heptio/ark/pkg/metrics"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestSnapshotSweepController(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Date(2018, 4, 5, 20, 12, 21, 0, time.UTC))
+
+	var (
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		snapshotService = &arktest.FakeSnapshotService{
+			SnapshotsTaken: sets.NewString("snap-1", "snap-2", "snap-3", "snap-4", "snap-5"),
+			SnapshotTags: map[string]map[string]string{
+				"snap-1": {"cluster": "prod"},
+				"snap-2": {"cluster": "prod"},
+				"snap-3": {"cluster": "prod"},
+				"snap-4": {"cluster": "staging"},
+				"snap-5": {"cluster": "prod"},
+			},
+			RetainedUntil: map[string]time.Time{
+				// expired
+				"snap-1": fakeClock.Now().Add(-time.Hour),
+				// not expired
+				"snap-2": fakeClock.Now().Add(time.Hour),
+				// expired, but referenced by a backup
+				"snap-3": fakeClock.Now().Add(-time.Hour),
+				// expired, but not one of this cluster's
+				"snap-4": fakeClock.Now().Add(-time.Hour),
+			},
+		}
+	)
+
+	controller := NewSnapshotSweepController(
+		arktest.NewLogger(),
+		"",
+		sharedInformers.Ark().V1().Backups(),
+		snapshotService,
+		map[string]string{"cluster": "prod"},
+		time.Hour,
+		metrics.NewServerMetrics(),
+	).(*snapshotSweepController)
+	controller.clock = fakeClock
+
+	backup := arktest.NewTestBackup().WithName("backup-1").WithPhase(api.BackupPhaseCompleted).WithSnapshot("pv-1", "snap-3").Backup
+	require.NoError(t, sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(backup))
+
+	controller.enqueueExpiredSnapshots()
+	require.Equal(t, 1, controller.queue.Len())
+
+	key, _ := controller.queue.Get()
+	require.NoError(t, controller.processQueueItem(key.(string)))
+	controller.queue.Done(key)
+
+	assert.Equal(t, []string{"snap-2", "snap-3", "snap-4", "snap-5"}, snapshotService.SnapshotsTaken.List())
+
+	// snapshots that no longer exist are skipped
+	require.NoError(t, controller.processQueueItem("snap-1"))
+}
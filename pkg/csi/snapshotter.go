@@ -0,0 +1,414 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/client"
+	"github.com/heptio/ark/pkg/util/collections"
+)
+
+var (
+	// GroupVersion is the group and version of the CSI snapshot API.
+	GroupVersion = schema.GroupVersion{Group: "snapshot.storage.k8s.io", Version: "v1"}
+
+	volumeSnapshotResource        = metav1.APIResource{Name: "volumesnapshots", Namespaced: true, Kind: "VolumeSnapshot"}
+	volumeSnapshotContentResource = metav1.APIResource{Name: "volumesnapshotcontents", Kind: "VolumeSnapshotContent"}
+	volumeSnapshotClassResource   = metav1.APIResource{Name: "volumesnapshotclasses", Kind: "VolumeSnapshotClass"}
+)
+
+const (
+	// defaultClassAnnotation is the annotation that, set to "true", makes a
+	// VolumeSnapshotClass the default for its driver.
+	defaultClassAnnotation = "snapshot.storage.kubernetes.io/is-default-class"
+
+	// snapshotPollInterval is how often a snapshot is checked while waiting for
+	// its driver to take it.
+	snapshotPollInterval = time.Second
+
+	retainDeletionPolicy = "Retain"
+	deleteDeletionPolicy = "Delete"
+)
+
+// Snapshotter snapshots the PersistentVolumes of CSI drivers with the CSI snapshot API,
+// and makes the snapshots available to restored PersistentVolumeClaims.
+type Snapshotter interface {
+	// CreateSnapshot creates a VolumeSnapshot, with the given labels, of the claim pv is
+	// bound to, and waits for pv's driver to take the snapshot. The snapshot is retained
+	// if the VolumeSnapshot is deleted, e.g. along with its namespace, until it's deleted
+	// with DeleteSnapshot.
+	CreateSnapshot(pv runtime.Unstructured, labels map[string]string) (*api.CSISnapshotInfo, error)
+
+	// CreateVolumeSnapshot creates a VolumeSnapshot of the snapshot, named name in namespace
+	// and with the given labels, for restored claims to use as their data source. It's bound
+	// to a new VolumeSnapshotContent that leaves the snapshot when it's deleted. Nothing is
+	// created if the VolumeSnapshot already exists.
+	CreateVolumeSnapshot(snapshot *api.CSISnapshotInfo, namespace, name string, labels map[string]string) error
+
+	// DeleteSnapshot deletes the snapshot, along with the VolumeSnapshot it was taken with
+	// and its VolumeSnapshotContent.
+	DeleteSnapshot(snapshot *api.CSISnapshotInfo) error
+
+	// SnapshotExists returns whether the snapshot's VolumeSnapshotContent still exists.
+	SnapshotExists(snapshot *api.CSISnapshotInfo) (bool, error)
+}
+
+// IsBackupVolumeSnapshot returns whether obj, an item of groupResource, is a VolumeSnapshot that
+// Ark created to snapshot a volume for a backup, as opposed to one of the cluster's own. Those
+// are managed with their backups, so they aren't backed up or restored themselves.
+func IsBackupVolumeSnapshot(groupResource schema.GroupResource, obj metav1.Object) bool {
+	if groupResource.Group != GroupVersion.Group || groupResource.Resource != volumeSnapshotResource.Name {
+		return false
+	}
+
+	_, found := obj.GetLabels()[api.BackupNameLabel]
+	return found
+}
+
+// Driver returns the name of the CSI driver of pv's volume, or an empty string if it isn't
+// a CSI volume.
+func Driver(pv runtime.Unstructured) string {
+	driver, _ := collections.GetString(pv.UnstructuredContent(), "spec.csi.driver")
+	return driver
+}
+
+type snapshotter struct {
+	dynamicFactory client.DynamicFactory
+	timeout        time.Duration
+	pollInterval   time.Duration
+}
+
+// NewSnapshotter returns a Snapshotter that waits up to timeout for each snapshot to be taken.
+func NewSnapshotter(dynamicFactory client.DynamicFactory, timeout time.Duration) Snapshotter {
+	return &snapshotter{
+		dynamicFactory: dynamicFactory,
+		timeout:        timeout,
+		pollInterval:   snapshotPollInterval,
+	}
+}
+
+func (s *snapshotter) CreateSnapshot(pv runtime.Unstructured, labels map[string]string) (*api.CSISnapshotInfo, error) {
+	driver := Driver(pv)
+	if driver == "" {
+		return nil, errors.New("PersistentVolume isn't a CSI volume")
+	}
+
+	claimNamespace, _ := collections.GetString(pv.UnstructuredContent(), "spec.claimRef.namespace")
+	claimName, _ := collections.GetString(pv.UnstructuredContent(), "spec.claimRef.name")
+	if claimName == "" {
+		return nil, errors.New("PersistentVolume isn't bound to a claim, so it can't be snapshotted with the CSI snapshot API")
+	}
+
+	className, err := s.snapshotClassFor(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, volumeSnapshotResource, claimNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	volumeSnapshot := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": GroupVersion.String(),
+			"kind":       volumeSnapshotResource.Kind,
+			"spec": map[string]interface{}{
+				"volumeSnapshotClassName": className,
+				"source": map[string]interface{}{
+					"persistentVolumeClaimName": claimName,
+				},
+			},
+		},
+	}
+	volumeSnapshot.SetNamespace(claimNamespace)
+	volumeSnapshot.SetGenerateName(claimName + "-")
+	volumeSnapshot.SetLabels(labels)
+
+	created, err := snapshots.Create(volumeSnapshot)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating VolumeSnapshot")
+	}
+
+	snapshot := &api.CSISnapshotInfo{
+		Driver:                  driver,
+		VolumeSnapshotClassName: className,
+		VolumeSnapshotNamespace: claimNamespace,
+		VolumeSnapshotName:      created.GetName(),
+	}
+
+	if err := s.waitForSnapshot(snapshots, snapshot); err != nil {
+		// the VolumeSnapshot's class decides whether deleting it deletes whatever was taken
+		snapshots.Delete(snapshot.VolumeSnapshotName, nil)
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// snapshotClassFor returns the name of the VolumeSnapshotClass to snapshot the volumes of
+// driver with: the one labeled with CSIVolumeSnapshotClassLabel, or else the driver's default.
+func (s *snapshotter) snapshotClassFor(driver string) (string, error) {
+	classes, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, volumeSnapshotClassResource, "")
+	if err != nil {
+		return "", err
+	}
+
+	list, err := classes.List(metav1.ListOptions{})
+	if err != nil {
+		return "", errors.Wrap(err, "error listing VolumeSnapshotClasses")
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var defaultClass string
+	for _, item := range items {
+		class, ok := item.(*unstructured.Unstructured)
+		if !ok {
+			return "", errors.Errorf("unexpected type %T", item)
+		}
+
+		if classDriver, _ := collections.GetString(class.Object, "driver"); classDriver != driver {
+			continue
+		}
+
+		if class.GetLabels()[api.CSIVolumeSnapshotClassLabel] == "true" {
+			return class.GetName(), nil
+		}
+		if class.GetAnnotations()[defaultClassAnnotation] == "true" && defaultClass == "" {
+			defaultClass = class.GetName()
+		}
+	}
+
+	if defaultClass == "" {
+		return "", errors.Errorf("no VolumeSnapshotClass found for CSI driver %s; label one with %s=true or make one the driver's default", driver, api.CSIVolumeSnapshotClassLabel)
+	}
+
+	return defaultClass, nil
+}
+
+// waitForSnapshot waits for snapshot's VolumeSnapshot to be bound to a VolumeSnapshotContent
+// with the driver's handle for the snapshot, recording them in snapshot, and then sets the
+// VolumeSnapshotContent to retain the snapshot when it's deleted.
+func (s *snapshotter) waitForSnapshot(snapshots client.Dynamic, snapshot *api.CSISnapshotInfo) error {
+	err := wait.PollImmediate(s.pollInterval, s.timeout, func() (bool, error) {
+		volumeSnapshot, err := snapshots.Get(snapshot.VolumeSnapshotName, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "error getting VolumeSnapshot %s/%s", snapshot.VolumeSnapshotNamespace, snapshot.VolumeSnapshotName)
+		}
+
+		if message, _ := collections.GetString(volumeSnapshot.Object, "status.error.message"); message != "" {
+			return false, errors.Errorf("VolumeSnapshot %s/%s failed: %s", snapshot.VolumeSnapshotNamespace, snapshot.VolumeSnapshotName, message)
+		}
+
+		snapshot.VolumeSnapshotContentName, _ = collections.GetString(volumeSnapshot.Object, "status.boundVolumeSnapshotContentName")
+		return snapshot.VolumeSnapshotContentName != "", nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return errors.Errorf("VolumeSnapshot %s/%s wasn't bound to a VolumeSnapshotContent within %s", snapshot.VolumeSnapshotNamespace, snapshot.VolumeSnapshotName, s.timeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	contents, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, volumeSnapshotContentResource, "")
+	if err != nil {
+		return err
+	}
+
+	var content *unstructured.Unstructured
+	err = wait.PollImmediate(s.pollInterval, s.timeout, func() (bool, error) {
+		var err error
+		content, err = contents.Get(snapshot.VolumeSnapshotContentName, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "error getting VolumeSnapshotContent %s", snapshot.VolumeSnapshotContentName)
+		}
+
+		if message, _ := collections.GetString(content.Object, "status.error.message"); message != "" {
+			return false, errors.Errorf("VolumeSnapshotContent %s failed: %s", snapshot.VolumeSnapshotContentName, message)
+		}
+
+		snapshot.SnapshotHandle, _ = collections.GetString(content.Object, "status.snapshotHandle")
+		return snapshot.SnapshotHandle != "", nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return errors.Errorf("CSI driver %s didn't take the snapshot of VolumeSnapshotContent %s within %s", snapshot.Driver, snapshot.VolumeSnapshotContentName, s.timeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	return setDeletionPolicy(contents, content, retainDeletionPolicy)
+}
+
+func (s *snapshotter) CreateVolumeSnapshot(snapshot *api.CSISnapshotInfo, namespace, name string, labels map[string]string) error {
+	snapshots, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, volumeSnapshotResource, namespace)
+	if err != nil {
+		return err
+	}
+
+	if _, err := snapshots.Get(name, metav1.GetOptions{}); err == nil {
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error getting VolumeSnapshot %s/%s", namespace, name)
+	}
+
+	contents, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, volumeSnapshotContentResource, "")
+	if err != nil {
+		return err
+	}
+
+	content := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": GroupVersion.String(),
+			"kind":       volumeSnapshotContentResource.Kind,
+			"spec": map[string]interface{}{
+				// the snapshot belongs to the backup, so it's left when the restore's copy is deleted
+				"deletionPolicy": retainDeletionPolicy,
+				"driver":         snapshot.Driver,
+				"source": map[string]interface{}{
+					"snapshotHandle": snapshot.SnapshotHandle,
+				},
+				"volumeSnapshotRef": map[string]interface{}{
+					"apiVersion": GroupVersion.String(),
+					"kind":       volumeSnapshotResource.Kind,
+					"namespace":  namespace,
+					"name":       name,
+				},
+			},
+		},
+	}
+	if snapshot.VolumeSnapshotClassName != "" {
+		unstructured.SetNestedField(content.Object, snapshot.VolumeSnapshotClassName, "spec", "volumeSnapshotClassName")
+	}
+	content.SetGenerateName(name + "-")
+	content.SetLabels(labels)
+
+	createdContent, err := contents.Create(content)
+	if err != nil {
+		return errors.Wrap(err, "error creating VolumeSnapshotContent")
+	}
+
+	volumeSnapshot := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": GroupVersion.String(),
+			"kind":       volumeSnapshotResource.Kind,
+			"spec": map[string]interface{}{
+				"source": map[string]interface{}{
+					"volumeSnapshotContentName": createdContent.GetName(),
+				},
+			},
+		},
+	}
+	if snapshot.VolumeSnapshotClassName != "" {
+		unstructured.SetNestedField(volumeSnapshot.Object, snapshot.VolumeSnapshotClassName, "spec", "volumeSnapshotClassName")
+	}
+	volumeSnapshot.SetNamespace(namespace)
+	volumeSnapshot.SetName(name)
+	volumeSnapshot.SetLabels(labels)
+
+	if _, err := snapshots.Create(volumeSnapshot); err != nil {
+		contents.Delete(createdContent.GetName(), nil)
+		return errors.Wrap(err, "error creating VolumeSnapshot")
+	}
+
+	return nil
+}
+
+func (s *snapshotter) DeleteSnapshot(snapshot *api.CSISnapshotInfo) error {
+	contents, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, volumeSnapshotContentResource, "")
+	if err != nil {
+		return err
+	}
+
+	content, err := contents.Get(snapshot.VolumeSnapshotContentName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// there's nothing left of the snapshot in the cluster to delete it with
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error getting VolumeSnapshotContent %s", snapshot.VolumeSnapshotContentName)
+	}
+
+	// the content was set to retain the snapshot when it was taken, so it has to be set back
+	// to delete it for the driver to delete the snapshot
+	if err := setDeletionPolicy(contents, content, deleteDeletionPolicy); err != nil {
+		return err
+	}
+
+	snapshots, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, volumeSnapshotResource, snapshot.VolumeSnapshotNamespace)
+	if err != nil {
+		return err
+	}
+
+	if err := snapshots.Delete(snapshot.VolumeSnapshotName, nil); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error deleting VolumeSnapshot %s/%s", snapshot.VolumeSnapshotNamespace, snapshot.VolumeSnapshotName)
+	}
+
+	if err := contents.Delete(snapshot.VolumeSnapshotContentName, nil); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error deleting VolumeSnapshotContent %s", snapshot.VolumeSnapshotContentName)
+	}
+
+	return nil
+}
+
+func (s *snapshotter) SnapshotExists(snapshot *api.CSISnapshotInfo) (bool, error) {
+	contents, err := s.dynamicFactory.ClientForGroupVersionResource(GroupVersion, volumeSnapshotContentResource, "")
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := contents.Get(snapshot.VolumeSnapshotContentName, metav1.GetOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "error getting VolumeSnapshotContent %s", snapshot.VolumeSnapshotContentName)
+	}
+
+	return true, nil
+}
+
+// setDeletionPolicy updates content's deletion policy, if it isn't already policy.
+func setDeletionPolicy(contents client.Dynamic, content *unstructured.Unstructured, policy string) error {
+	if current, _ := collections.GetString(content.Object, "spec.deletionPolicy"); current == policy {
+		return nil
+	}
+
+	updated := content.DeepCopy()
+	unstructured.SetNestedField(updated.Object, policy, "spec", "deletionPolicy")
+
+	if _, err := contents.Update(updated); err != nil {
+		return errors.Wrapf(err, "error setting deletion policy of VolumeSnapshotContent %s to %s", content.GetName(), policy)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestDriver(t *testing.T) {
+	assert.Equal(t, "ebs.csi.aws.com", Driver(unstructuredOrDie(`{"spec": {"csi": {"driver": "ebs.csi.aws.com"}}}`)))
+	assert.Equal(t, "", Driver(unstructuredOrDie(`{"spec": {"awsElasticBlockStore": {"volumeID": "vol-1"}}}`)))
+}
+
+func TestIsBackupVolumeSnapshot(t *testing.T) {
+	volumeSnapshots := schema.GroupResource{Group: "snapshot.storage.k8s.io", Resource: "volumesnapshots"}
+
+	backupSnapshot := unstructuredOrDie(`{"metadata": {"name": "vs-1", "labels": {"ark.heptio.com/backup-name": "backup-1"}}}`)
+	assert.True(t, IsBackupVolumeSnapshot(volumeSnapshots, backupSnapshot))
+
+	clusterSnapshot := unstructuredOrDie(`{"metadata": {"name": "vs-2", "labels": {"app": "db"}}}`)
+	assert.False(t, IsBackupVolumeSnapshot(volumeSnapshots, clusterSnapshot))
+
+	// only VolumeSnapshots are backup snapshots
+	assert.False(t, IsBackupVolumeSnapshot(schema.GroupResource{Resource: "configmaps"}, backupSnapshot))
+}
+
+func TestSnapshotClassFor(t *testing.T) {
+	tests := []struct {
+		name          string
+		classes       []string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name: "class labeled for Ark is used",
+			classes: []string{
+				`{"metadata": {"name": "default", "annotations": {"snapshot.storage.kubernetes.io/is-default-class": "true"}}, "driver": "ebs.csi.aws.com"}`,
+				`{"metadata": {"name": "ark", "labels": {"ark.heptio.com/csi-volumesnapshot-class": "true"}}, "driver": "ebs.csi.aws.com"}`,
+			},
+			expected: "ark",
+		},
+		{
+			name: "driver's default class is used when none is labeled for Ark",
+			classes: []string{
+				`{"metadata": {"name": "other", "labels": {"ark.heptio.com/csi-volumesnapshot-class": "true"}}, "driver": "pd.csi.storage.gke.io"}`,
+				`{"metadata": {"name": "default", "annotations": {"snapshot.storage.kubernetes.io/is-default-class": "true"}}, "driver": "ebs.csi.aws.com"}`,
+			},
+			expected: "default",
+		},
+		{
+			name: "no class for the driver returns an error",
+			classes: []string{
+				`{"metadata": {"name": "other", "annotations": {"snapshot.storage.kubernetes.io/is-default-class": "true"}}, "driver": "pd.csi.storage.gke.io"}`,
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dynamicFactory := &arktest.FakeDynamicFactory{}
+			classes := &arktest.FakeDynamicClient{}
+			dynamicFactory.On("ClientForGroupVersionResource", GroupVersion, volumeSnapshotClassResource, "").Return(classes, nil)
+
+			list := &unstructured.UnstructuredList{}
+			for _, class := range test.classes {
+				list.Items = append(list.Items, *unstructuredOrDie(class))
+			}
+			classes.On("List", metav1.ListOptions{}).Return(list, nil)
+
+			s := &snapshotter{dynamicFactory: dynamicFactory}
+
+			className, err := s.snapshotClassFor("ebs.csi.aws.com")
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, className)
+		})
+	}
+}
+
+func TestCreateSnapshot(t *testing.T) {
+	dynamicFactory := &arktest.FakeDynamicFactory{}
+	classes := &arktest.FakeDynamicClient{}
+	snapshots := &arktest.FakeDynamicClient{}
+	contents := &arktest.FakeDynamicClient{}
+	dynamicFactory.On("ClientForGroupVersionResource", GroupVersion, volumeSnapshotClassResource, "").Return(classes, nil)
+	dynamicFactory.On("ClientForGroupVersionResource", GroupVersion, volumeSnapshotResource, "ns").Return(snapshots, nil)
+	dynamicFactory.On("ClientForGroupVersionResource", GroupVersion, volumeSnapshotContentResource, "").Return(contents, nil)
+
+	classes.On("List", metav1.ListOptions{}).Return(&unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{
+			*unstructuredOrDie(`{"metadata": {"name": "default", "annotations": {"snapshot.storage.kubernetes.io/is-default-class": "true"}}, "driver": "ebs.csi.aws.com"}`),
+		},
+	}, nil)
+
+	snapshots.On("Create", mock.MatchedBy(func(obj *unstructured.Unstructured) bool {
+		claimName, _ := unstructured.NestedString(obj.Object, "spec", "source", "persistentVolumeClaimName")
+		className, _ := unstructured.NestedString(obj.Object, "spec", "volumeSnapshotClassName")
+		return obj.GetGenerateName() == "mypvc-" && claimName == "mypvc" && className == "default" && obj.GetLabels()[api.BackupNameLabel] == "mybackup"
+	})).Return(unstructuredOrDie(`{"metadata": {"namespace": "ns", "name": "mypvc-abcde"}}`), nil)
+	snapshots.On("Get", "mypvc-abcde", metav1.GetOptions{}).Return(unstructuredOrDie(`{"metadata": {"namespace": "ns", "name": "mypvc-abcde"}, "status": {"boundVolumeSnapshotContentName": "snapcontent-1"}}`), nil)
+
+	contents.On("Get", "snapcontent-1", metav1.GetOptions{}).Return(unstructuredOrDie(`{"metadata": {"name": "snapcontent-1"}, "spec": {"deletionPolicy": "Delete"}, "status": {"snapshotHandle": "snap-1"}}`), nil)
+	contents.On("Update", mock.MatchedBy(func(obj *unstructured.Unstructured) bool {
+		policy, _ := unstructured.NestedString(obj.Object, "spec", "deletionPolicy")
+		return obj.GetName() == "snapcontent-1" && policy == "Retain"
+	})).Return(unstructuredOrDie(`{"metadata": {"name": "snapcontent-1"}, "spec": {"deletionPolicy": "Retain"}}`), nil)
+
+	s := &snapshotter{dynamicFactory: dynamicFactory, timeout: time.Second, pollInterval: time.Millisecond}
+
+	pv := unstructuredOrDie(`{"metadata": {"name": "mypv"}, "spec": {"csi": {"driver": "ebs.csi.aws.com"}, "claimRef": {"namespace": "ns", "name": "mypvc"}}}`)
+
+	snapshot, err := s.CreateSnapshot(pv, map[string]string{api.BackupNameLabel: "mybackup"})
+	require.NoError(t, err)
+
+	expected := &api.CSISnapshotInfo{
+		Driver:                    "ebs.csi.aws.com",
+		SnapshotHandle:            "snap-1",
+		VolumeSnapshotClassName:   "default",
+		VolumeSnapshotNamespace:   "ns",
+		VolumeSnapshotName:        "mypvc-abcde",
+		VolumeSnapshotContentName: "snapcontent-1",
+	}
+	assert.Equal(t, expected, snapshot)
+
+	snapshots.AssertExpectations(t)
+	contents.AssertExpectations(t)
+}
+
+func unstructuredOrDie(data string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal([]byte(data), &obj.Object); err != nil {
+		panic(err)
+	}
+	return obj
+}
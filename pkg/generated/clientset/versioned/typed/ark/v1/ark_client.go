@@ -25,11 +25,14 @@ import (
 type ArkV1Interface interface {
 	RESTClient() rest.Interface
 	BackupsGetter
+	BackupTombstonesGetter
 	ConfigsGetter
 	DeleteBackupRequestsGetter
 	DownloadRequestsGetter
+	GCReportsGetter
 	RestoresGetter
 	SchedulesGetter
+	ServerStatusRequestsGetter
 }
 
 // ArkV1Client is used to interact with features provided by the ark.heptio.com group.
@@ -41,6 +44,10 @@ func (c *ArkV1Client) Backups(namespace string) BackupInterface {
 	return newBackups(c, namespace)
 }
 
+func (c *ArkV1Client) BackupTombstones(namespace string) BackupTombstoneInterface {
+	return newBackupTombstones(c, namespace)
+}
+
 func (c *ArkV1Client) Configs(namespace string) ConfigInterface {
 	return newConfigs(c, namespace)
 }
@@ -53,6 +60,10 @@ func (c *ArkV1Client) DownloadRequests(namespace string) DownloadRequestInterfac
 	return newDownloadRequests(c, namespace)
 }
 
+func (c *ArkV1Client) GCReports(namespace string) GCReportInterface {
+	return newGCReports(c, namespace)
+}
+
 func (c *ArkV1Client) Restores(namespace string) RestoreInterface {
 	return newRestores(c, namespace)
 }
@@ -61,6 +72,10 @@ func (c *ArkV1Client) Schedules(namespace string) ScheduleInterface {
 	return newSchedules(c, namespace)
 }
 
+func (c *ArkV1Client) ServerStatusRequests(namespace string) ServerStatusRequestInterface {
+	return newServerStatusRequests(c, namespace)
+}
+
 // NewForConfig creates a new ArkV1Client for the given config.
 func NewForConfig(c *rest.Config) (*ArkV1Client, error) {
 	config := *c
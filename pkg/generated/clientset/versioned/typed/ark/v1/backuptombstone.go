@@ -0,0 +1,154 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1
+
+import (
+	v1 "github.com/heptio/ark/pkg/apis/ark/v1"
+	scheme "github.com/heptio/ark/pkg/generated/clientset/versioned/scheme"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// BackupTombstonesGetter has a method to return a BackupTombstoneInterface.
+// A group's client should implement this interface.
+type BackupTombstonesGetter interface {
+	BackupTombstones(namespace string) BackupTombstoneInterface
+}
+
+// BackupTombstoneInterface has methods to work with BackupTombstone resources.
+type BackupTombstoneInterface interface {
+	Create(*v1.BackupTombstone) (*v1.BackupTombstone, error)
+	Update(*v1.BackupTombstone) (*v1.BackupTombstone, error)
+	Delete(name string, options *meta_v1.DeleteOptions) error
+	DeleteCollection(options *meta_v1.DeleteOptions, listOptions meta_v1.ListOptions) error
+	Get(name string, options meta_v1.GetOptions) (*v1.BackupTombstone, error)
+	List(opts meta_v1.ListOptions) (*v1.BackupTombstoneList, error)
+	Watch(opts meta_v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.BackupTombstone, err error)
+	BackupTombstoneExpansion
+}
+
+// backupTombstones implements BackupTombstoneInterface
+type backupTombstones struct {
+	client rest.Interface
+	ns     string
+}
+
+// newBackupTombstones returns a BackupTombstones
+func newBackupTombstones(c *ArkV1Client, namespace string) *backupTombstones {
+	return &backupTombstones{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the backupTombstone, and returns the corresponding backupTombstone object, and an error if there is any.
+func (c *backupTombstones) Get(name string, options meta_v1.GetOptions) (result *v1.BackupTombstone, err error) {
+	result = &v1.BackupTombstone{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("backuptombstones").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of BackupTombstones that match those selectors.
+func (c *backupTombstones) List(opts meta_v1.ListOptions) (result *v1.BackupTombstoneList, err error) {
+	result = &v1.BackupTombstoneList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("backuptombstones").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested backupTombstones.
+func (c *backupTombstones) Watch(opts meta_v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("backuptombstones").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
+
+// Create takes the representation of a backupTombstone and creates it.  Returns the server's representation of the backupTombstone, and an error, if there is any.
+func (c *backupTombstones) Create(backupTombstone *v1.BackupTombstone) (result *v1.BackupTombstone, err error) {
+	result = &v1.BackupTombstone{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("backuptombstones").
+		Body(backupTombstone).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a backupTombstone and updates it. Returns the server's representation of the backupTombstone, and an error, if there is any.
+func (c *backupTombstones) Update(backupTombstone *v1.BackupTombstone) (result *v1.BackupTombstone, err error) {
+	result = &v1.BackupTombstone{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("backuptombstones").
+		Name(backupTombstone.Name).
+		Body(backupTombstone).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the backupTombstone and deletes it. Returns an error if one occurs.
+func (c *backupTombstones) Delete(name string, options *meta_v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("backuptombstones").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *backupTombstones) DeleteCollection(options *meta_v1.DeleteOptions, listOptions meta_v1.ListOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("backuptombstones").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched backupTombstone.
+func (c *backupTombstones) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.BackupTombstone, err error) {
+	result = &v1.BackupTombstone{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("backuptombstones").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
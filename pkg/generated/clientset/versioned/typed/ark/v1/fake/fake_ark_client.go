@@ -29,6 +29,10 @@ func (c *FakeArkV1) Backups(namespace string) v1.BackupInterface {
 	return &FakeBackups{c, namespace}
 }
 
+func (c *FakeArkV1) BackupTombstones(namespace string) v1.BackupTombstoneInterface {
+	return &FakeBackupTombstones{c, namespace}
+}
+
 func (c *FakeArkV1) Configs(namespace string) v1.ConfigInterface {
 	return &FakeConfigs{c, namespace}
 }
@@ -41,6 +45,10 @@ func (c *FakeArkV1) DownloadRequests(namespace string) v1.DownloadRequestInterfa
 	return &FakeDownloadRequests{c, namespace}
 }
 
+func (c *FakeArkV1) GCReports(namespace string) v1.GCReportInterface {
+	return &FakeGCReports{c, namespace}
+}
+
 func (c *FakeArkV1) Restores(namespace string) v1.RestoreInterface {
 	return &FakeRestores{c, namespace}
 }
@@ -49,6 +57,10 @@ func (c *FakeArkV1) Schedules(namespace string) v1.ScheduleInterface {
 	return &FakeSchedules{c, namespace}
 }
 
+func (c *FakeArkV1) ServerStatusRequests(namespace string) v1.ServerStatusRequestInterface {
+	return &FakeServerStatusRequests{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeArkV1) RESTClient() rest.Interface {
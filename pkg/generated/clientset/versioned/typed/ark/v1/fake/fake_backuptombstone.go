@@ -0,0 +1,125 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fake
+
+import (
+	ark_v1 "github.com/heptio/ark/pkg/apis/ark/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeBackupTombstones implements BackupTombstoneInterface
+type FakeBackupTombstones struct {
+	Fake *FakeArkV1
+	ns   string
+}
+
+var backuptombstonesResource = schema.GroupVersionResource{Group: "ark.heptio.com", Version: "v1", Resource: "backuptombstones"}
+
+var backuptombstonesKind = schema.GroupVersionKind{Group: "ark.heptio.com", Version: "v1", Kind: "BackupTombstone"}
+
+// Get takes name of the backupTombstone, and returns the corresponding backupTombstone object, and an error if there is any.
+func (c *FakeBackupTombstones) Get(name string, options v1.GetOptions) (result *ark_v1.BackupTombstone, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(backuptombstonesResource, c.ns, name), &ark_v1.BackupTombstone{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*ark_v1.BackupTombstone), err
+}
+
+// List takes label and field selectors, and returns the list of BackupTombstones that match those selectors.
+func (c *FakeBackupTombstones) List(opts v1.ListOptions) (result *ark_v1.BackupTombstoneList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(backuptombstonesResource, backuptombstonesKind, c.ns, opts), &ark_v1.BackupTombstoneList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &ark_v1.BackupTombstoneList{}
+	for _, item := range obj.(*ark_v1.BackupTombstoneList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested backupTombstones.
+func (c *FakeBackupTombstones) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(backuptombstonesResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a backupTombstone and creates it.  Returns the server's representation of the backupTombstone, and an error, if there is any.
+func (c *FakeBackupTombstones) Create(backupTombstone *ark_v1.BackupTombstone) (result *ark_v1.BackupTombstone, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(backuptombstonesResource, c.ns, backupTombstone), &ark_v1.BackupTombstone{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*ark_v1.BackupTombstone), err
+}
+
+// Update takes the representation of a backupTombstone and updates it. Returns the server's representation of the backupTombstone, and an error, if there is any.
+func (c *FakeBackupTombstones) Update(backupTombstone *ark_v1.BackupTombstone) (result *ark_v1.BackupTombstone, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(backuptombstonesResource, c.ns, backupTombstone), &ark_v1.BackupTombstone{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*ark_v1.BackupTombstone), err
+}
+
+// Delete takes name of the backupTombstone and deletes it. Returns an error if one occurs.
+func (c *FakeBackupTombstones) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(backuptombstonesResource, c.ns, name), &ark_v1.BackupTombstone{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeBackupTombstones) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(backuptombstonesResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &ark_v1.BackupTombstoneList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched backupTombstone.
+func (c *FakeBackupTombstones) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *ark_v1.BackupTombstone, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(backuptombstonesResource, c.ns, name, data, subresources...), &ark_v1.BackupTombstone{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*ark_v1.BackupTombstone), err
+}
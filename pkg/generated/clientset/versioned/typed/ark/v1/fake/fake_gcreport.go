@@ -0,0 +1,125 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fake
+
+import (
+	ark_v1 "github.com/heptio/ark/pkg/apis/ark/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeGCReports implements GCReportInterface
+type FakeGCReports struct {
+	Fake *FakeArkV1
+	ns   string
+}
+
+var gcreportsResource = schema.GroupVersionResource{Group: "ark.heptio.com", Version: "v1", Resource: "gcreports"}
+
+var gcreportsKind = schema.GroupVersionKind{Group: "ark.heptio.com", Version: "v1", Kind: "GCReport"}
+
+// Get takes name of the gCReport, and returns the corresponding gCReport object, and an error if there is any.
+func (c *FakeGCReports) Get(name string, options v1.GetOptions) (result *ark_v1.GCReport, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(gcreportsResource, c.ns, name), &ark_v1.GCReport{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*ark_v1.GCReport), err
+}
+
+// List takes label and field selectors, and returns the list of GCReports that match those selectors.
+func (c *FakeGCReports) List(opts v1.ListOptions) (result *ark_v1.GCReportList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(gcreportsResource, gcreportsKind, c.ns, opts), &ark_v1.GCReportList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &ark_v1.GCReportList{}
+	for _, item := range obj.(*ark_v1.GCReportList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested gCReports.
+func (c *FakeGCReports) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(gcreportsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a gCReport and creates it.  Returns the server's representation of the gCReport, and an error, if there is any.
+func (c *FakeGCReports) Create(gCReport *ark_v1.GCReport) (result *ark_v1.GCReport, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(gcreportsResource, c.ns, gCReport), &ark_v1.GCReport{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*ark_v1.GCReport), err
+}
+
+// Update takes the representation of a gCReport and updates it. Returns the server's representation of the gCReport, and an error, if there is any.
+func (c *FakeGCReports) Update(gCReport *ark_v1.GCReport) (result *ark_v1.GCReport, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(gcreportsResource, c.ns, gCReport), &ark_v1.GCReport{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*ark_v1.GCReport), err
+}
+
+// Delete takes name of the gCReport and deletes it. Returns an error if one occurs.
+func (c *FakeGCReports) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(gcreportsResource, c.ns, name), &ark_v1.GCReport{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeGCReports) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(gcreportsResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &ark_v1.GCReportList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched gCReport.
+func (c *FakeGCReports) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *ark_v1.GCReport, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(gcreportsResource, c.ns, name, data, subresources...), &ark_v1.GCReport{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*ark_v1.GCReport), err
+}
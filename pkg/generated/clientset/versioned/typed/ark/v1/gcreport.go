@@ -0,0 +1,154 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1
+
+import (
+	v1 "github.com/heptio/ark/pkg/apis/ark/v1"
+	scheme "github.com/heptio/ark/pkg/generated/clientset/versioned/scheme"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// GCReportsGetter has a method to return a GCReportInterface.
+// A group's client should implement this interface.
+type GCReportsGetter interface {
+	GCReports(namespace string) GCReportInterface
+}
+
+// GCReportInterface has methods to work with GCReport resources.
+type GCReportInterface interface {
+	Create(*v1.GCReport) (*v1.GCReport, error)
+	Update(*v1.GCReport) (*v1.GCReport, error)
+	Delete(name string, options *meta_v1.DeleteOptions) error
+	DeleteCollection(options *meta_v1.DeleteOptions, listOptions meta_v1.ListOptions) error
+	Get(name string, options meta_v1.GetOptions) (*v1.GCReport, error)
+	List(opts meta_v1.ListOptions) (*v1.GCReportList, error)
+	Watch(opts meta_v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.GCReport, err error)
+	GCReportExpansion
+}
+
+// gCReports implements GCReportInterface
+type gCReports struct {
+	client rest.Interface
+	ns     string
+}
+
+// newGCReports returns a GCReports
+func newGCReports(c *ArkV1Client, namespace string) *gCReports {
+	return &gCReports{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the gCReport, and returns the corresponding gCReport object, and an error if there is any.
+func (c *gCReports) Get(name string, options meta_v1.GetOptions) (result *v1.GCReport, err error) {
+	result = &v1.GCReport{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("gcreports").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of GCReports that match those selectors.
+func (c *gCReports) List(opts meta_v1.ListOptions) (result *v1.GCReportList, err error) {
+	result = &v1.GCReportList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("gcreports").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested gCReports.
+func (c *gCReports) Watch(opts meta_v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("gcreports").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
+
+// Create takes the representation of a gCReport and creates it.  Returns the server's representation of the gCReport, and an error, if there is any.
+func (c *gCReports) Create(gCReport *v1.GCReport) (result *v1.GCReport, err error) {
+	result = &v1.GCReport{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("gcreports").
+		Body(gCReport).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a gCReport and updates it. Returns the server's representation of the gCReport, and an error, if there is any.
+func (c *gCReports) Update(gCReport *v1.GCReport) (result *v1.GCReport, err error) {
+	result = &v1.GCReport{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("gcreports").
+		Name(gCReport.Name).
+		Body(gCReport).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the gCReport and deletes it. Returns an error if one occurs.
+func (c *gCReports) Delete(name string, options *meta_v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("gcreports").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *gCReports) DeleteCollection(options *meta_v1.DeleteOptions, listOptions meta_v1.ListOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("gcreports").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched gCReport.
+func (c *gCReports) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.GCReport, err error) {
+	result = &v1.GCReport{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("gcreports").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -17,12 +17,18 @@ package v1
 
 type BackupExpansion interface{}
 
+type BackupTombstoneExpansion interface{}
+
 type ConfigExpansion interface{}
 
 type DeleteBackupRequestExpansion interface{}
 
 type DownloadRequestExpansion interface{}
 
+type GCReportExpansion interface{}
+
 type RestoreExpansion interface{}
 
 type ScheduleExpansion interface{}
+
+type ServerStatusRequestExpansion interface{}
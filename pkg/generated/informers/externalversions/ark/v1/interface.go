@@ -26,16 +26,22 @@ import (
 type Interface interface {
 	// Backups returns a BackupInformer.
 	Backups() BackupInformer
+	// BackupTombstones returns a BackupTombstoneInformer.
+	BackupTombstones() BackupTombstoneInformer
 	// Configs returns a ConfigInformer.
 	Configs() ConfigInformer
 	// DeleteBackupRequests returns a DeleteBackupRequestInformer.
 	DeleteBackupRequests() DeleteBackupRequestInformer
 	// DownloadRequests returns a DownloadRequestInformer.
 	DownloadRequests() DownloadRequestInformer
+	// GCReports returns a GCReportInformer.
+	GCReports() GCReportInformer
 	// Restores returns a RestoreInformer.
 	Restores() RestoreInformer
 	// Schedules returns a ScheduleInformer.
 	Schedules() ScheduleInformer
+	// ServerStatusRequests returns a ServerStatusRequestInformer.
+	ServerStatusRequests() ServerStatusRequestInformer
 }
 
 type version struct {
@@ -54,6 +60,11 @@ func (v *version) Backups() BackupInformer {
 	return &backupInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// BackupTombstones returns a BackupTombstoneInformer.
+func (v *version) BackupTombstones() BackupTombstoneInformer {
+	return &backupTombstoneInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Configs returns a ConfigInformer.
 func (v *version) Configs() ConfigInformer {
 	return &configInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -69,6 +80,11 @@ func (v *version) DownloadRequests() DownloadRequestInformer {
 	return &downloadRequestInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// GCReports returns a GCReportInformer.
+func (v *version) GCReports() GCReportInformer {
+	return &gCReportInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Restores returns a RestoreInformer.
 func (v *version) Restores() RestoreInformer {
 	return &restoreInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -78,3 +94,8 @@ func (v *version) Restores() RestoreInformer {
 func (v *version) Schedules() ScheduleInformer {
 	return &scheduleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// ServerStatusRequests returns a ServerStatusRequestInformer.
+func (v *version) ServerStatusRequests() ServerStatusRequestInformer {
+	return &serverStatusRequestInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file was automatically generated by lister-gen
+
+package v1
+
+import (
+	v1 "github.com/heptio/ark/pkg/apis/ark/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// BackupTombstoneLister helps list BackupTombstones.
+type BackupTombstoneLister interface {
+	// List lists all BackupTombstones in the indexer.
+	List(selector labels.Selector) (ret []*v1.BackupTombstone, err error)
+	// BackupTombstones returns an object that can list and get BackupTombstones.
+	BackupTombstones(namespace string) BackupTombstoneNamespaceLister
+	BackupTombstoneListerExpansion
+}
+
+// backupTombstoneLister implements the BackupTombstoneLister interface.
+type backupTombstoneLister struct {
+	indexer cache.Indexer
+}
+
+// NewBackupTombstoneLister returns a new BackupTombstoneLister.
+func NewBackupTombstoneLister(indexer cache.Indexer) BackupTombstoneLister {
+	return &backupTombstoneLister{indexer: indexer}
+}
+
+// List lists all BackupTombstones in the indexer.
+func (s *backupTombstoneLister) List(selector labels.Selector) (ret []*v1.BackupTombstone, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.BackupTombstone))
+	})
+	return ret, err
+}
+
+// BackupTombstones returns an object that can list and get BackupTombstones.
+func (s *backupTombstoneLister) BackupTombstones(namespace string) BackupTombstoneNamespaceLister {
+	return backupTombstoneNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// BackupTombstoneNamespaceLister helps list and get BackupTombstones.
+type BackupTombstoneNamespaceLister interface {
+	// List lists all BackupTombstones in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1.BackupTombstone, err error)
+	// Get retrieves the BackupTombstone from the indexer for a given namespace and name.
+	Get(name string) (*v1.BackupTombstone, error)
+	BackupTombstoneNamespaceListerExpansion
+}
+
+// backupTombstoneNamespaceLister implements the BackupTombstoneNamespaceLister
+// interface.
+type backupTombstoneNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all BackupTombstones in the indexer for a given namespace.
+func (s backupTombstoneNamespaceLister) List(selector labels.Selector) (ret []*v1.BackupTombstone, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.BackupTombstone))
+	})
+	return ret, err
+}
+
+// Get retrieves the BackupTombstone from the indexer for a given namespace and name.
+func (s backupTombstoneNamespaceLister) Get(name string) (*v1.BackupTombstone, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("backupTombstone"), name)
+	}
+	return obj.(*v1.BackupTombstone), nil
+}
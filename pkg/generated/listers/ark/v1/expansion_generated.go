@@ -26,6 +26,14 @@ type BackupListerExpansion interface{}
 // BackupNamespaceLister.
 type BackupNamespaceListerExpansion interface{}
 
+// BackupTombstoneListerExpansion allows custom methods to be added to
+// BackupTombstoneLister.
+type BackupTombstoneListerExpansion interface{}
+
+// BackupTombstoneNamespaceListerExpansion allows custom methods to be added to
+// BackupTombstoneNamespaceLister.
+type BackupTombstoneNamespaceListerExpansion interface{}
+
 // ConfigListerExpansion allows custom methods to be added to
 // ConfigLister.
 type ConfigListerExpansion interface{}
@@ -50,6 +58,14 @@ type DownloadRequestListerExpansion interface{}
 // DownloadRequestNamespaceLister.
 type DownloadRequestNamespaceListerExpansion interface{}
 
+// GCReportListerExpansion allows custom methods to be added to
+// GCReportLister.
+type GCReportListerExpansion interface{}
+
+// GCReportNamespaceListerExpansion allows custom methods to be added to
+// GCReportNamespaceLister.
+type GCReportNamespaceListerExpansion interface{}
+
 // RestoreListerExpansion allows custom methods to be added to
 // RestoreLister.
 type RestoreListerExpansion interface{}
@@ -65,3 +81,11 @@ type ScheduleListerExpansion interface{}
 // ScheduleNamespaceListerExpansion allows custom methods to be added to
 // ScheduleNamespaceLister.
 type ScheduleNamespaceListerExpansion interface{}
+
+// ServerStatusRequestListerExpansion allows custom methods to be added to
+// ServerStatusRequestLister.
+type ServerStatusRequestListerExpansion interface{}
+
+// ServerStatusRequestNamespaceListerExpansion allows custom methods to be added to
+// ServerStatusRequestNamespaceLister.
+type ServerStatusRequestNamespaceListerExpansion interface{}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file was automatically generated by lister-gen
+
+package v1
+
+import (
+	v1 "github.com/heptio/ark/pkg/apis/ark/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// GCReportLister helps list GCReports.
+type GCReportLister interface {
+	// List lists all GCReports in the indexer.
+	List(selector labels.Selector) (ret []*v1.GCReport, err error)
+	// GCReports returns an object that can list and get GCReports.
+	GCReports(namespace string) GCReportNamespaceLister
+	GCReportListerExpansion
+}
+
+// gCReportLister implements the GCReportLister interface.
+type gCReportLister struct {
+	indexer cache.Indexer
+}
+
+// NewGCReportLister returns a new GCReportLister.
+func NewGCReportLister(indexer cache.Indexer) GCReportLister {
+	return &gCReportLister{indexer: indexer}
+}
+
+// List lists all GCReports in the indexer.
+func (s *gCReportLister) List(selector labels.Selector) (ret []*v1.GCReport, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.GCReport))
+	})
+	return ret, err
+}
+
+// GCReports returns an object that can list and get GCReports.
+func (s *gCReportLister) GCReports(namespace string) GCReportNamespaceLister {
+	return gCReportNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// GCReportNamespaceLister helps list and get GCReports.
+type GCReportNamespaceLister interface {
+	// List lists all GCReports in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1.GCReport, err error)
+	// Get retrieves the GCReport from the indexer for a given namespace and name.
+	Get(name string) (*v1.GCReport, error)
+	GCReportNamespaceListerExpansion
+}
+
+// gCReportNamespaceLister implements the GCReportNamespaceLister
+// interface.
+type gCReportNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all GCReports in the indexer for a given namespace.
+func (s gCReportNamespaceLister) List(selector labels.Selector) (ret []*v1.GCReport, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.GCReport))
+	})
+	return ret, err
+}
+
+// Get retrieves the GCReport from the indexer for a given namespace and name.
+func (s gCReportNamespaceLister) Get(name string) (*v1.GCReport, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("gCReport"), name)
+	}
+	return obj.(*v1.GCReport), nil
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// ServerMetrics contains Prometheus metrics for the Ark server.
+type ServerMetrics struct {
+	metrics map[string]prometheus.Collector
+}
+
+const (
+	metricNamespace = "ark"
+
+	controllerDroppedItemsTotal   = "controller_dropped_items_total"
+	controllerLastResyncTimestamp = "controller_last_resync_timestamp_seconds"
+	controllerResyncDuration      = "controller_resync_duration_seconds"
+	controllerResyncItemsEnqueued = "controller_resync_items_enqueued"
+	backupsRunning                = "backups_running"
+	backupDuration                = "backup_duration_seconds"
+	backupStageDuration           = "backup_stage_duration_seconds"
+	backupDeletionDuration        = "backup_deletion_duration_seconds"
+	workqueueDepth                = "workqueue_depth"
+	workqueueAddsTotal            = "workqueue_adds_total"
+	workqueueQueueLatency         = "workqueue_queue_latency_microseconds"
+	workqueueWorkDuration         = "workqueue_work_duration_microseconds"
+	workqueueRetriesTotal         = "workqueue_retries_total"
+
+	controllerLabel = "controller"
+	queueLabel      = "name"
+	stageLabel      = "stage"
+
+	// BackupStageItemCollection, BackupStageVolumeSnapshots and BackupStageUpload are the
+	// stages of running a backup whose durations are recorded.
+	BackupStageItemCollection  = "item_collection"
+	BackupStageVolumeSnapshots = "volume_snapshots"
+	BackupStageUpload          = "upload"
+)
+
+// backupDurationBuckets are the buckets, in seconds, of the backup duration histograms.
+var backupDurationBuckets = []float64{10, 30, 60, 300, 600, 1800, 3600, 7200, 14400, 28800}
+
+// NewServerMetrics returns new ServerMetrics
+func NewServerMetrics() *ServerMetrics {
+	return &ServerMetrics{
+		metrics: map[string]prometheus.Collector{
+			controllerDroppedItemsTotal: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: metricNamespace,
+					Name:      controllerDroppedItemsTotal,
+					Help:      "Total number of queue items dropped by a controller after exceeding the maximum number of retries",
+				},
+				[]string{controllerLabel},
+			),
+			controllerLastResyncTimestamp: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: metricNamespace,
+					Name:      controllerLastResyncTimestamp,
+					Help:      "Time a controller's last periodic resync finished, in seconds since the Unix epoch",
+				},
+				[]string{controllerLabel},
+			),
+			controllerResyncDuration: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: metricNamespace,
+					Name:      controllerResyncDuration,
+					Help:      "Time a controller's last periodic resync took, in seconds",
+				},
+				[]string{controllerLabel},
+			),
+			controllerResyncItemsEnqueued: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: metricNamespace,
+					Name:      controllerResyncItemsEnqueued,
+					Help:      "Number of items a controller's last periodic resync enqueued",
+				},
+				[]string{controllerLabel},
+			),
+			backupsRunning: prometheus.NewGauge(
+				prometheus.GaugeOpts{
+					Namespace: metricNamespace,
+					Name:      backupsRunning,
+					Help:      "Number of backups currently running",
+				},
+			),
+			backupDuration: prometheus.NewHistogram(
+				prometheus.HistogramOpts{
+					Namespace: metricNamespace,
+					Name:      backupDuration,
+					Help:      "Time from a backup starting to run to it finishing, in seconds",
+					Buckets:   backupDurationBuckets,
+				},
+			),
+			backupStageDuration: prometheus.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Namespace: metricNamespace,
+					Name:      backupStageDuration,
+					Help:      "Time each stage of running a backup took, in seconds",
+					Buckets:   backupDurationBuckets,
+				},
+				[]string{stageLabel},
+			),
+			backupDeletionDuration: prometheus.NewHistogram(
+				prometheus.HistogramOpts{
+					Namespace: metricNamespace,
+					Name:      backupDeletionDuration,
+					Help:      "Time from a DeleteBackupRequest being created to it being processed, in seconds",
+					Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600},
+				},
+			),
+			workqueueDepth: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: metricNamespace,
+					Name:      workqueueDepth,
+					Help:      "Current number of items in a controller's work queue",
+				},
+				[]string{queueLabel},
+			),
+			workqueueAddsTotal: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: metricNamespace,
+					Name:      workqueueAddsTotal,
+					Help:      "Total number of items added to a controller's work queue",
+				},
+				[]string{queueLabel},
+			),
+			workqueueQueueLatency: prometheus.NewSummaryVec(
+				prometheus.SummaryOpts{
+					Namespace: metricNamespace,
+					Name:      workqueueQueueLatency,
+					Help:      "How long items stay in a controller's work queue before being processed, in microseconds",
+				},
+				[]string{queueLabel},
+			),
+			workqueueWorkDuration: prometheus.NewSummaryVec(
+				prometheus.SummaryOpts{
+					Namespace: metricNamespace,
+					Name:      workqueueWorkDuration,
+					Help:      "How long processing an item from a controller's work queue takes, in microseconds",
+				},
+				[]string{queueLabel},
+			),
+			workqueueRetriesTotal: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: metricNamespace,
+					Name:      workqueueRetriesTotal,
+					Help:      "Total number of items a controller's work queue has retried after rate limiting them",
+				},
+				[]string{queueLabel},
+			),
+		},
+	}
+}
+
+// RegisterAllMetrics registers all prometheus metrics.
+func (m *ServerMetrics) RegisterAllMetrics() {
+	for _, pm := range m.metrics {
+		prometheus.MustRegister(pm)
+	}
+}
+
+// RegisterControllerDroppedItem records that a controller dropped an item from its queue.
+func (m *ServerMetrics) RegisterControllerDroppedItem(controller string) {
+	if c, ok := m.metrics[controllerDroppedItemsTotal].(*prometheus.CounterVec); ok {
+		c.WithLabelValues(controller).Inc()
+	}
+}
+
+// RegisterControllerResync records that a controller's periodic resync finished at the given
+// time, and how long it took.
+func (m *ServerMetrics) RegisterControllerResync(controller string, finished time.Time, duration time.Duration) {
+	if g, ok := m.metrics[controllerLastResyncTimestamp].(*prometheus.GaugeVec); ok {
+		g.WithLabelValues(controller).Set(float64(finished.Unix()))
+	}
+	if g, ok := m.metrics[controllerResyncDuration].(*prometheus.GaugeVec); ok {
+		g.WithLabelValues(controller).Set(duration.Seconds())
+	}
+}
+
+// RegisterControllerResyncItemsEnqueued records how many items a controller's periodic resync
+// enqueued.
+func (m *ServerMetrics) RegisterControllerResyncItemsEnqueued(controller string, count int) {
+	if g, ok := m.metrics[controllerResyncItemsEnqueued].(*prometheus.GaugeVec); ok {
+		g.WithLabelValues(controller).Set(float64(count))
+	}
+}
+
+// RegisterBackupStarted records that a backup has started running.
+func (m *ServerMetrics) RegisterBackupStarted() {
+	if g, ok := m.metrics[backupsRunning].(prometheus.Gauge); ok {
+		g.Inc()
+	}
+}
+
+// RegisterBackupFinished records that a backup has finished running.
+func (m *ServerMetrics) RegisterBackupFinished() {
+	if g, ok := m.metrics[backupsRunning].(prometheus.Gauge); ok {
+		g.Dec()
+	}
+}
+
+// RegisterBackupDuration records how long a backup took to run.
+func (m *ServerMetrics) RegisterBackupDuration(duration time.Duration) {
+	if h, ok := m.metrics[backupDuration].(prometheus.Histogram); ok {
+		h.Observe(duration.Seconds())
+	}
+}
+
+// RegisterBackupStageDuration records how long a stage of running a backup, one of the
+// BackupStage constants, took.
+func (m *ServerMetrics) RegisterBackupStageDuration(stage string, duration time.Duration) {
+	if h, ok := m.metrics[backupStageDuration].(*prometheus.HistogramVec); ok {
+		h.WithLabelValues(stage).Observe(duration.Seconds())
+	}
+}
+
+// RegisterBackupDeletionDuration records how long a DeleteBackupRequest took to be processed
+// after it was created.
+func (m *ServerMetrics) RegisterBackupDeletionDuration(duration time.Duration) {
+	if h, ok := m.metrics[backupDeletionDuration].(prometheus.Histogram); ok {
+		h.Observe(duration.Seconds())
+	}
+}
+
+// WorkqueueMetricsProvider returns a workqueue.MetricsProvider that records the depth, adds,
+// latency, work duration and retries of each named work queue, labeled by its name. It must
+// be set with workqueue.SetProvider before the queues are created.
+func (m *ServerMetrics) WorkqueueMetricsProvider() workqueue.MetricsProvider {
+	return &workqueueMetricsProvider{metrics: m}
+}
+
+type workqueueMetricsProvider struct {
+	metrics *ServerMetrics
+}
+
+func (p *workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return p.metrics.metrics[workqueueDepth].(*prometheus.GaugeVec).WithLabelValues(name)
+}
+
+func (p *workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return p.metrics.metrics[workqueueAddsTotal].(*prometheus.CounterVec).WithLabelValues(name)
+}
+
+func (p *workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.SummaryMetric {
+	return p.metrics.metrics[workqueueQueueLatency].(*prometheus.SummaryVec).WithLabelValues(name)
+}
+
+func (p *workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.SummaryMetric {
+	return p.metrics.metrics[workqueueWorkDuration].(*prometheus.SummaryVec).WithLabelValues(name)
+}
+
+func (p *workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return p.metrics.metrics[workqueueRetriesTotal].(*prometheus.CounterVec).WithLabelValues(name)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gaugeValue(t *testing.T, m *ServerMetrics, name, controller string) float64 {
+	gauge, err := m.metrics[name].(*prometheus.GaugeVec).GetMetricWithLabelValues(controller)
+	require.NoError(t, err)
+
+	var metric dto.Metric
+	require.NoError(t, gauge.Write(&metric))
+	return metric.GetGauge().GetValue()
+}
+
+func TestRegisterControllerResync(t *testing.T) {
+	m := NewServerMetrics()
+	finished := time.Date(2018, 4, 5, 20, 12, 21, 0, time.UTC)
+
+	m.RegisterControllerResync("gc-controller", finished, 1500*time.Millisecond)
+	m.RegisterControllerResync("snapshot-check", finished.Add(time.Minute), time.Second)
+
+	assert.Equal(t, float64(finished.Unix()), gaugeValue(t, m, controllerLastResyncTimestamp, "gc-controller"))
+	assert.Equal(t, 1.5, gaugeValue(t, m, controllerResyncDuration, "gc-controller"))
+
+	assert.Equal(t, float64(finished.Add(time.Minute).Unix()), gaugeValue(t, m, controllerLastResyncTimestamp, "snapshot-check"))
+	assert.Equal(t, 1.0, gaugeValue(t, m, controllerResyncDuration, "snapshot-check"))
+}
+
+func TestRegisterControllerResyncItemsEnqueued(t *testing.T) {
+	m := NewServerMetrics()
+
+	m.RegisterControllerResyncItemsEnqueued("gc-controller", 1200)
+	m.RegisterControllerResyncItemsEnqueued("gc-controller", 1210)
+
+	assert.Equal(t, 1210.0, gaugeValue(t, m, controllerResyncItemsEnqueued, "gc-controller"))
+}
+
+func TestRegisterBackupStageDuration(t *testing.T) {
+	m := NewServerMetrics()
+
+	m.RegisterBackupStageDuration(BackupStageUpload, 20*time.Second)
+	m.RegisterBackupStageDuration(BackupStageUpload, 40*time.Second)
+	m.RegisterBackupStageDuration(BackupStageVolumeSnapshots, time.Minute)
+
+	histogram, err := m.metrics[backupStageDuration].(*prometheus.HistogramVec).GetMetricWithLabelValues(BackupStageUpload)
+	require.NoError(t, err)
+
+	var metric dto.Metric
+	require.NoError(t, histogram.(prometheus.Histogram).Write(&metric))
+	assert.Equal(t, uint64(2), metric.GetHistogram().GetSampleCount())
+	assert.Equal(t, 60.0, metric.GetHistogram().GetSampleSum())
+}
+
+func TestWorkqueueMetricsProvider(t *testing.T) {
+	m := NewServerMetrics()
+	provider := m.WorkqueueMetricsProvider()
+
+	backupDepth := provider.NewDepthMetric("backup")
+	backupDepth.Inc()
+	backupDepth.Inc()
+	backupDepth.Dec()
+	provider.NewDepthMetric("gc-controller").Inc()
+
+	provider.NewRetriesMetric("backup").Inc()
+
+	assert.Equal(t, 1.0, gaugeValue(t, m, workqueueDepth, "backup"))
+	assert.Equal(t, 1.0, gaugeValue(t, m, workqueueDepth, "gc-controller"))
+
+	counter, err := m.metrics[workqueueRetriesTotal].(*prometheus.CounterVec).GetMetricWithLabelValues("backup")
+	require.NoError(t, err)
+	var metric dto.Metric
+	require.NoError(t, counter.Write(&metric))
+	assert.Equal(t, 1.0, metric.GetCounter().GetValue())
+}
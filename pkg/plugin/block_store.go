@@ -22,6 +22,7 @@ import (
 	"github.com/hashicorp/go-plugin"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 
@@ -76,12 +77,14 @@ func (c *BlockStoreGRPCClient) Init(config map[string]string) error {
 }
 
 // CreateVolumeFromSnapshot creates a new block volume, initialized from the provided snapshot,
-// and with the specified type and IOPS (if using provisioned IOPS).
-func (c *BlockStoreGRPCClient) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64) (string, error) {
+// and with the specified type and IOPS (if using provisioned IOPS). Plugins built before the
+// tags were added don't apply them.
+func (c *BlockStoreGRPCClient) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64, tags map[string]string) (string, error) {
 	req := &proto.CreateVolumeRequest{
 		SnapshotID: snapshotID,
 		VolumeType: volumeType,
 		VolumeAZ:   volumeAZ,
+		Tags:       tags,
 	}
 
 	if iops == nil {
@@ -125,8 +128,9 @@ func (c *BlockStoreGRPCClient) IsVolumeReady(volumeID, volumeAZ string) (bool, e
 }
 
 // CreateSnapshot creates a snapshot of the specified block volume, and applies the provided
-// set of tags to the snapshot.
-func (c *BlockStoreGRPCClient) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, error) {
+// set of tags to the snapshot. It also returns the ID of the earlier snapshot the new one is
+// incremental to, if any.
+func (c *BlockStoreGRPCClient) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, string, error) {
 	req := &proto.CreateSnapshotRequest{
 		VolumeID: volumeID,
 		VolumeAZ: volumeAZ,
@@ -135,10 +139,10 @@ func (c *BlockStoreGRPCClient) CreateSnapshot(volumeID, volumeAZ string, tags ma
 
 	res, err := c.grpcClient.CreateSnapshot(context.Background(), req)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	return res.SnapshotID, nil
+	return res.SnapshotID, res.ParentSnapshotID, nil
 }
 
 // DeleteSnapshot deletes the specified volume snapshot.
@@ -148,6 +152,81 @@ func (c *BlockStoreGRPCClient) DeleteSnapshot(snapshotID string) error {
 	return err
 }
 
+// SnapshotExists returns whether the specified volume snapshot exists.
+func (c *BlockStoreGRPCClient) SnapshotExists(snapshotID string) (bool, error) {
+	res, err := c.grpcClient.SnapshotExists(context.Background(), &proto.SnapshotExistsRequest{SnapshotID: snapshotID})
+	if err != nil {
+		return false, err
+	}
+
+	return res.Exists, nil
+}
+
+// CreateSnapshotGroup snapshots the specified volumes as a consistency group, returning
+// the group's ID, or an empty ID if the block store doesn't support consistency groups,
+// and the volumes' snapshot IDs. Plugins built before the method was added are treated
+// as not supporting consistency groups.
+func (c *BlockStoreGRPCClient) CreateSnapshotGroup(volumes map[string]string, tags map[string]string) (string, map[string]string, error) {
+	req := &proto.CreateSnapshotGroupRequest{
+		Volumes: volumes,
+		Tags:    tags,
+	}
+
+	res, err := c.grpcClient.CreateSnapshotGroup(context.Background(), req)
+	if grpc.Code(err) == codes.Unimplemented {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	return res.GroupID, res.SnapshotIDs, nil
+}
+
+// DeleteSnapshots deletes the specified volume snapshots in bulk, returning false if the
+// block store doesn't support it, as plugins built before the method was added don't.
+func (c *BlockStoreGRPCClient) DeleteSnapshots(snapshotIDs []string) (bool, error) {
+	res, err := c.grpcClient.DeleteSnapshots(context.Background(), &proto.DeleteSnapshotsRequest{SnapshotIDs: snapshotIDs})
+	if grpc.Code(err) == codes.Unimplemented {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return res.Deleted, nil
+}
+
+// ListSnapshotsWithTag returns the value of the tag key on each volume snapshot that has
+// it, returning false if the block store doesn't support it, as plugins built before
+// the method was added don't.
+func (c *BlockStoreGRPCClient) ListSnapshotsWithTag(key string) (map[string]string, bool, error) {
+	res, err := c.grpcClient.ListSnapshotsWithTag(context.Background(), &proto.ListSnapshotsWithTagRequest{Key: key})
+	if grpc.Code(err) == codes.Unimplemented {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return res.Values, true, nil
+}
+
+// ListVolumesWithTag returns the value of the tag key on each block volume that has it,
+// returning false if the block store doesn't support it, as plugins built before the
+// method was added don't.
+func (c *BlockStoreGRPCClient) ListVolumesWithTag(key string) (map[string]string, bool, error) {
+	res, err := c.grpcClient.ListVolumesWithTag(context.Background(), &proto.ListVolumesWithTagRequest{Key: key})
+	if grpc.Code(err) == codes.Unimplemented {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return res.Values, true, nil
+}
+
 func (c *BlockStoreGRPCClient) GetVolumeID(pv runtime.Unstructured) (string, error) {
 	encodedPV, err := json.Marshal(pv.UnstructuredContent())
 	if err != nil {
@@ -220,7 +299,7 @@ func (s *BlockStoreGRPCServer) CreateVolumeFromSnapshot(ctx context.Context, req
 		iops = &req.Iops
 	}
 
-	volumeID, err := s.impl.CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ, iops)
+	volumeID, err := s.impl.CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ, iops, req.Tags)
 	if err != nil {
 		return nil, err
 	}
@@ -258,14 +337,15 @@ func (s *BlockStoreGRPCServer) IsVolumeReady(ctx context.Context, req *proto.IsV
 }
 
 // CreateSnapshot creates a snapshot of the specified block volume, and applies the provided
-// set of tags to the snapshot.
+// set of tags to the snapshot. It also returns the ID of the earlier snapshot the new one is
+// incremental to, if any.
 func (s *BlockStoreGRPCServer) CreateSnapshot(ctx context.Context, req *proto.CreateSnapshotRequest) (*proto.CreateSnapshotResponse, error) {
-	snapshotID, err := s.impl.CreateSnapshot(req.VolumeID, req.VolumeAZ, req.Tags)
+	snapshotID, parentSnapshotID, err := s.impl.CreateSnapshot(req.VolumeID, req.VolumeAZ, req.Tags)
 	if err != nil {
 		return nil, err
 	}
 
-	return &proto.CreateSnapshotResponse{SnapshotID: snapshotID}, nil
+	return &proto.CreateSnapshotResponse{SnapshotID: snapshotID, ParentSnapshotID: parentSnapshotID}, nil
 }
 
 // DeleteSnapshot deletes the specified volume snapshot.
@@ -277,6 +357,62 @@ func (s *BlockStoreGRPCServer) DeleteSnapshot(ctx context.Context, req *proto.De
 	return &proto.Empty{}, nil
 }
 
+// SnapshotExists returns whether the specified volume snapshot exists.
+func (s *BlockStoreGRPCServer) SnapshotExists(ctx context.Context, req *proto.SnapshotExistsRequest) (*proto.SnapshotExistsResponse, error) {
+	exists, err := s.impl.SnapshotExists(req.SnapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.SnapshotExistsResponse{Exists: exists}, nil
+}
+
+// CreateSnapshotGroup snapshots the specified volumes as a consistency group.
+func (s *BlockStoreGRPCServer) CreateSnapshotGroup(ctx context.Context, req *proto.CreateSnapshotGroupRequest) (*proto.CreateSnapshotGroupResponse, error) {
+	groupID, snapshotIDs, err := s.impl.CreateSnapshotGroup(req.Volumes, req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.CreateSnapshotGroupResponse{GroupID: groupID, SnapshotIDs: snapshotIDs}, nil
+}
+
+// DeleteSnapshots deletes the specified volume snapshots in bulk.
+func (s *BlockStoreGRPCServer) DeleteSnapshots(ctx context.Context, req *proto.DeleteSnapshotsRequest) (*proto.DeleteSnapshotsResponse, error) {
+	deleted, err := s.impl.DeleteSnapshots(req.SnapshotIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.DeleteSnapshotsResponse{Deleted: deleted}, nil
+}
+
+// ListSnapshotsWithTag returns the value of the tag key on each volume snapshot that has it.
+func (s *BlockStoreGRPCServer) ListSnapshotsWithTag(ctx context.Context, req *proto.ListSnapshotsWithTagRequest) (*proto.ListSnapshotsWithTagResponse, error) {
+	values, supported, err := s.impl.ListSnapshotsWithTag(req.Key)
+	if err != nil {
+		return nil, err
+	}
+	if !supported {
+		return nil, grpc.Errorf(codes.Unimplemented, "block store doesn't support listing snapshots by tag")
+	}
+
+	return &proto.ListSnapshotsWithTagResponse{Values: values}, nil
+}
+
+// ListVolumesWithTag returns the value of the tag key on each block volume that has it.
+func (s *BlockStoreGRPCServer) ListVolumesWithTag(ctx context.Context, req *proto.ListVolumesWithTagRequest) (*proto.ListVolumesWithTagResponse, error) {
+	values, supported, err := s.impl.ListVolumesWithTag(req.Key)
+	if err != nil {
+		return nil, err
+	}
+	if !supported {
+		return nil, grpc.Errorf(codes.Unimplemented, "block store doesn't support listing volumes by tag")
+	}
+
+	return &proto.ListVolumesWithTagResponse{Values: values}, nil
+}
+
 func (s *BlockStoreGRPCServer) GetVolumeID(ctx context.Context, req *proto.GetVolumeIDRequest) (*proto.GetVolumeIDResponse, error) {
 	var pv unstructured.Unstructured
 
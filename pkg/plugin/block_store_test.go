@@ -0,0 +1,74 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	proto "github.com/heptio/ark/pkg/plugin/generated"
+)
+
+// outdatedBlockStoreClient is the client of a block store plugin built before
+// CreateSnapshotGroup, DeleteSnapshots, ListSnapshotsWithTag and ListVolumesWithTag were added.
+type outdatedBlockStoreClient struct {
+	proto.BlockStoreClient
+}
+
+func (c *outdatedBlockStoreClient) CreateSnapshotGroup(ctx context.Context, in *proto.CreateSnapshotGroupRequest, opts ...grpc.CallOption) (*proto.CreateSnapshotGroupResponse, error) {
+	return nil, grpc.Errorf(codes.Unimplemented, "unknown method CreateSnapshotGroup")
+}
+
+func (c *outdatedBlockStoreClient) DeleteSnapshots(ctx context.Context, in *proto.DeleteSnapshotsRequest, opts ...grpc.CallOption) (*proto.DeleteSnapshotsResponse, error) {
+	return nil, grpc.Errorf(codes.Unimplemented, "unknown method DeleteSnapshots")
+}
+
+func (c *outdatedBlockStoreClient) ListSnapshotsWithTag(ctx context.Context, in *proto.ListSnapshotsWithTagRequest, opts ...grpc.CallOption) (*proto.ListSnapshotsWithTagResponse, error) {
+	return nil, grpc.Errorf(codes.Unimplemented, "unknown method ListSnapshotsWithTag")
+}
+
+func (c *outdatedBlockStoreClient) ListVolumesWithTag(ctx context.Context, in *proto.ListVolumesWithTagRequest, opts ...grpc.CallOption) (*proto.ListVolumesWithTagResponse, error) {
+	return nil, grpc.Errorf(codes.Unimplemented, "unknown method ListVolumesWithTag")
+}
+
+func TestBlockStoreGRPCClientWithOutdatedPlugin(t *testing.T) {
+	client := &BlockStoreGRPCClient{grpcClient: &outdatedBlockStoreClient{}}
+
+	groupID, snapshotIDs, err := client.CreateSnapshotGroup(map[string]string{"vol-1": "", "vol-2": ""}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, groupID)
+	assert.Empty(t, snapshotIDs)
+
+	deleted, err := client.DeleteSnapshots([]string{"snap-1"})
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	values, supported, err := client.ListSnapshotsWithTag("ark.heptio.com/retain-until")
+	require.NoError(t, err)
+	assert.False(t, supported)
+	assert.Empty(t, values)
+
+	values, supported, err = client.ListVolumesWithTag("ark.heptio.com/restored-volume")
+	require.NoError(t, err)
+	assert.False(t, supported)
+	assert.Empty(t, values)
+}
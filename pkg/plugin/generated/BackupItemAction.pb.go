@@ -28,6 +28,16 @@ It has these top-level messages:
 	GetVolumeIDResponse
 	SetVolumeIDRequest
 	SetVolumeIDResponse
+	SnapshotExistsRequest
+	SnapshotExistsResponse
+	CreateSnapshotGroupRequest
+	CreateSnapshotGroupResponse
+	DeleteSnapshotsRequest
+	DeleteSnapshotsResponse
+	ListSnapshotsWithTagRequest
+	ListSnapshotsWithTagResponse
+	ListVolumesWithTagRequest
+	ListVolumesWithTagResponse
 	PutObjectRequest
 	GetObjectRequest
 	Bytes
@@ -38,6 +48,8 @@ It has these top-level messages:
 	DeleteObjectRequest
 	CreateSignedURLRequest
 	CreateSignedURLResponse
+	AvailableBytesRequest
+	AvailableBytesResponse
 	RestoreExecuteRequest
 	RestoreExecuteResponse
 	Empty
@@ -18,10 +18,11 @@ var _ = fmt.Errorf
 var _ = math.Inf
 
 type CreateVolumeRequest struct {
-	SnapshotID string `protobuf:"bytes,1,opt,name=snapshotID" json:"snapshotID,omitempty"`
-	VolumeType string `protobuf:"bytes,2,opt,name=volumeType" json:"volumeType,omitempty"`
-	VolumeAZ   string `protobuf:"bytes,3,opt,name=volumeAZ" json:"volumeAZ,omitempty"`
-	Iops       int64  `protobuf:"varint,4,opt,name=iops" json:"iops,omitempty"`
+	SnapshotID string            `protobuf:"bytes,1,opt,name=snapshotID" json:"snapshotID,omitempty"`
+	VolumeType string            `protobuf:"bytes,2,opt,name=volumeType" json:"volumeType,omitempty"`
+	VolumeAZ   string            `protobuf:"bytes,3,opt,name=volumeAZ" json:"volumeAZ,omitempty"`
+	Iops       int64             `protobuf:"varint,4,opt,name=iops" json:"iops,omitempty"`
+	Tags       map[string]string `protobuf:"bytes,5,rep,name=tags" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 }
 
 func (m *CreateVolumeRequest) Reset()                    { *m = CreateVolumeRequest{} }
@@ -57,6 +58,13 @@ func (m *CreateVolumeRequest) GetIops() int64 {
 	return 0
 }
 
+func (m *CreateVolumeRequest) GetTags() map[string]string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
 type CreateVolumeResponse struct {
 	VolumeID string `protobuf:"bytes,1,opt,name=volumeID" json:"volumeID,omitempty"`
 }
@@ -194,7 +202,8 @@ func (m *CreateSnapshotRequest) GetTags() map[string]string {
 }
 
 type CreateSnapshotResponse struct {
-	SnapshotID string `protobuf:"bytes,1,opt,name=snapshotID" json:"snapshotID,omitempty"`
+	SnapshotID       string `protobuf:"bytes,1,opt,name=snapshotID" json:"snapshotID,omitempty"`
+	ParentSnapshotID string `protobuf:"bytes,2,opt,name=parentSnapshotID" json:"parentSnapshotID,omitempty"`
 }
 
 func (m *CreateSnapshotResponse) Reset()                    { *m = CreateSnapshotResponse{} }
@@ -209,6 +218,13 @@ func (m *CreateSnapshotResponse) GetSnapshotID() string {
 	return ""
 }
 
+func (m *CreateSnapshotResponse) GetParentSnapshotID() string {
+	if m != nil {
+		return m.ParentSnapshotID
+	}
+	return ""
+}
+
 type DeleteSnapshotRequest struct {
 	SnapshotID string `protobuf:"bytes,1,opt,name=snapshotID" json:"snapshotID,omitempty"`
 }
@@ -297,6 +313,182 @@ func (m *SetVolumeIDResponse) GetPersistentVolume() []byte {
 	return nil
 }
 
+type SnapshotExistsRequest struct {
+	SnapshotID string `protobuf:"bytes,1,opt,name=snapshotID" json:"snapshotID,omitempty"`
+}
+
+func (m *SnapshotExistsRequest) Reset()                    { *m = SnapshotExistsRequest{} }
+func (m *SnapshotExistsRequest) String() string            { return proto.CompactTextString(m) }
+func (*SnapshotExistsRequest) ProtoMessage()               {}
+func (*SnapshotExistsRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{13} }
+
+func (m *SnapshotExistsRequest) GetSnapshotID() string {
+	if m != nil {
+		return m.SnapshotID
+	}
+	return ""
+}
+
+type SnapshotExistsResponse struct {
+	Exists bool `protobuf:"varint,1,opt,name=exists" json:"exists,omitempty"`
+}
+
+func (m *SnapshotExistsResponse) Reset()                    { *m = SnapshotExistsResponse{} }
+func (m *SnapshotExistsResponse) String() string            { return proto.CompactTextString(m) }
+func (*SnapshotExistsResponse) ProtoMessage()               {}
+func (*SnapshotExistsResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{14} }
+
+func (m *SnapshotExistsResponse) GetExists() bool {
+	if m != nil {
+		return m.Exists
+	}
+	return false
+}
+
+type CreateSnapshotGroupRequest struct {
+	Volumes map[string]string `protobuf:"bytes,1,rep,name=volumes" json:"volumes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Tags    map[string]string `protobuf:"bytes,2,rep,name=tags" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *CreateSnapshotGroupRequest) Reset()                    { *m = CreateSnapshotGroupRequest{} }
+func (m *CreateSnapshotGroupRequest) String() string            { return proto.CompactTextString(m) }
+func (*CreateSnapshotGroupRequest) ProtoMessage()               {}
+func (*CreateSnapshotGroupRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{15} }
+
+func (m *CreateSnapshotGroupRequest) GetVolumes() map[string]string {
+	if m != nil {
+		return m.Volumes
+	}
+	return nil
+}
+
+func (m *CreateSnapshotGroupRequest) GetTags() map[string]string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+type CreateSnapshotGroupResponse struct {
+	GroupID     string            `protobuf:"bytes,1,opt,name=groupID" json:"groupID,omitempty"`
+	SnapshotIDs map[string]string `protobuf:"bytes,2,rep,name=snapshotIDs" json:"snapshotIDs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *CreateSnapshotGroupResponse) Reset()                    { *m = CreateSnapshotGroupResponse{} }
+func (m *CreateSnapshotGroupResponse) String() string            { return proto.CompactTextString(m) }
+func (*CreateSnapshotGroupResponse) ProtoMessage()               {}
+func (*CreateSnapshotGroupResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{16} }
+
+func (m *CreateSnapshotGroupResponse) GetGroupID() string {
+	if m != nil {
+		return m.GroupID
+	}
+	return ""
+}
+
+func (m *CreateSnapshotGroupResponse) GetSnapshotIDs() map[string]string {
+	if m != nil {
+		return m.SnapshotIDs
+	}
+	return nil
+}
+
+type DeleteSnapshotsRequest struct {
+	SnapshotIDs []string `protobuf:"bytes,1,rep,name=snapshotIDs" json:"snapshotIDs,omitempty"`
+}
+
+func (m *DeleteSnapshotsRequest) Reset()                    { *m = DeleteSnapshotsRequest{} }
+func (m *DeleteSnapshotsRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteSnapshotsRequest) ProtoMessage()               {}
+func (*DeleteSnapshotsRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{17} }
+
+func (m *DeleteSnapshotsRequest) GetSnapshotIDs() []string {
+	if m != nil {
+		return m.SnapshotIDs
+	}
+	return nil
+}
+
+type DeleteSnapshotsResponse struct {
+	Deleted bool `protobuf:"varint,1,opt,name=deleted" json:"deleted,omitempty"`
+}
+
+func (m *DeleteSnapshotsResponse) Reset()                    { *m = DeleteSnapshotsResponse{} }
+func (m *DeleteSnapshotsResponse) String() string            { return proto.CompactTextString(m) }
+func (*DeleteSnapshotsResponse) ProtoMessage()               {}
+func (*DeleteSnapshotsResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{18} }
+
+func (m *DeleteSnapshotsResponse) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
+}
+
+type ListSnapshotsWithTagRequest struct {
+	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+}
+
+func (m *ListSnapshotsWithTagRequest) Reset()                    { *m = ListSnapshotsWithTagRequest{} }
+func (m *ListSnapshotsWithTagRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListSnapshotsWithTagRequest) ProtoMessage()               {}
+func (*ListSnapshotsWithTagRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{19} }
+
+func (m *ListSnapshotsWithTagRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type ListSnapshotsWithTagResponse struct {
+	Values map[string]string `protobuf:"bytes,1,rep,name=values" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *ListSnapshotsWithTagResponse) Reset()                    { *m = ListSnapshotsWithTagResponse{} }
+func (m *ListSnapshotsWithTagResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListSnapshotsWithTagResponse) ProtoMessage()               {}
+func (*ListSnapshotsWithTagResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{20} }
+
+func (m *ListSnapshotsWithTagResponse) GetValues() map[string]string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type ListVolumesWithTagRequest struct {
+	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+}
+
+func (m *ListVolumesWithTagRequest) Reset()                    { *m = ListVolumesWithTagRequest{} }
+func (m *ListVolumesWithTagRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListVolumesWithTagRequest) ProtoMessage()               {}
+func (*ListVolumesWithTagRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{21} }
+
+func (m *ListVolumesWithTagRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type ListVolumesWithTagResponse struct {
+	Values map[string]string `protobuf:"bytes,1,rep,name=values" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *ListVolumesWithTagResponse) Reset()                    { *m = ListVolumesWithTagResponse{} }
+func (m *ListVolumesWithTagResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListVolumesWithTagResponse) ProtoMessage()               {}
+func (*ListVolumesWithTagResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{22} }
+
+func (m *ListVolumesWithTagResponse) GetValues() map[string]string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*CreateVolumeRequest)(nil), "generated.CreateVolumeRequest")
 	proto.RegisterType((*CreateVolumeResponse)(nil), "generated.CreateVolumeResponse")
@@ -311,6 +503,16 @@ func init() {
 	proto.RegisterType((*GetVolumeIDResponse)(nil), "generated.GetVolumeIDResponse")
 	proto.RegisterType((*SetVolumeIDRequest)(nil), "generated.SetVolumeIDRequest")
 	proto.RegisterType((*SetVolumeIDResponse)(nil), "generated.SetVolumeIDResponse")
+	proto.RegisterType((*SnapshotExistsRequest)(nil), "generated.SnapshotExistsRequest")
+	proto.RegisterType((*SnapshotExistsResponse)(nil), "generated.SnapshotExistsResponse")
+	proto.RegisterType((*CreateSnapshotGroupRequest)(nil), "generated.CreateSnapshotGroupRequest")
+	proto.RegisterType((*CreateSnapshotGroupResponse)(nil), "generated.CreateSnapshotGroupResponse")
+	proto.RegisterType((*DeleteSnapshotsRequest)(nil), "generated.DeleteSnapshotsRequest")
+	proto.RegisterType((*DeleteSnapshotsResponse)(nil), "generated.DeleteSnapshotsResponse")
+	proto.RegisterType((*ListSnapshotsWithTagRequest)(nil), "generated.ListSnapshotsWithTagRequest")
+	proto.RegisterType((*ListSnapshotsWithTagResponse)(nil), "generated.ListSnapshotsWithTagResponse")
+	proto.RegisterType((*ListVolumesWithTagRequest)(nil), "generated.ListVolumesWithTagRequest")
+	proto.RegisterType((*ListVolumesWithTagResponse)(nil), "generated.ListVolumesWithTagResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -332,6 +534,11 @@ type BlockStoreClient interface {
 	DeleteSnapshot(ctx context.Context, in *DeleteSnapshotRequest, opts ...grpc.CallOption) (*Empty, error)
 	GetVolumeID(ctx context.Context, in *GetVolumeIDRequest, opts ...grpc.CallOption) (*GetVolumeIDResponse, error)
 	SetVolumeID(ctx context.Context, in *SetVolumeIDRequest, opts ...grpc.CallOption) (*SetVolumeIDResponse, error)
+	SnapshotExists(ctx context.Context, in *SnapshotExistsRequest, opts ...grpc.CallOption) (*SnapshotExistsResponse, error)
+	CreateSnapshotGroup(ctx context.Context, in *CreateSnapshotGroupRequest, opts ...grpc.CallOption) (*CreateSnapshotGroupResponse, error)
+	DeleteSnapshots(ctx context.Context, in *DeleteSnapshotsRequest, opts ...grpc.CallOption) (*DeleteSnapshotsResponse, error)
+	ListSnapshotsWithTag(ctx context.Context, in *ListSnapshotsWithTagRequest, opts ...grpc.CallOption) (*ListSnapshotsWithTagResponse, error)
+	ListVolumesWithTag(ctx context.Context, in *ListVolumesWithTagRequest, opts ...grpc.CallOption) (*ListVolumesWithTagResponse, error)
 }
 
 type blockStoreClient struct {
@@ -414,6 +621,51 @@ func (c *blockStoreClient) SetVolumeID(ctx context.Context, in *SetVolumeIDReque
 	return out, nil
 }
 
+func (c *blockStoreClient) SnapshotExists(ctx context.Context, in *SnapshotExistsRequest, opts ...grpc.CallOption) (*SnapshotExistsResponse, error) {
+	out := new(SnapshotExistsResponse)
+	err := grpc.Invoke(ctx, "/generated.BlockStore/SnapshotExists", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blockStoreClient) CreateSnapshotGroup(ctx context.Context, in *CreateSnapshotGroupRequest, opts ...grpc.CallOption) (*CreateSnapshotGroupResponse, error) {
+	out := new(CreateSnapshotGroupResponse)
+	err := grpc.Invoke(ctx, "/generated.BlockStore/CreateSnapshotGroup", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blockStoreClient) DeleteSnapshots(ctx context.Context, in *DeleteSnapshotsRequest, opts ...grpc.CallOption) (*DeleteSnapshotsResponse, error) {
+	out := new(DeleteSnapshotsResponse)
+	err := grpc.Invoke(ctx, "/generated.BlockStore/DeleteSnapshots", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blockStoreClient) ListSnapshotsWithTag(ctx context.Context, in *ListSnapshotsWithTagRequest, opts ...grpc.CallOption) (*ListSnapshotsWithTagResponse, error) {
+	out := new(ListSnapshotsWithTagResponse)
+	err := grpc.Invoke(ctx, "/generated.BlockStore/ListSnapshotsWithTag", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blockStoreClient) ListVolumesWithTag(ctx context.Context, in *ListVolumesWithTagRequest, opts ...grpc.CallOption) (*ListVolumesWithTagResponse, error) {
+	out := new(ListVolumesWithTagResponse)
+	err := grpc.Invoke(ctx, "/generated.BlockStore/ListVolumesWithTag", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for BlockStore service
 
 type BlockStoreServer interface {
@@ -425,6 +677,11 @@ type BlockStoreServer interface {
 	DeleteSnapshot(context.Context, *DeleteSnapshotRequest) (*Empty, error)
 	GetVolumeID(context.Context, *GetVolumeIDRequest) (*GetVolumeIDResponse, error)
 	SetVolumeID(context.Context, *SetVolumeIDRequest) (*SetVolumeIDResponse, error)
+	SnapshotExists(context.Context, *SnapshotExistsRequest) (*SnapshotExistsResponse, error)
+	CreateSnapshotGroup(context.Context, *CreateSnapshotGroupRequest) (*CreateSnapshotGroupResponse, error)
+	DeleteSnapshots(context.Context, *DeleteSnapshotsRequest) (*DeleteSnapshotsResponse, error)
+	ListSnapshotsWithTag(context.Context, *ListSnapshotsWithTagRequest) (*ListSnapshotsWithTagResponse, error)
+	ListVolumesWithTag(context.Context, *ListVolumesWithTagRequest) (*ListVolumesWithTagResponse, error)
 }
 
 func RegisterBlockStoreServer(s *grpc.Server, srv BlockStoreServer) {
@@ -575,6 +832,96 @@ func _BlockStore_SetVolumeID_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BlockStore_SnapshotExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotExistsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlockStoreServer).SnapshotExists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.BlockStore/SnapshotExists",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlockStoreServer).SnapshotExists(ctx, req.(*SnapshotExistsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlockStore_CreateSnapshotGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSnapshotGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlockStoreServer).CreateSnapshotGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.BlockStore/CreateSnapshotGroup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlockStoreServer).CreateSnapshotGroup(ctx, req.(*CreateSnapshotGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlockStore_DeleteSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSnapshotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlockStoreServer).DeleteSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.BlockStore/DeleteSnapshots",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlockStoreServer).DeleteSnapshots(ctx, req.(*DeleteSnapshotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlockStore_ListSnapshotsWithTag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSnapshotsWithTagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlockStoreServer).ListSnapshotsWithTag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.BlockStore/ListSnapshotsWithTag",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlockStoreServer).ListSnapshotsWithTag(ctx, req.(*ListSnapshotsWithTagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlockStore_ListVolumesWithTag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVolumesWithTagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlockStoreServer).ListVolumesWithTag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.BlockStore/ListVolumesWithTag",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlockStoreServer).ListVolumesWithTag(ctx, req.(*ListVolumesWithTagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _BlockStore_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "generated.BlockStore",
 	HandlerType: (*BlockStoreServer)(nil),
@@ -611,6 +958,26 @@ var _BlockStore_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SetVolumeID",
 			Handler:    _BlockStore_SetVolumeID_Handler,
 		},
+		{
+			MethodName: "SnapshotExists",
+			Handler:    _BlockStore_SnapshotExists_Handler,
+		},
+		{
+			MethodName: "CreateSnapshotGroup",
+			Handler:    _BlockStore_CreateSnapshotGroup_Handler,
+		},
+		{
+			MethodName: "DeleteSnapshots",
+			Handler:    _BlockStore_DeleteSnapshots_Handler,
+		},
+		{
+			MethodName: "ListSnapshotsWithTag",
+			Handler:    _BlockStore_ListSnapshotsWithTag_Handler,
+		},
+		{
+			MethodName: "ListVolumesWithTag",
+			Handler:    _BlockStore_ListVolumesWithTag_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "BlockStore.proto",
@@ -619,39 +986,61 @@ var _BlockStore_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("BlockStore.proto", fileDescriptor1) }
 
 var fileDescriptor1 = []byte{
-	// 540 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0x4d, 0x6f, 0xd3, 0x40,
-	0x10, 0x95, 0x3f, 0x40, 0xcd, 0xa4, 0x54, 0xd1, 0x26, 0xa9, 0x2c, 0x4b, 0x04, 0xe3, 0x53, 0x54,
-	0x89, 0x08, 0xc2, 0xa1, 0x15, 0x07, 0x44, 0x21, 0x05, 0x45, 0x54, 0x3d, 0xd8, 0x85, 0x03, 0x70,
-	0x31, 0x64, 0x48, 0xa3, 0x26, 0x5e, 0xe3, 0xdd, 0x54, 0xf2, 0x0f, 0xe0, 0xbf, 0xf1, 0xa3, 0x38,
-	0x20, 0xdb, 0xeb, 0x8f, 0x4d, 0x36, 0x09, 0x55, 0x6e, 0x9e, 0x99, 0x7d, 0x6f, 0xdf, 0xec, 0xbe,
-	0x59, 0x43, 0xeb, 0xed, 0x9c, 0xfe, 0xb8, 0xf5, 0x39, 0x8d, 0x71, 0x10, 0xc5, 0x94, 0x53, 0xd2,
-	0x98, 0x62, 0x88, 0x71, 0xc0, 0x71, 0x62, 0x1f, 0xfa, 0x37, 0x41, 0x8c, 0x93, 0xbc, 0xe0, 0xfe,
-	0xd6, 0xa0, 0xfd, 0x2e, 0xc6, 0x80, 0xe3, 0x67, 0x3a, 0x5f, 0x2e, 0xd0, 0xc3, 0x5f, 0x4b, 0x64,
-	0x9c, 0xf4, 0x00, 0x58, 0x18, 0x44, 0xec, 0x86, 0xf2, 0xf1, 0xc8, 0xd2, 0x1c, 0xad, 0xdf, 0xf0,
-	0x6a, 0x99, 0xb4, 0x7e, 0x97, 0x01, 0xae, 0x93, 0x08, 0x2d, 0x3d, 0xaf, 0x57, 0x19, 0x62, 0xc3,
-	0x41, 0x1e, 0x9d, 0x7f, 0xb1, 0x8c, 0xac, 0x5a, 0xc6, 0x84, 0x80, 0x39, 0xa3, 0x11, 0xb3, 0x4c,
-	0x47, 0xeb, 0x1b, 0x5e, 0xf6, 0xed, 0x0e, 0xa1, 0x23, 0xcb, 0x60, 0x11, 0x0d, 0x59, 0x8d, 0xa7,
-	0x54, 0x51, 0xc6, 0xee, 0x15, 0x74, 0x3e, 0x20, 0xcf, 0x01, 0xe3, 0xf0, 0x27, 0x2d, 0xb4, 0x6f,
-	0xc1, 0x48, 0xba, 0x74, 0x59, 0x97, 0xfb, 0x11, 0xba, 0x2b, 0x7c, 0x42, 0x84, 0xdc, 0xac, 0xb6,
-	0xd6, 0x6c, 0xd1, 0x90, 0x5e, 0x6b, 0xe8, 0x0a, 0x3a, 0x63, 0x56, 0x34, 0x13, 0x4c, 0x92, 0x7d,
-	0xc5, 0x3d, 0x83, 0xee, 0x0a, 0x9f, 0x10, 0xd7, 0x81, 0x07, 0x71, 0x9a, 0xc8, 0xd8, 0x0e, 0xbc,
-	0x3c, 0x70, 0xff, 0x68, 0xd0, 0xcd, 0x0f, 0xd4, 0x17, 0x97, 0xb6, 0xa7, 0x00, 0xf2, 0x1a, 0x4c,
-	0x1e, 0x4c, 0x99, 0x65, 0x38, 0x46, 0xbf, 0x39, 0x3c, 0x19, 0x94, 0x8e, 0x1a, 0x28, 0xf7, 0x19,
-	0x5c, 0x07, 0x53, 0x76, 0x11, 0xf2, 0x38, 0xf1, 0x32, 0x9c, 0x7d, 0x0a, 0x8d, 0x32, 0x45, 0x5a,
-	0x60, 0xdc, 0x62, 0x22, 0xf6, 0x4f, 0x3f, 0xd3, 0x36, 0xee, 0x82, 0xf9, 0xb2, 0xf0, 0x52, 0x1e,
-	0xbc, 0xd2, 0xcf, 0x34, 0xf7, 0x0c, 0x8e, 0x57, 0x77, 0xa8, 0xee, 0x65, 0x9b, 0x49, 0xdd, 0x53,
-	0xe8, 0x8e, 0x70, 0x8e, 0xeb, 0x67, 0xb0, 0x0b, 0xf8, 0x06, 0x48, 0xe5, 0x84, 0x51, 0x81, 0x3a,
-	0x81, 0x56, 0x84, 0x31, 0x9b, 0x31, 0x8e, 0xa1, 0x28, 0x66, 0xd8, 0x43, 0x6f, 0x2d, 0xef, 0xbe,
-	0x80, 0xb6, 0xc4, 0xf0, 0x1f, 0x76, 0xfe, 0x06, 0xc4, 0xdf, 0x6b, 0x53, 0x89, 0x5d, 0x5f, 0x61,
-	0x3f, 0x87, 0xb6, 0xaf, 0x10, 0x74, 0x0f, 0xfa, 0xe1, 0x5f, 0x13, 0xa0, 0x7a, 0x59, 0xc8, 0x73,
-	0x30, 0xc7, 0xe1, 0x8c, 0x93, 0xe3, 0x9a, 0x15, 0xd2, 0x84, 0x50, 0x6e, 0xb7, 0x6a, 0xf9, 0x8b,
-	0x45, 0xc4, 0x13, 0xf2, 0x15, 0xac, 0xfa, 0x90, 0xbf, 0x8f, 0xe9, 0xa2, 0xb8, 0x19, 0xd2, 0x5b,
-	0x33, 0x94, 0xf4, 0x20, 0xd9, 0x4f, 0x36, 0xd6, 0x45, 0x27, 0x1e, 0x3c, 0x92, 0xa6, 0x97, 0xd4,
-	0x11, 0xaa, 0x77, 0xc2, 0x76, 0x36, 0x2f, 0xa8, 0x38, 0xa5, 0xa1, 0x93, 0x38, 0x55, 0xe3, 0x2d,
-	0x71, 0xaa, 0xe7, 0xf5, 0x13, 0x1c, 0xc9, 0x76, 0x26, 0xce, 0xae, 0x59, 0xb2, 0x9f, 0x6e, 0x59,
-	0x21, 0x68, 0x47, 0x70, 0x24, 0x7b, 0x5d, 0xa2, 0x55, 0x8e, 0x81, 0xe2, 0x86, 0x2e, 0xa1, 0x59,
-	0xb3, 0x2d, 0x79, 0xac, 0x3c, 0xa1, 0xc2, 0x9b, 0x76, 0x6f, 0x53, 0x59, 0x68, 0xba, 0x84, 0xa6,
-	0xbf, 0x81, 0xcd, 0xdf, 0xce, 0xa6, 0xb0, 0xea, 0xf7, 0x87, 0xd9, 0x1f, 0xeb, 0xe5, 0xbf, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0x76, 0x14, 0x93, 0xa6, 0xde, 0x06, 0x00, 0x00,
+	// 895 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0xdd, 0x4e, 0xdb, 0x48,
+	0x14, 0x96, 0x93, 0xf0, 0x93, 0x13, 0x96, 0x8d, 0x86, 0x24, 0x9b, 0x35, 0xbb, 0x6c, 0xb0, 0x16,
+	0x1a, 0x21, 0x11, 0x20, 0x5c, 0xa4, 0x45, 0x15, 0x2a, 0x25, 0x14, 0x45, 0x44, 0xbd, 0x88, 0x81,
+	0xfe, 0xde, 0xb8, 0xcd, 0x34, 0x44, 0x04, 0xdb, 0xf5, 0x4c, 0x50, 0xf3, 0x3a, 0x55, 0xd5, 0x67,
+	0xe9, 0x75, 0x5f, 0xa0, 0x2f, 0xd2, 0x8b, 0xca, 0xf6, 0x8c, 0xed, 0xb1, 0x1d, 0x27, 0x29, 0xdc,
+	0x79, 0xce, 0xcc, 0xf9, 0xce, 0x77, 0xbe, 0x39, 0x73, 0x66, 0x0c, 0xf9, 0xa7, 0x03, 0xe3, 0xfd,
+	0xb5, 0x4a, 0x0d, 0x0b, 0xd7, 0x4c, 0xcb, 0xa0, 0x06, 0xca, 0xf6, 0xb0, 0x8e, 0x2d, 0x8d, 0xe2,
+	0xae, 0xbc, 0xa4, 0x5e, 0x69, 0x16, 0xee, 0xba, 0x13, 0xca, 0x4f, 0x09, 0x56, 0x8e, 0x2d, 0xac,
+	0x51, 0x7c, 0x69, 0x0c, 0x86, 0x37, 0xb8, 0x83, 0x3f, 0x0e, 0x31, 0xa1, 0x68, 0x0d, 0x80, 0xe8,
+	0x9a, 0x49, 0xae, 0x0c, 0xda, 0x6a, 0x96, 0xa5, 0x8a, 0x54, 0xcd, 0x76, 0x02, 0x16, 0x7b, 0xfe,
+	0xd6, 0x71, 0x38, 0x1f, 0x99, 0xb8, 0x9c, 0x72, 0xe7, 0x7d, 0x0b, 0x92, 0x61, 0xd1, 0x1d, 0x1d,
+	0xbd, 0x2e, 0xa7, 0x9d, 0x59, 0x6f, 0x8c, 0x10, 0x64, 0xfa, 0x86, 0x49, 0xca, 0x99, 0x8a, 0x54,
+	0x4d, 0x77, 0x9c, 0x6f, 0xf4, 0x18, 0x32, 0x54, 0xeb, 0x91, 0xf2, 0x5c, 0x25, 0x5d, 0xcd, 0xd5,
+	0xab, 0x35, 0x8f, 0x6f, 0x2d, 0x86, 0x5d, 0xed, 0x5c, 0xeb, 0x91, 0x13, 0x9d, 0x5a, 0xa3, 0x8e,
+	0xe3, 0x25, 0x37, 0x20, 0xeb, 0x99, 0x50, 0x1e, 0xd2, 0xd7, 0x78, 0xc4, 0x38, 0xdb, 0x9f, 0xa8,
+	0x00, 0x73, 0xb7, 0xda, 0x60, 0xc8, 0x79, 0xba, 0x83, 0x83, 0xd4, 0x43, 0x49, 0xa9, 0x43, 0x41,
+	0xc4, 0x27, 0xa6, 0xa1, 0x93, 0x00, 0x7d, 0x2f, 0x79, 0x6f, 0xac, 0x3c, 0x87, 0xc2, 0x29, 0xa6,
+	0xae, 0x43, 0x4b, 0xff, 0x60, 0x70, 0xc9, 0x12, 0x7c, 0x04, 0x39, 0x52, 0xa2, 0x1c, 0xca, 0x19,
+	0x14, 0x43, 0x78, 0x8c, 0x84, 0xa8, 0xb1, 0x14, 0xd1, 0x98, 0xeb, 0x98, 0xf2, 0x75, 0xb4, 0xc9,
+	0xb5, 0x08, 0x4f, 0x46, 0xeb, 0x8e, 0xee, 0x4a, 0x6e, 0x1b, 0x8a, 0x21, 0x3c, 0x46, 0xae, 0x00,
+	0x73, 0x96, 0x6d, 0x70, 0xd0, 0x16, 0x3b, 0xee, 0x40, 0xf9, 0x26, 0x41, 0xd1, 0x15, 0x54, 0x65,
+	0xb5, 0x72, 0x47, 0x02, 0xe8, 0x90, 0x15, 0x46, 0xda, 0x29, 0x8c, 0xad, 0x48, 0x61, 0x84, 0xe2,
+	0xdc, 0x5f, 0x69, 0x74, 0xa1, 0x14, 0x8e, 0xe0, 0xef, 0x4b, 0xe2, 0xd9, 0xd8, 0x82, 0xbc, 0xa9,
+	0x59, 0x58, 0xa7, 0xaa, 0xbf, 0xca, 0x85, 0x8f, 0xd8, 0x95, 0x06, 0x14, 0x9b, 0x78, 0x80, 0xa3,
+	0x7a, 0x4d, 0x08, 0xa2, 0x3c, 0x01, 0xe4, 0x57, 0x4d, 0x93, 0x7b, 0xd9, 0xa1, 0xb1, 0x45, 0xfa,
+	0x84, 0x62, 0x9d, 0x4d, 0x3a, 0xbe, 0x4b, 0x9d, 0x88, 0x5d, 0xd9, 0x83, 0x15, 0x01, 0x61, 0x8a,
+	0xd2, 0x7f, 0x0b, 0x48, 0xbd, 0x53, 0x50, 0x01, 0x3d, 0x15, 0x42, 0x3f, 0x82, 0x15, 0x35, 0x86,
+	0xd0, 0x2c, 0x39, 0x35, 0xa0, 0xc8, 0x85, 0x3c, 0xf9, 0xd4, 0x27, 0x94, 0x4c, 0x2b, 0xe7, 0x2e,
+	0x94, 0xc2, 0x8e, 0x2c, 0x7c, 0x09, 0xe6, 0xb1, 0x63, 0x61, 0x95, 0xce, 0x46, 0xca, 0x97, 0x14,
+	0xc8, 0x62, 0x81, 0x9c, 0x5a, 0xc6, 0xd0, 0xe4, 0x01, 0xdb, 0xb0, 0xe0, 0x26, 0x66, 0xfb, 0xd9,
+	0xa5, 0x5b, 0x1f, 0x5b, 0xba, 0x41, 0xbf, 0x9a, 0x9b, 0x08, 0x2b, 0x61, 0x0e, 0x81, 0x8e, 0xd9,
+	0x29, 0x48, 0x39, 0x50, 0x3b, 0xd3, 0x41, 0x85, 0x8f, 0xc2, 0x01, 0x2c, 0x05, 0xd1, 0x67, 0x39,
+	0x0d, 0xbf, 0x7f, 0x8c, 0xbe, 0x4b, 0xb0, 0x1a, 0xcb, 0x91, 0xc9, 0x5b, 0x86, 0x85, 0x9e, 0x6d,
+	0xf0, 0x76, 0x85, 0x0f, 0xd1, 0x2b, 0xc8, 0xf9, 0x1b, 0xc4, 0x53, 0x6f, 0x4c, 0x4a, 0xdd, 0x85,
+	0xad, 0xf9, 0x47, 0x8c, 0x49, 0x10, 0xc4, 0x92, 0x0f, 0x21, 0x1f, 0x5e, 0x30, 0x53, 0x52, 0x07,
+	0x50, 0x12, 0x4f, 0xad, 0x57, 0x67, 0x15, 0x91, 0xb4, 0xbd, 0xf5, 0x59, 0x21, 0xb6, 0xb2, 0x0f,
+	0x7f, 0x45, 0x7c, 0x7d, 0x2d, 0xba, 0xce, 0x54, 0x97, 0xd5, 0x1a, 0x1f, 0x2a, 0x3b, 0xb0, 0xda,
+	0xee, 0x13, 0xaf, 0x71, 0x90, 0x17, 0x7d, 0x7a, 0x75, 0xae, 0xf5, 0x78, 0xd4, 0x08, 0x77, 0xe5,
+	0xab, 0x04, 0xff, 0xc4, 0x7b, 0xb0, 0x58, 0x67, 0x30, 0xef, 0xe4, 0xc3, 0xcb, 0x73, 0x3f, 0x20,
+	0x6c, 0x92, 0x63, 0xed, 0xd2, 0xf1, 0x72, 0x45, 0x65, 0x10, 0xf2, 0x23, 0xc8, 0x05, 0xcc, 0x33,
+	0x49, 0xb9, 0x0d, 0x7f, 0xdb, 0xe1, 0x58, 0x61, 0x4e, 0xcc, 0xeb, 0xb3, 0x04, 0x72, 0xdc, 0x7a,
+	0x96, 0x55, 0x2b, 0x94, 0xd5, 0x5e, 0x28, 0xab, 0x78, 0xb7, 0x7b, 0xce, 0xa9, 0xfe, 0x63, 0x11,
+	0xc0, 0x7f, 0x82, 0xa1, 0x5d, 0xc8, 0xb4, 0xf4, 0x3e, 0x45, 0xa5, 0x00, 0x19, 0xdb, 0xc0, 0xb2,
+	0x94, 0xf3, 0x01, 0xfb, 0xc9, 0x8d, 0x49, 0x47, 0xe8, 0x0d, 0x94, 0x83, 0xcf, 0x92, 0x67, 0x96,
+	0x71, 0xc3, 0xf7, 0x03, 0xad, 0x25, 0xbf, 0x8d, 0xe4, 0xff, 0xc6, 0xce, 0x33, 0x8d, 0x3a, 0xf0,
+	0x87, 0xf0, 0xde, 0x40, 0x41, 0x8f, 0xb8, 0x97, 0x8d, 0x5c, 0x19, 0xbf, 0xc0, 0xc7, 0x14, 0x9e,
+	0x09, 0x02, 0x66, 0xdc, 0x83, 0x44, 0xae, 0x8c, 0x5f, 0xc0, 0x30, 0x2f, 0x60, 0x59, 0x3c, 0xe1,
+	0xa8, 0x32, 0xe9, 0xf6, 0x97, 0xd7, 0x13, 0x56, 0x30, 0xd8, 0x26, 0x2c, 0x8b, 0xe7, 0x4f, 0x80,
+	0x8d, 0xbd, 0x8c, 0x63, 0x76, 0xa8, 0x0d, 0xb9, 0xc0, 0xe5, 0x89, 0xfe, 0x8d, 0x55, 0x88, 0xdf,
+	0x90, 0xf2, 0xda, 0xb8, 0x69, 0xc6, 0xa9, 0x0d, 0x39, 0x75, 0x0c, 0x9a, 0x9a, 0x8c, 0x16, 0x77,
+	0x61, 0x5e, 0xc0, 0xb2, 0x78, 0x97, 0x09, 0x19, 0xc6, 0xde, 0x8f, 0xf2, 0x7a, 0xc2, 0x0a, 0x06,
+	0xdb, 0xe5, 0x7f, 0x0a, 0x42, 0xc7, 0x45, 0x1b, 0x53, 0x5d, 0x46, 0xf2, 0xe6, 0x74, 0x8d, 0x1b,
+	0xbd, 0x84, 0x3f, 0x43, 0xed, 0x11, 0xad, 0x8f, 0xdd, 0x1f, 0x8f, 0xbe, 0x92, 0xb4, 0x84, 0x21,
+	0xf7, 0xa0, 0x10, 0xd7, 0xd8, 0xd0, 0xe6, 0xc4, 0xce, 0xe7, 0xc6, 0x78, 0x30, 0x65, 0x87, 0x44,
+	0x1a, 0xa0, 0x68, 0xaf, 0x41, 0xff, 0x4f, 0x68, 0x45, 0x6e, 0x90, 0x8d, 0xa9, 0x1a, 0xd6, 0xbb,
+	0x79, 0xe7, 0xef, 0x6d, 0xff, 0xd7, 0x00, 0xe1, 0x79, 0xa7, 0x1f, 0xea, 0x0d, 0x00, 0x00,
 }
@@ -241,6 +241,46 @@ func (m *CreateSignedURLResponse) GetUrl() string {
 	return ""
 }
 
+type AvailableBytesRequest struct {
+	Bucket string `protobuf:"bytes,1,opt,name=bucket" json:"bucket,omitempty"`
+}
+
+func (m *AvailableBytesRequest) Reset()                    { *m = AvailableBytesRequest{} }
+func (m *AvailableBytesRequest) String() string            { return proto.CompactTextString(m) }
+func (*AvailableBytesRequest) ProtoMessage()               {}
+func (*AvailableBytesRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{10} }
+
+func (m *AvailableBytesRequest) GetBucket() string {
+	if m != nil {
+		return m.Bucket
+	}
+	return ""
+}
+
+type AvailableBytesResponse struct {
+	Available int64 `protobuf:"varint,1,opt,name=available" json:"available,omitempty"`
+	Supported bool  `protobuf:"varint,2,opt,name=supported" json:"supported,omitempty"`
+}
+
+func (m *AvailableBytesResponse) Reset()                    { *m = AvailableBytesResponse{} }
+func (m *AvailableBytesResponse) String() string            { return proto.CompactTextString(m) }
+func (*AvailableBytesResponse) ProtoMessage()               {}
+func (*AvailableBytesResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{11} }
+
+func (m *AvailableBytesResponse) GetAvailable() int64 {
+	if m != nil {
+		return m.Available
+	}
+	return 0
+}
+
+func (m *AvailableBytesResponse) GetSupported() bool {
+	if m != nil {
+		return m.Supported
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*PutObjectRequest)(nil), "generated.PutObjectRequest")
 	proto.RegisterType((*GetObjectRequest)(nil), "generated.GetObjectRequest")
@@ -252,6 +292,8 @@ func init() {
 	proto.RegisterType((*DeleteObjectRequest)(nil), "generated.DeleteObjectRequest")
 	proto.RegisterType((*CreateSignedURLRequest)(nil), "generated.CreateSignedURLRequest")
 	proto.RegisterType((*CreateSignedURLResponse)(nil), "generated.CreateSignedURLResponse")
+	proto.RegisterType((*AvailableBytesRequest)(nil), "generated.AvailableBytesRequest")
+	proto.RegisterType((*AvailableBytesResponse)(nil), "generated.AvailableBytesResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -272,6 +314,7 @@ type ObjectStoreClient interface {
 	ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (*ListObjectsResponse, error)
 	DeleteObject(ctx context.Context, in *DeleteObjectRequest, opts ...grpc.CallOption) (*Empty, error)
 	CreateSignedURL(ctx context.Context, in *CreateSignedURLRequest, opts ...grpc.CallOption) (*CreateSignedURLResponse, error)
+	AvailableBytes(ctx context.Context, in *AvailableBytesRequest, opts ...grpc.CallOption) (*AvailableBytesResponse, error)
 }
 
 type objectStoreClient struct {
@@ -393,6 +436,15 @@ func (c *objectStoreClient) CreateSignedURL(ctx context.Context, in *CreateSigne
 	return out, nil
 }
 
+func (c *objectStoreClient) AvailableBytes(ctx context.Context, in *AvailableBytesRequest, opts ...grpc.CallOption) (*AvailableBytesResponse, error) {
+	out := new(AvailableBytesResponse)
+	err := grpc.Invoke(ctx, "/generated.ObjectStore/AvailableBytes", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for ObjectStore service
 
 type ObjectStoreServer interface {
@@ -403,6 +455,7 @@ type ObjectStoreServer interface {
 	ListObjects(context.Context, *ListObjectsRequest) (*ListObjectsResponse, error)
 	DeleteObject(context.Context, *DeleteObjectRequest) (*Empty, error)
 	CreateSignedURL(context.Context, *CreateSignedURLRequest) (*CreateSignedURLResponse, error)
+	AvailableBytes(context.Context, *AvailableBytesRequest) (*AvailableBytesResponse, error)
 }
 
 func RegisterObjectStoreServer(s *grpc.Server, srv ObjectStoreServer) {
@@ -546,6 +599,24 @@ func _ObjectStore_CreateSignedURL_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ObjectStore_AvailableBytes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AvailableBytesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ObjectStoreServer).AvailableBytes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.ObjectStore/AvailableBytes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ObjectStoreServer).AvailableBytes(ctx, req.(*AvailableBytesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ObjectStore_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "generated.ObjectStore",
 	HandlerType: (*ObjectStoreServer)(nil),
@@ -570,6 +641,10 @@ var _ObjectStore_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CreateSignedURL",
 			Handler:    _ObjectStore_CreateSignedURL_Handler,
 		},
+		{
+			MethodName: "AvailableBytes",
+			Handler:    _ObjectStore_AvailableBytes_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -589,33 +664,37 @@ var _ObjectStore_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("ObjectStore.proto", fileDescriptor2) }
 
 var fileDescriptor2 = []byte{
-	// 444 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x94, 0xdf, 0x8b, 0xd3, 0x40,
-	0x10, 0xc7, 0x89, 0xa9, 0xc5, 0xcc, 0x15, 0x8c, 0x73, 0x50, 0x6b, 0x4e, 0xa5, 0x2e, 0x0a, 0x15,
-	0xa1, 0x1c, 0xfa, 0xe2, 0xc3, 0x81, 0xe2, 0x9d, 0x88, 0x50, 0xb0, 0xa6, 0x0a, 0xbe, 0xa6, 0x97,
-	0xf1, 0x8c, 0xcd, 0x2f, 0x37, 0x13, 0x30, 0xff, 0x81, 0x7f, 0xb6, 0x64, 0xb3, 0xc6, 0x4d, 0x2e,
-	0x67, 0xf1, 0xde, 0x66, 0x67, 0xe7, 0xfb, 0x9d, 0xc9, 0xce, 0x87, 0xc0, 0x9d, 0x0f, 0xdb, 0xef,
-	0x74, 0xce, 0x1b, 0xce, 0x24, 0x2d, 0x73, 0x99, 0x71, 0x86, 0xce, 0x05, 0xa5, 0x24, 0x03, 0xa6,
-	0xd0, 0x9b, 0x6c, 0xbe, 0x05, 0x92, 0xc2, 0xe6, 0x42, 0xac, 0xc1, 0x5d, 0x97, 0xdc, 0x08, 0x7c,
-	0xfa, 0x51, 0x52, 0xc1, 0x38, 0x85, 0xf1, 0xb6, 0x3c, 0xdf, 0x11, 0xcf, 0xac, 0xb9, 0xb5, 0x70,
-	0x7c, 0x7d, 0x42, 0x17, 0xec, 0x1d, 0x55, 0xb3, 0x1b, 0x2a, 0x59, 0x87, 0x88, 0x30, 0xda, 0x66,
-	0x61, 0x35, 0xb3, 0xe7, 0xd6, 0x62, 0xe2, 0xab, 0x58, 0x9c, 0x80, 0xfb, 0x8e, 0xae, 0xeb, 0x28,
-	0x8e, 0xe0, 0xe6, 0x9b, 0x8a, 0xa9, 0xa8, 0xad, 0xc3, 0x80, 0x03, 0x25, 0x98, 0xf8, 0x2a, 0x16,
-	0x1f, 0xe1, 0xde, 0x2a, 0x2a, 0xf8, 0x34, 0x4b, 0x92, 0x2c, 0x5d, 0x4b, 0xfa, 0x1a, 0xfd, 0xa4,
-	0x62, 0x5f, 0x8f, 0xfb, 0xe0, 0x84, 0x14, 0x47, 0x49, 0xc4, 0x24, 0x75, 0xa7, 0xbf, 0x09, 0xf1,
-	0x12, 0xbc, 0x21, 0xcb, 0x22, 0xcf, 0xd2, 0x82, 0xd0, 0x83, 0x5b, 0xb9, 0xce, 0xcd, 0xac, 0xb9,
-	0xbd, 0x70, 0xfc, 0xf6, 0x2c, 0xce, 0x00, 0x6b, 0x65, 0xf3, 0xa1, 0x7b, 0xa7, 0x98, 0xc2, 0xb8,
-	0x51, 0xea, 0x11, 0xf4, 0x49, 0x3c, 0x85, 0xc3, 0x8e, 0x8b, 0x6e, 0x8c, 0x30, 0xda, 0x51, 0xf5,
-	0xa7, 0xa9, 0x8a, 0xc5, 0x2b, 0x38, 0x3c, 0xa3, 0x98, 0x98, 0xae, 0xfb, 0xb6, 0x9f, 0x60, 0x7a,
-	0x2a, 0x29, 0x60, 0xda, 0x44, 0x17, 0x29, 0x85, 0x9f, 0xfd, 0xd5, 0xff, 0x6f, 0xdc, 0x05, 0x9b,
-	0x39, 0x56, 0x0b, 0xb7, 0xfd, 0x3a, 0x14, 0xcf, 0xe0, 0xee, 0x25, 0x57, 0xfd, 0x15, 0x2e, 0xd8,
-	0xa5, 0x8c, 0xb5, 0x67, 0x1d, 0x3e, 0xff, 0x35, 0x82, 0x03, 0x83, 0x4e, 0x3c, 0x86, 0xd1, 0xfb,
-	0x34, 0x62, 0x9c, 0x2e, 0x5b, 0x40, 0x97, 0x75, 0x42, 0x0f, 0xe6, 0xb9, 0x46, 0xfe, 0x6d, 0x92,
-	0x73, 0x85, 0x27, 0xe0, 0xb4, 0xc0, 0xe2, 0x91, 0x71, 0xdd, 0xc7, 0xf8, 0xb2, 0x76, 0x61, 0xd5,
-	0xea, 0x16, 0xce, 0x8e, 0xba, 0x8f, 0x6c, 0x47, 0xad, 0x88, 0x3c, 0xb6, 0x30, 0x68, 0x56, 0xde,
-	0x85, 0x05, 0x1f, 0x1b, 0x95, 0x57, 0xe2, 0xe9, 0x3d, 0xd9, 0x53, 0xa5, 0x9f, 0x6c, 0x05, 0x07,
-	0x06, 0x0f, 0xf8, 0xa0, 0xa7, 0xea, 0xd2, 0xe6, 0x3d, 0xbc, 0xea, 0x5a, 0xbb, 0xbd, 0x86, 0x89,
-	0x89, 0x0c, 0x9a, 0xf5, 0x03, 0x2c, 0x0d, 0x3c, 0xf7, 0x17, 0xb8, 0xdd, 0xdb, 0x2e, 0x3e, 0x32,
-	0x8a, 0x86, 0x79, 0xf2, 0xc4, 0xbf, 0x4a, 0x9a, 0xd9, 0xb6, 0x63, 0xf5, 0x03, 0x7a, 0xf1, 0x3b,
-	0x00, 0x00, 0xff, 0xff, 0xf3, 0xb2, 0x85, 0x9a, 0xae, 0x04, 0x00, 0x00,
+	// 503 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0xdd, 0x6e, 0xd3, 0x4c,
+	0x10, 0x95, 0x3f, 0xe7, 0x8b, 0xea, 0x69, 0x04, 0x66, 0x2a, 0x42, 0x70, 0x0b, 0x4a, 0x57, 0x20,
+	0x05, 0x21, 0x85, 0x0a, 0x6e, 0xb8, 0xa8, 0xc4, 0x4f, 0x8b, 0x22, 0xa4, 0x48, 0x04, 0x87, 0x4a,
+	0xdc, 0xda, 0xf5, 0x50, 0x4c, 0x1c, 0xdb, 0xd8, 0x63, 0x84, 0x9f, 0x96, 0x57, 0x41, 0x5e, 0x6f,
+	0x93, 0x4d, 0xe2, 0x26, 0xa2, 0x77, 0xe3, 0x99, 0x39, 0x67, 0xce, 0xee, 0x9e, 0x31, 0xdc, 0xfb,
+	0xe4, 0xff, 0xa0, 0x4b, 0x9e, 0x72, 0x92, 0xd1, 0x30, 0xcd, 0x12, 0x4e, 0xd0, 0xba, 0xa2, 0x98,
+	0x32, 0x8f, 0x29, 0x70, 0x3a, 0xd3, 0xef, 0x5e, 0x46, 0x41, 0x5d, 0x10, 0x13, 0xb0, 0x27, 0x05,
+	0xd7, 0x00, 0x97, 0x7e, 0x16, 0x94, 0x33, 0x76, 0xa1, 0xed, 0x17, 0x97, 0x33, 0xe2, 0x9e, 0xd1,
+	0x37, 0x06, 0x96, 0xab, 0xbe, 0xd0, 0x06, 0x73, 0x46, 0x65, 0xef, 0x3f, 0x99, 0xac, 0x42, 0x44,
+	0x68, 0xf9, 0x49, 0x50, 0xf6, 0xcc, 0xbe, 0x31, 0xe8, 0xb8, 0x32, 0x16, 0xa7, 0x60, 0x8f, 0xe8,
+	0xb6, 0x8c, 0xe2, 0x10, 0xfe, 0x7f, 0x5f, 0x32, 0xe5, 0x15, 0x75, 0xe0, 0xb1, 0x27, 0x01, 0x1d,
+	0x57, 0xc6, 0xe2, 0x33, 0x3c, 0x1c, 0x87, 0x39, 0x9f, 0x25, 0xf3, 0x79, 0x12, 0x4f, 0x32, 0xfa,
+	0x16, 0xfe, 0xa6, 0x7c, 0xd7, 0x8c, 0x23, 0xb0, 0x02, 0x8a, 0xc2, 0x79, 0xc8, 0x94, 0xa9, 0x49,
+	0xcb, 0x84, 0x78, 0x0d, 0x4e, 0x13, 0x65, 0x9e, 0x26, 0x71, 0x4e, 0xe8, 0xc0, 0x5e, 0xaa, 0x72,
+	0x3d, 0xa3, 0x6f, 0x0e, 0x2c, 0x77, 0xf1, 0x2d, 0xce, 0x01, 0x2b, 0x64, 0x7d, 0xd0, 0x9d, 0x2a,
+	0xba, 0xd0, 0xae, 0x91, 0x4a, 0x82, 0xfa, 0x12, 0xcf, 0xe0, 0x60, 0x85, 0x45, 0x0d, 0x46, 0x68,
+	0xcd, 0xa8, 0xbc, 0x1e, 0x2a, 0x63, 0xf1, 0x06, 0x0e, 0xce, 0x29, 0x22, 0xa6, 0xdb, 0xde, 0xed,
+	0x17, 0xe8, 0x9e, 0x65, 0xe4, 0x31, 0x4d, 0xc3, 0xab, 0x98, 0x82, 0x0b, 0x77, 0xfc, 0xef, 0x2f,
+	0x6e, 0x83, 0xc9, 0x1c, 0xc9, 0x07, 0x37, 0xdd, 0x2a, 0x14, 0xcf, 0xe1, 0xc1, 0x06, 0xab, 0x3a,
+	0x85, 0x0d, 0x66, 0x91, 0x45, 0x8a, 0xb3, 0x0a, 0xc5, 0x0b, 0xb8, 0xff, 0xee, 0x97, 0x17, 0x46,
+	0x9e, 0x1f, 0x91, 0x7c, 0xe7, 0x1d, 0x0a, 0x2a, 0xcd, 0xeb, 0x00, 0x45, 0x7e, 0x04, 0x96, 0x77,
+	0x5d, 0x91, 0x20, 0xd3, 0x5d, 0x26, 0xaa, 0x6a, 0x5e, 0xa4, 0x69, 0x92, 0x31, 0x05, 0x52, 0xff,
+	0x9e, 0xbb, 0x4c, 0xbc, 0xfc, 0xd3, 0x82, 0x7d, 0x6d, 0x49, 0xf0, 0x04, 0x5a, 0x1f, 0xe3, 0x90,
+	0xb1, 0x3b, 0x5c, 0xec, 0xc9, 0xb0, 0x4a, 0x28, 0x75, 0x8e, 0xad, 0xe5, 0x3f, 0xcc, 0x53, 0x2e,
+	0xf1, 0x14, 0xac, 0xc5, 0xde, 0xe0, 0xa1, 0x56, 0x5e, 0xdf, 0xa6, 0x4d, 0xec, 0xc0, 0xa8, 0xd0,
+	0x23, 0x6a, 0x42, 0x8f, 0x68, 0x0b, 0x5a, 0x9e, 0xff, 0xc4, 0x40, 0x0f, 0x70, 0xd3, 0xb3, 0xf8,
+	0x44, 0xeb, 0xbc, 0x71, 0x4b, 0x9c, 0xa7, 0x3b, 0xba, 0xd4, 0xe5, 0x8e, 0x61, 0x5f, 0xb3, 0x25,
+	0x3e, 0x5a, 0x43, 0xad, 0x9a, 0xde, 0x79, 0x7c, 0x53, 0x59, 0xb1, 0xbd, 0x85, 0x8e, 0xee, 0x5c,
+	0xd4, 0xfb, 0x1b, 0x2c, 0xdd, 0x70, 0xdd, 0x5f, 0xe1, 0xee, 0x9a, 0xc9, 0xf0, 0x58, 0x6b, 0x6a,
+	0xb6, 0xb5, 0x23, 0xb6, 0xb5, 0x28, 0x6d, 0x17, 0x70, 0x67, 0xd5, 0x60, 0xd8, 0xd7, 0x50, 0x8d,
+	0x66, 0x75, 0x8e, 0xb7, 0x74, 0xd4, 0xb4, 0x7e, 0x5b, 0xfe, 0x5e, 0x5f, 0xfd, 0x1d, 0x00, 0xdf,
+	0xb4, 0x4c, 0x1a, 0x8c, 0x05, 0x00, 0x00,
 }
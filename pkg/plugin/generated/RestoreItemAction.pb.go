@@ -65,9 +65,42 @@ func (m *RestoreExecuteResponse) GetWarning() string {
 	return ""
 }
 
+type RestoreWaitConditionResponse struct {
+	ConditionType   string `protobuf:"bytes,1,opt,name=conditionType" json:"conditionType,omitempty"`
+	ConditionStatus string `protobuf:"bytes,2,opt,name=conditionStatus" json:"conditionStatus,omitempty"`
+	Timeout         int64  `protobuf:"varint,3,opt,name=timeout" json:"timeout,omitempty"`
+}
+
+func (m *RestoreWaitConditionResponse) Reset()                    { *m = RestoreWaitConditionResponse{} }
+func (m *RestoreWaitConditionResponse) String() string            { return proto.CompactTextString(m) }
+func (*RestoreWaitConditionResponse) ProtoMessage()               {}
+func (*RestoreWaitConditionResponse) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{2} }
+
+func (m *RestoreWaitConditionResponse) GetConditionType() string {
+	if m != nil {
+		return m.ConditionType
+	}
+	return ""
+}
+
+func (m *RestoreWaitConditionResponse) GetConditionStatus() string {
+	if m != nil {
+		return m.ConditionStatus
+	}
+	return ""
+}
+
+func (m *RestoreWaitConditionResponse) GetTimeout() int64 {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*RestoreExecuteRequest)(nil), "generated.RestoreExecuteRequest")
 	proto.RegisterType((*RestoreExecuteResponse)(nil), "generated.RestoreExecuteResponse")
+	proto.RegisterType((*RestoreWaitConditionResponse)(nil), "generated.RestoreWaitConditionResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -83,6 +116,7 @@ const _ = grpc.SupportPackageIsVersion4
 type RestoreItemActionClient interface {
 	AppliesTo(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*AppliesToResponse, error)
 	Execute(ctx context.Context, in *RestoreExecuteRequest, opts ...grpc.CallOption) (*RestoreExecuteResponse, error)
+	WaitCondition(ctx context.Context, in *RestoreExecuteRequest, opts ...grpc.CallOption) (*RestoreWaitConditionResponse, error)
 }
 
 type restoreItemActionClient struct {
@@ -111,11 +145,21 @@ func (c *restoreItemActionClient) Execute(ctx context.Context, in *RestoreExecut
 	return out, nil
 }
 
+func (c *restoreItemActionClient) WaitCondition(ctx context.Context, in *RestoreExecuteRequest, opts ...grpc.CallOption) (*RestoreWaitConditionResponse, error) {
+	out := new(RestoreWaitConditionResponse)
+	err := grpc.Invoke(ctx, "/generated.RestoreItemAction/WaitCondition", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for RestoreItemAction service
 
 type RestoreItemActionServer interface {
 	AppliesTo(context.Context, *Empty) (*AppliesToResponse, error)
 	Execute(context.Context, *RestoreExecuteRequest) (*RestoreExecuteResponse, error)
+	WaitCondition(context.Context, *RestoreExecuteRequest) (*RestoreWaitConditionResponse, error)
 }
 
 func RegisterRestoreItemActionServer(s *grpc.Server, srv RestoreItemActionServer) {
@@ -158,6 +202,24 @@ func _RestoreItemAction_Execute_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RestoreItemAction_WaitCondition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreExecuteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RestoreItemActionServer).WaitCondition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/generated.RestoreItemAction/WaitCondition",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RestoreItemActionServer).WaitCondition(ctx, req.(*RestoreExecuteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _RestoreItemAction_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "generated.RestoreItemAction",
 	HandlerType: (*RestoreItemActionServer)(nil),
@@ -170,6 +232,10 @@ var _RestoreItemAction_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Execute",
 			Handler:    _RestoreItemAction_Execute_Handler,
 		},
+		{
+			MethodName: "WaitCondition",
+			Handler:    _RestoreItemAction_WaitCondition_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "RestoreItemAction.proto",
@@ -178,19 +244,23 @@ var _RestoreItemAction_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("RestoreItemAction.proto", fileDescriptor3) }
 
 var fileDescriptor3 = []byte{
-	// 210 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x0f, 0x4a, 0x2d, 0x2e,
-	0xc9, 0x2f, 0x4a, 0xf5, 0x2c, 0x49, 0xcd, 0x75, 0x4c, 0x2e, 0xc9, 0xcc, 0xcf, 0xd3, 0x2b, 0x28,
-	0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x4c, 0x4f, 0xcd, 0x4b, 0x2d, 0x4a, 0x2c, 0x49, 0x4d, 0x91, 0xe2,
-	0x09, 0xce, 0x48, 0x2c, 0x4a, 0x4d, 0x81, 0x48, 0x28, 0xb9, 0x72, 0x89, 0x42, 0xf5, 0xb8, 0x56,
-	0xa4, 0x26, 0x97, 0x96, 0xa4, 0x06, 0xa5, 0x16, 0x96, 0xa6, 0x16, 0x97, 0x08, 0x09, 0x71, 0xb1,
-	0x64, 0x96, 0xa4, 0xe6, 0x4a, 0x30, 0x2a, 0x30, 0x6a, 0xf0, 0x04, 0x81, 0xd9, 0x42, 0x12, 0x5c,
-	0xec, 0x45, 0x10, 0xc5, 0x12, 0x4c, 0x60, 0x61, 0x18, 0x57, 0xc9, 0x8d, 0x4b, 0x0c, 0xdd, 0x98,
-	0xe2, 0x82, 0xfc, 0xbc, 0xe2, 0x54, 0x5c, 0xe6, 0x94, 0x27, 0x16, 0xe5, 0x65, 0xe6, 0xa5, 0x83,
-	0xcd, 0xe1, 0x0c, 0x82, 0x71, 0x8d, 0x16, 0x30, 0x72, 0x09, 0x62, 0xf8, 0x41, 0xc8, 0x9a, 0x8b,
-	0xd3, 0xb1, 0xa0, 0x20, 0x27, 0x33, 0xb5, 0x38, 0x24, 0x5f, 0x48, 0x40, 0x0f, 0xee, 0x17, 0x3d,
-	0xd7, 0xdc, 0x82, 0x92, 0x4a, 0x29, 0x19, 0x24, 0x11, 0xb8, 0x3a, 0xb8, 0x03, 0xfc, 0xb8, 0xd8,
-	0xa1, 0x6e, 0x12, 0x52, 0x40, 0x52, 0x88, 0xd5, 0xd7, 0x52, 0x8a, 0x78, 0x54, 0x40, 0xcc, 0x4b,
-	0x62, 0x03, 0x07, 0x9c, 0x31, 0x20, 0x00, 0x00, 0xff, 0xff, 0xb9, 0x08, 0x09, 0x74, 0x6c, 0x01,
-	0x00, 0x00,
+	// 286 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x92, 0x4f, 0x4b, 0xc3, 0x30,
+	0x18, 0xc6, 0xa9, 0x13, 0x47, 0x5f, 0x36, 0xd4, 0x80, 0x5a, 0xca, 0x0e, 0xb5, 0x08, 0xf6, 0xd4,
+	0x83, 0x1e, 0x3d, 0x0d, 0xa9, 0xe0, 0xc5, 0x43, 0x36, 0x10, 0xbc, 0xd5, 0xf6, 0x65, 0x06, 0x6c,
+	0x12, 0x93, 0xb7, 0xe8, 0xbe, 0x81, 0x1f, 0xda, 0x83, 0xac, 0x6b, 0xc3, 0xfe, 0x29, 0xbb, 0xf5,
+	0x79, 0xfa, 0xe4, 0x97, 0x37, 0x4f, 0x02, 0x17, 0x1c, 0x2d, 0x29, 0x83, 0x8f, 0x84, 0xd5, 0xb8,
+	0x20, 0xa1, 0x64, 0xaa, 0x8d, 0x22, 0xc5, 0xfc, 0x19, 0x4a, 0x34, 0x39, 0x61, 0x19, 0x0e, 0x26,
+	0x6f, 0xb9, 0xc1, 0x72, 0xf9, 0x23, 0xce, 0xe0, 0xac, 0x5d, 0x93, 0x7d, 0x61, 0x51, 0x13, 0x72,
+	0xfc, 0xa8, 0xd1, 0x12, 0x63, 0x70, 0x28, 0x08, 0xab, 0xc0, 0x8b, 0xbc, 0x64, 0xc0, 0x9b, 0x6f,
+	0x16, 0x40, 0xdf, 0x2c, 0xc3, 0xc1, 0x41, 0x63, 0x77, 0x32, 0x7e, 0x80, 0xf3, 0x4d, 0x8c, 0xd5,
+	0x4a, 0x5a, 0xfc, 0x8b, 0xf3, 0x99, 0x1b, 0x29, 0xe4, 0xac, 0xe1, 0xf8, 0xbc, 0x93, 0xf1, 0xb7,
+	0x07, 0xa3, 0x16, 0xf4, 0x9c, 0x0b, 0xba, 0x57, 0xb2, 0x14, 0x8b, 0x63, 0x38, 0xdc, 0x15, 0x0c,
+	0x8b, 0xce, 0x9c, 0xce, 0x35, 0x36, 0x5c, 0x9f, 0xaf, 0x9b, 0x2c, 0x81, 0x63, 0x67, 0x4c, 0x28,
+	0xa7, 0xda, 0xb6, 0x1b, 0x6d, 0xda, 0x8b, 0x51, 0x48, 0x54, 0xa8, 0x6a, 0x0a, 0x7a, 0x91, 0x97,
+	0xf4, 0x78, 0x27, 0x6f, 0x7e, 0x3c, 0x38, 0xdd, 0xaa, 0x93, 0xdd, 0x81, 0x3f, 0xd6, 0xfa, 0x5d,
+	0xa0, 0x9d, 0x2a, 0x76, 0x92, 0xba, 0x5a, 0xd3, 0xac, 0xd2, 0x34, 0x0f, 0x47, 0x2b, 0x8e, 0xcb,
+	0xb9, 0xe1, 0x9f, 0xa0, 0xdf, 0xd6, 0xc3, 0xa2, 0x95, 0xe0, 0xce, 0x0b, 0x08, 0x2f, 0xff, 0x49,
+	0xb4, 0xbc, 0x17, 0x18, 0xae, 0xb5, 0xb4, 0x07, 0xf5, 0x7a, 0x3b, 0xb1, 0xb3, 0xe8, 0xd7, 0xa3,
+	0xe6, 0x7d, 0xdc, 0xfe, 0x0e, 0x00, 0xec, 0x6b, 0x49, 0x05, 0x53, 0x02, 0x00, 0x00,
 }
@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	plugin "github.com/hashicorp/go-plugin"
 	"github.com/pkg/errors"
@@ -98,6 +99,18 @@ type Manager interface {
 	// cloudprovider.ObjectStore interface with the specified name.
 	GetObjectStore(name string) (cloudprovider.ObjectStore, error)
 
+	// GetMirrorObjectStore returns the plugin implementation of the
+	// cloudprovider.ObjectStore interface with the specified name for
+	// the given backup storage mirror. Each mirror gets its own plugin
+	// process, so it can be initialized with a different config than
+	// the instance returned by GetObjectStore.
+	GetMirrorObjectStore(name, mirror string) (cloudprovider.ObjectStore, error)
+
+	// GetStreamObjectStore returns the plugin implementation of the
+	// cloudprovider.ObjectStore interface with the specified name for
+	// the backup stream, in its own plugin process like the mirrors'.
+	GetStreamObjectStore(name string) (cloudprovider.ObjectStore, error)
+
 	// GetBlockStore returns the plugin implementation of the
 	// cloudprovider.BlockStore interface with the specified name.
 	GetBlockStore(name string) (cloudprovider.BlockStore, error)
@@ -131,6 +144,10 @@ type manager struct {
 	pluginRegistry *registry
 	clientStore    *clientStore
 	pluginDir      string
+
+	// cloudProviderLock serializes getting or creating cloud provider plugin
+	// clients, so that concurrent callers share a single client per plugin.
+	cloudProviderLock sync.Mutex
 }
 
 // NewManager constructs a manager for getting plugin implementations.
@@ -182,12 +199,24 @@ func (m *manager) registerPlugins() error {
 	for _, provider := range []string{"aws", "gcp", "azure"} {
 		m.pluginRegistry.register(provider, arkCommand, []string{"run-plugin", "cloudprovider", provider}, PluginKindObjectStore, PluginKindBlockStore)
 	}
+	m.pluginRegistry.register("filesystem", arkCommand, []string{"run-plugin", "cloudprovider", "filesystem"}, PluginKindObjectStore)
 	m.pluginRegistry.register("pv", arkCommand, []string{"run-plugin", string(PluginKindBackupItemAction), "pv"}, PluginKindBackupItemAction)
 	m.pluginRegistry.register("backup-pod", arkCommand, []string{"run-plugin", string(PluginKindBackupItemAction), "pod"}, PluginKindBackupItemAction)
+	m.pluginRegistry.register("helm-release", arkCommand, []string{"run-plugin", string(PluginKindBackupItemAction), "helm-release"}, PluginKindBackupItemAction)
 
 	m.pluginRegistry.register("job", arkCommand, []string{"run-plugin", string(PluginKindRestoreItemAction), "job"}, PluginKindRestoreItemAction)
 	m.pluginRegistry.register("restore-pod", arkCommand, []string{"run-plugin", string(PluginKindRestoreItemAction), "pod"}, PluginKindRestoreItemAction)
 	m.pluginRegistry.register("svc", arkCommand, []string{"run-plugin", string(PluginKindRestoreItemAction), "svc"}, PluginKindRestoreItemAction)
+	m.pluginRegistry.register("rolebinding", arkCommand, []string{"run-plugin", string(PluginKindRestoreItemAction), "rolebinding"}, PluginKindRestoreItemAction)
+	m.pluginRegistry.register("scale-to-zero", arkCommand, []string{"run-plugin", string(PluginKindRestoreItemAction), "scale-to-zero"}, PluginKindRestoreItemAction)
+	m.pluginRegistry.register("container-resources", arkCommand, []string{"run-plugin", string(PluginKindRestoreItemAction), "container-resources"}, PluginKindRestoreItemAction)
+	m.pluginRegistry.register("image-registry", arkCommand, []string{"run-plugin", string(PluginKindRestoreItemAction), "image-registry"}, PluginKindRestoreItemAction)
+	m.pluginRegistry.register("default-storage-class", arkCommand, []string{"run-plugin", string(PluginKindRestoreItemAction), "default-storage-class"}, PluginKindRestoreItemAction)
+
+	// object stores registered with RegisterObjectStore are served by this binary too
+	for _, name := range RegisteredObjectStores() {
+		m.pluginRegistry.register(name, arkCommand, []string{"run-plugin", string(PluginKindObjectStore), name}, PluginKindObjectStore)
+	}
 
 	// second, register external plugins (these will override internal plugins, if applicable)
 	if _, err := os.Stat(m.pluginDir); err != nil {
@@ -231,7 +260,23 @@ func parse(filename string) (string, PluginKind, error) {
 // GetObjectStore returns the plugin implementation of the cloudprovider.ObjectStore
 // interface with the specified name.
 func (m *manager) GetObjectStore(name string) (cloudprovider.ObjectStore, error) {
-	pluginObj, err := m.getCloudProviderPlugin(name, PluginKindObjectStore)
+	return m.getObjectStore(name, "")
+}
+
+// GetMirrorObjectStore returns the plugin implementation of the cloudprovider.ObjectStore
+// interface with the specified name, served by a plugin process for the given mirror.
+func (m *manager) GetMirrorObjectStore(name, mirror string) (cloudprovider.ObjectStore, error) {
+	return m.getObjectStore(name, "mirror/"+mirror)
+}
+
+// GetStreamObjectStore returns the plugin implementation of the cloudprovider.ObjectStore
+// interface with the specified name, served by a plugin process for the backup stream.
+func (m *manager) GetStreamObjectStore(name string) (cloudprovider.ObjectStore, error) {
+	return m.getObjectStore(name, "stream")
+}
+
+func (m *manager) getObjectStore(name, scope string) (cloudprovider.ObjectStore, error) {
+	pluginObj, err := m.getCloudProviderPlugin(name, PluginKindObjectStore, scope)
 	if err != nil {
 		return nil, err
 	}
@@ -247,7 +292,7 @@ func (m *manager) GetObjectStore(name string) (cloudprovider.ObjectStore, error)
 // GetBlockStore returns the plugin implementation of the cloudprovider.BlockStore
 // interface with the specified name.
 func (m *manager) GetBlockStore(name string) (cloudprovider.BlockStore, error) {
-	pluginObj, err := m.getCloudProviderPlugin(name, PluginKindBlockStore)
+	pluginObj, err := m.getCloudProviderPlugin(name, PluginKindBlockStore, "")
 	if err != nil {
 		return nil, err
 	}
@@ -260,8 +305,11 @@ func (m *manager) GetBlockStore(name string) (cloudprovider.BlockStore, error) {
 	return blockStore, nil
 }
 
-func (m *manager) getCloudProviderPlugin(name string, kind PluginKind) (interface{}, error) {
-	client, err := m.clientStore.get(kind, name, "")
+func (m *manager) getCloudProviderPlugin(name string, kind PluginKind, scope string) (interface{}, error) {
+	m.cloudProviderLock.Lock()
+	defer m.cloudProviderLock.Unlock()
+
+	client, err := m.clientStore.get(kind, name, scope)
 	if err != nil {
 		pluginInfo, err := m.pluginRegistry.get(kind, name)
 		if err != nil {
@@ -281,7 +329,7 @@ func (m *manager) getCloudProviderPlugin(name string, kind PluginKind) (interfac
 
 		// register the plugin client for the appropriate kinds
 		for _, kind := range pluginInfo.kinds {
-			m.clientStore.add(client, kind, name, "")
+			m.clientStore.add(client, kind, name, scope)
 		}
 	}
 
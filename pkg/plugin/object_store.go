@@ -23,6 +23,7 @@ import (
 	"github.com/hashicorp/go-plugin"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 
 	"github.com/heptio/ark/pkg/cloudprovider"
 	proto "github.com/heptio/ark/pkg/plugin/generated"
@@ -172,6 +173,21 @@ func (c *ObjectStoreGRPCClient) CreateSignedURL(bucket, key string, ttl time.Dur
 	return res.Url, nil
 }
 
+// AvailableBytes returns the storage still available in the given bucket, and
+// whether the object store reports it. Plugins built before the method was added
+// are treated as not reporting it.
+func (c *ObjectStoreGRPCClient) AvailableBytes(bucket string) (int64, bool, error) {
+	res, err := c.grpcClient.AvailableBytes(context.Background(), &proto.AvailableBytesRequest{Bucket: bucket})
+	if grpc.Code(err) == codes.Unimplemented {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return res.Available, res.Supported, nil
+}
+
 // ObjectStoreGRPCServer implements the proto-generated ObjectStoreServer interface, and accepts
 // gRPC calls and forwards them to an implementation of the pluggable interface.
 type ObjectStoreGRPCServer struct {
@@ -293,3 +309,13 @@ func (s *ObjectStoreGRPCServer) CreateSignedURL(ctx context.Context, req *proto.
 
 	return &proto.CreateSignedURLResponse{Url: url}, nil
 }
+
+// AvailableBytes returns the storage still available in the given bucket.
+func (s *ObjectStoreGRPCServer) AvailableBytes(ctx context.Context, req *proto.AvailableBytesRequest) (*proto.AvailableBytesResponse, error) {
+	available, supported, err := s.impl.AvailableBytes(req.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.AvailableBytesResponse{Available: available, Supported: supported}, nil
+}
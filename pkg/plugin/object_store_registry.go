@@ -0,0 +1,73 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/heptio/ark/pkg/cloudprovider"
+)
+
+var (
+	objectStoreFactoriesLock sync.Mutex
+	objectStoreFactories     = make(map[string]func() cloudprovider.ObjectStore)
+)
+
+// RegisterObjectStore registers an ObjectStore implementation that's compiled into the
+// Ark binary under name, so it can be selected by name in the Config's backupStorageProvider
+// or backupStorageMirrors, like the built-in object stores. Registered object stores are served
+// by the Ark binary's run-plugin command, the same way as the built-in ones, and override
+// built-in object stores with the same name. It's meant to be called from an init function,
+// and panics if name is already registered.
+func RegisterObjectStore(name string, newObjectStore func() cloudprovider.ObjectStore) {
+	objectStoreFactoriesLock.Lock()
+	defer objectStoreFactoriesLock.Unlock()
+
+	if _, found := objectStoreFactories[name]; found {
+		panic(fmt.Sprintf("object store %q is already registered", name))
+	}
+	objectStoreFactories[name] = newObjectStore
+}
+
+// NewRegisteredObjectStore returns a new instance of the object store registered under name,
+// and whether one is.
+func NewRegisteredObjectStore(name string) (cloudprovider.ObjectStore, bool) {
+	objectStoreFactoriesLock.Lock()
+	defer objectStoreFactoriesLock.Unlock()
+
+	newObjectStore, found := objectStoreFactories[name]
+	if !found {
+		return nil, false
+	}
+	return newObjectStore(), true
+}
+
+// RegisteredObjectStores returns the names of the registered object stores, sorted.
+func RegisteredObjectStores() []string {
+	objectStoreFactoriesLock.Lock()
+	defer objectStoreFactoriesLock.Unlock()
+
+	names := make([]string, 0, len(objectStoreFactories))
+	for name := range objectStoreFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
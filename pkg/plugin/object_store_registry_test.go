@@ -0,0 +1,59 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/heptio/ark/pkg/cloudprovider"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestRegisterObjectStore(t *testing.T) {
+	objectStore := arktest.NewFakeObjectStore()
+	RegisterObjectStore("test-in-house", func() cloudprovider.ObjectStore { return objectStore })
+
+	res, found := NewRegisteredObjectStore("test-in-house")
+	require.True(t, found)
+	assert.Equal(t, objectStore, res)
+
+	_, found = NewRegisteredObjectStore("test-unregistered")
+	assert.False(t, found)
+
+	assert.Contains(t, RegisteredObjectStores(), "test-in-house")
+
+	assert.Panics(t, func() {
+		RegisterObjectStore("test-in-house", func() cloudprovider.ObjectStore { return objectStore })
+	})
+
+	// the manager serves registered object stores with the Ark binary's run-plugin command
+	m := &manager{pluginRegistry: newRegistry(), pluginDir: "/nonexistent"}
+	require.NoError(t, m.registerPlugins())
+
+	info, err := m.pluginRegistry.get(PluginKindObjectStore, "test-in-house")
+	require.NoError(t, err)
+	assert.Equal(t, os.Args[0], info.commandName)
+	assert.Equal(t, []string{"run-plugin", "objectstore", "test-in-house"}, info.commandArgs)
+	assert.Equal(t, []PluginKind{PluginKindObjectStore}, info.kinds)
+
+	_, err = m.pluginRegistry.get(PluginKindBlockStore, "test-in-house")
+	assert.EqualError(t, err, `blockstore plugin "test-in-house" not found`)
+}
@@ -64,5 +64,5 @@ func (r *registry) get(kind PluginKind, name string) (pluginInfo, error) {
 		}
 	}
 
-	return pluginInfo{}, errors.New("plugin not found")
+	return pluginInfo{}, errors.Errorf("%s plugin %q not found", kind, name)
 }
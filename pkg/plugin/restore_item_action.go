@@ -18,12 +18,14 @@ package plugin
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/hashicorp/go-plugin"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -120,6 +122,43 @@ func (c *RestoreItemActionGRPCClient) Execute(item runtime.Unstructured, restore
 	return &updatedItem, warning, nil
 }
 
+// WaitCondition returns the condition the plugin wants the restored item to reach, if any. Plugins
+// built before WaitCondition was added to the protocol don't declare any.
+func (c *RestoreItemActionGRPCClient) WaitCondition(item runtime.Unstructured, arkRestore *api.Restore) (*restore.WaitCondition, error) {
+	itemJSON, err := json.Marshal(item.UnstructuredContent())
+	if err != nil {
+		return nil, err
+	}
+
+	restoreJSON, err := json.Marshal(arkRestore)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &proto.RestoreExecuteRequest{
+		Item:    itemJSON,
+		Restore: restoreJSON,
+	}
+
+	res, err := c.grpcClient.WaitCondition(context.Background(), req)
+	if grpc.Code(err) == codes.Unimplemented {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if res.ConditionType == "" {
+		return nil, nil
+	}
+
+	return &restore.WaitCondition{
+		Type:    res.ConditionType,
+		Status:  res.ConditionStatus,
+		Timeout: time.Duration(res.Timeout),
+	}, nil
+}
+
 func (c *RestoreItemActionGRPCClient) SetLog(log logrus.FieldLogger) {
 	c.log.impl = log
 }
@@ -179,3 +218,37 @@ func (s *RestoreItemActionGRPCServer) Execute(ctx context.Context, req *proto.Re
 		Warning: warnMessage,
 	}, nil
 }
+
+func (s *RestoreItemActionGRPCServer) WaitCondition(ctx context.Context, req *proto.RestoreExecuteRequest) (*proto.RestoreWaitConditionResponse, error) {
+	waitAction, ok := s.impl.(restore.WaitConditionItemAction)
+	if !ok {
+		return &proto.RestoreWaitConditionResponse{}, nil
+	}
+
+	var (
+		item    unstructured.Unstructured
+		restore api.Restore
+	)
+
+	if err := json.Unmarshal(req.Item, &item); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(req.Restore, &restore); err != nil {
+		return nil, err
+	}
+
+	condition, err := waitAction.WaitCondition(&item, &restore)
+	if err != nil {
+		return nil, err
+	}
+	if condition == nil {
+		return &proto.RestoreWaitConditionResponse{}, nil
+	}
+
+	return &proto.RestoreWaitConditionResponse{
+		ConditionType:   condition.Type,
+		ConditionStatus: condition.Status,
+		Timeout:         int64(condition.Timeout),
+	}, nil
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+// containerResourcesAction multiplies the resource requests and limits of the containers
+// in restored pods and pod templates by a restore's spec.containerResourcesFactor, so
+// that workloads fit on a smaller cluster. The backed-up values are recorded in an
+// annotation on each modified item.
+type containerResourcesAction struct {
+	logger logrus.FieldLogger
+}
+
+func NewContainerResourcesAction(logger logrus.FieldLogger) ItemAction {
+	return &containerResourcesAction{
+		logger: logger,
+	}
+}
+
+func (a *containerResourcesAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{
+			"pods",
+			"replicationcontrollers",
+			"deployments.apps",
+			"deployments.extensions",
+			"replicasets.apps",
+			"replicasets.extensions",
+			"statefulsets.apps",
+			"daemonsets.apps",
+			"daemonsets.extensions",
+			"jobs.batch",
+			"cronjobs.batch",
+		},
+	}, nil
+}
+
+func (a *containerResourcesAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	if restore.Spec.ContainerResourcesFactor == nil {
+		return obj, nil, nil
+	}
+	factor := *restore.Spec.ContainerResourcesFactor
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return obj, nil, nil
+	}
+
+	podSpecPath := podSpecPath(item.GetKind())
+	original := make(map[string]interface{})
+
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, found := unstructured.NestedSlice(item.Object, append(podSpecPath, field)...)
+		if !found {
+			continue
+		}
+
+		for i := range containers {
+			container, ok := containers[i].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			resources, found := unstructured.NestedMap(container, "resources")
+			if !found || len(resources) == 0 {
+				continue
+			}
+
+			name, _ := container["name"].(string)
+
+			scaled, err := scaleResources(resources, factor)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "error scaling resources of container %s", name)
+			}
+
+			original[name] = resources
+			if len(scaled) == 0 {
+				delete(container, "resources")
+			} else {
+				container["resources"] = scaled
+			}
+		}
+
+		unstructured.SetNestedSlice(item.Object, containers, append(podSpecPath, field)...)
+	}
+
+	if len(original) == 0 {
+		return item, nil, nil
+	}
+
+	a.logger.Infof("Scaling resources of %d containers in %s %s by %v", len(original), item.GetKind(), item.GetName(), factor)
+
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	addAnnotationIfMissing(item, api.OriginalResourcesAnnotation, string(originalJSON))
+
+	return item, nil, nil
+}
+
+// podSpecPath returns the path to the pod spec within an item of the given kind.
+func podSpecPath(kind string) []string {
+	switch kind {
+	case "Pod":
+		return []string{"spec"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return []string{"spec", "template", "spec"}
+	}
+}
+
+// scaleResources returns a copy of a container's resources with each of its requests
+// and limits multiplied by factor. A factor of 0 drops the requests and limits.
+func scaleResources(resources map[string]interface{}, factor float64) (map[string]interface{}, error) {
+	scaled := make(map[string]interface{})
+
+	for key, value := range resources {
+		if key != "requests" && key != "limits" {
+			scaled[key] = value
+			continue
+		}
+		if factor == 0 {
+			continue
+		}
+
+		quantities, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("resources.%s is not a map", key)
+		}
+
+		scaledQuantities := make(map[string]interface{})
+		for name, value := range quantities {
+			quantity, ok := value.(string)
+			if !ok {
+				return nil, errors.Errorf("resources.%s.%s is not a string", key, name)
+			}
+
+			q, err := resource.ParseQuantity(quantity)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error parsing resources.%s.%s", key, name)
+			}
+			scaledQuantities[name] = scaleQuantity(q, factor).String()
+		}
+		scaled[key] = scaledQuantities
+	}
+
+	return scaled, nil
+}
+
+// scaleQuantity multiplies q by factor, rounded to the nearest thousandth of a unit, which
+// is the finest precision Kubernetes allows. Binary quantities such as memory are rounded
+// up to whole units.
+func scaleQuantity(q resource.Quantity, factor float64) *resource.Quantity {
+	milli := int64(math.Round(float64(q.MilliValue()) * factor))
+
+	switch {
+	case milli%1000 == 0:
+		return resource.NewQuantity(milli/1000, q.Format)
+	case q.Format == resource.BinarySI:
+		return resource.NewQuantity(milli/1000+1, q.Format)
+	default:
+		return resource.NewMilliQuantity(milli, q.Format)
+	}
+}
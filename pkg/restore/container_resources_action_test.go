@@ -0,0 +1,183 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestContainerResourcesActionExecute(t *testing.T) {
+	container := func(name string, resources map[string]interface{}) interface{} {
+		c := map[string]interface{}{"name": name, "image": "nginx"}
+		if resources != nil {
+			c["resources"] = resources
+		}
+		return c
+	}
+	item := func(kind string, annotations map[string]interface{}, path []string, containers ...interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind":     kind,
+			"metadata": map[string]interface{}{"name": "item-1"},
+		}}
+		if annotations != nil {
+			unstructured.SetNestedField(obj.Object, annotations, "metadata", "annotations")
+		}
+		unstructured.SetNestedSlice(obj.Object, containers, append(path, "containers")...)
+		return obj
+	}
+
+	backedUp := map[string]interface{}{
+		"requests": map[string]interface{}{"cpu": "1", "memory": "1Gi"},
+		"limits":   map[string]interface{}{"cpu": "2"},
+	}
+	halved := map[string]interface{}{
+		"requests": map[string]interface{}{"cpu": "500m", "memory": "512Mi"},
+		"limits":   map[string]interface{}{"cpu": "1"},
+	}
+	originalAnnotation := map[string]interface{}{
+		api.OriginalResourcesAnnotation: `{"app":{"limits":{"cpu":"2"},"requests":{"cpu":"1","memory":"1Gi"}}}`,
+	}
+	podTemplate := []string{"spec", "template", "spec"}
+
+	tests := []struct {
+		name     string
+		factor   *float64
+		obj      *unstructured.Unstructured
+		expected *unstructured.Unstructured
+	}{
+		{
+			name:     "items aren't changed when the restore doesn't set a factor",
+			obj:      item("Deployment", nil, podTemplate, container("app", backedUp)),
+			expected: item("Deployment", nil, podTemplate, container("app", backedUp)),
+		},
+		{
+			name:     "deployment's resources are scaled and the originals are recorded",
+			factor:   float64Ptr(0.5),
+			obj:      item("Deployment", nil, podTemplate, container("app", backedUp), container("sidecar", nil)),
+			expected: item("Deployment", originalAnnotation, podTemplate, container("app", halved), container("sidecar", nil)),
+		},
+		{
+			name:     "pod's resources are removed with a factor of 0",
+			factor:   float64Ptr(0),
+			obj:      item("Pod", nil, []string{"spec"}, container("app", backedUp)),
+			expected: item("Pod", originalAnnotation, []string{"spec"}, container("app", nil)),
+		},
+		{
+			name:     "cronjob's job template is scaled",
+			factor:   float64Ptr(0.5),
+			obj:      item("CronJob", nil, []string{"spec", "jobTemplate", "spec", "template", "spec"}, container("app", backedUp)),
+			expected: item("CronJob", originalAnnotation, []string{"spec", "jobTemplate", "spec", "template", "spec"}, container("app", halved)),
+		},
+		{
+			name:     "items without resources aren't annotated",
+			factor:   float64Ptr(0.5),
+			obj:      item("StatefulSet", nil, podTemplate, container("app", nil)),
+			expected: item("StatefulSet", nil, podTemplate, container("app", nil)),
+		},
+		{
+			name:   "resources recorded by an earlier restore are kept",
+			factor: float64Ptr(0.5),
+			obj: item("Deployment", map[string]interface{}{api.OriginalResourcesAnnotation: `{"app":{}}`}, podTemplate,
+				container("app", backedUp)),
+			expected: item("Deployment", map[string]interface{}{api.OriginalResourcesAnnotation: `{"app":{}}`}, podTemplate,
+				container("app", halved)),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			action := NewContainerResourcesAction(arktest.NewLogger())
+			restore := &api.Restore{Spec: api.RestoreSpec{ContainerResourcesFactor: test.factor}}
+
+			res, warning, err := action.Execute(test.obj, restore)
+			require.NoError(t, err)
+			assert.NoError(t, warning)
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestScaleResourcesErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		resources   map[string]interface{}
+		expectedErr string
+	}{
+		{
+			name:        "requests that aren't a map",
+			resources:   map[string]interface{}{"requests": "1"},
+			expectedErr: "resources.requests is not a map",
+		},
+		{
+			name:        "a quantity that isn't a string",
+			resources:   map[string]interface{}{"limits": map[string]interface{}{"cpu": int64(1)}},
+			expectedErr: "resources.limits.cpu is not a string",
+		},
+		{
+			name:        "a quantity that can't be parsed",
+			resources:   map[string]interface{}{"limits": map[string]interface{}{"cpu": "lots"}},
+			expectedErr: "error parsing resources.limits.cpu: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := scaleResources(test.resources, 0.5)
+			assert.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestScaleQuantity(t *testing.T) {
+	tests := []struct {
+		quantity string
+		factor   float64
+		expected string
+	}{
+		{quantity: "1", factor: 0.5, expected: "500m"},
+		{quantity: "250m", factor: 0.5, expected: "125m"},
+		{quantity: "1m", factor: 0.3, expected: "0"},
+		{quantity: "4", factor: 0.25, expected: "1"},
+		{quantity: "1Gi", factor: 0.5, expected: "512Mi"},
+		{quantity: "100Mi", factor: 0.333, expected: "34917581"},
+		{quantity: "100Mi", factor: 0.3, expected: "30Mi"},
+		{quantity: "3", factor: 0.5, expected: "1500m"},
+		{quantity: "3Ki", factor: 0.5, expected: "1536"},
+		{quantity: "1001", factor: 0.5, expected: "500500m"},
+		{quantity: "1", factor: 0.0001, expected: "0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.quantity, func(t *testing.T) {
+			q := resource.MustParse(test.quantity)
+			assert.Equal(t, test.expected, scaleQuantity(q, test.factor).String())
+		})
+	}
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+// betaStorageClassAnnotation is the annotation that was used to set a
+// PersistentVolumeClaim's storage class before spec.storageClassName existed.
+const betaStorageClassAnnotation = "volume.beta.kubernetes.io/storage-class"
+
+// defaultStorageClassAction assigns the restore's spec.defaultStorageClass to restored
+// PersistentVolumeClaims that don't have a storage class, so they're provisioned the same
+// way whatever the target cluster's default StorageClass is. The assigned class is
+// recorded in an annotation.
+type defaultStorageClassAction struct {
+	logger logrus.FieldLogger
+}
+
+func NewDefaultStorageClassAction(logger logrus.FieldLogger) ItemAction {
+	return &defaultStorageClassAction{
+		logger: logger,
+	}
+}
+
+func (a *defaultStorageClassAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"persistentvolumeclaims"},
+	}, nil
+}
+
+func (a *defaultStorageClassAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	storageClass := restore.Spec.DefaultStorageClass
+	if storageClass == "" {
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return obj, nil, nil
+	}
+
+	// an empty storageClassName explicitly requests a volume with no class, so only
+	// claims without one at all are assigned the default
+	if value, found := unstructured.NestedFieldCopy(item.Object, "spec", "storageClassName"); found && value != nil {
+		return item, nil, nil
+	}
+	if _, found := item.GetAnnotations()[betaStorageClassAnnotation]; found {
+		return item, nil, nil
+	}
+
+	// claims that are bound to a volume aren't provisioned, and must keep the volume's class
+	if volumeName, _ := unstructured.NestedString(item.Object, "spec", "volumeName"); volumeName != "" {
+		return item, nil, nil
+	}
+
+	a.logger.Infof("Assigning storage class %s to PersistentVolumeClaim %s", storageClass, item.GetName())
+
+	addAnnotation(item, api.AssignedStorageClassAnnotation, storageClass)
+	unstructured.SetNestedField(item.Object, storageClass, "spec", "storageClassName")
+
+	return item, nil, nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestDefaultStorageClassActionExecute(t *testing.T) {
+	tests := []struct {
+		name                string
+		defaultStorageClass string
+		obj                 *unstructured.Unstructured
+		expected            *unstructured.Unstructured
+	}{
+		{
+			name:     "claims aren't changed when the restore has no default storage class",
+			obj:      NewTestUnstructured().WithName("pvc-1").WithSpec().Unstructured,
+			expected: NewTestUnstructured().WithName("pvc-1").WithSpec().Unstructured,
+		},
+		{
+			name:                "claim without a storage class is assigned the default and it's recorded",
+			defaultStorageClass: "fast",
+			obj:                 NewTestUnstructured().WithName("pvc-1").WithSpec().Unstructured,
+			expected: NewTestUnstructured().WithName("pvc-1").
+				WithMetadataField("annotations", map[string]interface{}{api.AssignedStorageClassAnnotation: "fast"}).
+				WithSpecField("storageClassName", "fast").Unstructured,
+		},
+		{
+			name:                "claim with a null storage class is assigned the default",
+			defaultStorageClass: "fast",
+			obj:                 NewTestUnstructured().WithName("pvc-1").WithSpecField("storageClassName", nil).Unstructured,
+			expected: NewTestUnstructured().WithName("pvc-1").
+				WithMetadataField("annotations", map[string]interface{}{api.AssignedStorageClassAnnotation: "fast"}).
+				WithSpecField("storageClassName", "fast").Unstructured,
+		},
+		{
+			name:                "claim with a storage class keeps it",
+			defaultStorageClass: "fast",
+			obj:                 NewTestUnstructured().WithName("pvc-1").WithSpecField("storageClassName", "slow").Unstructured,
+			expected:            NewTestUnstructured().WithName("pvc-1").WithSpecField("storageClassName", "slow").Unstructured,
+		},
+		{
+			name:                "claim that explicitly has no storage class keeps it",
+			defaultStorageClass: "fast",
+			obj:                 NewTestUnstructured().WithName("pvc-1").WithSpecField("storageClassName", "").Unstructured,
+			expected:            NewTestUnstructured().WithName("pvc-1").WithSpecField("storageClassName", "").Unstructured,
+		},
+		{
+			name:                "claim with the beta storage class annotation keeps it",
+			defaultStorageClass: "fast",
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithMetadataField("annotations", map[string]interface{}{betaStorageClassAnnotation: "slow"}).
+				WithSpec().Unstructured,
+			expected: NewTestUnstructured().WithName("pvc-1").
+				WithMetadataField("annotations", map[string]interface{}{betaStorageClassAnnotation: "slow"}).
+				WithSpec().Unstructured,
+		},
+		{
+			name:                "claim that's bound to a volume isn't changed",
+			defaultStorageClass: "fast",
+			obj:                 NewTestUnstructured().WithName("pvc-1").WithSpecField("volumeName", "pv-1").Unstructured,
+			expected:            NewTestUnstructured().WithName("pvc-1").WithSpecField("volumeName", "pv-1").Unstructured,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			action := NewDefaultStorageClassAction(arktest.NewLogger())
+			restore := &api.Restore{Spec: api.RestoreSpec{DefaultStorageClass: test.defaultStorageClass}}
+
+			res, warning, err := action.Execute(test.obj, restore)
+			require.NoError(t, err)
+			assert.NoError(t, warning)
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+// imageRegistryAction rewrites the images of the containers in restored pods and pod
+// templates according to a restore's spec.imageRegistryMapping, so that workloads pull
+// their images from a registry reachable from the cluster being restored into. The
+// backed-up images are recorded in an annotation on each modified item.
+type imageRegistryAction struct {
+	logger logrus.FieldLogger
+}
+
+func NewImageRegistryAction(logger logrus.FieldLogger) ItemAction {
+	return &imageRegistryAction{
+		logger: logger,
+	}
+}
+
+func (a *imageRegistryAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{
+			"pods",
+			"replicationcontrollers",
+			"deployments.apps",
+			"deployments.extensions",
+			"replicasets.apps",
+			"replicasets.extensions",
+			"statefulsets.apps",
+			"daemonsets.apps",
+			"daemonsets.extensions",
+			"jobs.batch",
+			"cronjobs.batch",
+		},
+	}, nil
+}
+
+func (a *imageRegistryAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	if len(restore.Spec.ImageRegistryMapping) == 0 {
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return obj, nil, nil
+	}
+
+	log := a.logger.WithField("item", item.GetKind()+" "+item.GetName())
+	podSpecPath := podSpecPath(item.GetKind())
+	original := make(map[string]string)
+
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, found := unstructured.NestedSlice(item.Object, append(podSpecPath, field)...)
+		if !found {
+			continue
+		}
+
+		for i := range containers {
+			container, ok := containers[i].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			image, _ := container["image"].(string)
+			if image == "" {
+				continue
+			}
+
+			rewritten, mapped := rewriteImageRegistry(image, restore.Spec.ImageRegistryMapping)
+			if !mapped {
+				log.Debugf("Image %s doesn't match any registry mapping, leaving it unchanged", image)
+				continue
+			}
+
+			name, _ := container["name"].(string)
+			original[name] = image
+			container["image"] = rewritten
+		}
+
+		unstructured.SetNestedSlice(item.Object, containers, append(podSpecPath, field)...)
+	}
+
+	if len(original) == 0 {
+		return item, nil, nil
+	}
+
+	log.Infof("Rewriting the images of %d containers", len(original))
+
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	addAnnotationIfMissing(item, api.OriginalImagesAnnotation, string(originalJSON))
+
+	return item, nil, nil
+}
+
+// rewriteImageRegistry replaces the longest registry prefix in mapping that image starts
+// with, followed by a "/", with the prefix it's mapped to. It returns false if no prefix
+// matches.
+func rewriteImageRegistry(image string, mapping map[string]string) (string, bool) {
+	var matched string
+	for prefix := range mapping {
+		if strings.HasPrefix(image, prefix+"/") && len(prefix) > len(matched) {
+			matched = prefix
+		}
+	}
+	if matched == "" {
+		return image, false
+	}
+
+	return mapping[matched] + strings.TrimPrefix(image, matched), true
+}
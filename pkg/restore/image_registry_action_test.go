@@ -0,0 +1,120 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestImageRegistryActionExecute(t *testing.T) {
+	container := func(name, image string) interface{} {
+		return map[string]interface{}{"name": name, "image": image}
+	}
+	item := func(kind string, annotations map[string]interface{}, path []string, initContainers []interface{}, containers ...interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind":     kind,
+			"metadata": map[string]interface{}{"name": "item-1"},
+		}}
+		if annotations != nil {
+			unstructured.SetNestedField(obj.Object, annotations, "metadata", "annotations")
+		}
+		if initContainers != nil {
+			unstructured.SetNestedSlice(obj.Object, initContainers, append(path, "initContainers")...)
+		}
+		unstructured.SetNestedSlice(obj.Object, containers, append(path, "containers")...)
+		return obj
+	}
+
+	mapping := map[string]string{
+		"registry.old.example.com":      "registry.new.example.com",
+		"registry.old.example.com/team": "team.example.com/images",
+	}
+	podTemplate := []string{"spec", "template", "spec"}
+
+	tests := []struct {
+		name     string
+		mapping  map[string]string
+		obj      *unstructured.Unstructured
+		expected *unstructured.Unstructured
+	}{
+		{
+			name:     "items aren't changed when the restore doesn't set a mapping",
+			obj:      item("Deployment", nil, podTemplate, nil, container("app", "registry.old.example.com/app:v1")),
+			expected: item("Deployment", nil, podTemplate, nil, container("app", "registry.old.example.com/app:v1")),
+		},
+		{
+			name:    "deployment's init containers and containers are rewritten and the originals are recorded",
+			mapping: mapping,
+			obj: item("Deployment", nil, podTemplate,
+				[]interface{}{container("init", "registry.old.example.com/init:v1")},
+				container("app", "registry.old.example.com/app:v1"), container("sidecar", "nginx")),
+			expected: item("Deployment",
+				map[string]interface{}{api.OriginalImagesAnnotation: `{"app":"registry.old.example.com/app:v1","init":"registry.old.example.com/init:v1"}`},
+				podTemplate,
+				[]interface{}{container("init", "registry.new.example.com/init:v1")},
+				container("app", "registry.new.example.com/app:v1"), container("sidecar", "nginx")),
+		},
+		{
+			name:    "the longest matching prefix is used",
+			mapping: mapping,
+			obj:     item("Pod", nil, []string{"spec"}, nil, container("app", "registry.old.example.com/team/app@sha256:abc")),
+			expected: item("Pod", map[string]interface{}{api.OriginalImagesAnnotation: `{"app":"registry.old.example.com/team/app@sha256:abc"}`}, []string{"spec"}, nil,
+				container("app", "team.example.com/images/app@sha256:abc")),
+		},
+		{
+			name:    "cronjob's job template is rewritten",
+			mapping: mapping,
+			obj:     item("CronJob", nil, []string{"spec", "jobTemplate", "spec", "template", "spec"}, nil, container("app", "registry.old.example.com/app")),
+			expected: item("CronJob", map[string]interface{}{api.OriginalImagesAnnotation: `{"app":"registry.old.example.com/app"}`}, []string{"spec", "jobTemplate", "spec", "template", "spec"}, nil,
+				container("app", "registry.new.example.com/app")),
+		},
+		{
+			name:     "prefixes only match whole path segments",
+			mapping:  mapping,
+			obj:      item("StatefulSet", nil, podTemplate, nil, container("app", "registry.old.example.com.au/app:v1")),
+			expected: item("StatefulSet", nil, podTemplate, nil, container("app", "registry.old.example.com.au/app:v1")),
+		},
+		{
+			name:    "images recorded by an earlier restore are kept",
+			mapping: mapping,
+			obj: item("Deployment", map[string]interface{}{api.OriginalImagesAnnotation: `{"app":"registry.older.example.com/app:v1"}`}, podTemplate, nil,
+				container("app", "registry.old.example.com/app:v1")),
+			expected: item("Deployment", map[string]interface{}{api.OriginalImagesAnnotation: `{"app":"registry.older.example.com/app:v1"}`}, podTemplate, nil,
+				container("app", "registry.new.example.com/app:v1")),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			action := NewImageRegistryAction(arktest.NewLogger())
+			restore := &api.Restore{Spec: api.RestoreSpec{ImageRegistryMapping: test.mapping}}
+
+			res, warning, err := action.Execute(test.obj, restore)
+			require.NoError(t, err)
+			assert.NoError(t, warning)
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
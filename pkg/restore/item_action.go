@@ -17,6 +17,8 @@ limitations under the License.
 package restore
 
 import (
+	"time"
+
 	"k8s.io/apimachinery/pkg/runtime"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
@@ -37,6 +39,28 @@ type ItemAction interface {
 	Execute(obj runtime.Unstructured, restore *api.Restore) (res runtime.Unstructured, warning error, err error)
 }
 
+// WaitConditionItemAction is an ItemAction that also declares a condition the restored item must
+// reach before the restore moves on to the next item. It's optional: ItemActions that don't
+// implement it are never waited for.
+type WaitConditionItemAction interface {
+	ItemAction
+
+	// WaitCondition is called with the item returned by Execute, and returns the condition to wait
+	// for once the item has been created, or nil if the restore shouldn't wait for it.
+	WaitCondition(obj runtime.Unstructured, restore *api.Restore) (*WaitCondition, error)
+}
+
+// WaitCondition is a condition in an item's status.conditions that the restore waits for.
+type WaitCondition struct {
+	// Type is the type of the condition, e.g. "Ready".
+	Type string
+	// Status is the status the condition must have. It defaults to "True".
+	Status string
+	// Timeout is how long to wait for the condition before recording a warning and
+	// moving on. It defaults to 30 seconds.
+	Timeout time.Duration
+}
+
 // ResourceSelector is a collection of included/excluded namespaces,
 // included/excluded resources, and a label-selector that can be used
 // to match a set of items from a cluster.
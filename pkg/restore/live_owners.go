@@ -0,0 +1,118 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+// liveOwner returns the first of obj's owners, by its owner references, that
+// existed in namespace before the restore and isn't being deleted, so its
+// controller will manage obj itself. Owners are looked up by kind and name, since a restored owner
+// has a new UID. Whether each owner is live is only looked up once per restore.
+func (ctx *context) liveOwner(obj *unstructured.Unstructured, namespace string) (metav1.OwnerReference, bool) {
+	for _, ref := range obj.GetOwnerReferences() {
+		key := fmt.Sprintf("%s/%s/%s/%s", ref.APIVersion, ref.Kind, namespace, ref.Name)
+
+		live, checked := ctx.liveOwners[key]
+		if !checked {
+			live = ctx.isLiveOwner(ref, namespace)
+
+			if ctx.liveOwners == nil {
+				ctx.liveOwners = make(map[string]bool)
+			}
+			ctx.liveOwners[key] = live
+		}
+
+		if live {
+			return ref, true
+		}
+	}
+
+	return metav1.OwnerReference{}, false
+}
+
+// isLiveOwner returns whether the owner ref refers to exists in namespace, wasn't
+// created by this restore, and isn't being deleted. Owners that can't be looked up
+// aren't live. Owners this restore created don't manage their restored items yet,
+// so those items are restored with them.
+func (ctx *context) isLiveOwner(ref metav1.OwnerReference, namespace string) bool {
+	gv, resource, err := ctx.resourceForOwner(ref)
+	if err != nil {
+		ctx.infof("Unable to find the resource of owner %s %s: %v", ref.Kind, ref.Name, err)
+		return false
+	}
+
+	if !resource.Namespaced {
+		namespace = ""
+	}
+
+	resourceClient, err := ctx.dynamicFactory.ClientForGroupVersionResource(gv, resource, namespace)
+	if err != nil {
+		ctx.infof("Unable to get a client for owner %s %s: %v", ref.Kind, ref.Name, err)
+		return false
+	}
+
+	owner, err := resourceClient.Get(ref.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			ctx.infof("Unable to get owner %s %s: %v", ref.Kind, ref.Name, err)
+		}
+		return false
+	}
+
+	if owner.GetLabels()[api.RestoreLabelKey] == ctx.restore.Name {
+		return false
+	}
+
+	return owner.GetDeletionTimestamp() == nil
+}
+
+// resourceForOwner returns the group version and resource of the kind that ref refers
+// to, in the group of its API version.
+func (ctx *context) resourceForOwner(ref metav1.OwnerReference) (schema.GroupVersion, metav1.APIResource, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return schema.GroupVersion{}, metav1.APIResource{}, errors.WithStack(err)
+	}
+
+	for _, resourceList := range ctx.discoveryHelper.Resources() {
+		listGV, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil || listGV.Group != gv.Group {
+			continue
+		}
+
+		for _, resource := range resourceList.APIResources {
+			// subresources share their parent resource's kind
+			if resource.Kind == ref.Kind && !strings.Contains(resource.Name, "/") {
+				return listGV, resource, nil
+			}
+		}
+	}
+
+	return schema.GroupVersion{}, metav1.APIResource{}, errors.Errorf("no resource found for kind %s in API version %s", ref.Kind, ref.APIVersion)
+}
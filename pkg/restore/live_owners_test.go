@@ -0,0 +1,112 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestRestoreResourceSkipsLiveOwnedItems(t *testing.T) {
+	ownedBy := func(name, owner string) *testConfigMap {
+		cm := newNamedTestConfigMap(name)
+		cm.OwnerReferences = append(cm.OwnerReferences, metav1.OwnerReference{APIVersion: "example.com/v1", Kind: "Widget", Name: owner})
+		return cm
+	}
+
+	// widget-1 is live, widget-2 doesn't exist, widget-3 is being deleted, widget-4
+	// was created by this restore and widget-5 by an earlier one
+	widgetsResource := metav1.APIResource{Name: "widgets", Kind: "Widget", Namespaced: true}
+	helper := arktest.NewFakeDiscoveryHelper(false, nil)
+	helper.ResourceList = []*metav1.APIResourceList{
+		{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{widgetsResource}},
+	}
+
+	widgetClient := &arktest.FakeDynamicClient{}
+	defer widgetClient.AssertExpectations(t)
+	widgetGroupResource := schema.GroupResource{Group: "example.com", Resource: "widgets"}
+	widgetClient.On("Get", "widget-1", metav1.GetOptions{}).Return(&unstructured.Unstructured{}, nil).Once()
+	widgetClient.On("Get", "widget-2", metav1.GetOptions{}).Return(&unstructured.Unstructured{}, apierrors.NewNotFound(widgetGroupResource, "widget-2"))
+	deleting := &unstructured.Unstructured{}
+	now := metav1.Now()
+	deleting.SetDeletionTimestamp(&now)
+	widgetClient.On("Get", "widget-3", metav1.GetOptions{}).Return(deleting, nil)
+	restored := &unstructured.Unstructured{}
+	restored.SetLabels(map[string]string{api.RestoreLabelKey: "my-restore"})
+	widgetClient.On("Get", "widget-4", metav1.GetOptions{}).Return(restored, nil)
+	restoredEarlier := &unstructured.Unstructured{}
+	restoredEarlier.SetLabels(map[string]string{api.RestoreLabelKey: "earlier-restore"})
+	widgetClient.On("Get", "widget-5", metav1.GetOptions{}).Return(restoredEarlier, nil)
+
+	resourceClient := &arktest.FakeDynamicClient{}
+	defer resourceClient.AssertExpectations(t)
+	resourceClient.On("Create", mock.Anything).Return(&unstructured.Unstructured{}, nil).Times(3)
+
+	dynamicFactory := &arktest.FakeDynamicFactory{}
+	defer dynamicFactory.AssertExpectations(t)
+	dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Group: "example.com", Version: "v1"}, widgetsResource, "ns-1").Return(widgetClient, nil)
+	dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Group: "", Version: "v1"}, metav1.APIResource{Name: "configmaps", Namespaced: true}, "ns-1").Return(resourceClient, nil)
+
+	ctx := &context{
+		dynamicFactory: dynamicFactory,
+		fileSystem: newFakeFileSystem().
+			WithFile("configmaps/cm-1.json", ownedBy("cm-1", "widget-1").ToJSON()).
+			WithFile("configmaps/cm-2.json", ownedBy("cm-2", "widget-2").ToJSON()).
+			WithFile("configmaps/cm-3.json", ownedBy("cm-3", "widget-1").ToJSON()).
+			WithFile("configmaps/cm-4.json", ownedBy("cm-4", "widget-3").ToJSON()).
+			WithFile("configmaps/cm-5.json", ownedBy("cm-5", "widget-4").ToJSON()).
+			WithFile("configmaps/cm-6.json", ownedBy("cm-6", "widget-5").ToJSON()),
+		selector: labels.NewSelector(),
+		restore: &api.Restore{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: api.DefaultNamespace,
+				Name:      "my-restore",
+			},
+			Spec: api.RestoreSpec{
+				SkipLiveOwnedItems: true,
+			},
+		},
+		backup:          &api.Backup{},
+		logger:          arktest.NewLogger(),
+		discoveryHelper: helper,
+	}
+
+	warnings, errs := ctx.restoreResource("configmaps", "ns-1", "configmaps")
+
+	expectedWarnings := api.RestoreResult{
+		Namespaces: map[string][]string{
+			"ns-1": {
+				"skipped configmaps cm-1 because its owner, Widget widget-1, already exists",
+				"skipped configmaps cm-3 because its owner, Widget widget-1, already exists",
+				"skipped configmaps cm-6 because its owner, Widget widget-5, already exists",
+			},
+		},
+	}
+	assert.Equal(t, expectedWarnings, warnings)
+	assert.Equal(t, api.RestoreResult{}, errs)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"sync"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+// Progress tracks the number of items a running restore has to restore and has
+// restored. It's safe for concurrent use, so it can be read while the restore
+// runs. A nil Progress tracks nothing.
+type Progress struct {
+	lock              sync.Mutex
+	totalItems        int
+	itemsRestored     int
+	skippedNamespaces int
+
+	// batchSize and checkpointFunc are set by CheckpointEvery.
+	batchSize      int
+	checkpointFunc func(api.RestoreProgress, api.RestoreCheckpoint)
+	itemsInBatch   int
+	lastItem       *api.RestoreCheckpoint
+}
+
+// CheckpointEvery makes the restore call fn after every batch of size items, with
+// its progress and a checkpoint identifying the batch's last item. fn is called
+// from the restore's goroutine before it starts on the next item, so the restore
+// waits for fn to record the checkpoint.
+func (p *Progress) CheckpointEvery(size int, fn func(api.RestoreProgress, api.RestoreCheckpoint)) {
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.batchSize = size
+	p.checkpointFunc = fn
+}
+
+// Get returns the restore's progress so far.
+func (p *Progress) Get() api.RestoreProgress {
+	if p == nil {
+		return api.RestoreProgress{}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return api.RestoreProgress{
+		TotalItems:        p.totalItems,
+		ItemsRestored:     p.itemsRestored,
+		SkippedNamespaces: p.skippedNamespaces,
+	}
+}
+
+// setTotal records the number of items to restore, as returned by count. count isn't
+// called when progress isn't being tracked, since counting the items reads the whole backup.
+func (p *Progress) setTotal(count func() int) {
+	if p == nil {
+		return
+	}
+
+	n := count()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.totalItems = n
+}
+
+// setSkippedNamespaces records the number of namespaces that aren't restored because they
+// don't match the restore's namespace selector.
+func (p *Progress) setSkippedNamespaces(n int) {
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.skippedNamespaces = n
+}
+
+// itemStarted records that the item identified by checkpoint, which has the
+// resource, namespace and name of the item, is being processed. If the items
+// before it complete a batch, the batch is checkpointed first.
+func (p *Progress) itemStarted(checkpoint api.RestoreCheckpoint) {
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	if p.batchSize > 0 && p.itemsInBatch >= p.batchSize && p.lastItem != nil {
+		progress := api.RestoreProgress{TotalItems: p.totalItems, ItemsRestored: p.itemsRestored, SkippedNamespaces: p.skippedNamespaces}
+		last, fn := *p.lastItem, p.checkpointFunc
+		p.itemsInBatch = 0
+
+		// not called with the lock held, so the progress can be read meanwhile
+		p.lock.Unlock()
+		fn(progress, last)
+		p.lock.Lock()
+	}
+	defer p.lock.Unlock()
+
+	p.itemsRestored++
+	p.itemsInBatch++
+	checkpoint.ItemsRestored = p.itemsRestored
+	p.lastItem = &checkpoint
+}
+
+// itemRestored records that an item has been processed.
+func (p *Progress) itemRestored() {
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.itemsRestored++
+}
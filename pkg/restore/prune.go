@@ -0,0 +1,142 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+// backedUpItems maps fully-qualified resources, as returned by schema.GroupResource's
+// String, to the names of their items in the backup, including any renames, by the
+// namespace they're restored into.
+type backedUpItems map[string]map[string]sets.String
+
+// recordBackedUpItems records the names of the items in files, the backup's items of
+// resource in namespace, so they aren't pruned.
+func (ctx *context) recordBackedUpItems(resource, namespace string, files []os.FileInfo) {
+	if ctx.backedUpItems == nil {
+		ctx.backedUpItems = make(backedUpItems)
+	}
+	if ctx.backedUpItems[resource] == nil {
+		ctx.backedUpItems[resource] = make(map[string]sets.String)
+	}
+
+	names := ctx.backedUpItems[resource][namespace]
+	if names == nil {
+		names = sets.NewString()
+		ctx.backedUpItems[resource][namespace] = names
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".json")
+		names.Insert(name)
+
+		if renamed, found := ctx.renames.names[resource][name]; found {
+			names.Insert(renamed)
+		}
+	}
+}
+
+// pruneLeftoverItems deletes the items in namespaces, of the resources the restore
+// includes, that have the ark-restore label but aren't in the backup, and returns
+// an error for each one that couldn't be deleted. Only resources the backup has items
+// of in a namespace are pruned in it, so that the items of resources the backup didn't
+// include, e.g. restored from a different backup, are kept.
+func (ctx *context) pruneLeftoverItems(namespaces []string) api.RestoreResult {
+	var errs api.RestoreResult
+
+	// only items an earlier restore created, e.g. from an older backup, are pruned
+	listOptions := metav1.ListOptions{LabelSelector: api.RestoreLabelKey}
+
+	for _, groupResource := range ctx.prioritizedResources {
+		resource := groupResource.String()
+
+		gvr, apiResource, err := ctx.discoveryHelper.ResourceFor(groupResource.WithVersion(""))
+		if err != nil {
+			continue
+		}
+		if !apiResource.Namespaced || !sets.NewString(apiResource.Verbs...).HasAll("list", "delete") {
+			continue
+		}
+
+		for _, namespace := range namespaces {
+			if _, found := ctx.backedUpItems[resource][namespace]; !found {
+				continue
+			}
+
+			resourceClient, err := ctx.dynamicFactory.ClientForGroupVersionResource(gvr.GroupVersion(), apiResource, namespace)
+			if err != nil {
+				addToResult(&errs, namespace, fmt.Errorf("error getting resource client for namespace %q, resource %q to prune: %v", namespace, resource, err))
+				continue
+			}
+
+			res, err := resourceClient.List(listOptions)
+			if err != nil {
+				addToResult(&errs, namespace, fmt.Errorf("error listing %s to prune: %v", resource, err))
+				continue
+			}
+
+			list, ok := res.(*unstructured.UnstructuredList)
+			if !ok {
+				addToResult(&errs, namespace, fmt.Errorf("error listing %s to prune: unexpected type %T", resource, res))
+				continue
+			}
+
+			for _, item := range list.Items {
+				if !ctx.isPrunable(resource, namespace, &item) {
+					continue
+				}
+
+				ctx.infof("Pruning %s %s/%s, restored by %s, because it isn't in the backup", resource, namespace, item.GetName(), item.GetLabels()[api.RestoreLabelKey])
+				if err := resourceClient.Delete(item.GetName(), &metav1.DeleteOptions{}); err != nil {
+					addToResult(&errs, namespace, fmt.Errorf("error pruning %s %s: %v", resource, item.GetName(), err))
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// isPrunable returns whether item, of resource in namespace, can be pruned: it isn't in
+// the backup, wasn't created by this restore, is in the scope of the restore's label
+// selector, and isn't managed by a controller.
+func (ctx *context) isPrunable(resource, namespace string, item *unstructured.Unstructured) bool {
+	if ctx.backedUpItems[resource][namespace].Has(item.GetName()) {
+		return false
+	}
+
+	itemLabels := item.GetLabels()
+	if itemLabels[api.RestoreLabelKey] == "" || itemLabels[api.RestoreLabelKey] == ctx.restore.Name {
+		return false
+	}
+
+	if !ctx.selector.Matches(labels.Set(itemLabels)) {
+		return false
+	}
+
+	return !hasControllerOwner(item.GetOwnerReferences())
+}
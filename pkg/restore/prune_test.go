@@ -0,0 +1,105 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestPruneLeftoverItems(t *testing.T) {
+	appLabels := map[string]string{"app": "foo"}
+
+	fileSystem := newFakeFileSystem().
+		WithFile("bak/resources/configmaps/namespaces/ns-1/cm-1.json", newNamedTestConfigMap("cm-1").ToJSON()).
+		WithFile("bak/resources/configmaps/namespaces/ns-1/cm-2.json", newNamedTestConfigMap("cm-2").ToJSON())
+	files, err := fileSystem.ReadDir("bak/resources/configmaps/namespaces/ns-1")
+	require.NoError(t, err)
+
+	helper := arktest.NewFakeDiscoveryHelper(false, map[schema.GroupVersionResource]schema.GroupVersionResource{
+		{Resource: "configmaps"}:        {Version: "v1", Resource: "configmaps"},
+		{Resource: "persistentvolumes"}: {Version: "v1", Resource: "persistentvolumes"},
+		{Resource: "secrets"}:           {Version: "v1", Resource: "secrets"},
+	})
+	configMapsResource := metav1.APIResource{Name: "configmaps", Namespaced: true, Verbs: []string{"create", "list", "delete"}}
+	helper.ResourceList = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				configMapsResource,
+				{Name: "persistentvolumes", Verbs: []string{"create", "list", "delete"}},
+				{Name: "secrets", Namespaced: true, Verbs: []string{"create", "list", "delete"}},
+			},
+		},
+	}
+
+	// cm-1 is in the backup, cm-2 is in the backup and is renamed, cm-4 was created by
+	// this restore, cm-5 doesn't match the restore's label selector, cm-6 has a
+	// controller owner, and cm-7 wasn't restored
+	items := toUnstructured(
+		newNamedTestConfigMap("cm-1").WithLabels(map[string]string{"app": "foo"}).WithArkLabel("restore-1").ConfigMap,
+		newNamedTestConfigMap("cm-2-renamed").WithLabels(map[string]string{"app": "foo"}).WithArkLabel("restore-1").ConfigMap,
+		newNamedTestConfigMap("cm-3").WithLabels(map[string]string{"app": "foo"}).WithArkLabel("restore-1").ConfigMap,
+		newNamedTestConfigMap("cm-4").WithLabels(map[string]string{"app": "foo"}).WithArkLabel("restore-2").ConfigMap,
+		newNamedTestConfigMap("cm-5").WithLabels(map[string]string{"app": "bar"}).WithArkLabel("restore-1").ConfigMap,
+		newNamedTestConfigMap("cm-6").WithLabels(map[string]string{"app": "foo"}).WithArkLabel("restore-1").WithControllerOwner().ConfigMap,
+		newNamedTestConfigMap("cm-7").WithLabels(map[string]string{"app": "foo"}).ConfigMap,
+		newNamedTestConfigMap("cm-8").WithLabels(map[string]string{"app": "foo"}).WithArkLabel("restore-1").ConfigMap,
+	)
+
+	resourceClient := &arktest.FakeDynamicClient{}
+	resourceClient.On("List", metav1.ListOptions{LabelSelector: api.RestoreLabelKey}).Return(&unstructured.UnstructuredList{Items: items}, nil)
+	resourceClient.On("Delete", "cm-3", &metav1.DeleteOptions{}).Return(nil)
+	resourceClient.On("Delete", "cm-8", &metav1.DeleteOptions{}).Return(errors.New("forbidden"))
+
+	dynamicFactory := &arktest.FakeDynamicFactory{}
+	dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Version: "v1"}, configMapsResource, "ns-1").Return(resourceClient, nil)
+
+	ctx := &context{
+		restore:              arktest.NewTestRestore(api.DefaultNamespace, "restore-2", api.RestorePhaseInProgress).Restore,
+		prioritizedResources: []schema.GroupResource{{Resource: "persistentvolumes"}, {Resource: "configmaps"}, {Resource: "secrets"}},
+		selector:             labels.SelectorFromSet(appLabels),
+		logger:               arktest.NewLogger(),
+		dynamicFactory:       dynamicFactory,
+		renames:              resourceRenames{names: map[string]map[string]string{"configmaps": {"cm-2": "cm-2-renamed"}}},
+		discoveryHelper:      helper,
+	}
+	ctx.recordBackedUpItems("configmaps", "ns-1", files)
+
+	expected := api.RestoreResult{
+		Namespaces: map[string][]string{
+			"ns-1": {"error pruning configmaps cm-8: forbidden"},
+		},
+	}
+
+	// secrets aren't in the backup, and ns-2 has no configmaps in it, so neither is
+	// listed or pruned
+	assert.Equal(t, expected, ctx.pruneLeftoverItems([]string{"ns-1", "ns-2"}))
+	resourceClient.AssertExpectations(t)
+	dynamicFactory.AssertExpectations(t)
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/discovery"
+)
+
+// ParseResourceRename splits a key of a restore's resourceRenames, "<resource>/<name>",
+// into the resource and the name of the item to rename.
+func ParseResourceRename(key string) (string, string, error) {
+	i := strings.LastIndex(key, "/")
+	if i <= 0 || i == len(key)-1 {
+		return "", "", errors.Errorf("%q must be in the form <resource>/<name>", key)
+	}
+
+	return key[:i], key[i+1:], nil
+}
+
+// resourceRenames holds the new names of the items a restore renames.
+type resourceRenames struct {
+	// names maps fully-qualified resources, as returned by schema.GroupResource's
+	// String, to the new names of their items, keyed by the items' names in the backup.
+	names map[string]map[string]string
+
+	// kinds maps the kinds of the renamed resources to the resources, so owner
+	// references to renamed items can be updated.
+	kinds map[schema.GroupKind]string
+}
+
+// volumeReferences maps the resources that pods' volumes refer to by name to the
+// field of the volume that holds the name.
+var volumeReferences = map[string][]string{
+	"persistentvolumeclaims": {"persistentVolumeClaim", "claimName"},
+	"configmaps":             {"configMap", "name"},
+	"secrets":                {"secret", "secretName"},
+}
+
+// envFromReferences maps the resources that containers' envFrom sources refer to by
+// name to the field of the source that holds the name.
+var envFromReferences = map[string][]string{
+	"configmaps": {"configMapRef", "name"},
+	"secrets":    {"secretRef", "name"},
+}
+
+// envReferences maps the resources that containers' env vars refer to by name to the
+// field of the var that holds the name.
+var envReferences = map[string][]string{
+	"configmaps": {"valueFrom", "configMapKeyRef", "name"},
+	"secrets":    {"valueFrom", "secretKeyRef", "name"},
+}
+
+// imagePullSecretReferences maps the resources that pod specs' image pull secrets refer
+// to by name to the field of the image pull secret that holds the name.
+var imagePullSecretReferences = map[string][]string{
+	"secrets": {"name"},
+}
+
+// resolveRenames resolves the resources of renames, returning an error if two items of
+// the same resource are renamed to the same name. Resources that don't exist in the
+// cluster are skipped, since none of their items can be restored.
+func resolveRenames(renames map[string]string, helper discovery.Helper) (resourceRenames, error) {
+	resolved := resourceRenames{
+		names: make(map[string]map[string]string),
+		kinds: make(map[schema.GroupKind]string),
+	}
+	// resource/new name -> the key renamed to it
+	targets := make(map[string]string)
+
+	for _, key := range sets.StringKeySet(renames).List() {
+		resource, name, err := ParseResourceRename(key)
+		if err != nil {
+			return resourceRenames{}, err
+		}
+
+		gvr, apiResource, err := helper.ResourceFor(schema.ParseGroupResource(resource).WithVersion(""))
+		if err != nil {
+			continue
+		}
+		gr := gvr.GroupResource()
+		groupResource := gr.String()
+
+		target := groupResource + "/" + renames[key]
+		if other, found := targets[target]; found {
+			return resourceRenames{}, errors.Errorf("%s and %s are both renamed to %s", other, key, renames[key])
+		}
+		targets[target] = key
+
+		if resolved.names[groupResource] == nil {
+			resolved.names[groupResource] = make(map[string]string)
+		}
+		resolved.names[groupResource][name] = renames[key]
+
+		if apiResource.Kind != "" {
+			resolved.kinds[schema.GroupKind{Group: gr.Group, Kind: apiResource.Kind}] = groupResource
+		}
+	}
+
+	return resolved, nil
+}
+
+// ValidateResourceRenames returns an error if renames, a restore's resourceRenames,
+// can't be resolved or rename two items of the same resource to the same name.
+// Resources are resolved first, so the same resource named in different forms is
+// caught.
+func ValidateResourceRenames(renames map[string]string, helper discovery.Helper) error {
+	_, err := resolveRenames(renames, helper)
+	return err
+}
+
+// rename sets the name of obj, an item of groupResource, to its new name, if it's renamed,
+// recording its original name in an annotation, and returns whether it was renamed.
+func (r resourceRenames) rename(groupResource string, obj *unstructured.Unstructured) bool {
+	newName, found := r.names[groupResource][obj.GetName()]
+	if !found {
+		return false
+	}
+
+	addAnnotationIfMissing(obj, api.OriginalNameAnnotation, obj.GetName())
+	obj.SetName(newName)
+
+	return true
+}
+
+// renameReferences updates the references of obj to renamed items to refer to their
+// new names, and returns the number of references updated. The references updated are
+// obj's owner references and, if obj has a pod spec, its volumes, its containers' envFrom
+// sources and env vars, its image pull secrets, and its service account.
+func (r resourceRenames) renameReferences(obj *unstructured.Unstructured) int {
+	renamed := r.renameOwnerReferences(obj)
+
+	path := podSpecPath(obj.GetKind())
+	podSpec, found := unstructured.NestedMap(obj.Object, path...)
+	if !found {
+		return renamed
+	}
+
+	n := r.renamePodSpecReferences(podSpec)
+	if n > 0 {
+		unstructured.SetNestedMap(obj.Object, podSpec, path...)
+	}
+
+	return renamed + n
+}
+
+// renameOwnerReferences updates obj's owner references to renamed items, and returns
+// the number updated.
+func (r resourceRenames) renameOwnerReferences(obj *unstructured.Unstructured) int {
+	refs := obj.GetOwnerReferences()
+
+	var renamed int
+	for i := range refs {
+		gv, err := schema.ParseGroupVersion(refs[i].APIVersion)
+		if err != nil {
+			continue
+		}
+
+		resource, found := r.kinds[schema.GroupKind{Group: gv.Group, Kind: refs[i].Kind}]
+		if !found {
+			continue
+		}
+
+		if newName, found := r.names[resource][refs[i].Name]; found {
+			refs[i].Name = newName
+			renamed++
+		}
+	}
+
+	if renamed > 0 {
+		obj.SetOwnerReferences(refs)
+	}
+
+	return renamed
+}
+
+// renamePodSpecReferences updates the references of podSpec to renamed items, and
+// returns the number updated.
+func (r resourceRenames) renamePodSpecReferences(podSpec map[string]interface{}) int {
+	renamed := r.renameSliceReferences(podSpec, []string{"volumes"}, volumeReferences)
+
+	for _, containers := range []string{"initContainers", "containers"} {
+		items, _ := podSpec[containers].([]interface{})
+		for _, item := range items {
+			container, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			renamed += r.renameSliceReferences(container, []string{"envFrom"}, envFromReferences)
+			renamed += r.renameSliceReferences(container, []string{"env"}, envReferences)
+		}
+	}
+
+	renamed += r.renameSliceReferences(podSpec, []string{"imagePullSecrets"}, imagePullSecretReferences)
+
+	// serviceAccount is the deprecated form of serviceAccountName
+	for _, field := range []string{"serviceAccountName", "serviceAccount"} {
+		if name, found := unstructured.NestedString(podSpec, field); found {
+			if newName, found := r.names["serviceaccounts"][name]; found {
+				podSpec[field] = newName
+				renamed++
+			}
+		}
+	}
+
+	return renamed
+}
+
+// renameSliceReferences updates the items of the slice at path in obj that refer to
+// renamed items, by the fields in references, and returns the number updated.
+func (r resourceRenames) renameSliceReferences(obj map[string]interface{}, path []string, references map[string][]string) int {
+	items, found := unstructured.NestedSlice(obj, path...)
+	if !found {
+		return 0
+	}
+
+	var renamed int
+	for i := range items {
+		item, ok := items[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for resource, field := range references {
+			name, found := unstructured.NestedString(item, field...)
+			if !found {
+				continue
+			}
+			if newName, found := r.names[resource][name]; found {
+				unstructured.SetNestedField(item, newName, field...)
+				renamed++
+			}
+		}
+	}
+
+	if renamed > 0 {
+		unstructured.SetNestedSlice(obj, items, path...)
+	}
+
+	return renamed
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestParseResourceRename(t *testing.T) {
+	resource, name, err := ParseResourceRename("widgets.example.com/prod-app")
+	require.NoError(t, err)
+	assert.Equal(t, "widgets.example.com", resource)
+	assert.Equal(t, "prod-app", name)
+
+	for _, key := range []string{"prod-app", "/prod-app", "widgets.example.com/"} {
+		_, _, err := ParseResourceRename(key)
+		assert.Error(t, err, key)
+	}
+}
+
+func TestResolveRenames(t *testing.T) {
+	helper := arktest.NewFakeDiscoveryHelper(false, map[schema.GroupVersionResource]schema.GroupVersionResource{
+		{Resource: "widgets"}:                       {Group: "example.com", Version: "v1", Resource: "widgets"},
+		{Resource: "configmaps"}:                    {Version: "v1", Resource: "configmaps"},
+		{Resource: "cm"}:                            {Version: "v1", Resource: "configmaps"},
+		{Group: "example.com", Resource: "widgets"}: {Group: "example.com", Version: "v1", Resource: "widgets"},
+	})
+	for _, resourceList := range helper.ResourceList {
+		for i := range resourceList.APIResources {
+			if resourceList.APIResources[i].Name == "widgets" {
+				resourceList.APIResources[i].Kind = "Widget"
+			}
+		}
+	}
+
+	renames, err := resolveRenames(map[string]string{
+		"widgets/prod-app":             "staging-app",
+		"cm/prod-config":               "staging-config",
+		"gadgets.example.com/prod-app": "staging-app",
+	}, helper)
+	require.NoError(t, err)
+	assert.Equal(t, resourceRenames{
+		names: map[string]map[string]string{
+			"widgets.example.com": {"prod-app": "staging-app"},
+			"configmaps":          {"prod-config": "staging-config"},
+		},
+		kinds: map[schema.GroupKind]string{
+			{Group: "example.com", Kind: "Widget"}: "widgets.example.com",
+		},
+	}, renames)
+
+	// collisions are found once resources are resolved
+	_, err = resolveRenames(map[string]string{
+		"widgets/prod-app":           "staging-app",
+		"widgets.example.com/qa-app": "staging-app",
+	}, helper)
+	assert.EqualError(t, err, "widgets.example.com/qa-app and widgets/prod-app are both renamed to staging-app")
+}
+
+func TestRename(t *testing.T) {
+	renames := resourceRenames{
+		names: map[string]map[string]string{
+			"widgets.example.com":    {"prod-app": "staging-app"},
+			"persistentvolumeclaims": {"prod-data": "staging-data"},
+			"configmaps":             {"prod-config": "staging-config"},
+			"secrets":                {"prod-secret": "staging-secret"},
+			"serviceaccounts":        {"prod-sa": "staging-sa"},
+		},
+		kinds: map[schema.GroupKind]string{
+			{Group: "example.com", Kind: "Widget"}: "widgets.example.com",
+		},
+	}
+
+	widget := unstructuredOrDie(`{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"namespace": "ns-1", "name": "prod-app"}}`)
+	assert.True(t, renames.rename("widgets.example.com", widget))
+	assert.Equal(t, "staging-app", widget.GetName())
+	assert.Equal(t, "prod-app", widget.GetAnnotations()[api.OriginalNameAnnotation])
+
+	other := unstructuredOrDie(`{"apiVersion": "example.com/v1", "kind": "Gadget", "metadata": {"namespace": "ns-1", "name": "prod-app"}}`)
+	assert.False(t, renames.rename("gadgets.example.com", other))
+	assert.Equal(t, "prod-app", other.GetName())
+
+	deployment := unstructuredOrDie(`{
+		"apiVersion": "apps/v1",
+		"kind": "Deployment",
+		"metadata": {"namespace": "ns-1", "name": "app"},
+		"spec": {"template": {"spec": {"volumes": [
+			{"name": "data", "persistentVolumeClaim": {"claimName": "prod-data"}},
+			{"name": "config", "configMap": {"name": "prod-config"}},
+			{"name": "secret", "secret": {"secretName": "prod-secret"}},
+			{"name": "other", "configMap": {"name": "other-config"}},
+			{"name": "scratch", "emptyDir": {}}
+		]}}}
+	}`)
+	assert.Equal(t, 3, renames.renameReferences(deployment))
+
+	volumes, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "volumes")
+	expected := []interface{}{
+		map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "staging-data"}},
+		map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "staging-config"}},
+		map[string]interface{}{"name": "secret", "secret": map[string]interface{}{"secretName": "staging-secret"}},
+		map[string]interface{}{"name": "other", "configMap": map[string]interface{}{"name": "other-config"}},
+		map[string]interface{}{"name": "scratch", "emptyDir": map[string]interface{}{}},
+	}
+	assert.Equal(t, expected, volumes)
+
+	pod := unstructuredOrDie(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "pod"}, "spec": {"volumes": [{"name": "data", "persistentVolumeClaim": {"claimName": "prod-data"}}]}}`)
+	assert.Equal(t, 1, renames.renameReferences(pod))
+	volumes, _ = unstructured.NestedSlice(pod.Object, "spec", "volumes")
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "staging-data"}}}, volumes)
+
+	assert.Equal(t, 0, renames.renameReferences(widget))
+
+	// containers' env, image pull secrets, service accounts, and owners are updated too
+	cronJob := unstructuredOrDie(`{
+		"apiVersion": "batch/v1beta1",
+		"kind": "CronJob",
+		"metadata": {"name": "job", "ownerReferences": [
+			{"apiVersion": "example.com/v1", "kind": "Widget", "name": "prod-app", "uid": "1"},
+			{"apiVersion": "example.com/v1", "kind": "Gadget", "name": "prod-app", "uid": "2"}
+		]},
+		"spec": {"jobTemplate": {"spec": {"template": {"spec": {
+			"serviceAccountName": "prod-sa",
+			"imagePullSecrets": [{"name": "prod-secret"}, {"name": "other-secret"}],
+			"initContainers": [{"name": "init", "envFrom": [{"configMapRef": {"name": "prod-config"}}]}],
+			"containers": [{
+				"name": "job",
+				"envFrom": [{"secretRef": {"name": "prod-secret"}}],
+				"env": [
+					{"name": "A", "valueFrom": {"configMapKeyRef": {"name": "prod-config", "key": "a"}}},
+					{"name": "B", "valueFrom": {"secretKeyRef": {"name": "prod-secret", "key": "b"}}},
+					{"name": "C", "value": "prod-config"}
+				]
+			}]
+		}}}}}
+	}`)
+	assert.Equal(t, 7, renames.renameReferences(cronJob))
+
+	podSpec, _ := unstructured.NestedMap(cronJob.Object, "spec", "jobTemplate", "spec", "template", "spec")
+	assert.Equal(t, "staging-sa", podSpec["serviceAccountName"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "staging-secret"},
+		map[string]interface{}{"name": "other-secret"},
+	}, podSpec["imagePullSecrets"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "init", "envFrom": []interface{}{map[string]interface{}{"configMapRef": map[string]interface{}{"name": "staging-config"}}}},
+	}, podSpec["initContainers"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"name":    "job",
+			"envFrom": []interface{}{map[string]interface{}{"secretRef": map[string]interface{}{"name": "staging-secret"}}},
+			"env": []interface{}{
+				map[string]interface{}{"name": "A", "valueFrom": map[string]interface{}{"configMapKeyRef": map[string]interface{}{"name": "staging-config", "key": "a"}}},
+				map[string]interface{}{"name": "B", "valueFrom": map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "staging-secret", "key": "b"}}},
+				map[string]interface{}{"name": "C", "value": "prod-config"},
+			},
+		},
+	}, podSpec["containers"])
+
+	owners := cronJob.GetOwnerReferences()
+	require.Len(t, owners, 2)
+	assert.Equal(t, "staging-app", owners[0].Name)
+	assert.Equal(t, "prod-app", owners[1].Name)
+}
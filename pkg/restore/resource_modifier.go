@@ -0,0 +1,148 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/discovery"
+	"github.com/heptio/ark/pkg/util/collections"
+)
+
+// resolvedModifier is a ResourceModifier whose resources and label selector have been
+// resolved, and whose patch has been decoded.
+type resolvedModifier struct {
+	resourceIncludesExcludes  *collections.IncludesExcludes
+	namespaceIncludesExcludes *collections.IncludesExcludes
+	selector                  labels.Selector
+
+	patch jsonpatch.Patch
+	// patchErr is the error decoding the modifier's patch, if any. It's reported
+	// as a warning for each item the modifier applies to.
+	patchErr error
+}
+
+func resolveModifiers(modifiers []api.ResourceModifier, helper discovery.Helper) ([]resolvedModifier, error) {
+	var resolved []resolvedModifier
+
+	for _, modifier := range modifiers {
+		selector := labels.Everything()
+		if modifier.LabelSelector != nil {
+			var err error
+			if selector, err = metav1.LabelSelectorAsSelector(modifier.LabelSelector); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+
+		resources := getResourceIncludesExcludes(helper, modifier.Resources, nil)
+		if len(modifier.Resources) > 0 && len(resources.GetIncludes()) == 0 {
+			// none of the modifier's resources exist in the cluster, so there's
+			// nothing it could apply to
+			continue
+		}
+
+		res := resolvedModifier{
+			resourceIncludesExcludes:  resources,
+			namespaceIncludesExcludes: collections.NewIncludesExcludes().Includes(modifier.Namespaces...),
+			selector:                  selector,
+		}
+		res.patch, res.patchErr = decodePatch(modifier.Patches)
+
+		resolved = append(resolved, res)
+	}
+
+	return resolved, nil
+}
+
+// decodePatch converts operations into a JSON patch.
+func decodePatch(operations []api.JSONPatchOperation) (jsonpatch.Patch, error) {
+	var ops []map[string]interface{}
+	for _, operation := range operations {
+		op := map[string]interface{}{
+			"op":   operation.Op,
+			"path": operation.Path,
+		}
+		if operation.From != "" {
+			op["from"] = operation.From
+		}
+		if operation.Value != "" {
+			if !json.Valid([]byte(operation.Value)) {
+				return nil, errors.Errorf("value for %s operation on %s is not valid JSON: %s", operation.Op, operation.Path, operation.Value)
+			}
+			op["value"] = json.RawMessage(operation.Value)
+		}
+		ops = append(ops, op)
+	}
+
+	patchBytes, err := json.Marshal(ops)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	patch, err := jsonpatch.DecodePatch(patchBytes)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return patch, nil
+}
+
+// appliesTo returns whether the modifier applies to obj, an item of groupResource
+// being restored into namespace.
+func (m *resolvedModifier) appliesTo(groupResource, namespace string, obj *unstructured.Unstructured) bool {
+	if !m.resourceIncludesExcludes.ShouldInclude(groupResource) {
+		return false
+	}
+
+	if namespace != "" && !m.namespaceIncludesExcludes.ShouldInclude(namespace) {
+		return false
+	}
+
+	return m.selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// apply returns a copy of obj with the modifier's patch applied.
+func (m *resolvedModifier) apply(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if m.patchErr != nil {
+		return nil, m.patchErr
+	}
+
+	objBytes, err := json.Marshal(obj)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	patchedBytes, err := m.patch.Apply(objBytes)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	patched := new(unstructured.Unstructured)
+	if err := json.Unmarshal(patchedBytes, patched); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return patched, nil
+}
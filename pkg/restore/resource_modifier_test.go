@@ -0,0 +1,179 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestResolvedModifierApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		patches     []api.JSONPatchOperation
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name: "replace and add",
+			patches: []api.JSONPatchOperation{
+				{Op: "replace", Path: "/spec/replicas", Value: "3"},
+				{Op: "add", Path: "/metadata/labels/env", Value: `"dr"`},
+			},
+			expected: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"app","labels":{"app":"web","env":"dr"}},"spec":{"replicas":3}}`,
+		},
+		{
+			name: "remove",
+			patches: []api.JSONPatchOperation{
+				{Op: "remove", Path: "/metadata/labels/app"},
+			},
+			expected: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"app","labels":{}},"spec":{"replicas":1}}`,
+		},
+		{
+			name: "missing path",
+			patches: []api.JSONPatchOperation{
+				{Op: "replace", Path: "/spec/template/spec/containers/0/image", Value: `"registry.example.com/app:v1"`},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "value that isn't valid JSON",
+			patches: []api.JSONPatchOperation{
+				{Op: "replace", Path: "/spec/replicas", Value: "three"},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			modifiers, err := resolveModifiers([]api.ResourceModifier{{Patches: test.patches}}, &arktest.FakeDiscoveryHelper{})
+			require.NoError(t, err)
+			require.Len(t, modifiers, 1)
+
+			obj := unstructuredOrDie(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"app","labels":{"app":"web"}},"spec":{"replicas":1}}`)
+			res, err := modifiers[0].apply(obj)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, unstructuredOrDie(test.expected), res)
+		})
+	}
+}
+
+func TestResolvedModifierAppliesTo(t *testing.T) {
+	discoveryHelper := &arktest.FakeDiscoveryHelper{
+		Mapper: &arktest.FakeMapper{
+			Resources: map[schema.GroupVersionResource]schema.GroupVersionResource{
+				{Resource: "deployments"}: {Group: "apps", Version: "v1", Resource: "deployments"},
+			},
+		},
+		ResourceList: []*metav1.APIResourceList{
+			{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{{Name: "deployments", Namespaced: true}},
+			},
+		},
+	}
+
+	modifiers, err := resolveModifiers([]api.ResourceModifier{
+		{
+			Resources:     []string{"deployments"},
+			Namespaces:    []string{"ns-1"},
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		{
+			// unknown resources match nothing rather than everything
+			Resources: []string{"unknown"},
+		},
+	}, discoveryHelper)
+	require.NoError(t, err)
+	require.Len(t, modifiers, 1)
+	m := modifiers[0]
+
+	web := unstructuredOrDie(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"app","labels":{"app":"web"}}}`)
+	db := unstructuredOrDie(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"app","labels":{"app":"db"}}}`)
+
+	assert.True(t, m.appliesTo("deployments.apps", "ns-1", web))
+	assert.False(t, m.appliesTo("deployments.apps", "ns-2", web))
+	assert.False(t, m.appliesTo("deployments.apps", "ns-1", db))
+	assert.False(t, m.appliesTo("configmaps", "ns-1", web))
+}
+
+func TestRestoreResourceWithModifiers(t *testing.T) {
+	modifiers, err := resolveModifiers([]api.ResourceModifier{
+		{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"modify": "true"}},
+			Patches:       []api.JSONPatchOperation{{Op: "add", Path: "/data", Value: `{"env":"dr"}`}},
+		},
+		{
+			// fails for every item, which are then restored without it
+			Patches: []api.JSONPatchOperation{{Op: "remove", Path: "/spec"}},
+		},
+	}, &arktest.FakeDiscoveryHelper{})
+	require.NoError(t, err)
+
+	modified := newNamedTestConfigMap("cm-1").WithLabels(map[string]string{"modify": "true"}).WithArkLabel("my-restore").ConfigMap
+	modified.Data = map[string]string{"env": "dr"}
+	unmodified := newNamedTestConfigMap("cm-2").WithArkLabel("my-restore").ConfigMap
+	expectedObjs := toUnstructured(modified, unmodified)
+
+	resourceClient := &arktest.FakeDynamicClient{}
+	for i := range expectedObjs {
+		resourceClient.On("Create", &expectedObjs[i]).Return(&expectedObjs[i], nil)
+	}
+
+	dynamicFactory := &arktest.FakeDynamicFactory{}
+	resource := metav1.APIResource{Name: "configmaps", Namespaced: true}
+	dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Group: "", Version: "v1"}, resource, "ns-1").Return(resourceClient, nil)
+
+	ctx := &context{
+		dynamicFactory: dynamicFactory,
+		fileSystem: newFakeFileSystem().
+			WithFile("configmaps/cm-1.json", newNamedTestConfigMap("cm-1").WithLabels(map[string]string{"modify": "true"}).ToJSON()).
+			WithFile("configmaps/cm-2.json", newNamedTestConfigMap("cm-2").ToJSON()),
+		selector: labels.NewSelector(),
+		restore: &api.Restore{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: api.DefaultNamespace,
+				Name:      "my-restore",
+			},
+		},
+		backup:    &api.Backup{},
+		modifiers: modifiers,
+		logger:    arktest.NewLogger(),
+	}
+
+	warnings, errs := ctx.restoreResource("configmaps", "ns-1", "configmaps")
+
+	assert.Equal(t, api.RestoreResult{}, errs)
+	require.Len(t, warnings.Namespaces["ns-1"], 2)
+	assert.Contains(t, warnings.Namespaces["ns-1"][0], "error applying resource modifier 1 to configmaps/cm-1.json")
+	assert.Contains(t, warnings.Namespaces["ns-1"][1], "error applying resource modifier 1 to configmaps/cm-2.json")
+	resourceClient.AssertNumberOfCalls(t, "Create", 2)
+}
@@ -31,6 +31,12 @@ import (
 // their specified conditions before continuing on.
 const objectCreateWaitTimeout = 30 * time.Second
 
+// how often to check whether a namespace created for a restore has become active.
+const namespaceActivePollInterval = time.Second
+
+// how often to check whether a restored item has reached a condition declared by an ItemAction.
+const waitConditionPollInterval = time.Second
+
 // resourceWaiter knows how to wait for a set of registered items to become "ready" (according
 // to a provided readyFunc) based on listening to a channel of Events. The correct usage
 // of this struct is to construct it, register all of the desired items to wait for via
@@ -23,9 +23,13 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -38,16 +42,21 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	pkgbackup "github.com/heptio/ark/pkg/backup"
 	"github.com/heptio/ark/pkg/client"
 	"github.com/heptio/ark/pkg/cloudprovider"
+	"github.com/heptio/ark/pkg/csi"
 	"github.com/heptio/ark/pkg/discovery"
 	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
 	"github.com/heptio/ark/pkg/util/boolptr"
 	"github.com/heptio/ark/pkg/util/collections"
+	"github.com/heptio/ark/pkg/util/compression"
 	"github.com/heptio/ark/pkg/util/kube"
 	"github.com/heptio/ark/pkg/util/logging"
 )
@@ -55,23 +64,44 @@ import (
 // Restorer knows how to restore a backup.
 type Restorer interface {
 	// Restore restores the backup data from backupReader, returning warnings and errors.
-	Restore(restore *api.Restore, backup *api.Backup, backupReader io.Reader, logFile io.Writer, actions []ItemAction) (api.RestoreResult, api.RestoreResult)
+	// The number of items to restore and restored is recorded in progress as the restore runs,
+	// and the volumes created from snapshots are recorded in volumes.
+	Restore(restore *api.Restore, backup *api.Backup, backupReader io.Reader, logFile io.Writer, actions []ItemAction, progress *Progress, volumes VolumeRecorder) (api.RestoreResult, api.RestoreResult)
 }
 
+// VolumeRecorder records the volumes created from snapshots while a restore runs, so
+// that if it's interrupted, the volumes can be reused when it's resumed rather than
+// being created again. Volumes recorded by an earlier run are found in the restore's
+// status.restoredVolumes.
+type VolumeRecorder interface {
+	// RecordVolume records that volumeID was created for the named PersistentVolume.
+	// An empty volumeID records that the volume's creation is starting.
+	RecordVolume(pvName, volumeID string) error
+}
+
+// restoredVolumeTag is the tag applied to the volumes created from snapshots, whose value
+// identifies the restore and PersistentVolume the volume was created for, so that a volume
+// whose creation was interrupted before it was recorded can be found when the restore is
+// resumed.
+const restoredVolumeTag = "ark.heptio.com/restored-volume"
+
 type gvString string
 type kindString string
 
 // kubernetesRestorer implements Restorer for restoring into a Kubernetes cluster.
 type kubernetesRestorer struct {
-	discoveryHelper    discovery.Helper
-	dynamicFactory     client.DynamicFactory
-	backupService      cloudprovider.BackupService
-	snapshotService    cloudprovider.SnapshotService
-	backupClient       arkv1client.BackupsGetter
-	namespaceClient    corev1.NamespaceInterface
-	resourcePriorities []string
-	fileSystem         FileSystem
-	logger             logrus.FieldLogger
+	discoveryHelper      discovery.Helper
+	dynamicFactory       client.DynamicFactory
+	backupService        cloudprovider.BackupService
+	snapshotService      cloudprovider.SnapshotService
+	csiSnapshotter       csi.Snapshotter
+	backupClient         arkv1client.BackupsGetter
+	namespaceClient      corev1.NamespaceInterface
+	resourcePriorities   []string
+	namespaceConcurrency int
+	versionedResources   []string
+	fileSystem           FileSystem
+	logger               logrus.FieldLogger
 }
 
 // prioritizeResources returns an ordered, fully-resolved list of resources to restore based on
@@ -132,34 +162,76 @@ func prioritizeResources(helper discovery.Helper, priorities []string, includedR
 	return ret, nil
 }
 
+// webhookResources are the resources whose items intercept requests to the API server, and
+// are restored after everything else unless a restore disables it, since restoring them before
+// the workloads that serve them makes the API server reject or fail subsequent requests.
+var webhookResources = []schema.GroupResource{
+	{Group: "apiregistration.k8s.io", Resource: "apiservices"},
+	{Group: "admissionregistration.k8s.io", Resource: "mutatingwebhookconfigurations"},
+	{Group: "admissionregistration.k8s.io", Resource: "validatingwebhookconfigurations"},
+}
+
+// moveToEnd returns resources with any of last that it contains moved to the end, in the order
+// they're listed in last. The order of the other resources is unchanged.
+func moveToEnd(resources []schema.GroupResource, last []schema.GroupResource) []schema.GroupResource {
+	lastSet := sets.NewString()
+	for _, gr := range last {
+		lastSet.Insert(gr.String())
+	}
+
+	var ret []schema.GroupResource
+	found := sets.NewString()
+	for _, gr := range resources {
+		if lastSet.Has(gr.String()) {
+			found.Insert(gr.String())
+			continue
+		}
+		ret = append(ret, gr)
+	}
+
+	for _, gr := range last {
+		if found.Has(gr.String()) {
+			ret = append(ret, gr)
+		}
+	}
+
+	return ret
+}
+
 // NewKubernetesRestorer creates a new kubernetesRestorer.
 func NewKubernetesRestorer(
 	discoveryHelper discovery.Helper,
 	dynamicFactory client.DynamicFactory,
 	backupService cloudprovider.BackupService,
 	snapshotService cloudprovider.SnapshotService,
+	csiSnapshotter csi.Snapshotter,
 	resourcePriorities []string,
+	namespaceConcurrency int,
+	versionedResources []string,
 	backupClient arkv1client.BackupsGetter,
 	namespaceClient corev1.NamespaceInterface,
 	logger logrus.FieldLogger,
 ) (Restorer, error) {
 	return &kubernetesRestorer{
-		discoveryHelper:    discoveryHelper,
-		dynamicFactory:     dynamicFactory,
-		backupService:      backupService,
-		snapshotService:    snapshotService,
-		backupClient:       backupClient,
-		namespaceClient:    namespaceClient,
-		resourcePriorities: resourcePriorities,
-		fileSystem:         &osFileSystem{},
-		logger:             logger,
+		discoveryHelper:      discoveryHelper,
+		dynamicFactory:       dynamicFactory,
+		backupService:        backupService,
+		snapshotService:      snapshotService,
+		csiSnapshotter:       csiSnapshotter,
+		backupClient:         backupClient,
+		namespaceClient:      namespaceClient,
+		resourcePriorities:   resourcePriorities,
+		namespaceConcurrency: namespaceConcurrency,
+		versionedResources:   versionedResources,
+		fileSystem:           &osFileSystem{},
+		logger:               logger,
 	}, nil
 }
 
 // Restore executes a restore into the target Kubernetes cluster according to the restore spec
 // and using data from the provided backup/backup reader. Returns a warnings and errors RestoreResult,
 // respectively, summarizing info about the restore.
-func (kr *kubernetesRestorer) Restore(restore *api.Restore, backup *api.Backup, backupReader io.Reader, logFile io.Writer, actions []ItemAction) (api.RestoreResult, api.RestoreResult) {
+func (kr *kubernetesRestorer) Restore(restore *api.Restore, backup *api.Backup, backupReader io.Reader, logFile io.Writer, actions []ItemAction, progress *Progress, volumes VolumeRecorder) (api.RestoreResult, api.RestoreResult) {
 	// metav1.LabelSelectorAsSelector converts a nil LabelSelector to a
 	// Nothing Selector, i.e. a selector that matches nothing. We want
 	// a selector that matches everything. This can be accomplished by
@@ -188,25 +260,47 @@ func (kr *kubernetesRestorer) Restore(restore *api.Restore, backup *api.Backup,
 	if err != nil {
 		return api.RestoreResult{}, api.RestoreResult{Ark: []string{err.Error()}}
 	}
+	if !boolptr.IsSetToFalse(restore.Spec.RestoreWebhooksLast) {
+		prioritizedResources = moveToEnd(prioritizedResources, webhookResources)
+	}
 
 	resolvedActions, err := resolveActions(actions, kr.discoveryHelper)
 	if err != nil {
 		return api.RestoreResult{}, api.RestoreResult{Ark: []string{err.Error()}}
 	}
 
+	resolvedModifiers, err := resolveModifiers(restore.Spec.ResourceModifiers, kr.discoveryHelper)
+	if err != nil {
+		return api.RestoreResult{}, api.RestoreResult{Ark: []string{err.Error()}}
+	}
+
+	renames, err := resolveRenames(restore.Spec.ResourceRenames, kr.discoveryHelper)
+	if err != nil {
+		return api.RestoreResult{}, api.RestoreResult{Ark: []string{err.Error()}}
+	}
+
 	ctx := &context{
-		backup:               backup,
-		backupReader:         backupReader,
-		restore:              restore,
-		prioritizedResources: prioritizedResources,
-		selector:             selector,
-		logger:               log,
-		dynamicFactory:       kr.dynamicFactory,
-		fileSystem:           kr.fileSystem,
-		namespaceClient:      kr.namespaceClient,
-		actions:              resolvedActions,
-		snapshotService:      kr.snapshotService,
-		waitForPVs:           true,
+		backup:                 backup,
+		backupReader:           backupReader,
+		restore:                restore,
+		prioritizedResources:   prioritizedResources,
+		selector:               selector,
+		logger:                 log,
+		dynamicFactory:         kr.dynamicFactory,
+		fileSystem:             kr.fileSystem,
+		namespaceClient:        kr.namespaceClient,
+		actions:                resolvedActions,
+		modifiers:              resolvedModifiers,
+		renames:                renames,
+		progress:               progress,
+		volumeRecorder:         volumes,
+		snapshotService:        kr.snapshotService,
+		csiSnapshotter:         kr.csiSnapshotter,
+		waitForPVs:             true,
+		namespaceConcurrency:   kr.namespaceConcurrency,
+		namespaceActiveTimeout: objectCreateWaitTimeout,
+		versionedResources:     resolveVersionedResources(kr.versionedResources, kr.discoveryHelper),
+		discoveryHelper:        kr.discoveryHelper,
 	}
 
 	return ctx.execute()
@@ -274,18 +368,34 @@ func resolveActions(actions []ItemAction, helper discovery.Helper) ([]resolvedAc
 }
 
 type context struct {
-	backup               *api.Backup
-	backupReader         io.Reader
-	restore              *api.Restore
-	prioritizedResources []schema.GroupResource
-	selector             labels.Selector
-	logger               logrus.FieldLogger
-	dynamicFactory       client.DynamicFactory
-	fileSystem           FileSystem
-	namespaceClient      corev1.NamespaceInterface
-	actions              []resolvedAction
-	snapshotService      cloudprovider.SnapshotService
-	waitForPVs           bool
+	backup                 *api.Backup
+	backupReader           io.Reader
+	restore                *api.Restore
+	prioritizedResources   []schema.GroupResource
+	selector               labels.Selector
+	logger                 logrus.FieldLogger
+	dynamicFactory         client.DynamicFactory
+	fileSystem             FileSystem
+	namespaceClient        corev1.NamespaceInterface
+	actions                []resolvedAction
+	modifiers              []resolvedModifier
+	renames                resourceRenames
+	progress               *Progress
+	volumeRecorder         VolumeRecorder
+	snapshotService        cloudprovider.SnapshotService
+	csiSnapshotter         csi.Snapshotter
+	waitForPVs             bool
+	namespaceConcurrency   int
+	namespaceActiveTimeout time.Duration
+	restoredItems          []restoredItem
+	backedUpItems          backedUpItems
+	backupResources        sets.String
+	liveOwners             map[string]bool
+	versionedResources     sets.String
+	discoveryHelper        discovery.Helper
+	// resumedVolumes are the volumes found by their tag for the PersistentVolumes whose
+	// creation was interrupted by an earlier run of the restore, keyed by PV name.
+	resumedVolumes map[string]string
 }
 
 func (ctx *context) infof(msg string, args ...interface{}) {
@@ -310,6 +420,42 @@ func (ctx *context) execute() (api.RestoreResult, api.RestoreResult) {
 func (ctx *context) restoreFromDir(dir string) (api.RestoreResult, api.RestoreResult) {
 	warnings, errs := api.RestoreResult{}, api.RestoreResult{}
 
+	// volumes whose creation started but wasn't recorded as finished by an earlier,
+	// interrupted run of this restore are looked for by their tag, so that they're reused
+	// rather than created again. If they can't be looked for, a duplicate may be orphaned.
+	ctx.resumedVolumes = make(map[string]string)
+	for _, pvName := range sets.StringKeySet(ctx.restore.Status.RestoredVolumes).List() {
+		if ctx.restore.Status.RestoredVolumes[pvName] != "" {
+			continue
+		}
+
+		var snapshotID string
+		if info := ctx.backup.Status.VolumeBackups[pvName]; info != nil {
+			snapshotID = info.SnapshotID
+		}
+
+		var (
+			volumeIDs []string
+			supported bool
+			err       error
+		)
+		if ctx.snapshotService != nil {
+			volumeIDs, supported, err = ctx.snapshotService.FindVolumesWithTag(restoredVolumeTag, ctx.restoredVolumeTagValue(pvName))
+		}
+		switch {
+		case err != nil:
+			addArkError(&warnings, errors.Wrapf(err, "restore was interrupted while creating a volume for PersistentVolume %s from snapshot %s, and the volume couldn't be looked for, so a duplicate volume may have been orphaned", pvName, snapshotID))
+		case !supported:
+			addArkError(&warnings, errors.Errorf("restore was interrupted while creating a volume for PersistentVolume %s from snapshot %s, so a duplicate volume may have been orphaned", pvName, snapshotID))
+		case len(volumeIDs) > 0:
+			ctx.resumedVolumes[pvName] = volumeIDs[0]
+			ctx.recordVolume(pvName, volumeIDs[0])
+			if len(volumeIDs) > 1 {
+				addArkError(&warnings, errors.Errorf("found volumes %s created for PersistentVolume %s from snapshot %s, so the volumes other than %s were orphaned", strings.Join(volumeIDs, ", "), pvName, snapshotID, volumeIDs[0]))
+			}
+		}
+	}
+
 	namespaceFilter := collections.NewIncludesExcludes().
 		Includes(ctx.restore.Spec.IncludedNamespaces...).
 		Excludes(ctx.restore.Spec.ExcludedNamespaces...)
@@ -339,7 +485,45 @@ func (ctx *context) restoreFromDir(dir string) (api.RestoreResult, api.RestoreRe
 		resourceDirsMap[rscName] = rscDir
 	}
 
-	existingNamespaces := sets.NewString()
+	if policy := ctx.restore.Spec.MissingCRDPolicy; policy == api.MissingCRDPolicyWarn || policy == api.MissingCRDPolicyFail {
+		missing := ctx.missingResources()
+
+		for _, resource := range missing {
+			if policy == api.MissingCRDPolicyFail {
+				addArkError(&errs, errors.Errorf("backup contains items of resource %s, which the cluster doesn't have, e.g. because its CustomResourceDefinition isn't installed, so nothing was restored", resource))
+			} else {
+				addArkError(&warnings, errors.Errorf("backup contains items of resource %s, which the cluster doesn't have, e.g. because its CustomResourceDefinition isn't installed, so they weren't restored", resource))
+			}
+		}
+
+		if policy == api.MissingCRDPolicyFail && len(missing) > 0 {
+			return warnings, errs
+		}
+	}
+
+	if ctx.restore.Spec.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(ctx.restore.Spec.NamespaceSelector)
+		if err != nil {
+			addArkError(&errs, errors.Wrap(err, "invalid namespace selector"))
+			return warnings, errs
+		}
+
+		if notSelected := ctx.namespacesNotSelected(resourcesDir, resourceDirsMap, namespaceFilter, selector); len(notSelected) > 0 {
+			ctx.infof("Skipping %d namespaces that don't match the namespace selector: %s", len(notSelected), strings.Join(notSelected, ", "))
+			namespaceFilter.Excludes(notSelected...)
+			ctx.progress.setSkippedNamespaces(len(notSelected))
+		}
+	}
+
+	ctx.progress.setTotal(func() int { return ctx.countItems(resourcesDir, resourceDirsMap, namespaceFilter) })
+
+	if checkpoint := ctx.restore.Status.Checkpoint; checkpoint != nil {
+		ctx.infof("Skipping the %d items restored up to checkpoint %s %s/%s", checkpoint.ItemsRestored, checkpoint.Resource, checkpoint.Namespace, checkpoint.Name)
+	}
+
+	// create all of the namespaces that items are restored into before restoring
+	// anything, so namespaces that can't be created only cause errors for themselves
+	readyNamespaces := ctx.restoreNamespaces(dir, ctx.namespacesToRestore(resourcesDir, resourceDirsMap, namespaceFilter), &errs)
 
 	for _, resource := range ctx.prioritizedResources {
 		// we don't want to explicitly restore namespace API objs because we'll handle
@@ -402,21 +586,9 @@ func (ctx *context) restoreFromDir(dir string) (api.RestoreResult, api.RestoreRe
 				mappedNsName = target
 			}
 
-			// if we don't know whether this namespace exists yet, attempt to create
-			// it in order to ensure it exists. Try to get it from the backup tarball
-			// (in order to get any backed-up metadata), but if we don't find it there,
-			// create a blank one.
-			if !existingNamespaces.Has(mappedNsName) {
-				logger := ctx.logger.WithField("namespace", nsName)
-				ns := getNamespace(logger, filepath.Join(dir, api.ResourcesDir, "namespaces", api.ClusterScopedDir, nsName+".json"), mappedNsName)
-				if _, err := kube.EnsureNamespaceExists(ns, ctx.namespaceClient); err != nil {
-					addArkError(&errs, err)
-					continue
-				}
-
-				// keep track of namespaces that we know exist so we don't
-				// have to try to create them multiple times
-				existingNamespaces.Insert(mappedNsName)
+			// the namespace's error was already reported by restoreNamespaces
+			if !readyNamespaces.Has(mappedNsName) {
+				continue
 			}
 
 			w, e := ctx.restoreResource(resource.String(), mappedNsName, nsPath)
@@ -425,9 +597,331 @@ func (ctx *context) restoreFromDir(dir string) (api.RestoreResult, api.RestoreRe
 		}
 	}
 
+	if ctx.restore.Spec.Prune {
+		if !isEmpty(&errs) {
+			addArkError(&warnings, errors.New("not pruning items that aren't in the backup because the restore has errors"))
+		} else {
+			e := ctx.pruneLeftoverItems(readyNamespaces.List())
+			merge(&errs, &e)
+		}
+	}
+
+	if ctx.restore.Spec.Verify {
+		w := ctx.verifyRestoredItems()
+		merge(&warnings, &w)
+	}
+
 	return warnings, errs
 }
 
+// missingResources returns the names of the resources the backup has items of, as recorded
+// from its tarball by readBackup, that the restore includes but the cluster doesn't have, so
+// their items can't be restored. Their items aren't extracted, so they can't be found from
+// the extracted backup.
+func (ctx *context) missingResources() []string {
+	resourceFilter := collections.NewIncludesExcludes().
+		Includes(ctx.restore.Spec.IncludedResources...).
+		Excludes(ctx.restore.Spec.ExcludedResources...)
+
+	var missing []string
+	for _, resource := range ctx.backupResources.List() {
+		if !resourceFilter.ShouldInclude(resource) {
+			continue
+		}
+
+		if _, _, err := ctx.discoveryHelper.ResourceFor(schema.ParseGroupResource(resource).WithVersion("")); err != nil {
+			ctx.infof("Cluster doesn't have backed-up resource %s: %v", resource, err)
+			missing = append(missing, resource)
+		}
+	}
+
+	sort.Strings(missing)
+	return missing
+}
+
+// countItems returns the number of items in the backup's resource directories that
+// will be considered for the restore, following the same layout as restoreFromDir.
+// Directories that can't be read aren't counted; restoreFromDir reports the errors.
+func (ctx *context) countItems(resourcesDir string, resourceDirsMap map[string]os.FileInfo, namespaceFilter *collections.IncludesExcludes) int {
+	countFiles := func(dir string) int {
+		files, err := ctx.fileSystem.ReadDir(dir)
+		if err != nil {
+			return 0
+		}
+		return len(files)
+	}
+
+	var count int
+	for _, resource := range ctx.prioritizedResources {
+		if resource.Group == "" && resource.Resource == "namespaces" {
+			continue
+		}
+
+		rscDir := resourceDirsMap[resource.String()]
+		if rscDir == nil {
+			continue
+		}
+		resourcePath := filepath.Join(resourcesDir, rscDir.Name())
+
+		clusterSubDir := filepath.Join(resourcePath, api.ClusterScopedDir)
+		if exists, _ := ctx.fileSystem.DirExists(clusterSubDir); exists {
+			if ctx.restore.Spec.IncludeClusterResources == nil || *ctx.restore.Spec.IncludeClusterResources {
+				count += countFiles(clusterSubDir)
+			}
+			continue
+		}
+
+		nsSubDir := filepath.Join(resourcePath, api.NamespaceScopedDir)
+		nsDirs, err := ctx.fileSystem.ReadDir(nsSubDir)
+		if err != nil {
+			continue
+		}
+		for _, nsDir := range nsDirs {
+			if nsDir.IsDir() && namespaceFilter.ShouldInclude(nsDir.Name()) {
+				count += countFiles(filepath.Join(nsSubDir, nsDir.Name()))
+			}
+		}
+	}
+
+	return count
+}
+
+// namespacesToRestore returns the names, in the backup, of the namespaces that items will be
+// restored into, following the same layout as restoreFromDir. Directories that can't be read
+// are skipped; restoreFromDir reports the errors.
+func (ctx *context) namespacesToRestore(resourcesDir string, resourceDirsMap map[string]os.FileInfo, namespaceFilter *collections.IncludesExcludes) []string {
+	namespaces := sets.NewString()
+
+	for _, resource := range ctx.prioritizedResources {
+		if resource.Group == "" && resource.Resource == "namespaces" {
+			continue
+		}
+
+		rscDir := resourceDirsMap[resource.String()]
+		if rscDir == nil {
+			continue
+		}
+		resourcePath := filepath.Join(resourcesDir, rscDir.Name())
+
+		if exists, _ := ctx.fileSystem.DirExists(filepath.Join(resourcePath, api.ClusterScopedDir)); exists {
+			continue
+		}
+
+		nsDirs, err := ctx.fileSystem.ReadDir(filepath.Join(resourcePath, api.NamespaceScopedDir))
+		if err != nil {
+			continue
+		}
+		for _, nsDir := range nsDirs {
+			if nsDir.IsDir() && namespaceFilter.ShouldInclude(nsDir.Name()) {
+				namespaces.Insert(nsDir.Name())
+			}
+		}
+	}
+
+	return namespaces.List()
+}
+
+// namespacesNotSelected returns the names of the namespaces in the backup that items would be
+// restored into whose backed-up labels don't match selector. Namespaces whose Namespace object
+// isn't in the backup have no labels.
+func (ctx *context) namespacesNotSelected(resourcesDir string, resourceDirsMap map[string]os.FileInfo, namespaceFilter *collections.IncludesExcludes, selector labels.Selector) []string {
+	var notSelected []string
+
+	for _, nsName := range ctx.namespacesToRestore(resourcesDir, resourceDirsMap, namespaceFilter) {
+		var nsLabels labels.Set
+		if ns, err := ctx.unmarshal(filepath.Join(resourcesDir, "namespaces", api.ClusterScopedDir, nsName+".json")); err == nil {
+			nsLabels = ns.GetLabels()
+		}
+
+		if !selector.Matches(nsLabels) {
+			notSelected = append(notSelected, nsName)
+		}
+	}
+
+	return notSelected
+}
+
+// restoreNamespaces creates the namespaces with the specified names in the backup, mapped
+// according to the restore's namespace mapping, and waits for each of them to become active.
+// At most ctx.namespaceConcurrency namespaces are created at once. It returns the mapped names
+// of the namespaces that are ready to restore items into; errors for the others are added to
+// errs.
+func (ctx *context) restoreNamespaces(dir string, nsNames []string, errs *api.RestoreResult) sets.String {
+	var (
+		ready       = sets.NewString()
+		lock        sync.Mutex
+		wg          sync.WaitGroup
+		concurrency = ctx.namespaceConcurrency
+	)
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+
+	mapped := sets.NewString()
+	for _, nsName := range nsNames {
+		mappedNsName := nsName
+		if target, ok := ctx.restore.Spec.NamespaceMapping[nsName]; ok {
+			mappedNsName = target
+		}
+
+		// several namespaces may be mapped to the same one, which only needs to be created once
+		if mapped.Has(mappedNsName) {
+			continue
+		}
+		mapped.Insert(mappedNsName)
+
+		// Try to get the namespace from the backup tarball (in order to get any backed-up
+		// metadata), but if we don't find it there, create a blank one.
+		logger := ctx.logger.WithField("namespace", nsName)
+		ns := getNamespace(logger, filepath.Join(dir, api.ResourcesDir, "namespaces", api.ClusterScopedDir, nsName+".json"), mappedNsName)
+
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func(ns *v1.Namespace) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			err := ctx.ensureNamespaceActive(ns)
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err != nil {
+				addToResult(errs, ns.Name, err)
+				return
+			}
+			ready.Insert(ns.Name)
+		}(ns)
+	}
+
+	wg.Wait()
+
+	return ready
+}
+
+// ensureNamespaceActive creates the namespace if it doesn't already exist, and waits for it
+// to be active, so that items can be restored into it.
+func (ctx *context) ensureNamespaceActive(ns *v1.Namespace) error {
+	created, err := kube.EnsureNamespaceExists(ns, ctx.namespaceClient)
+	if err != nil {
+		return err
+	}
+
+	if !created && ctx.restore.Spec.ExistingNamespacePolicy == api.ExistingNamespacePolicyMerge {
+		if err := ctx.mergeNamespaceMetadata(ns); err != nil {
+			return err
+		}
+	}
+
+	err = wait.PollImmediate(namespaceActivePollInterval, ctx.namespaceActiveTimeout, func() (bool, error) {
+		current, err := ctx.namespaceClient.Get(ns.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "error getting namespace %s", ns.Name)
+		}
+		return current.Status.Phase == v1.NamespaceActive, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return errors.Errorf("namespace %s didn't become active within %s", ns.Name, ctx.namespaceActiveTimeout)
+	}
+	return err
+}
+
+// mergeNamespaceMetadata adds the labels and annotations of ns, as it was backed up, that
+// the existing namespace with its name doesn't have to it. The existing namespace's own
+// labels and annotations are left as they are.
+func (ctx *context) mergeNamespaceMetadata(ns *v1.Namespace) error {
+	existing, err := ctx.namespaceClient.Get(ns.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "error getting namespace %s", ns.Name)
+	}
+
+	missingLabels := missingKeys(existing.Labels, ns.Labels)
+	missingAnnotations := missingKeys(existing.Annotations, ns.Annotations)
+	if len(missingLabels) == 0 && len(missingAnnotations) == 0 {
+		return nil
+	}
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels":      missingLabels,
+			"annotations": missingAnnotations,
+		},
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return errors.Wrapf(err, "error marshalling patch for namespace %s", ns.Name)
+	}
+
+	ctx.logger.WithField("namespace", ns.Name).Info("Adding backed-up labels and annotations to existing namespace")
+	if _, err := ctx.namespaceClient.Patch(ns.Name, types.MergePatchType, patchBytes); err != nil {
+		return errors.Wrapf(err, "error patching namespace %s", ns.Name)
+	}
+
+	return nil
+}
+
+// missingKeys returns the entries of backedUp whose keys existing doesn't have.
+func missingKeys(existing, backedUp map[string]string) map[string]string {
+	missing := make(map[string]string)
+	for k, v := range backedUp {
+		if _, found := existing[k]; !found {
+			missing[k] = v
+		}
+	}
+	return missing
+}
+
+// waitForCondition polls the named item until its status.conditions include one matching
+// condition, or the condition's timeout is exceeded.
+func (ctx *context) waitForCondition(resourceClient client.Dynamic, name string, condition *WaitCondition) error {
+	status := condition.Status
+	if status == "" {
+		status = string(v1.ConditionTrue)
+	}
+	timeout := condition.Timeout
+	if timeout <= 0 {
+		timeout = objectCreateWaitTimeout
+	}
+
+	ctx.infof("Waiting up to %s for %s to have condition %s=%s", timeout, name, condition.Type, status)
+
+	err := wait.PollImmediate(waitConditionPollInterval, timeout, func() (bool, error) {
+		obj, err := resourceClient.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "error getting %s", name)
+		}
+		return hasCondition(obj, condition.Type, status), nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return errors.Errorf("timed out after %s waiting for %s to have condition %s=%s", timeout, name, condition.Type, status)
+	}
+	return err
+}
+
+// hasCondition returns whether obj's status.conditions include one with the given type
+// and status.
+func hasCondition(obj *unstructured.Unstructured, conditionType, status string) bool {
+	conditions, err := collections.GetSlice(obj.UnstructuredContent(), "status.conditions")
+	if err != nil {
+		return false
+	}
+
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == conditionType && condition["status"] == status {
+			return true
+		}
+	}
+
+	return false
+}
+
 // getNamespace returns a namespace API object that we should attempt to
 // create before restoring anything into it. It will come from the backup
 // tarball if it exists, else will be a new one. If from the tarball, it
@@ -477,6 +971,16 @@ func merge(a, b *api.RestoreResult) {
 	}
 }
 
+// isEmpty returns whether the RestoreResult has no messages.
+func isEmpty(r *api.RestoreResult) bool {
+	for _, messages := range r.Namespaces {
+		if len(messages) > 0 {
+			return false
+		}
+	}
+	return len(r.Ark) == 0 && len(r.Cluster) == 0
+}
+
 // addArkError appends an error to the provided RestoreResult's Ark list.
 func addArkError(r *api.RestoreResult, err error) {
 	r.Ark = append(r.Ark, err.Error())
@@ -498,6 +1002,67 @@ func addToResult(r *api.RestoreResult, ns string, e error) {
 
 // restoreResource restores the specified cluster or namespace scoped resource. If namespace is
 // empty we are restoring a cluster level resource, otherwise into the specified namespace.
+// restoredBeforeCheckpoint returns a func that returns whether the item in the named file, of
+// the resource and in the namespace in the backup, was restored before the restore's checkpoint,
+// i.e. before the restore was interrupted. Items are restored in the order of the restore's
+// prioritized resources, then of their namespaces and files, so every item up to and including
+// the checkpoint's is. If the restore has no checkpoint, or its resource isn't being restored,
+// no item was.
+func (ctx *context) restoredBeforeCheckpoint(resource, namespace string) func(file string) bool {
+	none := func(string) bool { return false }
+	all := func(string) bool { return true }
+
+	checkpoint := ctx.restore.Status.Checkpoint
+	if checkpoint == nil {
+		return none
+	}
+
+	resourceIndex, checkpointIndex := -1, -1
+	for i, prioritized := range ctx.prioritizedResources {
+		switch prioritized.String() {
+		case resource:
+			resourceIndex = i
+		case checkpoint.Resource:
+			checkpointIndex = i
+		}
+	}
+	if resource == checkpoint.Resource {
+		checkpointIndex = resourceIndex
+	}
+
+	switch {
+	case resourceIndex < 0 || checkpointIndex < 0 || resourceIndex > checkpointIndex:
+		return none
+	case resourceIndex < checkpointIndex:
+		return all
+	case namespace < checkpoint.Namespace:
+		return all
+	case namespace > checkpoint.Namespace:
+		return none
+	}
+
+	checkpointFile := checkpoint.Name + ".json"
+	return func(file string) bool {
+		return file <= checkpointFile
+	}
+}
+
+// olderRevisions returns the names of the items in resourcePath's files that aren't
+// the latest revision of their family. Files that can't be decoded are left for
+// restoreResource to report.
+func (ctx *context) olderRevisions(resourcePath string, files []os.FileInfo) sets.String {
+	var items []*unstructured.Unstructured
+	for _, file := range files {
+		obj, err := ctx.unmarshal(filepath.Join(resourcePath, file.Name()))
+		if err != nil {
+			continue
+		}
+		items = append(items, obj)
+	}
+
+	return olderRevisions(items)
+}
+
 func (ctx *context) restoreResource(resource, namespace, resourcePath string) (api.RestoreResult, api.RestoreResult) {
 	warnings, errs := api.RestoreResult{}, api.RestoreResult{}
 
@@ -521,11 +1086,16 @@ func (ctx *context) restoreResource(resource, namespace, resourcePath string) (a
 		return warnings, errs
 	}
 
+	if ctx.restore.Spec.Prune && namespace != "" {
+		ctx.recordBackedUpItems(resource, namespace, files)
+	}
+
 	var (
 		resourceClient    client.Dynamic
 		waiter            *resourceWaiter
 		groupResource     = schema.ParseGroupResource(resource)
 		applicableActions []resolvedAction
+		applyUnsupported  bool
 	)
 
 	// pre-filter the actions based on namespace & resource includes/excludes since
@@ -542,7 +1112,30 @@ func (ctx *context) restoreResource(resource, namespace, resourcePath string) (a
 		applicableActions = append(applicableActions, action)
 	}
 
+	var olderRevisionNames sets.String
+	if ctx.restore.Spec.LatestRevisionsOnly && ctx.versionedResources.Has(resource) {
+		olderRevisionNames = ctx.olderRevisions(resourcePath, files)
+	}
+
+	// checkpoints identify items by their namespace in the backup
+	var backupNamespace string
+	if namespace != "" {
+		backupNamespace = filepath.Base(resourcePath)
+	}
+	restoredBeforeCheckpoint := ctx.restoredBeforeCheckpoint(resource, backupNamespace)
+
 	for _, file := range files {
+		if restoredBeforeCheckpoint(file.Name()) {
+			ctx.progress.itemRestored()
+			continue
+		}
+
+		ctx.progress.itemStarted(api.RestoreCheckpoint{
+			Resource:  resource,
+			Namespace: backupNamespace,
+			Name:      strings.TrimSuffix(file.Name(), ".json"),
+		})
+
 		fullPath := filepath.Join(resourcePath, file.Name())
 		obj, err := ctx.unmarshal(fullPath)
 		if err != nil {
@@ -554,11 +1147,30 @@ func (ctx *context) restoreResource(resource, namespace, resourcePath string) (a
 			continue
 		}
 
+		if ctx.restore.Spec.SkipLiveOwnedItems && namespace != "" {
+			if owner, live := ctx.liveOwner(obj, namespace); live {
+				ctx.infof("%s/%s is owned by %s %s, which exists - skipping", obj.GetNamespace(), obj.GetName(), owner.Kind, owner.Name)
+				addToResult(&warnings, namespace, fmt.Errorf("skipped %s %s because its owner, %s %s, already exists", &groupResource, obj.GetName(), owner.Kind, owner.Name))
+				continue
+			}
+		}
+
 		if hasControllerOwner(obj.GetOwnerReferences()) {
 			ctx.infof("%s/%s has a controller owner - skipping", obj.GetNamespace(), obj.GetName())
 			continue
 		}
 
+		// backups taken before they were excluded may contain the VolumeSnapshots of other backups
+		if csi.IsBackupVolumeSnapshot(groupResource, obj) {
+			ctx.infof("%s/%s is a VolumeSnapshot Ark created for a backup - skipping", obj.GetNamespace(), obj.GetName())
+			continue
+		}
+
+		if olderRevisionNames.Has(obj.GetName()) {
+			ctx.infof("%s/%s is not the latest revision - skipping", obj.GetNamespace(), obj.GetName())
+			continue
+		}
+
 		if resourceClient == nil {
 			// initialize client for this Resource. we need
 			// metadata from an object to do this.
@@ -578,6 +1190,12 @@ func (ctx *context) restoreResource(resource, namespace, resourcePath string) (a
 		}
 
 		if groupResource.Group == "" && groupResource.Resource == "persistentvolumes" {
+			// PVs with CSI snapshots are provisioned from their claims' snapshots instead
+			if ctx.csiSnapshot(obj.GetName()) != nil {
+				ctx.infof("PersistentVolume %s is restored from the CSI snapshot of its claim - skipping", obj.GetName())
+				continue
+			}
+
 			// restore the PV from snapshot (if applicable)
 			updatedObj, err := ctx.executePVAction(obj)
 			if err != nil {
@@ -599,6 +1217,7 @@ func (ctx *context) restoreResource(resource, namespace, resourcePath string) (a
 			}
 		}
 
+		var waitConditions []*WaitCondition
 		for _, action := range applicableActions {
 			if !action.selector.Matches(labels.Set(obj.GetLabels())) {
 				continue
@@ -626,8 +1245,28 @@ func (ctx *context) restoreResource(resource, namespace, resourcePath string) (a
 			}
 
 			obj = unstructuredObj
+
+			if waitAction, ok := action.ItemAction.(WaitConditionItemAction); ok {
+				condition, err := waitAction.WaitCondition(obj, ctx.restore)
+				if err != nil {
+					addToResult(&warnings, namespace, fmt.Errorf("error getting wait condition for %s: %v", fullPath, err))
+				} else if condition != nil {
+					waitConditions = append(waitConditions, condition)
+				}
+			}
+		}
+
+		// record when the item was originally created, since creationTimestamp is
+		// cleared below. Items that were themselves restored keep the time the
+		// first restore recorded.
+		if created := obj.GetCreationTimestamp(); !created.IsZero() {
+			if _, found := obj.GetAnnotations()[api.OriginalCreationTimestampAnnotation]; !found {
+				addAnnotation(obj, api.OriginalCreationTimestampAnnotation, created.UTC().Format(time.RFC3339))
+			}
 		}
 
+		originalUID := obj.GetUID()
+
 		// clear out non-core metadata fields & status
 		if obj, err = resetMetadataAndStatus(obj); err != nil {
 			addToResult(&errs, namespace, err)
@@ -640,12 +1279,72 @@ func (ctx *context) restoreResource(resource, namespace, resourcePath string) (a
 			obj.SetNamespace(namespace)
 		}
 
-		// add an ark-restore label to each resource for easy ID
-		addLabel(obj, api.RestoreLabelKey, ctx.restore.Name)
+		if originalName := obj.GetName(); ctx.renames.rename(groupResource.String(), obj) {
+			ctx.infof("Renaming %s %s to %s", obj.GroupVersionKind().Kind, originalName, obj.GetName())
+		}
+		if n := ctx.renames.renameReferences(obj); n > 0 {
+			ctx.infof("Updated %d references of %s %s to renamed items", n, obj.GroupVersionKind().Kind, obj.GetName())
+		}
+
+		if groupResource.Group == "" && groupResource.Resource == "persistentvolumeclaims" {
+			if err := ctx.restoreClaimFromCSISnapshot(obj); err != nil {
+				addToResult(&errs, namespace, fmt.Errorf("error restoring %s from its CSI snapshot: %v", fullPath, err))
+				continue
+			}
+		}
+
+		for i, modifier := range ctx.modifiers {
+			if !modifier.appliesTo(groupResource.String(), namespace, obj) {
+				continue
+			}
+
+			// a modifier that can't be applied doesn't stop the item from being restored unmodified
+			modifiedObj, err := modifier.apply(obj)
+			if err != nil {
+				addToResult(&warnings, namespace, fmt.Errorf("error applying resource modifier %d to %s: %v", i, fullPath, err))
+				continue
+			}
+			obj = modifiedObj
+		}
+
+		// add ark-restore and backup-name labels to each resource for easy ID
+		ctx.addRestoreLabels(obj)
+		ctx.addSpecifiedMetadata(obj)
 
 		ctx.infof("Restoring %s: %v", obj.GroupVersionKind().Kind, obj.GetName())
-		_, restoreErr := resourceClient.Create(obj)
+		var (
+			restored   *unstructured.Unstructured
+			restoreErr error
+		)
+		if ctx.restore.Spec.ApplyMethod == api.RestoreApplyMethodServerSideApply && !applyUnsupported {
+			restored, restoreErr = resourceClient.Apply(obj, ctx.fieldManager(), false)
+			if isApplyUnsupported(restoreErr) {
+				ctx.infof("Server-side apply is not supported for %v, creating items instead", &groupResource)
+				applyUnsupported = true
+				restored, restoreErr = ctx.create(resourceClient, obj, originalUID)
+			} else if apierrors.IsConflict(restoreErr) {
+				if ctx.restore.Spec.ApplyConflictPolicy != api.ApplyConflictPolicyForce {
+					e := errors.Errorf("not restored: %s has fields managed by another field manager: %v", obj.GetName(), restoreErr)
+					addToResult(&warnings, namespace, e)
+					continue
+				}
+
+				ctx.infof("Forcing apply of %s, taking ownership of its fields managed by another field manager: %v", obj.GetName(), restoreErr)
+				restored, restoreErr = resourceClient.Apply(obj, ctx.fieldManager(), true)
+			}
+		} else {
+			restored, restoreErr = ctx.create(resourceClient, obj, originalUID)
+		}
 		if apierrors.IsAlreadyExists(restoreErr) {
+			if fields, ok := mergeableDataFields[groupResource]; ok {
+				if strategy, ok := ctx.restore.Spec.MergeStrategies[groupResource.Resource]; ok {
+					if err := ctx.mergeIntoExisting(resourceClient, obj, fields, strategy); err != nil {
+						addToResult(&errs, namespace, fmt.Errorf("error merging %s into existing %s: %v", fullPath, obj.GetName(), err))
+					}
+					continue
+				}
+			}
+
 			equal := false
 			if fromCluster, err := resourceClient.Get(obj.GetName(), metav1.GetOptions{}); err == nil {
 				equal, err = objectsAreEqual(fromCluster, obj)
@@ -669,9 +1368,26 @@ func (ctx *context) restoreResource(resource, namespace, resourcePath string) (a
 			continue
 		}
 
+		ctx.recordUID(groupResource, namespace, obj.GetName(), originalUID, restored)
+
 		if waiter != nil {
 			waiter.RegisterItem(obj.GetName())
 		}
+
+		if ctx.restore.Spec.Verify {
+			ctx.restoredItems = append(ctx.restoredItems, restoredItem{
+				client:    resourceClient,
+				resource:  groupResource.String(),
+				namespace: namespace,
+				obj:       obj.DeepCopy(),
+			})
+		}
+
+		for _, condition := range waitConditions {
+			if err := ctx.waitForCondition(resourceClient, obj.GetName(), condition); err != nil {
+				addToResult(&warnings, namespace, fmt.Errorf("error waiting for %s: %v", fullPath, err))
+			}
+		}
 	}
 
 	if waiter != nil {
@@ -720,12 +1436,25 @@ func (ctx *context) executePVAction(obj *unstructured.Unstructured) (*unstructur
 		return nil, errors.New("you must configure a persistentVolumeProvider to restore PersistentVolumes from snapshots")
 	}
 
-	ctx.infof("restoring PersistentVolume %s from SnapshotID %s", pvName, backupInfo.SnapshotID)
-	volumeID, err := ctx.snapshotService.CreateVolumeFromSnapshot(backupInfo.SnapshotID, backupInfo.Type, backupInfo.AvailabilityZone, backupInfo.Iops)
-	if err != nil {
-		return nil, err
+	volumeID := ctx.restore.Status.RestoredVolumes[pvName]
+	if volumeID == "" {
+		volumeID = ctx.resumedVolumes[pvName]
+	}
+	if volumeID != "" {
+		ctx.infof("reusing volume %s previously restored for PersistentVolume %s", volumeID, pvName)
+	} else {
+		ctx.recordVolume(pvName, "")
+
+		ctx.infof("restoring PersistentVolume %s from SnapshotID %s", pvName, backupInfo.SnapshotID)
+		tags := map[string]string{restoredVolumeTag: ctx.restoredVolumeTagValue(pvName)}
+		volumeID, err = ctx.snapshotService.CreateVolumeFromSnapshot(backupInfo.SnapshotID, backupInfo.Type, backupInfo.AvailabilityZone, backupInfo.Iops, tags)
+		if err != nil {
+			return nil, err
+		}
+		ctx.infof("successfully restored PersistentVolume %s from snapshot", pvName)
+
+		ctx.recordVolume(pvName, volumeID)
 	}
-	ctx.infof("successfully restored PersistentVolume %s from snapshot", pvName)
 
 	updated1, err := ctx.snapshotService.SetVolumeID(obj, volumeID)
 	if err != nil {
@@ -740,6 +1469,192 @@ func (ctx *context) executePVAction(obj *unstructured.Unstructured) (*unstructur
 	return updated2, nil
 }
 
+// csiSnapshot returns the CSI snapshot of the PersistentVolume named pvName that the PV
+// should be restored from, or nil if it has none or isn't being restored from a snapshot.
+func (ctx *context) csiSnapshot(pvName string) *api.CSISnapshotInfo {
+	if boolptr.IsSetToFalse(ctx.backup.Spec.SnapshotVolumes) || boolptr.IsSetToFalse(ctx.restore.Spec.RestorePVs) {
+		return nil
+	}
+
+	backupInfo, found := ctx.backup.Status.VolumeBackups[pvName]
+	if !found {
+		return nil
+	}
+
+	return backupInfo.CSISnapshot
+}
+
+// boundClaimAnnotations are the annotations Kubernetes sets on a PersistentVolumeClaim
+// once it's bound, which would stop a claim restored from a CSI snapshot from being
+// provisioned.
+var boundClaimAnnotations = []string{
+	"pv.kubernetes.io/bind-completed",
+	"pv.kubernetes.io/bound-by-controller",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/storage-provisioner",
+}
+
+// restoreClaimFromCSISnapshot updates the PersistentVolumeClaim obj, if its volume has a
+// CSI snapshot, to be provisioned from a VolumeSnapshot of it created in the claim's
+// namespace rather than bound to the restored volume.
+func (ctx *context) restoreClaimFromCSISnapshot(obj *unstructured.Unstructured) error {
+	pvName, _ := unstructured.NestedString(obj.Object, "spec", "volumeName")
+	if pvName == "" {
+		return nil
+	}
+
+	snapshot := ctx.csiSnapshot(pvName)
+	if snapshot == nil {
+		return nil
+	}
+
+	if ctx.csiSnapshotter == nil {
+		return errors.New("you must enable csiSnapshots to restore PersistentVolumeClaims from CSI snapshots")
+	}
+
+	name := ctx.restore.Name + "-" + obj.GetName()
+	snapshotLabels := map[string]string{
+		api.RestoreLabelKey:         ctx.restore.Name,
+		api.RestoredFromBackupLabel: ctx.restore.Spec.BackupName,
+	}
+
+	ctx.infof("Creating VolumeSnapshot %s/%s from the CSI snapshot of PersistentVolume %s", obj.GetNamespace(), name, pvName)
+	if err := ctx.csiSnapshotter.CreateVolumeSnapshot(snapshot, obj.GetNamespace(), name, snapshotLabels); err != nil {
+		return err
+	}
+
+	spec, err := collections.GetMap(obj.UnstructuredContent(), "spec")
+	if err != nil {
+		return err
+	}
+
+	delete(spec, "volumeName")
+	spec["dataSource"] = map[string]interface{}{
+		"apiGroup": csi.GroupVersion.Group,
+		"kind":     "VolumeSnapshot",
+		"name":     name,
+	}
+
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		for _, key := range boundClaimAnnotations {
+			delete(annotations, key)
+		}
+		obj.SetAnnotations(annotations)
+	}
+
+	return nil
+}
+
+// restoredVolumeTagValue returns the value of the restoredVolumeTag of the volume created
+// for the PersistentVolume named pvName.
+func (ctx *context) restoredVolumeTagValue(pvName string) string {
+	return fmt.Sprintf("%s/%s", ctx.restore.UID, pvName)
+}
+
+// recordVolume records volumeID for the PersistentVolume with the volume recorder, if there
+// is one. Recording is best-effort: if it fails, the restore carries on, but resuming it may
+// create a duplicate volume.
+func (ctx *context) recordVolume(pvName, volumeID string) {
+	if ctx.volumeRecorder == nil {
+		return
+	}
+
+	if err := ctx.volumeRecorder.RecordVolume(pvName, volumeID); err != nil {
+		ctx.logger.WithError(err).WithField("persistentVolume", pvName).Warn("Error recording restored volume")
+	}
+}
+
+// restoreFieldManager is the field manager used when restoring items with server-side apply,
+// unless the restore specifies another.
+const restoreFieldManager = "ark-restore"
+
+// fieldManager returns the field manager to restore items with when using server-side apply.
+func (ctx *context) fieldManager() string {
+	if ctx.restore.Spec.FieldManager != "" {
+		return ctx.restore.Spec.FieldManager
+	}
+	return restoreFieldManager
+}
+
+// isApplyUnsupported returns true if err indicates that the API server doesn't support
+// server-side apply for a resource.
+func isApplyUnsupported(err error) bool {
+	if apierrors.IsMethodNotSupported(err) {
+		return true
+	}
+
+	status, ok := err.(apierrors.APIStatus)
+	return ok && status.Status().Code == http.StatusUnsupportedMediaType
+}
+
+// mergeableDataFields maps each resource that supports a merge strategy
+// to the fields containing its key/value data.
+var mergeableDataFields = map[schema.GroupResource][]string{
+	{Group: "", Resource: "configmaps"}: {"data", "binaryData"},
+	{Group: "", Resource: "secrets"}:    {"data"},
+}
+
+// mergeIntoExisting merges the data of the restored object into the existing
+// object of the same name in the cluster according to strategy, and updates it.
+func (ctx *context) mergeIntoExisting(resourceClient client.Dynamic, obj *unstructured.Unstructured, fields []string, strategy api.MergeStrategy) error {
+	existing, err := resourceClient.Get(obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	conflicts := mergeData(existing, obj, fields, strategy)
+	for _, key := range conflicts {
+		if strategy == api.MergeStrategyExistingWins {
+			ctx.infof("Key %s of %s conflicts with the existing value, keeping the existing value", key, obj.GetName())
+		} else {
+			ctx.infof("Key %s of %s conflicts with the existing value, replacing it with the restored value", key, obj.GetName())
+		}
+	}
+
+	ctx.addRestoreLabels(existing)
+
+	ctx.infof("Merging restored data into existing %s: %v", obj.GroupVersionKind().Kind, obj.GetName())
+	if _, err := resourceClient.Update(existing); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// mergeData adds each key of the given fields of restored to the same field of
+// existing. Keys present in both with different values are resolved according
+// to strategy and returned, sorted, as "<field>.<key>".
+func mergeData(existing, restored *unstructured.Unstructured, fields []string, strategy api.MergeStrategy) []string {
+	var conflicts []string
+
+	for _, field := range fields {
+		restoredData, _ := restored.UnstructuredContent()[field].(map[string]interface{})
+		if len(restoredData) == 0 {
+			continue
+		}
+
+		existingData, _ := existing.UnstructuredContent()[field].(map[string]interface{})
+		if existingData == nil {
+			existingData = make(map[string]interface{})
+			existing.UnstructuredContent()[field] = existingData
+		}
+
+		for key, restoredVal := range restoredData {
+			if existingVal, ok := existingData[key]; ok && !equality.Semantic.DeepEqual(existingVal, restoredVal) {
+				conflicts = append(conflicts, field+"."+key)
+				if strategy == api.MergeStrategyExistingWins {
+					continue
+				}
+			}
+			existingData[key] = restoredVal
+		}
+	}
+
+	sort.Strings(conflicts)
+
+	return conflicts
+}
+
 // objectsAreEqual takes two unstructured objects and checks for equality.
 // The fromCluster object is mutated to remove any insubstantial runtime
 // information that won't match
@@ -750,10 +1665,21 @@ func objectsAreEqual(fromCluster, fromBackup *unstructured.Unstructured) (bool,
 		return false, err
 	}
 
-	// We know the cluster won't have the restore name label, so
-	// copy it over from the backup
-	restoreName := fromBackup.GetLabels()[api.RestoreLabelKey]
-	addLabel(fromCluster, api.RestoreLabelKey, restoreName)
+	// We know the cluster won't have the restore labels, so copy them
+	// over from the backup, if the restore added them
+	for _, key := range []string{api.RestoreLabelKey, api.RestoredFromBackupLabel} {
+		if val, found := fromBackup.GetLabels()[key]; found {
+			addLabel(fromCluster, key, val)
+		}
+	}
+
+	// Likewise for the original creation timestamp annotation, if the backup
+	// has one and the cluster doesn't
+	if createdAt, found := fromBackup.GetAnnotations()[api.OriginalCreationTimestampAnnotation]; found {
+		if _, found := fromCluster.GetAnnotations()[api.OriginalCreationTimestampAnnotation]; !found {
+			addAnnotation(fromCluster, api.OriginalCreationTimestampAnnotation, createdAt)
+		}
+	}
 
 	// If there are no specific actions needed based on the type, simply check for equality.
 	return equality.Semantic.DeepEqual(fromBackup, fromCluster), nil
@@ -768,6 +1694,38 @@ func isPVReady(obj runtime.Unstructured) bool {
 	return phase == string(v1.VolumeAvailable)
 }
 
+// create creates obj, with the UID it was backed up with if the restore's spec.preserveUIDs
+// is set. obj itself isn't modified, so it can still be compared with an existing item.
+func (ctx *context) create(resourceClient client.Dynamic, obj *unstructured.Unstructured, originalUID types.UID) (*unstructured.Unstructured, error) {
+	if ctx.restore.Spec.PreserveUIDs && originalUID != "" {
+		obj = obj.DeepCopy()
+		obj.SetUID(originalUID)
+	}
+
+	return resourceClient.Create(obj)
+}
+
+// recordUID records in the restore's status.uidMappings when a restored item's UID differs
+// from its original UID, if the restore's spec.preserveUIDs is set.
+func (ctx *context) recordUID(groupResource schema.GroupResource, namespace, name string, originalUID types.UID, restored *unstructured.Unstructured) {
+	if !ctx.restore.Spec.PreserveUIDs || originalUID == "" || restored == nil {
+		return
+	}
+
+	if restored.GetUID() == originalUID {
+		ctx.infof("Preserved the original UID of %s", name)
+		return
+	}
+
+	ctx.restore.Status.UIDMappings = append(ctx.restore.Status.UIDMappings, api.RestoredUIDMapping{
+		Resource:    groupResource.String(),
+		Namespace:   namespace,
+		Name:        name,
+		OriginalUID: originalUID,
+		UID:         restored.GetUID(),
+	})
+}
+
 func resetMetadataAndStatus(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
 	metadata, err := collections.GetMap(obj.UnstructuredContent(), "metadata")
 	if err != nil {
@@ -790,6 +1748,30 @@ func resetMetadataAndStatus(obj *unstructured.Unstructured) (*unstructured.Unstr
 }
 
 // addLabel applies the specified key/value to an object as a label.
+// addRestoreLabels labels obj with the names of the restore and its backup, unless the
+// restore's spec.labelRestoredItems is false.
+func (ctx *context) addRestoreLabels(obj *unstructured.Unstructured) {
+	if boolptr.IsSetToFalse(ctx.restore.Spec.LabelRestoredItems) {
+		return
+	}
+
+	addLabel(obj, api.RestoreLabelKey, ctx.restore.Name)
+	if ctx.restore.Spec.BackupName != "" {
+		addLabel(obj, api.RestoredFromBackupLabel, ctx.restore.Spec.BackupName)
+	}
+}
+
+// addSpecifiedMetadata adds the restore's spec.addedLabels and spec.addedAnnotations to
+// the item, replacing any it already has with the same keys.
+func (ctx *context) addSpecifiedMetadata(obj *unstructured.Unstructured) {
+	for key, val := range ctx.restore.Spec.AddedLabels {
+		addLabel(obj, key, val)
+	}
+	for key, val := range ctx.restore.Spec.AddedAnnotations {
+		addAnnotation(obj, key, val)
+	}
+}
+
 func addLabel(obj *unstructured.Unstructured, key string, val string) {
 	labels := obj.GetLabels()
 
@@ -802,6 +1784,19 @@ func addLabel(obj *unstructured.Unstructured, key string, val string) {
 	obj.SetLabels(labels)
 }
 
+// addAnnotation applies the specified key/value to an object as an annotation.
+func addAnnotation(obj *unstructured.Unstructured, key string, val string) {
+	annotations := obj.GetAnnotations()
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[key] = val
+
+	obj.SetAnnotations(annotations)
+}
+
 // hasControllerOwner returns whether or not an object has a controller
 // owner ref. Used to identify whether or not an object should be explicitly
 // recreated during a restore.
@@ -832,20 +1827,30 @@ func (ctx *context) unmarshal(filePath string) (*unstructured.Unstructured, erro
 	return &obj, nil
 }
 
-// unzipAndExtractBackup extracts a reader on a gzipped tarball to a local temp directory
+// unzipAndExtractBackup extracts a reader on a tarball compressed in the backup's compression
+// format to a local temp directory
 func (ctx *context) unzipAndExtractBackup(src io.Reader) (string, error) {
-	gzr, err := gzip.NewReader(src)
+	codec, err := compression.CodecFor(ctx.backup.Status.CompressionFormat)
 	if err != nil {
-		ctx.infof("error creating gzip reader: %v", err)
+		ctx.infof("error getting compression codec: %v", err)
 		return "", err
 	}
-	defer gzr.Close()
 
-	return ctx.readBackup(tar.NewReader(gzr))
+	r, err := codec.NewReader(src)
+	if err != nil {
+		ctx.infof("error creating decompressing reader: %v", err)
+		return "", err
+	}
+	defer r.Close()
+
+	return ctx.readBackup(tar.NewReader(r))
 }
 
 // readBackup extracts a tar reader to a local directory/file tree within a
-// temp directory.
+// temp directory. Only the items the restore could include are extracted, so
+// restoring a few items from a large backup doesn't write out all of it. The
+// resources the backup has items of are recorded in backupResources, whether
+// or not they're extracted.
 func (ctx *context) readBackup(tarRdr *tar.Reader) (string, error) {
 	dir, err := ctx.fileSystem.TempDir("", "")
 	if err != nil {
@@ -853,6 +1858,14 @@ func (ctx *context) readBackup(tarRdr *tar.Reader) (string, error) {
 		return "", err
 	}
 
+	resources := sets.NewString()
+	for _, resource := range ctx.prioritizedResources {
+		resources.Insert(resource.String())
+	}
+	namespaceFilter := collections.NewIncludesExcludes().
+		Includes(ctx.restore.Spec.IncludedNamespaces...).
+		Excludes(ctx.restore.Spec.ExcludedNamespaces...)
+
 	for {
 		header, err := tarRdr.Next()
 
@@ -864,6 +1877,17 @@ func (ctx *context) readBackup(tarRdr *tar.Reader) (string, error) {
 			return "", err
 		}
 
+		if path, ok := pkgbackup.ParseItemPath(header.Name); ok && path.IsItem() {
+			if ctx.backupResources == nil {
+				ctx.backupResources = sets.NewString()
+			}
+			ctx.backupResources.Insert(path.Resource)
+		}
+
+		if !shouldExtract(header.Name, resources, namespaceFilter) {
+			continue
+		}
+
 		target := filepath.Join(dir, header.Name)
 
 		switch header.Typeflag {
@@ -887,14 +1911,40 @@ func (ctx *context) readBackup(tarRdr *tar.Reader) (string, error) {
 			if err != nil {
 				return "", err
 			}
-			defer file.Close()
 
-			if _, err := io.Copy(file, tarRdr); err != nil {
+			_, err = io.Copy(file, tarRdr)
+			file.Close()
+			if err != nil {
 				ctx.infof("error copying: %v", err)
 				return "", err
 			}
 		}
 	}
 
+	for _, resource := range ctx.backupResources.List() {
+		if resource != "namespaces" && !resources.Has(resource) {
+			ctx.infof("Not extracting items of resource %s, which the restore doesn't include or the cluster doesn't have", resource)
+		}
+	}
+
 	return dir, nil
 }
+
+// shouldExtract returns true if the tarball entry with the specified name is needed to
+// restore the specified resources from the namespaces that namespaceFilter includes.
+// Namespaces themselves, and entries other than items, are always needed.
+func shouldExtract(name string, resources sets.String, namespaceFilter *collections.IncludesExcludes) bool {
+	path, ok := pkgbackup.ParseItemPath(name)
+	if !ok || path.Resource == "namespaces" {
+		return true
+	}
+	if !resources.Has(path.Resource) {
+		return false
+	}
+
+	if path.Namespace != "" {
+		return namespaceFilter.ShouldInclude(path.Namespace)
+	}
+
+	return true
+}
@@ -17,22 +17,32 @@ limitations under the License.
 package restore
 
 import (
+	"archive/tar"
+	"bytes"
 	"encoding/json"
 	"io"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	api "github.com/heptio/ark/pkg/apis/ark/v1"
@@ -116,6 +126,43 @@ func TestPrioritizeResources(t *testing.T) {
 	}
 }
 
+func TestMoveToEnd(t *testing.T) {
+	configMaps := schema.GroupResource{Resource: "configmaps"}
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	services := schema.GroupResource{Resource: "services"}
+	apiServices := schema.GroupResource{Group: "apiregistration.k8s.io", Resource: "apiservices"}
+	validatingWebhooks := schema.GroupResource{Group: "admissionregistration.k8s.io", Resource: "validatingwebhookconfigurations"}
+	mutatingWebhooks := schema.GroupResource{Group: "admissionregistration.k8s.io", Resource: "mutatingwebhookconfigurations"}
+
+	tests := []struct {
+		name      string
+		resources []schema.GroupResource
+		expected  []schema.GroupResource
+	}{
+		{
+			name:      "no webhook resources leaves the order unchanged",
+			resources: []schema.GroupResource{services, configMaps, deployments},
+			expected:  []schema.GroupResource{services, configMaps, deployments},
+		},
+		{
+			name:      "webhook resources are moved to the end in a fixed order",
+			resources: []schema.GroupResource{validatingWebhooks, configMaps, apiServices, deployments, mutatingWebhooks, services},
+			expected:  []schema.GroupResource{configMaps, deployments, services, apiServices, mutatingWebhooks, validatingWebhooks},
+		},
+		{
+			name:      "webhook resources that aren't being restored aren't added",
+			resources: []schema.GroupResource{validatingWebhooks, services},
+			expected:  []schema.GroupResource{services, validatingWebhooks},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, moveToEnd(test.resources, webhookResources))
+		})
+	}
+}
+
 func TestRestoreNamespaceFiltering(t *testing.T) {
 	tests := []struct {
 		name                 string
@@ -130,7 +177,7 @@ func TestRestoreNamespaceFiltering(t *testing.T) {
 			fileSystem:       newFakeFileSystem().WithDirectories("bak/resources/nodes/cluster", "bak/resources/secrets/namespaces/a", "bak/resources/secrets/namespaces/b", "bak/resources/secrets/namespaces/c"),
 			baseDir:          "bak",
 			restore:          &api.Restore{Spec: api.RestoreSpec{IncludedNamespaces: []string{"*"}}},
-			expectedReadDirs: []string{"bak/resources", "bak/resources/nodes/cluster", "bak/resources/secrets/namespaces", "bak/resources/secrets/namespaces/a", "bak/resources/secrets/namespaces/b", "bak/resources/secrets/namespaces/c"},
+			expectedReadDirs: []string{"bak/resources", "bak/resources/secrets/namespaces", "bak/resources/nodes/cluster", "bak/resources/secrets/namespaces", "bak/resources/secrets/namespaces/a", "bak/resources/secrets/namespaces/b", "bak/resources/secrets/namespaces/c"},
 			prioritizedResources: []schema.GroupResource{
 				{Resource: "nodes"},
 				{Resource: "secrets"},
@@ -141,7 +188,7 @@ func TestRestoreNamespaceFiltering(t *testing.T) {
 			fileSystem:       newFakeFileSystem().WithDirectories("bak/resources/nodes/cluster", "bak/resources/secrets/namespaces/a", "bak/resources/secrets/namespaces/b", "bak/resources/secrets/namespaces/c"),
 			baseDir:          "bak",
 			restore:          &api.Restore{Spec: api.RestoreSpec{IncludedNamespaces: []string{"b", "c"}}},
-			expectedReadDirs: []string{"bak/resources", "bak/resources/nodes/cluster", "bak/resources/secrets/namespaces", "bak/resources/secrets/namespaces/b", "bak/resources/secrets/namespaces/c"},
+			expectedReadDirs: []string{"bak/resources", "bak/resources/secrets/namespaces", "bak/resources/nodes/cluster", "bak/resources/secrets/namespaces", "bak/resources/secrets/namespaces/b", "bak/resources/secrets/namespaces/c"},
 			prioritizedResources: []schema.GroupResource{
 				{Resource: "nodes"},
 				{Resource: "secrets"},
@@ -152,7 +199,7 @@ func TestRestoreNamespaceFiltering(t *testing.T) {
 			fileSystem:       newFakeFileSystem().WithDirectories("bak/resources/nodes/cluster", "bak/resources/secrets/namespaces/a", "bak/resources/secrets/namespaces/b", "bak/resources/secrets/namespaces/c"),
 			baseDir:          "bak",
 			restore:          &api.Restore{Spec: api.RestoreSpec{IncludedNamespaces: []string{"*"}, ExcludedNamespaces: []string{"a"}}},
-			expectedReadDirs: []string{"bak/resources", "bak/resources/nodes/cluster", "bak/resources/secrets/namespaces", "bak/resources/secrets/namespaces/b", "bak/resources/secrets/namespaces/c"},
+			expectedReadDirs: []string{"bak/resources", "bak/resources/secrets/namespaces", "bak/resources/nodes/cluster", "bak/resources/secrets/namespaces", "bak/resources/secrets/namespaces/b", "bak/resources/secrets/namespaces/c"},
 			prioritizedResources: []schema.GroupResource{
 				{Resource: "nodes"},
 				{Resource: "secrets"},
@@ -168,7 +215,7 @@ func TestRestoreNamespaceFiltering(t *testing.T) {
 					ExcludedNamespaces: []string{"b"},
 				},
 			},
-			expectedReadDirs: []string{"bak/resources", "bak/resources/nodes/cluster", "bak/resources/secrets/namespaces", "bak/resources/secrets/namespaces/a", "bak/resources/secrets/namespaces/c"},
+			expectedReadDirs: []string{"bak/resources", "bak/resources/secrets/namespaces", "bak/resources/nodes/cluster", "bak/resources/secrets/namespaces", "bak/resources/secrets/namespaces/a", "bak/resources/secrets/namespaces/c"},
 			prioritizedResources: []schema.GroupResource{
 				{Resource: "nodes"},
 				{Resource: "secrets"},
@@ -201,6 +248,239 @@ func TestRestoreNamespaceFiltering(t *testing.T) {
 	}
 }
 
+func TestRestoreProgress(t *testing.T) {
+	fileSystem := newFakeFileSystem().
+		WithDirectories("bak/resources/nodes/cluster", "bak/resources/secrets/namespaces/a", "bak/resources/secrets/namespaces/b").
+		WithFile("bak/resources/nodes/cluster/node-1.json", []byte("{}")).
+		WithFile("bak/resources/secrets/namespaces/a/secret-1.json", []byte("{}")).
+		WithFile("bak/resources/secrets/namespaces/a/secret-2.json", []byte("{}")).
+		WithFile("bak/resources/secrets/namespaces/b/secret-1.json", []byte("{}"))
+
+	progress := &Progress{}
+	ctx := &context{
+		restore:         &api.Restore{Spec: api.RestoreSpec{ExcludedNamespaces: []string{"b"}}},
+		namespaceClient: &fakeNamespaceClient{},
+		fileSystem:      fileSystem,
+		logger:          arktest.NewLogger(),
+		prioritizedResources: []schema.GroupResource{
+			{Resource: "nodes"},
+			{Resource: "secrets"},
+		},
+		selector: labels.NewSelector(),
+		progress: progress,
+	}
+
+	// the items can't be decoded, but they're still processed
+	ctx.restoreFromDir("bak")
+
+	assert.Equal(t, api.RestoreProgress{TotalItems: 3, ItemsRestored: 3}, progress.Get())
+}
+
+func TestRestoreNamespaceSelector(t *testing.T) {
+	fileSystem := newFakeFileSystem().
+		WithDirectories("bak/resources/namespaces/cluster", "bak/resources/secrets/namespaces/a", "bak/resources/secrets/namespaces/b", "bak/resources/secrets/namespaces/c").
+		WithFile("bak/resources/namespaces/cluster/a.json", []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"a","labels":{"tier":"critical"}}}`)).
+		WithFile("bak/resources/namespaces/cluster/b.json", []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"b","labels":{"tier":"low"}}}`)).
+		WithFile("bak/resources/secrets/namespaces/a/secret-1.json", []byte("{}")).
+		WithFile("bak/resources/secrets/namespaces/b/secret-1.json", []byte("{}")).
+		WithFile("bak/resources/secrets/namespaces/c/secret-1.json", []byte("{}"))
+
+	progress := &Progress{}
+	namespaceClient := &fakeNamespaceClient{}
+	ctx := &context{
+		restore: &api.Restore{
+			Spec: api.RestoreSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "critical"}},
+			},
+		},
+		namespaceClient: namespaceClient,
+		fileSystem:      fileSystem,
+		logger:          arktest.NewLogger(),
+		prioritizedResources: []schema.GroupResource{
+			{Resource: "namespaces"},
+			{Resource: "secrets"},
+		},
+		selector: labels.NewSelector(),
+		progress: progress,
+	}
+
+	_, errs := ctx.restoreFromDir("bak")
+	assert.Empty(t, errs.Ark)
+
+	// c's Namespace wasn't backed up, so it has no labels to match
+	require.Len(t, namespaceClient.createdNamespaces, 1)
+	assert.Equal(t, "a", namespaceClient.createdNamespaces[0].Name)
+	assert.Equal(t, api.RestoreProgress{TotalItems: 1, ItemsRestored: 1, SkippedNamespaces: 2}, progress.Get())
+}
+
+func newCheckpointTestContext(restore *api.Restore, progress *Progress) *context {
+	fileSystem := newFakeFileSystem().
+		WithDirectories("bak/resources/nodes/cluster", "bak/resources/secrets/namespaces/a").
+		WithFile("bak/resources/nodes/cluster/node-1.json", []byte("{}")).
+		WithFile("bak/resources/secrets/namespaces/a/secret-1.json", []byte("{}")).
+		WithFile("bak/resources/secrets/namespaces/a/secret-2.json", []byte("{}")).
+		WithFile("bak/resources/secrets/namespaces/a/secret-3.json", []byte("{}")).
+		WithFile("bak/resources/secrets/namespaces/a/secret-4.json", []byte("{}"))
+
+	return &context{
+		restore:         restore,
+		namespaceClient: &fakeNamespaceClient{},
+		fileSystem:      fileSystem,
+		logger:          arktest.NewLogger(),
+		prioritizedResources: []schema.GroupResource{
+			{Resource: "nodes"},
+			{Resource: "secrets"},
+		},
+		selector: labels.NewSelector(),
+		progress: progress,
+	}
+}
+
+func TestRestoreCheckpoints(t *testing.T) {
+	var checkpoints []api.RestoreCheckpoint
+	var progresses []api.RestoreProgress
+
+	progress := &Progress{}
+	progress.CheckpointEvery(2, func(p api.RestoreProgress, c api.RestoreCheckpoint) {
+		progresses = append(progresses, p)
+		checkpoints = append(checkpoints, c)
+	})
+
+	ctx := newCheckpointTestContext(&api.Restore{}, progress)
+	ctx.restoreFromDir("bak")
+
+	assert.Equal(t, []api.RestoreCheckpoint{
+		{Resource: "secrets", Namespace: "a", Name: "secret-1", ItemsRestored: 2},
+		{Resource: "secrets", Namespace: "a", Name: "secret-3", ItemsRestored: 4},
+	}, checkpoints)
+	assert.Equal(t, []api.RestoreProgress{
+		{TotalItems: 5, ItemsRestored: 2},
+		{TotalItems: 5, ItemsRestored: 4},
+	}, progresses)
+	assert.Equal(t, api.RestoreProgress{TotalItems: 5, ItemsRestored: 5}, progress.Get())
+}
+
+func TestRestoreResumesFromCheckpoint(t *testing.T) {
+	var checkpoints []api.RestoreCheckpoint
+
+	progress := &Progress{}
+	progress.CheckpointEvery(1, func(_ api.RestoreProgress, c api.RestoreCheckpoint) {
+		checkpoints = append(checkpoints, c)
+	})
+
+	restore := &api.Restore{
+		Status: api.RestoreStatus{
+			Checkpoint: &api.RestoreCheckpoint{Resource: "secrets", Namespace: "a", Name: "secret-2", ItemsRestored: 3},
+		},
+	}
+
+	ctx := newCheckpointTestContext(restore, progress)
+	ctx.restoreFromDir("bak")
+
+	// only secret-3 and secret-4 are restored, and the first is checkpointed before the second
+	assert.Equal(t, []api.RestoreCheckpoint{
+		{Resource: "secrets", Namespace: "a", Name: "secret-3", ItemsRestored: 4},
+	}, checkpoints)
+	assert.Equal(t, api.RestoreProgress{TotalItems: 5, ItemsRestored: 5}, progress.Get())
+}
+
+func TestRestoredBeforeCheckpoint(t *testing.T) {
+	checkpoint := &api.RestoreCheckpoint{Resource: "secrets", Namespace: "b", Name: "secret-2"}
+
+	tests := []struct {
+		name       string
+		checkpoint *api.RestoreCheckpoint
+		resource   string
+		namespace  string
+		file       string
+		expected   bool
+	}{
+		{
+			name:     "no checkpoint",
+			resource: "secrets",
+			file:     "secret-1.json",
+			expected: false,
+		},
+		{
+			name:       "earlier resource",
+			checkpoint: checkpoint,
+			resource:   "nodes",
+			file:       "node-1.json",
+			expected:   true,
+		},
+		{
+			name:       "later resource",
+			checkpoint: checkpoint,
+			resource:   "configmaps",
+			namespace:  "a",
+			file:       "cm-1.json",
+			expected:   false,
+		},
+		{
+			name:       "earlier namespace",
+			checkpoint: checkpoint,
+			resource:   "secrets",
+			namespace:  "a",
+			file:       "secret-3.json",
+			expected:   true,
+		},
+		{
+			name:       "later namespace",
+			checkpoint: checkpoint,
+			resource:   "secrets",
+			namespace:  "c",
+			file:       "secret-1.json",
+			expected:   false,
+		},
+		{
+			name:       "earlier item",
+			checkpoint: checkpoint,
+			resource:   "secrets",
+			namespace:  "b",
+			file:       "secret-1.json",
+			expected:   true,
+		},
+		{
+			name:       "checkpointed item",
+			checkpoint: checkpoint,
+			resource:   "secrets",
+			namespace:  "b",
+			file:       "secret-2.json",
+			expected:   true,
+		},
+		{
+			name:       "later item",
+			checkpoint: checkpoint,
+			resource:   "secrets",
+			namespace:  "b",
+			file:       "secret-3.json",
+			expected:   false,
+		},
+		{
+			name:       "checkpoint of a resource that isn't restored",
+			checkpoint: &api.RestoreCheckpoint{Resource: "foos.example.com", Name: "foo"},
+			resource:   "nodes",
+			file:       "node-1.json",
+			expected:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := &context{
+				restore: &api.Restore{Status: api.RestoreStatus{Checkpoint: test.checkpoint}},
+				prioritizedResources: []schema.GroupResource{
+					{Resource: "nodes"},
+					{Resource: "secrets"},
+					{Resource: "configmaps"},
+				},
+			}
+
+			assert.Equal(t, test.expected, ctx.restoredBeforeCheckpoint(test.resource, test.namespace)(test.file))
+		})
+	}
+}
+
 func TestRestorePriority(t *testing.T) {
 	tests := []struct {
 		name                 string
@@ -245,7 +525,7 @@ func TestRestorePriority(t *testing.T) {
 				{Resource: "b"},
 				{Resource: "c"},
 			},
-			expectedReadDirs: []string{"bak/resources", "bak/resources/a/namespaces", "bak/resources/a/namespaces/ns-1", "bak/resources/c/namespaces", "bak/resources/c/namespaces/ns-1"},
+			expectedReadDirs: []string{"bak/resources", "bak/resources/a/namespaces", "bak/resources/c/namespaces", "bak/resources/a/namespaces", "bak/resources/a/namespaces/ns-1", "bak/resources/c/namespaces", "bak/resources/c/namespaces/ns-1"},
 		},
 		{
 			name: "error in a single resource doesn't terminate restore immediately, but is returned",
@@ -264,7 +544,7 @@ func TestRestorePriority(t *testing.T) {
 					"ns-1": {"error decoding \"bak/resources/a/namespaces/ns-1/invalid-json.json\": invalid character 'i' looking for beginning of value"},
 				},
 			},
-			expectedReadDirs: []string{"bak/resources", "bak/resources/a/namespaces", "bak/resources/a/namespaces/ns-1", "bak/resources/c/namespaces", "bak/resources/c/namespaces/ns-1"},
+			expectedReadDirs: []string{"bak/resources", "bak/resources/a/namespaces", "bak/resources/c/namespaces", "bak/resources/a/namespaces", "bak/resources/a/namespaces/ns-1", "bak/resources/c/namespaces", "bak/resources/c/namespaces/ns-1"},
 		},
 	}
 
@@ -348,6 +628,130 @@ func TestNamespaceRemapping(t *testing.T) {
 	resourceClient.AssertExpectations(t)
 }
 
+func TestEnsureNamespaceActiveWithExistingNamespacePolicy(t *testing.T) {
+	backedUp := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "ns-1",
+			Labels:      map[string]string{"team": "a", "env": "prod"},
+			Annotations: map[string]string{"owner": "a@example.com"},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		policy        api.ExistingNamespacePolicy
+		existing      *v1.Namespace
+		expectedPatch string
+	}{
+		{
+			name:     "missing namespaces are created with their backed-up metadata",
+			policy:   api.ExistingNamespacePolicyMerge,
+			existing: nil,
+		},
+		{
+			name:     "existing namespaces are left by default",
+			existing: &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1"}},
+		},
+		{
+			name:     "missing labels and annotations are added with the merge policy",
+			policy:   api.ExistingNamespacePolicyMerge,
+			existing: &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", Labels: map[string]string{"env": "staging"}}},
+			// the existing namespace's env label is kept
+			expectedPatch: `{"metadata":{"annotations":{"owner":"a@example.com"},"labels":{"team":"a"}}}`,
+		},
+		{
+			name:   "nothing is patched with the merge policy when the existing namespace has everything",
+			policy: api.ExistingNamespacePolicyMerge,
+			existing: &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "ns-1",
+				Labels:      map[string]string{"team": "b", "env": "prod"},
+				Annotations: map[string]string{"owner": "b@example.com"},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namespaceClient := &fakeNamespaceClient{existingNamespaces: map[string]*v1.Namespace{}}
+			if test.existing != nil {
+				namespaceClient.existingNamespaces[test.existing.Name] = test.existing
+			}
+
+			ctx := &context{
+				namespaceClient:        namespaceClient,
+				restore:                &api.Restore{Spec: api.RestoreSpec{ExistingNamespacePolicy: test.policy}},
+				logger:                 arktest.NewLogger(),
+				namespaceActiveTimeout: time.Second,
+			}
+
+			require.NoError(t, ctx.ensureNamespaceActive(backedUp.DeepCopy()))
+
+			if test.existing == nil {
+				require.Len(t, namespaceClient.createdNamespaces, 1)
+				assert.Equal(t, backedUp.Labels, namespaceClient.createdNamespaces[0].Labels)
+				assert.Equal(t, backedUp.Annotations, namespaceClient.createdNamespaces[0].Annotations)
+			}
+
+			if test.expectedPatch == "" {
+				assert.Empty(t, namespaceClient.patches)
+			} else {
+				assert.Equal(t, map[string]string{"ns-1": test.expectedPatch}, namespaceClient.patches)
+			}
+		})
+	}
+}
+
+func TestRestoreNamespacesThatDontBecomeActive(t *testing.T) {
+	var (
+		baseDir              = "bak"
+		restore              = &api.Restore{Spec: api.RestoreSpec{IncludedNamespaces: []string{"*"}}}
+		prioritizedResources = []schema.GroupResource{{Resource: "namespaces"}, {Resource: "configmaps"}}
+		labelSelector        = labels.NewSelector()
+		fileSystem           = newFakeFileSystem().
+					WithFile("bak/resources/configmaps/namespaces/ns-1/cm-1.json", newTestConfigMap().WithNamespace("ns-1").ToJSON()).
+					WithFile("bak/resources/configmaps/namespaces/ns-2/cm-1.json", newTestConfigMap().WithNamespace("ns-2").ToJSON()).
+					WithFile("bak/resources/configmaps/namespaces/ns-3/cm-1.json", newTestConfigMap().WithNamespace("ns-3").ToJSON())
+		namespaceClient = &fakeNamespaceClient{inactiveNamespaces: sets.NewString("ns-2")}
+	)
+
+	resourceClient := &arktest.FakeDynamicClient{}
+	dynamicFactory := &arktest.FakeDynamicFactory{}
+	resource := metav1.APIResource{Name: "configmaps", Namespaced: true}
+	gv := schema.GroupVersion{Group: "", Version: "v1"}
+	for _, ns := range []string{"ns-1", "ns-3"} {
+		expectedObjs := toUnstructured(newTestConfigMap().WithNamespace(ns).WithArkLabel("").ConfigMap)
+		resourceClient.On("Create", &expectedObjs[0]).Return(&expectedObjs[0], nil)
+		dynamicFactory.On("ClientForGroupVersionResource", gv, resource, ns).Return(resourceClient, nil)
+	}
+
+	ctx := &context{
+		dynamicFactory:         dynamicFactory,
+		fileSystem:             fileSystem,
+		selector:               labelSelector,
+		namespaceClient:        namespaceClient,
+		prioritizedResources:   prioritizedResources,
+		restore:                restore,
+		backup:                 &api.Backup{},
+		logger:                 arktest.NewLogger(),
+		namespaceConcurrency:   2,
+		namespaceActiveTimeout: 10 * time.Millisecond,
+	}
+
+	warnings, errors := ctx.restoreFromDir(baseDir)
+
+	assert.Empty(t, warnings.Ark)
+	assert.Empty(t, warnings.Namespaces)
+	assert.Empty(t, errors.Ark)
+	assert.Equal(t, map[string][]string{"ns-2": {"namespace ns-2 didn't become active within 10ms"}}, errors.Namespaces)
+
+	assert.Len(t, namespaceClient.createdNamespaces, 3)
+
+	// nothing was restored into the namespace that didn't become active
+	dynamicFactory.AssertNotCalled(t, "ClientForGroupVersionResource", gv, resource, "ns-2")
+	dynamicFactory.AssertExpectations(t)
+	resourceClient.AssertExpectations(t)
+}
+
 func TestRestoreResourceForNamespace(t *testing.T) {
 	var (
 		trueVal  = true
@@ -362,6 +766,9 @@ func TestRestoreResourceForNamespace(t *testing.T) {
 		resourcePath            string
 		labelSelector           labels.Selector
 		includeClusterResources *bool
+		latestRevisionsOnly     bool
+		backupName              string
+		labelRestoredItems      *bool
 		fileSystem              *fakeFileSystem
 		actions                 []resolvedAction
 		expectedErrors          api.RestoreResult
@@ -437,6 +844,49 @@ func TestRestoreResourceForNamespace(t *testing.T) {
 				WithFile("configmaps/cm-2.json", newNamedTestConfigMap("cm-2").ToJSON()),
 			expectedObjs: toUnstructured(newNamedTestConfigMap("cm-2").WithArkLabel("my-restore").ConfigMap),
 		},
+		{
+			name:                "older revisions are skipped when latestRevisionsOnly=true",
+			namespace:           "ns-1",
+			resourcePath:        "configmaps",
+			labelSelector:       labels.NewSelector(),
+			latestRevisionsOnly: true,
+			fileSystem: newFakeFileSystem().
+				WithFile("configmaps/foo.v1.json", newNamedTestConfigMap("foo.v1").WithLabels(map[string]string{"OWNER": "TILLER", "NAME": "foo", "VERSION": "1"}).ToJSON()).
+				WithFile("configmaps/foo.v2.json", newNamedTestConfigMap("foo.v2").WithLabels(map[string]string{"OWNER": "TILLER", "NAME": "foo", "VERSION": "2"}).ToJSON()),
+			expectedObjs: toUnstructured(newNamedTestConfigMap("foo.v2").WithLabels(map[string]string{"OWNER": "TILLER", "NAME": "foo", "VERSION": "2"}).WithArkLabel("my-restore").ConfigMap),
+		},
+		{
+			name:          "older revisions are restored when latestRevisionsOnly=false",
+			namespace:     "ns-1",
+			resourcePath:  "configmaps",
+			labelSelector: labels.NewSelector(),
+			fileSystem: newFakeFileSystem().
+				WithFile("configmaps/foo.v1.json", newNamedTestConfigMap("foo.v1").WithLabels(map[string]string{"OWNER": "TILLER", "NAME": "foo", "VERSION": "1"}).ToJSON()).
+				WithFile("configmaps/foo.v2.json", newNamedTestConfigMap("foo.v2").WithLabels(map[string]string{"OWNER": "TILLER", "NAME": "foo", "VERSION": "2"}).ToJSON()),
+			expectedObjs: toUnstructured(
+				newNamedTestConfigMap("foo.v1").WithLabels(map[string]string{"OWNER": "TILLER", "NAME": "foo", "VERSION": "1"}).WithArkLabel("my-restore").ConfigMap,
+				newNamedTestConfigMap("foo.v2").WithLabels(map[string]string{"OWNER": "TILLER", "NAME": "foo", "VERSION": "2"}).WithArkLabel("my-restore").ConfigMap,
+			),
+		},
+		{
+			name:          "items are labeled with the restore and backup names",
+			namespace:     "ns-1",
+			resourcePath:  "configmaps",
+			labelSelector: labels.NewSelector(),
+			backupName:    "backup-1",
+			fileSystem:    newFakeFileSystem().WithFile("configmaps/cm-1.json", newTestConfigMap().ToJSON()),
+			expectedObjs:  toUnstructured(newTestConfigMap().WithLabels(map[string]string{api.RestoredFromBackupLabel: "backup-1"}).WithArkLabel("my-restore").ConfigMap),
+		},
+		{
+			name:               "items aren't labeled when labelRestoredItems=false",
+			namespace:          "ns-1",
+			resourcePath:       "configmaps",
+			labelSelector:      labels.NewSelector(),
+			backupName:         "backup-1",
+			labelRestoredItems: falsePtr,
+			fileSystem:         newFakeFileSystem().WithFile("configmaps/cm-1.json", newTestConfigMap().ToJSON()),
+			expectedObjs:       toUnstructured(newTestConfigMap().ConfigMap),
+		},
 		{
 			name:          "namespace is remapped",
 			namespace:     "ns-2",
@@ -560,10 +1010,14 @@ func TestRestoreResourceForNamespace(t *testing.T) {
 					},
 					Spec: api.RestoreSpec{
 						IncludeClusterResources: test.includeClusterResources,
+						LatestRevisionsOnly:     test.latestRevisionsOnly,
+						BackupName:              test.backupName,
+						LabelRestoredItems:      test.labelRestoredItems,
 					},
 				},
-				backup: &api.Backup{},
-				logger: arktest.NewLogger(),
+				backup:             &api.Backup{},
+				logger:             arktest.NewLogger(),
+				versionedResources: sets.NewString("configmaps"),
 			}
 
 			warnings, errors := ctx.restoreResource(test.resourcePath, test.namespace, test.resourcePath)
@@ -576,6 +1030,277 @@ func TestRestoreResourceForNamespace(t *testing.T) {
 	}
 }
 
+func TestRestoreResourceWithServerSideApply(t *testing.T) {
+	expectedObjs := toUnstructured(
+		newNamedTestConfigMap("cm-1").WithArkLabel("my-restore").ConfigMap,
+		newNamedTestConfigMap("cm-2").WithArkLabel("my-restore").ConfigMap,
+	)
+
+	tests := []struct {
+		name                string
+		applyErr            error
+		applyConflictPolicy api.ApplyConflictPolicy
+		expectedApplies     int
+		expectedForced      int
+		expectedCreates     int
+		expectedWarnings    api.RestoreResult
+	}{
+		{
+			name:            "items are applied",
+			expectedApplies: 2,
+		},
+		{
+			name:            "items are created when server-side apply is unsupported",
+			applyErr:        apierrors.NewGenericServerResponse(http.StatusUnsupportedMediaType, "patch", schema.GroupResource{Resource: "configmaps"}, "cm-1", "", 0, false),
+			expectedApplies: 1,
+			expectedCreates: 2,
+		},
+		{
+			name:            "conflicting items are not restored",
+			applyErr:        apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "cm", errors.New("conflict")),
+			expectedApplies: 2,
+			expectedWarnings: api.RestoreResult{
+				Namespaces: map[string][]string{
+					"ns-1": {
+						"not restored: cm-1 has fields managed by another field manager: Operation cannot be fulfilled on configmaps \"cm\": conflict",
+						"not restored: cm-2 has fields managed by another field manager: Operation cannot be fulfilled on configmaps \"cm\": conflict",
+					},
+				},
+			},
+		},
+		{
+			name:                "conflicting items are not restored with the skip policy",
+			applyErr:            apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "cm", errors.New("conflict")),
+			applyConflictPolicy: api.ApplyConflictPolicySkip,
+			expectedApplies:     2,
+			expectedWarnings: api.RestoreResult{
+				Namespaces: map[string][]string{
+					"ns-1": {
+						"not restored: cm-1 has fields managed by another field manager: Operation cannot be fulfilled on configmaps \"cm\": conflict",
+						"not restored: cm-2 has fields managed by another field manager: Operation cannot be fulfilled on configmaps \"cm\": conflict",
+					},
+				},
+			},
+		},
+		{
+			name:                "conflicting items are applied with force with the force policy",
+			applyErr:            apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "cm", errors.New("conflict")),
+			applyConflictPolicy: api.ApplyConflictPolicyForce,
+			expectedApplies:     4,
+			expectedForced:      2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resourceClient := &arktest.FakeDynamicClient{}
+			for i := range expectedObjs {
+				resourceClient.On("Apply", &expectedObjs[i], restoreFieldManager, false).Return(&expectedObjs[i], test.applyErr)
+				resourceClient.On("Apply", &expectedObjs[i], restoreFieldManager, true).Return(&expectedObjs[i], nil)
+				resourceClient.On("Create", &expectedObjs[i]).Return(&expectedObjs[i], nil)
+			}
+
+			dynamicFactory := &arktest.FakeDynamicFactory{}
+			resource := metav1.APIResource{Name: "configmaps", Namespaced: true}
+			dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Group: "", Version: "v1"}, resource, "ns-1").Return(resourceClient, nil)
+
+			ctx := &context{
+				dynamicFactory: dynamicFactory,
+				fileSystem: newFakeFileSystem().
+					WithFile("configmaps/cm-1.json", newNamedTestConfigMap("cm-1").ToJSON()).
+					WithFile("configmaps/cm-2.json", newNamedTestConfigMap("cm-2").ToJSON()),
+				selector: labels.NewSelector(),
+				restore: &api.Restore{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: api.DefaultNamespace,
+						Name:      "my-restore",
+					},
+					Spec: api.RestoreSpec{
+						ApplyMethod:         api.RestoreApplyMethodServerSideApply,
+						ApplyConflictPolicy: test.applyConflictPolicy,
+					},
+				},
+				backup: &api.Backup{},
+				logger: arktest.NewLogger(),
+			}
+
+			warnings, errs := ctx.restoreResource("configmaps", "ns-1", "configmaps")
+
+			assert.Equal(t, test.expectedWarnings, warnings)
+			assert.Equal(t, api.RestoreResult{}, errs)
+			resourceClient.AssertNumberOfCalls(t, "Apply", test.expectedApplies)
+			resourceClient.AssertNumberOfCalls(t, "Create", test.expectedCreates)
+
+			var forced int
+			for _, call := range resourceClient.Calls {
+				if call.Method == "Apply" && call.Arguments.Bool(2) {
+					forced++
+				}
+			}
+			assert.Equal(t, test.expectedForced, forced)
+		})
+	}
+}
+
+func TestRestoreResourceWithFieldManagerAndAddedMetadata(t *testing.T) {
+	expected := toUnstructured(newNamedTestConfigMap("cm-1").WithArkLabel("my-restore").ConfigMap)[0]
+	itemLabels := expected.GetLabels()
+	itemLabels["app.kubernetes.io/instance"] = "my-app"
+	expected.SetLabels(itemLabels)
+	expected.SetAnnotations(map[string]string{"argocd.argoproj.io/compare-options": "IgnoreExtraneous"})
+
+	resourceClient := &arktest.FakeDynamicClient{}
+	resourceClient.On("Apply", &expected, "argocd-controller", false).Return(&expected, nil)
+
+	dynamicFactory := &arktest.FakeDynamicFactory{}
+	resource := metav1.APIResource{Name: "configmaps", Namespaced: true}
+	dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Group: "", Version: "v1"}, resource, "ns-1").Return(resourceClient, nil)
+
+	ctx := &context{
+		dynamicFactory: dynamicFactory,
+		fileSystem:     newFakeFileSystem().WithFile("configmaps/cm-1.json", newNamedTestConfigMap("cm-1").ToJSON()),
+		selector:       labels.NewSelector(),
+		restore: &api.Restore{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: api.DefaultNamespace,
+				Name:      "my-restore",
+			},
+			Spec: api.RestoreSpec{
+				ApplyMethod:      api.RestoreApplyMethodServerSideApply,
+				FieldManager:     "argocd-controller",
+				AddedLabels:      map[string]string{"app.kubernetes.io/instance": "my-app"},
+				AddedAnnotations: map[string]string{"argocd.argoproj.io/compare-options": "IgnoreExtraneous"},
+			},
+		},
+		backup: &api.Backup{},
+		logger: arktest.NewLogger(),
+	}
+
+	warnings, errs := ctx.restoreResource("configmaps", "ns-1", "configmaps")
+
+	assert.Equal(t, api.RestoreResult{}, warnings)
+	assert.Equal(t, api.RestoreResult{}, errs)
+	resourceClient.AssertExpectations(t)
+}
+
+func TestRestoreResourceSkipsBackupVolumeSnapshots(t *testing.T) {
+	volumeSnapshot := func(name string, labels map[string]string) []byte {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("snapshot.storage.k8s.io/v1")
+		obj.SetKind("VolumeSnapshot")
+		obj.SetNamespace("ns-1")
+		obj.SetName(name)
+		obj.SetLabels(labels)
+		data, err := json.Marshal(obj)
+		require.NoError(t, err)
+		return data
+	}
+
+	resourceClient := &arktest.FakeDynamicClient{}
+	defer resourceClient.AssertExpectations(t)
+	resourceClient.On("Create", mock.MatchedBy(func(obj *unstructured.Unstructured) bool { return obj.GetName() == "vs-2" })).Return(&unstructured.Unstructured{}, nil).Once()
+
+	dynamicFactory := &arktest.FakeDynamicFactory{}
+	resource := metav1.APIResource{Name: "volumesnapshots", Namespaced: true}
+	dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Group: "snapshot.storage.k8s.io", Version: "v1"}, resource, "ns-1").Return(resourceClient, nil)
+
+	ctx := &context{
+		dynamicFactory: dynamicFactory,
+		fileSystem: newFakeFileSystem().
+			WithFile("volumesnapshots/vs-1.json", volumeSnapshot("vs-1", map[string]string{api.BackupNameLabel: "backup-1"})).
+			WithFile("volumesnapshots/vs-2.json", volumeSnapshot("vs-2", map[string]string{"app": "db"})),
+		selector: labels.NewSelector(),
+		restore: &api.Restore{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: api.DefaultNamespace,
+				Name:      "my-restore",
+			},
+		},
+		backup: &api.Backup{},
+		logger: arktest.NewLogger(),
+	}
+
+	warnings, errs := ctx.restoreResource("volumesnapshots.snapshot.storage.k8s.io", "ns-1", "volumesnapshots")
+
+	assert.Equal(t, api.RestoreResult{}, warnings)
+	assert.Equal(t, api.RestoreResult{}, errs)
+}
+
+func TestRestoreResourcePreservesUIDs(t *testing.T) {
+	backedUp := []*testConfigMap{newNamedTestConfigMap("cm-1"), newNamedTestConfigMap("cm-2")}
+	backedUp[0].UID = "uid-1"
+	backedUp[1].UID = "uid-2"
+
+	tests := []struct {
+		name             string
+		preserveUIDs     bool
+		createdUIDs      []types.UID
+		expectedUIDs     []types.UID
+		expectedMappings []api.RestoredUIDMapping
+	}{
+		{
+			name:         "UIDs aren't sent when preserveUIDs isn't set",
+			createdUIDs:  []types.UID{"new-1", "new-2"},
+			expectedUIDs: []types.UID{"", ""},
+		},
+		{
+			name:         "original UIDs are sent and new UIDs are recorded when preserveUIDs is set",
+			preserveUIDs: true,
+			createdUIDs:  []types.UID{"uid-1", "new-2"},
+			expectedUIDs: []types.UID{"uid-1", "uid-2"},
+			expectedMappings: []api.RestoredUIDMapping{
+				{Resource: "configmaps", Namespace: "ns-1", Name: "cm-2", OriginalUID: "uid-2", UID: "new-2"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resourceClient := &arktest.FakeDynamicClient{}
+			for i := range backedUp {
+				expected := toUnstructured(newNamedTestConfigMap(backedUp[i].Name).WithArkLabel("my-restore").ConfigMap)[0]
+				if test.expectedUIDs[i] != "" {
+					expected.SetUID(test.expectedUIDs[i])
+				}
+				created := expected.DeepCopy()
+				created.SetUID(test.createdUIDs[i])
+
+				resourceClient.On("Create", &expected).Return(created, nil)
+			}
+
+			dynamicFactory := &arktest.FakeDynamicFactory{}
+			resource := metav1.APIResource{Name: "configmaps", Namespaced: true}
+			dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Group: "", Version: "v1"}, resource, "ns-1").Return(resourceClient, nil)
+
+			ctx := &context{
+				dynamicFactory: dynamicFactory,
+				fileSystem: newFakeFileSystem().
+					WithFile("configmaps/cm-1.json", backedUp[0].ToJSON()).
+					WithFile("configmaps/cm-2.json", backedUp[1].ToJSON()),
+				selector: labels.NewSelector(),
+				restore: &api.Restore{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: api.DefaultNamespace,
+						Name:      "my-restore",
+					},
+					Spec: api.RestoreSpec{
+						PreserveUIDs: test.preserveUIDs,
+					},
+				},
+				backup: &api.Backup{},
+				logger: arktest.NewLogger(),
+			}
+
+			warnings, errs := ctx.restoreResource("configmaps", "ns-1", "configmaps")
+
+			assert.Equal(t, api.RestoreResult{}, warnings)
+			assert.Equal(t, api.RestoreResult{}, errs)
+			resourceClient.AssertNumberOfCalls(t, "Create", 2)
+			assert.Equal(t, test.expectedMappings, ctx.restore.Status.UIDMappings)
+		})
+	}
+}
+
 func TestHasControllerOwner(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -639,98 +1364,299 @@ func TestHasControllerOwner(t *testing.T) {
 	}
 }
 
-func TestResetMetadataAndStatus(t *testing.T) {
+func TestResetMetadataAndStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		obj         *unstructured.Unstructured
+		expectedErr bool
+		expectedRes *unstructured.Unstructured
+	}{
+		{
+			name:        "no metadata causes error",
+			obj:         NewTestUnstructured().Unstructured,
+			expectedErr: true,
+		},
+		{
+			name:        "keep name, namespace, labels, annotations only",
+			obj:         NewTestUnstructured().WithMetadata("name", "blah", "namespace", "labels", "annotations", "foo").Unstructured,
+			expectedErr: false,
+			expectedRes: NewTestUnstructured().WithMetadata("name", "namespace", "labels", "annotations").Unstructured,
+		},
+		{
+			name:        "don't keep status",
+			obj:         NewTestUnstructured().WithMetadata().WithStatus().Unstructured,
+			expectedErr: false,
+			expectedRes: NewTestUnstructured().WithMetadata().Unstructured,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := resetMetadataAndStatus(test.obj)
+
+			if assert.Equal(t, test.expectedErr, err != nil) {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
+
+func TestObjectsAreEqual(t *testing.T) {
+	tests := []struct {
+		name        string
+		backupObj   *unstructured.Unstructured
+		clusterObj  *unstructured.Unstructured
+		expectedErr bool
+		expectedRes bool
+	}{
+		{
+			name:        "objects are already equal",
+			backupObj:   NewTestUnstructured().WithName("obj").WithArkLabel("test").Unstructured,
+			clusterObj:  NewTestUnstructured().WithName("obj").Unstructured,
+			expectedErr: false,
+			expectedRes: true,
+		},
+		{
+			name:        "objects reset correctly",
+			backupObj:   NewTestUnstructured().WithName("obj").WithArkLabel("test").Unstructured,
+			clusterObj:  NewTestUnstructured().WithMetadata("blah", "foo").WithName("obj").Unstructured,
+			expectedErr: false,
+			expectedRes: true,
+		},
+		{
+			name:        "cluster object has no metadata to reset",
+			backupObj:   NewTestUnstructured().WithName("obj").WithArkLabel("test").Unstructured,
+			clusterObj:  NewTestUnstructured().Unstructured,
+			expectedErr: true,
+			expectedRes: false,
+		},
+		{
+			name:        "Test JSON objects",
+			backupObj:   unstructuredOrDie(`{"apiVersion":"v1","kind":"ServiceAccount","metadata":{"name":"default","namespace":"nginx-example", "labels": {"ark-restore": "test"}},"secrets":[{"name":"default-token-xhjjc"}]}`),
+			clusterObj:  unstructuredOrDie(`{"apiVersion":"v1","kind":"ServiceAccount","metadata":{"creationTimestamp":"2018-04-05T20:12:21Z","name":"default","namespace":"nginx-example","resourceVersion":"650","selfLink":"/api/v1/namespaces/nginx-example/serviceaccounts/default","uid":"a5a3d2a2-390d-11e8-9644-42010a960002"},"secrets":[{"name":"default-token-xhjjc"}]}`),
+			expectedErr: false,
+			expectedRes: true,
+		},
+		{
+			name:        "backup object's original creation timestamp annotation is ignored",
+			backupObj:   unstructuredOrDie(`{"apiVersion":"v1","kind":"ServiceAccount","metadata":{"name":"default","namespace":"nginx-example","labels":{"ark-restore":"test"},"annotations":{"restore.ark.heptio.com/original-created-at":"2018-04-05T20:12:21Z"}}}`),
+			clusterObj:  unstructuredOrDie(`{"apiVersion":"v1","kind":"ServiceAccount","metadata":{"creationTimestamp":"2018-04-05T20:12:21Z","name":"default","namespace":"nginx-example","resourceVersion":"650"}}`),
+			expectedErr: false,
+			expectedRes: true,
+		},
+		{
+			name:        "Test ServiceAccount secrets mismatch",
+			backupObj:   unstructuredOrDie(`{"apiVersion":"v1","kind":"ServiceAccount","metadata":{"name":"default","namespace":"nginx-example", "labels": {"ark-restore": "test"}},"secrets":[{"name":"default-token-abcde"}]}`),
+			clusterObj:  unstructuredOrDie(`{"apiVersion":"v1","kind":"ServiceAccount","metadata":{"creationTimestamp":"2018-04-05T20:12:21Z","name":"default","namespace":"nginx-example","resourceVersion":"650","selfLink":"/api/v1/namespaces/nginx-example/serviceaccounts/default","uid":"a5a3d2a2-390d-11e8-9644-42010a960002"},"secrets":[{"name":"default-token-xhjjc"}]}`),
+			expectedErr: false,
+			expectedRes: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := objectsAreEqual(test.clusterObj, test.backupObj)
+			if assert.Equal(t, test.expectedErr, err != nil) {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
+
+func TestRestoreResourceRecordsOriginalCreationTimestamp(t *testing.T) {
+	created := metav1.NewTime(time.Date(2018, 4, 5, 20, 12, 21, 0, time.UTC))
+
+	backedUp := newNamedTestConfigMap("cm-1").ConfigMap
+	backedUp.CreationTimestamp = created
+
+	// a config map that was itself restored keeps its recorded creation time
+	previouslyRestored := newNamedTestConfigMap("cm-2").ConfigMap
+	previouslyRestored.CreationTimestamp = created
+	previouslyRestored.Annotations = map[string]string{api.OriginalCreationTimestampAnnotation: "2017-01-01T00:00:00Z"}
+
+	expected1 := newNamedTestConfigMap("cm-1").WithArkLabel("my-restore").ConfigMap
+	expected1.Annotations = map[string]string{api.OriginalCreationTimestampAnnotation: "2018-04-05T20:12:21Z"}
+	expected2 := newNamedTestConfigMap("cm-2").WithArkLabel("my-restore").ConfigMap
+	expected2.Annotations = map[string]string{api.OriginalCreationTimestampAnnotation: "2017-01-01T00:00:00Z"}
+	expectedObjs := toUnstructured(expected1, expected2)
+
+	resourceClient := &arktest.FakeDynamicClient{}
+	for i := range expectedObjs {
+		resourceClient.On("Create", &expectedObjs[i]).Return(&expectedObjs[i], nil)
+	}
+
+	dynamicFactory := &arktest.FakeDynamicFactory{}
+	resource := metav1.APIResource{Name: "configmaps", Namespaced: true}
+	dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Group: "", Version: "v1"}, resource, "ns-1").Return(resourceClient, nil)
+
+	ctx := &context{
+		dynamicFactory: dynamicFactory,
+		fileSystem: newFakeFileSystem().
+			WithFile("configmaps/cm-1.json", (&testConfigMap{backedUp}).ToJSON()).
+			WithFile("configmaps/cm-2.json", (&testConfigMap{previouslyRestored}).ToJSON()),
+		selector: labels.NewSelector(),
+		restore: &api.Restore{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: api.DefaultNamespace,
+				Name:      "my-restore",
+			},
+		},
+		backup: &api.Backup{},
+		logger: arktest.NewLogger(),
+	}
+
+	warnings, errs := ctx.restoreResource("configmaps", "ns-1", "configmaps")
+
+	assert.Empty(t, warnings.Ark)
+	assert.Empty(t, warnings.Namespaces)
+	assert.Equal(t, api.RestoreResult{}, errs)
+	resourceClient.AssertExpectations(t)
+}
+
+func TestRestoreResourceWaitsForActionConditions(t *testing.T) {
+	fromCluster := toUnstructured(newTestConfigMap().ConfigMap)[0]
+	unstructured.SetNestedSlice(fromCluster.Object, []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "False"},
+		map[string]interface{}{"type": "Synced", "status": "True"},
+	}, "status", "conditions")
+
 	tests := []struct {
-		name        string
-		obj         *unstructured.Unstructured
-		expectedErr bool
-		expectedRes *unstructured.Unstructured
+		name             string
+		condition        *WaitCondition
+		fromCluster      *unstructured.Unstructured
+		expectedWarnings api.RestoreResult
 	}{
 		{
-			name:        "no metadata causes error",
-			obj:         NewTestUnstructured().Unstructured,
-			expectedErr: true,
+			name:             "condition that's already met doesn't add a warning",
+			condition:        &WaitCondition{Type: "Synced"},
+			fromCluster:      &fromCluster,
+			expectedWarnings: api.RestoreResult{},
 		},
 		{
-			name:        "keep name, namespace, labels, annotations only",
-			obj:         NewTestUnstructured().WithMetadata("name", "blah", "namespace", "labels", "annotations", "foo").Unstructured,
-			expectedErr: false,
-			expectedRes: NewTestUnstructured().WithMetadata("name", "namespace", "labels", "annotations").Unstructured,
+			name:             "condition with a non-default status that's met doesn't add a warning",
+			condition:        &WaitCondition{Type: "Ready", Status: "False"},
+			fromCluster:      &fromCluster,
+			expectedWarnings: api.RestoreResult{},
 		},
 		{
-			name:        "don't keep status",
-			obj:         NewTestUnstructured().WithMetadata().WithStatus().Unstructured,
-			expectedErr: false,
-			expectedRes: NewTestUnstructured().WithMetadata().Unstructured,
+			name:        "condition that isn't met before the timeout adds a warning",
+			condition:   &WaitCondition{Type: "Ready", Timeout: 10 * time.Millisecond},
+			fromCluster: &fromCluster,
+			expectedWarnings: api.RestoreResult{
+				Namespaces: map[string][]string{
+					"ns-1": {"error waiting for configmaps/cm-1.json: timed out after 10ms waiting for cm-1 to have condition Ready=True"},
+				},
+			},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			res, err := resetMetadataAndStatus(test.obj)
+			resourceClient := &arktest.FakeDynamicClient{}
+			resourceClient.On("Create", mock.Anything).Return(&unstructured.Unstructured{}, nil)
+			resourceClient.On("Get", "cm-1", metav1.GetOptions{}).Return(test.fromCluster, nil)
 
-			if assert.Equal(t, test.expectedErr, err != nil) {
-				assert.Equal(t, test.expectedRes, res)
+			dynamicFactory := &arktest.FakeDynamicFactory{}
+			resource := metav1.APIResource{Name: "configmaps", Namespaced: true}
+			dynamicFactory.On("ClientForGroupVersionResource", schema.GroupVersion{Group: "", Version: "v1"}, resource, "ns-1").Return(resourceClient, nil)
+
+			ctx := &context{
+				dynamicFactory: dynamicFactory,
+				fileSystem:     newFakeFileSystem().WithFile("configmaps/cm-1.json", newTestConfigMap().ToJSON()),
+				selector:       labels.NewSelector(),
+				actions: []resolvedAction{
+					{
+						ItemAction:                &fakeWaitConditionAction{fakeAction: newFakeAction("configmaps"), condition: test.condition},
+						resourceIncludesExcludes:  collections.NewIncludesExcludes().Includes("configmaps"),
+						namespaceIncludesExcludes: collections.NewIncludesExcludes(),
+						selector:                  labels.Everything(),
+					},
+				},
+				restore: &api.Restore{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: api.DefaultNamespace,
+						Name:      "my-restore",
+					},
+				},
+				backup: &api.Backup{},
+				logger: arktest.NewLogger(),
 			}
+
+			warnings, errs := ctx.restoreResource("configmaps", "ns-1", "configmaps")
+
+			assert.Equal(t, test.expectedWarnings, warnings)
+			assert.Equal(t, api.RestoreResult{}, errs)
+			resourceClient.AssertExpectations(t)
 		})
 	}
 }
 
-func TestObjectsAreEqual(t *testing.T) {
+func TestMergeData(t *testing.T) {
 	tests := []struct {
-		name        string
-		backupObj   *unstructured.Unstructured
-		clusterObj  *unstructured.Unstructured
-		expectedErr bool
-		expectedRes bool
+		name              string
+		existing          string
+		restored          string
+		strategy          api.MergeStrategy
+		expected          string
+		expectedConflicts []string
 	}{
 		{
-			name:        "objects are already equal",
-			backupObj:   NewTestUnstructured().WithName("obj").WithArkLabel("test").Unstructured,
-			clusterObj:  NewTestUnstructured().WithName("obj").Unstructured,
-			expectedErr: false,
-			expectedRes: true,
-		},
-		{
-			name:        "objects reset correctly",
-			backupObj:   NewTestUnstructured().WithName("obj").WithArkLabel("test").Unstructured,
-			clusterObj:  NewTestUnstructured().WithMetadata("blah", "foo").WithName("obj").Unstructured,
-			expectedErr: false,
-			expectedRes: true,
-		},
-		{
-			name:        "cluster object has no metadata to reset",
-			backupObj:   NewTestUnstructured().WithName("obj").WithArkLabel("test").Unstructured,
-			clusterObj:  NewTestUnstructured().Unstructured,
-			expectedErr: true,
-			expectedRes: false,
+			name:     "keys are unioned",
+			existing: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"},"data":{"a":"1"}}`,
+			restored: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"},"data":{"b":"2"},"binaryData":{"c":"Mw=="}}`,
+			strategy: api.MergeStrategyRestoredWins,
+			expected: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"},"data":{"a":"1","b":"2"},"binaryData":{"c":"Mw=="}}`,
 		},
 		{
-			name:        "Test JSON objects",
-			backupObj:   unstructuredOrDie(`{"apiVersion":"v1","kind":"ServiceAccount","metadata":{"name":"default","namespace":"nginx-example", "labels": {"ark-restore": "test"}},"secrets":[{"name":"default-token-xhjjc"}]}`),
-			clusterObj:  unstructuredOrDie(`{"apiVersion":"v1","kind":"ServiceAccount","metadata":{"creationTimestamp":"2018-04-05T20:12:21Z","name":"default","namespace":"nginx-example","resourceVersion":"650","selfLink":"/api/v1/namespaces/nginx-example/serviceaccounts/default","uid":"a5a3d2a2-390d-11e8-9644-42010a960002"},"secrets":[{"name":"default-token-xhjjc"}]}`),
-			expectedErr: false,
-			expectedRes: true,
+			name:              "restored values win conflicts",
+			existing:          `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"},"data":{"a":"1","b":"2","c":"3"}}`,
+			restored:          `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"},"data":{"a":"1","b":"restored-2","c":"restored-3"}}`,
+			strategy:          api.MergeStrategyRestoredWins,
+			expected:          `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"},"data":{"a":"1","b":"restored-2","c":"restored-3"}}`,
+			expectedConflicts: []string{"data.b", "data.c"},
 		},
 		{
-			name:        "Test ServiceAccount secrets mismatch",
-			backupObj:   unstructuredOrDie(`{"apiVersion":"v1","kind":"ServiceAccount","metadata":{"name":"default","namespace":"nginx-example", "labels": {"ark-restore": "test"}},"secrets":[{"name":"default-token-abcde"}]}`),
-			clusterObj:  unstructuredOrDie(`{"apiVersion":"v1","kind":"ServiceAccount","metadata":{"creationTimestamp":"2018-04-05T20:12:21Z","name":"default","namespace":"nginx-example","resourceVersion":"650","selfLink":"/api/v1/namespaces/nginx-example/serviceaccounts/default","uid":"a5a3d2a2-390d-11e8-9644-42010a960002"},"secrets":[{"name":"default-token-xhjjc"}]}`),
-			expectedErr: false,
-			expectedRes: false,
+			name:              "existing values win conflicts",
+			existing:          `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"},"data":{"a":"1","b":"2"}}`,
+			restored:          `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"},"data":{"b":"restored-2","c":"3"}}`,
+			strategy:          api.MergeStrategyExistingWins,
+			expected:          `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"},"data":{"a":"1","b":"2","c":"3"}}`,
+			expectedConflicts: []string{"data.b"},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			res, err := objectsAreEqual(test.clusterObj, test.backupObj)
-			if assert.Equal(t, test.expectedErr, err != nil) {
-				assert.Equal(t, test.expectedRes, res)
-			}
+			existing := unstructuredOrDie(test.existing)
+
+			conflicts := mergeData(existing, unstructuredOrDie(test.restored), []string{"data", "binaryData"}, test.strategy)
+
+			assert.Equal(t, test.expectedConflicts, conflicts)
+			assert.Equal(t, unstructuredOrDie(test.expected), existing)
 		})
 	}
 }
 
+func TestMergeIntoExisting(t *testing.T) {
+	existing := unstructuredOrDie(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"ns-1","name":"cm-1","resourceVersion":"100"},"data":{"a":"1","b":"2"}}`)
+	restored := unstructuredOrDie(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"ns-1","name":"cm-1"},"data":{"b":"restored-2","c":"3"}}`)
+	expected := unstructuredOrDie(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"namespace":"ns-1","name":"cm-1","resourceVersion":"100","labels":{"ark-restore":"my-restore"}},"data":{"a":"1","b":"restored-2","c":"3"}}`)
+
+	resourceClient := &arktest.FakeDynamicClient{}
+	resourceClient.On("Get", "cm-1", metav1.GetOptions{}).Return(existing, nil)
+	resourceClient.On("Update", expected).Return(expected, nil)
+
+	ctx := &context{
+		restore: &api.Restore{ObjectMeta: metav1.ObjectMeta{Name: "my-restore"}},
+		logger:  arktest.NewLogger(),
+	}
+
+	err := ctx.mergeIntoExisting(resourceClient, restored, mergeableDataFields[schema.GroupResource{Resource: "configmaps"}], api.MergeStrategyRestoredWins)
+	require.NoError(t, err)
+
+	resourceClient.AssertExpectations(t)
+}
+
 func TestExecutePVAction(t *testing.T) {
 	iops := int64(1000)
 
@@ -740,11 +1666,13 @@ func TestExecutePVAction(t *testing.T) {
 		restore           *api.Restore
 		backup            *api.Backup
 		volumeMap         map[api.VolumeBackupInfo]string
+		resumedVolumes    map[string]string
 		noSnapshotService bool
 		expectedErr       bool
 		expectedRes       *unstructured.Unstructured
 		volumeID          string
 		expectSetVolumeID bool
+		expectedRecorded  []recordedVolume
 	}{
 		{
 			name:        "no name should error",
@@ -806,6 +1734,37 @@ func TestExecutePVAction(t *testing.T) {
 			expectedErr:       false,
 			expectSetVolumeID: true,
 			expectedRes:       NewTestUnstructured().WithName("pv-1").WithSpec("xyz").Unstructured,
+			expectedRecorded:  []recordedVolume{{"pv-1", ""}, {"pv-1", "volume-1"}},
+		},
+		{
+			name:              "volume restored by an interrupted run of the restore is reused",
+			obj:               NewTestUnstructured().WithName("pv-1").WithSpec("xyz").Unstructured,
+			restore:           arktest.NewDefaultTestRestore().WithRestorePVs(true).WithRestoredVolume("pv-1", "volume-1").Restore,
+			backup:            &api.Backup{Status: api.BackupStatus{VolumeBackups: map[string]*api.VolumeBackupInfo{"pv-1": {SnapshotID: "snap-1"}}}},
+			volumeID:          "volume-1",
+			expectSetVolumeID: true,
+			expectedRes:       NewTestUnstructured().WithName("pv-1").WithSpec("xyz").Unstructured,
+		},
+		{
+			name:              "volume whose creation was interrupted is created again",
+			obj:               NewTestUnstructured().WithName("pv-1").WithSpec("xyz").Unstructured,
+			restore:           arktest.NewDefaultTestRestore().WithRestorePVs(true).WithRestoredVolume("pv-1", "").Restore,
+			backup:            &api.Backup{Status: api.BackupStatus{VolumeBackups: map[string]*api.VolumeBackupInfo{"pv-1": {SnapshotID: "snap-1"}}}},
+			volumeMap:         map[api.VolumeBackupInfo]string{{SnapshotID: "snap-1"}: "volume-2"},
+			volumeID:          "volume-2",
+			expectSetVolumeID: true,
+			expectedRes:       NewTestUnstructured().WithName("pv-1").WithSpec("xyz").Unstructured,
+			expectedRecorded:  []recordedVolume{{"pv-1", ""}, {"pv-1", "volume-2"}},
+		},
+		{
+			name:              "volume found for a PV whose creation was interrupted is reused",
+			obj:               NewTestUnstructured().WithName("pv-1").WithSpec("xyz").Unstructured,
+			restore:           arktest.NewDefaultTestRestore().WithRestorePVs(true).WithRestoredVolume("pv-1", "").Restore,
+			backup:            &api.Backup{Status: api.BackupStatus{VolumeBackups: map[string]*api.VolumeBackupInfo{"pv-1": {SnapshotID: "snap-1"}}}},
+			resumedVolumes:    map[string]string{"pv-1": "volume-1"},
+			volumeID:          "volume-1",
+			expectSetVolumeID: true,
+			expectedRes:       NewTestUnstructured().WithName("pv-1").WithSpec("xyz").Unstructured,
 		},
 		{
 			name:              "restoring, snapshotService=nil, backup has at least 1 snapshot -> error",
@@ -834,10 +1793,14 @@ func TestExecutePVAction(t *testing.T) {
 				snapshotService = fakeSnapshotService
 			}
 
+			volumeRecorder := new(fakeVolumeRecorder)
+
 			ctx := &context{
 				restore:         test.restore,
 				backup:          test.backup,
 				snapshotService: snapshotService,
+				volumeRecorder:  volumeRecorder,
+				resumedVolumes:  test.resumedVolumes,
 				logger:          arktest.NewLogger(),
 			}
 
@@ -855,10 +1818,341 @@ func TestExecutePVAction(t *testing.T) {
 				assert.Equal(t, "", fakeSnapshotService.VolumeIDSet)
 			}
 			assert.Equal(t, test.expectedRes, res)
+			assert.Equal(t, test.expectedRecorded, volumeRecorder.recorded)
+
+			// created volumes are tagged with the restore and PV they're for
+			for _, recorded := range volumeRecorder.recorded {
+				if recorded.volumeID != "" {
+					assert.Equal(t, map[string]string{restoredVolumeTag: "/pv-1"}, fakeSnapshotService.VolumeTags[recorded.volumeID])
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreClaimFromCSISnapshot(t *testing.T) {
+	snapshot := &api.CSISnapshotInfo{
+		Driver:                    "ebs.csi.aws.com",
+		SnapshotHandle:            "snap-1",
+		VolumeSnapshotContentName: "snapcontent-1",
+	}
+	csiBackup := &api.Backup{Status: api.BackupStatus{VolumeBackups: map[string]*api.VolumeBackupInfo{"pv-1": {SnapshotID: "snap-1", CSISnapshot: snapshot}}}}
+
+	newClaim := func() *testUnstructured {
+		return NewTestUnstructured().
+			WithName("pvc-1").
+			WithMetadataField("namespace", "ns-1").
+			WithAnnotations("pv.kubernetes.io/bind-completed", "volume.beta.kubernetes.io/storage-provisioner", "foo")
+	}
+
+	tests := []struct {
+		name                   string
+		obj                    *unstructured.Unstructured
+		restore                *api.Restore
+		backup                 *api.Backup
+		noCSISnapshotter       bool
+		expectedErr            bool
+		expectedRes            *unstructured.Unstructured
+		expectedVolumeSnapshot string
+	}{
+		{
+			name:    "claim for a volume with a CSI snapshot is provisioned from a VolumeSnapshot",
+			obj:     newClaim().WithSpecField("volumeName", "pv-1").Unstructured,
+			restore: arktest.NewTestRestore(api.DefaultNamespace, "restore-1", api.RestorePhaseInProgress).WithBackup("backup-1").Restore,
+			backup:  csiBackup,
+			expectedRes: NewTestUnstructured().
+				WithName("pvc-1").
+				WithMetadataField("namespace", "ns-1").
+				WithAnnotations("foo").
+				WithSpecField("dataSource", map[string]interface{}{
+					"apiGroup": "snapshot.storage.k8s.io",
+					"kind":     "VolumeSnapshot",
+					"name":     "restore-1-pvc-1",
+				}).Unstructured,
+			expectedVolumeSnapshot: "ns-1/restore-1-pvc-1",
+		},
+		{
+			name:        "claim for a volume without a CSI snapshot is left as it is",
+			obj:         newClaim().WithSpecField("volumeName", "pv-2").Unstructured,
+			restore:     arktest.NewTestRestore(api.DefaultNamespace, "restore-1", api.RestorePhaseInProgress).Restore,
+			backup:      csiBackup,
+			expectedRes: newClaim().WithSpecField("volumeName", "pv-2").Unstructured,
+		},
+		{
+			name:        "claim is left as it is when the restore doesn't restore PVs",
+			obj:         newClaim().WithSpecField("volumeName", "pv-1").Unstructured,
+			restore:     arktest.NewTestRestore(api.DefaultNamespace, "restore-1", api.RestorePhaseInProgress).WithRestorePVs(false).Restore,
+			backup:      csiBackup,
+			expectedRes: newClaim().WithSpecField("volumeName", "pv-1").Unstructured,
+		},
+		{
+			name:             "claim for a volume with a CSI snapshot without CSI snapshots enabled -> error",
+			obj:              newClaim().WithSpecField("volumeName", "pv-1").Unstructured,
+			restore:          arktest.NewTestRestore(api.DefaultNamespace, "restore-1", api.RestorePhaseInProgress).Restore,
+			backup:           csiBackup,
+			noCSISnapshotter: true,
+			expectedErr:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			csiSnapshotter := &arktest.FakeCSISnapshotter{}
+
+			ctx := &context{
+				restore: test.restore,
+				backup:  test.backup,
+				logger:  arktest.NewLogger(),
+			}
+			if !test.noCSISnapshotter {
+				ctx.csiSnapshotter = csiSnapshotter
+			}
+
+			err := ctx.restoreClaimFromCSISnapshot(test.obj)
+
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedRes, test.obj)
+			if test.expectedVolumeSnapshot == "" {
+				assert.Empty(t, csiSnapshotter.VolumeSnapshotsCreated)
+			} else {
+				assert.Equal(t, map[string]*api.CSISnapshotInfo{test.expectedVolumeSnapshot: snapshot}, csiSnapshotter.VolumeSnapshotsCreated)
+			}
+		})
+	}
+}
+
+type recordedVolume struct {
+	pvName   string
+	volumeID string
+}
+
+type fakeVolumeRecorder struct {
+	recorded []recordedVolume
+}
+
+func (r *fakeVolumeRecorder) RecordVolume(pvName, volumeID string) error {
+	r.recorded = append(r.recorded, recordedVolume{pvName, volumeID})
+	return nil
+}
+
+func TestRestoreFromDirFindsInterruptedVolumes(t *testing.T) {
+	tests := []struct {
+		name             string
+		supported        bool
+		volumeTags       map[string]map[string]string
+		expectedResumed  map[string]string
+		expectedRecorded []recordedVolume
+		expectedWarning  string
+	}{
+		{
+			name:            "volumes can't be found by tag",
+			expectedResumed: map[string]string{},
+			expectedWarning: "restore was interrupted while creating a volume for PersistentVolume pv-2 from snapshot snap-2, so a duplicate volume may have been orphaned",
+		},
+		{
+			name:      "volume isn't found",
+			supported: true,
+			volumeTags: map[string]map[string]string{
+				"volume-2": {restoredVolumeTag: "other-uid/pv-2"},
+			},
+			expectedResumed: map[string]string{},
+		},
+		{
+			name:      "volume is found",
+			supported: true,
+			volumeTags: map[string]map[string]string{
+				"volume-2": {restoredVolumeTag: "uid/pv-2"},
+			},
+			expectedResumed:  map[string]string{"pv-2": "volume-2"},
+			expectedRecorded: []recordedVolume{{"pv-2", "volume-2"}},
+		},
+		{
+			name:      "duplicate volumes are reported",
+			supported: true,
+			volumeTags: map[string]map[string]string{
+				"volume-2": {restoredVolumeTag: "uid/pv-2"},
+				"volume-3": {restoredVolumeTag: "uid/pv-2"},
+			},
+			expectedResumed:  map[string]string{"pv-2": "volume-2"},
+			expectedRecorded: []recordedVolume{{"pv-2", "volume-2"}},
+			expectedWarning:  "found volumes volume-2, volume-3 created for PersistentVolume pv-2 from snapshot snap-2, so the volumes other than volume-2 were orphaned",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			restore := arktest.NewDefaultTestRestore().
+				WithRestoredVolume("pv-1", "volume-1").
+				WithRestoredVolume("pv-2", "").
+				Restore
+			restore.UID = "uid"
+
+			volumeRecorder := new(fakeVolumeRecorder)
+			ctx := &context{
+				restore: restore,
+				backup: &api.Backup{Status: api.BackupStatus{VolumeBackups: map[string]*api.VolumeBackupInfo{
+					"pv-1": {SnapshotID: "snap-1"},
+					"pv-2": {SnapshotID: "snap-2"},
+				}}},
+				snapshotService: &arktest.FakeSnapshotService{
+					VolumeTags:             test.volumeTags,
+					SupportsFindingVolumes: test.supported,
+				},
+				volumeRecorder:  volumeRecorder,
+				namespaceClient: &fakeNamespaceClient{},
+				fileSystem:      newFakeFileSystem().WithDirectory("bak/resources"),
+				logger:          arktest.NewLogger(),
+				selector:        labels.NewSelector(),
+			}
+
+			warnings, _ := ctx.restoreFromDir("bak")
+
+			if test.expectedWarning == "" {
+				assert.Empty(t, warnings.Ark)
+			} else {
+				assert.Equal(t, []string{test.expectedWarning}, warnings.Ark)
+			}
+			assert.Equal(t, test.expectedResumed, ctx.resumedVolumes)
+			assert.Equal(t, test.expectedRecorded, volumeRecorder.recorded)
+		})
+	}
+}
+
+func TestRestoreFromDirWithMissingCRDPolicy(t *testing.T) {
+	missingWarning := "backup contains items of resource widgets.example.com, which the cluster doesn't have, e.g. because its CustomResourceDefinition isn't installed, so they weren't restored"
+	missingError := "backup contains items of resource widgets.example.com, which the cluster doesn't have, e.g. because its CustomResourceDefinition isn't installed, so nothing was restored"
+
+	tests := []struct {
+		name             string
+		restore          *api.Restore
+		expectedWarnings []string
+		expectedErrors   []string
+		expectedReadDirs []string
+	}{
+		{
+			name:             "missing resources are skipped by default",
+			restore:          arktest.NewDefaultTestRestore().Restore,
+			expectedReadDirs: []string{"resources", "resources/secrets/namespaces", "resources/secrets/namespaces", "resources/secrets/namespaces/a"},
+		},
+		{
+			name:             "missing resources are skipped with a warning with the Warn policy",
+			restore:          arktest.NewDefaultTestRestore().WithMissingCRDPolicy(api.MissingCRDPolicyWarn).Restore,
+			expectedWarnings: []string{missingWarning},
+			expectedReadDirs: []string{"resources", "resources/secrets/namespaces", "resources/secrets/namespaces", "resources/secrets/namespaces/a"},
+		},
+		{
+			name:             "nothing is restored with the Fail policy",
+			restore:          arktest.NewDefaultTestRestore().WithMissingCRDPolicy(api.MissingCRDPolicyFail).Restore,
+			expectedErrors:   []string{missingError},
+			expectedReadDirs: []string{"resources"},
+		},
+		{
+			name:             "excluded resources aren't checked",
+			restore:          arktest.NewDefaultTestRestore().WithMissingCRDPolicy(api.MissingCRDPolicyFail).WithExcludedResource("widgets.example.com").Restore,
+			expectedReadDirs: []string{"resources", "resources/secrets/namespaces", "resources/secrets/namespaces", "resources/secrets/namespaces/a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the widgets aren't extracted, since the cluster doesn't have their resource, so
+			// the backup has to be read for them to be found
+			buf := new(bytes.Buffer)
+			tw := tar.NewWriter(buf)
+			for _, name := range []string{"resources/", "resources/secrets/", "resources/secrets/namespaces/", "resources/secrets/namespaces/a/"} {
+				require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeDir, Mode: 0755}))
+			}
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: "resources/widgets.example.com/namespaces/a/widget-1.json", Typeflag: tar.TypeReg, Mode: 0644, Size: 2}))
+			_, err := tw.Write([]byte("{}"))
+			require.NoError(t, err)
+			require.NoError(t, tw.Close())
+
+			fileSystem := newFakeFileSystem()
+
+			ctx := &context{
+				restore:              test.restore,
+				namespaceClient:      &fakeNamespaceClient{},
+				fileSystem:           fileSystem,
+				logger:               arktest.NewLogger(),
+				prioritizedResources: []schema.GroupResource{{Resource: "secrets"}},
+				discoveryHelper: arktest.NewFakeDiscoveryHelper(false, map[schema.GroupVersionResource]schema.GroupVersionResource{
+					{Resource: "secrets"}: {Version: "v1", Resource: "secrets"},
+				}),
+			}
+
+			dir, err := ctx.readBackup(tar.NewReader(buf))
+			require.NoError(t, err)
+
+			warnings, errs := ctx.restoreFromDir(dir)
+
+			assert.Equal(t, test.expectedWarnings, warnings.Ark)
+			assert.Equal(t, test.expectedErrors, errs.Ark)
+
+			var readDirs []string
+			for _, call := range fileSystem.readDirCalls {
+				readDirs = append(readDirs, strings.TrimPrefix(call, dir+"/"))
+			}
+			assert.Equal(t, test.expectedReadDirs, readDirs)
 		})
 	}
 }
 
+func TestReadBackupExtractsOnlyRestoredItems(t *testing.T) {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, name := range []string{
+		"metadata/version",
+		"resources/namespaces/cluster/ns-1.json",
+		"resources/namespaces/cluster/ns-2.json",
+		"resources/configmaps/namespaces/ns-1/cm-1.json",
+		"resources/configmaps/namespaces/ns-2/cm-2.json",
+		"./resources/configmaps/namespaces/ns-1/cm-3.json",
+		"resources/secrets/namespaces/ns-1/secret-1.json",
+		"resources/persistentvolumes/cluster/pv-1.json",
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: 2}))
+		_, err := tw.Write([]byte("{}"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	fileSystem := newFakeFileSystem()
+	ctx := &context{
+		restore:              arktest.NewDefaultTestRestore().WithIncludedNamespace("ns-1").Restore,
+		prioritizedResources: []schema.GroupResource{{Resource: "configmaps"}, {Resource: "persistentvolumes"}},
+		fileSystem:           fileSystem,
+		logger:               arktest.NewLogger(),
+	}
+
+	dir, err := ctx.readBackup(tar.NewReader(buf))
+	require.NoError(t, err)
+
+	var extracted []string
+	require.NoError(t, afero.Walk(fileSystem.fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			extracted = append(extracted, strings.TrimPrefix(path, dir+"/"))
+		}
+		return err
+	}))
+
+	assert.Equal(t, []string{
+		"metadata/version",
+		"resources/configmaps/namespaces/ns-1/cm-1.json",
+		"resources/configmaps/namespaces/ns-1/cm-3.json",
+		"resources/namespaces/cluster/ns-1.json",
+		"resources/namespaces/cluster/ns-2.json",
+		"resources/persistentvolumes/cluster/pv-1.json",
+	}, extracted)
+
+	// resources are recorded whether or not their items are extracted
+	assert.Equal(t, []string{"configmaps", "namespaces", "persistentvolumes", "secrets"}, ctx.backupResources.List())
+}
+
 func TestIsPVReady(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1243,13 +2537,64 @@ func (r *fakeAction) Execute(obj runtime.Unstructured, restore *api.Restore) (ru
 	return res, nil, nil
 }
 
+type fakeWaitConditionAction struct {
+	*fakeAction
+	condition *WaitCondition
+}
+
+func (a *fakeWaitConditionAction) WaitCondition(obj runtime.Unstructured, restore *api.Restore) (*WaitCondition, error) {
+	return a.condition, nil
+}
+
 type fakeNamespaceClient struct {
+	lock              sync.Mutex
 	createdNamespaces []*v1.Namespace
 
+	// inactiveNamespaces are the names of namespaces that never become active
+	inactiveNamespaces sets.String
+
+	// existingNamespaces are namespaces that already exist, keyed by name
+	existingNamespaces map[string]*v1.Namespace
+	patches            map[string]string
+
 	corev1.NamespaceInterface
 }
 
 func (nsc *fakeNamespaceClient) Create(ns *v1.Namespace) (*v1.Namespace, error) {
+	nsc.lock.Lock()
+	defer nsc.lock.Unlock()
+
+	if _, found := nsc.existingNamespaces[ns.Name]; found {
+		return nil, apierrors.NewAlreadyExists(schema.GroupResource{Resource: "namespaces"}, ns.Name)
+	}
+
 	nsc.createdNamespaces = append(nsc.createdNamespaces, ns)
 	return ns, nil
 }
+
+func (nsc *fakeNamespaceClient) Get(name string, options metav1.GetOptions) (*v1.Namespace, error) {
+	phase := v1.NamespaceActive
+	if nsc.inactiveNamespaces.Has(name) {
+		phase = v1.NamespaceTerminating
+	}
+
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if existing, found := nsc.existingNamespaces[name]; found {
+		ns = existing.DeepCopy()
+	}
+	ns.Status.Phase = phase
+
+	return ns, nil
+}
+
+func (nsc *fakeNamespaceClient) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (*v1.Namespace, error) {
+	nsc.lock.Lock()
+	defer nsc.lock.Unlock()
+
+	if nsc.patches == nil {
+		nsc.patches = make(map[string]string)
+	}
+	nsc.patches[name] = string(data)
+
+	return nsc.existingNamespaces[name], nil
+}
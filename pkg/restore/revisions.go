@@ -0,0 +1,127 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/heptio/ark/pkg/discovery"
+)
+
+// revisionLabels are the labels Helm uses to identify the release and revision
+// that a configmap or secret stores, in the form Tiller (Helm v2) and Helm v3
+// use respectively.
+var revisionLabels = []struct {
+	ownerLabel, ownerValue, nameLabel, versionLabel string
+}{
+	{ownerLabel: "OWNER", ownerValue: "TILLER", nameLabel: "NAME", versionLabel: "VERSION"},
+	{ownerLabel: "owner", ownerValue: "helm", nameLabel: "name", versionLabel: "version"},
+}
+
+// resolveVersionedResources returns the fully-qualified names of resources, as
+// returned by schema.GroupResource's String. Resources that can't be resolved are
+// kept as they were given.
+func resolveVersionedResources(resources []string, helper discovery.Helper) sets.String {
+	resolved := sets.NewString()
+
+	for _, resource := range resources {
+		if gvr, _, err := helper.ResourceFor(schema.ParseGroupResource(resource).WithVersion("")); err == nil {
+			groupResource := gvr.GroupResource()
+			resource = groupResource.String()
+		}
+		resolved.Insert(resource)
+	}
+
+	return resolved
+}
+
+// revisionOf returns the family of revisions obj belongs to and its revision in
+// that family, or false if obj isn't a revision. Helm releases are identified by
+// their labels and controller revisions by their owner and revision field.
+func revisionOf(obj *unstructured.Unstructured) (string, int64, bool) {
+	labels := obj.GetLabels()
+	for _, l := range revisionLabels {
+		if labels[l.ownerLabel] != l.ownerValue || labels[l.nameLabel] == "" {
+			continue
+		}
+
+		revision, err := strconv.ParseInt(labels[l.versionLabel], 10, 64)
+		if err != nil {
+			return "", 0, false
+		}
+		return l.ownerValue + "/" + labels[l.nameLabel], revision, true
+	}
+
+	if obj.GetKind() != "ControllerRevision" {
+		return "", 0, false
+	}
+
+	owners := obj.GetOwnerReferences()
+	if len(owners) == 0 {
+		return "", 0, false
+	}
+
+	var revision int64
+	switch v := obj.UnstructuredContent()["revision"].(type) {
+	case int64:
+		revision = v
+	case float64:
+		revision = int64(v)
+	default:
+		return "", 0, false
+	}
+
+	return owners[0].Kind + "/" + owners[0].Name, revision, true
+}
+
+// olderRevisions returns the names of the items that aren't the latest revision
+// of their family. Items that aren't revisions aren't included.
+func olderRevisions(items []*unstructured.Unstructured) sets.String {
+	type latest struct {
+		name     string
+		revision int64
+	}
+
+	var (
+		older   = sets.NewString()
+		latests = make(map[string]latest)
+	)
+
+	for _, item := range items {
+		family, revision, ok := revisionOf(item)
+		if !ok {
+			continue
+		}
+
+		current, found := latests[family]
+		switch {
+		case !found:
+			latests[family] = latest{name: item.GetName(), revision: revision}
+		case revision > current.revision:
+			older.Insert(current.name)
+			latests[family] = latest{name: item.GetName(), revision: revision}
+		default:
+			older.Insert(item.GetName())
+		}
+	}
+
+	return older
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func newRevision(kind, name string, labels map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":   name,
+				"labels": labels,
+			},
+		},
+	}
+}
+
+func newControllerRevision(name, owner string, revision interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "ControllerRevision",
+			"metadata": map[string]interface{}{
+				"name": name,
+				"ownerReferences": []interface{}{
+					map[string]interface{}{"apiVersion": "apps/v1", "kind": "StatefulSet", "name": owner, "uid": "1"},
+				},
+			},
+			"revision": revision,
+		},
+	}
+}
+
+func TestRevisionOf(t *testing.T) {
+	tests := []struct {
+		name             string
+		obj              *unstructured.Unstructured
+		expectedFamily   string
+		expectedRevision int64
+		expectedOK       bool
+	}{
+		{
+			name:             "helm v2 release",
+			obj:              newRevision("ConfigMap", "foo.v3", map[string]interface{}{"OWNER": "TILLER", "NAME": "foo", "VERSION": "3"}),
+			expectedFamily:   "TILLER/foo",
+			expectedRevision: 3,
+			expectedOK:       true,
+		},
+		{
+			name:             "helm v3 release",
+			obj:              newRevision("Secret", "sh.helm.release.v1.foo.v2", map[string]interface{}{"owner": "helm", "name": "foo", "version": "2"}),
+			expectedFamily:   "helm/foo",
+			expectedRevision: 2,
+			expectedOK:       true,
+		},
+		{
+			name:       "helm release with an invalid version isn't a revision",
+			obj:        newRevision("Secret", "foo", map[string]interface{}{"owner": "helm", "name": "foo", "version": "latest"}),
+			expectedOK: false,
+		},
+		{
+			name:       "unlabeled secret isn't a revision",
+			obj:        newRevision("Secret", "foo", nil),
+			expectedOK: false,
+		},
+		{
+			name:             "controller revision decoded from JSON",
+			obj:              newControllerRevision("web-1", "web", float64(4)),
+			expectedFamily:   "StatefulSet/web",
+			expectedRevision: 4,
+			expectedOK:       true,
+		},
+		{
+			name:             "controller revision with an int64 revision",
+			obj:              newControllerRevision("web-1", "web", int64(5)),
+			expectedFamily:   "StatefulSet/web",
+			expectedRevision: 5,
+			expectedOK:       true,
+		},
+		{
+			name:       "controller revision without an owner isn't a revision",
+			obj:        newRevision("ControllerRevision", "web-1", nil),
+			expectedOK: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			family, revision, ok := revisionOf(test.obj)
+
+			assert.Equal(t, test.expectedOK, ok)
+			assert.Equal(t, test.expectedFamily, family)
+			assert.Equal(t, test.expectedRevision, revision)
+		})
+	}
+}
+
+func TestOlderRevisions(t *testing.T) {
+	helm := func(name, release, version string) *unstructured.Unstructured {
+		return newRevision("Secret", name, map[string]interface{}{"owner": "helm", "name": release, "version": version})
+	}
+
+	items := []*unstructured.Unstructured{
+		helm("foo.v2", "foo", "2"),
+		helm("foo.v10", "foo", "10"),
+		helm("foo.v1", "foo", "1"),
+		helm("bar.v1", "bar", "1"),
+		newRevision("Secret", "plain", nil),
+		newControllerRevision("web-2", "web", float64(2)),
+		newControllerRevision("web-1", "web", float64(1)),
+	}
+
+	assert.Equal(t, sets.NewString("foo.v2", "foo.v1", "web-1"), olderRevisions(items))
+}
+
+func TestResolveVersionedResources(t *testing.T) {
+	helper := arktest.NewFakeDiscoveryHelper(false, map[schema.GroupVersionResource]schema.GroupVersionResource{
+		{Resource: "secrets"}:             {Group: "", Version: "v1", Resource: "secrets"},
+		{Resource: "controllerrevisions"}: {Group: "apps", Version: "v1", Resource: "controllerrevisions"},
+	})
+
+	resolved := resolveVersionedResources([]string{"secrets", "controllerrevisions", "foos.example.com"}, helper)
+
+	assert.Equal(t, sets.NewString("secrets", "controllerrevisions.apps", "foos.example.com"), resolved)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/util/collections"
+)
+
+const (
+	serviceAccountUserPrefix  = "system:serviceaccount:"
+	serviceAccountGroupPrefix = "system:serviceaccounts:"
+)
+
+// roleBindingAction rewrites the namespaces of the ServiceAccounts that RoleBindings and
+// ClusterRoleBindings refer to when they're restored with a namespace mapping, so that
+// they keep granting access to the restored ServiceAccounts.
+type roleBindingAction struct {
+	logger logrus.FieldLogger
+}
+
+func NewRoleBindingAction(logger logrus.FieldLogger) ItemAction {
+	return &roleBindingAction{
+		logger: logger,
+	}
+}
+
+func (a *roleBindingAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"rolebindings.rbac.authorization.k8s.io", "clusterrolebindings.rbac.authorization.k8s.io"},
+	}, nil
+}
+
+// Execute sets the namespace of each ServiceAccount subject whose namespace is mapped to the
+// mapped namespace. Subjects without a namespace refer to the binding's own namespace, which
+// is mapped when the binding is restored, so they're left as they are. User and Group subjects
+// that name ServiceAccounts in a mapped namespace (system:serviceaccount:<NAMESPACE>:<NAME> and
+// system:serviceaccounts:<NAMESPACE>) aren't rewritten, since they may be used by an
+// authenticator other than Kubernetes', but a warning is returned for them.
+func (a *roleBindingAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	if len(restore.Spec.NamespaceMapping) == 0 {
+		return obj, nil, nil
+	}
+
+	// an empty or missing list of subjects is valid
+	subjects, err := collections.GetSlice(obj.UnstructuredContent(), "subjects")
+	if err != nil {
+		return obj, nil, nil
+	}
+
+	var warnings []error
+	for i, item := range subjects {
+		subject, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, nil, errors.Errorf("subject %d is not an object", i)
+		}
+
+		kind, _ := collections.GetString(subject, "kind")
+		name, _ := collections.GetString(subject, "name")
+
+		switch kind {
+		case rbacv1.ServiceAccountKind:
+			namespace, _ := collections.GetString(subject, "namespace")
+			if target, ok := restore.Spec.NamespaceMapping[namespace]; ok {
+				a.logger.Infof("Rewriting namespace of ServiceAccount subject %s from %s to %s", name, namespace, target)
+				subject["namespace"] = target
+			}
+		case rbacv1.UserKind, rbacv1.GroupKind:
+			if namespace, ok := serviceAccountNamespace(kind, name); ok {
+				if target, ok := restore.Spec.NamespaceMapping[namespace]; ok {
+					warnings = append(warnings, errors.Errorf("%s subject %s refers to ServiceAccounts in namespace %s, which is mapped to %s, but wasn't rewritten", kind, name, namespace, target))
+				}
+			}
+		}
+	}
+
+	return obj, kerrors.NewAggregate(warnings), nil
+}
+
+// serviceAccountNamespace returns the namespace of the ServiceAccounts that a User or Group
+// subject named name refers to, and whether it refers to any.
+func serviceAccountNamespace(kind, name string) (string, bool) {
+	switch kind {
+	case rbacv1.UserKind:
+		if !strings.HasPrefix(name, serviceAccountUserPrefix) {
+			return "", false
+		}
+		parts := strings.Split(strings.TrimPrefix(name, serviceAccountUserPrefix), ":")
+		if len(parts) != 2 {
+			return "", false
+		}
+		return parts[0], true
+	case rbacv1.GroupKind:
+		if !strings.HasPrefix(name, serviceAccountGroupPrefix) {
+			return "", false
+		}
+		return strings.TrimPrefix(name, serviceAccountGroupPrefix), true
+	}
+
+	return "", false
+}
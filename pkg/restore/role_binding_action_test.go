@@ -0,0 +1,112 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestRoleBindingActionExecute(t *testing.T) {
+	tests := []struct {
+		name             string
+		namespaceMapping map[string]string
+		subjects         []interface{}
+		expectedSubjects []interface{}
+		expectedWarning  string
+	}{
+		{
+			name: "subjects aren't changed without a namespace mapping",
+			subjects: []interface{}{
+				map[string]interface{}{"kind": "ServiceAccount", "name": "sa-1", "namespace": "ns-1"},
+			},
+			expectedSubjects: []interface{}{
+				map[string]interface{}{"kind": "ServiceAccount", "name": "sa-1", "namespace": "ns-1"},
+			},
+		},
+		{
+			name:             "ServiceAccount subjects in mapped namespaces are rewritten",
+			namespaceMapping: map[string]string{"ns-1": "ns-2"},
+			subjects: []interface{}{
+				map[string]interface{}{"kind": "ServiceAccount", "name": "sa-1", "namespace": "ns-1"},
+				map[string]interface{}{"kind": "ServiceAccount", "name": "sa-2", "namespace": "other"},
+				map[string]interface{}{"kind": "ServiceAccount", "name": "sa-3"},
+			},
+			expectedSubjects: []interface{}{
+				map[string]interface{}{"kind": "ServiceAccount", "name": "sa-1", "namespace": "ns-2"},
+				map[string]interface{}{"kind": "ServiceAccount", "name": "sa-2", "namespace": "other"},
+				map[string]interface{}{"kind": "ServiceAccount", "name": "sa-3"},
+			},
+		},
+		{
+			name:             "User and Group subjects naming ServiceAccounts in mapped namespaces aren't rewritten, with warnings",
+			namespaceMapping: map[string]string{"ns-1": "ns-2"},
+			subjects: []interface{}{
+				map[string]interface{}{"kind": "User", "name": "system:serviceaccount:ns-1:sa-1"},
+				map[string]interface{}{"kind": "Group", "name": "system:serviceaccounts:ns-1"},
+				map[string]interface{}{"kind": "User", "name": "system:serviceaccount:other:sa-1"},
+				map[string]interface{}{"kind": "User", "name": "jane"},
+			},
+			expectedSubjects: []interface{}{
+				map[string]interface{}{"kind": "User", "name": "system:serviceaccount:ns-1:sa-1"},
+				map[string]interface{}{"kind": "Group", "name": "system:serviceaccounts:ns-1"},
+				map[string]interface{}{"kind": "User", "name": "system:serviceaccount:other:sa-1"},
+				map[string]interface{}{"kind": "User", "name": "jane"},
+			},
+			expectedWarning: "[User subject system:serviceaccount:ns-1:sa-1 refers to ServiceAccounts in namespace ns-1, which is mapped to ns-2, but wasn't rewritten, " +
+				"Group subject system:serviceaccounts:ns-1 refers to ServiceAccounts in namespace ns-1, which is mapped to ns-2, but wasn't rewritten]",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			action := NewRoleBindingAction(arktest.NewLogger())
+
+			obj := NewTestUnstructured().WithName("rb-1").Unstructured
+			obj.Object["subjects"] = test.subjects
+
+			restore := &api.Restore{Spec: api.RestoreSpec{NamespaceMapping: test.namespaceMapping}}
+
+			res, warning, err := action.Execute(obj, restore)
+			require.NoError(t, err)
+
+			if test.expectedWarning == "" {
+				assert.NoError(t, warning)
+			} else {
+				assert.EqualError(t, warning, test.expectedWarning)
+			}
+
+			assert.Equal(t, test.expectedSubjects, res.UnstructuredContent()["subjects"])
+		})
+	}
+}
+
+func TestRoleBindingActionExecuteWithoutSubjects(t *testing.T) {
+	action := NewRoleBindingAction(arktest.NewLogger())
+	obj := NewTestUnstructured().WithName("rb-1").Unstructured
+	restore := &api.Restore{Spec: api.RestoreSpec{NamespaceMapping: map[string]string{"ns-1": "ns-2"}}}
+
+	res, warning, err := action.Execute(obj, restore)
+	require.NoError(t, err)
+	assert.NoError(t, warning)
+	assert.Equal(t, obj, res)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+// scaleToZeroAction scales restored Deployments and StatefulSets to zero replicas, and
+// suspends restored CronJobs, when a restore has spec.scaleToZero set. The backed-up
+// values are recorded in annotations so the workloads can be scaled back up later.
+type scaleToZeroAction struct {
+	logger logrus.FieldLogger
+}
+
+func NewScaleToZeroAction(logger logrus.FieldLogger) ItemAction {
+	return &scaleToZeroAction{
+		logger: logger,
+	}
+}
+
+func (a *scaleToZeroAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"deployments.apps", "deployments.extensions", "statefulsets.apps", "cronjobs.batch"},
+	}, nil
+}
+
+func (a *scaleToZeroAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	if !restore.Spec.ScaleToZero {
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return obj, nil, nil
+	}
+
+	if item.GetKind() == "CronJob" {
+		suspend, _ := unstructured.NestedBool(item.Object, "spec", "suspend")
+		a.logger.Infof("Suspending CronJob %s", item.GetName())
+
+		addAnnotationIfMissing(item, api.OriginalSuspendAnnotation, strconv.FormatBool(suspend))
+		unstructured.SetNestedField(item.Object, true, "spec", "suspend")
+		return item, nil, nil
+	}
+
+	// replicas defaults to 1 when it isn't set
+	replicas := int64(1)
+	if value, found := unstructured.NestedFieldCopy(item.Object, "spec", "replicas"); found {
+		switch v := value.(type) {
+		case int64:
+			replicas = v
+		case float64:
+			replicas = int64(v)
+		}
+	}
+	a.logger.Infof("Scaling %s %s from %d replicas to zero", item.GetKind(), item.GetName(), replicas)
+
+	addAnnotationIfMissing(item, api.OriginalReplicasAnnotation, strconv.FormatInt(replicas, 10))
+	unstructured.SetNestedField(item.Object, int64(0), "spec", "replicas")
+
+	return item, nil, nil
+}
+
+// addAnnotationIfMissing adds the annotation unless the item already has it, so that items
+// that were themselves restored with spec.scaleToZero keep the values recorded first.
+func addAnnotationIfMissing(obj *unstructured.Unstructured, key, val string) {
+	if _, found := obj.GetAnnotations()[key]; found {
+		return
+	}
+	addAnnotation(obj, key, val)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestScaleToZeroActionExecute(t *testing.T) {
+	tests := []struct {
+		name        string
+		kind        string
+		scaleToZero bool
+		obj         *unstructured.Unstructured
+		expected    *unstructured.Unstructured
+	}{
+		{
+			name: "items aren't changed when the restore doesn't scale to zero",
+			obj: NewTestUnstructured().WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).Unstructured,
+			expected: NewTestUnstructured().WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).Unstructured,
+		},
+		{
+			name:        "deployment is scaled to zero and its replicas are recorded",
+			scaleToZero: true,
+			obj: NewTestUnstructured().WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).Unstructured,
+			expected: NewTestUnstructured().WithName("deploy-1").
+				WithMetadataField("annotations", map[string]interface{}{api.OriginalReplicasAnnotation: "3"}).
+				WithSpecField("replicas", int64(0)).Unstructured,
+		},
+		{
+			name:        "replicas defaults to 1 when it isn't set",
+			scaleToZero: true,
+			obj:         NewTestUnstructured().WithName("sts-1").WithSpec().Unstructured,
+			expected: NewTestUnstructured().WithName("sts-1").
+				WithMetadataField("annotations", map[string]interface{}{api.OriginalReplicasAnnotation: "1"}).
+				WithSpecField("replicas", int64(0)).Unstructured,
+		},
+		{
+			name:        "replicas recorded by an earlier restore are kept",
+			scaleToZero: true,
+			obj: NewTestUnstructured().WithName("deploy-1").
+				WithMetadataField("annotations", map[string]interface{}{api.OriginalReplicasAnnotation: "5"}).
+				WithSpecField("replicas", int64(0)).Unstructured,
+			expected: NewTestUnstructured().WithName("deploy-1").
+				WithMetadataField("annotations", map[string]interface{}{api.OriginalReplicasAnnotation: "5"}).
+				WithSpecField("replicas", int64(0)).Unstructured,
+		},
+		{
+			name:        "cronjob is suspended and its original value is recorded",
+			kind:        "CronJob",
+			scaleToZero: true,
+			obj: NewTestUnstructured().WithName("cron-1").
+				WithSpecField("schedule", "* * * * *").Unstructured,
+			expected: NewTestUnstructured().WithName("cron-1").
+				WithMetadataField("annotations", map[string]interface{}{api.OriginalSuspendAnnotation: "false"}).
+				WithSpecField("schedule", "* * * * *").
+				WithSpecField("suspend", true).Unstructured,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.kind != "" {
+				test.obj.SetKind(test.kind)
+				test.expected.SetKind(test.kind)
+			}
+
+			action := NewScaleToZeroAction(arktest.NewLogger())
+			restore := &api.Restore{Spec: api.RestoreSpec{ScaleToZero: test.scaleToZero}}
+
+			res, warning, err := action.Execute(test.obj, restore)
+			require.NoError(t, err)
+			assert.NoError(t, warning)
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
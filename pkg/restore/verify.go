@@ -0,0 +1,139 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/client"
+)
+
+// restoredItem is an item created by a restore with spec.verify set, as it was sent to the
+// API server.
+type restoredItem struct {
+	client    client.Dynamic
+	resource  string
+	namespace string
+	obj       *unstructured.Unstructured
+}
+
+// verifyRestoredItems reads each restored item back from the cluster and returns a warning
+// for each one whose fields differ from the version that was restored.
+func (ctx *context) verifyRestoredItems() api.RestoreResult {
+	var warnings api.RestoreResult
+
+	ctx.infof("Verifying %d restored items", len(ctx.restoredItems))
+
+	for _, item := range ctx.restoredItems {
+		name := item.obj.GetName()
+
+		fromCluster, err := item.client.Get(name, metav1.GetOptions{})
+		if err != nil {
+			addToResult(&warnings, item.namespace, fmt.Errorf("error verifying restored %s %s: %v", item.resource, name, err))
+			continue
+		}
+
+		if fields := divergentFields(item.obj, fromCluster); len(fields) > 0 {
+			addToResult(&warnings, item.namespace, fmt.Errorf("restored %s %s differs from the backup in %s", item.resource, name, strings.Join(fields, ", ")))
+		}
+	}
+
+	return warnings
+}
+
+// divergentFields returns the paths of the fields of restored whose values differ in
+// fromCluster. Fields that are only in fromCluster, such as those defaulted by the API
+// server, are ignored, as are status and all metadata except labels and annotations.
+func divergentFields(restored, fromCluster *unstructured.Unstructured) []string {
+	var fields []string
+
+	for key, value := range restored.Object {
+		switch key {
+		case "status":
+			continue
+		case "metadata":
+			for _, metadataKey := range []string{"labels", "annotations"} {
+				restoredValue, found := unstructured.NestedFieldCopy(restored.Object, "metadata", metadataKey)
+				if !found {
+					continue
+				}
+				clusterValue, _ := unstructured.NestedFieldCopy(fromCluster.Object, "metadata", metadataKey)
+				fields = append(fields, compareFields("metadata."+metadataKey, restoredValue, clusterValue)...)
+			}
+		default:
+			fields = append(fields, compareFields(key, value, fromCluster.Object[key])...)
+		}
+	}
+
+	sort.Strings(fields)
+	return fields
+}
+
+// compareFields returns the paths, starting at path, at which restored and fromCluster
+// differ. Keys of maps in fromCluster that aren't in restored are ignored.
+func compareFields(path string, restored, fromCluster interface{}) []string {
+	switch restoredValue := restored.(type) {
+	case map[string]interface{}:
+		clusterValue, ok := fromCluster.(map[string]interface{})
+		if !ok {
+			return []string{path}
+		}
+
+		var fields []string
+		for key, value := range restoredValue {
+			fields = append(fields, compareFields(path+"."+key, value, clusterValue[key])...)
+		}
+		return fields
+	case []interface{}:
+		clusterValue, ok := fromCluster.([]interface{})
+		if !ok || len(clusterValue) != len(restoredValue) {
+			return []string{path}
+		}
+
+		var fields []string
+		for i := range restoredValue {
+			fields = append(fields, compareFields(fmt.Sprintf("%s[%d]", path, i), restoredValue[i], clusterValue[i])...)
+		}
+		return fields
+	}
+
+	if !reflect.DeepEqual(normalizeNumber(restored), normalizeNumber(fromCluster)) {
+		return []string{path}
+	}
+	return nil
+}
+
+// normalizeNumber converts JSON numbers to float64, since unstructured objects may hold
+// them as either int64 or float64 depending on how they were decoded.
+func normalizeNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	}
+	return value
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestDivergentFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		restored    map[string]interface{}
+		fromCluster map[string]interface{}
+		expected    []string
+	}{
+		{
+			name: "identical objects have no divergent fields",
+			restored: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "cm-1", "labels": map[string]interface{}{"a": "b"}},
+				"data":     map[string]interface{}{"foo": "bar"},
+			},
+			fromCluster: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "cm-1", "labels": map[string]interface{}{"a": "b"}},
+				"data":     map[string]interface{}{"foo": "bar"},
+			},
+			expected: nil,
+		},
+		{
+			name: "fields only in the cluster, status, and metadata other than labels and annotations are ignored",
+			restored: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "cm-1", "namespace": "ns-1"},
+				"spec":     map[string]interface{}{"replicas": int64(1)},
+				"status":   map[string]interface{}{"phase": "Pending"},
+			},
+			fromCluster: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "cm-1", "namespace": "ns-1", "uid": "abc", "resourceVersion": "1"},
+				"spec":     map[string]interface{}{"replicas": float64(1), "strategy": "RollingUpdate"},
+				"status":   map[string]interface{}{"phase": "Running"},
+			},
+			expected: nil,
+		},
+		{
+			name: "changed and missing fields are reported",
+			restored: map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": map[string]interface{}{"a": "b"}},
+				"spec": map[string]interface{}{
+					"replicas": int64(3),
+					"template": map[string]interface{}{"image": "nginx"},
+				},
+			},
+			fromCluster: map[string]interface{}{
+				"metadata": map[string]interface{}{},
+				"spec": map[string]interface{}{
+					"replicas": int64(1),
+					"template": map[string]interface{}{"image": "nginx:1.15"},
+				},
+			},
+			expected: []string{"metadata.annotations", "spec.replicas", "spec.template.image"},
+		},
+		{
+			name: "slices are compared element by element",
+			restored: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"port": int64(80)},
+						map[string]interface{}{"port": int64(443)},
+					},
+					"args": []interface{}{"a", "b"},
+				},
+			},
+			fromCluster: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"port": int64(80), "protocol": "TCP"},
+						map[string]interface{}{"port": int64(8443), "protocol": "TCP"},
+					},
+					"args": []interface{}{"a"},
+				},
+			},
+			expected: []string{"spec.args", "spec.ports[1].port"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			restored := &unstructured.Unstructured{Object: test.restored}
+			fromCluster := &unstructured.Unstructured{Object: test.fromCluster}
+
+			assert.Equal(t, test.expected, divergentFields(restored, fromCluster))
+		})
+	}
+}
+
+func TestVerifyRestoredItems(t *testing.T) {
+	cm := newTestConfigMap().ConfigMap
+	cm.Data = map[string]string{"foo": "bar"}
+	restored := toUnstructured(cm)[0]
+
+	cm.Data["foo"] = "baz"
+	modified := toUnstructured(cm)[0]
+
+	cm.Name = "cm-2"
+	deleted := toUnstructured(cm)[0]
+
+	resourceClient := &arktest.FakeDynamicClient{}
+	resourceClient.On("Get", "cm-1", metav1.GetOptions{}).Return(&modified, nil)
+	resourceClient.On("Get", "cm-2", metav1.GetOptions{}).Return((*unstructured.Unstructured)(nil), errors.New("not found"))
+
+	ctx := &context{
+		logger: arktest.NewLogger(),
+		restoredItems: []restoredItem{
+			{client: resourceClient, resource: "configmaps", namespace: "ns-1", obj: &restored},
+			{client: resourceClient, resource: "configmaps", namespace: "ns-1", obj: &deleted},
+		},
+	}
+
+	expected := api.RestoreResult{
+		Namespaces: map[string][]string{
+			"ns-1": {
+				"restored configmaps cm-1 differs from the backup in data.foo",
+				"error verifying restored configmaps cm-2: not found",
+			},
+		},
+	}
+
+	assert.Equal(t, expected, ctx.verifyRestoredItems())
+	resourceClient.AssertExpectations(t)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collections
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IsPattern returns whether s is a pattern rather than a name: either a regular
+// expression between slashes, e.g. /^pr-[0-9]+$/, or a glob pattern, e.g. pr-*.
+// The wildcard '*' on its own isn't a pattern.
+func IsPattern(s string) bool {
+	return isRegexp(s) || (s != "*" && strings.ContainsAny(s, "*?["))
+}
+
+func isRegexp(s string) bool {
+	return len(s) > 1 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/")
+}
+
+// ValidatePattern returns an error if s is a pattern that can't be parsed.
+func ValidatePattern(s string) error {
+	if !IsPattern(s) {
+		return nil
+	}
+
+	if isRegexp(s) {
+		if _, err := regexp.Compile(s[1 : len(s)-1]); err != nil {
+			return errors.Errorf("invalid regular expression %q: %v", s, err)
+		}
+		return nil
+	}
+
+	if _, err := path.Match(s, ""); err != nil {
+		return errors.Errorf("invalid glob pattern %q: %v", s, err)
+	}
+	return nil
+}
+
+// ExpandPatterns returns items with each pattern in it replaced by the names it
+// matches, in the order they're given. Items that aren't patterns are kept as
+// they are. Patterns that can't be parsed don't match anything.
+func ExpandPatterns(items, names []string) []string {
+	var res []string
+
+	for _, item := range items {
+		if !IsPattern(item) {
+			res = append(res, item)
+			continue
+		}
+
+		matches := patternMatcher(item)
+		for _, name := range names {
+			if matches(name) {
+				res = append(res, name)
+			}
+		}
+	}
+
+	return res
+}
+
+// patternMatcher returns a function that returns whether a name matches pattern.
+func patternMatcher(pattern string) func(string) bool {
+	if isRegexp(pattern) {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return func(string) bool { return false }
+		}
+		return re.MatchString
+	}
+
+	return func(name string) bool {
+		matched, err := path.Match(pattern, name)
+		return err == nil && matched
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collections
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPattern(t *testing.T) {
+	tests := []struct {
+		item     string
+		expected bool
+	}{
+		{item: "foo", expected: false},
+		{item: "*", expected: false},
+		{item: "/", expected: false},
+		{item: "pr-*", expected: true},
+		{item: "pr-?", expected: true},
+		{item: "pr-[0-9]", expected: true},
+		{item: "/^pr-[0-9]+$/", expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.item, func(t *testing.T) {
+			assert.Equal(t, test.expected, IsPattern(test.item))
+		})
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	tests := []struct {
+		item        string
+		expectedErr string
+	}{
+		{item: "foo"},
+		{item: "pr-*"},
+		{item: "/^pr-[0-9]+$/"},
+		{item: "pr-[0-9", expectedErr: `invalid glob pattern "pr-[0-9": syntax error in pattern`},
+		{item: "/pr-(/", expectedErr: "invalid regular expression \"/pr-(/\": error parsing regexp: missing closing ): `pr-(`"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.item, func(t *testing.T) {
+			err := ValidatePattern(test.item)
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.expectedErr)
+			}
+		})
+	}
+}
+
+func TestExpandPatterns(t *testing.T) {
+	names := []string{"default", "pr-1", "pr-22", "pr-x"}
+
+	tests := []struct {
+		name     string
+		items    []string
+		expected []string
+	}{
+		{
+			name:     "names are kept",
+			items:    []string{"foo", "*"},
+			expected: []string{"foo", "*"},
+		},
+		{
+			name:     "glob patterns are expanded",
+			items:    []string{"pr-?", "default"},
+			expected: []string{"pr-1", "pr-x", "default"},
+		},
+		{
+			name:     "regular expressions are expanded",
+			items:    []string{"/^pr-[0-9]+$/"},
+			expected: []string{"pr-1", "pr-22"},
+		},
+		{
+			name:  "invalid patterns match nothing",
+			items: []string{"pr-[", "/pr-(/"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, ExpandPatterns(test.items, names))
+		})
+	}
+}
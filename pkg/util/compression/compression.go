@@ -0,0 +1,135 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Gzip is the name of the gzip compression format, which backups are
+	// compressed with by default and which backups that don't record their
+	// format were compressed with.
+	Gzip = "gzip"
+
+	// Zstd is the name of the Zstandard compression format.
+	Zstd = "zstd"
+
+	// LZ4 is the name of the LZ4 frame compression format.
+	LZ4 = "lz4"
+)
+
+// Codec compresses and decompresses backup tarballs in a compression format.
+type Codec interface {
+	// NewWriter returns a writer that compresses what's written to it to w.
+	// Closing it flushes the compressed data, but doesn't close w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+
+	// NewReader returns a reader that decompresses what's read from r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+var codecs = map[string]Codec{
+	Gzip: gzipCodec{},
+	Zstd: zstdCodec{},
+	LZ4:  lz4Codec{},
+}
+
+// Register makes codec available for the compression format named format,
+// replacing any codec already registered for it. It isn't safe to call
+// concurrently with CodecFor, so codecs should be registered when the program
+// starts, e.g. from an init function.
+func Register(format string, codec Codec) {
+	codecs[format] = codec
+}
+
+// CodecFor returns the codec for the compression format named format, or for
+// gzip if format is empty.
+func CodecFor(format string) (Codec, error) {
+	if format == "" {
+		format = Gzip
+	}
+
+	codec, found := codecs[format]
+	if !found {
+		return nil, errors.Errorf("unsupported compression format %q, must be one of %s", format, strings.Join(Formats(), ", "))
+	}
+	return codec, nil
+}
+
+// Formats returns the names of the supported compression formats, sorted.
+func Formats() []string {
+	formats := make([]string, 0, len(codecs))
+	for format := range codecs {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// gzipCodec implements Codec for gzip.
+type gzipCodec struct{}
+
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return gzr, nil
+}
+
+// zstdCodec implements Codec for Zstandard.
+type zstdCodec struct{}
+
+func (zstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return zw, nil
+}
+
+func (zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return zr.IOReadCloser(), nil
+}
+
+// lz4Codec implements Codec for LZ4.
+type lz4Codec struct{}
+
+func (lz4Codec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return lz4.NewWriter(w), nil
+}
+
+func (lz4Codec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(lz4.NewReader(r)), nil
+}
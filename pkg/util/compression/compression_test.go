@@ -0,0 +1,103 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compression
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecFor(t *testing.T) {
+	codec, err := CodecFor("")
+	require.NoError(t, err)
+	assert.Equal(t, gzipCodec{}, codec)
+
+	codec, err = CodecFor(Gzip)
+	require.NoError(t, err)
+	assert.Equal(t, gzipCodec{}, codec)
+
+	codec, err = CodecFor(Zstd)
+	require.NoError(t, err)
+	assert.Equal(t, zstdCodec{}, codec)
+
+	codec, err = CodecFor(LZ4)
+	require.NoError(t, err)
+	assert.Equal(t, lz4Codec{}, codec)
+
+	_, err = CodecFor("rar")
+	assert.EqualError(t, err, `unsupported compression format "rar", must be one of gzip, lz4, zstd`)
+}
+
+func TestRoundTrip(t *testing.T) {
+	contents := bytes.Repeat([]byte("backup contents\n"), 10000)
+
+	for _, format := range []string{Gzip, Zstd, LZ4} {
+		t.Run(format, func(t *testing.T) {
+			codec, err := CodecFor(format)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			w, err := codec.NewWriter(&buf)
+			require.NoError(t, err)
+			_, err = w.Write(contents)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			assert.True(t, buf.Len() < len(contents)/10, "%s compressed %d bytes to %d", format, len(contents), buf.Len())
+
+			r, err := codec.NewReader(&buf)
+			require.NoError(t, err)
+			defer r.Close()
+
+			data, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, contents, data)
+		})
+	}
+}
+
+// identityCodec implements Codec without compressing anything.
+type identityCodec struct{}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func (identityCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+func (identityCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(r), nil
+}
+
+func TestRegister(t *testing.T) {
+	Register("identity", identityCodec{})
+	defer delete(codecs, "identity")
+
+	codec, err := CodecFor("identity")
+	require.NoError(t, err)
+	assert.Equal(t, identityCodec{}, codec)
+	assert.Equal(t, []string{"gzip", "identity", "lz4", "zstd"}, Formats())
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import "github.com/sirupsen/logrus"
+
+// Format is a string representation of the desired output format for logs.
+type Format string
+
+const (
+	// FormatText emits logs as human-readable key=value text.
+	FormatText Format = "text"
+	// FormatJSON emits each log entry as a JSON object.
+	FormatJSON Format = "json"
+)
+
+// Formats returns the string representations of all supported log formats.
+func Formats() []string {
+	return []string{string(FormatText), string(FormatJSON)}
+}
+
+// Formatter returns the logrus formatter for the given format, defaulting
+// to text for unrecognized formats.
+func Formatter(format Format) logrus.Formatter {
+	switch format {
+	case FormatJSON:
+		return new(logrus.JSONFormatter)
+	default:
+		return new(logrus.TextFormatter)
+	}
+}
@@ -27,6 +27,34 @@ type BackupService struct {
 	mock.Mock
 }
 
+// AvailableBytes provides a mock function with given fields: bucket
+func (_m *BackupService) AvailableBytes(bucket string) (int64, bool, error) {
+	ret := _m.Called(bucket)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(string) int64); ok {
+		r0 = rf(bucket)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = rf(bucket)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(bucket)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // CreateSignedURL provides a mock function with given fields: target, bucket, ttl
 func (_m *BackupService) CreateSignedURL(target v1.DownloadTarget, bucket, directory string, ttl time.Duration) (string, error) {
 	ret := _m.Called(target, bucket, directory, ttl)
@@ -62,6 +90,41 @@ func (_m *BackupService) DeleteBackupDir(bucket string, backupName string) error
 	return r0
 }
 
+// BackupContentsExist provides a mock function with given fields: bucket, name
+func (_m *BackupService) BackupContentsExist(bucket string, name string) (bool, error) {
+	ret := _m.Called(bucket, name)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = rf(bucket, name)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(bucket, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteBackupContents provides a mock function with given fields: bucket, backupName
+func (_m *BackupService) DeleteBackupContents(bucket string, backupName string) error {
+	ret := _m.Called(bucket, backupName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(bucket, backupName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DownloadBackup provides a mock function with given fields: bucket, name
 func (_m *BackupService) DownloadBackup(bucket string, name string) (io.ReadCloser, error) {
 	ret := _m.Called(bucket, name)
@@ -145,6 +208,20 @@ func (_m *BackupService) UploadBackup(bucket string, name string, metadata io.Re
 	return r0
 }
 
+// UploadBackupMetadata provides a mock function with given fields: bucket, name, metadata
+func (_m *BackupService) UploadBackupMetadata(bucket string, name string, metadata io.Reader) error {
+	ret := _m.Called(bucket, name, metadata)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, io.Reader) error); ok {
+		r0 = rf(bucket, name, metadata)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UploadRestoreLog provides a mock function with given fields: bucket, backup, restore, log
 func (_m *BackupService) UploadRestoreLog(bucket string, backup string, restore string, log io.Reader) error {
 	ret := _m.Called(bucket, backup, restore, log)
@@ -0,0 +1,349 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// FakeVolume is a volume in a FakeBlockStore.
+type FakeVolume struct {
+	Type             string
+	Iops             *int64
+	AvailabilityZone string
+	// SnapshotID is the snapshot the volume was created from, if any.
+	SnapshotID string
+	// Tags are the tags the volume was created with.
+	Tags map[string]string
+}
+
+// FakeSnapshot is a snapshot in a FakeBlockStore.
+type FakeSnapshot struct {
+	VolumeID         string
+	AvailabilityZone string
+	Tags             map[string]string
+	// GroupID is the consistency group the snapshot was created in, if any.
+	GroupID string
+}
+
+// FakeBlockStore is an in-memory implementation of cloudprovider.BlockStore. It records
+// the name of each method called on it, and can be programmed to return an error from any
+// method.
+type FakeBlockStore struct {
+	// Volumes maps volume IDs to volumes.
+	Volumes map[string]*FakeVolume
+
+	// Snapshots maps snapshot IDs to snapshots.
+	Snapshots map[string]*FakeSnapshot
+
+	// PVVolumeIDs maps PersistentVolume names to the IDs of their volumes. It's
+	// used by GetVolumeID and updated by SetVolumeID.
+	PVVolumeIDs map[string]string
+
+	// Errors maps method names to the error they return. Methods that return
+	// an error don't modify the store.
+	Errors map[string]error
+
+	// SupportsSnapshotGroups is whether CreateSnapshotGroup snapshots volumes
+	// as a consistency group, rather than returning an empty group ID.
+	SupportsSnapshotGroups bool
+
+	// SupportsBulkDeletes is whether DeleteSnapshots deletes snapshots, rather
+	// than returning false.
+	SupportsBulkDeletes bool
+
+	// SupportsListingByTag is whether ListSnapshotsWithTag and ListVolumesWithTag
+	// list snapshots and volumes, rather than returning false.
+	SupportsListingByTag bool
+
+	// Calls is the name of each method called, in order.
+	Calls []string
+
+	lock   sync.Mutex
+	nextID int
+}
+
+// NewFakeBlockStore returns an empty FakeBlockStore.
+func NewFakeBlockStore() *FakeBlockStore {
+	return &FakeBlockStore{
+		Volumes:     make(map[string]*FakeVolume),
+		Snapshots:   make(map[string]*FakeSnapshot),
+		PVVolumeIDs: make(map[string]string),
+		Errors:      make(map[string]error),
+	}
+}
+
+// call records a call to method and returns the error programmed for it, if any.
+func (s *FakeBlockStore) call(method string) error {
+	s.Calls = append(s.Calls, method)
+	return s.Errors[method]
+}
+
+func (s *FakeBlockStore) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s-%d", prefix, s.nextID)
+}
+
+func (s *FakeBlockStore) Init(config map[string]string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.call("Init")
+}
+
+func (s *FakeBlockStore) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64, tags map[string]string) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("CreateVolumeFromSnapshot"); err != nil {
+		return "", err
+	}
+
+	if _, ok := s.Snapshots[snapshotID]; !ok {
+		return "", errors.Errorf("snapshot %q not found", snapshotID)
+	}
+
+	volumeID := s.newID("volume")
+	s.Volumes[volumeID] = &FakeVolume{
+		Type:             volumeType,
+		Iops:             iops,
+		AvailabilityZone: volumeAZ,
+		SnapshotID:       snapshotID,
+		Tags:             tags,
+	}
+
+	return volumeID, nil
+}
+
+func (s *FakeBlockStore) GetVolumeID(pv runtime.Unstructured) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("GetVolumeID"); err != nil {
+		return "", err
+	}
+
+	metadata, err := meta.Accessor(pv)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return s.PVVolumeIDs[metadata.GetName()], nil
+}
+
+func (s *FakeBlockStore) SetVolumeID(pv runtime.Unstructured, volumeID string) (runtime.Unstructured, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("SetVolumeID"); err != nil {
+		return nil, err
+	}
+
+	metadata, err := meta.Accessor(pv)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s.PVVolumeIDs[metadata.GetName()] = volumeID
+
+	return pv, nil
+}
+
+func (s *FakeBlockStore) GetVolumeInfo(volumeID, volumeAZ string) (string, *int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("GetVolumeInfo"); err != nil {
+		return "", nil, err
+	}
+
+	volume, ok := s.Volumes[volumeID]
+	if !ok {
+		return "", nil, errors.Errorf("volume %q not found", volumeID)
+	}
+
+	return volume.Type, volume.Iops, nil
+}
+
+func (s *FakeBlockStore) IsVolumeReady(volumeID, volumeAZ string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("IsVolumeReady"); err != nil {
+		return false, err
+	}
+
+	_, ok := s.Volumes[volumeID]
+	return ok, nil
+}
+
+func (s *FakeBlockStore) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("CreateSnapshot"); err != nil {
+		return "", "", err
+	}
+
+	if _, ok := s.Volumes[volumeID]; !ok {
+		return "", "", errors.Errorf("volume %q not found", volumeID)
+	}
+
+	snapshotID := s.newID("snapshot")
+	s.Snapshots[snapshotID] = &FakeSnapshot{
+		VolumeID:         volumeID,
+		AvailabilityZone: volumeAZ,
+		Tags:             tags,
+	}
+
+	return snapshotID, "", nil
+}
+
+func (s *FakeBlockStore) DeleteSnapshot(snapshotID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("DeleteSnapshot"); err != nil {
+		return err
+	}
+
+	if _, ok := s.Snapshots[snapshotID]; !ok {
+		return errors.Errorf("snapshot %q not found", snapshotID)
+	}
+	delete(s.Snapshots, snapshotID)
+
+	return nil
+}
+
+func (s *FakeBlockStore) SnapshotExists(snapshotID string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("SnapshotExists"); err != nil {
+		return false, err
+	}
+
+	_, ok := s.Snapshots[snapshotID]
+	return ok, nil
+}
+
+func (s *FakeBlockStore) CreateSnapshotGroup(volumes map[string]string, tags map[string]string) (string, map[string]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("CreateSnapshotGroup"); err != nil {
+		return "", nil, err
+	}
+
+	if !s.SupportsSnapshotGroups {
+		return "", nil, nil
+	}
+
+	for volumeID := range volumes {
+		if _, ok := s.Volumes[volumeID]; !ok {
+			return "", nil, errors.Errorf("volume %q not found", volumeID)
+		}
+	}
+
+	groupID := s.newID("group")
+	snapshotIDs := make(map[string]string, len(volumes))
+	for volumeID, volumeAZ := range volumes {
+		snapshotID := s.newID("snapshot")
+		s.Snapshots[snapshotID] = &FakeSnapshot{
+			VolumeID:         volumeID,
+			AvailabilityZone: volumeAZ,
+			Tags:             tags,
+			GroupID:          groupID,
+		}
+		snapshotIDs[volumeID] = snapshotID
+	}
+
+	return groupID, snapshotIDs, nil
+}
+
+func (s *FakeBlockStore) DeleteSnapshots(snapshotIDs []string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("DeleteSnapshots"); err != nil {
+		return false, err
+	}
+
+	if !s.SupportsBulkDeletes {
+		return false, nil
+	}
+
+	for _, snapshotID := range snapshotIDs {
+		if _, ok := s.Snapshots[snapshotID]; !ok {
+			return false, errors.Errorf("snapshot %q not found", snapshotID)
+		}
+	}
+	for _, snapshotID := range snapshotIDs {
+		delete(s.Snapshots, snapshotID)
+	}
+
+	return true, nil
+}
+
+func (s *FakeBlockStore) ListSnapshotsWithTag(key string) (map[string]string, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("ListSnapshotsWithTag"); err != nil {
+		return nil, false, err
+	}
+
+	if !s.SupportsListingByTag {
+		return nil, false, nil
+	}
+
+	values := make(map[string]string)
+	for snapshotID, snapshot := range s.Snapshots {
+		if value, ok := snapshot.Tags[key]; ok {
+			values[snapshotID] = value
+		}
+	}
+
+	return values, true, nil
+}
+
+func (s *FakeBlockStore) ListVolumesWithTag(key string) (map[string]string, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("ListVolumesWithTag"); err != nil {
+		return nil, false, err
+	}
+
+	if !s.SupportsListingByTag {
+		return nil, false, nil
+	}
+
+	values := make(map[string]string)
+	for volumeID, volume := range s.Volumes {
+		if value, ok := volume.Tags[key]; ok {
+			values[volumeID] = value
+		}
+	}
+
+	return values, true, nil
+}
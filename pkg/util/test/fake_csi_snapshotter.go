@@ -0,0 +1,91 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"errors"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+)
+
+type FakeCSISnapshotter struct {
+	// PersistentVolume name -> the snapshot CreateSnapshot returns for it
+	SnapshottableVolumes map[string]*api.CSISnapshotInfo
+
+	// PersistentVolume name -> the labels of the VolumeSnapshot created for it
+	SnapshotLabels map[string]map[string]string
+
+	// "namespace/name" -> the snapshot of each VolumeSnapshot created by CreateVolumeSnapshot
+	VolumeSnapshotsCreated map[string]*api.CSISnapshotInfo
+
+	// VolumeSnapshotContentName -> whether the snapshot exists
+	Snapshots map[string]bool
+
+	// the VolumeSnapshotContentNames of the snapshots deleted by DeleteSnapshot
+	SnapshotsDeleted []string
+}
+
+func (s *FakeCSISnapshotter) CreateSnapshot(pv runtime.Unstructured, labels map[string]string) (*api.CSISnapshotInfo, error) {
+	metadata, err := meta.Accessor(pv)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, found := s.SnapshottableVolumes[metadata.GetName()]
+	if !found {
+		return nil, errors.New("snapshottable volume not found")
+	}
+
+	if s.SnapshotLabels == nil {
+		s.SnapshotLabels = make(map[string]map[string]string)
+	}
+	s.SnapshotLabels[metadata.GetName()] = labels
+
+	if s.Snapshots == nil {
+		s.Snapshots = make(map[string]bool)
+	}
+	s.Snapshots[snapshot.VolumeSnapshotContentName] = true
+
+	return snapshot, nil
+}
+
+func (s *FakeCSISnapshotter) CreateVolumeSnapshot(snapshot *api.CSISnapshotInfo, namespace, name string, labels map[string]string) error {
+	if s.VolumeSnapshotsCreated == nil {
+		s.VolumeSnapshotsCreated = make(map[string]*api.CSISnapshotInfo)
+	}
+	s.VolumeSnapshotsCreated[namespace+"/"+name] = snapshot
+
+	return nil
+}
+
+func (s *FakeCSISnapshotter) DeleteSnapshot(snapshot *api.CSISnapshotInfo) error {
+	if !s.Snapshots[snapshot.VolumeSnapshotContentName] {
+		return errors.New("snapshot not found")
+	}
+
+	delete(s.Snapshots, snapshot.VolumeSnapshotContentName)
+	s.SnapshotsDeleted = append(s.SnapshotsDeleted, snapshot.VolumeSnapshotContentName)
+
+	return nil
+}
+
+func (s *FakeCSISnapshotter) SnapshotExists(snapshot *api.CSISnapshotInfo) (bool, error) {
+	return s.Snapshots[snapshot.VolumeSnapshotContentName], nil
+}
@@ -64,3 +64,18 @@ func (c *FakeDynamicClient) Get(name string, opts metav1.GetOptions) (*unstructu
 	args := c.Called(name, opts)
 	return args.Get(0).(*unstructured.Unstructured), args.Error(1)
 }
+
+func (c *FakeDynamicClient) Update(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	args := c.Called(obj)
+	return args.Get(0).(*unstructured.Unstructured), args.Error(1)
+}
+
+func (c *FakeDynamicClient) Apply(obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error) {
+	args := c.Called(obj, fieldManager, force)
+	return args.Get(0).(*unstructured.Unstructured), args.Error(1)
+}
+
+func (c *FakeDynamicClient) Delete(name string, opts *metav1.DeleteOptions) error {
+	args := c.Called(name, opts)
+	return args.Error(0)
+}
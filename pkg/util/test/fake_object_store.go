@@ -0,0 +1,226 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FakeObjectStore is an in-memory implementation of cloudprovider.ObjectStore. It records
+// the name of each method called on it, and can be programmed to return an error from any
+// method.
+type FakeObjectStore struct {
+	// Buckets maps bucket names to the objects in them, by key.
+	Buckets map[string]map[string][]byte
+
+	// Errors maps method names to the error they return. Methods that return
+	// an error don't modify the store.
+	Errors map[string]error
+
+	// Calls is the name of each method called, in order.
+	Calls []string
+
+	// Available, if non-nil, is the number of bytes AvailableBytes reports for
+	// every bucket; otherwise the store doesn't report its capacity.
+	Available *int64
+
+	lock sync.Mutex
+}
+
+// NewFakeObjectStore returns a FakeObjectStore containing the specified, empty buckets.
+func NewFakeObjectStore(buckets ...string) *FakeObjectStore {
+	s := &FakeObjectStore{
+		Buckets: make(map[string]map[string][]byte),
+		Errors:  make(map[string]error),
+	}
+	for _, bucket := range buckets {
+		s.Buckets[bucket] = make(map[string][]byte)
+	}
+	return s
+}
+
+// call records a call to method and returns the error programmed for it, if any.
+func (s *FakeObjectStore) call(method string) error {
+	s.Calls = append(s.Calls, method)
+	return s.Errors[method]
+}
+
+func (s *FakeObjectStore) bucket(bucket string) (map[string][]byte, error) {
+	objects, ok := s.Buckets[bucket]
+	if !ok {
+		return nil, errors.Errorf("bucket %q not found", bucket)
+	}
+	return objects, nil
+}
+
+func (s *FakeObjectStore) Init(config map[string]string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.call("Init")
+}
+
+func (s *FakeObjectStore) PutObject(bucket string, key string, body io.Reader) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("PutObject"); err != nil {
+		return err
+	}
+
+	objects, err := s.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	objects[key] = data
+
+	return nil
+}
+
+func (s *FakeObjectStore) GetObject(bucket string, key string) (io.ReadCloser, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("GetObject"); err != nil {
+		return nil, err
+	}
+
+	objects, err := s.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := objects[key]
+	if !ok {
+		return nil, errors.Errorf("object %q not found", key)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *FakeObjectStore) ListCommonPrefixes(bucket string, delimiter string) ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("ListCommonPrefixes"); err != nil {
+		return nil, err
+	}
+
+	objects, err := s.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes := make(map[string]struct{})
+	for key := range objects {
+		if i := strings.Index(key, delimiter); i >= 0 {
+			prefixes[key[:i]] = struct{}{}
+		}
+	}
+
+	var res []string
+	for prefix := range prefixes {
+		res = append(res, prefix)
+	}
+	sort.Strings(res)
+
+	return res, nil
+}
+
+func (s *FakeObjectStore) ListObjects(bucket, prefix string) ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("ListObjects"); err != nil {
+		return nil, err
+	}
+
+	objects, err := s.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for key := range objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+func (s *FakeObjectStore) DeleteObject(bucket string, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("DeleteObject"); err != nil {
+		return err
+	}
+
+	objects, err := s.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := objects[key]; !ok {
+		return errors.Errorf("object %q not found", key)
+	}
+	delete(objects, key)
+
+	return nil
+}
+
+func (s *FakeObjectStore) CreateSignedURL(bucket, key string, ttl time.Duration) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("CreateSignedURL"); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("https://%s.example.com/%s?ttl=%s", bucket, key, ttl), nil
+}
+
+func (s *FakeObjectStore) AvailableBytes(bucket string) (int64, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.call("AvailableBytes"); err != nil {
+		return 0, false, err
+	}
+
+	if s.Available == nil {
+		return 0, false, nil
+	}
+	return *s.Available, true, nil
+}
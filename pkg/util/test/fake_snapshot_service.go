@@ -18,7 +18,10 @@ package test
 
 import (
 	"errors"
+	"sort"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 
@@ -29,19 +32,53 @@ type FakeSnapshotService struct {
 	// SnapshotID->VolumeID
 	SnapshotsTaken sets.String
 
+	// SnapshotID -> tags
+	SnapshotTags map[string]map[string]string
+
 	// VolumeID -> (SnapshotID, Type, Iops)
 	SnapshottableVolumes map[string]api.VolumeBackupInfo
 
 	// VolumeBackupInfo -> VolumeID
 	RestorableVolumes map[api.VolumeBackupInfo]string
 
+	// VolumeID -> the tags it was created with
+	VolumeTags map[string]map[string]string
+
+	// SupportsFindingVolumes is whether FindVolumesWithTag finds volumes in
+	// VolumeTags, rather than returning false.
+	SupportsFindingVolumes bool
+
+	// VolumeID -> parent SnapshotID of the volume's next snapshot
+	ParentSnapshots map[string]string
+
+	// SnapshotGroupID is the ID of the consistency groups created by
+	// CreateSnapshotGroup. If empty, it doesn't support consistency groups.
+	SnapshotGroupID string
+
+	// GroupID -> the VolumeIDs snapshotted in the group
+	SnapshotGroupsTaken map[string][]string
+
+	// SupportsBulkDeletes is whether DeleteSnapshots deletes snapshots, rather
+	// than returning false.
+	SupportsBulkDeletes bool
+
+	// the SnapshotIDs deleted by each call to DeleteSnapshots
+	BulkDeletes [][]string
+
+	// SnapshotID -> when its retention ends, for snapshots that are retained
+	RetainedUntil map[string]time.Time
+
+	// PersistentVolume name -> VolumeID. PersistentVolumes not in it
+	// have VolumeID.
+	VolumeIDs map[string]string
+
 	VolumeID    string
 	VolumeIDSet string
 }
 
-func (s *FakeSnapshotService) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, error) {
+func (s *FakeSnapshotService) CreateSnapshot(volumeID, volumeAZ string, tags map[string]string) (string, string, error) {
 	if _, exists := s.SnapshottableVolumes[volumeID]; !exists {
-		return "", errors.New("snapshottable volume not found")
+		return "", "", errors.New("snapshottable volume not found")
 	}
 
 	if s.SnapshotsTaken == nil {
@@ -49,10 +86,15 @@ func (s *FakeSnapshotService) CreateSnapshot(volumeID, volumeAZ string, tags map
 	}
 	s.SnapshotsTaken.Insert(s.SnapshottableVolumes[volumeID].SnapshotID)
 
-	return s.SnapshottableVolumes[volumeID].SnapshotID, nil
+	if s.SnapshotTags == nil {
+		s.SnapshotTags = make(map[string]map[string]string)
+	}
+	s.SnapshotTags[s.SnapshottableVolumes[volumeID].SnapshotID] = tags
+
+	return s.SnapshottableVolumes[volumeID].SnapshotID, s.ParentSnapshots[volumeID], nil
 }
 
-func (s *FakeSnapshotService) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64) (string, error) {
+func (s *FakeSnapshotService) CreateVolumeFromSnapshot(snapshotID, volumeType, volumeAZ string, iops *int64, tags map[string]string) (string, error) {
 	key := api.VolumeBackupInfo{
 		SnapshotID:       snapshotID,
 		Type:             volumeType,
@@ -60,9 +102,30 @@ func (s *FakeSnapshotService) CreateVolumeFromSnapshot(snapshotID, volumeType, v
 		AvailabilityZone: volumeAZ,
 	}
 
+	if s.VolumeTags == nil {
+		s.VolumeTags = make(map[string]map[string]string)
+	}
+	s.VolumeTags[s.RestorableVolumes[key]] = tags
+
 	return s.RestorableVolumes[key], nil
 }
 
+func (s *FakeSnapshotService) FindVolumesWithTag(key, value string) ([]string, bool, error) {
+	if !s.SupportsFindingVolumes {
+		return nil, false, nil
+	}
+
+	var volumeIDs []string
+	for volumeID, tags := range s.VolumeTags {
+		if tagValue, ok := tags[key]; ok && tagValue == value {
+			volumeIDs = append(volumeIDs, volumeID)
+		}
+	}
+	sort.Strings(volumeIDs)
+
+	return volumeIDs, true, nil
+}
+
 func (s *FakeSnapshotService) DeleteSnapshot(snapshotID string) error {
 	if !s.SnapshotsTaken.Has(snapshotID) {
 		return errors.New("snapshot not found")
@@ -73,6 +136,68 @@ func (s *FakeSnapshotService) DeleteSnapshot(snapshotID string) error {
 	return nil
 }
 
+func (s *FakeSnapshotService) DeleteSnapshots(snapshotIDs []string) (bool, error) {
+	if !s.SupportsBulkDeletes {
+		return false, nil
+	}
+
+	for _, snapshotID := range snapshotIDs {
+		if !s.SnapshotsTaken.Has(snapshotID) {
+			return false, errors.New("snapshot not found")
+		}
+	}
+	s.SnapshotsTaken.Delete(snapshotIDs...)
+	s.BulkDeletes = append(s.BulkDeletes, snapshotIDs)
+
+	return true, nil
+}
+
+func (s *FakeSnapshotService) RetainedSnapshots(tags map[string]string) (map[string]time.Time, error) {
+	retained := make(map[string]time.Time)
+	for snapshotID, retainUntil := range s.RetainedUntil {
+		hasTags := true
+		for k, v := range tags {
+			if s.SnapshotTags[snapshotID][k] != v {
+				hasTags = false
+			}
+		}
+		if hasTags {
+			retained[snapshotID] = retainUntil
+		}
+	}
+
+	return retained, nil
+}
+
+func (s *FakeSnapshotService) SnapshotExists(snapshotID string) (bool, error) {
+	return s.SnapshotsTaken.Has(snapshotID), nil
+}
+
+func (s *FakeSnapshotService) CreateSnapshotGroup(volumes map[string]string, tags map[string]string) (string, map[string]string, error) {
+	if s.SnapshotGroupID == "" {
+		return "", nil, nil
+	}
+
+	snapshotIDs := make(map[string]string, len(volumes))
+	var volumeIDs []string
+	for volumeID, volumeAZ := range volumes {
+		snapshotID, _, err := s.CreateSnapshot(volumeID, volumeAZ, tags)
+		if err != nil {
+			return "", nil, err
+		}
+		snapshotIDs[volumeID] = snapshotID
+		volumeIDs = append(volumeIDs, volumeID)
+	}
+	sort.Strings(volumeIDs)
+
+	if s.SnapshotGroupsTaken == nil {
+		s.SnapshotGroupsTaken = make(map[string][]string)
+	}
+	s.SnapshotGroupsTaken[s.SnapshotGroupID] = volumeIDs
+
+	return s.SnapshotGroupID, snapshotIDs, nil
+}
+
 func (s *FakeSnapshotService) GetVolumeInfo(volumeID, volumeAZ string) (string, *int64, error) {
 	if volumeInfo, exists := s.SnapshottableVolumes[volumeID]; !exists {
 		return "", nil, errors.New("VolumeID not found")
@@ -82,6 +207,11 @@ func (s *FakeSnapshotService) GetVolumeInfo(volumeID, volumeAZ string) (string,
 }
 
 func (s *FakeSnapshotService) GetVolumeID(pv runtime.Unstructured) (string, error) {
+	if metadata, err := meta.Accessor(pv); err == nil {
+		if volumeID, found := s.VolumeIDs[metadata.GetName()]; found {
+			return volumeID, nil
+		}
+	}
 	return s.VolumeID, nil
 }
 
@@ -26,6 +26,34 @@ type ObjectStore struct {
 	mock.Mock
 }
 
+// AvailableBytes provides a mock function with given fields: bucket
+func (_m *ObjectStore) AvailableBytes(bucket string) (int64, bool, error) {
+	ret := _m.Called(bucket)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(string) int64); ok {
+		r0 = rf(bucket)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = rf(bucket)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(bucket)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // CreateSignedURL provides a mock function with given fields: bucket, key, ttl
 func (_m *ObjectStore) CreateSignedURL(bucket string, key string, ttl time.Duration) (string, error) {
 	ret := _m.Called(bucket, key, ttl)
@@ -57,6 +57,15 @@ func (b *TestBackup) WithLabel(key, value string) *TestBackup {
 	return b
 }
 
+func (b *TestBackup) WithAnnotation(key, value string) *TestBackup {
+	if b.Annotations == nil {
+		b.Annotations = make(map[string]string)
+	}
+	b.Annotations[key] = value
+
+	return b
+}
+
 func (b *TestBackup) WithPhase(phase v1.BackupPhase) *TestBackup {
 	b.Status.Phase = phase
 	return b
@@ -92,11 +101,26 @@ func (b *TestBackup) WithExpiration(expiration time.Time) *TestBackup {
 	return b
 }
 
+func (b *TestBackup) WithStartTimestamp(startTimestamp time.Time) *TestBackup {
+	b.Status.StartTimestamp = metav1.Time{Time: startTimestamp}
+	return b
+}
+
+func (b *TestBackup) WithCompletionTimestamp(completionTimestamp time.Time) *TestBackup {
+	b.Status.CompletionTimestamp = metav1.Time{Time: completionTimestamp}
+	return b
+}
+
 func (b *TestBackup) WithVersion(version int) *TestBackup {
 	b.Status.Version = version
 	return b
 }
 
+func (b *TestBackup) WithClusterID(id string) *TestBackup {
+	b.Status.ClusterID = id
+	return b
+}
+
 func (b *TestBackup) WithSnapshot(pv string, snapshot string) *TestBackup {
 	if b.Status.VolumeBackups == nil {
 		b.Status.VolumeBackups = make(map[string]*v1.VolumeBackupInfo)
@@ -105,6 +129,15 @@ func (b *TestBackup) WithSnapshot(pv string, snapshot string) *TestBackup {
 	return b
 }
 
+func (b *TestBackup) WithCondition(conditionType v1.BackupConditionType, status v1.ConditionStatus, lastTransitionTime time.Time) *TestBackup {
+	b.Status.Conditions = append(b.Status.Conditions, v1.BackupCondition{
+		Type:               conditionType,
+		Status:             status,
+		LastTransitionTime: metav1.Time{Time: lastTransitionTime},
+	})
+	return b
+}
+
 func (b *TestBackup) WithSnapshotVolumes(value bool) *TestBackup {
 	b.Spec.SnapshotVolumes = &value
 	return b
@@ -115,6 +148,39 @@ func (b *TestBackup) WithSnapshotVolumesPointer(value *bool) *TestBackup {
 	return b
 }
 
+func (b *TestBackup) WithMinItems(minItems int) *TestBackup {
+	b.Spec.MinItems = minItems
+	return b
+}
+
+func (b *TestBackup) WithModifiedSince(window time.Duration) *TestBackup {
+	b.Spec.ModifiedSince.Duration = window
+	return b
+}
+
+func (b *TestBackup) WithExcludedFields(resource string, fields ...string) *TestBackup {
+	if b.Spec.ExcludedFields == nil {
+		b.Spec.ExcludedFields = make(map[string][]string)
+	}
+	b.Spec.ExcludedFields[resource] = fields
+	return b
+}
+
+func (b *TestBackup) WithCompressionFormat(format string) *TestBackup {
+	b.Spec.CompressionFormat = format
+	return b
+}
+
+func (b *TestBackup) WithBaseBackup(name string) *TestBackup {
+	b.Spec.BaseBackup = name
+	return b
+}
+
+func (b *TestBackup) WithBackupChain(names ...string) *TestBackup {
+	b.Status.BackupChain = names
+	return b
+}
+
 func (b *TestBackup) WithDeletionTimestamp(time time.Time) *TestBackup {
 	b.DeletionTimestamp = &metav1.Time{Time: time}
 	return b
@@ -124,3 +190,8 @@ func (b *TestBackup) WithResourceVersion(version string) *TestBackup {
 	b.ResourceVersion = version
 	return b
 }
+
+func (b *TestBackup) WithCreationTimestamp(time time.Time) *TestBackup {
+	b.CreationTimestamp = metav1.Time{Time: time}
+	return b
+}
@@ -88,7 +88,100 @@ func (r *TestRestore) WithIncludedResource(resource string) *TestRestore {
 	return r
 }
 
+func (r *TestRestore) WithMergeStrategy(resource string, strategy api.MergeStrategy) *TestRestore {
+	if r.Spec.MergeStrategies == nil {
+		r.Spec.MergeStrategies = make(map[string]api.MergeStrategy)
+	}
+	r.Spec.MergeStrategies[resource] = strategy
+	return r
+}
+
+func (r *TestRestore) WithExistingNamespacePolicy(policy api.ExistingNamespacePolicy) *TestRestore {
+	r.Spec.ExistingNamespacePolicy = policy
+	return r
+}
+
+func (r *TestRestore) WithMissingCRDPolicy(policy api.MissingCRDPolicy) *TestRestore {
+	r.Spec.MissingCRDPolicy = policy
+	return r
+}
+
+func (r *TestRestore) WithApplyConflictPolicy(policy api.ApplyConflictPolicy) *TestRestore {
+	r.Spec.ApplyConflictPolicy = policy
+	return r
+}
+
+func (r *TestRestore) WithFieldManager(fieldManager string) *TestRestore {
+	r.Spec.FieldManager = fieldManager
+	return r
+}
+
+func (r *TestRestore) WithAddedLabel(key, value string) *TestRestore {
+	if r.Spec.AddedLabels == nil {
+		r.Spec.AddedLabels = make(map[string]string)
+	}
+	r.Spec.AddedLabels[key] = value
+	return r
+}
+
+func (r *TestRestore) WithAddedAnnotation(key, value string) *TestRestore {
+	if r.Spec.AddedAnnotations == nil {
+		r.Spec.AddedAnnotations = make(map[string]string)
+	}
+	r.Spec.AddedAnnotations[key] = value
+	return r
+}
+
+func (r *TestRestore) WithApplyMethod(method api.RestoreApplyMethod) *TestRestore {
+	r.Spec.ApplyMethod = method
+	return r
+}
+
+func (r *TestRestore) WithContainerResourcesFactor(factor float64) *TestRestore {
+	r.Spec.ContainerResourcesFactor = &factor
+	return r
+}
+
+func (r *TestRestore) WithImageRegistryMapping(src, dst string) *TestRestore {
+	if r.Spec.ImageRegistryMapping == nil {
+		r.Spec.ImageRegistryMapping = make(map[string]string)
+	}
+	r.Spec.ImageRegistryMapping[src] = dst
+	return r
+}
+
+func (r *TestRestore) WithResourceRename(key, name string) *TestRestore {
+	if r.Spec.ResourceRenames == nil {
+		r.Spec.ResourceRenames = make(map[string]string)
+	}
+	r.Spec.ResourceRenames[key] = name
+	return r
+}
+
+func (r *TestRestore) WithNamespaceSelector(selector *metav1.LabelSelector) *TestRestore {
+	r.Spec.NamespaceSelector = selector
+	return r
+}
+
+func (r *TestRestore) WithBatchSize(size int) *TestRestore {
+	r.Spec.BatchSize = size
+	return r
+}
+
+func (r *TestRestore) WithAllowClusterMismatch(value bool) *TestRestore {
+	r.Spec.AllowClusterMismatch = value
+	return r
+}
+
 func (r *TestRestore) WithExcludedResource(resource string) *TestRestore {
 	r.Spec.ExcludedResources = append(r.Spec.ExcludedResources, resource)
 	return r
 }
+
+func (r *TestRestore) WithRestoredVolume(pvName, volumeID string) *TestRestore {
+	if r.Status.RestoredVolumes == nil {
+		r.Status.RestoredVolumes = make(map[string]string)
+	}
+	r.Status.RestoredVolumes[pvName] = volumeID
+	return r
+}
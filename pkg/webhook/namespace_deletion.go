@@ -0,0 +1,231 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	arkv1client "github.com/heptio/ark/pkg/generated/clientset/versioned/typed/ark/v1"
+	listers "github.com/heptio/ark/pkg/generated/listers/ark/v1"
+)
+
+// NamespaceDeletionPath is the path the namespace deletion webhook is served on.
+const NamespaceDeletionPath = "/namespace-deletion"
+
+// namespaceDeletionBackupMaxAge is how long after it finishes a backup of a namespace
+// allows the namespace to be deleted. Deleting the namespace later takes a new backup.
+const namespaceDeletionBackupMaxAge = time.Hour
+
+// admissionReview, admissionRequest, and admissionResponse are the parts of the
+// admission.k8s.io/v1beta1 AdmissionReview API that the webhook uses.
+type admissionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *admissionRequest  `json:"request,omitempty"`
+	Response        *admissionResponse `json:"response,omitempty"`
+}
+
+type admissionRequest struct {
+	UID       types.UID               `json:"uid"`
+	Kind      metav1.GroupVersionKind `json:"kind"`
+	Name      string                  `json:"name"`
+	Operation string                  `json:"operation"`
+}
+
+type admissionResponse struct {
+	UID     types.UID      `json:"uid"`
+	Allowed bool           `json:"allowed"`
+	Result  *metav1.Status `json:"status,omitempty"`
+}
+
+// namespaceDeletionHandler is a validating admission webhook that denies the deletion of
+// namespaces annotated with NamespaceBackupBeforeDeleteAnnotation until they're backed up,
+// so that the backup has all of their contents.
+type namespaceDeletionHandler struct {
+	logger         logrus.FieldLogger
+	namespace      string
+	namespaceStore cache.Store
+	backupLister   listers.BackupLister
+	backupClient   arkv1client.BackupsGetter
+	template       api.BackupSpec
+	clock          clock.Clock
+}
+
+// NewNamespaceDeletionHandler constructs a webhook that backs up the annotated namespaces
+// in namespaceStore with template, into namespace, when they're deleted, denying the
+// deletion until the backup finishes.
+func NewNamespaceDeletionHandler(
+	logger logrus.FieldLogger,
+	namespace string,
+	namespaceStore cache.Store,
+	backupLister listers.BackupLister,
+	backupClient arkv1client.BackupsGetter,
+	template api.BackupSpec,
+) http.Handler {
+	return &namespaceDeletionHandler{
+		logger:         logger.WithField("webhook", "namespace-deletion"),
+		namespace:      namespace,
+		namespaceStore: namespaceStore,
+		backupLister:   backupLister,
+		backupClient:   backupClient,
+		template:       template,
+		clock:          &clock.RealClock{},
+	}
+}
+
+func (h *namespaceDeletionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	review := new(admissionReview)
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil || review.Request == nil {
+		http.Error(w, "request body must be an AdmissionReview", http.StatusBadRequest)
+		return
+	}
+
+	response := &admissionResponse{UID: review.Request.UID, Allowed: true}
+	if review.Request.Kind.Kind == "Namespace" && review.Request.Operation == "DELETE" {
+		log := h.logger.WithField("namespace", review.Request.Name)
+
+		allowed, message, err := h.allowDeletion(review.Request.Name, log)
+		if err != nil {
+			// the namespace is kept when its backup can't be checked
+			log.WithError(err).Error("Error checking backup of namespace")
+			allowed, message = false, fmt.Sprintf("error checking backup of namespace: %v", err)
+		}
+
+		response.Allowed = allowed
+		if !allowed {
+			response.Result = &metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonForbidden, Code: http.StatusForbidden, Message: message}
+		}
+	}
+
+	review.Request = nil
+	review.Response = response
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		h.logger.WithError(err).Error("Error writing admission response")
+	}
+}
+
+// allowDeletion returns whether the namespace named name can be deleted and, if not, why.
+// Annotated namespaces can be deleted once a backup of them taken when they were deleted
+// completes, partially fails, or is skipped, and the backup finished recently. Otherwise,
+// a backup is taken, or the one in progress is waited for.
+func (h *namespaceDeletionHandler) allowDeletion(name string, log logrus.FieldLogger) (bool, string, error) {
+	obj, exists, err := h.namespaceStore.GetByKey(name)
+	if err != nil {
+		return false, "", errors.Wrap(err, "error getting namespace")
+	}
+	if !exists {
+		return true, "", nil
+	}
+	namespace := obj.(*v1.Namespace)
+
+	if namespace.Annotations[api.NamespaceBackupBeforeDeleteAnnotation] != "true" || namespace.DeletionTimestamp != nil {
+		return true, "", nil
+	}
+
+	backup, err := h.latestBackup(namespace)
+	if err != nil {
+		return false, "", err
+	}
+
+	if backup != nil {
+		switch backup.Status.Phase {
+		case api.BackupPhaseCompleted, api.BackupPhasePartiallyFailed, api.BackupPhaseSkipped:
+			if !h.isStale(backup) {
+				log.WithField("backup", backup.Namespace+"/"+backup.Name).WithField("phase", backup.Status.Phase).Info("Namespace was backed up, allowing it to be deleted")
+				return true, "", nil
+			}
+		case api.BackupPhaseFailed, api.BackupPhaseFailedValidation:
+			if !h.isStale(backup) {
+				return false, fmt.Sprintf("backup %s/%s of the namespace failed; remove its %s annotation to delete it without a backup", backup.Namespace, backup.Name, api.NamespaceBackupBeforeDeleteAnnotation), nil
+			}
+		default:
+			return false, fmt.Sprintf("the namespace is being backed up by %s/%s; delete it again once the backup completes", backup.Namespace, backup.Name), nil
+		}
+	}
+
+	backup = h.newBackup(namespace)
+
+	log.WithField("backup", backup.Namespace+"/"+backup.Name).Info("Backing up namespace before it's deleted")
+	if _, err := h.backupClient.Backups(h.namespace).Create(backup); err != nil && !apierrors.IsAlreadyExists(err) {
+		return false, "", errors.Wrap(err, "error creating backup")
+	}
+
+	return false, fmt.Sprintf("the namespace is being backed up by %s/%s; delete it again once the backup completes", backup.Namespace, backup.Name), nil
+}
+
+// latestBackup returns the most recently created backup taken of namespace when it was
+// deleted, or nil if there isn't one.
+func (h *namespaceDeletionHandler) latestBackup(namespace *v1.Namespace) (*api.Backup, error) {
+	backups, err := h.backupLister.Backups(h.namespace).List(labels.SelectorFromSet(labels.Set{api.DeletedNamespaceLabel: namespace.Name}))
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing backups")
+	}
+
+	var latest *api.Backup
+	for _, backup := range backups {
+		// backups of an earlier namespace with the same name don't count
+		if backup.Annotations[api.DeletedNamespaceUIDAnnotation] != string(namespace.UID) {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&backup.CreationTimestamp) {
+			latest = backup
+		}
+	}
+
+	return latest, nil
+}
+
+// isStale returns whether backup, which finished, finished too long ago to allow its
+// namespace to be deleted.
+func (h *namespaceDeletionHandler) isStale(backup *api.Backup) bool {
+	finished := backup.Status.CompletionTimestamp
+	if finished.IsZero() {
+		finished = backup.CreationTimestamp
+	}
+	return h.clock.Since(finished.Time) > namespaceDeletionBackupMaxAge
+}
+
+func (h *namespaceDeletionHandler) newBackup(namespace *v1.Namespace) *api.Backup {
+	backup := &api.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   h.namespace,
+			Name:        fmt.Sprintf("%s-before-delete-%s", namespace.Name, h.clock.Now().UTC().Format("20060102150405")),
+			Labels:      map[string]string{api.DeletedNamespaceLabel: namespace.Name},
+			Annotations: map[string]string{api.DeletedNamespaceUIDAnnotation: string(namespace.UID)},
+		},
+		Spec: *h.template.DeepCopy(),
+	}
+	backup.Spec.IncludedNamespaces = []string{namespace.Name}
+
+	return backup
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2018 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/heptio/ark/pkg/apis/ark/v1"
+	"github.com/heptio/ark/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/ark/pkg/generated/informers/externalversions"
+	arktest "github.com/heptio/ark/pkg/util/test"
+)
+
+func TestNamespaceDeletionHandler(t *testing.T) {
+	template := api.BackupSpec{
+		TTL:                metav1.Duration{Duration: 72 * time.Hour},
+		IncludedNamespaces: []string{"ignored"},
+	}
+	now := time.Date(2018, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	namespace := func(annotated bool) *v1.Namespace {
+		ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", UID: "uid-1"}}
+		if annotated {
+			ns.Annotations = map[string]string{api.NamespaceBackupBeforeDeleteAnnotation: "true"}
+		}
+		return ns
+	}
+
+	backup := func(uid string, phase api.BackupPhase, completed time.Time) *api.Backup {
+		backup := arktest.NewTestBackup().
+			WithNamespace(api.DefaultNamespace).
+			WithName("team-a-before-delete-20180601120000").
+			WithLabel(api.DeletedNamespaceLabel, "team-a").
+			WithAnnotation(api.DeletedNamespaceUIDAnnotation, uid).
+			WithPhase(phase).
+			Backup
+		backup.CreationTimestamp = metav1.NewTime(now.Add(-30 * time.Minute))
+		backup.Status.CompletionTimestamp = metav1.NewTime(completed)
+		return backup
+	}
+
+	expectedBackup := &api.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   api.DefaultNamespace,
+			Name:        "team-a-before-delete-20180601123000",
+			Labels:      map[string]string{api.DeletedNamespaceLabel: "team-a"},
+			Annotations: map[string]string{api.DeletedNamespaceUIDAnnotation: "uid-1"},
+		},
+		Spec: api.BackupSpec{
+			TTL:                metav1.Duration{Duration: 72 * time.Hour},
+			IncludedNamespaces: []string{"team-a"},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		namespace       *v1.Namespace
+		backup          *api.Backup
+		operation       string
+		expectedAllowed bool
+		expectedCreate  *api.Backup
+	}{
+		{
+			name:            "namespace without the annotation can be deleted",
+			namespace:       namespace(false),
+			operation:       "DELETE",
+			expectedAllowed: true,
+		},
+		{
+			name:            "updates of annotated namespaces are allowed",
+			namespace:       namespace(true),
+			operation:       "UPDATE",
+			expectedAllowed: true,
+		},
+		{
+			name:           "deleting an annotated namespace without a backup backs it up",
+			namespace:      namespace(true),
+			operation:      "DELETE",
+			expectedCreate: expectedBackup,
+		},
+		{
+			name:      "deletion waits for the namespace's backup",
+			namespace: namespace(true),
+			backup:    backup("uid-1", api.BackupPhaseInProgress, time.Time{}),
+			operation: "DELETE",
+		},
+		{
+			name:            "namespace can be deleted once its backup completes",
+			namespace:       namespace(true),
+			backup:          backup("uid-1", api.BackupPhaseCompleted, now.Add(-time.Minute)),
+			operation:       "DELETE",
+			expectedAllowed: true,
+		},
+		{
+			name:            "namespace can be deleted once its backup partially fails",
+			namespace:       namespace(true),
+			backup:          backup("uid-1", api.BackupPhasePartiallyFailed, now.Add(-time.Minute)),
+			operation:       "DELETE",
+			expectedAllowed: true,
+		},
+		{
+			name:            "namespace can be deleted once its backup is skipped",
+			namespace:       namespace(true),
+			backup:          backup("uid-1", api.BackupPhaseSkipped, now.Add(-time.Minute)),
+			operation:       "DELETE",
+			expectedAllowed: true,
+		},
+		{
+			name:      "namespace isn't deleted when its backup fails",
+			namespace: namespace(true),
+			backup:    backup("uid-1", api.BackupPhaseFailed, now.Add(-time.Minute)),
+			operation: "DELETE",
+		},
+		{
+			name:           "an old backup doesn't allow deletion",
+			namespace:      namespace(true),
+			backup:         backup("uid-1", api.BackupPhaseCompleted, now.Add(-2*time.Hour)),
+			operation:      "DELETE",
+			expectedCreate: expectedBackup,
+		},
+		{
+			name:           "a backup of an earlier namespace with the same name doesn't allow deletion",
+			namespace:      namespace(true),
+			backup:         backup("uid-0", api.BackupPhaseCompleted, now.Add(-time.Minute)),
+			operation:      "DELETE",
+			expectedCreate: expectedBackup,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				client          = fake.NewSimpleClientset()
+				sharedInformers = informers.NewSharedInformerFactory(client, 0)
+				namespaceStore  = cache.NewStore(cache.MetaNamespaceKeyFunc)
+			)
+
+			h := NewNamespaceDeletionHandler(
+				arktest.NewLogger(),
+				api.DefaultNamespace,
+				namespaceStore,
+				sharedInformers.Ark().V1().Backups().Lister(),
+				client.ArkV1(),
+				template,
+			).(*namespaceDeletionHandler)
+			h.clock = clock.NewFakeClock(now)
+
+			require.NoError(t, namespaceStore.Add(test.namespace))
+			if test.backup != nil {
+				require.NoError(t, sharedInformers.Ark().V1().Backups().Informer().GetStore().Add(test.backup))
+			}
+
+			body, err := json.Marshal(&admissionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1beta1", Kind: "AdmissionReview"},
+				Request: &admissionRequest{
+					UID:       "request-1",
+					Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+					Name:      "team-a",
+					Operation: test.operation,
+				},
+			})
+			require.NoError(t, err)
+
+			res := httptest.NewRecorder()
+			h.ServeHTTP(res, httptest.NewRequest("POST", NamespaceDeletionPath, bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, res.Code)
+
+			review := new(admissionReview)
+			require.NoError(t, json.NewDecoder(res.Body).Decode(review))
+			require.NotNil(t, review.Response)
+			assert.Equal(t, "AdmissionReview", review.Kind)
+			assert.EqualValues(t, "request-1", review.Response.UID)
+			assert.Equal(t, test.expectedAllowed, review.Response.Allowed)
+			if !test.expectedAllowed {
+				require.NotNil(t, review.Response.Result)
+				assert.NotEmpty(t, review.Response.Result.Message)
+			}
+
+			if test.expectedCreate != nil {
+				require.Len(t, client.Actions(), 1)
+				assert.Equal(t, core.NewCreateAction(api.SchemeGroupVersion.WithResource("backups"), api.DefaultNamespace, test.expectedCreate), client.Actions()[0])
+			} else {
+				assert.Len(t, client.Actions(), 0)
+			}
+		})
+	}
+}
+
+func TestNamespaceDeletionHandlerRejectsInvalidRequests(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	h := NewNamespaceDeletionHandler(
+		arktest.NewLogger(),
+		api.DefaultNamespace,
+		cache.NewStore(cache.MetaNamespaceKeyFunc),
+		informers.NewSharedInformerFactory(client, 0).Ark().V1().Backups().Lister(),
+		client.ArkV1(),
+		api.BackupSpec{},
+	)
+
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, httptest.NewRequest("POST", NamespaceDeletionPath, bytes.NewReader([]byte("{}"))))
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}